@@ -0,0 +1,197 @@
+// Package client is a typed Go client for the Byte Board API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Client configuration
+type Config struct {
+	// Base URL of the service, e.g. http://localhost:8080
+	BaseURL string
+	// JWT to send with requests (can also be set later with SetToken or Login)
+	Token string
+	// API key to send in X-API-Key instead, for scripts and services (used while no JWT is set)
+	APIKey string
+	// HTTP client to use (defaults to a client with a 15 second timeout)
+	HTTPClient *http.Client
+	// How many times to retry idempotent requests that fail with a network error or 5xx (default 2)
+	MaxRetries int
+	// Delay before the first retry, doubled on each attempt (default 200ms)
+	RetryBackoff time.Duration
+}
+
+// Calls the Byte Board API
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+	apiKey       string
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Error returned by the API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("byteboard: %d %s", e.StatusCode, e.Message)
+}
+
+// Creates a new API client
+func New(config Config) *Client {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	maxRetries := config.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 2
+	}
+	retryBackoff := config.RetryBackoff
+	if retryBackoff == 0 {
+		retryBackoff = 200 * time.Millisecond
+	}
+
+	return &Client{
+		baseURL:      strings.TrimRight(config.BaseURL, "/"),
+		httpClient:   httpClient,
+		maxRetries:   maxRetries,
+		retryBackoff: retryBackoff,
+		apiKey:       config.APIKey,
+		token:        config.Token,
+	}
+}
+
+// Sets the JWT sent with every request
+func (c *Client) SetToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.token = token
+}
+
+// Returns the JWT currently used by the client
+func (c *Client) Token() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// Sends a request and decodes the JSON response into out (if not nil)
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+	}
+
+	// Only retry requests that are safe to repeat
+	attempts := 1
+	if method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete {
+		attempts += c.maxRetries
+	}
+
+	var lastErr error
+	backoff := c.retryBackoff
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		retry, err := c.send(ctx, method, path, payload, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+
+	return lastErr
+}
+
+// Performs a single attempt of a request, reporting whether it may be retried
+func (c *Client) send(ctx context.Context, method, path string, payload []byte, out interface{}) (bool, error) {
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.Token(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Don't retry once the caller has given up
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return true, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode >= 500, decodeError(resp)
+	}
+
+	if out == nil {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return false, nil
+}
+
+// Builds an APIError from an error response
+func decodeError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+		message = errResp.Error
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+
+	return &APIError{StatusCode: resp.StatusCode, Message: message}
+}
@@ -0,0 +1,427 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// #region Auth
+
+// Create a new account
+func (c *Client) Register(ctx context.Context, req RegisterRequest) (*RegisterResponse, error) {
+	var resp RegisterResponse
+	if err := c.do(ctx, http.MethodPost, "/api/register", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Log in and store the returned token on the client
+func (c *Client) Login(ctx context.Context, username, password string) (*AuthResponse, error) {
+	body := map[string]string{"username": username, "password": password}
+
+	var resp AuthResponse
+	if err := c.do(ctx, http.MethodPost, "/api/login", body, &resp); err != nil {
+		return nil, err
+	}
+
+	c.SetToken(resp.Token)
+	return &resp, nil
+}
+
+// Get the authenticated user and their profile
+func (c *Client) Me(ctx context.Context) (*CurrentUser, error) {
+	var resp CurrentUser
+	if err := c.do(ctx, http.MethodGet, "/api/auth/me", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Change the authenticated user's password. Older tokens stop working unless keepOtherSessions is set,
+// so the fresh token from the response is stored on the client.
+func (c *Client) ChangePassword(ctx context.Context, currentPassword, newPassword string, keepOtherSessions bool) (*AuthResponse, error) {
+	body := map[string]interface{}{
+		"current_password":    currentPassword,
+		"new_password":        newPassword,
+		"keep_other_sessions": keepOtherSessions,
+	}
+
+	var resp AuthResponse
+	if err := c.do(ctx, http.MethodPut, "/api/auth/me/password", body, &resp); err != nil {
+		return nil, err
+	}
+
+	c.SetToken(resp.Token)
+	return &resp, nil
+}
+
+// Ask for a password reset email for the accounts using an address (succeeds whether or not any do)
+func (c *Client) ForgotPassword(ctx context.Context, email string) error {
+	body := map[string]string{"email": email}
+	return c.do(ctx, http.MethodPost, "/api/auth/forgot-password", body, nil)
+}
+
+// Set a new password with the token from a reset email. No token is returned; log in afterwards.
+func (c *Client) ResetPassword(ctx context.Context, token, newPassword string) error {
+	body := map[string]string{"token": token, "new_password": newPassword}
+	return c.do(ctx, http.MethodPost, "/api/auth/reset-password", body, nil)
+}
+
+// List the authenticated user's API keys, newest first
+func (c *Client) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var resp []APIKey
+	if err := c.do(ctx, http.MethodGet, "/api/auth/apikeys", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Generate an API key; the returned Key is only ever shown here
+func (c *Client) CreateAPIKey(ctx context.Context, name string) (*CreatedAPIKey, error) {
+	body := map[string]string{"name": name}
+
+	var resp CreatedAPIKey
+	if err := c.do(ctx, http.MethodPost, "/api/auth/apikeys", body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Revoke one of the authenticated user's API keys
+func (c *Client) RevokeAPIKey(ctx context.Context, keyId int64) error {
+	return c.do(ctx, http.MethodDelete, "/api/auth/apikeys/"+strconv.FormatInt(keyId, 10), nil, nil)
+}
+
+// List the devices the authenticated user is signed in on, most recently used first
+func (c *Client) ListSessions(ctx context.Context) ([]Session, error) {
+	var resp []Session
+	if err := c.do(ctx, http.MethodGet, "/api/auth/sessions", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Sign the authenticated user out of one of their sessions
+func (c *Client) RevokeSession(ctx context.Context, sessionId int64) error {
+	return c.do(ctx, http.MethodDelete, "/api/auth/sessions/"+strconv.FormatInt(sessionId, 10), nil, nil)
+}
+
+// #endregion
+
+// #region Posts
+
+// Get all posts
+func (c *Client) ListPosts(ctx context.Context) ([]Post, error) {
+	var posts []Post
+	if err := c.do(ctx, http.MethodGet, "/api/posts", nil, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// Get a post by ID
+func (c *Client) GetPost(ctx context.Context, postId int64) (*Post, error) {
+	var post Post
+	if err := c.do(ctx, http.MethodGet, "/api/posts/"+strconv.FormatInt(postId, 10), nil, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// Get a post with its first comments (oldest first) and their reactions
+func (c *Client) GetPostWithComments(ctx context.Context, postId int64) (*PostWithComments, error) {
+	var post PostWithComments
+	if err := c.do(ctx, http.MethodGet, "/api/posts/"+strconv.FormatInt(postId, 10)+"?expand=comments", nil, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// Get a post machine-translated into a language (a BCP 47 tag such as de or pt-BR)
+func (c *Client) GetPostTranslation(ctx context.Context, postId int64, lang string) (*PostTranslation, error) {
+	var translation PostTranslation
+	path := "/api/posts/" + strconv.FormatInt(postId, 10) + "/translation?lang=" + url.QueryEscape(lang)
+	if err := c.do(ctx, http.MethodGet, path, nil, &translation); err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+// Get all posts made by a user
+func (c *Client) ListPostsByUser(ctx context.Context, userId int64) ([]Post, error) {
+	var posts []Post
+	if err := c.do(ctx, http.MethodGet, "/api/posts/user/"+strconv.FormatInt(userId, 10), nil, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// Create a post as the authenticated user
+func (c *Client) CreatePost(ctx context.Context, title, content string) (*Post, error) {
+	body := map[string]string{"title": title, "content": content}
+
+	var post Post
+	if err := c.do(ctx, http.MethodPost, "/api/posts", body, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// Update one of the authenticated user's posts
+func (c *Client) UpdatePost(ctx context.Context, postId int64, title, content string) (*Post, error) {
+	body := map[string]string{"title": title, "content": content}
+
+	var post Post
+	if err := c.do(ctx, http.MethodPut, "/api/posts/"+strconv.FormatInt(postId, 10), body, &post); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// Delete a post
+func (c *Client) DeletePost(ctx context.Context, postId int64) error {
+	return c.do(ctx, http.MethodDelete, "/api/posts/"+strconv.FormatInt(postId, 10), nil, nil)
+}
+
+// #endregion
+
+// #region Comments
+
+// Get all comments
+func (c *Client) ListComments(ctx context.Context) ([]Comment, error) {
+	var comments []Comment
+	if err := c.do(ctx, http.MethodGet, "/api/comments", nil, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// Get all comments on a post
+func (c *Client) ListCommentsOnPost(ctx context.Context, postId int64) ([]Comment, error) {
+	var comments []Comment
+	if err := c.do(ctx, http.MethodGet, "/api/posts/"+strconv.FormatInt(postId, 10)+"/comments", nil, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// Get a comment by ID
+func (c *Client) GetComment(ctx context.Context, commentId int64) (*Comment, error) {
+	var comment Comment
+	if err := c.do(ctx, http.MethodGet, "/api/comments/"+strconv.FormatInt(commentId, 10), nil, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// Comment on a post as the authenticated user
+func (c *Client) CreateComment(ctx context.Context, postId int64, content string) (*Comment, error) {
+	body := map[string]string{"content": content}
+
+	var comment Comment
+	if err := c.do(ctx, http.MethodPost, "/api/posts/"+strconv.FormatInt(postId, 10)+"/comments", body, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// Update one of the authenticated user's comments
+func (c *Client) UpdateComment(ctx context.Context, commentId int64, content string) (*Comment, error) {
+	body := map[string]string{"content": content}
+
+	var comment Comment
+	if err := c.do(ctx, http.MethodPut, "/api/comments/"+strconv.FormatInt(commentId, 10), body, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// Delete a comment
+func (c *Client) DeleteComment(ctx context.Context, commentId int64) error {
+	return c.do(ctx, http.MethodDelete, "/api/comments/"+strconv.FormatInt(commentId, 10), nil, nil)
+}
+
+// React to a comment (like, love, laugh, insightful or confused)
+func (c *Client) ReactToComment(ctx context.Context, commentId int64, reaction string) (*CommentReactions, error) {
+	var reactions CommentReactions
+	path := "/api/comments/" + strconv.FormatInt(commentId, 10) + "/reactions/" + url.PathEscape(reaction)
+	if err := c.do(ctx, http.MethodPut, path, nil, &reactions); err != nil {
+		return nil, err
+	}
+	return &reactions, nil
+}
+
+// Take back a reaction to a comment
+func (c *Client) UnreactToComment(ctx context.Context, commentId int64, reaction string) (*CommentReactions, error) {
+	var reactions CommentReactions
+	path := "/api/comments/" + strconv.FormatInt(commentId, 10) + "/reactions/" + url.PathEscape(reaction)
+	if err := c.do(ctx, http.MethodDelete, path, nil, &reactions); err != nil {
+		return nil, err
+	}
+	return &reactions, nil
+}
+
+// #endregion
+
+// #region Boards and subscriptions
+
+// Get the boards posts can be filed under
+func (c *Client) ListBoards(ctx context.Context) ([]Board, error) {
+	var boards []Board
+	if err := c.do(ctx, http.MethodGet, "/api/boards", nil, &boards); err != nil {
+		return nil, err
+	}
+	return boards, nil
+}
+
+// Get the boards and tags the client subscribes to
+func (c *Client) Subscriptions(ctx context.Context) (*Subscriptions, error) {
+	var subscriptions Subscriptions
+	if err := c.do(ctx, http.MethodGet, "/api/subscriptions", nil, &subscriptions); err != nil {
+		return nil, err
+	}
+	return &subscriptions, nil
+}
+
+// Subscribe to a board's posts
+func (c *Client) SubscribeToBoard(ctx context.Context, slug string) (*Subscriptions, error) {
+	return c.setSubscription(ctx, http.MethodPut, "/api/subscriptions/boards/"+url.PathEscape(slug))
+}
+
+// Unsubscribe from a board
+func (c *Client) UnsubscribeFromBoard(ctx context.Context, slug string) (*Subscriptions, error) {
+	return c.setSubscription(ctx, http.MethodDelete, "/api/subscriptions/boards/"+url.PathEscape(slug))
+}
+
+// Subscribe to the posts carrying a tag
+func (c *Client) SubscribeToTag(ctx context.Context, tag string) (*Subscriptions, error) {
+	return c.setSubscription(ctx, http.MethodPut, "/api/subscriptions/tags/"+url.PathEscape(tag))
+}
+
+// Unsubscribe from a tag
+func (c *Client) UnsubscribeFromTag(ctx context.Context, tag string) (*Subscriptions, error) {
+	return c.setSubscription(ctx, http.MethodDelete, "/api/subscriptions/tags/"+url.PathEscape(tag))
+}
+
+func (c *Client) setSubscription(ctx context.Context, method, path string) (*Subscriptions, error) {
+	var subscriptions Subscriptions
+	if err := c.do(ctx, method, path, nil, &subscriptions); err != nil {
+		return nil, err
+	}
+	return &subscriptions, nil
+}
+
+// Get the newest posts on the boards and with the tags the client subscribes to
+func (c *Client) SubscriptionFeed(ctx context.Context) ([]Post, error) {
+	var posts []Post
+	if err := c.do(ctx, http.MethodGet, "/api/feed?scope=subscriptions", nil, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// #endregion
+
+// #region Profiles
+
+// Get all profiles
+func (c *Client) ListProfiles(ctx context.Context) ([]Profile, error) {
+	var profiles []Profile
+	if err := c.do(ctx, http.MethodGet, "/api/profiles", nil, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// Get a user's profile
+func (c *Client) GetProfile(ctx context.Context, userId int64) (*Profile, error) {
+	var profile Profile
+	if err := c.do(ctx, http.MethodGet, "/api/profiles/"+strconv.FormatInt(userId, 10), nil, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Update the authenticated user's profile
+func (c *Client) UpdateProfile(ctx context.Context, userId int64, update ProfileUpdate) (*Profile, error) {
+	var profile Profile
+	if err := c.do(ctx, http.MethodPut, "/api/profiles/"+strconv.FormatInt(userId, 10), update, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Request an email change for the authenticated user (confirmed via emailed link)
+func (c *Client) RequestEmailChange(ctx context.Context, email string) error {
+	body := map[string]string{"email": email}
+	return c.do(ctx, http.MethodPut, "/api/profiles/me/email", body, nil)
+}
+
+// Suggest users whose username or display name starts with prefix (for @mention autocompletes)
+func (c *Client) SuggestUsers(ctx context.Context, prefix string) ([]Author, error) {
+	var authors []Author
+	if err := c.do(ctx, http.MethodGet, "/api/users/suggest?q="+url.QueryEscape(prefix), nil, &authors); err != nil {
+		return nil, err
+	}
+	return authors, nil
+}
+
+// Suggest posts, tags and users matching what's been typed in a search box
+func (c *Client) SuggestSearch(ctx context.Context, q string) (*SearchSuggestions, error) {
+	var suggestions SearchSuggestions
+	if err := c.do(ctx, http.MethodGet, "/api/search/suggest?q="+url.QueryEscape(q), nil, &suggestions); err != nil {
+		return nil, err
+	}
+	return &suggestions, nil
+}
+
+// #endregion
+
+// #region Admin
+
+// Get all users (admin only)
+func (c *Client) ListUsers(ctx context.Context) ([]User, error) {
+	var users []User
+	if err := c.do(ctx, http.MethodGet, "/api/admin/users", nil, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Get a user by ID (admin only)
+func (c *Client) GetUser(ctx context.Context, userId int64) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, "/api/admin/users/"+strconv.FormatInt(userId, 10), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Get a user by username (admin only)
+func (c *Client) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, "/api/admin/users/username/"+url.PathEscape(username), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Change a user's role (admin only)
+func (c *Client) SetUserRole(ctx context.Context, userId int64, role string) (*User, error) {
+	var user User
+	body := map[string]string{"role": role}
+	if err := c.do(ctx, http.MethodPut, "/api/admin/users/"+strconv.FormatInt(userId, 10)+"/role", body, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Delete a user account (own account, or any account with the users:delete permission)
+func (c *Client) DeleteUser(ctx context.Context, userId int64) error {
+	return c.do(ctx, http.MethodDelete, "/api/users/"+strconv.FormatInt(userId, 10), nil, nil)
+}
+
+// #endregion
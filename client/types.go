@@ -0,0 +1,237 @@
+package client
+
+import "time"
+
+// Who wrote a post or comment
+type Author struct {
+	UserId      int64  `json:"user_id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+type PostSuggestion struct {
+	PostId int64  `json:"post_id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+type TagSuggestion struct {
+	Name    string `json:"name"`
+	Members int    `json:"members"`
+}
+
+// Quick results for a search box
+type SearchSuggestions struct {
+	Posts []PostSuggestion `json:"posts"`
+	Tags  []TagSuggestion  `json:"tags"`
+	Users []Author         `json:"users"`
+	// Some groups took too long and were left empty
+	Partial bool `json:"partial"`
+}
+
+type Comment struct {
+	CommentId  int64     `json:"comment_id"`
+	UserId     int64     `json:"user_id"`
+	PostId     int64     `json:"post_id"`
+	Content    string    `json:"content"`
+	Author     string    `json:"author"`
+	AuthorInfo *Author   `json:"author_info,omitempty"`
+	DatePosted time.Time `json:"date_posted"`
+	// Only sent with a post's expanded comments (GetPostWithComments)
+	Reactions *ReactionSummary `json:"reactions,omitempty"`
+}
+
+// The reactions on a comment: how many members left each, and which the client left
+type ReactionSummary struct {
+	Counts map[string]int `json:"counts"`
+	Mine   []string       `json:"mine"`
+}
+
+// A comment's reactions after reacting or taking one back
+type CommentReactions struct {
+	CommentId int64 `json:"comment_id"`
+	ReactionSummary
+}
+
+type Post struct {
+	PostId         int64     `json:"post_id"`
+	UserId         int64     `json:"user_id"`
+	Title          string    `json:"title"`
+	Content        string    `json:"content"`
+	Author         string    `json:"author"`
+	AuthorInfo     *Author   `json:"author_info,omitempty"`
+	DatePosted     time.Time `json:"date_posted"`
+	Visibility     string    `json:"visibility"`
+	Language       string    `json:"language"`
+	LanguageSource string    `json:"language_source"`
+	Excerpt        string    `json:"excerpt"`
+	ReadingMinutes int       `json:"reading_minutes"`
+	CommentCount   int       `json:"comment_count"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	// The board the post is filed under (nil when none) and its tags
+	BoardId *int64   `json:"board_id,omitempty"`
+	Tags    []string `json:"tags"`
+	// Only sent when the client is signed in
+	FollowingAuthor *bool `json:"following_author,omitempty"`
+	Watching        *bool `json:"watching,omitempty"`
+}
+
+// A board posts are filed under
+type Board struct {
+	BoardId     int64     `json:"board_id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// The boards and tags the client subscribes to
+type Subscriptions struct {
+	Boards []Board  `json:"boards"`
+	Tags   []string `json:"tags"`
+}
+
+// A post with a page of its comments and their reactions
+type PostWithComments struct {
+	Post
+	Comments []Comment `json:"comments"`
+}
+
+// A post's title and content in another language
+type PostTranslation struct {
+	PostId         int64  `json:"post_id"`
+	SourceLanguage string `json:"source_language"`
+	Language       string `json:"language"`
+	Title          string `json:"title"`
+	Content        string `json:"content"`
+	Translated     bool   `json:"translated"`
+}
+
+type Profile struct {
+	UserId         int64     `json:"user_id"`
+	FirstName      string    `json:"first_name"`
+	LastName       string    `json:"last_name"`
+	Email          string    `json:"email,omitempty"` // only sent to the member themselves and staff
+	GithubLink     string    `json:"github_link"`
+	City           string    `json:"city"`
+	State          string    `json:"state"`
+	DateRegistered time.Time `json:"date_registered"`
+	DisplayName    string    `json:"display_name,omitempty"`
+	// Only sent when the client is signed in
+	IsFollowing *bool `json:"is_following,omitempty"`
+	// Only sent to staff (roles with the users:read permission)
+	Staff *MemberDetails `json:"staff,omitempty"`
+}
+
+// Details about a member only staff see
+type MemberDetails struct {
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Banned bool   `json:"banned"`
+	// Set while the member is suspended rather than banned
+	BannedUntil  *time.Time `json:"banned_until,omitempty"`
+	Shadowbanned bool       `json:"shadowbanned"`
+	Reports      struct {
+		Against     int `json:"against"`
+		OpenAgainst int `json:"open_against"`
+		Filed       int `json:"filed"`
+	} `json:"reports"`
+}
+
+// Safe user data returned by auth endpoints
+type UserSummary struct {
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// User as returned by the admin endpoints
+type User struct {
+	ID        int64          `json:"user_id"`
+	Username  string         `json:"username"`
+	Role      string         `json:"role"`
+	FirstName string         `json:"first_name"`
+	LastName  string         `json:"last_name"`
+	Staff     *MemberDetails `json:"staff,omitempty"`
+}
+
+// Registration request body
+type RegisterRequest struct {
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email,omitempty"`
+	// Required when the server verifies signups with a CAPTCHA
+	CaptchaToken string `json:"captcha_token,omitempty"`
+}
+
+// Registration response
+type RegisterResponse struct {
+	Message string      `json:"message"`
+	User    UserSummary `json:"user"`
+	Profile *Profile    `json:"profile"`
+}
+
+// Login response
+type AuthResponse struct {
+	Token string      `json:"token"`
+	User  UserSummary `json:"user"`
+}
+
+// Current user response
+type CurrentUser struct {
+	User                UserSummary `json:"user"`
+	Profile             *Profile    `json:"profile"`
+	UnreadNotifications int         `json:"unread_notifications"`
+	// Nil when the profile couldn't be scored
+	ProfileCompleteness *ProfileCompleteness `json:"profile_completeness"`
+}
+
+// How much of their profile a user has filled in
+type ProfileCompleteness struct {
+	Score   int      `json:"score"`
+	Missing []string `json:"missing"`
+}
+
+// An API key (the key itself is only returned when it's generated)
+type APIKey struct {
+	KeyId      int64      `json:"key_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// A newly generated API key
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// A device the user is signed in on (Current marks the one making the request)
+type Session struct {
+	SessionId  int64     `json:"session_id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// An admin acting as the user (support reproducing an issue)
+	Impersonated bool `json:"impersonated"`
+	Current      bool `json:"current"`
+}
+
+// Fields that can be set when updating a profile (change the email with RequestEmailChange)
+type ProfileUpdate struct {
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	GithubLink string `json:"github_link"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	// Nil keeps the current display name; an empty string clears it
+	DisplayName *string `json:"display_name,omitempty"`
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"byte-board/client"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const usage = `Usage: byteboard [--url URL] <command> [arguments]
+
+Commands:
+  login <username>                  Log in and cache the token (password is read from stdin)
+  logout                            Remove the cached token
+  me                                Show the logged in user
+  post list                         List posts
+  post create <title> <content>     Create a post
+  comment add <postId> <content>    Comment on a post
+  admin users                       List all users (admin only)
+  admin user <userId>               Show a user (admin only)
+  admin role <userId> <role>        Change a user's role (admin only)
+
+The API URL defaults to $BYTEBOARD_URL or http://localhost:8080.
+`
+
+func main() {
+	defaultURL := os.Getenv("BYTEBOARD_URL")
+	if defaultURL == "" {
+		defaultURL = "http://localhost:8080"
+	}
+
+	baseURL := flag.String("url", defaultURL, "Byte Board API URL")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load the cached token (if any)
+	token, err := loadToken()
+	if err != nil {
+		fatal(err)
+	}
+
+	c := client.New(client.Config{BaseURL: *baseURL, Token: token})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := run(ctx, c, args); err != nil {
+		fatal(err)
+	}
+}
+
+// Dispatch a command
+func run(ctx context.Context, c *client.Client, args []string) error {
+	switch args[0] {
+	case "login":
+		if len(args) != 2 {
+			return errors.New("usage: byteboard login <username>")
+		}
+		return login(ctx, c, args[1])
+
+	case "logout":
+		path, err := tokenPath()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove cached token: %w", err)
+		}
+		fmt.Println("Logged out")
+		return nil
+
+	case "me":
+		me, err := c.Me(ctx)
+		if err != nil {
+			return err
+		}
+		return printJSON(me)
+
+	case "post":
+		return runPost(ctx, c, args[1:])
+
+	case "comment":
+		return runComment(ctx, c, args[1:])
+
+	case "admin":
+		return runAdmin(ctx, c, args[1:])
+	}
+
+	return fmt.Errorf("unknown command %q (run byteboard --help)", args[0])
+}
+
+// Log in and cache the token for later commands
+func login(ctx context.Context, c *client.Client, username string) error {
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+
+	resp, err := c.Login(ctx, username, strings.TrimRight(password, "\r\n"))
+	if err != nil {
+		return err
+	}
+
+	if err := saveToken(resp.Token); err != nil {
+		return err
+	}
+
+	fmt.Printf("Logged in as %s (%s)\n", resp.User.Username, resp.User.Role)
+	return nil
+}
+
+// post list | post create <title> <content>
+func runPost(ctx context.Context, c *client.Client, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: byteboard post list|create")
+	}
+
+	switch args[0] {
+	case "list":
+		posts, err := c.ListPosts(ctx)
+		if err != nil {
+			return err
+		}
+		for _, post := range posts {
+			fmt.Printf("%d\t%s\t%s\t%s\n", post.PostId, post.DatePosted.Format("2006-01-02 15:04"), post.Author, post.Title)
+		}
+		return nil
+
+	case "create":
+		if len(args) != 3 {
+			return errors.New("usage: byteboard post create <title> <content>")
+		}
+		post, err := c.CreatePost(ctx, args[1], args[2])
+		if err != nil {
+			return err
+		}
+		return printJSON(post)
+	}
+
+	return fmt.Errorf("unknown post command %q", args[0])
+}
+
+// comment add <postId> <content>
+func runComment(ctx context.Context, c *client.Client, args []string) error {
+	if len(args) != 3 || args[0] != "add" {
+		return errors.New("usage: byteboard comment add <postId> <content>")
+	}
+
+	postId, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid post ID %q", args[1])
+	}
+
+	comment, err := c.CreateComment(ctx, postId, args[2])
+	if err != nil {
+		return err
+	}
+	return printJSON(comment)
+}
+
+// admin users | admin user <userId> | admin role <userId> <role>
+func runAdmin(ctx context.Context, c *client.Client, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: byteboard admin users|user <userId>|role <userId> <role>")
+	}
+
+	switch args[0] {
+	case "users":
+		users, err := c.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			fmt.Printf("%d\t%s\t%s\t%s %s\n", user.ID, user.Username, user.Role, user.FirstName, user.LastName)
+		}
+		return nil
+
+	case "user":
+		if len(args) != 2 {
+			return errors.New("usage: byteboard admin user <userId>")
+		}
+		userId, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid user ID %q", args[1])
+		}
+		user, err := c.GetUser(ctx, userId)
+		if err != nil {
+			return err
+		}
+		return printJSON(user)
+
+	case "role":
+		if len(args) != 3 {
+			return errors.New("usage: byteboard admin role <userId> <role>")
+		}
+		userId, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid user ID %q", args[1])
+		}
+		user, err := c.SetUserRole(ctx, userId, args[2])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s is now %s\n", user.Username, user.Role)
+		return nil
+	}
+
+	return fmt.Errorf("unknown admin command %q", args[0])
+}
+
+// Location of the cached token
+func tokenPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %w", err)
+	}
+	return filepath.Join(dir, "byteboard", "token"), nil
+}
+
+// Read the cached token (empty if not logged in)
+func loadToken() (string, error) {
+	path, err := tokenPath()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read cached token: %w", err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Cache the token so only the current user can read it
+func saveToken(token string) error {
+	path, err := tokenPath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(token), 0o600); err != nil {
+		return fmt.Errorf("failed to cache token: %w", err)
+	}
+
+	return nil
+}
+
+// Print a value as indented JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// Print an error and exit
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "byteboard:", err)
+	os.Exit(1)
+}
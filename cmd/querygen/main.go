@@ -0,0 +1,756 @@
+// Command querygen generates typed Go functions for the SQL queries in internal/repository/queries, in
+// the spirit of sqlc: each query is written once in SQL, and its parameters and result columns get their
+// Go types from the tables in database.sql, so a scan can't get out of step with the columns it reads.
+//
+// Usage (see the go:generate line in internal/repository/scan.go):
+//
+//	querygen -schema database.sql -queries queries -out queries.sql.go
+//
+// A query file holds queries like this one:
+//
+//	-- name: GetUserByID :one
+//	-- row: userRow
+//	-- Get a user by ID
+//	SELECT user_id, username FROM users WHERE user_id = $1;
+//
+// :one returns a row (sql.ErrNoRows when there's none), :many a slice of rows, :exec only an error and
+// :execrows the number of rows affected. A query returning a single column returns its value instead of
+// a row. Rows are named after the query (getUserByIDRow) unless a "row" line names them; queries sharing
+// a row must return the same columns. Parameters are named and typed after the column they're compared
+// with, assigned to or inserted into; a "param" line ("-- param: $2 old_hash") renames one. Other
+// comment lines become the function's doc comment.
+//
+// Results other than plain columns need a cast and a name ("(SELECT COUNT(*) FROM posts)::int AS
+// post_count") and are read as NOT NULL. Parameters that aren't tied to a column are typed with a cast
+// ("$2::timestamptz") and named with a param line. Subqueries don't change which table the query reads.
+//
+// Rows made of plain columns also get a column list (userRowColumns) and a dest method returning the
+// scan destinations in the same order, so queries built at runtime can select and scan the same row.
+//
+// Nullable columns are read and written through the database/sql Null types, and array columns through
+// pq.Array. Queries the generator can't type (uncast expressions in the result, parameters it can't tie
+// to a column or a cast) are rejected rather than guessed at.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "database.sql", "schema file with the CREATE TABLE statements")
+	queriesDir := flag.String("queries", "queries", "directory of .sql query files")
+	outPath := flag.String("out", "queries.sql.go", "Go file to write")
+	pkg := flag.String("package", "repository", "package of the generated file")
+	flag.Parse()
+
+	code, err := generate(*schemaPath, *queriesDir, *pkg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "querygen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*outPath, code, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "querygen:", err)
+		os.Exit(1)
+	}
+}
+
+// Reads the schema and query files and returns the formatted Go source
+func generate(schemaPath, queriesDir, pkg string) ([]byte, error) {
+	schemaSQL, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := parseSchema(string(schemaSQL))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", schemaPath, err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(queriesDir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .sql files in %s", queriesDir)
+	}
+	sort.Strings(files)
+
+	var queries []*query
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := parseQueries(filepath.Base(file), string(source))
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, parsed...)
+	}
+
+	return render(pkg, tables, queries)
+}
+
+// #region Schema
+
+// A column of a table in the schema
+type column struct {
+	name    string
+	sqlType string
+	notNull bool
+}
+
+type table struct {
+	name    string
+	columns map[string]column
+}
+
+var (
+	createTablePattern = regexp.MustCompile(`(?ms)^CREATE TABLE (?:IF NOT EXISTS )?(\w+) \((.*?)^\);`)
+	// Table-level constraints rather than columns
+	constraintPrefixes = []string{"PRIMARY KEY", "FOREIGN KEY", "UNIQUE", "CHECK", "CONSTRAINT", "EXCLUDE"}
+)
+
+// Reads the tables and their columns from CREATE TABLE statements
+func parseSchema(schema string) (map[string]*table, error) {
+	tables := make(map[string]*table)
+	for _, match := range createTablePattern.FindAllStringSubmatch(schema, -1) {
+		t := &table{name: strings.ToLower(match[1]), columns: make(map[string]column)}
+		for _, line := range strings.Split(match[2], "\n") {
+			if i := strings.Index(line, "--"); i >= 0 {
+				line = line[:i]
+			}
+			line = strings.TrimSpace(line)
+			if line == "" || hasAnyPrefix(strings.ToUpper(line), constraintPrefixes) {
+				continue
+			}
+
+			fields := strings.Fields(strings.TrimSuffix(line, ","))
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("table %s: can't read column %q", t.name, line)
+			}
+			upper := strings.ToUpper(line)
+			t.columns[strings.ToLower(fields[0])] = column{
+				name:    strings.ToLower(fields[0]),
+				sqlType: strings.ToUpper(fields[1]),
+				notNull: strings.Contains(upper, "NOT NULL") || strings.Contains(upper, "PRIMARY KEY"),
+			}
+		}
+		tables[t.name] = t
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no CREATE TABLE statements")
+	}
+
+	return tables, nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Go types for SQL types, when the column is NOT NULL and when it's nullable
+var goTypes = map[string][2]string{
+	"BIGSERIAL":   {"int64", "sql.NullInt64"},
+	"BIGINT":      {"int64", "sql.NullInt64"},
+	"SERIAL":      {"int", "sql.NullInt32"},
+	"INT":         {"int", "sql.NullInt32"},
+	"INTEGER":     {"int", "sql.NullInt32"},
+	"SMALLINT":    {"int", "sql.NullInt32"},
+	"BOOLEAN":     {"bool", "sql.NullBool"},
+	"TEXT":        {"string", "sql.NullString"},
+	"VARCHAR":     {"string", "sql.NullString"},
+	"CHAR":        {"string", "sql.NullString"},
+	"TIMESTAMPTZ": {"time.Time", "sql.NullTime"},
+	"TIMESTAMP":   {"time.Time", "sql.NullTime"},
+	"DATE":        {"time.Time", "sql.NullTime"},
+	"JSONB":       {"[]byte", "[]byte"},
+	"BYTEA":       {"[]byte", "[]byte"},
+	// NULL arrays read as nil slices
+	"TEXT[]":   {"[]string", "[]string"},
+	"BIGINT[]": {"[]int64", "[]int64"},
+}
+
+// Go types for the casts that type expressions and parameters
+var castTypes = map[string]string{
+	"int":         "int",
+	"integer":     "int",
+	"bigint":      "int64",
+	"boolean":     "bool",
+	"bool":        "bool",
+	"text":        "string",
+	"timestamptz": "time.Time",
+	"text[]":      "[]string",
+	"bigint[]":    "[]int64",
+}
+
+// Arrays are passed and scanned through pq.Array (byte slices aren't arrays)
+func isArray(goType string) bool {
+	return strings.HasPrefix(goType, "[]") && goType != "[]byte"
+}
+
+// The Go type a column is read into and written from
+func (c column) goType() (string, error) {
+	base := c.sqlType
+	if i := strings.Index(base, "("); i >= 0 {
+		base = base[:i]
+	}
+	types, ok := goTypes[strings.TrimSuffix(base, ",")]
+	if !ok {
+		return "", fmt.Errorf("column %s has unsupported type %s", c.name, c.sqlType)
+	}
+	if c.notNull {
+		return types[0], nil
+	}
+	return types[1], nil
+}
+
+// #endregion
+
+// #region Queries
+
+// Kinds of query, by what the generated function returns
+const (
+	cmdOne      = ":one"
+	cmdMany     = ":many"
+	cmdExec     = ":exec"
+	cmdExecRows = ":execrows"
+)
+
+type query struct {
+	file    string
+	name    string
+	cmd     string
+	rowName string
+	doc     []string
+	sql     string
+	// Names given by "param" lines, by position
+	paramNames map[int]string
+}
+
+var (
+	namePattern  = regexp.MustCompile(`^-- name: (\w+) (:one|:many|:exec|:execrows)$`)
+	rowPattern   = regexp.MustCompile(`^-- row: (\w+)$`)
+	paramPattern = regexp.MustCompile(`^-- param: \$(\d+) (\w+)$`)
+)
+
+// Splits a query file into its queries
+func parseQueries(file, source string) ([]*query, error) {
+	var queries []*query
+	var current *query
+	var body []string
+
+	finish := func() error {
+		if current == nil {
+			return nil
+		}
+		current.sql = strings.TrimSuffix(strings.TrimSpace(strings.Join(body, "\n")), ";")
+		if current.sql == "" {
+			return fmt.Errorf("%s: query %s has no SQL", file, current.name)
+		}
+		queries = append(queries, current)
+		return nil
+	}
+
+	for n, line := range strings.Split(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if match := namePattern.FindStringSubmatch(trimmed); match != nil {
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			current = &query{file: file, name: match[1], cmd: match[2], paramNames: make(map[int]string)}
+			body = nil
+			continue
+		}
+		if current == nil {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return nil, fmt.Errorf("%s:%d: SQL before the first -- name: line", file, n+1)
+			}
+			continue
+		}
+
+		// Comment lines before the SQL starts are directives or documentation
+		if len(body) == 0 && strings.HasPrefix(trimmed, "--") {
+			if match := rowPattern.FindStringSubmatch(trimmed); match != nil {
+				current.rowName = match[1]
+			} else if match := paramPattern.FindStringSubmatch(trimmed); match != nil {
+				position, _ := strconv.Atoi(match[1])
+				current.paramNames[position] = match[2]
+			} else {
+				current.doc = append(current.doc, strings.TrimSpace(strings.TrimPrefix(trimmed, "--")))
+			}
+			continue
+		}
+		if len(body) == 0 && trimmed == "" {
+			continue
+		}
+		body = append(body, line)
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+
+	return queries, nil
+}
+
+var (
+	tablePattern      = regexp.MustCompile(`(?i)\b(?:FROM|INSERT INTO|UPDATE)\s+(\w+)`)
+	selectPattern     = regexp.MustCompile(`(?is)^SELECT\s+(.*?)\s+FROM\s`)
+	selectOnlyPattern = regexp.MustCompile(`(?is)^SELECT\s+(.*)$`)
+	returningPattern  = regexp.MustCompile(`(?is)\bRETURNING\s+(.*)$`)
+	insertPattern     = regexp.MustCompile(`(?is)^INSERT INTO \w+\s*\(([^)]*)\)\s*VALUES\s*\(([^)]*)\)`)
+	// column = $1, LOWER(column) = LOWER($1), t.column <= $1 ...
+	comparisonPattern  = regexp.MustCompile(`(?i)(?:\w+\.)?(\w+)\)?\s*(?:=|<>|!=|<=|>=|<|>)\s*(?:\w+\()?\$(\d+)\b`)
+	placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+	identifierPattern  = regexp.MustCompile(`^(?:\w+\.)?(\w+)$`)
+	// (SELECT COUNT(*) FROM posts)::int AS post_count
+	castResultPattern = regexp.MustCompile(`(?is)^(.+)::(\w+(?:\[\])?)\s+AS\s+(\w+)$`)
+	// $2::timestamptz
+	castParamPattern = regexp.MustCompile(`\$(\d+)::(\w+(?:\[\])?)`)
+)
+
+// A typed parameter or result column of a query
+type field struct {
+	column string
+	name   string
+	goType string
+	// A plain column of the query's table (not a cast expression)
+	plain bool
+}
+
+// A query with its parameters and result columns resolved against the schema
+type resolved struct {
+	*query
+	table   *table
+	params  []field
+	results []field
+}
+
+// Blanks out everything inside parentheses (keeping the length), so patterns run on the masked SQL
+// only see the top-level statement and not its subqueries or function arguments
+func topLevel(sql string) string {
+	masked := []byte(sql)
+	depth := 0
+	for i, c := range masked {
+		switch {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth > 0:
+			masked[i] = ' '
+		}
+	}
+	return string(masked)
+}
+
+// Splits a list at its top-level commas
+func splitTopLevel(list string) []string {
+	masked := topLevel(list)
+	var items []string
+	start := 0
+	for i := range masked {
+		if masked[i] == ',' {
+			items = append(items, list[start:i])
+			start = i + 1
+		}
+	}
+	return append(items, list[start:])
+}
+
+func resolve(q *query, tables map[string]*table) (*resolved, error) {
+	fail := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%s: %s: %s", q.file, q.name, fmt.Sprintf(format, args...))
+	}
+	masked := topLevel(q.sql)
+
+	// The statement's own table; a query whose only FROM is in a subquery (SELECT EXISTS (...)) reads that one
+	match := tablePattern.FindStringSubmatch(masked)
+	if match == nil {
+		match = tablePattern.FindStringSubmatch(q.sql)
+	}
+	if match == nil {
+		return nil, fail("can't tell which table the query reads or writes")
+	}
+	t, ok := tables[strings.ToLower(match[1])]
+	if !ok {
+		return nil, fail("table %s isn't in the schema", match[1])
+	}
+	r := &resolved{query: q, table: t}
+
+	// Result columns, found in the masked SQL and read from the original
+	var resultList string
+	for _, pattern := range []*regexp.Regexp{selectPattern, selectOnlyPattern, returningPattern} {
+		if m := pattern.FindStringSubmatchIndex(masked); m != nil {
+			resultList = q.sql[m[2]:m[3]]
+			break
+		}
+	}
+	if resultList != "" {
+		for _, item := range splitTopLevel(resultList) {
+			item = strings.TrimSpace(item)
+			if m := identifierPattern.FindStringSubmatch(item); m != nil {
+				f, err := r.field(m[1])
+				if err != nil {
+					return nil, fail("%v", err)
+				}
+				r.results = append(r.results, f)
+				continue
+			}
+
+			m := castResultPattern.FindStringSubmatch(item)
+			if m == nil {
+				return nil, fail("result %q isn't a plain column (cast expressions: (...)::type AS name)", item)
+			}
+			goType, ok := castTypes[strings.ToLower(m[2])]
+			if !ok {
+				return nil, fail("result %s has unsupported cast type %s", m[3], m[2])
+			}
+			r.results = append(r.results, field{column: strings.ToLower(m[3]), name: strings.ToLower(m[3]), goType: goType})
+		}
+	}
+	switch {
+	case (q.cmd == cmdOne || q.cmd == cmdMany) && len(r.results) == 0:
+		return nil, fail("%s query returns no columns", q.cmd)
+	case (q.cmd == cmdExec || q.cmd == cmdExecRows) && len(r.results) > 0:
+		return nil, fail("%s query returns columns (use :one or :many)", q.cmd)
+	}
+
+	// Parameters, typed by their cast or by the column they're stored in or compared with
+	casts := make(map[int]string)
+	for _, m := range castParamPattern.FindAllStringSubmatch(q.sql, -1) {
+		position, _ := strconv.Atoi(m[1])
+		goType, ok := castTypes[strings.ToLower(m[2])]
+		if !ok {
+			return nil, fail("$%d has unsupported cast type %s", position, m[2])
+		}
+		casts[position] = goType
+	}
+	paramColumns := make(map[int]string)
+	if m := insertPattern.FindStringSubmatch(q.sql); m != nil {
+		columns := strings.Split(m[1], ",")
+		values := strings.Split(m[2], ",")
+		if len(columns) != len(values) {
+			return nil, fail("INSERT lists %d columns and %d values", len(columns), len(values))
+		}
+		for i, value := range values {
+			if p := placeholderPattern.FindStringSubmatch(strings.TrimSpace(value)); p != nil && p[0] == strings.TrimSpace(value) {
+				position, _ := strconv.Atoi(p[1])
+				if _, ok := paramColumns[position]; !ok {
+					paramColumns[position] = strings.TrimSpace(columns[i])
+				}
+			}
+		}
+	}
+	for _, m := range comparisonPattern.FindAllStringSubmatch(q.sql, -1) {
+		position, _ := strconv.Atoi(m[2])
+		if _, ok := paramColumns[position]; !ok {
+			paramColumns[position] = m[1]
+		}
+	}
+
+	count := 0
+	for _, m := range placeholderPattern.FindAllStringSubmatch(q.sql, -1) {
+		position, _ := strconv.Atoi(m[1])
+		if position > count {
+			count = position
+		}
+	}
+	seen := make(map[string]int)
+	for position := 1; position <= count; position++ {
+		var f field
+		if goType, ok := casts[position]; ok {
+			name, ok := q.paramNames[position]
+			if !ok {
+				return nil, fail("$%d is typed by a cast, so it needs a param line to name it", position)
+			}
+			f = field{name: name, goType: goType}
+		} else {
+			columnName, ok := paramColumns[position]
+			if !ok {
+				return nil, fail("can't tell which column $%d is for", position)
+			}
+			var err error
+			if f, err = r.field(columnName); err != nil {
+				return nil, fail("$%d: %v", position, err)
+			}
+			if name, ok := q.paramNames[position]; ok {
+				f.name = name
+			}
+		}
+		f.name = lowerCamel(f.name)
+		if other, ok := seen[f.name]; ok {
+			return nil, fail("$%d and $%d are both named %s (name one with a param line)", other, position, f.name)
+		}
+		seen[f.name] = position
+		r.params = append(r.params, f)
+	}
+	for position := range q.paramNames {
+		if position < 1 || position > count {
+			return nil, fail("param line names $%d, which the query doesn't use", position)
+		}
+	}
+
+	return r, nil
+}
+
+// A column of the query's table as a typed field
+func (r *resolved) field(name string) (field, error) {
+	c, ok := r.table.columns[strings.ToLower(name)]
+	if !ok {
+		return field{}, fmt.Errorf("%s isn't a column of %s", name, r.table.name)
+	}
+	goType, err := c.goType()
+	if err != nil {
+		return field{}, err
+	}
+	return field{column: c.name, name: c.name, goType: goType, plain: true}, nil
+}
+
+// #endregion
+
+// #region Output
+
+// user_id -> UserId
+func upperCamel(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// user_id -> userId
+func lowerCamel(name string) string {
+	camel := upperCamel(name)
+	if camel == "" {
+		return camel
+	}
+	return strings.ToLower(camel[:1]) + camel[1:]
+}
+
+// GetUserByID -> getUserByID
+func unexported(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func render(pkg string, tables map[string]*table, queries []*query) ([]byte, error) {
+	var all []*resolved
+	rows := make(map[string]*resolved)
+	var rowOrder []string
+	names := make(map[string]bool)
+	for _, q := range queries {
+		if names[q.name] {
+			return nil, fmt.Errorf("%s: query %s is defined twice", q.file, q.name)
+		}
+		names[q.name] = true
+
+		r, err := resolve(q, tables)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, r)
+		if len(r.results) < 2 {
+			continue
+		}
+
+		if q.rowName == "" {
+			q.rowName = unexported(q.name) + "Row"
+		}
+		if first, ok := rows[q.rowName]; ok {
+			if !sameFields(first.results, r.results) {
+				return nil, fmt.Errorf("%s: %s: row %s has different columns in %s", q.file, q.name, q.rowName, first.name)
+			}
+			continue
+		}
+		rows[q.rowName] = r
+		rowOrder = append(rowOrder, q.rowName)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by querygen from queries/*.sql. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	b.WriteString("import (\n\t\"database/sql\"\n")
+	if uses(all, func(f field) bool { return f.goType == "time.Time" }) {
+		b.WriteString("\t\"time\"\n")
+	}
+	if uses(all, func(f field) bool { return isArray(f.goType) }) {
+		b.WriteString("\n\t\"github.com/lib/pq\"\n")
+	}
+	b.WriteString(")\n\n")
+	b.WriteString("// Runs the generated queries (implemented by both *DB and *Tx)\n")
+	b.WriteString("type queryer interface {\n")
+	b.WriteString("\tExec(query string, args ...interface{}) (sql.Result, error)\n")
+	b.WriteString("\tQuery(query string, args ...interface{}) (*sql.Rows, error)\n")
+	b.WriteString("\tQueryRow(query string, args ...interface{}) *sql.Row\n")
+	b.WriteString("}\n\n")
+
+	for _, name := range rowOrder {
+		var users []string
+		for _, r := range all {
+			if r.rowName == name {
+				users = append(users, r.name)
+			}
+		}
+		fmt.Fprintf(&b, "// Row returned by %s\n", strings.Join(users, ", "))
+		fmt.Fprintf(&b, "type %s struct {\n", name)
+		for _, f := range rows[name].results {
+			fmt.Fprintf(&b, "\t%s %s\n", upperCamel(f.column), f.goType)
+		}
+		b.WriteString("}\n\n")
+
+		if !plainRow(rows[name].results) {
+			continue
+		}
+		columns := make([]string, 0, len(rows[name].results))
+		dest := make([]string, 0, len(rows[name].results))
+		for _, f := range rows[name].results {
+			columns = append(columns, f.column)
+			dest = append(dest, scanDest("r."+upperCamel(f.column), f.goType))
+		}
+		fmt.Fprintf(&b, "// Columns of %s, in the order dest returns them\n", name)
+		fmt.Fprintf(&b, "const %sColumns = %q\n\n", name, strings.Join(columns, ", "))
+		fmt.Fprintf(&b, "// Scan destinations for a row selected with %sColumns\n", name)
+		fmt.Fprintf(&b, "func (r *%s) dest() []interface{} {\n", name)
+		fmt.Fprintf(&b, "\treturn []interface{}{%s}\n}\n\n", strings.Join(dest, ", "))
+	}
+
+	for _, r := range all {
+		renderQuery(&b, r)
+	}
+
+	code, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, b.String())
+	}
+	return code, nil
+}
+
+func renderQuery(b *bytes.Buffer, r *resolved) {
+	fn := unexported(r.name)
+	constName := fn + "SQL"
+	fmt.Fprintf(b, "const %s = `-- name: %s %s\n%s\n`\n\n", constName, r.name, r.cmd, r.sql)
+
+	for _, line := range r.doc {
+		fmt.Fprintf(b, "// %s\n", line)
+	}
+	if len(r.doc) == 0 {
+		fmt.Fprintf(b, "// Runs %s from %s\n", r.name, r.file)
+	}
+
+	params := []string{"q queryer"}
+	args := []string{constName}
+	for _, p := range r.params {
+		params = append(params, p.name+" "+p.goType)
+		if isArray(p.goType) {
+			args = append(args, "pq.Array("+p.name+")")
+		} else {
+			args = append(args, p.name)
+		}
+	}
+
+	// What one row scans into
+	result := r.rowName
+	scanArgs := make([]string, 0, len(r.results))
+	switch {
+	case len(r.results) == 1:
+		result = r.results[0].goType
+		scanArgs = append(scanArgs, scanDest("item", result))
+	case plainRow(r.results):
+		scanArgs = append(scanArgs, "item.dest()...")
+	default:
+		for _, f := range r.results {
+			scanArgs = append(scanArgs, scanDest("item."+upperCamel(f.column), f.goType))
+		}
+	}
+	scan := strings.Join(scanArgs, ", ")
+	call := strings.Join(args, ", ")
+
+	switch r.cmd {
+	case cmdOne:
+		fmt.Fprintf(b, "func %s(%s) (%s, error) {\n", fn, strings.Join(params, ", "), result)
+		fmt.Fprintf(b, "\tvar item %s\n", result)
+		fmt.Fprintf(b, "\terr := q.QueryRow(%s).Scan(%s)\n", call, scan)
+		b.WriteString("\treturn item, err\n}\n\n")
+	case cmdMany:
+		fmt.Fprintf(b, "func %s(%s) ([]%s, error) {\n", fn, strings.Join(params, ", "), result)
+		fmt.Fprintf(b, "\trows, err := q.Query(%s)\n", call)
+		b.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n\tdefer rows.Close()\n\n")
+		fmt.Fprintf(b, "\tvar items []%s\n", result)
+		b.WriteString("\tfor rows.Next() {\n")
+		fmt.Fprintf(b, "\t\tvar item %s\n", result)
+		fmt.Fprintf(b, "\t\tif err := rows.Scan(%s); err != nil {\n\t\t\treturn nil, err\n\t\t}\n", scan)
+		b.WriteString("\t\titems = append(items, item)\n\t}\n")
+		b.WriteString("\treturn items, rows.Err()\n}\n\n")
+	case cmdExec:
+		fmt.Fprintf(b, "func %s(%s) error {\n", fn, strings.Join(params, ", "))
+		fmt.Fprintf(b, "\t_, err := q.Exec(%s)\n", call)
+		b.WriteString("\treturn err\n}\n\n")
+	case cmdExecRows:
+		fmt.Fprintf(b, "func %s(%s) (int64, error) {\n", fn, strings.Join(params, ", "))
+		fmt.Fprintf(b, "\tresult, err := q.Exec(%s)\n", call)
+		b.WriteString("\tif err != nil {\n\t\treturn 0, err\n\t}\n")
+		b.WriteString("\treturn result.RowsAffected()\n}\n\n")
+	}
+}
+
+func sameFields(a, b []field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].column != b[i].column || a[i].goType != b[i].goType {
+			return false
+		}
+	}
+	return true
+}
+
+// Whether the query's results are all plain columns, so its row gets a column list and a dest method
+func plainRow(results []field) bool {
+	for _, f := range results {
+		if !f.plain {
+			return false
+		}
+	}
+	return true
+}
+
+// The scan destination for a value of the given type
+func scanDest(value, goType string) string {
+	if isArray(goType) {
+		return "pq.Array(&" + value + ")"
+	}
+	return "&" + value
+}
+
+// Whether any parameter or result of the queries matches
+func uses(queries []*resolved, match func(field) bool) bool {
+	for _, r := range queries {
+		for _, fields := range [][]field{r.params, r.results} {
+			for _, f := range fields {
+				if match(f) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// #endregion
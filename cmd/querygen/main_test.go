@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSchema = `
+CREATE TABLE users (
+    user_id BIGSERIAL PRIMARY KEY,
+    username VARCHAR(50) NOT NULL UNIQUE,
+    -- A comment line
+    first_name VARCHAR(50),
+    banned_until TIMESTAMPTZ,
+    token_version INTEGER NOT NULL DEFAULT 0,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    balance NUMERIC(10, 2),
+    FOREIGN KEY (user_id) REFERENCES accounts (account_id)
+);
+`
+
+// Generates code for one query file against testSchema
+func generateFrom(t *testing.T, queries string) (string, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "schema.sql"), []byte(testSchema), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "queries"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "queries", "test.sql"), []byte(queries), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := generate(filepath.Join(dir, "schema.sql"), filepath.Join(dir, "queries"), "repository")
+	return string(code), err
+}
+
+func TestGeneratedCodeIsUpToDate(t *testing.T) {
+	want, err := generate("../../database.sql", "../../internal/repository/queries", "repository")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../../internal/repository/queries.sql.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatal("internal/repository/queries.sql.go is stale; run go generate ./internal/repository")
+	}
+}
+
+func TestTypesFollowTheSchema(t *testing.T) {
+	code, err := generateFrom(t, `
+-- name: GetUser :one
+SELECT user_id, username, first_name, banned_until, token_version, tags FROM users WHERE user_id = $1;
+
+-- name: ListUsers :many
+-- param: $1 since
+SELECT username FROM users WHERE banned_until > $1;
+
+-- name: RenameUser :execrows
+UPDATE users SET username = $2, first_name = $3 WHERE user_id = $1;
+
+-- name: TagUser :exec
+UPDATE users SET tags = $2 WHERE user_id = $1;
+
+-- name: GetUserStats :one
+-- param: $2 since
+SELECT username, (SELECT COUNT(*) FROM users u WHERE u.banned_until > $2::timestamptz)::int AS banned_count
+FROM users
+WHERE user_id = $1;
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"type getUserRow struct",
+		"UserId       int64",
+		"Username     string",
+		"FirstName    sql.NullString",
+		"BannedUntil  sql.NullTime",
+		"TokenVersion int",
+		"Tags         []string",
+		`const getUserRowColumns = "user_id, username, first_name, banned_until, token_version, tags"`,
+		"pq.Array(&r.Tags)",
+		"func getUser(q queryer, userId int64) (getUserRow, error)",
+		"Scan(item.dest()...)",
+		"func listUsers(q queryer, since sql.NullTime) ([]string, error)",
+		"func renameUser(q queryer, userId int64, username string, firstName sql.NullString) (int64, error)",
+		"func tagUser(q queryer, userId int64, tags []string) error",
+		"q.Exec(tagUserSQL, userId, pq.Array(tags))",
+		"BannedCount int",
+		"func getUserStats(q queryer, userId int64, since time.Time) (getUserStatsRow, error)",
+		"Scan(&item.Username, &item.BannedCount)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code is missing %q:\n%s", want, code)
+		}
+	}
+}
+
+func TestRejectsQueriesItCantType(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries string
+		err     string
+	}{
+		{"expression result", "-- name: Q :one\nSELECT COUNT(*) FROM users;", "isn't a plain column"},
+		{"unsupported cast", "-- name: Q :one\nSELECT COUNT(*)::numeric AS total FROM users;", "unsupported cast type numeric"},
+		{"unnamed cast parameter", "-- name: Q :many\nSELECT user_id FROM users LIMIT $1::int;", "needs a param line"},
+		{"unknown column", "-- name: Q :one\nSELECT nickname FROM users;", "nickname isn't a column of users"},
+		{"unknown table", "-- name: Q :one\nSELECT user_id FROM accounts;", "isn't in the schema"},
+		{"unsupported type", "-- name: Q :one\nSELECT balance FROM users;", "unsupported type"},
+		{"untyped parameter", "-- name: Q :many\nSELECT user_id FROM users LIMIT $1;", "can't tell which column $1 is for"},
+		{"duplicate parameter names", "-- name: Q :exec\nUPDATE users SET username = $2 WHERE username = $1;", "both named username"},
+		{"unused param line", "-- name: Q :exec\n-- param: $2 other\nDELETE FROM users WHERE user_id = $1;", "doesn't use"},
+		{"exec returning columns", "-- name: Q :exec\nDELETE FROM users WHERE user_id = $1 RETURNING username;", "returns columns"},
+		{"one without columns", "-- name: Q :one\nDELETE FROM users WHERE user_id = $1;", "returns no columns"},
+		{"mismatched insert", "-- name: Q :exec\nINSERT INTO users (username, first_name) VALUES ($1);", "lists 2 columns and 1 values"},
+		{"duplicate query", "-- name: Q :exec\nDELETE FROM users;\n-- name: Q :exec\nDELETE FROM users;", "defined twice"},
+		{"sql before a name", "SELECT 1;\n-- name: Q :exec\nDELETE FROM users;", "before the first"},
+		{"shared row with other columns", "-- name: A :one\n-- row: r\nSELECT user_id, username FROM users;\n" +
+			"-- name: B :one\n-- row: r\nSELECT user_id, first_name FROM users;", "different columns"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := generateFrom(t, tc.queries)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("got error %v, want one containing %q", err, tc.err)
+			}
+		})
+	}
+}
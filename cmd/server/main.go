@@ -1,13 +1,23 @@
 package main
 
 import (
+	"byte-board/internal/adminui"
 	"byte-board/internal/appconfig"
 	"byte-board/internal/auth"
+	"byte-board/internal/captcha"
+	"byte-board/internal/federation"
 	"byte-board/internal/handler"
+	"byte-board/internal/jobs"
+	"byte-board/internal/mail"
 	"byte-board/internal/middleware"
+	"byte-board/internal/model"
 	"byte-board/internal/service"
+	"byte-board/internal/storage"
+	"byte-board/internal/translate"
+	"context"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	database "byte-board/internal/repository"
@@ -18,6 +28,10 @@ import (
 )
 
 func main() {
+	// Work in UTC whatever the host's zone, so times made here render with the same Z offset as those
+	// read from the database
+	time.Local = time.UTC
+
 	// Setup Zerologger
 	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
 	log.Logger = zerolog.New(zerolog.ConsoleWriter{
@@ -45,111 +59,478 @@ func main() {
 
 	// Initialize JWT token provider
 	jwtConfig := auth.JWTConfig{
-		SecretKey:       cfg.JWTSecret,
-		ExpirationHours: cfg.JWTExpirationHours,
+		SecretKey:             cfg.JWTSecret,
+		ExpirationHours:       cfg.JWTExpirationHours,
+		RefreshExpirationDays: cfg.JWTRefreshExpirationDays,
+		Issuer:                cfg.JWTIssuer,
+		Audience:              cfg.JWTAudience,
+		PreviousSecretKeys:    cfg.GetJWTPreviousSecrets(),
+	}
+	signingKey, err := cfg.GetJWTSigningKey()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load JWT signing key")
+	}
+	if signingKey != nil {
+		jwtConfig.SigningKey, err = auth.ParseSigningKey(signingKey)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid JWT signing key")
+		}
+		log.Info().Str("alg", jwtConfig.SigningKey.Method.Alg()).Str("kid", jwtConfig.SigningKey.JWK.KeyID).Msg("Signing tokens with private key")
+	}
+	previousKeys, err := cfg.GetJWTPreviousSigningKeys()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load previous JWT signing keys")
+	}
+	for _, keyBytes := range previousKeys {
+		key, err := auth.ParseSigningKey(keyBytes)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid previous JWT signing key")
+		}
+		jwtConfig.PreviousSigningKeys = append(jwtConfig.PreviousSigningKeys, key)
+		log.Info().Str("alg", key.Method.Alg()).Str("kid", key.JWK.KeyID).Msg("Accepting tokens signed with previous key")
 	}
 	tokenProvider := auth.NewTokenProvider(jwtConfig)
 	log.Info().Msg("JWT token provider initialized")
 
+	// Initialize password hashing
+	passwordHasher, err := auth.NewPasswordHasher(cfg.PasswordHash, auth.Argon2Params{
+		Memory:     cfg.Argon2MemoryKiB,
+		Iterations: cfg.Argon2Iterations,
+		Threads:    cfg.Argon2Parallelism,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid password hashing configuration")
+	}
+	log.Info().Str("algorithm", cfg.PasswordHash).Msg("Password hasher initialized")
+
+	// Initialize mailer (log emails when SMTP isn't configured)
+	var mailer mail.Mailer
+	if cfg.SMTPHost != "" {
+		smtpPassword, err := cfg.GetSMTPPassword()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load SMTP password")
+		}
+		mailer = mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: smtpPassword,
+			From:     cfg.SMTPFrom,
+		})
+		log.Info().Str("host", cfg.SMTPHost).Msg("SMTP mailer initialized")
+	} else {
+		mailer = mail.NewLogMailer()
+		log.Warn().Msg("SMTP_HOST not set - emails will be logged instead of sent")
+	}
+
+	// Initialize signup throttling
+	var disposableDomains service.DisposableDomainList = service.DefaultDisposableDomains
+	if cfg.DisposableDomainsFile != "" {
+		domains, err := service.LoadDomainSet(cfg.DisposableDomainsFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load disposable email domains")
+		}
+		disposableDomains = domains
+		log.Info().Int("domains", len(domains)).Msg("Disposable email domain list loaded")
+	}
+	var captchaVerifier captcha.Verifier
+	if cfg.CaptchaProvider != "" {
+		verifier, err := captcha.NewHTTPVerifier(cfg.CaptchaProvider, cfg.CaptchaSecret, cfg.CaptchaMinScore)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize CAPTCHA verifier")
+		}
+		captchaVerifier = verifier
+		log.Info().Str("provider", cfg.CaptchaProvider).Msg("CAPTCHA verification enabled for registration")
+	} else {
+		log.Warn().Msg("CAPTCHA_PROVIDER not set - registrations won't require a CAPTCHA")
+	}
+	signupGuard := service.NewSignupGuard(db, service.SignupLimits{
+		PerIP:           cfg.SignupsPerIP,
+		PerSubnet:       cfg.SignupsPerSubnet,
+		PerEmail:        cfg.SignupsPerEmail,
+		BlockDisposable: cfg.BlockDisposableEmails,
+	}, disposableDomains, captchaVerifier)
+
+	// Initialize login throttling (progressive delays on repeated failed logins)
+	loginThrottle := service.NewLoginThrottle(cfg.LoginFreeFailures,
+		time.Duration(cfg.LoginBackoffBaseSeconds)*time.Second, time.Duration(cfg.LoginBackoffMaxSeconds)*time.Second)
+
 	// Initialize auth service
-	authService := service.NewAuthService(db, tokenProvider)
+	authService := service.NewAuthService(db, tokenProvider, passwordHasher, mailer, cfg.PublicURL, cfg.PasswordResetURL, signupGuard, loginThrottle, service.AdminBootstrap{
+		FirstUser: cfg.BootstrapFirstUserAdmin,
+		Username:  cfg.BootstrapAdminUsername,
+		Email:     cfg.BootstrapAdminEmail,
+	})
 	log.Info().Msg("Auth service initialized")
 
+	// Initialize notification service (unsubscribe links are signed with the JWT secret)
+	notificationService := service.NewNotificationService(db, mailer, cfg.PublicURL, cfg.JWTSecret)
+	log.Info().Msg("Notification service initialized")
+
+	// Initialize word filter
+	wordFilter, err := service.NewWordFilterService(db)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load word filters")
+	}
+	log.Info().Msg("Word filter initialized")
+
+	// Initialize roles and permissions
+	roleService, err := service.NewRoleService(db)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load roles")
+	}
+	log.Info().Msg("Role service initialized")
+
+	// Initialize per-account content quotas
+	contentQuotas := service.NewContentQuotas(db, map[string]service.ContentQuota{
+		"user":  {PostsPerHour: cfg.PostsPerHour, CommentsPerHour: cfg.CommentsPerHour},
+		"admin": {PostsPerHour: cfg.AdminPostsPerHour, CommentsPerHour: cfg.AdminCommentsPerHour},
+	})
+
+	// Initialize post service (post writes flush the anonymous post list cache)
+	postListCache := service.NewPostListCache(db, time.Duration(cfg.PostListCacheSeconds)*time.Second, time.Duration(cfg.PostListStaleSeconds)*time.Second)
+	postService := service.NewPostService(db, time.Duration(cfg.DuplicatePostWindowMinutes)*time.Minute, wordFilter, contentQuotas, postListCache)
+	log.Info().Msg("Post service initialized")
+
+	// Initialize comment service
+	commentService := service.NewCommentService(db, wordFilter, notificationService, contentQuotas)
+	log.Info().Msg("Comment service initialized")
+
+	// Initialize leaderboard service
+	leaderboardService := service.NewLeaderboardService(db, time.Duration(cfg.LeaderboardCacheSeconds)*time.Second)
+	log.Info().Msg("Leaderboard service initialized")
+
+	// Initialize snippet service
+	snippetService := service.NewSnippetService(db)
+	log.Info().Msg("Snippet service initialized")
+
+	// Initialize gist import service
+	gistService := service.NewGistService(postService, snippetService, cfg.GithubAPIURL, cfg.GithubToken)
+	log.Info().Msg("Gist import service initialized")
+
+	// Initialize maintenance job queue (admin-triggered reindexes, cache flushes and skill migrations; identical jobs
+	// are merged, so it stays small)
+	maintenanceQueue := jobs.NewQueue(10)
+	maintenanceQueue.Start(context.Background())
+
+	// Initialize skill tag moderation (migrations run on the maintenance queue)
+	skillService := service.NewSkillService(db, maintenanceQueue)
+
+	// Initialize profile service
+	profileService := service.NewProfileService(db, wordFilter, skillService)
+	log.Info().Msg("Profile service initialized")
+
+	// Initialize report service
+	reportService := service.NewReportService(db, roleService)
+	log.Info().Msg("Report service initialized")
+
+	// Initialize moderation service
+	moderationService := service.NewModerationService(db, notificationService, postListCache)
+	log.Info().Msg("Moderation service initialized")
+
+	// Initialize digest service
+	digestService := service.NewDigestService(db, notificationService, cfg.PublicURL, time.Duration(cfg.SiteDigestRefreshMinutes)*time.Minute)
+	log.Info().Msg("Digest service initialized")
+
+	// Initialize keyword alert service
+	keywordAlertService := service.NewKeywordAlertService(db, notificationService, time.Duration(cfg.KeywordAlertCooldownMinutes)*time.Minute)
+	log.Info().Msg("Keyword alert service initialized")
+
+	// Initialize advisory post edit locks
+	editLockService := service.NewEditLockService(db, time.Duration(cfg.EditLockSeconds)*time.Second)
+
+	// Initialize presence tracking (who's online)
+	presenceTTL := time.Duration(cfg.PresenceTTLSeconds) * time.Second
+	presenceService := service.NewPresenceService(presenceTTL)
+
+	// Initialize attachment storage
+	attachmentStore, err := storage.NewDiskStore(cfg.AttachmentsDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize attachment storage")
+	}
+	attachmentService := service.NewAttachmentService(db, attachmentStore, service.AttachmentLimits{
+		MaxFileBytes: cfg.AttachmentMaxBytes,
+		QuotaBytes:   cfg.StorageQuotaBytes,
+	}, time.Duration(cfg.AttachmentUnlinkedDays)*24*time.Hour)
+	log.Info().Str("dir", cfg.AttachmentsDir).Msg("Attachment service initialized")
+
+	maintenanceService := service.NewMaintenanceService(db, maintenanceQueue, leaderboardService, postListCache, wordFilter)
+	log.Info().Msg("Maintenance service initialized")
+
+	// Initialize database backups (run on the maintenance queue so they never overlap)
+	backupStore, err := storage.NewDiskStore(cfg.BackupsDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize backup storage")
+	}
+	databasePassword, err := cfg.GetDatabasePassword()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load database password for backups")
+	}
+	backupService := service.NewBackupService(db, maintenanceQueue, backupStore, service.BackupConfig{
+		PgDumpPath: cfg.PgDumpPath,
+		Host:       cfg.PostgresHost,
+		Port:       cfg.PostgresPort,
+		Database:   cfg.PostgresDB,
+		User:       cfg.PostgresUser,
+		Password:   databasePassword,
+		SSLMode:    cfg.PostgresSSLMode,
+		Retention:  cfg.BackupRetention,
+	})
+	if err := backupService.FailInterrupted(); err != nil {
+		log.Error().Err(err).Msg("Failed to clean up interrupted backups")
+	}
+	log.Info().Str("dir", cfg.BackupsDir).Msg("Backup service initialized")
+
+	// Check the database, storage and SMTP before going any further
+	if cfg.StartupChecks {
+		stores := map[string]storage.Store{"attachment": attachmentStore, "backup": backupStore}
+		if err := runStartupChecks(startupChecks(db, stores, mailer)); err != nil {
+			log.Fatal().Msg(err.Error())
+		}
+	} else {
+		log.Warn().Msg("STARTUP_CHECKS disabled - skipping dependency checks")
+	}
+
+	// Initialize domain event log
+	eventService := service.NewEventService(db)
+
+	// Initialize event replay to the configured consumers
+	replayTargets, err := cfg.GetEventReplayTargets()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid event replay targets")
+	}
+	replayConsumers := make(map[string]service.EventConsumer, len(replayTargets))
+	for name, target := range replayTargets {
+		replayConsumers[name] = service.NewHTTPEventConsumer(target, cfg.EventReplaySecret)
+	}
+	eventReplayService := service.NewEventReplayService(db, maintenanceQueue, replayConsumers)
+
+	// Initialize site-wide announcements
+	announcementService := service.NewAnnouncementService(db)
+
+	// Initialize machine translation of posts (disabled when TRANSLATE_API_URL isn't set)
+	var translator translate.Translator
+	if cfg.TranslateAPIURL != "" {
+		translator = translate.NewHTTPTranslator(cfg.TranslateAPIURL, cfg.TranslateAPIKey)
+		log.Info().Str("url", cfg.TranslateAPIURL).Msg("Translation provider initialized")
+	} else {
+		log.Warn().Msg("TRANSLATE_API_URL not set - post translation is disabled")
+	}
+	translationService := service.NewTranslationService(db, translator)
+
+	// Initialize service clients (client credentials grant for internal services calling admin endpoints)
+	serviceClientService := service.NewServiceClientService(db, tokenProvider, time.Duration(cfg.ServiceTokenMinutes)*time.Minute)
+
+	// Initialize impersonation (short-lived tokens letting admins act as a user to reproduce their issues)
+	impersonationService := service.NewImpersonationService(db, tokenProvider, roleService, time.Duration(cfg.ImpersonationTokenMinutes)*time.Minute)
+	searchService := service.NewSearchService(db, time.Duration(cfg.SearchSuggestTimeoutMs)*time.Millisecond)
+
+	// Initialize federation (publish-only ActivityPub; nil, so its routes and job are left out, when disabled)
+	var federationService *service.FederationService
+	if cfg.FederationEnabled {
+		keyBytes, err := cfg.GetFederationKey()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to load federation key")
+		}
+		key, err := federation.ParsePrivateKey(keyBytes)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid federation key")
+		}
+		federationService, err = service.NewFederationService(db, key, cfg.PublicURL)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to initialize federation")
+		}
+		log.Info().Str("public_url", cfg.PublicURL).Msg("ActivityPub federation enabled")
+	}
+
+	// Initialize API keys (users' scripts and services authenticate with X-API-Key instead of a JWT)
+	apiKeyService := service.NewAPIKeyService(db)
+
+	// Initialize sessions (signed-in devices, checked on every request made with a JWT)
+	sessionService := service.NewSessionService(db)
+
+	// Initialize cleanup of expired tokens and old signup records
+	cleanupService := service.NewCleanupService(db, time.Duration(cfg.SignupRetentionDays)*24*time.Hour)
+
+	// Start background jobs
+	scheduler := jobs.New()
+	scheduler.Add("word-filter-reload", time.Duration(cfg.WordFilterReloadSeconds)*time.Second, wordFilter.ReloadJob)
+	scheduler.Add("role-reload", time.Minute, roleService.ReloadJob)
+	scheduler.Add("suspension-expiry", time.Minute, moderationService.LiftExpiredSuspensions)
+	scheduler.Add("email-digest", time.Duration(cfg.DigestCheckMinutes)*time.Minute, digestService.SendDueDigests)
+	scheduler.Add("site-digest", time.Duration(cfg.SiteDigestRefreshMinutes)*time.Minute, digestService.RefreshSiteDigests)
+	scheduler.Add("keyword-alerts", time.Duration(cfg.KeywordAlertIntervalSeconds)*time.Second, keywordAlertService.MatchNewPosts)
+	scheduler.Add("token-cleanup", time.Duration(cfg.CleanupIntervalMinutes)*time.Minute, cleanupService.Run)
+	scheduler.Add("database-backup", time.Duration(cfg.BackupIntervalHours)*time.Hour, backupService.ScheduledJob)
+	scheduler.Add("presence-sweep", presenceTTL, presenceService.Sweep)
+	scheduler.Add("login-throttle-sweep", 10*time.Minute, loginThrottle.Sweep)
+	scheduler.Add("attachment-unlinked-cleanup", time.Duration(cfg.AttachmentGCIntervalMinutes)*time.Minute, attachmentService.CleanupUnlinked)
+	scheduler.Add("attachment-gc", time.Duration(cfg.AttachmentGCIntervalMinutes)*time.Minute, attachmentService.CollectGarbage)
+	if federationService != nil {
+		scheduler.Add("federation-delivery", 30*time.Second, federationService.Run)
+	}
+	scheduler.Start(context.Background())
+
 	// Initialize auth middleware
-	authMiddleware := middleware.NewAuthMiddleware(tokenProvider)
+	authMiddleware := middleware.NewAuthMiddleware(tokenProvider, db, serviceClientService, apiKeyService, sessionService, roleService)
 	log.Info().Msg("Auth middleware initialized")
 
-	// Initialize handlers with auth service
-	handler := handler.New(db, cfg, authService)
+	// Initialize activity tracking (for active user metrics)
+	activityTracker := middleware.NewActivityTracker(db)
+
+	// Initialize rate limiting of @mention autocomplete
+	suggestLimiter := middleware.NewRateLimiter(cfg.UserSuggestPerMinute, time.Minute, cfg.TrustProxyHeaders)
+
+	// Initialize read-only mode switch
+	readOnly := middleware.NewReadOnlyMode(cfg.ReadOnly)
+	if cfg.ReadOnly {
+		log.Warn().Msg("Starting in read-only mode")
+	}
+
+	// Initialize handlers with services
+	handler := handler.New(db, cfg, authService, postService, readOnly, leaderboardService, postListCache, snippetService, gistService, profileService, reportService, moderationService, commentService, wordFilter, digestService, notificationService, attachmentService, maintenanceService, cleanupService, eventService, announcementService, backupService, translationService, serviceClientService, keywordAlertService, editLockService, presenceService, skillService, apiKeyService, eventReplayService, sessionService, roleService, impersonationService, searchService, federationService)
 
 	// Set up router with middlewear
-	router := setupRouter(handler, authMiddleware)
+	router := setupRouter(handler, authMiddleware, activityTracker, suggestLimiter, middleware.Transaction(db))
 
-	// Initialize CORS middleware with configuration
+	// Initialize CORS middleware with configuration (admin endpoints can be limited to the admin UI's origin)
 	corsConfig := middleware.CORSConfig{
 		AllowedOrigins: cfg.GetAllowedOrigins(),
 	}
+	adminCORS := middleware.CORSRoute{
+		PathPrefix: "/api/admin",
+		Config:     middleware.CORSConfig{AllowedOrigins: cfg.GetAdminAllowedOrigins()},
+	}
 
-	// Apply middleware chain: Recover -> Logging -> CORS -> Router
+	// Apply middleware chain: Recover -> Logging -> CORS -> Read-only -> Router
 	httpHandler := middleware.Recovery(
 		middleware.Logging(
-			middleware.CORS(corsConfig)(router),
+			middleware.CORS(corsConfig, adminCORS)(
+				readOnly.Enforce(router),
+			),
 		),
 	)
 
 	// Start server
 	log.Info().Str("port", cfg.Port).Msg("Byte Board Service starting")
 
+	server := newServer(cfg, httpHandler)
+	log.Fatal().Err(server.ListenAndServe()).Msg("Server failed to start")
+}
+
+// Builds the HTTP server with the listener tuning from config
+func newServer(cfg *appconfig.Config, handler http.Handler) *http.Server {
+	seconds := func(n int) time.Duration { return time.Duration(n) * time.Second }
+
 	server := &http.Server{
-		Addr:         ":" + cfg.Port,
-		Handler:      httpHandler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              ":" + cfg.Port,
+		Handler:           handler,
+		ReadTimeout:       seconds(cfg.HTTPReadTimeoutSeconds),
+		ReadHeaderTimeout: seconds(cfg.HTTPReadHeaderTimeoutSeconds),
+		WriteTimeout:      seconds(cfg.HTTPWriteTimeoutSeconds),
+		IdleTimeout:       seconds(cfg.HTTPIdleTimeoutSeconds),
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: cfg.HTTP2MaxConcurrentStreams,
+		},
 	}
+	server.SetKeepAlivesEnabled(cfg.HTTPKeepAlives)
 
-	log.Fatal().Err(server.ListenAndServe()).Msg("Server failed to start")
+	// HTTP/1.1 always; HTTP/2 over TLS is negotiated automatically, cleartext HTTP/2 only when asked for
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(cfg.HTTPH2C)
+	server.Protocols = protocols
+
+	log.Info().
+		Dur("read_timeout", server.ReadTimeout).
+		Dur("read_header_timeout", server.ReadHeaderTimeout).
+		Dur("write_timeout", server.WriteTimeout).
+		Dur("idle_timeout", server.IdleTimeout).
+		Int("max_header_bytes", server.MaxHeaderBytes).
+		Bool("keep_alives", cfg.HTTPKeepAlives).
+		Bool("h2c", cfg.HTTPH2C).
+		Msg("HTTP server configured")
+	return server
 }
 
 // Setup router configures all of the API routes
-func setupRouter(h *handler.Handler, authMiddleware *middleware.AuthMiddleware) *mux.Router {
+func setupRouter(h *handler.Handler, authMiddleware *middleware.AuthMiddleware, activityTracker *middleware.ActivityTracker,
+	suggestLimiter *middleware.RateLimiter, transaction func(http.Handler) http.Handler) *mux.Router {
 	router := mux.NewRouter()
+	// JSON errors instead of gorilla's plain-text defaults
+	router.NotFoundHandler = handler.RouteNotMatched(router)
+	router.MethodNotAllowedHandler = router.NotFoundHandler
 
 	// Set up API routes
 	api := router.PathPrefix("/api").Subrouter()
 
+	// Share lookups of the current user between middleware and handlers within a request
+	api.Use(middleware.RequestCache)
+
+	// Identify signed-in users on public routes too (listings hide shadowbanned content from everyone but its author)
+	api.Use(authMiddleware.OptionalJWTAuth)
+	api.Use(activityTracker.Track)
+
 	// Set up protected routes (JWT Required)
-	protected := api.PathPrefix("").Subrouter()
-	protected.Use(authMiddleware.JWTAuth)
-
-	// Set up admin routes
-	admin := api.PathPrefix("/admin").Subrouter()
-	admin.Use(authMiddleware.JWTAuth)
-	admin.Use(middleware.RequireRole("admin"))
-
-	// Login/Register endpoints
-	api.HandleFunc("/register", h.Register).Methods("POST")
-	api.HandleFunc("/login", h.Login).Methods("POST")
-
-	// Comment endpoints
-	// GET
-	api.HandleFunc("/comments", h.GetAllComments).Methods("GET")
-	api.HandleFunc("/posts/{postId}/comments", h.GetCommentsOnPost).Methods("GET")
-	api.HandleFunc("/comments/{commentId}", h.GetCommentById).Methods("GET")
-	// POST
-	protected.HandleFunc("/posts/{postId}/comments", h.CreateComment).Methods("POST")
-	// PUT
-	protected.HandleFunc("/comments/{commentId}", h.UpdateComment).Methods("PUT")
-	// DELETE
-	protected.HandleFunc("/comments/{commentId}", h.DeleteComment).Methods("DELETE")
-
-	// Post endpoints
-	// GET
-	api.HandleFunc("/posts", h.GetAllPosts).Methods("GET")
-	api.HandleFunc("/posts/{postId}", h.GetPostById).Methods("GET")
-	api.HandleFunc("/posts/user/{userId}", h.GetPostsByUserId).Methods("GET")
-	// POST
-	protected.HandleFunc("/posts", h.CreatePost).Methods("POST")
-	// PUT
-	protected.HandleFunc("/posts/{postId}", h.UpdatePost).Methods("PUT")
-	// DELETE
-	protected.HandleFunc("/posts/{postId}", h.DeletePost).Methods("DELETE")
-
-	// Profile endpoints
-	api.HandleFunc("/profiles", h.GetAllProfiles).Methods("GET")
-	api.HandleFunc("/profiles/{userId}", h.GetProfileByUserId).Methods("GET")
-	// PUT
-	protected.HandleFunc("/profiles/{userId}", h.UpdateProfile).Methods("PUT")
-
-	// User endpoints
-	protected.HandleFunc("/auth/me", h.GetCurrentUser).Methods("GET")
-	// DELETE
-	protected.HandleFunc("/users/{userId}", h.DeleteUser).Methods("DELETE")
-
-	// User management (Admin only)
-	admin.HandleFunc("/users", h.GetAllUsers).Methods("GET")
-	admin.HandleFunc("/users/{userId}", h.GetUserById).Methods("GET")
-	admin.HandleFunc("/users/username/{username}", h.GetUserByUsername).Methods("GET")
+	protectedRoutes := api.PathPrefix("").Subrouter()
+	protectedRoutes.Use(authMiddleware.JWTAuth)
+	protectedRoutes.Use(authMiddleware.RejectBanned)
+
+	// Set up routes banned users can still reach (JWT Required) so they can see and appeal the ban
+	appealableRoutes := api.PathPrefix("").Subrouter()
+	appealableRoutes.Use(authMiddleware.JWTAuth)
+
+	// Set up admin routes (each checks the permission it needs)
+	adminOnly := api.PathPrefix("/admin").Subrouter()
+
+	// Register every endpoint from the route table on the subrouter for its access level
+	table := routes(h, suggestLimiter, transaction)
+	if err := validateRoutes(table); err != nil {
+		log.Fatal().Err(err).Msg("Invalid route table")
+	}
+	for _, r := range table {
+		switch r.Access {
+		case public:
+			api.Handle(r.Path, r.Handler).Methods(r.Method)
+		case protected:
+			protectedRoutes.Handle(r.Path, r.Handler).Methods(r.Method)
+		case appealable:
+			appealableRoutes.Handle(r.Path, r.Handler).Methods(r.Method)
+		case admin:
+			key := r.Method + " " + r.Path
+			scoped := middleware.ClientScope(clientScopes[key])(r.Handler)
+			adminOnly.Handle(strings.TrimPrefix(r.Path, "/admin"), authMiddleware.PermissionAuth(routePermission(key))(scoped)).Methods(r.Method)
+		}
+	}
+
+	// Readiness probe; ?verbose=1 adds database detail for admins and service clients with the maintenance scope
+	readinessDetail := authMiddleware.AdminAuth(middleware.ClientScope(model.ScopeMaintenance)(http.HandlerFunc(h.ReadinessDetail)))
+	router.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("verbose") == "1" {
+			readinessDetail.ServeHTTP(w, r)
+			return
+		}
+		h.Readiness(w, r)
+	}).Methods("GET")
+
+	// ActivityPub actors, outboxes and inboxes (outside /api, where fediverse servers expect them)
+	if h.Federating() {
+		router.HandleFunc("/.well-known/webfinger", h.WebFinger).Methods("GET")
+		router.HandleFunc("/users/{username}", h.GetActor).Methods("GET")
+		router.HandleFunc("/users/{username}/outbox", h.GetOutbox).Methods("GET")
+		router.HandleFunc("/users/{username}/followers", h.GetFederationFollowers).Methods("GET")
+		router.HandleFunc("/users/{username}/posts/{postId}", h.GetNote).Methods("GET")
+		router.HandleFunc("/users/{username}/inbox", h.PostInbox).Methods("POST")
+	}
+
+	// Public keys other services verify our tokens with
+	router.HandleFunc("/.well-known/jwks.json", h.GetJWKS).Methods("GET")
+
+	// Embedded admin UI (API calls it makes require an admin JWT)
+	router.Handle("/admin-ui", http.RedirectHandler("/admin-ui/", http.StatusMovedPermanently))
+	router.PathPrefix("/admin-ui/").Handler(http.StripPrefix("/admin-ui/", adminui.Handler()))
 
 	return router
 }
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"byte-board/internal/handler"
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Who can call a route, which decides the middleware it runs behind
+type access int
+
+const (
+	// Anyone (a JWT, if sent, identifies the viewer)
+	public access = iota
+	// Signed-in users who aren't banned
+	protected
+	// Signed-in users, including banned ones (so they can see and appeal the ban)
+	appealable
+	// Signed-in admins who aren't banned, users whose role grants the route's permission (for routes in
+	// routePermissions), and service clients (for routes in clientScopes)
+	admin
+)
+
+func (a access) String() string {
+	return [...]string{"public", "protected", "appealable", "admin"}[a]
+}
+
+// One API endpoint (path is relative to /api)
+type route struct {
+	Method  string
+	Path    string
+	Access  access
+	Handler http.Handler
+}
+
+// The full API route table. Order matters where paths overlap: gorilla/mux uses the first match.
+// Handlers making several writes that must land together are wrapped in transaction.
+func routes(h *handler.Handler, suggestLimiter *middleware.RateLimiter, transaction func(http.Handler) http.Handler) []route {
+	fn := func(f http.HandlerFunc) http.Handler { return f }
+
+	return []route{
+		// Login/Register endpoints
+		{"POST", "/register", public, fn(h.Register)},
+		{"POST", "/login", public, fn(h.Login)},
+		{"POST", "/auth/refresh", public, fn(h.RefreshToken)},
+		{"POST", "/auth/forgot-password", public, fn(h.ForgotPassword)},
+		{"POST", "/auth/reset-password", public, fn(h.ResetPassword)},
+		{"POST", "/auth/token", public, fn(h.IssueClientToken)},
+
+		// Comment endpoints
+		// GET
+		{"GET", "/comments", public, fn(h.GetAllComments)},
+		{"GET", "/posts/{postId}/comments", public, fn(h.GetCommentsOnPost)},
+		{"GET", "/comments/{commentId}", public, fn(h.GetCommentById)},
+		// POST
+		{"POST", "/posts/{postId}/comments", protected, fn(h.CreateComment)},
+		// PUT
+		{"PUT", "/comments/{commentId}", protected, fn(h.UpdateComment)},
+		{"PUT", "/comments/{commentId}/reactions/{reaction}", protected, fn(h.ReactToComment)},
+		// DELETE
+		{"DELETE", "/comments/{commentId}", protected, fn(h.DeleteComment)},
+		{"DELETE", "/comments/{commentId}/reactions/{reaction}", protected, fn(h.UnreactToComment)},
+
+		// Post endpoints
+		// GET
+		{"GET", "/posts", public, fn(h.GetAllPosts)},
+		{"GET", "/posts/{postId}", public, fn(h.GetPostById)},
+		{"GET", "/posts/user/{userId}", public, middleware.Deprecated(middleware.Deprecation{
+			Since:     time.Date(2026, time.October, 16, 0, 0, 0, 0, time.UTC),
+			Successor: "/api/posts?user_id={userId}",
+		})(fn(h.GetPostsByUserId))},
+		// POST
+		{"POST", "/posts", protected, fn(h.CreatePost)},
+		{"POST", "/posts/import/gist", protected, fn(h.ImportGist)},
+		// PUT
+		{"PUT", "/posts/{postId}", protected, fn(h.UpdatePost)},
+		// DELETE
+		{"DELETE", "/posts/{postId}", protected, fn(h.DeletePost)},
+
+		// Boards, and the feed of the boards and tags you subscribe to
+		{"GET", "/boards", public, fn(h.GetBoards)},
+		{"GET", "/feed", protected, fn(h.GetFeed)},
+		{"GET", "/subscriptions", protected, fn(h.GetSubscriptions)},
+		{"PUT", "/subscriptions/boards/{slug}", protected, fn(h.SubscribeToBoard)},
+		{"DELETE", "/subscriptions/boards/{slug}", protected, fn(h.UnsubscribeFromBoard)},
+		{"PUT", "/subscriptions/tags/{tag}", protected, fn(h.SubscribeToTag)},
+		{"DELETE", "/subscriptions/tags/{tag}", protected, fn(h.UnsubscribeFromTag)},
+
+		// Machine translation of posts
+		{"GET", "/posts/{postId}/translation", public, fn(h.GetPostTranslation)},
+
+		// Post watch endpoints (comment notifications)
+		{"GET", "/posts/{postId}/watch", protected, fn(h.GetPostWatch)},
+		{"POST", "/posts/{postId}/watch", protected, fn(h.WatchPost)},
+		{"DELETE", "/posts/{postId}/watch", protected, fn(h.UnwatchPost)},
+		{"POST", "/posts/{postId}/mute", protected, fn(h.MutePost)},
+		{"DELETE", "/posts/{postId}/mute", protected, fn(h.UnmutePost)},
+
+		// Post edit locks (advisory, so two editing sessions don't overwrite each other)
+		{"GET", "/posts/{postId}/edit-lock", protected, fn(h.GetEditLock)},
+		{"POST", "/posts/{postId}/edit-lock", protected, fn(h.AcquireEditLock)},
+		{"PUT", "/posts/{postId}/edit-lock", protected, fn(h.RenewEditLock)},
+		{"DELETE", "/posts/{postId}/edit-lock", protected, fn(h.ReleaseEditLock)},
+
+		// Snippet endpoints
+		// GET
+		{"GET", "/posts/{postId}/snippets", public, fn(h.GetSnippetsOnPost)},
+		{"GET", "/snippets/{snippetId}", public, fn(h.GetSnippetById)},
+		{"GET", "/snippets/{snippetId}/raw", public, fn(h.GetSnippetRaw)},
+		// POST
+		{"POST", "/posts/{postId}/snippets", protected, fn(h.CreateSnippet)},
+		// DELETE
+		{"DELETE", "/snippets/{snippetId}", protected, fn(h.DeleteSnippet)},
+
+		// Profile endpoints
+		// GET
+		{"GET", "/profiles", public, fn(h.GetAllProfiles)},
+		{"GET", "/profiles/{userId}", public, fn(h.GetProfileByUserId)},
+		{"GET", "/profiles/email/confirm", public, fn(h.ConfirmEmailChange)},
+		{"GET", "/profiles/{userId}/projects", public, fn(h.GetProjectsOnProfile)},
+		{"GET", "/profiles/{userId}/projects/{projectId}", public, fn(h.GetProjectById)},
+		{"GET", "/skills", public, fn(h.GetSkills)},
+		// POST
+		{"POST", "/profiles/{userId}/projects", protected, fn(h.CreateProject)},
+		// PUT
+		{"PUT", "/profiles/me/email", protected, middleware.RejectImpersonated(fn(h.RequestEmailChange))},
+		{"PUT", "/profiles/{userId}", protected, middleware.RejectImpersonated(transaction(fn(h.UpdateProfile)))},
+		{"PUT", "/profiles/{userId}/skills", protected, fn(h.SetProfileSkills)},
+		{"PUT", "/profiles/{userId}/projects/{projectId}", protected, fn(h.UpdateProject)},
+		// DELETE
+		{"DELETE", "/profiles/{userId}/projects/{projectId}", protected, fn(h.DeleteProject)},
+
+		// Attachment endpoints
+		{"GET", "/attachments", protected, fn(h.GetMyAttachments)},
+		{"GET", "/attachments/{attachmentId}", public, fn(h.GetAttachment)},
+		{"POST", "/attachments", protected, fn(h.UploadAttachment)},
+		{"DELETE", "/attachments/{attachmentId}", protected, fn(h.DeleteAttachment)},
+
+		// Markdown preview (nothing is saved)
+		{"POST", "/preview", protected, fn(h.PreviewContent)},
+
+		// Report endpoints
+		{"GET", "/reports/reasons", public, fn(h.GetReportReasons)},
+		{"POST", "/reports", protected, fn(h.CreateReport)},
+
+		// Moderation history and appeals
+		{"GET", "/moderation/me", appealable, fn(h.GetMyModerationActions)},
+		{"GET", "/appeals", appealable, fn(h.GetMyAppeals)},
+		{"POST", "/appeals", appealable, fn(h.CreateAppeal)},
+
+		// Notification endpoints
+		{"GET", "/notifications", protected, fn(h.GetNotifications)},
+		{"GET", "/notifications/unread-count", protected, fn(h.GetUnreadNotificationCount)},
+		{"PUT", "/notifications/read-all", protected, fn(h.MarkAllNotificationsRead)},
+		{"PUT", "/notifications/{notificationId}/read", protected, fn(h.MarkNotificationRead)},
+		// Signed links from emails (GET when clicked, POST for one-click unsubscribe)
+		{"GET", "/unsubscribe", public, fn(h.Unsubscribe)},
+		{"POST", "/unsubscribe", public, fn(h.Unsubscribe)},
+
+		// Announcement endpoints (site-wide banners)
+		{"GET", "/announcements", public, fn(h.GetAnnouncements)},
+		{"POST", "/announcements/{announcementId}/dismiss", protected, fn(h.DismissAnnouncement)},
+
+		// Leaderboard endpoints
+		{"GET", "/leaderboard", public, fn(h.GetLeaderboard)},
+
+		// Site digest ("this week on Byte Board")
+		{"GET", "/digest", public, fn(h.GetSiteDigest)},
+
+		// User endpoints
+		// GET
+		{"GET", "/auth/me", protected, fn(h.GetCurrentUser)},
+		{"GET", "/auth/me/storage", protected, fn(h.GetStorageUsage)},
+		{"GET", "/auth/me/digest", protected, fn(h.GetDigestSettings)},
+		{"GET", "/auth/me/notification-settings", protected, fn(h.GetNotificationSettings)},
+		{"GET", "/auth/me/keyword-alerts", protected, fn(h.GetKeywordAlerts)},
+		{"GET", "/auth/apikeys", protected, fn(h.GetAPIKeys)},
+		{"GET", "/auth/sessions", protected, fn(h.GetSessions)},
+		// PUT
+		{"PUT", "/auth/me/password", protected, middleware.RejectImpersonated(fn(h.ChangePassword))},
+		{"POST", "/auth/change-password", protected, middleware.RejectImpersonated(fn(h.ChangePassword))},
+		{"PUT", "/auth/me/digest", protected, fn(h.UpdateDigestSettings)},
+		{"PUT", "/auth/me/notification-settings", protected, fn(h.UpdateNotificationSettings)},
+		{"GET", "/users/suggest", protected, suggestLimiter.Limit(fn(h.SuggestUsers))},
+
+		// Search box typeahead
+		{"GET", "/search/suggest", public, suggestLimiter.Limit(fn(h.SuggestSearch))},
+
+		// Presence (who's online)
+		{"GET", "/presence/online-count", public, fn(h.GetOnlineCount)},
+		{"POST", "/presence/ping", protected, fn(h.PingPresence)},
+		// POST
+		{"POST", "/users/{userId}/follow", protected, fn(h.FollowUser)},
+		{"POST", "/auth/me/keyword-alerts", protected, fn(h.CreateKeywordAlert)},
+		{"POST", "/auth/apikeys", protected, middleware.RejectImpersonated(fn(h.CreateAPIKey))},
+		// DELETE
+		{"DELETE", "/auth/me/keyword-alerts/{alertId}", protected, fn(h.DeleteKeywordAlert)},
+		{"DELETE", "/auth/apikeys/{keyId}", protected, middleware.RejectImpersonated(fn(h.RevokeAPIKey))},
+		{"DELETE", "/auth/sessions/{sessionId}", protected, middleware.RejectImpersonated(fn(h.RevokeSession))},
+		{"DELETE", "/users/{userId}", protected, middleware.RejectImpersonated(transaction(fn(h.DeleteUser)))},
+		{"DELETE", "/users/{userId}/follow", protected, fn(h.UnfollowUser)},
+
+		// User management (Admin only)
+		{"GET", "/admin/users", admin, fn(h.GetAllUsers)},
+		{"GET", "/admin/users/{userId}", admin, fn(h.GetUserById)},
+		{"GET", "/admin/users/username/{username}", admin, fn(h.GetUserByUsername)},
+		{"GET", "/admin/users/{userId}/email-history", admin, fn(h.GetEmailHistory)},
+		{"POST", "/admin/users/{userId}/merge", admin, fn(h.MergeUsers)},
+		{"POST", "/admin/users/import", admin, fn(h.ImportUsers)},
+		{"PUT", "/admin/users/{userId}/role", admin, fn(h.SetUserRole)},
+		{"POST", "/admin/impersonate/{userId}", admin, fn(h.ImpersonateUser)},
+		{"PUT", "/admin/users/{userId}/ban", admin, fn(h.BanUser)},
+		{"PUT", "/admin/users/{userId}/suspend", admin, fn(h.SuspendUser)},
+
+		// Roles and permissions (Admin only)
+		{"GET", "/admin/roles", admin, fn(h.GetRoles)},
+		{"PUT", "/admin/roles/{role}", admin, fn(h.SetRole)},
+		{"DELETE", "/admin/roles/{role}", admin, fn(h.DeleteRole)},
+
+		// Moderation (Admin only)
+		{"GET", "/admin/reports", admin, fn(h.GetReports)},
+		{"GET", "/admin/reports/export", admin, fn(h.ExportReports)},
+		{"GET", "/admin/reports/{reportId}", admin, fn(h.GetReportById)},
+		{"PUT", "/admin/reports/{reportId}/status", admin, fn(h.SetReportStatus)},
+		{"PUT", "/admin/reports/{reportId}/assignee", admin, fn(h.AssignReport)},
+		{"GET", "/admin/report-reasons", admin, fn(h.GetAllReportReasons)},
+		{"POST", "/admin/report-reasons", admin, fn(h.CreateReportReason)},
+		{"PUT", "/admin/report-reasons/{code}", admin, fn(h.UpdateReportReason)},
+
+		{"GET", "/admin/moderation/actions", admin, fn(h.GetModerationActions)},
+		{"POST", "/admin/moderation/actions", admin, fn(h.TakeModerationAction)},
+		{"GET", "/admin/moderation/stats", admin, fn(h.GetModeratorStats)},
+		{"GET", "/admin/appeals", admin, fn(h.GetAppeals)},
+		{"PUT", "/admin/appeals/{appealId}", admin, fn(h.ResolveAppeal)},
+
+		{"GET", "/admin/word-filters", admin, fn(h.GetWordFilters)},
+		{"POST", "/admin/word-filters", admin, fn(h.CreateWordFilter)},
+		{"DELETE", "/admin/word-filters/{filterId}", admin, fn(h.DeleteWordFilter)},
+
+		// Skill tag moderation (Admin only)
+		{"GET", "/admin/skills/rules", admin, fn(h.GetSkillRules)},
+		{"PUT", "/admin/skills/rules/{name}", admin, fn(h.SetSkillRule)},
+		{"DELETE", "/admin/skills/rules/{name}", admin, fn(h.DeleteSkillRule)},
+		{"POST", "/admin/skills/merge", admin, fn(h.MergeSkills)},
+		{"POST", "/admin/skills/migrate", admin, fn(h.StartSkillMigration)},
+
+		// Boards (Admin only)
+		{"PUT", "/admin/boards/{slug}", admin, fn(h.SetBoard)},
+		{"DELETE", "/admin/boards/{slug}", admin, fn(h.DeleteBoard)},
+
+		// Announcements (Admin only)
+		{"GET", "/admin/announcements", admin, fn(h.GetAllAnnouncements)},
+		{"POST", "/admin/announcements", admin, fn(h.CreateAnnouncement)},
+		{"PUT", "/admin/announcements/{announcementId}", admin, fn(h.UpdateAnnouncement)},
+		{"POST", "/admin/announcements/{announcementId}/expire", admin, fn(h.ExpireAnnouncement)},
+
+		// Metrics (Admin only)
+		{"GET", "/admin/metrics/active-users", admin, fn(h.GetActiveUserMetrics)},
+		{"GET", "/admin/metrics/growth", admin, fn(h.GetGrowthMetrics)},
+		{"GET", "/admin/metrics/cleanup", admin, fn(h.GetCleanupMetrics)},
+		{"GET", "/admin/metrics/counters", admin, fn(h.GetCounterMetrics)},
+
+		// Domain event log (Admin only)
+		{"GET", "/admin/events", admin, fn(h.GetEvents)},
+		{"GET", "/admin/events/replay/targets", admin, fn(h.GetEventReplayTargets)},
+		{"POST", "/admin/events/replay", admin, fn(h.StartEventReplay)},
+		{"GET", "/admin/data-access", admin, fn(h.GetDataAccessLog)},
+
+		// Maintenance (Admin only)
+		{"GET", "/admin/read-only", admin, fn(h.GetReadOnlyMode)},
+		{"PUT", "/admin/read-only", admin, fn(h.SetReadOnlyMode)},
+		{"POST", "/admin/maintenance/reindex", admin, fn(h.StartReindex)},
+		{"POST", "/admin/maintenance/cache/flush", admin, fn(h.StartCacheFlush)},
+		{"GET", "/admin/maintenance/jobs/{jobId}", admin, fn(h.GetMaintenanceJob)},
+		{"GET", "/admin/backups", admin, fn(h.GetBackups)},
+		{"POST", "/admin/backups", admin, fn(h.StartBackup)},
+
+		// Service clients (Admin only, never callable with a machine token)
+		{"GET", "/admin/service-clients", admin, fn(h.GetServiceClients)},
+		{"POST", "/admin/service-clients", admin, fn(h.CreateServiceClient)},
+		{"DELETE", "/admin/service-clients/{clientId}", admin, fn(h.RevokeServiceClient)},
+	}
+}
+
+// Admin routes service clients may call, keyed by "METHOD path", with the scope their token needs.
+// Admin routes missing here stay closed to machine tokens.
+var clientScopes = map[string]string{
+	"GET /admin/announcements":                          model.ScopeAnnouncements,
+	"POST /admin/announcements":                         model.ScopeAnnouncements,
+	"PUT /admin/announcements/{announcementId}":         model.ScopeAnnouncements,
+	"POST /admin/announcements/{announcementId}/expire": model.ScopeAnnouncements,
+	"GET /admin/metrics/active-users":                   model.ScopeMetrics,
+	"GET /admin/metrics/growth":                         model.ScopeMetrics,
+	"GET /admin/metrics/cleanup":                        model.ScopeMetrics,
+	"GET /admin/metrics/counters":                       model.ScopeMetrics,
+	"GET /admin/events":                                 model.ScopeEvents,
+	"GET /admin/read-only":                              model.ScopeMaintenance,
+	"PUT /admin/read-only":                              model.ScopeMaintenance,
+	"POST /admin/maintenance/reindex":                   model.ScopeMaintenance,
+	"POST /admin/maintenance/cache/flush":               model.ScopeMaintenance,
+	"GET /admin/maintenance/jobs/{jobId}":               model.ScopeMaintenance,
+	"GET /admin/backups":                                model.ScopeBackups,
+	"POST /admin/backups":                               model.ScopeBackups,
+}
+
+// Admin routes users can reach with a narrower permission than an admin's, keyed by "METHOD path".
+// Admin routes missing here need a role granting every permission (model.PermissionAll).
+var routePermissions = map[string]string{
+	"GET /admin/users":                        model.PermissionUsersRead,
+	"GET /admin/users/{userId}":               model.PermissionUsersRead,
+	"GET /admin/users/username/{username}":    model.PermissionUsersRead,
+	"GET /admin/users/{userId}/email-history": model.PermissionUsersRead,
+	"PUT /admin/users/{userId}/ban":           model.PermissionModerationAct,
+	"PUT /admin/users/{userId}/suspend":       model.PermissionModerationAct,
+	"GET /admin/reports":                      model.PermissionReportsManage,
+	"GET /admin/reports/export":               model.PermissionReportsManage,
+	"GET /admin/reports/{reportId}":           model.PermissionReportsManage,
+	"PUT /admin/reports/{reportId}/status":    model.PermissionReportsManage,
+	"PUT /admin/reports/{reportId}/assignee":  model.PermissionReportsManage,
+	"GET /admin/moderation/actions":           model.PermissionModerationAct,
+	"POST /admin/moderation/actions":          model.PermissionModerationAct,
+	"GET /admin/moderation/stats":             model.PermissionModerationAct,
+	"GET /admin/appeals":                      model.PermissionModerationAct,
+	"PUT /admin/appeals/{appealId}":           model.PermissionModerationAct,
+}
+
+// Gets the permission users need for an admin route
+func routePermission(key string) string {
+	if permission, ok := routePermissions[key]; ok {
+		return permission
+	}
+	return model.PermissionAll
+}
+
+// Checks the route table for mistakes that would silently shadow or expose an endpoint
+func validateRoutes(table []route) error {
+	seen := make(map[string]bool, len(table))
+	for _, r := range table {
+		key := r.Method + " " + r.Path
+		if seen[key] {
+			return fmt.Errorf("route %s is registered twice", key)
+		}
+		seen[key] = true
+
+		if r.Handler == nil {
+			return fmt.Errorf("route %s has no handler", key)
+		}
+		// Everything under /admin must require the admin role (and only /admin routes should)
+		isAdminPath := r.Path == "/admin" || strings.HasPrefix(r.Path, "/admin/")
+		if isAdminPath != (r.Access == admin) {
+			return fmt.Errorf("route %s has %s access but admin routes must live under /admin", key, r.Access)
+		}
+	}
+
+	// A scope on a route that doesn't exist (or isn't admin) is a typo that would leave the real route closed
+	for key, scope := range clientScopes {
+		if !seen[key] || !strings.HasPrefix(key[strings.Index(key, " ")+1:], "/admin/") {
+			return fmt.Errorf("client scope %q is set for %s, which isn't an admin route", scope, key)
+		}
+		if !slices.Contains(model.ServiceScopes, scope) {
+			return fmt.Errorf("route %s needs unknown client scope %q", key, scope)
+		}
+	}
+
+	// Likewise a permission on a route that doesn't exist would leave the real route admin-only
+	for key, permission := range routePermissions {
+		if !seen[key] || !strings.HasPrefix(key[strings.Index(key, " ")+1:], "/admin/") {
+			return fmt.Errorf("permission %q is set for %s, which isn't an admin route", permission, key)
+		}
+		if !slices.Contains(model.Permissions, permission) {
+			return fmt.Errorf("route %s needs unknown permission %q", key, permission)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"byte-board/internal/auth"
+	"byte-board/internal/handler"
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	// Every rejected request logs a warning
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+}
+
+// Users the test authenticates as, by API key
+type testAccounts struct {
+	byKey map[string]*model.User
+	byId  map[int64]*model.User
+}
+
+// Adds a user with a role and returns their API key
+func (a *testAccounts) add(role string, banned bool) string {
+	id := int64(len(a.byId) + 1)
+	user := &model.User{ID: id, Username: fmt.Sprintf("user%d", id), Role: role, Status: model.UserStatusActive}
+	if banned {
+		user.Status = model.UserStatusBanned
+	}
+	key := fmt.Sprintf("key-%d", id)
+	a.byKey[key] = user
+	a.byId[id] = user
+	return key
+}
+
+func (a *testAccounts) GetUserByID(userId int64) (*model.User, error) {
+	if user, ok := a.byId[userId]; ok {
+		return user, nil
+	}
+	return nil, fmt.Errorf("user %w", repository.ErrNotFound)
+}
+
+func (a *testAccounts) Authenticate(key string) (int64, error) {
+	if user, ok := a.byKey[key]; ok {
+		return user.ID, nil
+	}
+	return 0, model.ErrInvalidToken
+}
+
+// Admins get every permission; a role named "only:<permission>" gets just that one
+type testRoles struct{}
+
+func (testRoles) Permissions(role string) []string {
+	if role == "admin" {
+		return []string{model.PermissionAll}
+	}
+	if permission, ok := strings.CutPrefix(role, "only:"); ok {
+		return []string{permission}
+	}
+	return nil
+}
+
+// Every service client and session is active
+type testActive struct{}
+
+func (testActive) IsActive(string) (bool, error) { return true, nil }
+
+type testSessions struct{}
+
+func (testSessions) IsActive(int64, int64) (bool, error) { return true, nil }
+
+type testActivity struct{}
+
+func (testActivity) RecordUserActivity(int64, time.Time) error { return nil }
+
+// Header set by the fake transaction middleware, so tests can see which routes run in one
+const transactionHeader = "X-Test-Transaction"
+
+// The router main serves, built with in-memory users and a handler without services. Requests that get
+// past the middleware reach a handler that fails (usually panicking on a missing service), which is how
+// the tests tell them apart from requests the middleware rejected.
+type testServer struct {
+	router   *mux.Router
+	table    []route
+	accounts *testAccounts
+	tokens   *auth.TokenProvider
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	accounts := &testAccounts{byKey: make(map[string]*model.User), byId: make(map[int64]*model.User)}
+	tokens := auth.NewTokenProvider(auth.JWTConfig{SecretKey: strings.Repeat("route-test-secret-", 3), ExpirationHours: 1})
+	authMiddleware := middleware.NewAuthMiddleware(tokens, accounts, testActive{}, accounts, testSessions{}, testRoles{})
+	limiter := middleware.NewRateLimiter(1_000_000, time.Minute, false)
+	transaction := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(transactionHeader, "1")
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	h := &handler.Handler{}
+	return &testServer{
+		router:   setupRouter(h, authMiddleware, middleware.NewActivityTracker(testActivity{}), limiter, transaction),
+		table:    routes(h, limiter, transaction),
+		accounts: accounts,
+		tokens:   tokens,
+	}
+}
+
+var pathVariable = regexp.MustCompile(`\{[^}]+\}`)
+
+// A concrete URL for a route's path
+func concretePath(path string) string {
+	return "/api" + pathVariable.ReplaceAllString(path, "1")
+}
+
+// The outcome of one request
+type outcome struct {
+	status int
+	body   string
+	header http.Header
+	// The request got past the middleware to the route's handler
+	reached bool
+}
+
+// Sends a request, authenticated with an API key or bearer token when one is given
+func (s *testServer) do(method, path, apiKey, bearer string) outcome {
+	req := httptest.NewRequest(method, path, nil)
+	if apiKey != "" {
+		req.Header.Set(middleware.APIKeyHeader, apiKey)
+	}
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	rec := httptest.NewRecorder()
+
+	panicked := func() (panicked bool) {
+		defer func() {
+			panicked = recover() != nil
+		}()
+		s.router.ServeHTTP(rec, req)
+		return false
+	}()
+
+	// Middleware rejects with plain-text errors; handlers always answer JSON
+	reached := panicked || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain")
+	return outcome{status: rec.Code, body: rec.Body.String(), header: rec.Header(), reached: reached}
+}
+
+func TestRouteTable(t *testing.T) {
+	s := newTestServer(t)
+	if err := validateRoutes(s.table); err != nil {
+		t.Fatal(err)
+	}
+
+	// The write endpoints users reach with their own account, and who may call them
+	tests := []struct {
+		method string
+		path   string
+		access access
+	}{
+		{"POST", "/register", public},
+		{"POST", "/login", public},
+		{"POST", "/posts", protected},
+		{"PUT", "/posts/{postId}", protected},
+		{"DELETE", "/posts/{postId}", protected},
+		{"POST", "/posts/import/gist", protected},
+		{"POST", "/posts/{postId}/comments", protected},
+		{"PUT", "/comments/{commentId}", protected},
+		{"DELETE", "/comments/{commentId}", protected},
+		{"PUT", "/comments/{commentId}/reactions/{reaction}", protected},
+		{"DELETE", "/comments/{commentId}/reactions/{reaction}", protected},
+		{"POST", "/posts/{postId}/snippets", protected},
+		{"DELETE", "/snippets/{snippetId}", protected},
+		{"PUT", "/subscriptions/boards/{slug}", protected},
+		{"DELETE", "/subscriptions/boards/{slug}", protected},
+		{"PUT", "/subscriptions/tags/{tag}", protected},
+		{"DELETE", "/subscriptions/tags/{tag}", protected},
+		{"PUT", "/profiles/{userId}", protected},
+		{"PUT", "/profiles/me/email", protected},
+		{"PUT", "/profiles/{userId}/skills", protected},
+		{"POST", "/profiles/{userId}/projects", protected},
+		{"PUT", "/profiles/{userId}/projects/{projectId}", protected},
+		{"DELETE", "/profiles/{userId}/projects/{projectId}", protected},
+		{"POST", "/attachments", protected},
+		{"DELETE", "/attachments/{attachmentId}", protected},
+		{"POST", "/reports", protected},
+		{"PUT", "/auth/me/password", protected},
+		{"POST", "/auth/apikeys", protected},
+		{"DELETE", "/auth/sessions/{sessionId}", protected},
+		{"DELETE", "/users/{userId}", protected},
+		{"GET", "/moderation/me", appealable},
+		{"POST", "/appeals", appealable},
+		{"GET", "/admin/users", admin},
+		{"PUT", "/admin/users/{userId}/ban", admin},
+		{"POST", "/admin/moderation/actions", admin},
+		{"POST", "/admin/impersonate/{userId}", admin},
+		{"PUT", "/admin/boards/{slug}", admin},
+		{"DELETE", "/admin/boards/{slug}", admin},
+		{"POST", "/admin/service-clients", admin},
+	}
+
+	registered := make(map[string]access, len(s.table))
+	for _, r := range s.table {
+		registered[r.Method+" "+r.Path] = r.Access
+	}
+	for _, tc := range tests {
+		key := tc.method + " " + tc.path
+		got, ok := registered[key]
+		if !ok {
+			t.Errorf("%s isn't registered", key)
+			continue
+		}
+		if got != tc.access {
+			t.Errorf("%s has %s access, want %s", key, got, tc.access)
+		}
+	}
+}
+
+func TestRoutesMatch(t *testing.T) {
+	s := newTestServer(t)
+
+	for _, r := range s.table {
+		t.Run(r.Method+" "+r.Path, func(t *testing.T) {
+			req := httptest.NewRequest(r.Method, concretePath(r.Path), nil)
+			var match mux.RouteMatch
+			if !s.router.Match(req, &match) || match.MatchErr != nil {
+				t.Fatalf("no route matched %s %s (%v)", req.Method, req.URL.Path, match.MatchErr)
+			}
+
+			template, _ := match.Route.GetPathTemplate()
+			if template != "/api"+r.Path {
+				t.Fatalf("%s %s matched %s", req.Method, req.URL.Path, template)
+			}
+			methods, _ := match.Route.GetMethods()
+			if len(methods) != 1 || methods[0] != r.Method {
+				t.Fatalf("route is registered for %v", methods)
+			}
+		})
+	}
+}
+
+func TestUnknownRoutes(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{"GET", "/api/nope", http.StatusNotFound},
+		{"PATCH", "/api/posts/1", http.StatusMethodNotAllowed},
+		{"POST", "/api/comments/1", http.StatusMethodNotAllowed},
+		{"PUT", "/api/users/1", http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range tests {
+		if got := s.do(tc.method, tc.path, "", ""); got.status != tc.status {
+			t.Errorf("%s %s answered %d, want %d", tc.method, tc.path, got.status, tc.status)
+		}
+	}
+}
+
+func TestRouteMiddleware(t *testing.T) {
+	s := newTestServer(t)
+	member := s.accounts.add("user", false)
+	bannedMember := s.accounts.add("user", true)
+	administrator := s.accounts.add("admin", false)
+	bannedAdmin := s.accounts.add("admin", true)
+
+	for _, r := range s.table {
+		t.Run(r.Method+" "+r.Path, func(t *testing.T) {
+			path := concretePath(r.Path)
+			anonymous := s.do(r.Method, path, "", "")
+
+			switch r.Access {
+			case public:
+				if !anonymous.reached {
+					t.Fatalf("public route rejected an anonymous request: %d %s", anonymous.status, anonymous.body)
+				}
+
+			case protected:
+				// JWTAuth
+				if anonymous.reached || anonymous.status != http.StatusUnauthorized {
+					t.Fatalf("anonymous request got %d %s, want 401", anonymous.status, anonymous.body)
+				}
+				// RejectBanned
+				if got := s.do(r.Method, path, bannedMember, ""); got.reached || !strings.Contains(got.body, "banned") {
+					t.Fatalf("banned user got %d %s, want the ban rejection", got.status, got.body)
+				}
+				if got := s.do(r.Method, path, member, ""); !got.reached {
+					t.Fatalf("member was rejected: %d %s", got.status, got.body)
+				}
+
+			case appealable:
+				if anonymous.reached || anonymous.status != http.StatusUnauthorized {
+					t.Fatalf("anonymous request got %d %s, want 401", anonymous.status, anonymous.body)
+				}
+				// Banned users must still reach their moderation history and appeals
+				if got := s.do(r.Method, path, bannedMember, ""); !got.reached {
+					t.Fatalf("banned user was rejected: %d %s", got.status, got.body)
+				}
+
+			case admin:
+				key := r.Method + " " + r.Path
+				if anonymous.reached || anonymous.status != http.StatusUnauthorized {
+					t.Fatalf("anonymous request got %d %s, want 401", anonymous.status, anonymous.body)
+				}
+
+				// PermissionAuth
+				if got := s.do(r.Method, path, member, ""); got.reached || got.status != http.StatusForbidden {
+					t.Fatalf("member without the permission got %d %s, want 403", got.status, got.body)
+				}
+				if got := s.do(r.Method, path, bannedAdmin, ""); got.reached || !strings.Contains(got.body, "banned") {
+					t.Fatalf("banned admin got %d %s, want the ban rejection", got.status, got.body)
+				}
+				if got := s.do(r.Method, path, administrator, ""); !got.reached {
+					t.Fatalf("admin was rejected: %d %s", got.status, got.body)
+				}
+				if permission, ok := routePermissions[key]; ok {
+					granted := s.accounts.add("only:"+permission, false)
+					if got := s.do(r.Method, path, granted, ""); !got.reached {
+						t.Fatalf("user with %s was rejected: %d %s", permission, got.status, got.body)
+					}
+				}
+
+				// ClientScope: machine tokens only reach routes their scopes allow
+				for _, scope := range model.ServiceScopes {
+					token, err := s.tokens.CreateClientToken("client-"+scope, []string{scope}, time.Minute)
+					if err != nil {
+						t.Fatal(err)
+					}
+					got := s.do(r.Method, path, "", token)
+					if want := clientScopes[key] == scope; got.reached != want {
+						t.Fatalf("client with scope %s: reached=%t (%d %s), want %t", scope, got.reached, got.status, got.body, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestTransactionRoutes(t *testing.T) {
+	s := newTestServer(t)
+	member := s.accounts.add("user", false)
+
+	// Handlers making several writes that must land together
+	want := map[string]bool{
+		"PUT /profiles/{userId}": true,
+		"DELETE /users/{userId}": true,
+	}
+
+	for _, r := range s.table {
+		if r.Access != protected {
+			continue
+		}
+		got := s.do(r.Method, concretePath(r.Path), member, "")
+		key := r.Method + " " + r.Path
+		if inTx := got.header.Get(transactionHeader) != ""; inTx != want[key] {
+			t.Errorf("%s runs in a transaction: %t, want %t", key, inTx, want[key])
+		}
+	}
+}
+
+func TestImpersonationRejectedRoutes(t *testing.T) {
+	s := newTestServer(t)
+	s.accounts.add("admin", false)
+	member := s.accounts.add("user", false)
+	user := s.accounts.byKey[member]
+
+	token, err := s.tokens.CreateImpersonationToken(user.ID, user.Username, user.Role, user.TokenVersion, 1, 1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Account security an admin acting as the user must not touch
+	rejected := []struct {
+		method string
+		path   string
+	}{
+		{"PUT", "/profiles/{userId}"},
+		{"PUT", "/profiles/me/email"},
+		{"PUT", "/auth/me/password"},
+		{"POST", "/auth/apikeys"},
+		{"DELETE", "/auth/sessions/{sessionId}"},
+		{"DELETE", "/users/{userId}"},
+	}
+	for _, tc := range rejected {
+		if got := s.do(tc.method, concretePath(tc.path), "", token); got.reached || got.status != http.StatusForbidden {
+			t.Errorf("impersonated %s %s got %d %s, want 403", tc.method, tc.path, got.status, got.body)
+		}
+	}
+
+	// Other member routes stay open, so support can reproduce issues
+	if got := s.do("POST", concretePath("/posts"), "", token); !got.reached {
+		t.Errorf("impersonated POST /posts was rejected: %d %s", got.status, got.body)
+	}
+}
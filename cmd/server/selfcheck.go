@@ -0,0 +1,105 @@
+package main
+
+import (
+	"byte-board/internal/mail"
+	"byte-board/internal/model"
+	"byte-board/internal/storage"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	database "byte-board/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+// How long each startup check may take
+const startupCheckTimeout = 10 * time.Second
+
+// A dependency checked before the server starts listening. Fix says what to do when the check fails.
+type startupCheck struct {
+	name string
+	run  func() error
+	fix  string
+}
+
+// Runs every check and returns one error listing all failures, so a misconfigured deployment refuses
+// to start with actionable messages instead of failing on its first request
+func runStartupChecks(checks []startupCheck) error {
+	var failures []string
+	for _, check := range checks {
+		if err := check.run(); err != nil {
+			log.Error().Err(err).Str("check", check.name).Msg("Startup check failed")
+			failures = append(failures, fmt.Sprintf("%s: %v (%s)", check.name, err, check.fix))
+			continue
+		}
+		log.Info().Str("check", check.name).Msg("Startup check passed")
+	}
+
+	if len(failures) > 0 {
+		return errors.New("startup checks failed:\n  - " + strings.Join(failures, "\n  - "))
+	}
+	return nil
+}
+
+// The checks for this server's dependencies: database connectivity and schema version, the storage
+// directories, and the SMTP server when email is enabled
+func startupChecks(db *database.DB, stores map[string]storage.Store, mailer mail.Mailer) []startupCheck {
+	checks := []startupCheck{
+		{
+			name: "database",
+			run: func() error {
+				ctx, cancel := context.WithTimeout(context.Background(), startupCheckTimeout)
+				defer cancel()
+				return db.PingContext(ctx)
+			},
+			fix: "check POSTGRES_HOST, POSTGRES_PORT and that the database is running",
+		},
+		{
+			name: "schema",
+			run:  func() error { return checkSchema(db) },
+			fix:  "apply database.sql (see Database Schema in the README)",
+		},
+	}
+
+	for name, store := range stores {
+		checks = append(checks, startupCheck{
+			name: name + " storage",
+			run:  func() error { return storage.Probe(store) },
+			fix:  "make sure the directory exists and is writable by the server",
+		})
+	}
+
+	if smtpMailer, ok := mailer.(*mail.SMTPMailer); ok {
+		checks = append(checks, startupCheck{
+			name: "smtp",
+			run:  func() error { return smtpMailer.Verify(startupCheckTimeout) },
+			fix:  "check SMTP_HOST, SMTP_PORT, SMTP_USERNAME and SMTP_PASSWORD_FILE, or unset SMTP_HOST to log emails instead",
+		})
+	}
+
+	return checks
+}
+
+// Fails when the database wasn't built from a database.sql this build understands. A newer schema
+// is only logged, since the server usually still works against it.
+func checkSchema(db *database.DB) error {
+	status, version, err := db.GetMigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	switch status {
+	case model.MigrationUpToDate:
+		return nil
+	case model.MigrationAhead:
+		log.Warn().Int("schema_version", *version).Int("expected", database.SchemaVersion).Msg("Database schema is newer than this build")
+		return nil
+	case model.MigrationPending:
+		return fmt.Errorf("database schema version %d is older than the %d this build expects", *version, database.SchemaVersion)
+	}
+
+	return errors.New("database has no schema_version, so it wasn't built from database.sql")
+}
@@ -9,10 +9,82 @@
 -- ----------------------------------------------------------------------
 
 -- Drop tables if they exist
+DROP TABLE IF EXISTS role_permissions CASCADE;
+DROP TABLE IF EXISTS roles CASCADE;
+DROP TABLE IF EXISTS sessions CASCADE;
+DROP TABLE IF EXISTS api_keys CASCADE;
+DROP TABLE IF EXISTS skill_rules CASCADE;
+DROP TABLE IF EXISTS password_resets CASCADE;
+DROP TABLE IF EXISTS refresh_tokens CASCADE;
+DROP TABLE IF EXISTS post_edit_locks CASCADE;
+DROP TABLE IF EXISTS keyword_alerts CASCADE;
+DROP TABLE IF EXISTS data_access_log CASCADE;
+DROP TABLE IF EXISTS schema_version CASCADE;
+
+DROP TABLE IF EXISTS attachment_blobs CASCADE;
+
+DROP TABLE IF EXISTS service_clients CASCADE;
+
+DROP TABLE IF EXISTS post_translations CASCADE;
+
+DROP TABLE IF EXISTS backups CASCADE;
+
+DROP TABLE IF EXISTS announcement_dismissals CASCADE;
+
+DROP TABLE IF EXISTS announcements CASCADE;
+
+DROP TABLE IF EXISTS comment_reactions CASCADE;
+
+DROP TABLE IF EXISTS tag_subscriptions CASCADE;
+
+DROP TABLE IF EXISTS board_subscriptions CASCADE;
+
+DROP TABLE IF EXISTS post_watches CASCADE;
+
+DROP TABLE IF EXISTS events CASCADE;
+
+DROP TABLE IF EXISTS follows CASCADE;
+
+DROP TABLE IF EXISTS attachments CASCADE;
+
+DROP TABLE IF EXISTS user_activity CASCADE;
+
+DROP TABLE IF EXISTS notifications CASCADE;
+
+DROP TABLE IF EXISTS notification_settings CASCADE;
+
+DROP TABLE IF EXISTS digest_subscriptions CASCADE;
+
+DROP TABLE IF EXISTS signups CASCADE;
+
+DROP TABLE IF EXISTS email_history CASCADE;
+
+DROP TABLE IF EXISTS email_change_requests CASCADE;
+
+DROP TABLE IF EXISTS word_filters CASCADE;
+
+DROP TABLE IF EXISTS appeals CASCADE;
+
+DROP TABLE IF EXISTS moderation_actions CASCADE;
+
+DROP TABLE IF EXISTS reports CASCADE;
+
+DROP TABLE IF EXISTS report_reasons CASCADE;
+
+DROP TABLE IF EXISTS projects CASCADE;
+
+DROP TABLE IF EXISTS profile_skills CASCADE;
+
+DROP TABLE IF EXISTS skills CASCADE;
+
+DROP TABLE IF EXISTS snippets CASCADE;
+
 DROP TABLE IF EXISTS comments CASCADE;
 
 DROP TABLE IF EXISTS posts CASCADE;
 
+DROP TABLE IF EXISTS boards CASCADE;
+
 DROP TABLE IF EXISTS profiles CASCADE;
 
 DROP TABLE IF EXISTS users CASCADE;
@@ -22,17 +94,41 @@ DROP TABLE IF EXISTS users CASCADE;
 -- ----------------------------------------------------------------------
 
 -- Creating tables
+-- Roles users can hold; each grants the permissions listed in role_permissions
+CREATE TABLE roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Permissions a role grants ('*' grants every permission)
+CREATE TABLE role_permissions (
+    role VARCHAR(50) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(50) NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
 CREATE TABLE users (
-    user_id SERIAL PRIMARY KEY,
+    user_id BIGSERIAL PRIMARY KEY,
     username VARCHAR(50) NOT NULL UNIQUE,
     hashed_password VARCHAR(255) NOT NULL,
-    role VARCHAR(50) NOT NULL,
+    role VARCHAR(50) NOT NULL REFERENCES roles(name),
     first_name VARCHAR(50), -- ADD THIS
-    last_name VARCHAR(50) -- ADD THIS
+    last_name VARCHAR(50), -- ADD THIS
+    -- Set by moderation: bans and suspensions (a ban with a duration), and the unban that ends them
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended', 'banned')),
+    -- When a suspension lifts; NULL for permanent bans
+    banned_until TIMESTAMPTZ,
+    shadowbanned BOOLEAN NOT NULL DEFAULT FALSE,
+    last_active_at TIMESTAMPTZ,
+    -- Bumped on password changes and bans; tokens carrying an older version are rejected
+    token_version INTEGER NOT NULL DEFAULT 0,
+    -- Total size of the user's attachments (kept in step with the attachments table)
+    storage_used_bytes BIGINT NOT NULL DEFAULT 0
 );
 
 CREATE TABLE profiles (
-    user_id INTEGER PRIMARY KEY,
+    user_id BIGINT PRIMARY KEY,
     first_name VARCHAR(50),
     last_name VARCHAR(50),
     email VARCHAR(200),
@@ -40,35 +136,664 @@ CREATE TABLE profiles (
     city VARCHAR(50),
     state VARCHAR(50),
     date_registered DATE,
+    display_name VARCHAR(50),
     FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
 );
 
+-- Boards posts are filed under, managed by admins
+CREATE TABLE boards (
+    board_id BIGSERIAL PRIMARY KEY,
+    slug VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE TABLE posts (
-    post_id SERIAL PRIMARY KEY,
-    user_id INTEGER NOT NULL,
+    post_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
     title VARCHAR(255) NOT NULL,
     content TEXT NOT NULL,
     author VARCHAR(50) NOT NULL,
-    date_posted TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+    date_posted TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    locked BOOLEAN NOT NULL DEFAULT FALSE,
+    -- Who can read the post: public, members (signed in), followers (of the author) or private (author only)
+    visibility VARCHAR(20) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'members', 'followers', 'private')),
+    -- BCP 47 language tag ('und' when unknown), declared by the author or detected from the text
+    language VARCHAR(35) NOT NULL DEFAULT 'und',
+    language_source VARCHAR(10) NOT NULL DEFAULT 'detected' CHECK (language_source IN ('declared', 'detected')),
+    -- Plain-text opening of the content and estimated minutes to read it, computed when the content is saved
+    excerpt TEXT NOT NULL DEFAULT '',
+    reading_minutes INTEGER NOT NULL DEFAULT 1,
+    -- Comments everyone can see (not hidden, not by shadowbanned users)
+    comment_count INTEGER NOT NULL DEFAULT 0,
+    -- When the post was made or last got a publicly visible comment
+    last_activity_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- When the post was queued for delivery to the author's fediverse followers (NULL until then)
+    federated_at TIMESTAMPTZ,
+    -- The board the post is filed under (NULL when none, or once the board is deleted) and its tags
+    board_id BIGINT,
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (board_id) REFERENCES boards (board_id) ON DELETE SET NULL
 );
 
 CREATE TABLE comments (
-    comment_id SERIAL PRIMARY KEY,
-    user_id INTEGER NOT NULL,
-    post_id INTEGER NOT NULL,
+    comment_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    post_id BIGINT NOT NULL,
     content TEXT NOT NULL,
     author VARCHAR(50) NOT NULL,
-    date_posted TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    date_posted TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts (post_id) ON DELETE CASCADE
+);
+
+CREATE TABLE skills (
+    skill_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(30) NOT NULL UNIQUE
+);
+
+CREATE TABLE profile_skills (
+    user_id BIGINT NOT NULL,
+    skill_id BIGINT NOT NULL,
+    PRIMARY KEY (user_id, skill_id),
+    FOREIGN KEY (user_id) REFERENCES profiles (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (skill_id) REFERENCES skills (skill_id) ON DELETE CASCADE
+);
+
+-- Admin rules for skill tags: a synonym is saved as its canonical skill instead, a blocked tag is rejected.
+-- Tags named here are migrated off existing profiles by a background job.
+CREATE TABLE skill_rules (
+    name VARCHAR(30) PRIMARY KEY,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('synonym', 'block')),
+    canonical VARCHAR(30),
+    created_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((action = 'synonym') = (canonical IS NOT NULL)),
+    FOREIGN KEY (created_by) REFERENCES users (user_id) ON DELETE SET NULL
+);
+
+CREATE TABLE projects (
+    project_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    repo_url VARCHAR(500) NOT NULL DEFAULT '',
+    screenshot_url VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES profiles (user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE snippets (
+    snippet_id BIGSERIAL PRIMARY KEY,
+    post_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    language VARCHAR(50) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (post_id) REFERENCES posts (post_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE report_reasons (
+    code VARCHAR(50) PRIMARY KEY,
+    label VARCHAR(100) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+-- target_id points at posts or comments depending on target_type, so it has no foreign key
+CREATE TABLE reports (
+    report_id BIGSERIAL PRIMARY KEY,
+    reporter_id BIGINT, -- NULL for reports filed automatically (e.g. by the word filter)
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('post', 'comment')),
+    target_id BIGINT NOT NULL,
+    reason_code VARCHAR(50) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'reviewing', 'actioned', 'dismissed')),
+    assigned_to BIGINT,
+    resolution_note TEXT NOT NULL DEFAULT '',
+    -- Who moved the report to actioned or dismissed, and when (cleared if it's reopened)
+    resolved_by BIGINT,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (reporter_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (reason_code) REFERENCES report_reasons (code) ON UPDATE CASCADE,
+    FOREIGN KEY (assigned_to) REFERENCES users (user_id) ON DELETE SET NULL,
+    FOREIGN KEY (resolved_by) REFERENCES users (user_id) ON DELETE SET NULL
+);
+
+-- Audit trail of moderation actions (target_id points at a post, comment or user depending on target_type)
+CREATE TABLE moderation_actions (
+    action_id BIGSERIAL PRIMARY KEY,
+    actor_id BIGINT,
+    action VARCHAR(20) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id BIGINT NOT NULL,
+    target_user_id BIGINT NOT NULL,
+    reason TEXT NOT NULL,
+    report_id BIGINT,
+    -- When a suspension ends (NULL for every other action)
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (actor_id) REFERENCES users (user_id) ON DELETE SET NULL,
+    FOREIGN KEY (target_user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (report_id) REFERENCES reports (report_id) ON DELETE SET NULL
+);
+
+CREATE TABLE appeals (
+    appeal_id BIGSERIAL PRIMARY KEY,
+    action_id BIGINT NOT NULL UNIQUE,
+    user_id BIGINT NOT NULL,
+    message TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'upheld', 'overturned')),
+    resolved_by BIGINT,
+    resolution_note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMPTZ,
+    FOREIGN KEY (action_id) REFERENCES moderation_actions (action_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (resolved_by) REFERENCES users (user_id) ON DELETE SET NULL
+);
+
+-- Admin-managed banned words/patterns checked when posts and comments are written
+CREATE TABLE word_filters (
+    filter_id BIGSERIAL PRIMARY KEY,
+    pattern VARCHAR(255) NOT NULL,
+    is_regex BOOLEAN NOT NULL DEFAULT FALSE,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('block', 'flag', 'replace')),
+    replacement VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE email_change_requests (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    new_email VARCHAR(200) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Emailed password reset tokens (SHA-256 only); each works once and only until it expires
+CREATE TABLE password_resets (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE email_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    email VARCHAR(200) NOT NULL,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Registrations, kept for signup throttling (rows outlive deleted accounts)
+CREATE TABLE signups (
+    signup_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT,
+    ip_address VARCHAR(45) NOT NULL,
+    subnet VARCHAR(50) NOT NULL,
+    email VARCHAR(200),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE SET NULL
+);
+
+-- Top posts digest preferences (users without a row don't get digests)
+CREATE TABLE digest_subscriptions (
+    user_id BIGINT PRIMARY KEY,
+    frequency VARCHAR(10) NOT NULL CHECK (frequency IN ('off', 'daily', 'weekly')),
+    last_sent_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Per-event notification channel choices (missing rows use the defaults in the notification service)
+CREATE TABLE notification_settings (
+    user_id BIGINT NOT NULL,
+    event_type VARCHAR(20) NOT NULL,
+    email BOOLEAN NOT NULL,
+    in_app BOOLEAN NOT NULL,
+    PRIMARY KEY (user_id, event_type),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- In-app notifications
+CREATE TABLE notifications (
+    notification_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    event_type VARCHAR(20) NOT NULL,
+    message VARCHAR(500) NOT NULL,
+    link VARCHAR(255) NOT NULL DEFAULT '',
+    read_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Days each user made an authenticated request (for DAU/WAU/MAU)
+CREATE TABLE user_activity (
+    day DATE NOT NULL,
+    user_id BIGINT NOT NULL,
+    PRIMARY KEY (day, user_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Attachment contents, stored once however many attachments share them (keyed by the SHA-256 of the contents).
+-- Blobs nothing references any more are garbage collected once they've been unreferenced for a while.
+CREATE TABLE attachment_blobs (
+    content_hash CHAR(64) PRIMARY KEY,
+    size_bytes BIGINT NOT NULL,
+    ref_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    unreferenced_at TIMESTAMPTZ
+);
+
+-- Uploaded files (contents live in the attachment store under storage_key)
+CREATE TABLE attachments (
+    attachment_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    -- Content hash of the blob holding the file (shared by identical uploads)
+    storage_key CHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- When a post or comment first linked the attachment (uploads never linked are deleted after a while)
+    linked_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (storage_key) REFERENCES attachment_blobs (content_hash)
+);
+
+-- Who follows whom (followers can read an author's followers-only posts)
+CREATE TABLE follows (
+    follower_id BIGINT NOT NULL,
+    followee_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id),
+    FOREIGN KEY (follower_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (followee_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Users watching a post for new comments (muted rows opt out, including an author's default watch)
+CREATE TABLE post_watches (
+    user_id BIGINT NOT NULL,
+    post_id BIGINT NOT NULL,
+    muted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, post_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (post_id) REFERENCES posts (post_id) ON DELETE CASCADE
+);
+
+-- Reactions members left on comments (one of each kind per member and comment). The key leads with
+-- comment_id, so a post's comments get their counts from one grouped index scan.
+CREATE TABLE comment_reactions (
+    comment_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    reaction VARCHAR(20) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (comment_id, user_id, reaction),
+    FOREIGN KEY (comment_id) REFERENCES comments (comment_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Boards and tags members follow; their posts make up the subscriptions feed
+CREATE TABLE board_subscriptions (
+    user_id BIGINT NOT NULL,
+    board_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, board_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (board_id) REFERENCES boards (board_id) ON DELETE CASCADE
+);
+
+CREATE TABLE tag_subscriptions (
+    user_id BIGINT NOT NULL,
+    tag VARCHAR(30) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, tag),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Site-wide banners posted by admins (shown between starts_at and expires_at)
+CREATE TABLE announcements (
+    announcement_id BIGSERIAL PRIMARY KEY,
+    title VARCHAR(200) NOT NULL,
+    message TEXT NOT NULL,
+    level VARCHAR(20) NOT NULL DEFAULT 'info' CHECK (level IN ('info', 'warning', 'critical')),
+    starts_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ,
+    created_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (created_by) REFERENCES users (user_id) ON DELETE SET NULL
+);
+
+-- Announcements each user has closed (they stop being returned to that user)
+CREATE TABLE announcement_dismissals (
+    user_id BIGINT NOT NULL,
+    announcement_id BIGINT NOT NULL,
+    dismissed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, announcement_id),
     FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (announcement_id) REFERENCES announcements (announcement_id) ON DELETE CASCADE
+);
+
+-- Logical database backups (pg_dump archives in backup storage)
+CREATE TABLE backups (
+    backup_id BIGSERIAL PRIMARY KEY,
+    storage_key VARCHAR(200) NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('running', 'succeeded', 'failed', 'pruned')),
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    triggered_by BIGINT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMPTZ,
+    FOREIGN KEY (triggered_by) REFERENCES users (user_id) ON DELETE SET NULL
+);
+
+-- Machine translations of posts, cached per target language. source_hash is the hash of the title and
+-- content they were made from, so edited posts get translated again
+CREATE TABLE post_translations (
+    post_id BIGINT NOT NULL,
+    language VARCHAR(35) NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    source_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, language),
     FOREIGN KEY (post_id) REFERENCES posts (post_id) ON DELETE CASCADE
 );
 
+-- Machine clients (e.g. internal cron services) that get scoped tokens through the client credentials grant.
+-- Only a SHA-256 hash of the secret is kept
+CREATE TABLE service_clients (
+    client_id VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    secret_hash CHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    FOREIGN KEY (created_by) REFERENCES users (user_id) ON DELETE SET NULL
+);
+
+-- Long-lived keys users give their scripts and services, sent in the X-API-Key header instead of a JWT.
+-- Only a SHA-256 hash of the key is kept; the prefix identifies it in listings
+CREATE TABLE api_keys (
+    key_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Version of this schema file the database was built from (see repository.SchemaVersion; bump both together)
+CREATE TABLE schema_version (
+    version INT PRIMARY KEY,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Keywords users want to hear about when they show up in new posts. The matcher has scanned posts up to
+-- last_post_id; matches found while an alert is cooling down are sent together once it's due again
+CREATE TABLE keyword_alerts (
+    alert_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    keyword VARCHAR(50) NOT NULL,
+    last_post_id BIGINT NOT NULL DEFAULT 0,
+    last_notified_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, keyword),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Long-lived tokens exchanged for new access tokens at POST /api/auth/refresh. Only the SHA-256 of each
+-- token is stored. Every use rotates it: the old row is marked used and a new one joins the same family,
+-- so a used token turning up again means it leaked and the whole family is revoked
+CREATE TABLE refresh_tokens (
+    token_hash CHAR(64) PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    family_id CHAR(32) NOT NULL,
+    -- The user's token version when issued; a password change or ban makes the token unusable
+    token_version INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Signed-in devices: one per login, covering the refresh token family it started and the JWTs issued
+-- from it (which carry the session ID as sid and stop working once the session is revoked)
+CREATE TABLE sessions (
+    session_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    family_id CHAR(32) NOT NULL UNIQUE,
+    -- The user's token version at login; a password change or ban ends the session
+    token_version INTEGER NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- When its latest refresh token expires (extended on every refresh)
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    -- The admin acting as the user, for short-lived impersonation sessions (no refresh token)
+    impersonator_id BIGINT,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (impersonator_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Advisory locks taken while a post is being edited, so a second editing session gets a clear conflict
+-- instead of overwriting the first. Only the SHA-256 of the lock token is stored; an expired lock is
+-- simply replaced by the next editor
+CREATE TABLE post_edit_locks (
+    post_id BIGINT PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    acquired_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES posts (post_id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Which admins viewed which user's personal data, for privacy compliance. Not foreign keys, so entries
+-- outlive the accounts involved
+CREATE TABLE data_access_log (
+    access_id BIGSERIAL PRIMARY KEY,
+    -- Whose data was viewed (NULL for entries covering no one user)
+    user_id BIGINT,
+    viewer_id BIGINT NOT NULL,
+    -- What was viewed: user, email_history, moderation_history, report_export or impersonation
+    access VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Append-only log of domain events (who did what to which record), for support investigations
+CREATE TABLE events (
+    event_id BIGSERIAL PRIMARY KEY,
+    event_type VARCHAR(50) NOT NULL,
+    -- The user whose request caused the event (NULL for system events). Deliberately not a foreign key,
+    -- so the log keeps pointing at deleted accounts
+    actor_id BIGINT,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_id BIGINT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Fediverse accounts (Mastodon and other ActivityPub servers) following local authors
+CREATE TABLE federation_followers (
+    user_id BIGINT NOT NULL,
+    -- The remote actor's ActivityPub ID (a URL)
+    actor_id VARCHAR(500) NOT NULL,
+    inbox_url VARCHAR(500) NOT NULL,
+    -- The remote server's inbox for all its users, so each post goes there once (NULL when it has none)
+    shared_inbox_url VARCHAR(500),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, actor_id),
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
+-- Signed activities waiting to be delivered to remote inboxes, retried with backoff
+CREATE TABLE federation_deliveries (
+    delivery_id BIGSERIAL PRIMARY KEY,
+    -- The local author the activity is from (its signature is theirs)
+    user_id BIGINT NOT NULL,
+    inbox_url VARCHAR(500) NOT NULL,
+    activity JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
+
 -- Create indexes for better query performance
-CREATE INDEX idx_posts_user_id ON posts (user_id);
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE UNIQUE INDEX idx_users_username_lower ON users (LOWER(username));
+
+-- Prefix indexes for @mention autocomplete (LIKE 'abc%' can't use the default collation indexes)
+CREATE INDEX idx_users_username_prefix ON users (LOWER(username) text_pattern_ops);
+
+-- Also backs the per-account posting quota (recent posts by a user)
+CREATE INDEX idx_posts_user_id ON posts (user_id, date_posted);
 
 CREATE INDEX idx_posts_date_posted ON posts (date_posted);
 
+CREATE INDEX idx_posts_last_activity_at ON posts (last_activity_at);
+
+CREATE INDEX idx_posts_language ON posts (language);
+
+-- Back the two halves of the subscriptions feed (a board's posts, and posts carrying any of a set of tags)
+CREATE INDEX idx_posts_board_id ON posts (board_id, date_posted);
+
+CREATE INDEX idx_posts_tags ON posts USING GIN (tags);
+
+-- Prefix index for the search box typeahead (post titles)
+CREATE INDEX idx_posts_title_prefix ON posts (LOWER(title) text_pattern_ops);
+
+-- Trigram indexes back the ILIKE post search (rebuilt by the admin reindex job)
+CREATE INDEX idx_posts_title_trgm ON posts USING GIN (title gin_trgm_ops);
+
+CREATE INDEX idx_posts_content_trgm ON posts USING GIN (content gin_trgm_ops);
+
 CREATE INDEX idx_comments_post_id ON comments (post_id);
 
-CREATE INDEX idx_comments_user_id ON comments (user_id);
\ No newline at end of file
+CREATE INDEX idx_comments_user_id ON comments (user_id, date_posted);
+
+CREATE INDEX idx_comments_date_posted ON comments (date_posted);
+
+CREATE INDEX idx_profiles_date_registered ON profiles (date_registered);
+CREATE UNIQUE INDEX idx_profiles_display_name_lower ON profiles (LOWER(display_name));
+CREATE INDEX idx_profiles_display_name_prefix ON profiles (LOWER(display_name) text_pattern_ops);
+
+CREATE INDEX idx_email_change_requests_user_id ON email_change_requests (user_id);
+CREATE INDEX idx_password_resets_user_id ON password_resets (user_id, created_at);
+CREATE INDEX idx_profiles_email_lower ON profiles (LOWER(email));
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens (expires_at);
+CREATE INDEX idx_sessions_user_id ON sessions (user_id, last_seen_at);
+CREATE INDEX idx_sessions_expires_at ON sessions (expires_at);
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id, created_at);
+
+CREATE INDEX idx_email_history_user_id ON email_history (user_id);
+
+CREATE INDEX idx_snippets_post_id ON snippets (post_id);
+
+CREATE INDEX idx_profile_skills_skill_id ON profile_skills (skill_id);
+
+-- Prefix index for the search box typeahead (skill tags)
+CREATE INDEX idx_skills_name_prefix ON skills (LOWER(name) text_pattern_ops);
+
+CREATE INDEX idx_projects_user_id ON projects (user_id);
+
+CREATE INDEX idx_reports_status ON reports (status);
+
+CREATE INDEX idx_reports_target ON reports (target_type, target_id);
+
+CREATE INDEX idx_moderation_actions_target_user_id ON moderation_actions (target_user_id);
+
+CREATE INDEX idx_appeals_status ON appeals (status);
+
+CREATE INDEX idx_notifications_user_id ON notifications (user_id, created_at);
+
+-- Keeps the unread count polled by the notification bell cheap
+CREATE INDEX idx_notifications_unread ON notifications (user_id) WHERE read_at IS NULL;
+
+CREATE INDEX idx_user_activity_user_id ON user_activity (user_id);
+
+CREATE INDEX idx_signups_ip_address ON signups (ip_address, created_at);
+
+CREATE INDEX idx_signups_subnet ON signups (subnet, created_at);
+
+CREATE INDEX idx_signups_email ON signups (email, created_at);
+
+-- One open report per user per piece of content
+CREATE UNIQUE INDEX idx_reports_open_per_reporter ON reports (reporter_id, target_type, target_id)
+    WHERE status IN ('open', 'reviewing');
+
+CREATE INDEX idx_attachments_user_id ON attachments (user_id, created_at);
+CREATE INDEX idx_attachments_storage_key ON attachments (storage_key);
+CREATE INDEX idx_attachments_unlinked ON attachments (created_at) WHERE linked_at IS NULL;
+CREATE INDEX idx_attachment_blobs_unreferenced ON attachment_blobs (unreferenced_at) WHERE ref_count = 0;
+
+CREATE INDEX idx_follows_followee_id ON follows (followee_id);
+CREATE INDEX idx_post_watches_post_id ON post_watches (post_id);
+CREATE INDEX idx_announcements_active ON announcements (starts_at, expires_at);
+CREATE INDEX idx_backups_started_at ON backups (started_at);
+
+CREATE INDEX idx_federation_deliveries_due ON federation_deliveries (next_attempt_at);
+
+CREATE INDEX idx_events_type ON events (event_type, event_id);
+
+CREATE INDEX idx_events_actor_id ON events (actor_id, event_id);
+
+CREATE INDEX idx_events_created_at ON events (created_at);
+
+CREATE INDEX idx_data_access_log_user_id ON data_access_log (user_id, access_id);
+
+CREATE INDEX idx_data_access_log_viewer_id ON data_access_log (viewer_id, access_id);
+
+-- ----------------------------------------------------------------------
+-- Seed data
+-- ----------------------------------------------------------------------
+
+-- Schema file version (matches repository.SchemaVersion)
+INSERT INTO schema_version (version) VALUES (4);
+
+-- Built-in roles (admins can add roles granting narrower permissions)
+INSERT INTO roles (name, description) VALUES
+    ('user', 'Regular member'),
+    ('admin', 'Full access');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', '*');
+
+-- Default report reason taxonomy (admins can add or retire reasons)
+INSERT INTO report_reasons (code, label) VALUES
+    ('spam', 'Spam or advertising'),
+    ('harassment', 'Harassment or hate speech'),
+    ('off_topic', 'Off-topic'),
+    ('plagiarism', 'Plagiarized or stolen code'),
+    ('malware', 'Malicious code or links'),
+    ('other', 'Other');
+
+-- Used for reports filed automatically by the word filter (inactive so users can't pick it)
+INSERT INTO report_reasons (code, label, active) VALUES
+    ('word_filter', 'Matched a word filter rule', FALSE);
@@ -0,0 +1,24 @@
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// Static files for the admin UI
+//
+//go:embed static
+var staticFiles embed.FS
+
+// Returns a handler serving the embedded admin UI.
+// The page itself is public; every API call it makes requires an admin JWT.
+func Handler() http.Handler {
+	static, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		// The embedded directory is fixed at compile time
+		panic(err)
+	}
+
+	return http.FileServer(http.FS(static))
+}
@@ -0,0 +1,138 @@
+'use strict';
+
+const TOKEN_KEY = 'byteboard-admin-token';
+
+// Call the API with the stored admin token
+async function api(method, path, body) {
+  const headers = { 'Accept': 'application/json' };
+  const token = sessionStorage.getItem(TOKEN_KEY);
+  if (token) headers['Authorization'] = 'Bearer ' + token;
+  if (body !== undefined) headers['Content-Type'] = 'application/json';
+
+  const resp = await fetch(path, { method, headers, body: body === undefined ? undefined : JSON.stringify(body) });
+  const text = await resp.text();
+  let data = null;
+  try { data = text ? JSON.parse(text) : null; } catch (e) { data = text; }
+
+  if (resp.status === 401) {
+    logout();
+  }
+  if (!resp.ok) {
+    throw new Error((data && data.error) || text || resp.statusText);
+  }
+  return data;
+}
+
+function show(view) {
+  document.getElementById('login-view').hidden = view !== 'login';
+  document.getElementById('admin-view').hidden = view !== 'admin';
+  document.getElementById('logout').hidden = view !== 'admin';
+}
+
+function logout() {
+  sessionStorage.removeItem(TOKEN_KEY);
+  document.getElementById('whoami').textContent = '';
+  show('login');
+}
+
+async function login(event) {
+  event.preventDefault();
+  const form = event.target;
+  const error = document.getElementById('login-error');
+  error.textContent = '';
+
+  try {
+    const resp = await api('POST', '/api/login', {
+      username: form.username.value,
+      password: form.password.value,
+    });
+    if (resp.user.role !== 'admin') {
+      error.textContent = 'This account is not an administrator.';
+      return;
+    }
+    sessionStorage.setItem(TOKEN_KEY, resp.token);
+    form.reset();
+    await loadAdmin();
+  } catch (e) {
+    error.textContent = e.message;
+  }
+}
+
+async function loadAdmin() {
+  const error = document.getElementById('admin-error');
+  error.textContent = '';
+
+  try {
+    const me = await api('GET', '/api/auth/me');
+    if (me.user.role !== 'admin') {
+      logout();
+      return;
+    }
+    document.getElementById('whoami').textContent = me.user.username;
+    show('admin');
+
+    const [users, posts, comments] = await Promise.all([
+      api('GET', '/api/admin/users'),
+      api('GET', '/api/posts'),
+      api('GET', '/api/comments'),
+    ]);
+    renderStats({ Users: (users || []).length, Posts: (posts || []).length, Comments: (comments || []).length });
+    renderUsers(users || []);
+  } catch (e) {
+    error.textContent = e.message;
+  }
+}
+
+function renderStats(stats) {
+  const container = document.getElementById('stats');
+  container.replaceChildren();
+  for (const [label, value] of Object.entries(stats)) {
+    const stat = document.createElement('div');
+    stat.className = 'stat';
+    const strong = document.createElement('strong');
+    strong.textContent = value;
+    stat.append(strong, label);
+    container.append(stat);
+  }
+}
+
+function renderUsers(users) {
+  const tbody = document.getElementById('users');
+  tbody.replaceChildren();
+  for (const user of users) {
+    const row = document.createElement('tr');
+    for (const value of [user.user_id, user.username, (user.first_name || '') + ' ' + (user.last_name || ''), user.role]) {
+      const cell = document.createElement('td');
+      cell.textContent = value;
+      row.append(cell);
+    }
+
+    const actions = document.createElement('td');
+    const del = document.createElement('button');
+    del.textContent = 'Delete';
+    del.addEventListener('click', () => deleteUser(user));
+    actions.append(del);
+    row.append(actions);
+
+    tbody.append(row);
+  }
+}
+
+async function deleteUser(user) {
+  if (!confirm('Delete ' + user.username + ' and all of their posts and comments?')) return;
+  try {
+    await api('DELETE', '/api/users/' + user.user_id);
+    await loadAdmin();
+  } catch (e) {
+    document.getElementById('admin-error').textContent = e.message;
+  }
+}
+
+document.getElementById('login-form').addEventListener('submit', login);
+document.getElementById('logout').addEventListener('click', logout);
+
+if (sessionStorage.getItem(TOKEN_KEY)) {
+  loadAdmin();
+} else {
+  show('login');
+}
@@ -1,7 +1,10 @@
 package appconfig
 
 import (
+	"byte-board/internal/auth"
 	"fmt"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,6 +17,21 @@ import (
 type Config struct {
 	// Server configuration
 	Port string `env:"PORT" envDefault:"8080"`
+	// Start with all mutating endpoints disabled (can be toggled at runtime by admins)
+	ReadOnly bool `env:"READ_ONLY" envDefault:"false"`
+
+	// Listener tuning (timeouts in seconds; 0 means no timeout). Keep the idle timeout above the
+	// proxy's upstream keep-alive timeout so the proxy never reuses a connection we just closed.
+	HTTPReadTimeoutSeconds       int  `env:"HTTP_READ_TIMEOUT_SECONDS" envDefault:"15"`
+	HTTPReadHeaderTimeoutSeconds int  `env:"HTTP_READ_HEADER_TIMEOUT_SECONDS" envDefault:"5"`
+	HTTPWriteTimeoutSeconds      int  `env:"HTTP_WRITE_TIMEOUT_SECONDS" envDefault:"15"`
+	HTTPIdleTimeoutSeconds       int  `env:"HTTP_IDLE_TIMEOUT_SECONDS" envDefault:"60"`
+	HTTPMaxHeaderBytes           int  `env:"HTTP_MAX_HEADER_BYTES" envDefault:"1048576"`
+	HTTPKeepAlives               bool `env:"HTTP_KEEP_ALIVES" envDefault:"true"`
+	// Accept HTTP/2 without TLS (h2c with prior knowledge), for proxies that speak HTTP/2 to their upstreams
+	HTTPH2C bool `env:"HTTP_H2C" envDefault:"false"`
+	// Concurrent streams per HTTP/2 connection (0 uses Go's default of 250)
+	HTTP2MaxConcurrentStreams int `env:"HTTP2_MAX_CONCURRENT_STREAMS" envDefault:"0"`
 
 	// Database Configuration
 	PostgresHost         string `env:"POSTGRES_HOST"`
@@ -23,15 +41,167 @@ type Config struct {
 	PostgresPasswordFile string `env:"POSTGRES_PASSWORD_FILE"`
 	// PostgresPassword string `env:"POSTGRES_PASSWORD_FILE"`
 	PostgresSSLMode string `env:"POSTGRES_SSL_MODE"`
+	// Connection pool size (0 for no limit; /readyz?verbose=1 reports saturation against it)
+	DBMaxOpenConns int `env:"DB_MAX_OPEN_CONNS" envDefault:"25"`
+	DBMaxIdleConns int `env:"DB_MAX_IDLE_CONNS" envDefault:"10"`
+	// Most rows any listing query returns, whatever limit it asks for, so no response can dump a whole table
+	MaxResultRows int `env:"MAX_RESULT_ROWS" envDefault:"1000"`
 
 	FrontendURL string `env:"FRONTEND_URL"`
 
+	// Public base URL of this service, used to build links in emails
+	PublicURL string `env:"PUBLIC_URL" envDefault:"http://localhost:8080"`
+	// Frontend page password reset emails link to, with the token appended as ?token= (empty sends the bare token)
+	PasswordResetURL string `env:"PASSWORD_RESET_URL"`
+
 	// JWT Configuration
 	JWTSecret          string `env:"JWT_SECRET,required"`
 	JWTExpirationHours int    `env:"JWT_EXPIRATION_HOURS" envDefault:"30"`
+	// How long a refresh token from login can be exchanged for new tokens at POST /api/auth/refresh
+	JWTRefreshExpirationDays int `env:"JWT_REFRESH_EXPIRATION_DAYS" envDefault:"30"`
+	// iss/aud claims; give each deployment its own so tokens can't be replayed across them (empty skips the check)
+	JWTIssuer   string `env:"JWT_ISSUER"`
+	JWTAudience string `env:"JWT_AUDIENCE"`
+	// PEM RSA or Ed25519 private key to sign tokens with (RS256/EdDSA) instead of JWT_SECRET; its public key is
+	// published at /.well-known/jwks.json. Relative paths resolve against SECRETS_PATH.
+	JWTSigningKeyFile string `env:"JWT_SIGNING_KEY_FILE"`
+	// Comma-separated secrets and key files that were rotated out: tokens they signed are still accepted
+	// (and the keys stay in the JWKS) until they're removed, which is safe JWT_EXPIRATION_HOURS after the rotation
+	JWTPreviousSecrets         string `env:"JWT_PREVIOUS_SECRETS"`
+	JWTPreviousSigningKeyFiles string `env:"JWT_PREVIOUS_SIGNING_KEY_FILES"`
+	// Lifetime of machine tokens issued to service clients by POST /api/auth/token
+	ServiceTokenMinutes int `env:"SERVICE_TOKEN_MINUTES" envDefault:"60"`
+	// Lifetime of the tokens admins get from POST /api/admin/impersonate/{userId}
+	ImpersonationTokenMinutes int `env:"IMPERSONATION_TOKEN_MINUTES" envDefault:"15"`
+
+	// Posting Configuration
+	DuplicatePostWindowMinutes int `env:"DUPLICATE_POST_WINDOW_MINUTES" envDefault:"10"`
+	// Per-account content quotas by role, per hour (0 disables a limit)
+	PostsPerHour         int `env:"POSTS_PER_HOUR" envDefault:"10"`
+	CommentsPerHour      int `env:"COMMENTS_PER_HOUR" envDefault:"60"`
+	AdminPostsPerHour    int `env:"ADMIN_POSTS_PER_HOUR" envDefault:"0"`
+	AdminCommentsPerHour int `env:"ADMIN_COMMENTS_PER_HOUR" envDefault:"0"`
+	// How often word filter rules are reloaded from the database (0 only reloads on change)
+	WordFilterReloadSeconds int `env:"WORD_FILTER_RELOAD_SECONDS" envDefault:"60"`
+	// How long a post edit lock lasts unless the editor renews it
+	EditLockSeconds int `env:"EDIT_LOCK_SECONDS" envDefault:"120"`
+	// How long a user counts as online after their last POST /api/presence/ping
+	PresenceTTLSeconds int `env:"PRESENCE_TTL_SECONDS" envDefault:"90"`
+
+	// Digest Configuration (how often to check for due digest emails; 0 disables digests)
+	DigestCheckMinutes int `env:"DIGEST_CHECK_MINUTES" envDefault:"60"`
+	// How often the public site digests behind GET /api/digest are regenerated (0 generates them on
+	// request instead, kept for an hour)
+	SiteDigestRefreshMinutes int `env:"SITE_DIGEST_REFRESH_MINUTES" envDefault:"15"`
+
+	// Keyword Alert Configuration (how often new posts are matched against alerts, 0 disables matching;
+	// and how long each alert waits after notifying before it notifies again)
+	KeywordAlertIntervalSeconds int `env:"KEYWORD_ALERT_INTERVAL_SECONDS" envDefault:"60"`
+	KeywordAlertCooldownMinutes int `env:"KEYWORD_ALERT_COOLDOWN_MINUTES" envDefault:"60"`
+
+	// Cleanup Configuration (how often expired tokens and old signup records are purged; 0 disables cleanup)
+	CleanupIntervalMinutes int `env:"CLEANUP_INTERVAL_MINUTES" envDefault:"60"`
+	// How long signup records are kept for throttling and abuse review (never less than a day)
+	SignupRetentionDays int `env:"SIGNUP_RETENTION_DAYS" envDefault:"30"`
+
+	// Backup Configuration (logical pg_dump backups; 0 hours only backs up when an admin asks)
+	BackupIntervalHours int    `env:"BACKUP_INTERVAL_HOURS" envDefault:"0"`
+	BackupsDir          string `env:"BACKUPS_DIR" envDefault:"./data/backups"`
+	BackupRetention     int    `env:"BACKUP_RETENTION" envDefault:"7"`
+	PgDumpPath          string `env:"PG_DUMP_PATH" envDefault:"pg_dump"`
+
+	// Admin bootstrap: while no admin exists, give the admin role to the first account to
+	// register, or to the account registering with the configured username/email
+	BootstrapFirstUserAdmin bool   `env:"BOOTSTRAP_FIRST_USER_ADMIN" envDefault:"false"`
+	BootstrapAdminUsername  string `env:"BOOTSTRAP_ADMIN_USERNAME"`
+	BootstrapAdminEmail     string `env:"BOOTSTRAP_ADMIN_EMAIL"`
+
+	// Signup throttling (limits are per day; 0 disables a limit)
+	SignupsPerIP          int  `env:"SIGNUPS_PER_IP" envDefault:"5"`
+	SignupsPerSubnet      int  `env:"SIGNUPS_PER_SUBNET" envDefault:"20"`
+	SignupsPerEmail       int  `env:"SIGNUPS_PER_EMAIL" envDefault:"1"`
+	BlockDisposableEmails bool `env:"BLOCK_DISPOSABLE_EMAILS" envDefault:"false"`
+	// One domain per line; the built-in list is used when empty
+	DisposableDomainsFile string `env:"DISPOSABLE_DOMAINS_FILE"`
+	// Take the client IP from X-Forwarded-For (only enable behind a proxy that sets it)
+	TrustProxyHeaders bool `env:"TRUST_PROXY_HEADERS" envDefault:"false"`
+
+	// CAPTCHA on registration (hcaptcha or recaptcha; disabled when the provider is empty). The minimum
+	// score only applies to reCAPTCHA v3 responses.
+	CaptchaProvider string  `env:"CAPTCHA_PROVIDER"`
+	CaptchaSecret   string  `env:"CAPTCHA_SECRET"`
+	CaptchaMinScore float64 `env:"CAPTCHA_MIN_SCORE" envDefault:"0.5"`
+
+	// Login throttling per username+IP: failed logins allowed before delays start (0 disables throttling),
+	// then a delay starting at the base and doubling with each failure, up to the max
+	LoginFreeFailures       int `env:"LOGIN_FREE_FAILURES" envDefault:"5"`
+	LoginBackoffBaseSeconds int `env:"LOGIN_BACKOFF_BASE_SECONDS" envDefault:"1"`
+	LoginBackoffMaxSeconds  int `env:"LOGIN_BACKOFF_MAX_SECONDS" envDefault:"900"`
+
+	// Algorithm new password hashes are made with (bcrypt or argon2id). Logins with a password hashed
+	// another way still work, and are re-hashed when switching from bcrypt to argon2id.
+	PasswordHash string `env:"PASSWORD_HASH" envDefault:"bcrypt"`
+	// Argon2id cost: memory per hash in KiB, passes and threads (defaults follow RFC 9106's second
+	// recommended option, with fewer threads to suit small servers)
+	Argon2MemoryKiB   uint32 `env:"ARGON2_MEMORY_KIB" envDefault:"65536"`
+	Argon2Iterations  uint32 `env:"ARGON2_ITERATIONS" envDefault:"3"`
+	Argon2Parallelism uint8  `env:"ARGON2_PARALLELISM" envDefault:"2"`
+
+	// Attachment Configuration (limits in bytes; 0 disables a limit)
+	AttachmentsDir     string `env:"ATTACHMENTS_DIR" envDefault:"./data/attachments"`
+	AttachmentMaxBytes int64  `env:"ATTACHMENT_MAX_BYTES" envDefault:"5242880"`
+	StorageQuotaBytes  int64  `env:"STORAGE_QUOTA_BYTES" envDefault:"52428800"`
+	// How often unlinked uploads and file contents no attachment uses any more are deleted (0 disables both)
+	AttachmentGCIntervalMinutes int `env:"ATTACHMENT_GC_INTERVAL_MINUTES" envDefault:"60"`
+	// Uploads no post or comment has linked are deleted after this many days (0 keeps them)
+	AttachmentUnlinkedDays int `env:"ATTACHMENT_UNLINKED_DAYS" envDefault:"7"`
+
+	// GitHub Configuration (gist import; a token raises the API rate limit)
+	GithubAPIURL string `env:"GITHUB_API_URL" envDefault:"https://api.github.com"`
+	GithubToken  string `env:"GITHUB_TOKEN"`
+
+	// Translation Configuration (LibreTranslate-compatible API for post translations; disabled when the URL is empty)
+	TranslateAPIURL string `env:"TRANSLATE_API_URL"`
+	TranslateAPIKey string `env:"TRANSLATE_API_KEY"`
+
+	// Consumers admins can replay the event log to, as comma-separated name=url pairs
+	// (e.g. "search=https://search.internal/events"); batches are signed with the secret when it's set
+	EventReplayTargets string `env:"EVENT_REPLAY_TARGETS"`
+	EventReplaySecret  string `env:"EVENT_REPLAY_SECRET"`
+
+	// Federation Configuration (publish-only ActivityPub, so fediverse accounts such as Mastodon's can follow
+	// authors). Activities are signed with the PEM RSA key in the file; relative paths resolve against SECRETS_PATH.
+	FederationEnabled bool   `env:"FEDERATION_ENABLED" envDefault:"false"`
+	FederationKeyFile string `env:"FEDERATION_KEY_FILE"`
+
+	// Autocomplete requests (@mentions and the search box) allowed per user (or IP) per minute (0 disables the limit)
+	UserSuggestPerMinute int `env:"USER_SUGGEST_PER_MINUTE" envDefault:"60"`
+	// How long the search box typeahead waits on its lookups before answering with what it has
+	SearchSuggestTimeoutMs int `env:"SEARCH_SUGGEST_TIMEOUT_MS" envDefault:"250"`
+
+	// Leaderboard Configuration
+	LeaderboardCacheSeconds int `env:"LEADERBOARD_CACHE_SECONDS" envDefault:"300"`
+
+	// How long anonymous post listings are cached (0 turns the cache off), and how much longer an expired
+	// listing is still served while it's refreshed in the background
+	PostListCacheSeconds int `env:"POST_LIST_CACHE_SECONDS" envDefault:"5"`
+	PostListStaleSeconds int `env:"POST_LIST_STALE_SECONDS" envDefault:"60"`
+
+	// Check the database schema, storage directories and SMTP server at startup, refusing to start when
+	// one is unusable (turn off to start anyway, e.g. when SMTP is only reachable in production)
+	StartupChecks bool `env:"STARTUP_CHECKS" envDefault:"true"`
 
 	// Allowed Origins
 	AllowedOrigins string `env:"ALLOWED_ORIGINS"`
+	// Origins allowed to call /api/admin (such as the admin UI's); ALLOWED_ORIGINS applies when empty
+	AdminAllowedOrigins string `env:"ADMIN_ALLOWED_ORIGINS"`
+
+	// SMTP Configuration (emails are logged instead of sent when SMTP_HOST is empty)
+	SMTPHost         string `env:"SMTP_HOST"`
+	SMTPPort         string `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername     string `env:"SMTP_USERNAME"`
+	SMTPPasswordFile string `env:"SMTP_PASSWORD_FILE"`
+	SMTPFrom         string `env:"SMTP_FROM" envDefault:"no-reply@byteboard.local"`
 
 	// Secrets Configuration
 	SecretsPath string `env:"SECRETS_PATH"`
@@ -69,33 +239,208 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
-// Validate will validate the configuration
+// Minimum JWT_SECRET length, and the least Shannon entropy per character it may have (rejects
+// secrets like "aaaa..." or a short word repeated)
+const (
+	minJWTSecretLength      = 32
+	minJWTSecretBitsPerChar = 2.8
+)
+
+// Placeholder secrets from the docs that must never reach a real deployment
+var placeholderJWTSecrets = []string{
+	"your-super-secret-jwt-key-change-this-in-production",
+	"your-secret-key-change-in-production",
+}
+
+// Everything wrong with the configuration, so it can all be fixed in one go
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate will validate the configuration, reporting every problem found
 func (c *Config) Validate() error {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
 	// Check each individual database component
 	if c.PostgresHost == "" {
-		return fmt.Errorf("POSTGRES_HOST is required")
+		problem("POSTGRES_HOST is required")
 	}
 	if c.PostgresPort == "" {
-		return fmt.Errorf("POSTGRES_PORT is required")
+		problem("POSTGRES_PORT is required")
 	}
 	if c.PostgresDB == "" {
-		return fmt.Errorf("POSTGRES_DB is required")
+		problem("POSTGRES_DB is required")
 	}
 	if c.PostgresUser == "" {
-		return fmt.Errorf("POSTGRES_USER is required")
+		problem("POSTGRES_USER is required")
 	}
 	if c.PostgresPasswordFile == "" {
-		return fmt.Errorf("POSTGRES_PASSWORD_FILE is required")
+		problem("POSTGRES_PASSWORD_FILE is required")
 	}
 
 	// Check that SECRETS_PATH is set
-	if !filepath.IsAbs(c.PostgresPasswordFile) && c.SecretsPath == "" {
-		return fmt.Errorf("SECRETS_PATH is required when using relative paths for POSTGRES_PASSWORD_FILE")
+	if c.PostgresPasswordFile != "" && !filepath.IsAbs(c.PostgresPasswordFile) && c.SecretsPath == "" {
+		problem("SECRETS_PATH is required when using relative paths for POSTGRES_PASSWORD_FILE")
+	}
+
+	if msg := checkJWTSecret("JWT_SECRET", c.JWTSecret); msg != "" {
+		problem("%s", msg)
+	}
+	for _, secret := range c.GetJWTPreviousSecrets() {
+		if msg := checkJWTSecret("JWT_PREVIOUS_SECRETS entries", secret); msg != "" {
+			problem("%s", msg)
+		}
+	}
+	if c.JWTSigningKeyFile != "" && !filepath.IsAbs(c.JWTSigningKeyFile) && c.SecretsPath == "" {
+		problem("SECRETS_PATH is required when using relative paths for JWT_SIGNING_KEY_FILE")
+	}
+	for _, file := range splitList(c.JWTPreviousSigningKeyFiles) {
+		if !filepath.IsAbs(file) && c.SecretsPath == "" {
+			problem("SECRETS_PATH is required when using relative paths for JWT_PREVIOUS_SIGNING_KEY_FILES")
+			break
+		}
+	}
+	if c.FederationEnabled {
+		if c.FederationKeyFile == "" {
+			problem("FEDERATION_KEY_FILE is required when FEDERATION_ENABLED is set")
+		} else if !filepath.IsAbs(c.FederationKeyFile) && c.SecretsPath == "" {
+			problem("SECRETS_PATH is required when using relative paths for FEDERATION_KEY_FILE")
+		}
+		if !strings.HasPrefix(c.PublicURL, "https://") {
+			problem("PUBLIC_URL must be an https URL when FEDERATION_ENABLED is set (got %q)", c.PublicURL)
+		}
+	}
+	if c.JWTExpirationHours <= 0 {
+		problem("JWT_EXPIRATION_HOURS must be positive (got %d)", c.JWTExpirationHours)
+	}
+	if c.ImpersonationTokenMinutes <= 0 || c.ImpersonationTokenMinutes > 60 {
+		problem("IMPERSONATION_TOKEN_MINUTES must be between 1 and 60 (got %d)", c.ImpersonationTokenMinutes)
+	}
+	if c.SearchSuggestTimeoutMs <= 0 {
+		problem("SEARCH_SUGGEST_TIMEOUT_MS must be positive (got %d)", c.SearchSuggestTimeoutMs)
+	}
+	if c.JWTRefreshExpirationDays <= 0 {
+		problem("JWT_REFRESH_EXPIRATION_DAYS must be positive (got %d)", c.JWTRefreshExpirationDays)
+	}
+	if c.AttachmentUnlinkedDays < 0 {
+		problem("ATTACHMENT_UNLINKED_DAYS can't be negative (got %d)", c.AttachmentUnlinkedDays)
+	}
+	if c.EditLockSeconds <= 0 {
+		problem("EDIT_LOCK_SECONDS must be positive (got %d)", c.EditLockSeconds)
+	}
+	if c.PresenceTTLSeconds <= 0 {
+		problem("PRESENCE_TTL_SECONDS must be positive (got %d)", c.PresenceTTLSeconds)
+	}
+	if c.LoginFreeFailures > 0 {
+		if c.LoginBackoffBaseSeconds <= 0 {
+			problem("LOGIN_BACKOFF_BASE_SECONDS must be positive (got %d)", c.LoginBackoffBaseSeconds)
+		}
+		if c.LoginBackoffMaxSeconds < c.LoginBackoffBaseSeconds {
+			problem("LOGIN_BACKOFF_MAX_SECONDS must be at least LOGIN_BACKOFF_BASE_SECONDS (got %d)", c.LoginBackoffMaxSeconds)
+		}
+	}
+
+	if c.MaxResultRows < 100 {
+		problem("MAX_RESULT_ROWS must be at least 100, the largest page size (got %d)", c.MaxResultRows)
 	}
 
+	switch c.PasswordHash {
+	case "bcrypt":
+	case "argon2id":
+		params := auth.Argon2Params{Memory: c.Argon2MemoryKiB, Iterations: c.Argon2Iterations, Threads: c.Argon2Parallelism}
+		if c.Argon2Iterations == 0 || c.Argon2Parallelism == 0 {
+			problem("ARGON2_ITERATIONS and ARGON2_PARALLELISM must be positive")
+		} else if c.Argon2MemoryKiB < 8*uint32(c.Argon2Parallelism) {
+			problem("ARGON2_MEMORY_KIB must be at least 8 per thread (got %d)", c.Argon2MemoryKiB)
+		} else if _, err := auth.NewPasswordHasher(auth.PasswordArgon2id, params); err != nil {
+			// Above the limits logins are checked with, so the hashes could never be verified
+			problem("ARGON2_MEMORY_KIB, ARGON2_ITERATIONS and ARGON2_PARALLELISM: %v", err)
+		}
+	default:
+		problem("PASSWORD_HASH must be bcrypt or argon2id (got %q)", c.PasswordHash)
+	}
+
+	if publicURL, err := url.Parse(c.PublicURL); err != nil || publicURL.Scheme == "" || publicURL.Host == "" {
+		problem("PUBLIC_URL must be an absolute URL such as https://byteboard.example.com (got %q)", c.PublicURL)
+	}
+
+	if c.PasswordResetURL != "" {
+		if resetURL, err := url.Parse(c.PasswordResetURL); err != nil || resetURL.Scheme == "" || resetURL.Host == "" || resetURL.RawQuery != "" {
+			problem("PASSWORD_RESET_URL must be an absolute URL without a query string (got %q)", c.PasswordResetURL)
+		}
+	}
+
+	if _, err := c.GetEventReplayTargets(); err != nil {
+		problem("%s", err)
+	}
+
+	if c.CaptchaProvider != "" {
+		if c.CaptchaProvider != "hcaptcha" && c.CaptchaProvider != "recaptcha" {
+			problem("CAPTCHA_PROVIDER must be hcaptcha or recaptcha (got %q)", c.CaptchaProvider)
+		}
+		if c.CaptchaSecret == "" {
+			problem("CAPTCHA_SECRET is required when CAPTCHA_PROVIDER is set")
+		}
+		if c.CaptchaMinScore < 0 || c.CaptchaMinScore > 1 {
+			problem("CAPTCHA_MIN_SCORE must be between 0 and 1 (got %g)", c.CaptchaMinScore)
+		}
+	}
+
+	if c.SMTPHost != "" && c.SMTPPasswordFile != "" && c.SMTPUsername == "" {
+		problem("SMTP_USERNAME is required when SMTP_PASSWORD_FILE is set")
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
 	return nil
 }
 
+// Checks a JWT secret is long and random enough to sign tokens with (returns what's wrong, or ""); name
+// is the setting it came from
+func checkJWTSecret(name, secret string) string {
+	const hint = "; generate one with: openssl rand -hex 32"
+
+	for _, placeholder := range placeholderJWTSecrets {
+		if secret == placeholder {
+			return name + " is still the example value" + hint
+		}
+	}
+	if len(secret) < minJWTSecretLength {
+		return fmt.Sprintf("%s must be at least %d characters (got %d)%s", name, minJWTSecretLength, len(secret), hint)
+	}
+	if bitsPerChar(secret) < minJWTSecretBitsPerChar {
+		return name + " is too predictable (too few distinct characters)" + hint
+	}
+
+	return ""
+}
+
+// Shannon entropy of a string's characters, in bits per character
+func bitsPerChar(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, c := range s {
+		counts[c]++
+		total++
+	}
+
+	var bits float64
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		bits -= p * math.Log2(p)
+	}
+
+	return bits
+}
+
 // Constructs the database URL from individual components (No Fallbacks)
 func (c *Config) GetDatabaseURL() (string, error) {
 	// Always construct the URL from individual components
@@ -104,8 +449,9 @@ func (c *Config) GetDatabaseURL() (string, error) {
 		return "", fmt.Errorf("failed to get the database password: %w", err)
 	}
 
-	// Construct the PostgresSQL connection string
-	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
+	// Construct the PostgresSQL connection string. Sessions use UTC, so timestamps come back in UTC
+	// and dates are cut at UTC midnight whatever the server's zone.
+	dbURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s&timezone=UTC",
 		c.PostgresUser, password, c.PostgresHost, c.PostgresPort, c.PostgresDB, c.PostgresSSLMode)
 
 	log.Info().
@@ -153,6 +499,80 @@ func (c *Config) GetDatabasePassword() (string, error) {
 	return password, nil
 }
 
+// Reads the SMTP password from SMTP_PASSWORD_FILE (empty if no file is configured)
+func (c *Config) GetSMTPPassword() (string, error) {
+	if c.SMTPPasswordFile == "" {
+		return "", nil
+	}
+
+	filePath := c.SMTPPasswordFile
+
+	// Resolve relative paths against SECRETS_PATH
+	if !filepath.IsAbs(filePath) {
+		if c.SecretsPath == "" {
+			return "", fmt.Errorf("relative path provided for SMTP_PASSWORD_FILE but SECRETS_PATH is not set")
+		}
+		filePath = filepath.Join(c.SecretsPath, filePath)
+	}
+
+	passwordBytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read SMTP password from file %s: %w", filePath, err)
+	}
+
+	return strings.TrimSpace(string(passwordBytes)), nil
+}
+
+// Reads the PEM private key tokens are signed with from JWT_SIGNING_KEY_FILE (nil if no file is configured)
+func (c *Config) GetJWTSigningKey() ([]byte, error) {
+	if c.JWTSigningKeyFile == "" {
+		return nil, nil
+	}
+
+	return c.readKeyFile("JWT_SIGNING_KEY_FILE", c.JWTSigningKeyFile)
+}
+
+// GetJWTPreviousSigningKeys reads the rotated-out PEM signing keys tokens are still verified with
+func (c *Config) GetJWTPreviousSigningKeys() ([][]byte, error) {
+	var keys [][]byte
+	for _, file := range splitList(c.JWTPreviousSigningKeyFiles) {
+		keyBytes, err := c.readKeyFile("JWT_PREVIOUS_SIGNING_KEY_FILES", file)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, keyBytes)
+	}
+
+	return keys, nil
+}
+
+// GetFederationKey reads the PEM key ActivityPub activities are signed with
+func (c *Config) GetFederationKey() ([]byte, error) {
+	return c.readKeyFile("FEDERATION_KEY_FILE", c.FederationKeyFile)
+}
+
+// GetJWTPreviousSecrets returns the rotated-out secrets tokens are still verified with
+func (c *Config) GetJWTPreviousSecrets() []string {
+	return splitList(c.JWTPreviousSecrets)
+}
+
+// Reads a JWT key file named by a setting, resolving relative paths against SECRETS_PATH
+func (c *Config) readKeyFile(name, filePath string) ([]byte, error) {
+	if !filepath.IsAbs(filePath) {
+		if c.SecretsPath == "" {
+			return nil, fmt.Errorf("relative path provided for %s but SECRETS_PATH is not set", name)
+		}
+		filePath = filepath.Join(c.SecretsPath, filePath)
+	}
+
+	keyBytes, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT signing key from file %s: %w", filePath, err)
+	}
+
+	return keyBytes, nil
+}
+
 // GetAllowedOrigins returns the list of allowed CORS origins
 func (c *Config) GetAllowedOrigins() []string {
 	if c.AllowedOrigins == "" {
@@ -160,8 +580,43 @@ func (c *Config) GetAllowedOrigins() []string {
 		return []string{"http://localhost:3000"}
 	}
 
-	// Split comma-separated origins and trim whitespace
-	origins := strings.Split(c.AllowedOrigins, ",")
+	return splitList(c.AllowedOrigins)
+}
+
+// GetAdminAllowedOrigins returns the CORS origins allowed on admin endpoints (the general list when unset)
+func (c *Config) GetAdminAllowedOrigins() []string {
+	if c.AdminAllowedOrigins == "" {
+		return c.GetAllowedOrigins()
+	}
+
+	return splitList(c.AdminAllowedOrigins)
+}
+
+// GetEventReplayTargets returns the event replay consumers' URLs by name
+func (c *Config) GetEventReplayTargets() (map[string]string, error) {
+	targets := make(map[string]string)
+	for _, pair := range splitList(c.EventReplayTargets) {
+		name, target, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("EVENT_REPLAY_TARGETS entries must look like name=url (got %q)", pair)
+		}
+		if _, dup := targets[name]; dup {
+			return nil, fmt.Errorf("EVENT_REPLAY_TARGETS names %q more than once", name)
+		}
+		if parsed, err := url.Parse(strings.TrimSpace(target)); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("EVENT_REPLAY_TARGETS target %q must have an absolute http(s) URL (got %q)", name, target)
+		}
+
+		targets[name] = strings.TrimSpace(target)
+	}
+
+	return targets, nil
+}
+
+// Splits a comma-separated list, trimming whitespace and dropping empty entries
+func splitList(list string) []string {
+	origins := strings.Split(list, ",")
 	result := make([]string, 0, len(origins))
 	for _, origin := range origins {
 		trimmed := strings.TrimSpace(origin)
@@ -2,8 +2,14 @@ package auth
 
 import (
 	"byte-board/internal/model"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,8 +17,28 @@ import (
 
 // JWT claims structure
 type Claims struct {
+	UserID   int64  `json:"user_id"`
 	Username string `json:"username"`
 	Role     string `json:"role"`
+	// Must match the user's current token version (bumped on password changes and bans)
+	TokenVersion int `json:"token_version"`
+	// The session (login) the token was issued to; revoking the session rejects the token
+	SessionID int64 `json:"sid"`
+	// Set on impersonation tokens: the admin acting as the user
+	ImpersonatorID int64 `json:"impersonator_id,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Value of token_use in machine tokens
+const ClientTokenUse = "client"
+
+// Claims of a machine token issued to a service client. They carry no user, so ParseToken
+// rejects them, and ParseClientToken rejects user tokens (which have no token_use).
+type ClientClaims struct {
+	ClientID string `json:"client_id"`
+	// Space-separated granted scopes (as in OAuth 2.0)
+	Scope    string `json:"scope"`
+	TokenUse string `json:"token_use"`
 	jwt.RegisteredClaims
 }
 
@@ -20,42 +46,97 @@ type Claims struct {
 type JWTConfig struct {
 	SecretKey       string
 	ExpirationHours int
+	// How long a refresh token can be exchanged for a new token pair
+	RefreshExpirationDays int
+	// Written to the iss/aud claims and required when parsing (skipped when empty), so tokens
+	// issued by one deployment (e.g. staging) are rejected by another sharing the secret
+	Issuer   string
+	Audience string
+	// When set, tokens are signed with this key instead of the secret and its public key is published
+	// by JWKS. Tokens signed with the secret are still accepted, so switching doesn't log anyone out.
+	SigningKey *SigningKey
+	// Secrets and signing keys that were rotated out. Tokens are never signed with them, but tokens they
+	// signed are still accepted (picked by the kid header), so a rotation doesn't end every session at
+	// once. Previous signing keys stay in the JWKS so other services can verify those tokens too.
+	PreviousSecretKeys  []string
+	PreviousSigningKeys []*SigningKey
 }
 
 // JWT Token creation and validation
 type TokenProvider struct {
 	config JWTConfig
+	// kid stamped on tokens signed with the secret, and every accepted secret and signing key by kid
+	secretKid   string
+	secrets     map[string][]byte
+	signingKeys map[string]*SigningKey
 }
 
 // Creates a new JWT token provider
 func NewTokenProvider(config JWTConfig) *TokenProvider {
-	return &TokenProvider{
-		config: config,
+	tp := &TokenProvider{
+		config:      config,
+		secretKid:   secretKeyID(config.SecretKey),
+		secrets:     make(map[string][]byte),
+		signingKeys: make(map[string]*SigningKey),
+	}
+
+	for _, secret := range append([]string{config.SecretKey}, config.PreviousSecretKeys...) {
+		tp.secrets[secretKeyID(secret)] = []byte(secret)
+	}
+	for _, key := range append([]*SigningKey{config.SigningKey}, config.PreviousSigningKeys...) {
+		if key != nil {
+			tp.signingKeys[key.JWK.KeyID] = key
+		}
 	}
+
+	return tp
 }
 
-// Generates new JWT token for a given user
-func (tp *TokenProvider) CreateToken(username string, role string) (string, error) {
+// Names an HMAC secret for kid headers without giving it away: a truncated HMAC of a fixed label
+// keyed by the secret
+func secretKeyID(secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("byte-board jwt kid"))
+	return "hs-" + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:12])
+}
+
+// Generates new JWT token for a given user's session
+func (tp *TokenProvider) CreateToken(userId int64, username string, role string, tokenVersion int, sessionId int64) (string, error) {
+	return tp.createUserToken(userId, username, role, tokenVersion, sessionId, 0, time.Duration(tp.config.ExpirationHours)*time.Hour)
+}
+
+// Generates a JWT letting an admin act as a user in an impersonation session, valid for ttl. The admin
+// is named in the impersonator_id claim.
+func (tp *TokenProvider) CreateImpersonationToken(userId int64, username string, role string, tokenVersion int, sessionId, impersonatorId int64, ttl time.Duration) (string, error) {
+	return tp.createUserToken(userId, username, role, tokenVersion, sessionId, impersonatorId, ttl)
+}
+
+func (tp *TokenProvider) createUserToken(userId int64, username string, role string, tokenVersion int, sessionId, impersonatorId int64, ttl time.Duration) (string, error) {
 	now := time.Now()
-	expirationTime := now.Add(time.Duration(tp.config.ExpirationHours) * time.Hour)
+	expirationTime := now.Add(ttl)
 
 	// Create claims with user info and standard class
 	claims := &Claims{
-		Username: username,
-		Role:     role,
+		UserID:         userId,
+		Username:       username,
+		Role:           role,
+		TokenVersion:   tokenVersion,
+		SessionID:      sessionId,
+		ImpersonatorID: impersonatorId,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Subject:   username,
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    tp.config.Issuer,
 		},
 	}
+	if tp.config.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{tp.config.Audience}
+	}
 
-	// Create token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
-
-	// Sign token with secret key (using HMAC-SHA512)
-	tokenString, err := token.SignedString([]byte(tp.config.SecretKey))
+	// Sign token with the signing key, or the secret key (using HMAC-SHA512) when there's none
+	tokenString, err := tp.sign(claims)
 	if err != nil {
 		return "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -63,17 +144,141 @@ func (tp *TokenProvider) CreateToken(username string, role string) (string, erro
 	return tokenString, nil
 }
 
+// Generates an opaque refresh token, returning it with the hash to store for it and when it expires.
+// Refresh tokens aren't JWTs: they're only good for POST /api/auth/refresh, which looks them up.
+func (tp *TokenProvider) CreateRefreshToken() (string, string, time.Time, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", "", time.Time{}, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	token := hex.EncodeToString(tokenBytes)
+	expiresAt := time.Now().Add(time.Duration(tp.config.RefreshExpirationDays) * 24 * time.Hour)
+	return token, HashRefreshToken(token), expiresAt, nil
+}
+
+// Hashes a refresh token the way it's stored, for looking it up
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Generates a machine token for a service client, valid for ttl
+func (tp *TokenProvider) CreateClientToken(clientId string, scopes []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := &ClientClaims{
+		ClientID: clientId,
+		Scope:    strings.Join(scopes, " "),
+		TokenUse: ClientTokenUse,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "client:" + clientId,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    tp.config.Issuer,
+		},
+	}
+	if tp.config.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{tp.config.Audience}
+	}
+
+	tokenString, err := tp.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign client token: %w", err)
+	}
+
+	return tokenString, nil
+}
+
+// Parse a machine token and return its claims (user tokens are rejected)
+func (tp *TokenProvider) ParseClientToken(tokenString string) (*ClientClaims, error) {
+	token, err := tp.parse(tokenString, &ClientClaims{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the client token: %w", err)
+	}
+
+	claims, ok := token.Claims.(*ClientClaims)
+	if !ok || !token.Valid {
+		return nil, model.ErrInvalidToken
+	}
+	if claims.TokenUse != ClientTokenUse || claims.ClientID == "" {
+		return nil, model.ErrMissingClaims
+	}
+
+	return claims, nil
+}
+
+// Signs claims with the signing key when configured, otherwise with the secret, naming the key in the
+// kid header
+func (tp *TokenProvider) sign(claims jwt.Claims) (string, error) {
+	if key := tp.config.SigningKey; key != nil {
+		token := jwt.NewWithClaims(key.Method, claims)
+		token.Header["kid"] = key.JWK.KeyID
+		return token.SignedString(key.Private)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS512, claims)
+	token.Header["kid"] = tp.secretKid
+	return token.SignedString([]byte(tp.config.SecretKey))
+}
+
+// Gets the public keys tokens can be verified with: the signing key and the previous ones (empty while
+// tokens are signed with the secret and no signing key was used before)
+func (tp *TokenProvider) JWKS() JWKSet {
+	keys := []JWK{}
+	for _, key := range append([]*SigningKey{tp.config.SigningKey}, tp.config.PreviousSigningKeys...) {
+		if key != nil {
+			keys = append(keys, key.JWK)
+		}
+	}
+	return JWKSet{Keys: keys}
+}
+
+// Parses a token into claims, checking its signature, expiry and (when configured) issuer and audience
+func (tp *TokenProvider) parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	var options []jwt.ParserOption
+	if tp.config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(tp.config.Issuer))
+	}
+	if tp.config.Audience != "" {
+		options = append(options, jwt.WithAudience(tp.config.Audience))
+	}
+
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		// Verify signing method is HMAC-SHA512 with a known secret, or a known signing key's method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			if kid == "" {
+				// Issued before tokens carried a kid: try every secret
+				keys := jwt.VerificationKeySet{}
+				for _, secret := range tp.secrets {
+					keys.Keys = append(keys.Keys, secret)
+				}
+				return keys, nil
+			}
+			if secret, ok := tp.secrets[kid]; ok {
+				return secret, nil
+			}
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+
+		key := tp.signingKeys[kid]
+		if kid == "" {
+			key = tp.config.SigningKey
+		}
+		if key != nil && token.Method.Alg() == key.Method.Alg() {
+			return key.Public, nil
+		}
+		return nil, fmt.Errorf("unexpected signing method or key: %v (kid %q)", token.Header["alg"], kid)
+	}, options...)
+}
+
 // Validate the JWT token signature and expiration
 func (tp *TokenProvider) ValidateToken(tokenString string) error {
 	// Parse and validate token
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{},
-		error) {
-		// Verify signing method is HMAC-SHA512
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(tp.config.SecretKey), nil
-	})
+	token, err := tp.parse(tokenString, &Claims{})
 
 	if err != nil {
 		// Check for specific JWT errors
@@ -83,6 +288,9 @@ func (tp *TokenProvider) ValidateToken(tokenString string) error {
 		if errors.Is(err, jwt.ErrSignatureInvalid) {
 			return jwt.ErrSignatureInvalid
 		}
+		if errors.Is(err, jwt.ErrTokenInvalidIssuer) || errors.Is(err, jwt.ErrTokenInvalidAudience) {
+			return model.ErrWrongAudience
+		}
 		return fmt.Errorf("%w, %v", model.ErrInvalidToken, err)
 	}
 
@@ -96,14 +304,7 @@ func (tp *TokenProvider) ValidateToken(tokenString string) error {
 
 // Parse the JWT token and return the claims
 func (tp *TokenProvider) ParseToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(tp.config.SecretKey), nil
-	})
-
+	token, err := tp.parse(tokenString, &Claims{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse the token: %w", err)
 	}
@@ -115,7 +316,7 @@ func (tp *TokenProvider) ParseToken(tokenString string) (*Claims, error) {
 	}
 
 	// Varify required claims exists
-	if claims.Username == "" {
+	if claims.UserID == 0 || claims.Username == "" || claims.SessionID == 0 {
 		return nil, model.ErrMissingClaims
 	}
 
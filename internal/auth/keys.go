@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Smallest RSA key accepted for signing tokens
+const minRSAKeyBits = 2048
+
+// A private key tokens are signed with instead of the HMAC secret (RS256 for RSA keys, EdDSA for Ed25519),
+// with the public half published as a JWK so other services can verify tokens without the secret
+type SigningKey struct {
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+	Public  crypto.PublicKey
+	// Public key as a JWK; its kid is the key's RFC 7638 thumbprint and is written to token headers
+	JWK JWK
+}
+
+// A public key in JSON Web Key form (RFC 7517). RSA keys use n and e, Ed25519 keys (kty OKP) use crv and x.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// A JSON Web Key Set, as served at /.well-known/jwks.json
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Parses a PEM-encoded RSA (PKCS #1 or PKCS #8) or Ed25519 (PKCS #8) private key to sign tokens with
+func ParseSigningKey(data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in signing key")
+	}
+
+	var private any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in signing key (expected a private key)", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key: %w", err)
+	}
+
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA signing key must be at least %d bits (got %d)", minRSAKeyBits, key.N.BitLen())
+		}
+		jwk := JWK{
+			KeyType:   "RSA",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			N:         base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+		return newSigningKey(jwt.SigningMethodRS256, key, jwk)
+	case ed25519.PrivateKey:
+		jwk := JWK{
+			KeyType:   "OKP",
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		}
+		return newSigningKey(jwt.SigningMethodEdDSA, key, jwk)
+	default:
+		return nil, fmt.Errorf("unsupported signing key type %T (expected RSA or Ed25519)", private)
+	}
+}
+
+// Fills in the JWK's use and thumbprint kid
+func newSigningKey(method jwt.SigningMethod, private crypto.Signer, jwk JWK) (*SigningKey, error) {
+	// RFC 7638: SHA-256 of the required members only, in lexicographic order
+	required := map[string]string{"kty": jwk.KeyType}
+	if jwk.KeyType == "RSA" {
+		required["n"], required["e"] = jwk.N, jwk.E
+	} else {
+		required["crv"], required["x"] = jwk.Curve, jwk.X
+	}
+	canonical, err := json.Marshal(required)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute signing key thumbprint: %w", err)
+	}
+	thumbprint := sha256.Sum256(canonical)
+
+	jwk.Use = "sig"
+	jwk.KeyID = base64.RawURLEncoding.EncodeToString(thumbprint[:])
+	return &SigningKey{
+		Method:  method,
+		Private: private,
+		Public:  private.Public(),
+		JWK:     jwk,
+	}, nil
+}
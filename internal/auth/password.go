@@ -2,9 +2,14 @@ package auth
 
 import (
 	"byte-board/internal/model"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"strings"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -12,6 +17,92 @@ import (
 // Higher values = more secure but slower
 const DefaultCost = 10
 
+// Password hashing algorithms new hashes can be made with (PASSWORD_HASH)
+const (
+	PasswordBcrypt   = "bcrypt"
+	PasswordArgon2id = "argon2id"
+)
+
+// Argon2id cost parameters
+type Argon2Params struct {
+	// Memory used per hash, in KiB
+	Memory     uint32
+	Iterations uint32
+	Threads    uint8
+}
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+	argon2Prefix     = "$argon2id$"
+	// The most memory (in KiB), passes and threads a stored hash may ask for, so a bad import can't
+	// exhaust the server's memory or tie up its CPUs on every login attempt
+	argon2MaxMemory     = 4 * 1024 * 1024
+	argon2MaxIterations = 10
+	argon2MaxThreads    = 16
+)
+
+// Reports whether Argon2id settings are within the limits hashes are checked with
+func argon2ParamsInRange(params Argon2Params) bool {
+	return params.Iterations > 0 && params.Iterations <= argon2MaxIterations &&
+		params.Threads > 0 && params.Threads <= argon2MaxThreads &&
+		params.Memory <= argon2MaxMemory
+}
+
+// Hashes new passwords with the configured algorithm. Passwords are checked against whichever algorithm
+// their hash was made with, so switching algorithms doesn't lock anyone out; hashes made with another
+// algorithm or weaker settings are reported by NeedsRehash so they can be upgraded at the next login.
+type PasswordHasher struct {
+	algorithm string
+	argon2    Argon2Params
+}
+
+// Creates a password hasher for algorithm (PasswordBcrypt or PasswordArgon2id; params only apply to Argon2id)
+func NewPasswordHasher(algorithm string, params Argon2Params) (*PasswordHasher, error) {
+	switch algorithm {
+	case PasswordBcrypt:
+	case PasswordArgon2id:
+		if params.Memory < 8*uint32(params.Threads) || params.Iterations == 0 || params.Threads == 0 {
+			return nil, errors.New("argon2id needs at least one iteration and thread, and 8 KiB of memory per thread")
+		}
+		if !argon2ParamsInRange(params) {
+			return nil, fmt.Errorf("argon2id allows at most %d iterations, %d threads and %d KiB of memory",
+				argon2MaxIterations, argon2MaxThreads, argon2MaxMemory)
+		}
+	default:
+		return nil, fmt.Errorf("unknown password hashing algorithm %q", algorithm)
+	}
+
+	return &PasswordHasher{algorithm: algorithm, argon2: params}, nil
+}
+
+// Hashes a plaintext password with the configured algorithm
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if h.algorithm == PasswordArgon2id {
+		return HashPasswordArgon2id(password, h.argon2)
+	}
+	return HashPassword(password)
+}
+
+// Reports whether a hash should be replaced by one made with the configured algorithm and settings.
+// Bcrypt hashes are only upgraded when Argon2id is configured, never the other way round.
+func (h *PasswordHasher) NeedsRehash(hashedPassword string) bool {
+	if hashedPassword == NoPassword {
+		return false
+	}
+
+	if h.algorithm == PasswordArgon2id {
+		params, _, _, err := decodeArgon2id(hashedPassword)
+		return err != nil || params != h.argon2
+	}
+
+	if strings.HasPrefix(hashedPassword, argon2Prefix) {
+		return false
+	}
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err == nil && cost < DefaultCost
+}
+
 // Generates a BCRYPT hash from a plaintext password
 func HashPassword(password string) (string, error) {
 	// Validate password
@@ -32,19 +123,81 @@ func HashPassword(password string) (string, error) {
 	return string(hashedBytes), nil
 }
 
-// Compare a plaintext password with a bcrypt hash
-func CheckPassword(password, hashedPassword string) bool {
-	// Compare password with hash. Returns nil on success and error on failure
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+// Generates an Argon2id hash from a plaintext password, in the PHC string format
+// ($argon2id$v=19$m=...,t=...,p=...$salt$hash) so the settings travel with it
+func HashPasswordArgon2id(password string, params Argon2Params) (string, error) {
+	// Same limits as bcrypt, so an install can switch back
+	if password == "" {
+		return "", model.ErrPasswordEmpty
+	}
+	if len(password) > 72 {
+		return "", model.ErrPasswordTooLong
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Threads, argon2KeyLength)
 
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version, params.Memory, params.Iterations,
+		params.Threads, base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Splits an Argon2id hash into its settings, salt and key
+func decodeArgon2id(hashedPassword string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 || parts[1] != PasswordArgon2id {
+		return params, nil, nil, errors.New("not an argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Threads); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	return params, salt, key, nil
+}
+
+// Compare a plaintext password with a bcrypt or Argon2id hash
+func CheckPassword(password, hashedPassword string) bool {
 	// Return false if there is an error
-	return err == nil
+	return CheckPasswordWithError(password, hashedPassword) == nil
 }
 
 // Like CheckPassword but returns the error
 // Useful if you need to distinguish between wrong password vs other error
 func CheckPasswordWithError(password, hashedPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	if !strings.HasPrefix(hashedPassword, argon2Prefix) {
+		return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	}
+
+	params, salt, key, err := decodeArgon2id(hashedPassword)
+	if err != nil {
+		return err
+	}
+	if !argon2ParamsInRange(params) {
+		return errors.New("argon2id parameters out of range")
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return bcrypt.ErrMismatchedHashAndPassword
+	}
+	return nil
 }
 
 // Validate password meets minimum requirements
@@ -59,3 +212,31 @@ func ValidatePasswordStrength(password string) error {
 
 	return nil
 }
+
+// Stored for accounts that have no password yet (nothing hashes to it, so it never matches)
+const NoPassword = "!"
+
+// Checks a password hash brought over from another system is a bcrypt or Argon2id hash we can verify
+// logins against
+func ValidatePasswordHash(hashedPassword string) error {
+	if strings.HasPrefix(hashedPassword, argon2Prefix) {
+		params, salt, _, err := decodeArgon2id(hashedPassword)
+		if err != nil {
+			return fmt.Errorf("password hash is not a valid argon2id hash: %w", err)
+		}
+		if len(salt) < 8 || !argon2ParamsInRange(params) {
+			return errors.New("password hash has argon2id parameters out of range")
+		}
+		return nil
+	}
+
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return errors.New("password hash must be a bcrypt or argon2id hash")
+	}
+	if cost < DefaultCost {
+		return fmt.Errorf("password hash must have a bcrypt cost of at least %d", DefaultCost)
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"byte-board/internal/model"
+	"strings"
+)
+
+// Username length limits (in characters, after normalization)
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 30
+)
+
+// Names that can't be registered because they collide with routes or imply authority
+var reservedUsernames = map[string]bool{
+	"admin":         true,
+	"administrator": true,
+	"api":           true,
+	"auth":          true,
+	"byteboard":     true,
+	"me":            true,
+	"moderator":     true,
+	"root":          true,
+	"support":       true,
+	"system":        true,
+}
+
+// Converts a username into its canonical (lowercase, trimmed) form
+func NormalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// Validate a normalized username meets the username policy
+func ValidateUsername(username string) error {
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength {
+		return model.ErrUsernameLength
+	}
+
+	// Only lowercase letters, digits, underscores, hyphens and dots are allowed
+	for _, c := range username {
+		isLetter := c >= 'a' && c <= 'z'
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && !isDigit && c != '_' && c != '-' && c != '.' {
+			return model.ErrUsernameCharset
+		}
+	}
+
+	// Must start with a letter or digit
+	first := username[0]
+	if !(first >= 'a' && first <= 'z') && !(first >= '0' && first <= '9') {
+		return model.ErrUsernameCharset
+	}
+
+	if reservedUsernames[username] {
+		return model.ErrUsernameReserved
+	}
+
+	return nil
+}
+
+// Reports whether a free-form name (such as a display name) reads as a reserved username once case,
+// spaces and punctuation are ignored ("Ad.Min" counts as "admin")
+func IsReservedName(name string) bool {
+	var compact strings.Builder
+	for _, c := range strings.ToLower(name) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			compact.WriteRune(c)
+		}
+	}
+	return reservedUsernames[compact.String()]
+}
@@ -0,0 +1,119 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Supported providers
+const (
+	ProviderHCaptcha  = "hcaptcha"
+	ProviderReCaptcha = "recaptcha"
+)
+
+// Where each provider checks challenge responses
+var verifyURLs = map[string]string{
+	ProviderHCaptcha:  "https://api.hcaptcha.com/siteverify",
+	ProviderReCaptcha: "https://www.google.com/recaptcha/api/siteverify",
+}
+
+var (
+	// The challenge response is missing, invalid, expired or was already used
+	ErrFailed = errors.New("captcha verification failed")
+	// The provider couldn't be reached or answered with an error, so the response couldn't be checked
+	ErrUnavailable = errors.New("captcha verification unavailable")
+)
+
+// Checks the response a client got from solving a challenge
+type Verifier interface {
+	// Verify a challenge response (remoteIP, when known, is passed on to the provider as a signal)
+	Verify(ctx context.Context, response, remoteIP string) error
+}
+
+// Checks challenge responses with hCaptcha's or reCAPTCHA's siteverify API
+type HTTPVerifier struct {
+	httpClient *http.Client
+	provider   string
+	verifyURL  string
+	secret     string
+	// Lowest reCAPTCHA v3 score accepted (v2 responses carry no score and only need to succeed)
+	minScore float64
+}
+
+// Creates a new verifier for a provider (hcaptcha or recaptcha)
+func NewHTTPVerifier(provider, secret string, minScore float64) (*HTTPVerifier, error) {
+	verifyURL, ok := verifyURLs[provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown captcha provider %q", provider)
+	}
+
+	return &HTTPVerifier{
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		provider:   provider,
+		verifyURL:  verifyURL,
+		secret:     secret,
+		minScore:   minScore,
+	}, nil
+}
+
+type verifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Send the response to the provider's siteverify API
+func (v *HTTPVerifier) Verify(ctx context.Context, response, remoteIP string) error {
+	response = strings.TrimSpace(response)
+	if response == "" {
+		return fmt.Errorf("%w: no challenge response", ErrFailed)
+	}
+
+	form := url.Values{"secret": {v.secret}, "response": {response}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build captcha request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s returned status %d", ErrUnavailable, v.provider, resp.StatusCode)
+	}
+
+	var result verifyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&result); err != nil {
+		return fmt.Errorf("%w: failed to decode %s response: %v", ErrUnavailable, v.provider, err)
+	}
+
+	if !result.Success {
+		// A wrong secret is our misconfiguration, not the client's fault
+		for _, code := range result.ErrorCodes {
+			if code == "invalid-input-secret" || code == "missing-input-secret" {
+				return fmt.Errorf("%w: %s rejected the secret", ErrUnavailable, v.provider)
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrFailed, strings.Join(result.ErrorCodes, ", "))
+	}
+	if v.provider == ProviderReCaptcha && result.Score != nil && *result.Score < v.minScore {
+		return fmt.Errorf("%w: score %.2f is below %.2f", ErrFailed, *result.Score, v.minScore)
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package federation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Media types for ActivityPub documents and WebFinger responses
+const (
+	ContentType          = "application/activity+json"
+	WebFingerContentType = "application/jrd+json"
+)
+
+// Addressing a public activity to everyone (the ActivityStreams Public collection)
+const PublicAddress = "https://www.w3.org/ns/activitystreams#Public"
+
+// JSON-LD contexts of the documents we serve (security adds the publicKey terms)
+var documentContext = []string{
+	"https://www.w3.org/ns/activitystreams",
+	"https://w3id.org/security/v1",
+}
+
+// Activity types handled in inboxes and published in outboxes
+const (
+	TypeFollow = "Follow"
+	TypeAccept = "Accept"
+	TypeUndo   = "Undo"
+	TypeCreate = "Create"
+)
+
+// A local author as an ActivityPub actor
+type Actor struct {
+	Context           []string  `json:"@context"`
+	ID                string    `json:"id"`
+	Type              string    `json:"type"`
+	PreferredUsername string    `json:"preferredUsername"`
+	Name              string    `json:"name"`
+	URL               string    `json:"url"`
+	Inbox             string    `json:"inbox"`
+	Outbox            string    `json:"outbox"`
+	Followers         string    `json:"followers"`
+	Published         time.Time `json:"published"`
+	// Follows are accepted automatically
+	ManuallyApprovesFollowers bool      `json:"manuallyApprovesFollowers"`
+	PublicKey                 PublicKey `json:"publicKey"`
+}
+
+// The key an actor's activities are signed with
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPem string `json:"publicKeyPem"`
+}
+
+// A post as an ActivityPub object
+type Note struct {
+	Context      []string          `json:"@context,omitempty"`
+	ID           string            `json:"id"`
+	Type         string            `json:"type"`
+	AttributedTo string            `json:"attributedTo"`
+	Content      string            `json:"content"`
+	ContentMap   map[string]string `json:"contentMap,omitempty"`
+	Published    time.Time         `json:"published"`
+	URL          string            `json:"url"`
+	To           []string          `json:"to"`
+	CC           []string          `json:"cc"`
+}
+
+// An activity we publish (the object is a Note, or the activity being accepted)
+type Activity struct {
+	Context   []string    `json:"@context,omitempty"`
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Actor     string      `json:"actor"`
+	Published *time.Time  `json:"published,omitempty"`
+	To        []string    `json:"to,omitempty"`
+	CC        []string    `json:"cc,omitempty"`
+	Object    interface{} `json:"object"`
+}
+
+// An activity received in an inbox. The object can be a link or an embedded object, so it's kept raw.
+type IncomingActivity struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  string          `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+// The ID of the activity's object, whether it's a link or an embedded object
+func (a IncomingActivity) ObjectID() string {
+	var id string
+	if json.Unmarshal(a.Object, &id) == nil {
+		return id
+	}
+
+	var object struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(a.Object, &object) == nil {
+		return object.ID
+	}
+	return ""
+}
+
+// The activity embedded as the object (an Undo's Follow), if there is one
+func (a IncomingActivity) EmbeddedActivity() (IncomingActivity, bool) {
+	var embedded IncomingActivity
+	if err := json.Unmarshal(a.Object, &embedded); err != nil || embedded.Type == "" {
+		return IncomingActivity{}, false
+	}
+	return embedded, true
+}
+
+// A remote actor, as far as delivering to it goes
+type RemoteActor struct {
+	ID        string `json:"id"`
+	Inbox     string `json:"inbox"`
+	Endpoints struct {
+		// Where the actor's server takes activities for all its users at once
+		SharedInbox string `json:"sharedInbox"`
+	} `json:"endpoints"`
+	PublicKey PublicKey `json:"publicKey"`
+}
+
+// An actor's outbox. Items are served in pages, linked from first.
+type OrderedCollection struct {
+	Context    []string `json:"@context"`
+	ID         string   `json:"id"`
+	Type       string   `json:"type"`
+	TotalItems int      `json:"totalItems"`
+	First      string   `json:"first,omitempty"`
+}
+
+// A page of an outbox, newest first
+type OrderedCollectionPage struct {
+	Context      []string   `json:"@context"`
+	ID           string     `json:"id"`
+	Type         string     `json:"type"`
+	PartOf       string     `json:"partOf"`
+	Next         string     `json:"next,omitempty"`
+	Prev         string     `json:"prev,omitempty"`
+	OrderedItems []Activity `json:"orderedItems"`
+}
+
+// A WebFinger response (RFC 7033) pointing from acct:user@host to the actor
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type"`
+	Href string `json:"href"`
+}
+
+// Builds an actor document
+func NewActor(id, username, name, url string, published time.Time, publicKeyPem string) *Actor {
+	return &Actor{
+		Context:           documentContext,
+		ID:                id,
+		Type:              "Person",
+		PreferredUsername: username,
+		Name:              name,
+		URL:               url,
+		Inbox:             id + "/inbox",
+		Outbox:            id + "/outbox",
+		Followers:         id + "/followers",
+		Published:         published,
+		PublicKey: PublicKey{
+			ID:           KeyID(id),
+			Owner:        id,
+			PublicKeyPem: publicKeyPem,
+		},
+	}
+}
+
+// The ID of an actor's signing key
+func KeyID(actorId string) string {
+	return actorId + "#main-key"
+}
+
+// Builds a Create activity publishing a note to everyone and the author's followers
+func NewCreate(note Note) Activity {
+	published := note.Published
+	return Activity{
+		ID:        note.ID + "/activity",
+		Type:      TypeCreate,
+		Actor:     note.AttributedTo,
+		Published: &published,
+		To:        note.To,
+		CC:        note.CC,
+		Object:    note,
+	}
+}
+
+// Builds an Accept of a follow request
+func NewAccept(actorId string, follow IncomingActivity) Activity {
+	sum := sha256.Sum256([]byte(follow.ID))
+	return Activity{
+		ID:    actorId + "#accepts/follows/" + hex.EncodeToString(sum[:8]),
+		Type:  TypeAccept,
+		Actor: actorId,
+		Object: map[string]string{
+			"id":     follow.ID,
+			"type":   TypeFollow,
+			"actor":  follow.Actor,
+			"object": actorId,
+		},
+	}
+}
+
+// Adds the JSON-LD context an activity needs when it's sent on its own rather than inside a collection
+func WithContext(activity Activity) Activity {
+	activity.Context = documentContext
+	return activity
+}
+
+// Adds the JSON-LD context to a note served on its own
+func NoteWithContext(note Note) Note {
+	note.Context = documentContext
+	return note
+}
+
+// Context for collection documents
+func CollectionContext() []string {
+	return documentContext
+}
@@ -0,0 +1,148 @@
+package federation
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// Largest remote document read (actors are a few kilobytes)
+const maxDocumentBytes = 1 << 20
+
+// Accept header for fetching ActivityPub documents
+const acceptActivity = `application/activity+json, application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+
+// A remote server refused a delivery for good (the inbox is gone), so retrying won't help
+var ErrGone = errors.New("remote inbox is gone")
+
+// Fetches remote actors and delivers activities to remote inboxes, signing every request with the key
+// of the local actor it's made for. Only https URLs on public addresses are reached, so remote documents
+// can't point requests at the internal network.
+type Client struct {
+	httpClient *http.Client
+	key        *rsa.PrivateKey
+}
+
+// Creates a new client signing with key
+func NewClient(key *rsa.PrivateKey) *Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: refusePrivateAddresses}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Client{
+		httpClient: &http.Client{
+			Timeout:   15 * time.Second,
+			Transport: transport,
+			// Redirects aren't followed: signatures cover the original URL, and they could lead anywhere
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		key: key,
+	}
+}
+
+// Refuses connections to loopback, private, link-local and other non-public addresses
+func refusePrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+	return nil
+}
+
+// Checks that a remote URL is an absolute https URL
+func CheckRemoteURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("remote URL must be an absolute https URL (got %q)", raw)
+	}
+	return nil
+}
+
+// Fetches a remote actor, signing the request as signerId (some servers require signed fetches)
+func (c *Client) FetchActor(ctx context.Context, actorId, signerId string) (*RemoteActor, error) {
+	if err := CheckRemoteURL(actorId); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, actorId, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build actor request: %w", err)
+	}
+	req.Header.Set("Accept", acceptActivity)
+	if err := SignRequest(req, nil, KeyID(signerId), c.key); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch actor: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("actor fetch returned status %d", resp.StatusCode)
+	}
+
+	var actor RemoteActor
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentBytes)).Decode(&actor); err != nil {
+		return nil, fmt.Errorf("failed to decode actor: %w", err)
+	}
+	if actor.ID != actorId {
+		return nil, fmt.Errorf("actor document has ID %q, not %q", actor.ID, actorId)
+	}
+	if err := CheckRemoteURL(actor.Inbox); err != nil {
+		return nil, fmt.Errorf("actor inbox: %w", err)
+	}
+	if actor.Endpoints.SharedInbox != "" && CheckRemoteURL(actor.Endpoints.SharedInbox) != nil {
+		actor.Endpoints.SharedInbox = ""
+	}
+
+	return &actor, nil
+}
+
+// Posts an activity to a remote inbox, signed as the local actor that sent it
+func (c *Client) Deliver(ctx context.Context, inbox string, activity []byte, actorId string) error {
+	if err := CheckRemoteURL(inbox); err != nil {
+		return fmt.Errorf("%w: %v", ErrGone, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, inbox, bytes.NewReader(activity))
+	if err != nil {
+		return fmt.Errorf("failed to build delivery: %w", err)
+	}
+	req.Header.Set("Content-Type", ContentType)
+	if err := SignRequest(req, activity, KeyID(actorId), c.key); err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver activity: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDocumentBytes))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusGone || resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: status %d", ErrGone, resp.StatusCode)
+	default:
+		return fmt.Errorf("delivery returned status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,201 @@
+package federation
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Smallest RSA key accepted for signing activities
+const minKeyBits = 2048
+
+// How far a signed request's Date may be from our clock (what Mastodon accepts too)
+const maxClockSkew = 12 * time.Hour
+
+// Headers covered by the signatures we make, and that a signature we verify must cover
+var signedHeaders = []string{"(request-target)", "host", "date", "digest"}
+
+// One key="value" parameter of a Signature header
+var signatureParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// A request's signature failed to verify
+var ErrBadSignature = errors.New("invalid HTTP signature")
+
+// Parses the PEM-encoded RSA private key (PKCS #1 or PKCS #8) activities are signed with
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in federation key")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q in federation key", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse federation key: %w", err)
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("federation key must be an RSA key")
+	}
+	if rsaKey.N.BitLen() < minKeyBits {
+		return nil, fmt.Errorf("federation key must be at least %d bits (got %d)", minKeyBits, rsaKey.N.BitLen())
+	}
+
+	return rsaKey, nil
+}
+
+// Encodes the public half of a key as the PEM actors publish
+func PublicKeyPEM(key *rsa.PrivateKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode federation public key: %w", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// The Digest header value for a body
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Builds the string a signature covers from the listed headers
+func signingString(r *http.Request, headers []string) string {
+	lines := make([]string, 0, len(headers))
+	for _, header := range headers {
+		switch header {
+		case "(request-target)":
+			lines = append(lines, "(request-target): "+strings.ToLower(r.Method)+" "+r.URL.RequestURI())
+		case "host":
+			host := r.Host
+			if host == "" {
+				host = r.URL.Host
+			}
+			lines = append(lines, "host: "+host)
+		default:
+			lines = append(lines, header+": "+r.Header.Get(header))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Signs an outgoing request (draft-cavage HTTP Signatures with rsa-sha256, as Mastodon expects), setting
+// its Date, Digest and Signature headers. body is what the request sends (nil for GETs).
+func SignRequest(r *http.Request, body []byte, keyId string, key *rsa.PrivateKey) error {
+	r.Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	r.Header.Set("Digest", digest(body))
+
+	hashed := sha256.Sum256([]byte(signingString(r, signedHeaders)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	r.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyId, strings.Join(signedHeaders, " "), base64.StdEncoding.EncodeToString(signature)))
+	return nil
+}
+
+// A parsed Signature header
+type Signature struct {
+	KeyID     string
+	Algorithm string
+	Headers   []string
+	Signature []byte
+}
+
+// Parses a request's Signature header
+func ParseSignature(r *http.Request) (*Signature, error) {
+	header := r.Header.Get("Signature")
+	if header == "" {
+		return nil, fmt.Errorf("%w: missing Signature header", ErrBadSignature)
+	}
+
+	params := make(map[string]string)
+	for _, match := range signatureParam.FindAllStringSubmatch(header, -1) {
+		params[match[1]] = match[2]
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil || len(signature) == 0 || params["keyId"] == "" {
+		return nil, fmt.Errorf("%w: malformed Signature header", ErrBadSignature)
+	}
+
+	headers := []string{"date"}
+	if params["headers"] != "" {
+		headers = strings.Fields(strings.ToLower(params["headers"]))
+	}
+
+	return &Signature{
+		KeyID:     params["keyId"],
+		Algorithm: params["algorithm"],
+		Headers:   headers,
+		Signature: signature,
+	}, nil
+}
+
+// Verifies a signed POST against the signer's public key (PEM): the signature must cover the request
+// target, host, date and body digest, the digest must match body, and the date must be recent
+func (s *Signature) Verify(r *http.Request, body []byte, publicKeyPem string, now time.Time) error {
+	switch s.Algorithm {
+	case "", "rsa-sha256", "hs2019":
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrBadSignature, s.Algorithm)
+	}
+	for _, header := range signedHeaders {
+		if !slices.Contains(s.Headers, header) {
+			return fmt.Errorf("%w: %s is not signed", ErrBadSignature, header)
+		}
+	}
+
+	date, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil {
+		return fmt.Errorf("%w: bad Date header", ErrBadSignature)
+	}
+	if date.Before(now.Add(-maxClockSkew)) || date.After(now.Add(maxClockSkew)) {
+		return fmt.Errorf("%w: Date is too far from now", ErrBadSignature)
+	}
+	if r.Header.Get("Digest") != digest(body) {
+		return fmt.Errorf("%w: Digest doesn't match the body", ErrBadSignature)
+	}
+
+	block, _ := pem.Decode([]byte(publicKeyPem))
+	if block == nil {
+		return fmt.Errorf("%w: signer's public key isn't PEM", ErrBadSignature)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("%w: failed to parse signer's public key", ErrBadSignature)
+	}
+	publicKey, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: signer's key isn't an RSA key", ErrBadSignature)
+	}
+
+	hashed := sha256.Sum256([]byte(signingString(r, s.Headers)))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], s.Signature); err != nil {
+		return fmt.Errorf("%w: signature doesn't match", ErrBadSignature)
+	}
+
+	return nil
+}
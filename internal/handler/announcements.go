@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/announcements - Handler to get the site-wide banners showing right now (signed-in users don't get
+// the ones they dismissed). Clients poll this, so it's a single indexed query.
+func (h *Handler) GetAnnouncements(w http.ResponseWriter, r *http.Request) {
+	announcements, err := h.announcementService.Active(h.viewerId(r))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get announcements")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get announcements")
+		return
+	}
+
+	// Responses differ per user and change as soon as an admin acts, so always revalidate
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("Vary", "Authorization")
+	writeJSONResponse(w, http.StatusOK, announcements)
+}
+
+// POST /api/announcements/{announcementId}/dismiss - Handler to stop seeing an announcement
+func (h *Handler) DismissAnnouncement(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/announcements/{announcementId}/dismiss - Dismissing announcement")
+
+	announcementId, ok := parseAnnouncementID(w, r)
+	if !ok {
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	if err := h.announcementService.Dismiss(userId, announcementId); err != nil {
+		writeMappedError(w, err, "Announcement not found", "Failed to dismiss announcement")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Int64("announcement_id", announcementId).Msg("Announcement dismissed")
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Announcement dismissed"})
+}
+
+// GET /api/admin/announcements - Handler to get every announcement, including scheduled and expired ones
+func (h *Handler) GetAllAnnouncements(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/announcements - Getting all announcements")
+
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	announcements, err := h.announcementService.List(limit, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get announcements")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get announcements")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, announcements)
+}
+
+// POST /api/admin/announcements - Handler to post a site-wide announcement
+func (h *Handler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/announcements - Creating announcement")
+
+	// Parse request body
+	var req model.AnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	announcement := &model.Announcement{
+		Title:     req.Title,
+		Message:   req.Message,
+		Level:     req.Level,
+		ExpiresAt: model.UTCTime(req.ExpiresAt),
+	}
+	if req.StartsAt != nil {
+		announcement.StartsAt = req.StartsAt.UTC()
+	}
+
+	if err := h.announcementService.Create(middleware.GetUserID(r), announcement); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Failed to create announcement", "Failed to create announcement")
+		return
+	}
+
+	log.Info().Int64("announcement_id", announcement.AnnouncementId).Str("level", announcement.Level).Msg("Announcement created")
+	writeJSONResponse(w, http.StatusCreated, announcement)
+}
+
+// PUT /api/admin/announcements/{announcementId} - Handler to edit or reschedule an announcement
+func (h *Handler) UpdateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/announcements/{announcementId} - Updating announcement")
+
+	announcementId, ok := parseAnnouncementID(w, r)
+	if !ok {
+		return
+	}
+
+	announcement, err := h.db.GetAnnouncementById(announcementId)
+	if err != nil {
+		writeMappedError(w, err, "Announcement not found", "Failed to get announcement")
+		return
+	}
+
+	// Parse request body
+	var req model.AnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Only change the fields that were sent
+	if req.Title != "" {
+		announcement.Title = req.Title
+	}
+	if req.Message != "" {
+		announcement.Message = req.Message
+	}
+	if req.Level != "" {
+		announcement.Level = req.Level
+	}
+	if req.StartsAt != nil {
+		announcement.StartsAt = req.StartsAt.UTC()
+	}
+	if req.ExpiresAt != nil {
+		announcement.ExpiresAt = model.UTCTime(req.ExpiresAt)
+	}
+
+	if err := h.announcementService.Update(announcement); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Announcement not found", "Failed to update announcement")
+		return
+	}
+
+	log.Info().Int64("announcement_id", announcement.AnnouncementId).Msg("Announcement updated")
+	writeJSONResponse(w, http.StatusOK, announcement)
+}
+
+// POST /api/admin/announcements/{announcementId}/expire - Handler to take an announcement down now
+func (h *Handler) ExpireAnnouncement(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/announcements/{announcementId}/expire - Expiring announcement")
+
+	announcementId, ok := parseAnnouncementID(w, r)
+	if !ok {
+		return
+	}
+
+	announcement, err := h.announcementService.Expire(announcementId)
+	if err != nil {
+		writeMappedError(w, err, "Announcement not found", "Failed to expire announcement")
+		return
+	}
+
+	log.Info().Int64("announcement_id", announcementId).Msg("Announcement expired")
+	writeJSONResponse(w, http.StatusOK, announcement)
+}
+
+// Parses the announcement ID from the URL, writing a 400 if it's invalid
+func parseAnnouncementID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := mux.Vars(r)["announcementId"]
+	announcementId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("announcement_id", idStr).Msg("Invalid announcement ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid announcement ID")
+		return 0, false
+	}
+
+	return announcementId, true
+}
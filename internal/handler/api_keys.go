@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/auth/apikeys - Handler to list the current user's API keys
+func (h *Handler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/auth/apikeys - Getting API keys")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.apiKeyService.List(user.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get API keys")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get API keys")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, keys)
+}
+
+// POST /api/auth/apikeys - Handler to generate an API key for the current user.
+// The key is only ever returned here.
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/auth/apikeys - Generating API key")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req model.APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	key, secret, err := h.apiKeyService.Create(user.ID, req.Name)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to generate API key")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to generate API key")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSONResponse(w, http.StatusCreated, model.APIKeyCreatedResponse{
+		APIKey: *key,
+		Key:    secret,
+	})
+}
+
+// DELETE /api/auth/apikeys/{keyId} - Handler to revoke one of the current user's API keys
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/auth/apikeys/{keyId} - Revoking API key")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	keyId, err := model.ParseID(mux.Vars(r)["keyId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(user.ID, keyId); err != nil {
+		writeMappedError(w, err, "API key not found", "Failed to revoke API key")
+		return
+	}
+
+	log.Info().Int64("user_id", user.ID).Int64("key_id", keyId).Msg("API key revoked")
+	w.WriteHeader(http.StatusNoContent)
+}
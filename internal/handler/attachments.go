@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/service"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Room for the multipart envelope (boundaries, part headers) on top of the file itself
+const multipartOverheadBytes = 64 << 10
+
+// POST /api/attachments - Upload a file (multipart/form-data with a "file" field)
+func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/attachments - Uploading attachment")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	// Reject oversized bodies early; the service enforces the exact per-file limit
+	if h.config.AttachmentMaxBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, h.config.AttachmentMaxBytes+multipartOverheadBytes)
+	}
+
+	// Stream the file part straight to storage instead of buffering the whole form
+	reader, err := r.MultipartReader()
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Request must be multipart/form-data with a \"file\" field")
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			writeErrorResponse(w, http.StatusBadRequest, "Request must be multipart/form-data with a \"file\" field")
+			return
+		}
+		if err != nil {
+			h.writeUploadError(w, err)
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		attachment, err := h.attachmentService.Upload(user.ID, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			h.writeUploadError(w, err)
+			return
+		}
+
+		writeJSONResponse(w, http.StatusCreated, model.NewAttachmentResponse(attachment))
+		return
+	}
+}
+
+// Writes the response for a failed upload, including the limit that was hit
+func (h *Handler) writeUploadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case writeValidationError(w, err):
+	case errors.As(err, &maxBytesErr):
+		log.Warn().Err(err).Msg("Upload body too large")
+		writeErrorResponse(w, http.StatusRequestEntityTooLarge, "files can be up to "+strconv.FormatInt(h.config.AttachmentMaxBytes, 10)+" bytes")
+	case errors.Is(err, service.ErrFileTooLarge):
+		writeMappedError(w, err, strings.TrimPrefix(err.Error(), service.ErrFileTooLarge.Error()+": "), "Failed to upload attachment")
+	case errors.Is(err, service.ErrQuotaExceeded):
+		writeMappedError(w, err, strings.TrimPrefix(err.Error(), service.ErrQuotaExceeded.Error()+": "), "Failed to upload attachment")
+	default:
+		writeMappedError(w, err, "Failed to upload attachment", "Failed to upload attachment")
+	}
+}
+
+// GET /api/attachments - List your attachments (newest first)
+func (h *Handler) GetMyAttachments(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/attachments - Getting attachments")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = maxPageSize
+	}
+
+	attachments, err := h.attachmentService.List(user.ID, limit, offset)
+	if err != nil {
+		writeMappedError(w, err, "Failed to get attachments", "Failed to get attachments")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, model.NewAttachmentResponses(attachments))
+}
+
+// GET /api/attachments/{attachmentId} - Download an attachment
+func (h *Handler) GetAttachment(w http.ResponseWriter, r *http.Request) {
+	attachmentId, err := model.ParseID(mux.Vars(r)["attachmentId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid attachment ID")
+		return
+	}
+
+	attachment, contents, err := h.attachmentService.Open(attachmentId)
+	if err != nil {
+		writeMappedError(w, err, "Attachment not found", "Failed to get attachment")
+		return
+	}
+	defer contents.Close()
+
+	// Images and PDFs display inline; everything else downloads. nosniff stops browsers
+	// from treating the file as anything other than its detected type.
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.ContentType, "image/") || attachment.ContentType == "application/pdf" {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, contents); err != nil {
+		log.Error().Err(err).Int64("attachment_id", attachmentId).Msg("Error writing attachment")
+	}
+}
+
+// DELETE /api/attachments/{attachmentId} - Delete one of your attachments (admins can delete any)
+func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/attachments/{attachmentId} - Deleting attachment")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	attachmentId, err := model.ParseID(mux.Vars(r)["attachmentId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid attachment ID")
+		return
+	}
+
+	if err := h.attachmentService.Delete(user, attachmentId); err != nil {
+		writeMappedError(w, err, "Attachment not found", "Failed to delete attachment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/auth/me/storage - How much attachment storage you're using and your quota
+func (h *Handler) GetStorageUsage(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	usage, err := h.attachmentService.Usage(user.ID)
+	if err != nil {
+		writeMappedError(w, err, "User not found", "Failed to get storage usage")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, usage)
+}
@@ -3,8 +3,11 @@ package handler
 import (
 	"byte-board/internal/middleware"
 	"byte-board/internal/model"
+	"byte-board/internal/service"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 )
@@ -29,42 +32,49 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Create user and profile with auth service
-	user, profile, err := h.authService.Register(req.Username, req.Password, req.FirstName, req.LastName)
+	user, profile, err := h.authService.Register(r.Context(), req.Username, req.Password, req.FirstName, req.LastName, req.Email, h.clientIP(r), req.CaptchaToken)
 	if err != nil {
-		// Specific errors
-		if err.Error() == "username already exists" {
-			log.Warn().Str("username", req.Username).Msg("Username already exists")
-			writeErrorResponse(w, http.StatusConflict, "Username already exists")
+		// Validation errors carry a message that's safe to show the user
+		if errors.Is(err, service.ErrInvalidInput) {
+			log.Warn().Str("username", req.Username).Err(err).Msg("Registration rejected by validation")
+			writeErrorResponse(w, http.StatusBadRequest, strings.TrimPrefix(err.Error(), service.ErrInvalidInput.Error()+": "))
 			return
 		}
-		if err.Error() == "password must be at least 8 characters long" {
-			log.Warn().Msg("Password too short")
-			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+
+		if errors.Is(err, service.ErrCaptchaFailed) {
+			writeMappedError(w, err, "Captcha verification failed, please try again", "Failed to register user")
+			return
+		}
+
+		if errors.Is(err, service.ErrCaptchaUnavailable) {
+			writeMappedError(w, err, "Captcha verification is unavailable, try again later", "Failed to register user")
+			return
+		}
+
+		if errors.Is(err, service.ErrTooManySignups) {
+			writeMappedError(w, err, "Too many accounts created from your network or email today, try again later", "Failed to register user")
 			return
 		}
 
-		log.Error().Err(err).Msg("Failed to register user")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to register user")
+		writeMappedError(w, err, "Username already exists", "Failed to register user")
 		return
 	}
 
 	// Create response
 	response := map[string]interface{}{
 		"message": "User successfully registered",
-		"user": model.UserSummary{
-			UserID:    user.ID,
-			Username:  user.Username,
-			Role:      user.Role,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-		},
-		"profile": profile,
+		"user":    model.NewUserSummary(user),
+		"profile": model.NewProfileResponse(profile, model.Viewer{UserId: user.ID}),
 	}
 
 	log.Info().
 		Str("username", user.Username).
-		Int("user_id", user.ID).
+		Int64("user_id", user.ID).
 		Msg("User registered successfully")
+	h.events.Record(model.EventUserRegistered, user.ID, model.EventSubjectUser, user.ID, map[string]interface{}{
+		"username": user.Username,
+		"ip":       h.clientIP(r),
+	})
 
 	writeJSONResponse(w, http.StatusCreated, response)
 }
@@ -88,9 +98,18 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Authenticate user and get JWT token
-	token, err := h.authService.Login(req.Username, req.Password)
+	// Authenticate user and get JWT and refresh tokens
+	tokens, err := h.authService.Login(req.Username, req.Password, h.clientIP(r), r.UserAgent())
 	if err != nil {
+		if writeLoginThrottledError(w, err) {
+			log.Warn().Str("username", req.Username).Str("ip", h.clientIP(r)).Msg("Login throttled")
+			return
+		}
+		if errors.Is(err, service.ErrAccountBanned) {
+			log.Warn().Str("username", req.Username).Msg("Login from banned account rejected")
+			writeErrorResponse(w, http.StatusForbidden, "Account is banned or suspended")
+			return
+		}
 		// Don't reveal whether user or pass was wrong
 		log.Warn().Str("username", req.Username).Err(err).Msg("Login failed")
 		writeErrorResponse(w, http.StatusUnauthorized, "Invalid username or password")
@@ -105,61 +124,222 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Create response
-	response := model.AuthResponse{
-		Token: token,
-		User: model.UserSummary{
-			UserID:    user.ID,
-			Username:  user.Username,
-			Role:      user.Role,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-		},
-	}
-
-	log.Info().Str("username", user.Username).Int("user_id", user.ID).Msg("User logged in successfully")
-	writeJSONResponse(w, http.StatusOK, response)
+	log.Info().Str("username", user.Username).Int64("user_id", user.ID).Msg("User logged in successfully")
+	writeJSONResponse(w, http.StatusOK, newAuthResponse(tokens, user))
 }
 
-// GET /api/auth/me - GET current user handler
-func (h *Handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
-	log.Info().Msg("GET /api/auth/me - Getting current user")
+// POST /api/auth/refresh - Exchange a refresh token for a new JWT and refresh token
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/auth/refresh - Refreshing token")
 
-	// Get username from JWT middleware context
-	username := middleware.GetUsername(r)
-	if username == "" {
-		log.Warn().Msg("No username in context")
-		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+	var req model.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.RefreshToken == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "refresh_token is required")
 		return
 	}
 
-	// Get user from database
-	user, err := h.db.GetUserByUsername(username)
+	tokens, user, err := h.authService.Refresh(req.RefreshToken)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get current user")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get current user")
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			log.Warn().Err(err).Msg("Refresh rejected")
+			writeErrorResponse(w, http.StatusUnauthorized, "Invalid or expired refresh token")
+			return
+		}
+		if errors.Is(err, service.ErrAccountBanned) {
+			log.Warn().Msg("Refresh from banned account rejected")
+			writeErrorResponse(w, http.StatusForbidden, "Account is banned or suspended")
+			return
+		}
+		log.Error().Err(err).Msg("Failed to refresh token")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to refresh token")
+		return
+	}
+
+	log.Info().Int64("user_id", user.ID).Msg("Token refreshed")
+	writeJSONResponse(w, http.StatusOK, newAuthResponse(tokens, user))
+}
+
+// Builds the response for a freshly issued token pair
+func newAuthResponse(tokens *model.TokenPair, user *model.User) model.AuthResponse {
+	return model.AuthResponse{
+		Token:            tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		RefreshExpiresAt: &tokens.RefreshExpiresAt,
+		User:             model.NewUserSummary(user),
+	}
+}
+
+// GET /api/auth/me - GET current user handler
+func (h *Handler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/auth/me - Getting current user")
+
+	// Get user from database
+	user, ok := h.currentUser(w, r)
+	if !ok {
 		return
 	}
 
 	// Get user profile from database
-	profile, err := h.db.GetProfileByUserId(user.ID)
+	var profileResponse *model.ProfileResponse
+	profile, err := middleware.CachedProfile(r, h.db, user.ID)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to get user profile")
 		// Continue without profile
+	} else {
+		mapped := model.NewProfileResponse(profile, viewerOf(r))
+		profileResponse = &mapped
 	}
 
 	// Create response
 	response := map[string]interface{}{
-		"user": model.UserSummary{
-			UserID:    user.ID,
-			Username:  user.Username,
-			Role:      user.Role,
-			FirstName: user.FirstName,
-			LastName:  user.LastName,
-		},
-		"profile": profile,
+		"user":    model.NewUserSummary(user),
+		"profile": profileResponse,
 	}
 
-	log.Info().Str("username", username).Msg("Successfully retrieved current user")
+	// Include how complete the profile is so UIs can prompt for what's missing
+	if profile != nil {
+		completeness, err := h.profileService.Completeness(profile)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to score profile completeness")
+			// Continue without the score
+		} else {
+			response["profile_completeness"] = completeness
+		}
+	}
+
+	// Include the unread count so UIs can badge the notification bell without another request
+	unread, err := h.notificationService.Unread(user.ID)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to count unread notifications")
+		// Continue without the count
+	} else {
+		response["unread_notifications"] = unread.Count
+	}
+
+	log.Info().Str("username", user.Username).Msg("Successfully retrieved current user")
 	writeJSONResponse(w, http.StatusOK, response)
 }
+
+// Gets the client's IP address (see middleware.ClientIP)
+func (h *Handler) clientIP(r *http.Request) string {
+	return middleware.ClientIP(r, h.config.TrustProxyHeaders)
+}
+
+// PUT /api/auth/me/password (also POST /api/auth/change-password) - Change the current user's password,
+// revoking all existing tokens unless keep_other_sessions is set
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/auth/me/password - Changing password")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Current and new password are required")
+		return
+	}
+
+	tokens, err := h.authService.ChangePassword(user.ID, middleware.GetSessionID(r), req.CurrentPassword, req.NewPassword,
+		req.KeepOtherSessions, h.clientIP(r), r.UserAgent())
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidCredentials) {
+			writeErrorResponse(w, http.StatusUnauthorized, "Current password is incorrect")
+			return
+		}
+		log.Error().Err(err).Msg("Failed to change password")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	log.Info().Str("username", user.Username).Bool("kept_other_sessions", req.KeepOtherSessions).Msg("Password changed")
+	h.events.Record(model.EventUserPasswordChanged, user.ID, model.EventSubjectUser, user.ID,
+		map[string]interface{}{"kept_other_sessions": req.KeepOtherSessions})
+	writeJSONResponse(w, http.StatusOK, newAuthResponse(tokens, user))
+}
+
+// POST /api/auth/forgot-password - Email a password reset token to the accounts using an address
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/auth/forgot-password - Requesting password reset")
+
+	var req model.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Email == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(req.Email); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to request password reset")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to request password reset")
+		return
+	}
+
+	// Same answer whether or not the address belongs to anyone
+	writeJSONResponse(w, http.StatusAccepted, map[string]string{"message": "If an account uses that address, a password reset email is on its way"})
+}
+
+// POST /api/auth/reset-password - Set a new password with an emailed reset token
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/auth/reset-password - Resetting password")
+
+	var req model.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Token == "" || req.NewPassword == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Token and new password are required")
+		return
+	}
+
+	userId, err := h.authService.ResetPassword(req.Token, req.NewPassword)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, service.ErrInvalidToken) {
+			log.Warn().Msg("Password reset rejected")
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid or expired reset token")
+			return
+		}
+		log.Error().Err(err).Msg("Failed to reset password")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Msg("Password reset; existing tokens revoked")
+	h.events.Record(model.EventUserPasswordChanged, userId, model.EventSubjectUser, userId, map[string]interface{}{"via": "reset"})
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Password updated, log in with your new password"})
+}
+
+// GET /.well-known/jwks.json - Handler to publish the public keys tokens are signed with, so other services
+// can verify them without the secret (no keys while tokens are signed with JWT_SECRET)
+func (h *Handler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /.well-known/jwks.json - Getting signing keys")
+
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	writeJSONResponse(w, http.StatusOK, h.authService.JWKS())
+}
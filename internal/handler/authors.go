@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"byte-board/internal/model"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Looks up the current author details for the given users. Content already carries a copy of the
+// author's name, so a failed lookup is logged and responses fall back to that copy.
+func (h *Handler) loadAuthors(userIds []int64) map[int64]model.Author {
+	authors, err := h.db.GetAuthors(userIds)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load authors")
+		return nil
+	}
+	return authors
+}
+
+// Looks up which of the given posts notify a signed-in viewer about new comments (nil when
+// anonymous or the lookup fails, which is logged)
+func (h *Handler) loadWatched(viewer model.Viewer, postIds []int64) map[int64]bool {
+	if viewer.UserId == 0 {
+		return nil
+	}
+
+	watched, err := h.db.GetWatchedAmong(viewer.UserId, postIds)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load watched posts")
+		return nil
+	}
+	return watched
+}
+
+// Builds post responses credited to their authors' current names, with whether a signed-in viewer
+// follows each author and watches each post
+func (h *Handler) postResponses(posts []model.Post, viewer model.Viewer) []model.PostResponse {
+	userIds := make([]int64, 0, len(posts))
+	postIds := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		userIds = append(userIds, post.UserId)
+		postIds = append(postIds, post.PostId)
+	}
+	authors := h.loadAuthors(userIds)
+	followed := h.loadFollowed(viewer, userIds)
+	watched := h.loadWatched(viewer, postIds)
+
+	responses := model.NewPostResponses(posts)
+	for i := range responses {
+		if author, ok := authors[responses[i].UserId]; ok {
+			responses[i].Author = author.Name()
+			responses[i].AuthorInfo = &author
+		}
+		if followed != nil {
+			following := followed[responses[i].UserId]
+			responses[i].FollowingAuthor = &following
+		}
+		if watched != nil {
+			watching := watched[responses[i].PostId]
+			responses[i].Watching = &watching
+		}
+	}
+	return responses
+}
+
+// Builds a post response credited to its author's current name, shaped for the viewer
+func (h *Handler) postResponse(post *model.Post, viewer model.Viewer) model.PostResponse {
+	return h.postResponses([]model.Post{*post}, viewer)[0]
+}
+
+// Builds comment responses credited to their authors' current names
+func (h *Handler) commentResponses(comments []model.Comment) []model.CommentResponse {
+	userIds := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		userIds = append(userIds, comment.UserId)
+	}
+	authors := h.loadAuthors(userIds)
+
+	responses := model.NewCommentResponses(comments)
+	for i := range responses {
+		if author, ok := authors[responses[i].UserId]; ok {
+			responses[i].Author = author.Name()
+			responses[i].AuthorInfo = &author
+		}
+	}
+	return responses
+}
+
+// Builds a comment response credited to its author's current name
+func (h *Handler) commentResponse(comment *model.Comment) model.CommentResponse {
+	return h.commentResponses([]model.Comment{*comment})[0]
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Backups listed when no limit is given
+const defaultBackupPageSize = 20
+
+// POST /api/admin/backups - Handler to queue a database backup with admin permissions
+// (track it with GET /api/admin/maintenance/jobs/{jobId})
+func (h *Handler) StartBackup(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/backups - Queueing database backup")
+
+	// Backups queued by a service client have no user behind them
+	var triggeredBy *int64
+	if adminId := middleware.GetUserID(r); adminId != 0 {
+		triggeredBy = &adminId
+	}
+
+	status, err := h.backupService.Start(triggeredBy)
+	if err != nil {
+		writeQueueError(w, err, "Failed to queue backup")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusAccepted, status)
+}
+
+// GET /api/admin/backups - Handler to list recent database backups and their status with admin permissions
+func (h *Handler) GetBackups(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/backups - Getting backups")
+
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = defaultBackupPageSize
+	}
+
+	backups, err := h.backupService.List(limit, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get backups")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get backups")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, backups)
+}
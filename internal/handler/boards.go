@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"byte-board/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/boards - Handler to list the boards posts can be filed under
+func (h *Handler) GetBoards(w http.ResponseWriter, r *http.Request) {
+	boards, err := h.postService.Boards()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get boards")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get boards")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, boards)
+}
+
+// PUT /api/admin/boards/{slug} - Handler to create a board or change its name and description
+func (h *Handler) SetBoard(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/boards/{slug} - Setting board")
+
+	var req model.BoardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	board, err := h.postService.SetBoard(mux.Vars(r)["slug"], req)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to set board")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to save board")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, board)
+}
+
+// DELETE /api/admin/boards/{slug} - Handler to delete a board (its posts stay, without a board)
+func (h *Handler) DeleteBoard(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/admin/boards/{slug} - Deleting board")
+
+	if err := h.postService.DeleteBoard(mux.Vars(r)["slug"]); err != nil {
+		writeMappedError(w, err, "Board not found", "Failed to delete board")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/subscriptions - Handler to get the boards and tags you subscribe to
+func (h *Handler) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	h.writeSubscriptions(w, middleware.GetUserID(r))
+}
+
+// PUT /api/subscriptions/boards/{slug} - Handler to subscribe to a board's posts
+func (h *Handler) SubscribeToBoard(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/subscriptions/boards/{slug} - Subscribing to board")
+
+	board, err := h.postService.Board(mux.Vars(r)["slug"])
+	if err != nil {
+		writeMappedError(w, err, "Board not found", "Failed to subscribe to board")
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	if err := h.db.SubscribeToBoard(userId, board.BoardId); err != nil {
+		log.Error().Err(err).Msg("Failed to subscribe to board")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to subscribe to board")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Str("board", board.Slug).Msg("Subscribed to board")
+	h.writeSubscriptions(w, userId)
+}
+
+// DELETE /api/subscriptions/boards/{slug} - Handler to unsubscribe from a board
+func (h *Handler) UnsubscribeFromBoard(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/subscriptions/boards/{slug} - Unsubscribing from board")
+
+	board, err := h.postService.Board(mux.Vars(r)["slug"])
+	if err != nil {
+		writeMappedError(w, err, "Board not found", "Failed to unsubscribe from board")
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	if err := h.db.UnsubscribeFromBoard(userId, board.BoardId); err != nil {
+		writeMappedError(w, err, "You aren't subscribed to that board", "Failed to unsubscribe from board")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Str("board", board.Slug).Msg("Unsubscribed from board")
+	h.writeSubscriptions(w, userId)
+}
+
+// PUT /api/subscriptions/tags/{tag} - Handler to subscribe to the posts carrying a tag
+func (h *Handler) SubscribeToTag(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/subscriptions/tags/{tag} - Subscribing to tag")
+
+	tag, err := service.NormalizeTag(mux.Vars(r)["tag"])
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	if err := h.db.SubscribeToTag(userId, tag); err != nil {
+		log.Error().Err(err).Msg("Failed to subscribe to tag")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to subscribe to tag")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Str("tag", tag).Msg("Subscribed to tag")
+	h.writeSubscriptions(w, userId)
+}
+
+// DELETE /api/subscriptions/tags/{tag} - Handler to unsubscribe from a tag
+func (h *Handler) UnsubscribeFromTag(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/subscriptions/tags/{tag} - Unsubscribing from tag")
+
+	tag, err := service.NormalizeTag(mux.Vars(r)["tag"])
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	if err := h.db.UnsubscribeFromTag(userId, tag); err != nil {
+		writeMappedError(w, err, "You aren't subscribed to that tag", "Failed to unsubscribe from tag")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Str("tag", tag).Msg("Unsubscribed from tag")
+	h.writeSubscriptions(w, userId)
+}
+
+// GET /api/feed?scope=subscriptions - Handler to get the posts on the boards and with the tags you subscribe to
+func (h *Handler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/feed - Getting feed")
+
+	if r.URL.Query().Get("scope") != "subscriptions" {
+		writeErrorResponse(w, http.StatusBadRequest, "scope must be subscriptions")
+		return
+	}
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	posts, more, err := h.db.GetSubscriptionFeed(middleware.GetUserID(r), limit, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get subscription feed")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get feed")
+		return
+	}
+	if more {
+		setNextPageLink(w, r, offset, len(posts))
+	}
+
+	writeJSONResponse(w, http.StatusOK, h.postResponses(posts, viewerOf(r)))
+}
+
+// Writes the boards and tags a user subscribes to
+func (h *Handler) writeSubscriptions(w http.ResponseWriter, userId int64) {
+	subscriptions, err := h.db.GetSubscriptions(userId)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get subscriptions")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get subscriptions")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, subscriptions)
+}
+
+// Looks up the board a post is filed under by its slug ("" for none), writing a 400 for unknown boards
+func (h *Handler) postBoard(w http.ResponseWriter, slug string) (*int64, bool) {
+	if slug == "" {
+		return nil, true
+	}
+
+	board, err := h.postService.Board(slug)
+	if errors.Is(err, repository.ErrNotFound) {
+		writeErrorResponse(w, http.StatusBadRequest, "Unknown board "+slug)
+		return nil, false
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get board")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get board")
+		return nil, false
+	}
+
+	return &board.BoardId, true
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/auth/me/digest - Handler to get the current user's digest email settings
+func (h *Handler) GetDigestSettings(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/auth/me/digest - Getting digest settings")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.digestService.GetSettings(user.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get digest settings")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get digest settings")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, settings)
+}
+
+// PUT /api/auth/me/digest - Handler to change how often the current user gets the digest email
+func (h *Handler) UpdateDigestSettings(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/auth/me/digest - Updating digest settings")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req model.DigestSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	settings, err := h.digestService.SetFrequency(user.ID, req.Frequency)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to update digest settings")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to update digest settings")
+		return
+	}
+
+	log.Info().Int64("user_id", user.ID).Str("frequency", settings.Frequency).Msg("Digest settings updated")
+	writeJSONResponse(w, http.StatusOK, settings)
+}
+
+// GET /api/digest?period=day|week - Handler to get the site digest (top posts, top commenters and new members)
+func (h *Handler) GetSiteDigest(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/digest - Getting site digest")
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "week"
+	}
+
+	digest, err := h.digestService.GetSiteDigest(period)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to get site digest")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get site digest")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, digest)
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Header carrying the edit lock token when renewing or releasing a lock and when saving a post
+const editLockHeader = "X-Edit-Lock"
+
+// Body of the 409 sent while someone else holds a post's edit lock
+type postLockedResponse struct {
+	ErrorResponse
+	Lock *model.PostEditLock `json:"lock"`
+}
+
+// Body of GET /api/posts/{postId}/edit-lock
+type editLockStatus struct {
+	Locked bool                `json:"locked"`
+	Lock   *model.PostEditLock `json:"lock,omitempty"`
+}
+
+// GET /api/posts/{postId}/edit-lock - Handler to check whether a post is being edited, and by whom
+func (h *Handler) GetEditLock(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.editLockTarget(w, r)
+	if !ok {
+		return
+	}
+
+	lock, err := h.editLockService.Get(post.PostId)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get post edit lock")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get edit lock")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, editLockStatus{Locked: lock != nil, Lock: lock})
+}
+
+// POST /api/posts/{postId}/edit-lock - Handler to start editing a post (returns the lock token)
+func (h *Handler) AcquireEditLock(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/posts/{postId}/edit-lock - Acquiring edit lock")
+
+	post, ok := h.editLockTarget(w, r)
+	if !ok {
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	lock, err := h.editLockService.Acquire(post.PostId, userId)
+	if err != nil {
+		if writePostLockedError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to acquire post edit lock")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to acquire edit lock")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Int64("post_id", post.PostId).Msg("Post edit lock acquired")
+	writeJSONResponse(w, http.StatusCreated, lock)
+}
+
+// PUT /api/posts/{postId}/edit-lock - Handler to keep editing a post (extends the lock sent in X-Edit-Lock)
+func (h *Handler) RenewEditLock(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.editLockTarget(w, r)
+	if !ok {
+		return
+	}
+
+	token := r.Header.Get(editLockHeader)
+	if token == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Missing "+editLockHeader+" header")
+		return
+	}
+
+	lock, err := h.editLockService.Renew(post.PostId, middleware.GetUserID(r), token)
+	if err != nil {
+		if writePostLockedError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to renew post edit lock")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to renew edit lock")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, lock)
+}
+
+// DELETE /api/posts/{postId}/edit-lock - Handler to stop editing a post (releases the lock sent in X-Edit-Lock)
+func (h *Handler) ReleaseEditLock(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/posts/{postId}/edit-lock - Releasing edit lock")
+
+	post, ok := h.editLockTarget(w, r)
+	if !ok {
+		return
+	}
+
+	token := r.Header.Get(editLockHeader)
+	if token == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "Missing "+editLockHeader+" header")
+		return
+	}
+
+	if err := h.editLockService.Release(post.PostId, token); err != nil {
+		log.Error().Err(err).Msg("Failed to release post edit lock")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to release edit lock")
+		return
+	}
+
+	log.Info().Int64("post_id", post.PostId).Msg("Post edit lock released")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Loads the post named in the URL, checking the current user may edit it (writes the error response if not)
+func (h *Handler) editLockTarget(w http.ResponseWriter, r *http.Request) (*model.Post, bool) {
+	idStr := mux.Vars(r)["postId"]
+	postId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("post_id", idStr).Msg("Invalid post ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
+		return nil, false
+	}
+
+	post, err := h.db.GetPostById(postId)
+	if err != nil {
+		writeMappedError(w, err, "Post not found", "Failed to get post")
+		return nil, false
+	}
+
+	userId := middleware.GetUserID(r)
+	if post.UserId != userId {
+		log.Warn().Int64("userId", userId).Int64("postId", post.PostId).Msg("User does not own this post")
+		writeErrorResponse(w, http.StatusForbidden, "You can only edit your own posts")
+		return nil, false
+	}
+
+	return post, true
+}
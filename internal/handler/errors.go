@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"byte-board/internal/federation"
+	"byte-board/internal/repository"
+	"byte-board/internal/service"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Methods checked when working out which ones a path accepts
+var routableMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// Maps errors from the repository and service layers to HTTP status codes
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrInvalidInput), errors.Is(err, service.ErrInvalidToken), errors.Is(err, service.ErrCaptchaFailed):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, federation.ErrBadSignature):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrTooManySignups), errors.Is(err, service.ErrContentQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, service.ErrFileTooLarge), errors.Is(err, service.ErrQuotaExceeded):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrTranslationUnavailable), errors.Is(err, service.ErrCaptchaUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, service.ErrTranslationFailed):
+		return http.StatusBadGateway
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Writes the response for an error from the repository or service layer.
+// Known errors get their mapped status and clientMessage; anything else is
+// logged and reported as a 500 with serverMessage.
+func writeMappedError(w http.ResponseWriter, err error, clientMessage, serverMessage string) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Error().Err(err).Msg(serverMessage)
+		writeErrorResponse(w, status, serverMessage)
+		return
+	}
+
+	log.Warn().Err(err).Int("status", status).Msg(clientMessage)
+	writeErrorResponse(w, status, clientMessage)
+}
+
+// Writes a 400 with the validation message when err wraps service.ErrInvalidInput.
+// Reports whether it handled the error.
+func writeValidationError(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, service.ErrInvalidInput) {
+		return false
+	}
+
+	log.Warn().Err(err).Msg("Request rejected by validation")
+	writeErrorResponse(w, http.StatusBadRequest, strings.TrimPrefix(err.Error(), service.ErrInvalidInput.Error()+": "))
+	return true
+}
+
+// Writes a 429 with a Retry-After header when err is a service.ContentQuotaError.
+// Reports whether it handled the error.
+func writeContentQuotaError(w http.ResponseWriter, err error) bool {
+	var quotaErr *service.ContentQuotaError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(quotaErr.RetryAfter.Seconds()))))
+	writeJSONResponse(w, http.StatusTooManyRequests, ErrorResponse{Error: quotaErr.Error(), Code: "content_quota_exceeded"})
+	return true
+}
+
+// Writes a 429 with a Retry-After header when err is a service.LoginThrottledError.
+// Reports whether it handled the error.
+func writeLoginThrottledError(w http.ResponseWriter, err error) bool {
+	var throttledErr *service.LoginThrottledError
+	if !errors.As(err, &throttledErr) {
+		return false
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttledErr.RetryAfter.Seconds()))))
+	writeJSONResponse(w, http.StatusTooManyRequests, ErrorResponse{Error: "Too many failed logins, try again later", Code: "login_throttled"})
+	return true
+}
+
+// Writes a 409 naming the lock holder when err is a service.PostLockedError. Reports whether it handled the error.
+func writePostLockedError(w http.ResponseWriter, err error) bool {
+	var lockedErr *service.PostLockedError
+	if !errors.As(err, &lockedErr) {
+		return false
+	}
+
+	log.Warn().Int64("post_id", lockedErr.Lock.PostId).Str("locked_by", lockedErr.Lock.LockedBy).Msg("Post is locked for editing")
+	writeJSONResponse(w, http.StatusConflict, postLockedResponse{
+		ErrorResponse: ErrorResponse{Error: lockedErr.Error(), Code: "post_locked"},
+		Lock:          lockedErr.Lock,
+	})
+	return true
+}
+
+// Replies to requests that match no route: a JSON 405 with an Allow header when the path exists under other
+// methods, otherwise a JSON 404. Set it as both the router's NotFoundHandler and MethodNotAllowedHandler;
+// gorilla reports some wrong-method requests to nested subrouters as not found, so both cases check the methods.
+func RouteNotMatched(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range routableMethods {
+			probe := r.Clone(r.Context())
+			probe.Method = method
+
+			var match mux.RouteMatch
+			if router.Match(probe, &match) && match.MatchErr == nil {
+				allowed = append(allowed, method)
+			}
+		}
+
+		if len(allowed) == 0 {
+			log.Warn().Str("method", r.Method).Str("path", r.URL.Path).Msg("No route matched")
+			writeJSONResponse(w, http.StatusNotFound, ErrorResponse{Error: "Not found", Code: "not_found"})
+			return
+		}
+
+		log.Warn().Str("method", r.Method).Str("path", r.URL.Path).Strs("allowed", allowed).Msg("Method not allowed")
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeJSONResponse(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed", Code: "method_not_allowed"})
+	})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/admin/events/replay/targets - Handler to list the consumers events can be replayed to with admin permissions
+func (h *Handler) GetEventReplayTargets(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/events/replay/targets - Getting event replay targets")
+
+	writeJSONResponse(w, http.StatusOK, map[string][]string{"targets": h.eventReplayService.Targets()})
+}
+
+// POST /api/admin/events/replay - Handler to queue a replay of domain events to a consumer with admin permissions.
+// Follow the job at GET /api/admin/maintenance/jobs/{jobId}.
+func (h *Handler) StartEventReplay(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/events/replay - Queueing event replay")
+
+	// Parse request body
+	var req model.EventReplayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	status, err := h.eventReplayService.Replay(req.Target, model.EventReplayFilter{
+		Types:   req.Types,
+		Since:   model.UTCTime(req.Since),
+		Until:   model.UTCTime(req.Until),
+		AfterId: req.AfterId,
+	})
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeQueueError(w, err, "Failed to queue event replay")
+		return
+	}
+
+	log.Info().Int64("admin_id", middleware.GetUserID(r)).Str("target", req.Target).Strs("types", req.Types).Int("job_id", status.ID).Msg("Event replay queued")
+	writeJSONResponse(w, http.StatusAccepted, status)
+}
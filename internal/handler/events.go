@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Events returned when no limit is given
+const defaultEventPageSize = 50
+
+// GET /api/admin/events?type=&actor_id=&before=&limit=&offset= - Handler to page through recent domain events
+// (newest first) with admin permissions. Pass the last event_id of a page as before to get the next one.
+func (h *Handler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/events - Getting domain events")
+
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = defaultEventPageSize
+	}
+	actorId, err := parseOptionalID(r, "actor_id")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	before, err := parseBefore(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	events, err := h.events.List(model.EventFilter{
+		Type:    r.URL.Query().Get("type"),
+		ActorId: actorId,
+		Before:  before,
+		Limit:   limit,
+		Offset:  offset,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get events")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get events")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, events)
+}
+
+// GET /api/admin/data-access?user_id=&viewer_id=&before=&limit=&offset= - Handler to page through the log of admins
+// viewing users' personal data (newest first) with admin permissions. Bulk exports have no user_id, so they only
+// show up when not filtering by user. Reading this log isn't itself recorded.
+func (h *Handler) GetDataAccessLog(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/data-access - Getting data access log")
+
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = defaultEventPageSize
+	}
+	userId, err := parseOptionalID(r, "user_id")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	viewerId, err := parseOptionalID(r, "viewer_id")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	before, err := parseBefore(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	accesses, err := h.events.ListDataAccess(model.DataAccessFilter{
+		UserId:   userId,
+		ViewerId: viewerId,
+		Before:   before,
+		Limit:    limit,
+		Offset:   offset,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get data access log")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get data access log")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, accesses)
+}
+
+// Records that the requesting admin viewed a user's personal data
+func (h *Handler) recordDataAccess(r *http.Request, userId int64, access string) {
+	h.events.RecordDataAccess(middleware.GetUserID(r), userId, access)
+}
+
+// Parses the optional before cursor of a log listing (0 when not given)
+func parseBefore(r *http.Request) (int64, error) {
+	beforeStr := r.URL.Query().Get("before")
+	if beforeStr == "" {
+		return 0, nil
+	}
+
+	before, err := strconv.ParseInt(beforeStr, 10, 64)
+	if err != nil || before < 1 {
+		return 0, fmt.Errorf("before must be a positive number")
+	}
+	return before, nil
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"byte-board/internal/federation"
+	"byte-board/internal/model"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Largest activity accepted in an inbox
+const maxInboxBytes = 1 << 20
+
+// Reports whether federation is enabled, so the ActivityPub routes are served
+func (h *Handler) Federating() bool {
+	return h.federationService != nil
+}
+
+// Writes an ActivityPub (or WebFinger) document with its media type
+func writeActivityResponse(w http.ResponseWriter, contentType string, data interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Error().Err(err).Msg("Error encoding ActivityPub response")
+	}
+}
+
+// GET /.well-known/webfinger?resource=acct:username@host - Handler to find a user's ActivityPub actor
+func (h *Handler) WebFinger(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /.well-known/webfinger - Resolving fediverse account")
+
+	resource := r.URL.Query().Get("resource")
+	if resource == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "resource is required")
+		return
+	}
+
+	finger, err := h.federationService.WebFinger(resource)
+	if writeValidationError(w, err) {
+		return
+	}
+	if err != nil {
+		writeMappedError(w, err, "Account not found", "Failed to resolve account")
+		return
+	}
+
+	writeActivityResponse(w, federation.WebFingerContentType, finger)
+}
+
+// GET /users/{username} - Handler to get a user's ActivityPub actor
+func (h *Handler) GetActor(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /users/{username} - Getting ActivityPub actor")
+
+	actor, err := h.federationService.Actor(mux.Vars(r)["username"])
+	if err != nil {
+		writeMappedError(w, err, "Actor not found", "Failed to get actor")
+		return
+	}
+
+	writeActivityResponse(w, federation.ContentType, actor)
+}
+
+// GET /users/{username}/outbox?page= - Handler to list a user's public posts as ActivityPub activities
+// (without page, the collection summary linking to the first page)
+func (h *Handler) GetOutbox(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /users/{username}/outbox - Getting ActivityPub outbox")
+
+	username := mux.Vars(r)["username"]
+	pageStr := r.URL.Query().Get("page")
+	if pageStr == "" {
+		outbox, err := h.federationService.Outbox(username)
+		if err != nil {
+			writeMappedError(w, err, "Actor not found", "Failed to get outbox")
+			return
+		}
+		writeActivityResponse(w, federation.ContentType, outbox)
+		return
+	}
+
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "page must be a number")
+		return
+	}
+
+	outboxPage, err := h.federationService.OutboxPage(username, page)
+	if writeValidationError(w, err) {
+		return
+	}
+	if err != nil {
+		writeMappedError(w, err, "Actor not found", "Failed to get outbox")
+		return
+	}
+
+	writeActivityResponse(w, federation.ContentType, outboxPage)
+}
+
+// GET /users/{username}/followers - Handler to get how many fediverse accounts follow a user
+func (h *Handler) GetFederationFollowers(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /users/{username}/followers - Getting ActivityPub followers")
+
+	followers, err := h.federationService.Followers(mux.Vars(r)["username"])
+	if err != nil {
+		writeMappedError(w, err, "Actor not found", "Failed to get followers")
+		return
+	}
+
+	writeActivityResponse(w, federation.ContentType, followers)
+}
+
+// GET /users/{username}/posts/{postId} - Handler to get one of a user's public posts as an ActivityPub note
+func (h *Handler) GetNote(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /users/{username}/posts/{postId} - Getting ActivityPub note")
+
+	vars := mux.Vars(r)
+	postId, err := model.ParseID(vars["postId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	note, err := h.federationService.Note(vars["username"], postId)
+	if err != nil {
+		writeMappedError(w, err, "Post not found", "Failed to get post")
+		return
+	}
+
+	writeActivityResponse(w, federation.ContentType, note)
+}
+
+// POST /users/{username}/inbox - Handler to receive activities from fediverse servers (follows and unfollows;
+// everything else is accepted and ignored)
+func (h *Handler) PostInbox(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /users/{username}/inbox - Receiving ActivityPub activity")
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxInboxBytes))
+	if err != nil {
+		writeErrorResponse(w, http.StatusRequestEntityTooLarge, "Activity is too large")
+		return
+	}
+
+	err = h.federationService.HandleInbox(r.Context(), mux.Vars(r)["username"], r, body)
+	if writeValidationError(w, err) {
+		return
+	}
+	if err != nil {
+		writeMappedError(w, err, "Activity rejected", "Failed to handle activity")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
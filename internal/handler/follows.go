@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// POST /api/users/{userId}/follow - Handler to follow a user (their followers-only posts become readable)
+func (h *Handler) FollowUser(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/users/{userId}/follow - Following user")
+
+	followeeId, ok := h.followTarget(w, r)
+	if !ok {
+		return
+	}
+
+	// Make sure the user exists so a bad ID is a 404 rather than a foreign key error
+	if _, err := h.db.GetUserByID(followeeId); err != nil {
+		writeMappedError(w, err, "User not found", "Failed to follow user")
+		return
+	}
+
+	if err := h.db.FollowUser(middleware.GetUserID(r), followeeId); err != nil {
+		log.Error().Err(err).Msg("Failed to follow user")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to follow user")
+		return
+	}
+
+	log.Info().Int64("follower_id", middleware.GetUserID(r)).Int64("followee_id", followeeId).Msg("User followed")
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Now following user"})
+}
+
+// DELETE /api/users/{userId}/follow - Handler to unfollow a user
+func (h *Handler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/users/{userId}/follow - Unfollowing user")
+
+	followeeId, ok := h.followTarget(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.db.UnfollowUser(middleware.GetUserID(r), followeeId); err != nil {
+		writeMappedError(w, err, "You aren't following that user", "Failed to unfollow user")
+		return
+	}
+
+	log.Info().Int64("follower_id", middleware.GetUserID(r)).Int64("followee_id", followeeId).Msg("User unfollowed")
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "No longer following user"})
+}
+
+// Parses the user to follow or unfollow from the URL, writing a 400 if it's invalid or the caller themselves
+func (h *Handler) followTarget(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := mux.Vars(r)["userId"]
+	followeeId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("user_id", idStr).Msg("Invalid user ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+	if followeeId == middleware.GetUserID(r) {
+		writeErrorResponse(w, http.StatusBadRequest, "You can't follow yourself")
+		return 0, false
+	}
+
+	return followeeId, true
+}
@@ -2,13 +2,17 @@ package handler
 
 import (
 	"byte-board/internal/appconfig"
+	"byte-board/internal/language"
 	"byte-board/internal/middleware"
 	"byte-board/internal/model"
 	"byte-board/internal/repository"
 	"byte-board/internal/service"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -19,20 +23,116 @@ type Handler struct {
 	db          *repository.DB
 	config      *appconfig.Config
 	authService *service.AuthService
+	postService *service.PostService
+	readOnly    *middleware.ReadOnlyMode
+	leaderboard *service.LeaderboardService
+	postLists   *service.PostListCache
+
+	snippetService *service.SnippetService
+	gistService    *service.GistService
+	profileService *service.ProfileService
+	reportService  *service.ReportService
+
+	moderationService *service.ModerationService
+	commentService    *service.CommentService
+	wordFilter        *service.WordFilterService
+	digestService     *service.DigestService
+
+	notificationService *service.NotificationService
+	attachmentService   *service.AttachmentService
+	maintenanceService  *service.MaintenanceService
+	cleanupService      *service.CleanupService
+	events              *service.EventService
+
+	announcementService *service.AnnouncementService
+	backupService       *service.BackupService
+	translationService  *service.TranslationService
+
+	serviceClientService *service.ServiceClientService
+	keywordAlertService  *service.KeywordAlertService
+	editLockService      *service.EditLockService
+	presenceService      *service.PresenceService
+	skillService         *service.SkillService
+	apiKeyService        *service.APIKeyService
+	eventReplayService   *service.EventReplayService
+	sessionService       *service.SessionService
+	roleService          *service.RoleService
+	impersonationService *service.ImpersonationService
+	searchService        *service.SearchService
+	// nil when federation is disabled
+	federationService *service.FederationService
 }
 
 // Create a new instance of a handler
-func New(db *repository.DB, cfg *appconfig.Config, authService *service.AuthService) *Handler {
+func New(db *repository.DB, cfg *appconfig.Config, authService *service.AuthService, postService *service.PostService, readOnly *middleware.ReadOnlyMode,
+	leaderboard *service.LeaderboardService, postLists *service.PostListCache, snippetService *service.SnippetService,
+	gistService *service.GistService, profileService *service.ProfileService,
+	reportService *service.ReportService, moderationService *service.ModerationService,
+	commentService *service.CommentService, wordFilter *service.WordFilterService,
+	digestService *service.DigestService, notificationService *service.NotificationService,
+	attachmentService *service.AttachmentService, maintenanceService *service.MaintenanceService,
+	cleanupService *service.CleanupService, events *service.EventService,
+	announcementService *service.AnnouncementService, backupService *service.BackupService,
+	translationService *service.TranslationService, serviceClientService *service.ServiceClientService,
+	keywordAlertService *service.KeywordAlertService, editLockService *service.EditLockService,
+	presenceService *service.PresenceService, skillService *service.SkillService,
+	apiKeyService *service.APIKeyService, eventReplayService *service.EventReplayService,
+	sessionService *service.SessionService, roleService *service.RoleService,
+	impersonationService *service.ImpersonationService, searchService *service.SearchService,
+	federationService *service.FederationService) *Handler {
 	return &Handler{
 		db:          db,
 		config:      cfg,
 		authService: authService,
+		postService: postService,
+		readOnly:    readOnly,
+		leaderboard: leaderboard,
+		postLists:   postLists,
+
+		snippetService: snippetService,
+		gistService:    gistService,
+		profileService: profileService,
+		reportService:  reportService,
+
+		moderationService: moderationService,
+		commentService:    commentService,
+		wordFilter:        wordFilter,
+		digestService:     digestService,
+
+		notificationService: notificationService,
+		attachmentService:   attachmentService,
+		maintenanceService:  maintenanceService,
+		cleanupService:      cleanupService,
+		events:              events,
+
+		announcementService: announcementService,
+		backupService:       backupService,
+		translationService:  translationService,
+
+		serviceClientService: serviceClientService,
+		keywordAlertService:  keywordAlertService,
+		editLockService:      editLockService,
+		presenceService:      presenceService,
+		skillService:         skillService,
+		apiKeyService:        apiKeyService,
+		eventReplayService:   eventReplayService,
+		sessionService:       sessionService,
+		roleService:          roleService,
+		impersonationService: impersonationService,
+		searchService:        searchService,
+		federationService:    federationService,
 	}
 }
 
-// Represents an error response
+// Gets the database for the request: bound to its transaction on routes wrapped in middleware.Transaction
+func (h *Handler) dbFor(r *http.Request) *repository.DB {
+	return repository.FromContext(r.Context(), h.db)
+}
+
+// Represents an error response (Code is a machine-readable reason, set by the router-level errors)
 type ErrorResponse struct {
 	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
 }
 
 // Writes a JSON response
@@ -51,13 +151,116 @@ func writeErrorResponse(w http.ResponseWriter, status int, message string) {
 	writeJSONResponse(w, status, ErrorResponse{Error: message})
 }
 
+// Maximum page size for listing endpoints
+const maxPageSize = 100
+
+// Comments returned per post by the bulk comments endpoint when per_post isn't given
+const defaultCommentsPerPost = 3
+
+// Parses the shared sort, limit and offset query parameters of listing endpoints
+func parseListParams(r *http.Request) (sort string, limit int, offset int, err error) {
+	query := r.URL.Query()
+	sort = query.Get("sort")
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return "", 0, 0, fmt.Errorf("limit must be between 1 and %d", maxPageSize)
+		}
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return "", 0, 0, fmt.Errorf("offset must be a non-negative number")
+		}
+	}
+
+	return sort, limit, offset, nil
+}
+
+// Points clients at the rest of a listing that had more rows than one page: a Link header (rel="next")
+// to the same URL with the offset moved past the rows returned
+func setNextPageLink(w http.ResponseWriter, r *http.Request, offset, returned int) {
+	next := *r.URL
+	query := next.Query()
+	query.Set("offset", strconv.Itoa(offset+returned))
+	next.RawQuery = query.Encode()
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+}
+
+// Parses a comma-separated list of up to max positive IDs (duplicates dropped, order kept)
+func parseIDList(value string, max int) ([]int64, error) {
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, part := range strings.Split(value, ",") {
+		id, err := model.ParseID(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("must be a comma-separated list of IDs")
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > max {
+		return nil, fmt.Errorf("can list at most %d IDs", max)
+	}
+
+	return ids, nil
+}
+
+// Parses an optional numeric ID query parameter (0 when absent)
+func parseOptionalID(r *http.Request, name string) (int64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := model.ParseID(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a positive number", name)
+	}
+
+	return id, nil
+}
+
 // #region Comment handlers
 
-// GET /api/comments - Handler to get all comments
+// GET /api/comments?user_id=&post_id=&sort=oldest|newest&limit=&offset= - Handler to get all comments
+// GET /api/comments?postIds=1,2,3&per_post=&sort= - Comments grouped by post (delegates to getCommentsByPosts)
 func (h *Handler) GetAllComments(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("GET /comments - Getting all comments")
 
-	comments, err := h.db.GetAllComments()
+	if r.URL.Query().Has("postIds") {
+		h.getCommentsByPosts(w, r)
+		return
+	}
+
+	// Parse filters
+	sort, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userId, err := parseOptionalID(r, "user_id")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	postId, err := parseOptionalID(r, "post_id")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	comments, err := h.db.ListComments(model.CommentFilter{
+		UserId:   userId,
+		PostId:   postId,
+		Sort:     sort,
+		Limit:    limit,
+		Offset:   offset,
+		ViewerId: h.viewerId(r),
+	})
 	if err != nil {
 		log.Error().Err(err).Msg("Error getting comments")
 		writeErrorResponse(w, http.StatusInternalServerError, "failed to get comments")
@@ -65,7 +268,60 @@ func (h *Handler) GetAllComments(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Info().Int("count", len(comments)).Msg("Successfully retrieved comments!")
-	writeJSONResponse(w, http.StatusOK, comments)
+	writeJSONResponse(w, http.StatusOK, h.commentResponses(comments))
+}
+
+// Returns the first per_post comments on each requested post in one query, so feeds don't need a request per post
+func (h *Handler) getCommentsByPosts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	postIds, err := parseIDList(query.Get("postIds"), maxPageSize)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "postIds "+err.Error())
+		return
+	}
+
+	perPost := defaultCommentsPerPost
+	if perPostStr := query.Get("per_post"); perPostStr != "" {
+		perPost, err = strconv.Atoi(perPostStr)
+		if err != nil || perPost < 1 || perPost > maxPageSize {
+			writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("per_post must be between 1 and %d", maxPageSize))
+			return
+		}
+	}
+
+	comments, err := h.db.GetCommentsByPosts(postIds, perPost, query.Get("sort"), h.viewerId(r))
+	if err != nil {
+		log.Error().Err(err).Msg("Error getting comments by posts")
+		writeErrorResponse(w, http.StatusInternalServerError, "failed to get comments")
+		return
+	}
+
+	// Look up every commenter at once, then regroup by post
+	var all []model.Comment
+	for _, postComments := range comments {
+		all = append(all, postComments...)
+	}
+	byPost := make(map[int64][]model.CommentResponse, len(comments))
+	for _, comment := range h.commentResponses(all) {
+		byPost[comment.PostId] = append(byPost[comment.PostId], comment)
+	}
+
+	// Keep the requested order, including posts without comments
+	response := make([]model.PostCommentsResponse, 0, len(postIds))
+	for _, postId := range postIds {
+		postComments := byPost[postId]
+		if postComments == nil {
+			postComments = []model.CommentResponse{}
+		}
+		response = append(response, model.PostCommentsResponse{
+			PostId:   postId,
+			Comments: postComments,
+		})
+	}
+
+	log.Info().Int("posts", len(postIds)).Msg("Successfully retrieved comments by posts")
+	writeJSONResponse(w, http.StatusOK, response)
 }
 
 // GET /api/comments/{commentId} - Handler to get a comment by comment ID
@@ -78,28 +334,20 @@ func (h *Handler) GetCommentById(w http.ResponseWriter, r *http.Request) {
 	log.Info().Str("comment_id", idStr).Msg("GET /comments/{CommentID} - Getting comment by ID")
 
 	// Convert id string into an int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("id", idStr).Msg("Invalid ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
 		return
 	}
 
-	// Get comment by id from the database
-	comment, err := h.db.GetCommentById(id)
-	if err != nil {
-		if err.Error() == "comment not found" {
-			log.Warn().Int("ID", id).Msg("Comment with that ID not found")
-			writeErrorResponse(w, http.StatusNotFound, "Comment not found")
-			return
-		}
-		log.Error().Err(err).Msg("Failed to get comment by ID")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get that comment")
+	comment, ok := h.readableComment(w, r, id)
+	if !ok {
 		return
 	}
 
-	log.Info().Int("ID", id).Msg("Successfully retrieved the comment")
-	writeJSONResponse(w, http.StatusOK, comment)
+	log.Info().Int64("ID", id).Msg("Successfully retrieved the comment")
+	writeJSONResponse(w, http.StatusOK, h.commentResponse(comment))
 }
 
 // GET /api/post/{postId}/comments - Handler to get all of the comments on a post
@@ -110,22 +358,30 @@ func (h *Handler) GetCommentsOnPost(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["postId"]
 
 	// Convert the ID string into an int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("id", idStr).Msg("Invalid post ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid Post ID")
 		return
 	}
 
-	comments, err := h.db.GetCommentsByPost(id)
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	comments, more, err := h.db.GetCommentsByPost(id, h.viewerId(r), limit, offset)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get all comments on the post")
-		writeErrorResponse(w, http.StatusInternalServerError, "failed to get comments on post")
+		writeMappedError(w, err, "No comments found on post", "failed to get comments on post")
 		return
 	}
+	if more {
+		setNextPageLink(w, r, offset, len(comments))
+	}
 
 	log.Info().Int("count", len(comments)).Msg("Successfully retrieved comments on post")
-	writeJSONResponse(w, http.StatusOK, comments)
+	writeJSONResponse(w, http.StatusOK, h.commentResponses(comments))
 
 }
 
@@ -138,46 +394,50 @@ func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
 	postIdStr := vars["postId"]
 
 	// Convert post ID string into int
-	postId, err := strconv.Atoi(postIdStr)
+	postId, err := model.ParseID(postIdStr)
 	if err != nil {
 		log.Warn().Str("Post ID", postIdStr).Msg("Invalid Post ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
 		return
 	}
 
-	// Get username
-	username := middleware.GetUsername(r)
-	if username == "" {
-		log.Warn().Msg("No username in that context")
+	// Get user ID
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
 		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized user")
 		return
 	}
 
-	// Get user from db
-	user, err := h.db.GetUserByUsername(username)
+	// Verify post exists and is open for comments
+	post, err := h.db.GetPostById(postId)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to get user")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get user info")
+		writeMappedError(w, err, "Post not found", "Failed to verify post existence")
 		return
 	}
-
-	// Verify post exists
-	_, err = h.db.GetPostById(postId)
+	if post.Hidden {
+		log.Warn().Int64("Post ID", postId).Msg("Post is hidden by a moderator")
+		writeErrorResponse(w, http.StatusNotFound, "Post not found")
+		return
+	}
+	visible, err := h.canViewPost(post, userId)
 	if err != nil {
-		if err.Error() == "post not found" {
-			log.Warn().Int("Post ID", postId).Msg("Post not found")
-			writeErrorResponse(w, http.StatusNotFound, "Post not found")
-			return
-		}
-		log.Error().Err(err).Msg("Failed to verify post")
+		log.Error().Err(err).Msg("Failed to check post visibility")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to verify post existence")
 		return
 	}
+	if !visible {
+		writeErrorResponse(w, http.StatusNotFound, "Post not found")
+		return
+	}
+	if post.Locked {
+		log.Warn().Int64("Post ID", postId).Msg("Post is locked")
+		writeErrorResponse(w, http.StatusForbidden, "This post is locked for new comments")
+		return
+	}
 
 	// Parse the request body
-	var req struct {
-		Content string `json:"content"`
-	}
+	var req model.CommentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Warn().Err(err).Msg("Invalid request body")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid req body")
@@ -193,23 +453,28 @@ func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
 
 	// Create comment object
 	comment := model.Comment{
-		UserId:     user.ID,
+		UserId:     userId,
 		PostId:     postId,
 		Content:    req.Content,
-		Author:     user.Username,
 		DatePosted: time.Now(),
 	}
 
-	// Call database to create comment
-	if err := h.db.CreateComment(&comment, postId); err != nil {
+	// Call comment service to create comment
+	if err := h.commentService.CreateComment(&comment); err != nil {
+		if writeValidationError(w, err) || writeContentQuotaError(w, err) {
+			return
+		}
 		log.Error().Err(err).Msg("Failed to create comment")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to create comment")
 		return
 	}
 
 	// Success
-	log.Info().Int("Comment ID", comment.CommentId).Msg("Successfully added comment to post")
-	writeJSONResponse(w, http.StatusCreated, comment)
+	log.Info().Int64("Comment ID", comment.CommentId).Msg("Successfully added comment to post")
+	h.events.Record(model.EventCommentCreated, userId, model.EventSubjectComment, comment.CommentId, map[string]interface{}{
+		"post_id": postId,
+	})
+	writeJSONResponse(w, http.StatusCreated, h.commentResponse(&comment))
 }
 
 // PUT /api/comments/{commentId} - Update comment
@@ -217,27 +482,19 @@ func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("PUT /api/comments/{commentId} - Updating comment")
 
 	// Verify authenticated user
-	username := middleware.GetUsername(r)
-	if username == "" {
-		log.Warn().Msg("No username in context")
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
 		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
 
-	// Get user from db
-	user, err := h.db.GetUserByUsername(username)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get user info")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get user info")
-		return
-	}
-
 	// Get comment ID string from URL
 	vars := mux.Vars(r)
 	idStr := vars["commentId"]
 
 	// Convert comment ID string to int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("Comment ID", idStr).Msg("Invalid Comment ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid Comment ID")
@@ -247,27 +504,19 @@ func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
 	// Get existing comment from db
 	existingComment, err := h.db.GetCommentById(id)
 	if err != nil {
-		if err.Error() == "comment not found" {
-			log.Warn().Int("Comment ID", id).Msg("Comment not found")
-			writeErrorResponse(w, http.StatusNotFound, "Comment not found")
-			return
-		}
-		log.Error().Err(err).Msg("Failed to get comment")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get comment")
+		writeMappedError(w, err, "Comment not found", "Failed to get comment")
 		return
 	}
 
 	// Verify user owns the comment
-	if existingComment.UserId != user.ID {
-		log.Warn().Int("User ID", user.ID).Int("Comment ID", existingComment.CommentId).Msg("User does not own this comment")
+	if existingComment.UserId != userId {
+		log.Warn().Int64("User ID", userId).Int64("Comment ID", existingComment.CommentId).Msg("User does not own this comment")
 		writeErrorResponse(w, http.StatusForbidden, "You can only update comments you own")
 		return
 	}
 
 	// Parse the request body
-	var req struct {
-		Content string `json:"content"`
-	}
+	var req model.CommentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error().Err(err).Msg("Invalid request body")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
@@ -284,16 +533,22 @@ func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
 	// Update comment object with new data
 	existingComment.Content = req.Content
 
-	// Call the db to update the comment
-	if err := h.db.UpdateComment(existingComment); err != nil {
+	// Call comment service to update the comment
+	if err := h.commentService.UpdateComment(existingComment); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
 		log.Error().Err(err).Msg("Failed to update comment")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to update comment")
 		return
 	}
 
 	// Success
-	log.Info().Int("Comment ID", id).Msg("Successfully updated comment")
-	writeJSONResponse(w, http.StatusOK, existingComment)
+	log.Info().Int64("Comment ID", id).Msg("Successfully updated comment")
+	h.events.Record(model.EventCommentUpdated, userId, model.EventSubjectComment, id, map[string]interface{}{
+		"post_id": existingComment.PostId,
+	})
+	writeJSONResponse(w, http.StatusOK, h.commentResponse(existingComment))
 }
 
 // DELETE /api/comments/{commentId} - Delete a comment
@@ -301,27 +556,19 @@ func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("DELETE /api/comments/{commentId} - Deleting comment")
 
 	// Verify user authentification
-	username := middleware.GetUsername(r)
-	if username == "" {
-		log.Warn().Msg("No username in context")
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
 		writeErrorResponse(w, http.StatusUnauthorized, "Unauthorized user")
 		return
 	}
 
-	// Get user from database
-	user, err := h.db.GetUserByUsername(username)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get user info")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get user info")
-		return
-	}
-
 	// Get string commentID from URL
 	vars := mux.Vars(r)
 	idStr := vars["commentId"]
 
 	// Convert string ID to int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("Comment ID", idStr).Msg("Invalid comment ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid comment ID format")
@@ -331,16 +578,13 @@ func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 	// Get existing comment from db
 	existingComment, err := h.db.GetCommentById(id)
 	if err != nil {
-		if err.Error() == "comment not found" {
-			log.Warn().Int("Comment ID", id).Msg("Comment not found")
-			writeErrorResponse(w, http.StatusNotFound, "Comment not found")
-			return
-		}
+		writeMappedError(w, err, "Comment not found", "Failed to get comment")
+		return
 	}
 
-	// Verify comment belongs to user or user deleting is admin
-	if existingComment.UserId != user.ID && user.Role != "admin" {
-		log.Warn().Int("Comment ID", id).Int("User ID", user.ID).Msg("User does not own this comment")
+	// Verify comment belongs to user or user deleting can delete anyone's comments
+	if existingComment.UserId != userId && !middleware.HasPermission(r, model.PermissionCommentsDelete) {
+		log.Warn().Int64("Comment ID", id).Int64("User ID", userId).Msg("User does not own this comment")
 		writeErrorResponse(w, http.StatusForbidden, "You can only delete your comments")
 		return
 	}
@@ -353,7 +597,11 @@ func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Success
-	log.Info().Int("Comment ID", id).Msg("Successfully deleted comment")
+	log.Info().Int64("Comment ID", id).Msg("Successfully deleted comment")
+	h.events.Record(model.EventCommentDeleted, userId, model.EventSubjectComment, id, map[string]interface{}{
+		"post_id":   existingComment.PostId,
+		"author_id": existingComment.UserId,
+	})
 	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "comment successfully deleted"})
 }
 
@@ -361,11 +609,44 @@ func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 
 // #region Post handlers
 
-// GET /api/posts - Handler to get all posts
+// GET /api/posts?author=&q=&user_id=&lang=&include_muted=true&sort=newest|oldest|title&limit=&offset= - Handler to get all posts.
+// The unfiltered feed leaves out posts the viewer muted unless include_muted is set; listings by author keep them.
 func (h *Handler) GetAllPosts(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("GET /posts - Getting all posts")
 
-	posts, err := h.db.GetAllPosts()
+	// Parse filters
+	sort, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	userId, err := parseOptionalID(r, "user_id")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	lang := r.URL.Query().Get("lang")
+	if lang != "" {
+		if lang, err = language.Normalize(lang); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// Muted posts only drop out of the general feed, not out of one author's posts
+	hideMuted := userId == 0 && r.URL.Query().Get("author") == "" && r.URL.Query().Get("include_muted") != "true"
+
+	posts, err := h.postLists.ListPosts(model.PostFilter{
+		UserId:    userId,
+		Author:    r.URL.Query().Get("author"),
+		Search:    r.URL.Query().Get("q"),
+		Language:  lang,
+		Sort:      sort,
+		Limit:     limit,
+		Offset:    offset,
+		ViewerId:  h.viewerId(r),
+		HideMuted: hideMuted,
+	})
 	if err != nil {
 		log.Error().Err(err).Msg("Error getting all posts")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get all posts")
@@ -373,7 +654,7 @@ func (h *Handler) GetAllPosts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.Info().Int("count", len(posts)).Msg("Successfully retrieved all posts")
-	writeJSONResponse(w, http.StatusOK, posts)
+	writeJSONResponse(w, http.StatusOK, h.postResponses(posts, viewerOf(r)))
 }
 
 // GET /api/posts/{postId} - Handler to get post by ID
@@ -384,7 +665,7 @@ func (h *Handler) GetPostById(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["postId"]
 
 	// Convert the ID from string to an int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("ID", idStr).Msg("Invalid post ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
@@ -393,13 +674,77 @@ func (h *Handler) GetPostById(w http.ResponseWriter, r *http.Request) {
 
 	post, err := h.db.GetPostById(id)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get post by ID")
+		writeMappedError(w, err, "Post not found", "Failed to get post by ID")
+		return
+	}
+	if post.Hidden {
+		log.Warn().Int64("Post ID", id).Msg("Post is hidden by a moderator")
+		writeErrorResponse(w, http.StatusNotFound, "Post not found")
+		return
+	}
+	visible, err := h.canViewPost(post, h.viewerId(r))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check post visibility")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get post by ID")
 		return
 	}
+	if !visible {
+		writeErrorResponse(w, http.StatusNotFound, "Post not found")
+		return
+	}
 
-	log.Info().Int("Post ID", id).Msg("Successfully retrieved post by ID")
-	writeJSONResponse(w, http.StatusOK, post)
+	switch expand := r.URL.Query().Get("expand"); expand {
+	case "":
+	case "comments":
+		h.writePostWithComments(w, r, post)
+		return
+	default:
+		writeErrorResponse(w, http.StatusBadRequest, "expand must be comments")
+		return
+	}
+
+	log.Info().Int64("Post ID", id).Msg("Successfully retrieved post by ID")
+	writeJSONResponse(w, http.StatusOK, h.postResponse(post, viewerOf(r)))
+}
+
+// Writes a post with a page of its comments (oldest first, paged by limit and offset) and their reactions.
+// The reactions for the whole page come from one grouped query.
+func (h *Handler) writePostWithComments(w http.ResponseWriter, r *http.Request, post *model.Post) {
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewerId := h.viewerId(r)
+	comments, more, err := h.db.GetCommentsByPost(post.PostId, viewerId, limit, offset)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Error().Err(err).Msg("Failed to get comments on post")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get post by ID")
+		return
+	}
+	if more {
+		setNextPageLink(w, r, offset, len(comments))
+	}
+
+	commentIds := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		commentIds = append(commentIds, comment.CommentId)
+	}
+	reactions := h.loadReactions(viewerId, commentIds)
+
+	responses := h.commentResponses(comments)
+	for i := range responses {
+		if summary, ok := reactions[responses[i].CommentId]; ok {
+			responses[i].Reactions = &summary
+		}
+	}
+
+	log.Info().Int64("Post ID", post.PostId).Int("comments", len(responses)).Msg("Successfully retrieved post with comments")
+	writeJSONResponse(w, http.StatusOK, model.PostWithCommentsResponse{
+		PostResponse: h.postResponse(post, viewerOf(r)),
+		Comments:     responses,
+	})
 }
 
 // GET /api/posts/user/{userId} - Handler to get all posts by UserID
@@ -410,22 +755,30 @@ func (h *Handler) GetPostsByUserId(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["userId"]
 
 	// Convert string ID into an int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("ID", idStr).Msg("Invalid user ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
 		return
 	}
 
-	posts, err := h.db.GetPostsByUserId(id)
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	posts, more, err := h.db.GetPostsByUserId(id, h.viewerId(r), limit, offset)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to get posts from that user")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failure to get posts with that user ID")
+		writeMappedError(w, err, "No posts found for that user", "Failure to get posts with that user ID")
 		return
 	}
+	if more {
+		setNextPageLink(w, r, offset, len(posts))
+	}
 
 	log.Info().Int("Count", len(posts)).Msg("Successfully retrieved posts from user ID")
-	writeJSONResponse(w, http.StatusOK, posts)
+	writeJSONResponse(w, http.StatusOK, h.postResponses(posts, viewerOf(r)))
 }
 
 // POST /api/posts - Create new post
@@ -433,26 +786,15 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("POST /api/posts - Creating new post")
 
 	// Get authenticated user from JWT mware context
-	username := middleware.GetUsername(r)
-	if username == "" {
-		log.Warn().Msg("No username in context")
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
 		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
 
-	// Get user from db
-	user, err := h.db.GetUserByUsername(username)
-	if err != nil {
-		log.Error().Err(err).Msg("failed to get user")
-		writeErrorResponse(w, http.StatusInternalServerError, "failed to get user info")
-		return
-	}
-
 	// Parse body request
-	var req struct {
-		Title   string `json:"title"`
-		Content string `json:"content"`
-	}
+	var req model.PostRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Warn().Err(err).Msg("Invalid request body")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
@@ -468,22 +810,37 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 
 	// Create post object
 	post := &model.Post{
-		UserId:     user.ID,
+		UserId:     userId,
 		Title:      req.Title,
 		Content:    req.Content,
-		Author:     user.Username,
 		DatePosted: time.Now(),
+		Visibility: req.Visibility,
+		Language:   req.Language,
+		Tags:       req.Tags,
+	}
+	if req.Board != nil {
+		var ok bool
+		if post.BoardId, ok = h.postBoard(w, *req.Board); !ok {
+			return
+		}
 	}
 
-	// Call db to create post
-	if err := h.db.CreatePost(post); err != nil {
-		log.Error().Err(err).Msg("failed to create post")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to create post")
+	// Call post service to create post
+	if err := h.postService.CreatePost(post); err != nil {
+		if writeValidationError(w, err) || writeContentQuotaError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "You already posted this recently", "Failed to create post")
 		return
 	}
 
 	log.Info().Str("title", post.Title).Msg("Post created successfully")
-	writeJSONResponse(w, http.StatusCreated, post)
+	h.events.Record(model.EventPostCreated, userId, model.EventSubjectPost, post.PostId, map[string]interface{}{
+		"title":      post.Title,
+		"visibility": post.Visibility,
+		"language":   post.Language,
+	})
+	writeJSONResponse(w, http.StatusCreated, h.postResponse(post, viewerOf(r)))
 }
 
 // PUT /api/posts/{postId} - Update post
@@ -491,27 +848,19 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("PUT /api/posts/{postId} - Updating a post")
 
 	// Get authenticated user from context
-	username := middleware.GetUsername(r)
-	if username == "" {
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
 		log.Warn().Msg("No username in the context")
 		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
 
-	// Get the user from the db
-	user, err := h.db.GetUserByUsername(username)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get user")
-		writeErrorResponse(w, http.StatusInternalServerError, "failed to get user")
-		return
-	}
-
 	// Get post ID from URL params
 	vars := mux.Vars(r)
 	idStr := vars["postId"]
 
 	// Convert string ID into int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("post_id", idStr).Msg("Invalid post ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
@@ -521,28 +870,29 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	// Get existing post from the db
 	existingPost, err := h.db.GetPostById(id)
 	if err != nil {
-		if err.Error() == "post not found" {
-			log.Warn().Int("postId", id).Msg("post not found")
-			writeErrorResponse(w, http.StatusNotFound, "Post not found")
-			return
-		}
-		log.Error().Err(err).Msg("failed to get post")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get post")
+		writeMappedError(w, err, "Post not found", "Failed to get post")
 		return
 	}
 
 	// Verify the user owns the post (holy cow... long function)
-	if existingPost.UserId != user.ID {
-		log.Warn().Int("userId", user.ID).Int("postId", existingPost.PostId).Msg("User does not own this post")
+	if existingPost.UserId != userId {
+		log.Warn().Int64("userId", userId).Int64("postId", existingPost.PostId).Msg("User does not own this post")
 		writeErrorResponse(w, http.StatusForbidden, "You can only update your own posts")
 		return
 	}
 
-	// Parse request body
-	var req struct {
-		Title   string `json:"title"`
-		Content string `json:"content"`
+	// Don't overwrite changes being made in another editing session
+	if err := h.editLockService.CheckSave(id, r.Header.Get(editLockHeader)); err != nil {
+		if writePostLockedError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to check post edit lock")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to update post")
+		return
 	}
+
+	// Parse request body
+	var req model.PostRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Warn().Err(err).Msg("Invalid request body")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
@@ -559,17 +909,41 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	// Update post object with new data
 	existingPost.Title = req.Title
 	existingPost.Content = req.Content
+	if req.Visibility != "" {
+		existingPost.Visibility = req.Visibility
+	}
+	if req.Language != "" {
+		existingPost.Language = req.Language
+		existingPost.LanguageSource = model.LanguageDeclared
+	}
+	if req.Tags != nil {
+		existingPost.Tags = req.Tags
+	}
+	if req.Board != nil {
+		var ok bool
+		if existingPost.BoardId, ok = h.postBoard(w, *req.Board); !ok {
+			return
+		}
+	}
 
-	// Call database to update post
-	if err := h.db.UpdatePost(existingPost); err != nil {
+	// Call post service to update post
+	if err := h.postService.UpdatePost(existingPost); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
 		log.Error().Err(err).Msg("failed to update post")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to update post")
 		return
 	}
 
 	// Success
-	log.Info().Int("postId", id).Str("title", existingPost.Title).Msg("Post updated successfully")
-	writeJSONResponse(w, http.StatusOK, existingPost)
+	log.Info().Int64("postId", id).Str("title", existingPost.Title).Msg("Post updated successfully")
+	h.events.Record(model.EventPostUpdated, userId, model.EventSubjectPost, id, map[string]interface{}{
+		"title":      existingPost.Title,
+		"visibility": existingPost.Visibility,
+		"language":   existingPost.Language,
+	})
+	writeJSONResponse(w, http.StatusOK, h.postResponse(existingPost, viewerOf(r)))
 }
 
 // DELETE /api/posts/{postId} - Handler to delete a post
@@ -577,27 +951,19 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("DELETE /api/posts/{postId} - Deleting post")
 
 	// Get authenticated user from context
-	username := middleware.GetUsername(r)
-	if username == "" {
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
 		log.Warn().Msg("No username in the context")
 		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
 
-	// Get user from the db
-	user, err := h.db.GetUserByUsername(username)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get user")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get user")
-		return
-	}
-
 	// Get the string post ID
 	vars := mux.Vars(r)
 	idStr := vars["postId"]
 
 	// Conver string postID to an int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("PostID", idStr).Msg("Invalid post ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
@@ -607,31 +973,29 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 	// Get existing post from the db
 	existingPost, err := h.db.GetPostById(id)
 	if err != nil {
-		if err.Error() == "post not found" {
-			log.Warn().Int("PostID", id).Msg("post not found")
-			writeErrorResponse(w, http.StatusNotFound, "Post not found")
-			return
-		}
-		log.Error().Err(err).Msg("failed to get post")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get post")
+		writeMappedError(w, err, "Post not found", "Failed to get post")
 		return
 	}
 
-	// Verify the user owns the post or user deleting post is admin
-	if existingPost.UserId != user.ID && user.Role != "admin" {
-		log.Warn().Int("PostID", id).Int("UserID", user.ID).Msg("User does not own this post")
+	// Verify the user owns the post or user deleting post can delete anyone's posts
+	if existingPost.UserId != userId && !middleware.HasPermission(r, model.PermissionPostsDelete) {
+		log.Warn().Int64("PostID", id).Int64("UserID", userId).Msg("User does not own this post")
 		writeErrorResponse(w, http.StatusForbidden, "You can only delete your own posts")
 		return
 	}
 
 	// Call the database to delete the post
-	if err := h.db.DeletePost(id); err != nil {
+	if err := h.postService.DeletePost(id); err != nil {
 		log.Error().Err(err).Msg("failed to delete post")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to delete post")
 		return
 	}
 
-	log.Info().Int("PostID", id).Msg("Post deleted successfully")
+	log.Info().Int64("PostID", id).Msg("Post deleted successfully")
+	h.events.Record(model.EventPostDeleted, userId, model.EventSubjectPost, id, map[string]interface{}{
+		"title":     existingPost.Title,
+		"author_id": existingPost.UserId,
+	})
 	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Post deleted successfully"})
 }
 
@@ -639,22 +1003,65 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 
 // #region Profile handlers
 
-// GET /api/profiles - Handler to get all profiles
+// GET /api/profiles?skill= - Handler to get all profiles (optionally only members with a skill)
 func (h *Handler) GetAllProfiles(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("GET /profiles - Getting all profiles")
 
-	profiles, err := h.db.GetAllProfiles()
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var profiles []model.Profile
+	var more bool
+	if skill := r.URL.Query().Get("skill"); skill != "" {
+		tag, normErr := service.NormalizeSkill(skill)
+		if normErr != nil {
+			log.Warn().Str("skill", skill).Msg("Invalid skill filter")
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid skill")
+			return
+		}
+		// A synonym finds the members listed under its canonical skill
+		if tag, err = h.skillService.Canonical(tag); err == nil {
+			profiles, more, err = h.db.GetProfilesBySkill(tag, limit, offset)
+		}
+	} else {
+		profiles, more, err = h.db.GetAllProfiles(limit, offset)
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get all profiles")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get profiles")
 		return
 	}
 
+	// Attach each member's skills for the directory listing
+	userIds := make([]int64, 0, len(profiles))
+	for _, profile := range profiles {
+		userIds = append(userIds, profile.UserId)
+	}
+	skillsByUser, err := h.db.GetSkillsByUsers(userIds)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get profile skills")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get profiles")
+		return
+	}
+
+	online := h.presenceService.Online(userIds)
+	responses := h.profileResponses(profiles, viewerOf(r))
+	for i := range responses {
+		responses[i].Skills = skillsByUser[responses[i].UserId]
+		responses[i].Online = online[responses[i].UserId]
+	}
+	if more {
+		setNextPageLink(w, r, offset, len(profiles))
+	}
+
 	log.Info().Int("Count", len(profiles)).Msg("Successfully retrieved all profiles")
-	writeJSONResponse(w, http.StatusOK, profiles)
+	writeJSONResponse(w, http.StatusOK, responses)
 }
 
-// GET /api/profiles/{userId} - Handler to get profile by User ID
+// GET /api/profiles/{userId} - Handler to get profile by User ID (with activity stats)
 func (h *Handler) GetProfileByUserId(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("GET /profiles/{userId} - Getting profile by user ID")
 
@@ -663,27 +1070,37 @@ func (h *Handler) GetProfileByUserId(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["userId"]
 
 	// Convert string user ID to an int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("User ID", idStr).Msg("Invalid user ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
 		return
 	}
 
-	profile, err := h.db.GetProfileByUserId(id)
+	profile, stats, err := h.db.GetProfileWithStats(id)
 	if err != nil {
-		if err.Error() == "profile not found" {
-			log.Warn().Int("ID", id).Msg("Profile not found")
-			writeErrorResponse(w, http.StatusNotFound, "Profile not found")
-			return
-		}
-		log.Error().Err(err).Msg("Error getting profile")
+		writeMappedError(w, err, "Profile not found", "Failed to get profile")
+		return
+	}
+
+	skills, err := h.db.GetSkillsByUser(id)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get profile skills")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get profile")
 		return
 	}
 
-	log.Info().Int("ID", id).Msg("Successfully retrieved profile")
-	writeJSONResponse(w, http.StatusOK, profile)
+	viewer := viewerOf(r)
+	response := h.profileResponse(profile, viewer)
+	response.Skills = skills
+	response.Stats = stats
+	response.Online = h.presenceService.IsOnline(id)
+
+	log.Info().Int64("ID", id).Msg("Successfully retrieved profile")
+	if response.Staff != nil && viewer.UserId != id {
+		h.recordDataAccess(r, id, model.DataAccessUser)
+	}
+	writeJSONResponse(w, http.StatusOK, response)
 }
 
 // PUT /api/profiles/{userId} - Handler to update profile
@@ -691,86 +1108,209 @@ func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("PUT /api/profiles/{userId} - Updating profile")
 
 	// Get authenticated username from context
-	username := middleware.GetUsername(r)
-	if username == "" {
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
 		log.Warn().Msg("No username in the context")
 		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
 
-	// Get the user from the db
-	user, err := h.db.GetUserByUsername(username)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get user")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get user")
-		return
-	}
-
 	// Get UserID from req URL
 	vars := mux.Vars(r)
 	idStr := vars["userId"]
 
 	// Convert string ID to int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("User ID", idStr).Msg("Invalid user ID format in URL")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
 		return
 	}
 
+	// Runs in a transaction, so a rejected update doesn't leave the display name half-applied
+	db := h.dbFor(r)
+
 	// Get existing profile from the db
-	existingProfile, err := h.db.GetProfileByUserId(id)
+	existingProfile, err := db.GetProfileByUserId(id)
 	if err != nil {
-		if err.Error() == "profile not found" {
-			log.Warn().Int("User ID", id).Msg("profile not found")
-			writeErrorResponse(w, http.StatusNotFound, "Profile not found")
-			return
-		}
-		log.Error().Err(err).Msg("failed to get profile")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get profile")
+		writeMappedError(w, err, "Profile not found", "Failed to get profile")
 		return
 	}
 
 	// Verify the user owns the profile
-	if user.ID != existingProfile.UserId {
-		log.Warn().Int("Profile ID", existingProfile.UserId).Int("User ID", user.ID).Msg("User does not own this profile")
+	if userId != existingProfile.UserId {
+		log.Warn().Int64("Profile ID", existingProfile.UserId).Int64("User ID", userId).Msg("User does not own this profile")
 		writeErrorResponse(w, http.StatusForbidden, "You can only update your profile")
 		return
 	}
 
 	// Parse request body
-	var req struct {
-		FirstName  string `json:"first_name"`
-		LastName   string `json:"last_name"`
-		Email      string `json:"email"`
-		GithubLink string `json:"github_link"`
-		City       string `json:"city"`
-		State      string `json:"state"`
-	}
+	var req model.UpdateProfileRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Warn().Msg("Missing required field")
-		writeErrorResponse(w, http.StatusBadRequest, "Missing at least one of the required fields, Firstname, Lastname, Email, Github Link, City, or State")
+		writeErrorResponse(w, http.StatusBadRequest, "Missing at least one of the required fields, Firstname, Lastname, Github Link, City, or State")
 		return
 	}
 
-	// Update profile object with new data
+	// Validate and save the display name first so a rejected name fails the update before anything else changes
+	if req.DisplayName != nil && *req.DisplayName != existingProfile.DisplayName {
+		displayName, err := h.profileService.WithDB(db).SetDisplayName(userId, *req.DisplayName)
+		if err != nil {
+			if writeValidationError(w, err) {
+				return
+			}
+			writeMappedError(w, err, "Display name is already taken", "Failed to update display name")
+			return
+		}
+		existingProfile.DisplayName = displayName
+	}
+
+	// Update profile object with new data (the email only changes through PUT /api/profiles/me/email)
 	existingProfile.FirstName = req.FirstName
 	existingProfile.LastName = req.LastName
-	existingProfile.Email = req.Email
 	existingProfile.GithubLink = req.GithubLink
 	existingProfile.City = req.City
 	existingProfile.State = req.State
 
 	// Call the database to update the profile
-	if err := h.db.UpdateProfile(existingProfile); err != nil {
+	if err := db.UpdateProfile(existingProfile); err != nil {
 		log.Error().Err(err).Msg("Failed to update profile")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to update profile")
 		return
 	}
 
 	// Success
-	log.Info().Int("User ID", id).Msg("Successfully updated profile")
-	writeJSONResponse(w, http.StatusOK, existingProfile)
+	log.Info().Int64("User ID", id).Msg("Successfully updated profile")
+	writeJSONResponse(w, http.StatusOK, model.NewProfileResponse(existingProfile, viewerOf(r)))
+}
+
+// PUT /api/profiles/{userId}/skills - Handler to replace the skills on a profile
+func (h *Handler) SetProfileSkills(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/profiles/{userId}/skills - Setting profile skills")
+
+	// Get authenticated username from context
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No username in the context")
+		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	// Get UserID from req URL
+	vars := mux.Vars(r)
+	idStr := vars["userId"]
+
+	// Convert string ID to int
+	id, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("User ID", idStr).Msg("Invalid user ID format in URL")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	// Verify the user owns the profile
+	if userId != id {
+		log.Warn().Int64("Profile ID", id).Int64("User ID", userId).Msg("User does not own this profile")
+		writeErrorResponse(w, http.StatusForbidden, "You can only update your profile")
+		return
+	}
+
+	// Parse request body
+	var req model.SkillsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	skills, err := h.profileService.SetSkills(userId, req.Skills)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Profile not found", "Failed to set skills")
+		return
+	}
+
+	// Success
+	log.Info().Int64("User ID", id).Int("Count", len(skills)).Msg("Successfully set profile skills")
+	writeJSONResponse(w, http.StatusOK, map[string]interface{}{"skills": skills})
+}
+
+// GET /api/skills - Handler to get all skills in use with member counts
+func (h *Handler) GetSkills(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/skills - Getting skills")
+
+	skills, err := h.db.GetSkillCounts()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get skills")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get skills")
+		return
+	}
+
+	log.Info().Int("Count", len(skills)).Msg("Successfully retrieved skills")
+	writeJSONResponse(w, http.StatusOK, skills)
+}
+
+// PUT /api/profiles/me/email - Request an email change (applied once the new address is confirmed)
+func (h *Handler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/profiles/me/email - Requesting email change")
+
+	// Get authenticated username from context
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No username in the context")
+		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	// Parse request body
+	var req model.ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if req.Email == "" {
+		log.Warn().Msg("Missing required field: email")
+		writeErrorResponse(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	// Call auth service to send the confirmation link
+	if err := h.authService.RequestEmailChange(userId, req.Email); err != nil {
+		writeMappedError(w, err, "Invalid email address", "Failed to request email change")
+		return
+	}
+
+	// Success
+	log.Info().Int64("User ID", userId).Msg("Email change confirmation sent")
+	writeJSONResponse(w, http.StatusAccepted, map[string]string{"message": "Check your new email address for a confirmation link"})
+}
+
+// GET /api/profiles/email/confirm?token= - Confirm a pending email change
+func (h *Handler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/profiles/email/confirm - Confirming email change")
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		log.Warn().Msg("Missing confirmation token")
+		writeErrorResponse(w, http.StatusBadRequest, "Token is required")
+		return
+	}
+
+	request, err := h.authService.ConfirmEmailChange(token)
+	if err != nil {
+		writeMappedError(w, err, "Invalid or expired token", "Failed to confirm email change")
+		return
+	}
+
+	// Success
+	log.Info().Int64("User ID", request.UserId).Msg("Successfully changed email")
+	h.events.Record(model.EventUserEmailChanged, request.UserId, model.EventSubjectUser, request.UserId, nil)
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Email address updated", "email": request.NewEmail})
 }
 
 // #endregion
@@ -781,15 +1321,24 @@ func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("GET /users - Getting all users")
 
-	users, err := h.db.GetAllUsers()
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, more, err := h.db.GetAllUsers(limit, offset)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to get all users")
 		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get all users")
 		return
 	}
+	if more {
+		setNextPageLink(w, r, offset, len(users))
+	}
 
 	log.Info().Msg("Successfully retrieved all users")
-	writeJSONResponse(w, http.StatusOK, users)
+	writeJSONResponse(w, http.StatusOK, h.userResponses(users))
 }
 
 // GET /api/admin/users/{userId} - Handler to get User by User ID with admin permissions
@@ -801,7 +1350,7 @@ func (h *Handler) GetUserById(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["userId"]
 
 	// Convert int UserID to a string
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("ID", idStr).Msg("Invalid user ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
@@ -810,91 +1359,212 @@ func (h *Handler) GetUserById(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.db.GetUserByID(id)
 	if err != nil {
-		if err.Error() == "user not found" {
-			log.Warn().Int("ID", id).Msg("No user with that ID found")
-			writeErrorResponse(w, http.StatusNotFound, "User not found")
-			return
-		}
-		log.Error().Err(err).Msg("Failed to get user with that ID")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get user")
+		writeMappedError(w, err, "User not found", "Failed to get user")
 		return
 	}
 
-	log.Info().Int("ID", id).Msg("Successfully retrieved user")
-	writeJSONResponse(w, http.StatusOK, user)
+	log.Info().Int64("ID", id).Msg("Successfully retrieved user")
+	h.recordDataAccess(r, user.ID, model.DataAccessUser)
+	writeJSONResponse(w, http.StatusOK, h.userResponse(user))
 }
 
 // GET /api/users/username/{username} - Handler to get User by Username with admin permissions
 func (h *Handler) GetUserByUsername(w http.ResponseWriter, r *http.Request) {
 	log.Info().Msg("GET /users/username/{username} - Getting user by username")
 
-	// Get username
+	// Get user ID
 	vars := mux.Vars(r)
 	username := vars["username"]
 
 	user, err := h.db.GetUserByUsername(username)
 	if err != nil {
-		if err.Error() == "username not found" {
-			log.Warn().Str("username", username).Msg("No user with that username found")
-			writeErrorResponse(w, http.StatusNotFound, "Username not found")
-			return
-		}
-		log.Error().Err(err).Msg("Failed to get user with that username")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get user")
+		writeMappedError(w, err, "Username not found", "Failed to get user")
 		return
 	}
 
 	log.Info().Str("Username", username).Msg("Successfully retrieved user")
-	writeJSONResponse(w, http.StatusOK, user)
+	h.recordDataAccess(r, user.ID, model.DataAccessUser)
+	writeJSONResponse(w, http.StatusOK, h.userResponse(user))
+}
+
+// GET /api/admin/users/{userId}/email-history - Handler to get a user's previous email addresses with admin permissions
+func (h *Handler) GetEmailHistory(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /admin/users/{userId}/email-history - Getting email history")
+
+	// Get ID
+	vars := mux.Vars(r)
+	idStr := vars["userId"]
+
+	id, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("ID", idStr).Msg("Invalid user ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	history, err := h.db.GetEmailHistory(id)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get email history")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get email history")
+		return
+	}
+
+	log.Info().Int64("ID", id).Int("count", len(history)).Msg("Successfully retrieved email history")
+	h.recordDataAccess(r, id, model.DataAccessEmailHistory)
+	writeJSONResponse(w, http.StatusOK, history)
 }
 
 // DELETE /api/users/{userId} - Delete a user and their profile
 func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	// Get username from context
-	username := middleware.GetUsername(r)
-	if username == "" {
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
 		log.Warn().Msg("No username in the context")
 		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
 		return
 	}
 
-	// Get user from the db
-	user, err := h.db.GetUserByUsername(username)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get user")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get user information")
-		return
-	}
-
 	// Get the userID string from URL
 	vars := mux.Vars(r)
 	idStr := vars["userId"]
 
 	// Convert the ID to int
-	id, err := strconv.Atoi(idStr)
+	id, err := model.ParseID(idStr)
 	if err != nil {
 		log.Warn().Str("User ID", idStr).Msg("Invalid User ID format")
 		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID format")
 		return
 	}
 
-	// Verify user owns the account or is an admin
-	if user.ID != id && user.Role != "admin" {
+	// Verify user owns the account or can delete anyone's account
+	if userId != id && !middleware.HasPermission(r, model.PermissionUsersDelete) {
 		log.Warn().Msg("User does not own this account")
 		writeErrorResponse(w, http.StatusForbidden, "You can only delete your account")
 		return
 	}
 
-	// Delete the user (cascades to profile, posts, comments)
-	if err := h.db.DeleteUser(id); err != nil {
-		log.Error().Err(err).Msg("Failed to delete user")
-		writeErrorResponse(w, http.StatusInternalServerError, "Failed to delete user")
+	// Delete the user (cascades to profile, posts, comments and sessions) and record it in one transaction
+	db := h.dbFor(r)
+	if err := db.DeleteUser(id); err != nil {
+		writeMappedError(w, err, "User not found", "Failed to delete user")
 		return
 	}
+	// The account's posts went with it. Flushed once they're gone for everyone, so a concurrent listing
+	// can't cache them again in the meantime.
+	db.AfterCommit(h.postLists.Flush)
 
 	// Success
-	log.Info().Int("User ID", id).Msg("User account deleted successfully")
+	log.Info().Int64("User ID", id).Msg("User account deleted successfully")
+	h.events.WithDB(db).Record(model.EventUserDeleted, userId, model.EventSubjectUser, id, nil)
 	writeJSONResponse(w, http.StatusOK, "User successfully deleted!")
 }
 
+// POST /api/admin/users/{userId}/merge - Handler to merge a duplicate account into another with admin permissions.
+// Posts, comments, profile details, followers and the rest move to the "into" account and the duplicate is deleted;
+// with dry_run nothing changes and the response shows what would move.
+func (h *Handler) MergeUsers(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/users/{userId}/merge - Merging accounts")
+
+	sourceId, err := model.ParseID(mux.Vars(r)["userId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req model.MergeUsersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Into < 1 {
+		writeErrorResponse(w, http.StatusBadRequest, "into must be the ID of the account to keep")
+		return
+	}
+	if req.Into == sourceId {
+		writeErrorResponse(w, http.StatusBadRequest, "An account can't be merged into itself")
+		return
+	}
+
+	summary, err := h.db.MergeUsers(sourceId, req.Into, req.DryRun)
+	if err != nil {
+		writeMappedError(w, err, "User not found", "Failed to merge accounts")
+		return
+	}
+
+	if req.DryRun {
+		log.Info().Int64("source_id", sourceId).Int64("target_id", req.Into).Msg("Account merge dry run")
+		writeJSONResponse(w, http.StatusOK, summary)
+		return
+	}
+	// Moved posts are shown under their new author
+	h.postLists.Flush()
+
+	log.Info().Int64("source_id", sourceId).Int64("target_id", req.Into).Int64("posts", summary.Posts).Int64("comments", summary.Comments).Msg("Accounts merged")
+	h.events.Record(model.EventUserMerged, middleware.GetUserID(r), model.EventSubjectUser, sourceId, map[string]interface{}{
+		"into":     req.Into,
+		"posts":    summary.Posts,
+		"comments": summary.Comments,
+	})
+	writeJSONResponse(w, http.StatusOK, summary)
+}
+
+// #endregion
+
+// #region Leaderboard handlers
+
+// GET /api/leaderboard?period=week|month|all&by=karma|posts|comments - Handler to get the community leaderboard
+func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /leaderboard - Getting leaderboard")
+
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = "week"
+	}
+	rankBy := r.URL.Query().Get("by")
+	if rankBy == "" {
+		rankBy = "karma"
+	}
+
+	entries, err := h.leaderboard.GetLeaderboard(period, rankBy)
+	if err != nil {
+		writeMappedError(w, err, "period must be week, month or all and by must be karma, posts or comments", "Failed to get leaderboard")
+		return
+	}
+
+	log.Info().Str("period", period).Str("by", rankBy).Int("count", len(entries)).Msg("Successfully retrieved leaderboard")
+	writeJSONResponse(w, http.StatusOK, entries)
+}
+
+// #endregion
+
+// #region Maintenance handlers
+
+// GET /api/admin/read-only - Handler to get the read-only mode status with admin permissions
+func (h *Handler) GetReadOnlyMode(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /admin/read-only - Getting read-only mode")
+
+	writeJSONResponse(w, http.StatusOK, map[string]bool{"enabled": h.readOnly.Enabled()})
+}
+
+// PUT /api/admin/read-only - Handler to turn read-only mode on or off with admin permissions
+func (h *Handler) SetReadOnlyMode(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /admin/read-only - Setting read-only mode")
+
+	// Parse request body
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		log.Warn().Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Request body must include enabled (true or false)")
+		return
+	}
+
+	h.readOnly.SetEnabled(*req.Enabled)
+
+	log.Info().Str("admin", middleware.GetUsername(r)).Bool("enabled", *req.Enabled).Msg("Read-only mode updated")
+	writeJSONResponse(w, http.StatusOK, map[string]bool{"enabled": *req.Enabled})
+}
+
 // #endregion
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+}
+
+// An in-memory profiles table behind a database/sql driver, enough for the profile handlers:
+// SELECTs by user ID return the stored row and UPDATEs apply their "column = $n" assignments
+type profileStore struct {
+	mu   sync.Mutex
+	rows map[int64]map[string]driver.Value
+}
+
+// Columns in the order the repository selects them
+var profileStoreColumns = strings.Split("user_id, first_name, last_name, email, github_link, city, state, date_registered, display_name", ", ")
+
+var assignmentPattern = regexp.MustCompile(`(\w+) = \$(\d+)`)
+
+func (s *profileStore) Open(string) (driver.Conn, error) { return &profileConn{store: s}, nil }
+
+type profileConn struct{ store *profileStore }
+
+func (c *profileConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare isn't supported")
+}
+func (c *profileConn) Close() error { return nil }
+func (c *profileConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions aren't supported")
+}
+
+func (c *profileConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	// Compare with the whitespace collapsed, as statements may span lines
+	if !strings.Contains(strings.Join(strings.Fields(query), " "), "FROM profiles WHERE user_id = $1") {
+		return nil, fmt.Errorf("unexpected query %q", query)
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	rows := &profileRows{}
+	if row, ok := c.store.rows[args[0].Value.(int64)]; ok {
+		values := make([]driver.Value, len(profileStoreColumns))
+		for i, column := range profileStoreColumns {
+			values[i] = row[column]
+		}
+		rows.values = append(rows.values, values)
+	}
+	return rows, nil
+}
+
+func (c *profileConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	set, where, ok := strings.Cut(query, "WHERE user_id = $1")
+	if !strings.Contains(set, "UPDATE profiles") || !ok || strings.TrimSpace(where) != "" {
+		return nil, fmt.Errorf("unexpected statement %q", query)
+	}
+
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	row, ok := c.store.rows[args[0].Value.(int64)]
+	if !ok {
+		return driver.RowsAffected(0), nil
+	}
+	for _, assignment := range assignmentPattern.FindAllStringSubmatch(set, -1) {
+		var position int
+		fmt.Sscan(assignment[2], &position)
+		row[assignment[1]] = args[position-1].Value
+	}
+	return driver.RowsAffected(1), nil
+}
+
+type profileRows struct {
+	values [][]driver.Value
+}
+
+func (r *profileRows) Columns() []string { return profileStoreColumns }
+func (r *profileRows) Close() error      { return nil }
+
+func (r *profileRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// A handler whose database is an in-memory profiles table holding one profile
+func newProfileHandler(t *testing.T, profile map[string]driver.Value) (*Handler, *profileStore) {
+	t.Helper()
+
+	store := &profileStore{rows: map[int64]map[string]driver.Value{profile["user_id"].(int64): profile}}
+	name := "profiles-" + t.Name()
+	sql.Register(name, store)
+
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &Handler{db: &repository.DB{DB: db}}, store
+}
+
+func TestUpdateProfileLeavesEmailUnchanged(t *testing.T) {
+	h, store := newProfileHandler(t, map[string]driver.Value{
+		"user_id":         int64(7),
+		"first_name":      "Ada",
+		"last_name":       "Lovelace",
+		"email":           "ada@example.com",
+		"github_link":     "https://github.com/ada",
+		"city":            "London",
+		"state":           "LDN",
+		"date_registered": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		"display_name":    nil,
+	})
+
+	// The old endpoint must not be a way around the confirmed email change flow
+	body := `{"first_name": "Augusta", "last_name": "King", "email": "attacker@example.com",
+		"github_link": "https://github.com/augusta", "city": "Ockham", "state": "SRY"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/profiles/7", strings.NewReader(body))
+	req = mux.SetURLVars(req, map[string]string{"userId": "7"})
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, int64(7)))
+	rec := httptest.NewRecorder()
+
+	h.UpdateProfile(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update answered %d %s", rec.Code, rec.Body.String())
+	}
+	var response model.ProfileResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Email != "ada@example.com" || response.FirstName != "Augusta" {
+		t.Errorf("response has email %q and first name %q", response.Email, response.FirstName)
+	}
+	if stored := store.rows[7]; stored["email"] != "ada@example.com" || stored["city"] != "Ockham" {
+		t.Errorf("stored email %q and city %q", stored["email"], stored["city"])
+	}
+}
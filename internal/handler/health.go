@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"byte-board/internal/model"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// How long the readiness probe waits for the database
+const readinessTimeout = 2 * time.Second
+
+// GET /readyz - Readiness probe for load balancers and orchestrators: 200 while the database answers, 503 otherwise
+func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	if !h.databaseReady(r) {
+		writeJSONResponse(w, http.StatusServiceUnavailable, model.ReadinessResponse{Status: "unavailable", ReadOnly: h.readOnly.Enabled()})
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, model.ReadinessResponse{Status: "ready", ReadOnly: h.readOnly.Enabled()})
+}
+
+// GET /readyz?verbose=1 - Handler to add database detail to the readiness probe with admin permissions:
+// schema version and migration status, replication state, pool saturation and table statistics
+func (h *Handler) ReadinessDetail(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /readyz?verbose=1 - Getting readiness detail")
+
+	if !h.databaseReady(r) {
+		writeJSONResponse(w, http.StatusServiceUnavailable, model.ReadinessResponse{Status: "unavailable", ReadOnly: h.readOnly.Enabled()})
+		return
+	}
+
+	status, err := h.db.GetDatabaseStatus()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get database status")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get database status")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, model.ReadinessResponse{
+		Status:   "ready",
+		ReadOnly: h.readOnly.Enabled(),
+		Database: status,
+	})
+}
+
+// Pings the database, logging why it isn't ready
+func (h *Handler) databaseReady(r *http.Request) bool {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	if err := h.db.PingContext(ctx); err != nil {
+		log.Warn().Err(err).Msg("Readiness check failed: database unreachable")
+		return false
+	}
+
+	return true
+}
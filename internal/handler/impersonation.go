@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// POST /api/admin/impersonate/{userId} - Handler to get a short-lived token that acts as the user, with only their permissions
+func (h *Handler) ImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/impersonate/{userId} - Impersonating user")
+
+	userId, err := model.ParseID(mux.Vars(r)["userId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req model.ImpersonationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	adminId := middleware.GetUserID(r)
+	response, err := h.impersonationService.Impersonate(adminId, userId, req.Reason, h.clientIP(r), r.UserAgent())
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "User not found", "Failed to impersonate user")
+		return
+	}
+
+	h.events.Record(model.EventUserImpersonated, adminId, model.EventSubjectUser, userId, map[string]interface{}{
+		"session_id": response.SessionId,
+		"expires_at": response.ExpiresAt,
+		"reason":     req.Reason,
+	})
+	h.recordDataAccess(r, userId, model.DataAccessImpersonation)
+	writeJSONResponse(w, http.StatusCreated, response)
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/auth/me/keyword-alerts - Handler to get the current user's keyword alerts
+func (h *Handler) GetKeywordAlerts(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/auth/me/keyword-alerts - Getting keyword alerts")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	alerts, err := h.keywordAlertService.List(user.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get keyword alerts")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get keyword alerts")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, alerts)
+}
+
+// POST /api/auth/me/keyword-alerts - Handler to register a keyword the current user wants to hear about in new posts
+func (h *Handler) CreateKeywordAlert(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/auth/me/keyword-alerts - Creating keyword alert")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req model.KeywordAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	alert, err := h.keywordAlertService.Create(user.ID, req.Keyword)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "You already have an alert for that keyword", "Failed to create keyword alert")
+		return
+	}
+
+	log.Info().Int64("user_id", user.ID).Str("keyword", alert.Keyword).Msg("Keyword alert created")
+	writeJSONResponse(w, http.StatusCreated, alert)
+}
+
+// DELETE /api/auth/me/keyword-alerts/{alertId} - Handler to remove one of the current user's keyword alerts
+func (h *Handler) DeleteKeywordAlert(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/auth/me/keyword-alerts/{alertId} - Deleting keyword alert")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	alertId, err := model.ParseID(mux.Vars(r)["alertId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid alert ID")
+		return
+	}
+
+	if err := h.keywordAlertService.Delete(user.ID, alertId); err != nil {
+		writeMappedError(w, err, "Keyword alert not found", "Failed to delete keyword alert")
+		return
+	}
+
+	log.Info().Int64("user_id", user.ID).Int64("alert_id", alertId).Msg("Keyword alert deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"byte-board/internal/jobs"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// POST /api/admin/maintenance/reindex - Handler to queue a rebuild of the search indexes with admin permissions
+func (h *Handler) StartReindex(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/maintenance/reindex - Queueing search reindex")
+
+	status, err := h.maintenanceService.Reindex()
+	if err != nil {
+		writeQueueError(w, err, "Failed to queue reindex")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusAccepted, status)
+}
+
+// POST /api/admin/maintenance/cache/flush - Handler to queue a flush of the in-memory caches with admin permissions
+func (h *Handler) StartCacheFlush(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/maintenance/cache/flush - Queueing cache flush")
+
+	status, err := h.maintenanceService.FlushCaches()
+	if err != nil {
+		writeQueueError(w, err, "Failed to queue cache flush")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusAccepted, status)
+}
+
+// GET /api/admin/maintenance/jobs/{jobId} - Handler to get the progress of a maintenance job with admin permissions
+func (h *Handler) GetMaintenanceJob(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/maintenance/jobs/{jobId} - Getting maintenance job status")
+
+	idStr := mux.Vars(r)["jobId"]
+	jobId, err := strconv.Atoi(idStr)
+	if err != nil {
+		log.Warn().Str("job_id", idStr).Msg("Invalid job ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	status, err := h.maintenanceService.JobStatus(jobId)
+	if err != nil {
+		writeMappedError(w, err, "Job not found", "Failed to get job status")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, status)
+}
+
+// Writes the response for a job that couldn't be queued (503 when the queue is backed up)
+func writeQueueError(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, jobs.ErrQueueFull) {
+		log.Warn().Err(err).Msg(message)
+		w.Header().Set("Retry-After", "60")
+		writeErrorResponse(w, http.StatusServiceUnavailable, "Too many maintenance jobs are queued, please try again later")
+		return
+	}
+
+	log.Error().Err(err).Msg(message)
+	writeErrorResponse(w, http.StatusInternalServerError, message)
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Who is making the request, for shaping responses about members
+func viewerOf(r *http.Request) model.Viewer {
+	return model.Viewer{
+		UserId: middleware.GetUserID(r),
+		Staff:  middleware.HasPermission(r, model.PermissionUsersRead),
+	}
+}
+
+// Looks up the staff-only details of the given members. They're extras, so a failed lookup is logged
+// and responses go out without them.
+func (h *Handler) loadMemberDetails(userIds []int64) map[int64]model.MemberDetails {
+	details, err := h.db.GetMemberDetails(userIds)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load member details")
+		return nil
+	}
+	return details
+}
+
+// Looks up which of the given members a signed-in viewer follows. Like the staff details, a failed
+// lookup is logged and responses go out without the flags (nil).
+func (h *Handler) loadFollowed(viewer model.Viewer, userIds []int64) map[int64]bool {
+	if viewer.UserId == 0 {
+		return nil
+	}
+
+	followed, err := h.db.GetFollowedAmong(viewer.UserId, userIds)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load followed users")
+		return nil
+	}
+	return followed
+}
+
+// Builds profile responses shaped for the viewer, with whether they follow each member when signed in
+// and the staff details when they're staff
+func (h *Handler) profileResponses(profiles []model.Profile, viewer model.Viewer) []model.ProfileResponse {
+	responses := model.NewProfileResponses(profiles, viewer)
+
+	userIds := make([]int64, 0, len(profiles))
+	for _, profile := range profiles {
+		userIds = append(userIds, profile.UserId)
+	}
+	if followed := h.loadFollowed(viewer, userIds); followed != nil {
+		for i := range responses {
+			following := followed[responses[i].UserId]
+			responses[i].IsFollowing = &following
+		}
+	}
+	if !viewer.Staff {
+		return responses
+	}
+
+	details := h.loadMemberDetails(userIds)
+	for i := range responses {
+		if member, ok := details[responses[i].UserId]; ok {
+			responses[i].Staff = &member
+		}
+	}
+	return responses
+}
+
+// Builds a profile response shaped for the viewer
+func (h *Handler) profileResponse(profile *model.Profile, viewer model.Viewer) model.ProfileResponse {
+	return h.profileResponses([]model.Profile{*profile}, viewer)[0]
+}
+
+// Builds user responses for the admin user lookups, with the staff details (everyone who can reach
+// them is staff)
+func (h *Handler) userResponses(users []model.User) []model.UserResponse {
+	userIds := make([]int64, 0, len(users))
+	for _, user := range users {
+		userIds = append(userIds, user.ID)
+	}
+	details := h.loadMemberDetails(userIds)
+
+	responses := model.NewUserResponses(users)
+	for i := range responses {
+		if member, ok := details[responses[i].UserID]; ok {
+			responses[i].Staff = &member
+		}
+	}
+	return responses
+}
+
+// Builds a user response for the admin user lookups
+func (h *Handler) userResponse(user *model.User) model.UserResponse {
+	return h.userResponses([]model.User{*user})[0]
+}
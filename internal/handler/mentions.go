@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Suggestion limits for @mention autocomplete
+const (
+	defaultSuggestLimit = 8
+	maxSuggestLimit     = 20
+	maxSuggestQuery     = 50
+)
+
+// GET /api/users/suggest?q=&limit= - Handler to suggest users whose username or display name starts with q (for @mentions)
+func (h *Handler) SuggestUsers(w http.ResponseWriter, r *http.Request) {
+	// The autocomplete asks on every keystroke, so only log at debug level
+	log.Debug().Msg("GET /api/users/suggest - Suggesting users")
+
+	// Accept the query as typed, with or without the leading @
+	q := strings.TrimPrefix(strings.TrimSpace(r.URL.Query().Get("q")), "@")
+	if q == "" || len(q) > maxSuggestQuery {
+		writeErrorResponse(w, http.StatusBadRequest, "q must be 1-50 characters")
+		return
+	}
+
+	limit := defaultSuggestLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxSuggestLimit {
+			writeErrorResponse(w, http.StatusBadRequest, "limit must be between 1 and 20")
+			return
+		}
+	}
+
+	authors, err := h.db.SuggestAuthors(r.Context(), q, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to suggest users")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to suggest users")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, authors)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"byte-board/internal/metrics"
+	"byte-board/internal/model"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Longest range the metrics endpoints return, and the default when no range is given
+const (
+	maxMetricsDays     = 366
+	defaultMetricsDays = 30
+)
+
+// GET /api/admin/metrics/active-users?from=YYYY-MM-DD&to=YYYY-MM-DD - Handler to get DAU/WAU/MAU per day with admin permissions
+func (h *Handler) GetActiveUserMetrics(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/metrics/active-users - Getting active user metrics")
+
+	from, to, err := parseMetricsRange(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	points, err := h.db.GetActiveUserMetrics(from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get active user metrics")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get active user metrics")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, points)
+}
+
+// GET /api/admin/metrics/growth?from=YYYY-MM-DD&to=YYYY-MM-DD - Handler to get daily new users/posts/comments with admin permissions
+func (h *Handler) GetGrowthMetrics(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/metrics/growth - Getting growth metrics")
+
+	from, to, err := parseMetricsRange(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	points, err := h.db.GetGrowthMetrics(from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get growth metrics")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get growth metrics")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, points)
+}
+
+// GET /api/admin/metrics/cleanup - Handler to get how many expired rows the cleanup job has purged with admin permissions
+func (h *Handler) GetCleanupMetrics(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/metrics/cleanup - Getting cleanup metrics")
+
+	writeJSONResponse(w, http.StatusOK, h.cleanupService.Stats())
+}
+
+// GET /api/admin/metrics/counters - Handler to export business counters (registrations, logins, posts, comments,
+// moderation actions) in OpenMetrics text format for scrapers, with admin permissions or the metrics scope
+func (h *Handler) GetCounterMetrics(w http.ResponseWriter, r *http.Request) {
+	// Scraped every few seconds, so keep it out of the info log
+	log.Debug().Msg("GET /api/admin/metrics/counters - Exporting business counters")
+
+	metrics.Handler().ServeHTTP(w, r)
+}
+
+// Parses the from/to dates of a metrics request (defaults to the last 30 days, ending today in UTC)
+func parseMetricsRange(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if toStr := query.Get("to"); toStr != "" {
+		parsed, err := time.Parse(model.MetricsDateLayout, toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to must be a date like 2024-01-31")
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -(defaultMetricsDays - 1))
+	if fromStr := query.Get("from"); fromStr != "" {
+		parsed, err := time.Parse(model.MetricsDateLayout, fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from must be a date like 2024-01-01")
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must not be after to")
+	}
+	if to.Sub(from) >= maxMetricsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("range can cover at most %d days", maxMetricsDays)
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,405 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// POST /api/admin/moderation/actions - Handler to hide/lock/ban/suspend (or undo) with admin permissions
+func (h *Handler) TakeModerationAction(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/moderation/actions - Taking moderation action")
+
+	moderator, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req model.ModerationActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	action := &model.ModerationAction{
+		Action:     req.Action,
+		TargetType: req.TargetType,
+		TargetId:   req.TargetId,
+		Reason:     req.Reason,
+		ReportId:   req.ReportId,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	h.takeModerationAction(w, moderator, action)
+}
+
+// PUT /api/admin/users/{userId}/ban - Handler to ban a user, or suspend them when a duration is given, with admin permissions
+func (h *Handler) BanUser(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/users/{userId}/ban - Banning user")
+	h.banUser(w, r, false)
+}
+
+// PUT /api/admin/users/{userId}/suspend - Handler to suspend a user for a number of hours with admin permissions
+func (h *Handler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/users/{userId}/suspend - Suspending user")
+	h.banUser(w, r, true)
+}
+
+// Bans or suspends the user in the path. A duration is required when suspend is set.
+func (h *Handler) banUser(w http.ResponseWriter, r *http.Request, suspend bool) {
+	userId, err := model.ParseID(mux.Vars(r)["userId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	moderator, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req model.BanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.DurationHours < 0 || (suspend && req.DurationHours == 0) {
+		writeErrorResponse(w, http.StatusBadRequest, "duration_hours must be a positive number of hours")
+		return
+	}
+
+	action := &model.ModerationAction{
+		Action:     model.ModerationBan,
+		TargetType: model.ModerationTargetUser,
+		TargetId:   userId,
+		Reason:     req.Reason,
+		ReportId:   req.ReportId,
+	}
+	if req.DurationHours > 0 {
+		expiresAt := time.Now().UTC().Add(time.Duration(req.DurationHours) * time.Hour)
+		action.Action = model.ModerationSuspend
+		action.ExpiresAt = &expiresAt
+	}
+	h.takeModerationAction(w, moderator, action)
+}
+
+// Applies a moderation action and answers with it
+func (h *Handler) takeModerationAction(w http.ResponseWriter, moderator *model.User, action *model.ModerationAction) {
+	if err := h.moderationService.TakeAction(moderator, action); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Target not found", "Failed to take moderation action")
+		return
+	}
+
+	h.events.Record(model.EventModerationAction, moderator.ID, action.TargetType, action.TargetId, map[string]interface{}{
+		"action_id":      action.ActionId,
+		"action":         action.Action,
+		"target_user_id": action.TargetUserId,
+		"reason":         action.Reason,
+		"expires_at":     action.ExpiresAt,
+	})
+	writeJSONResponse(w, http.StatusCreated, action)
+}
+
+// GET /api/admin/moderation/stats?from=YYYY-MM-DD&to=YYYY-MM-DD - Handler to get each moderator's workload with admin permissions
+func (h *Handler) GetModeratorStats(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/moderation/stats - Getting moderator stats")
+
+	from, to, err := parseMetricsRange(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	moderators, err := h.db.GetModeratorStats(from, to)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get moderator stats")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get moderator stats")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"from":       from.Format(model.MetricsDateLayout),
+		"to":         to.Format(model.MetricsDateLayout),
+		"moderators": moderators,
+	})
+}
+
+// GET /api/admin/moderation/actions?target_user_id=&action=&limit=&offset= - Handler to get the moderation audit trail with admin permissions
+func (h *Handler) GetModerationActions(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/moderation/actions - Getting moderation actions")
+
+	// Parse filters
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	targetUserId, err := parseOptionalID(r, "target_user_id")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	actions, err := h.db.ListModerationActions(model.ModerationActionFilter{
+		TargetUserId: targetUserId,
+		Action:       r.URL.Query().Get("action"),
+		Limit:        limit,
+		Offset:       offset,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get moderation actions")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get moderation actions")
+		return
+	}
+
+	log.Info().Int("count", len(actions)).Msg("Successfully retrieved moderation actions")
+	if targetUserId != 0 {
+		h.recordDataAccess(r, targetUserId, model.DataAccessModerationHistory)
+	}
+	writeJSONResponse(w, http.StatusOK, actions)
+}
+
+// GET /api/moderation/me - Handler to get the moderation actions taken on your content or account
+func (h *Handler) GetMyModerationActions(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/moderation/me - Getting own moderation history")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	actions, err := h.db.ListModerationActions(model.ModerationActionFilter{TargetUserId: user.ID, VisibleToTarget: true})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get moderation actions")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get moderation actions")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, actions)
+}
+
+// POST /api/appeals - Contest a moderation action taken on your content or account
+func (h *Handler) CreateAppeal(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/appeals - Creating appeal")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req model.AppealRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	appeal := &model.Appeal{ActionId: req.ActionId, Message: req.Message}
+	if err := h.moderationService.CreateAppeal(user, appeal); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Moderation action not found or already appealed", "Failed to create appeal")
+		return
+	}
+
+	log.Info().Int64("appeal_id", appeal.AppealId).Int64("action_id", appeal.ActionId).Msg("Appeal created")
+	writeJSONResponse(w, http.StatusCreated, appeal)
+}
+
+// GET /api/appeals - Handler to get your appeals and their outcomes
+func (h *Handler) GetMyAppeals(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/appeals - Getting own appeals")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	appeals, err := h.db.ListAppeals(model.AppealFilter{UserId: user.ID})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get appeals")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get appeals")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, appeals)
+}
+
+// GET /api/admin/appeals?status=pending|upheld|overturned&limit=&offset= - Handler to get the appeals queue with admin permissions
+func (h *Handler) GetAppeals(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/appeals - Getting appeals queue")
+
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = model.AppealStatusPending
+	}
+
+	appeals, err := h.db.ListAppeals(model.AppealFilter{Status: status, Limit: limit, Offset: offset})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get appeals")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get appeals")
+		return
+	}
+
+	log.Info().Int("count", len(appeals)).Msg("Successfully retrieved appeals")
+	writeJSONResponse(w, http.StatusOK, appeals)
+}
+
+// PUT /api/admin/appeals/{appealId} - Handler to uphold or overturn an appeal with admin permissions
+func (h *Handler) ResolveAppeal(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/appeals/{appealId} - Resolving appeal")
+
+	moderator, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	idStr := mux.Vars(r)["appealId"]
+	appealId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("appeal_id", idStr).Msg("Invalid appeal ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid appeal ID")
+		return
+	}
+
+	// Parse request body
+	var req model.ResolveAppealRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	appeal, err := h.moderationService.ResolveAppeal(moderator, appealId, req.Status, req.Note)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Appeal not found", "Failed to resolve appeal")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, appeal)
+}
+
+// Returns the ID of the signed-in user making the request (0 for anonymous requests)
+func (h *Handler) viewerId(r *http.Request) int64 {
+	return middleware.GetUserID(r)
+}
+
+// Reports whether content by authorId can be shown to the viewer
+// (a shadowbanned author's content is only visible to the author)
+func (h *Handler) visibleTo(authorId, viewerId int64) (bool, error) {
+	if authorId == viewerId {
+		return true, nil
+	}
+
+	shadowbanned, err := h.db.IsUserShadowbanned(authorId)
+	if err != nil {
+		return false, err
+	}
+
+	return !shadowbanned, nil
+}
+
+// Reports whether the viewer can read a post: its author isn't shadowbanned (unless that's the viewer) and the
+// viewer is in the audience the post's visibility allows. Moderator-hidden posts are checked separately.
+func (h *Handler) canViewPost(post *model.Post, viewerId int64) (bool, error) {
+	visible, err := h.visibleTo(post.UserId, viewerId)
+	if err != nil || !visible {
+		return false, err
+	}
+
+	return h.postService.CanView(post, viewerId)
+}
+
+// Loads a post the viewer can read, writing a 404 if it doesn't exist, is hidden, or is outside their audience
+func (h *Handler) readablePost(w http.ResponseWriter, r *http.Request, postId int64) (*model.Post, bool) {
+	post, err := h.db.GetPostById(postId)
+	if err != nil {
+		writeMappedError(w, err, "Post not found", "Failed to get post")
+		return nil, false
+	}
+
+	visible, err := h.canViewPost(post, h.viewerId(r))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check post visibility")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get post")
+		return nil, false
+	}
+	if post.Hidden || !visible {
+		writeErrorResponse(w, http.StatusNotFound, "Post not found")
+		return nil, false
+	}
+
+	return post, true
+}
+
+// Loads a comment the viewer can read, writing a 404 if it doesn't exist, is hidden, or is on a post they
+// can't read
+func (h *Handler) readableComment(w http.ResponseWriter, r *http.Request, commentId int64) (*model.Comment, bool) {
+	comment, err := h.db.GetCommentById(commentId)
+	if err != nil {
+		writeMappedError(w, err, "Comment not found", "Failed to get that comment")
+		return nil, false
+	}
+	if comment.Hidden {
+		log.Warn().Int64("ID", commentId).Msg("Comment is hidden by a moderator")
+		writeErrorResponse(w, http.StatusNotFound, "Comment not found")
+		return nil, false
+	}
+	visible, err := h.visibleTo(comment.UserId, h.viewerId(r))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to check comment visibility")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get that comment")
+		return nil, false
+	}
+	if !visible {
+		writeErrorResponse(w, http.StatusNotFound, "Comment not found")
+		return nil, false
+	}
+
+	// Comments are only as visible as the post they're on
+	if _, ok := h.readablePost(w, r, comment.PostId); !ok {
+		return nil, false
+	}
+
+	return comment, true
+}
+
+// Loads the authenticated user (once per request), writing an error response if there isn't one
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) (*model.User, bool) {
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
+		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return nil, false
+	}
+
+	user, err := middleware.CachedUser(r, h.db, userId)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get user")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get user info")
+		return nil, false
+	}
+
+	return user, true
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"byte-board/internal/service"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Notifications returned when no limit is given
+const defaultNotificationPageSize = 50
+
+// GET /api/auth/me/notification-settings - Handler to get how the current user is notified about each event
+func (h *Handler) GetNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/auth/me/notification-settings - Getting notification settings")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	settings, err := h.notificationService.GetSettings(user.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get notification settings")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get notification settings")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, settings)
+}
+
+// PUT /api/auth/me/notification-settings - Handler to choose email/in-app delivery per event type
+func (h *Handler) UpdateNotificationSettings(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/auth/me/notification-settings - Updating notification settings")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req model.NotificationSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	settings, err := h.notificationService.UpdateSettings(user.ID, req.Settings)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to update notification settings")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to update notification settings")
+		return
+	}
+
+	log.Info().Int64("user_id", user.ID).Msg("Notification settings updated")
+	writeJSONResponse(w, http.StatusOK, settings)
+}
+
+// GET /api/notifications?unread=true&limit=&offset= - Handler to get the current user's in-app notifications
+func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/notifications - Getting notifications")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 {
+		limit = defaultNotificationPageSize
+	}
+
+	notifications, err := h.notificationService.List(model.NotificationFilter{
+		UserId:     user.ID,
+		UnreadOnly: r.URL.Query().Get("unread") == "true",
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get notifications")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get notifications")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, notifications)
+}
+
+// GET /api/notifications/unread-count - Handler to get the current user's unread notification count.
+// Clients may cache it but must revalidate; the ETag changes whenever the count or newest unread
+// notification does, so polling usually ends in a body-less 304.
+func (h *Handler) GetUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	log.Debug().Msg("GET /api/notifications/unread-count - Getting unread notification count")
+
+	userId := middleware.GetUserID(r)
+	unread, err := h.notificationService.Unread(userId)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count unread notifications")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to count unread notifications")
+		return
+	}
+
+	etag := fmt.Sprintf(`W/"unread-%d-%d"`, unread.Count, unread.LatestId)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Authorization")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, unread)
+}
+
+// PUT /api/notifications/{notificationId}/read - Handler to mark a notification as read
+func (h *Handler) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/notifications/{notificationId}/read - Marking notification read")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	idStr := mux.Vars(r)["notificationId"]
+	notificationId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("notification_id", idStr).Msg("Invalid notification ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid notification ID")
+		return
+	}
+
+	if err := h.notificationService.MarkRead(user.ID, notificationId); err != nil {
+		writeMappedError(w, err, "Notification not found", "Failed to mark notification read")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Notification marked as read"})
+}
+
+// PUT /api/notifications/read-all - Handler to mark all of the current user's notifications as read
+func (h *Handler) MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/notifications/read-all - Marking all notifications read")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.MarkAllRead(user.ID); err != nil {
+		log.Error().Err(err).Msg("Failed to mark notifications read")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to mark notifications read")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "All notifications marked as read"})
+}
+
+// GET|POST /api/unsubscribe?user=&event=&sig= - Handler for the signed unsubscribe links in emails.
+// POST is the RFC 8058 one-click request mail clients send on the user's behalf.
+func (h *Handler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("/api/unsubscribe - Unsubscribing from emails")
+
+	query := r.URL.Query()
+	userId, err := model.ParseID(query.Get("user"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid unsubscribe link")
+		return
+	}
+
+	eventType := query.Get("event")
+	if err := h.notificationService.Unsubscribe(userId, eventType, query.Get("sig")); err != nil {
+		if errors.Is(err, service.ErrInvalidToken) {
+			writeErrorResponse(w, http.StatusBadRequest, "Invalid unsubscribe link")
+			return
+		}
+		writeMappedError(w, err, "Invalid unsubscribe link", "Failed to unsubscribe")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "You won't get " + eventType + " emails anymore"})
+}
@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// POST /api/presence/ping - Handler to mark the current user as online (clients call it periodically while open)
+func (h *Handler) PingPresence(w http.ResponseWriter, r *http.Request) {
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in the context")
+		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	h.presenceService.Ping(userId)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/presence/online-count - Handler to get how many signed-in users are online
+func (h *Handler) GetOnlineCount(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, map[string]int{"online": h.presenceService.OnlineCount()})
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"byte-board/internal/markdown"
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Largest request body accepted for a preview
+const maxPreviewBytes = 256 << 10
+
+// POST /api/preview - Render Markdown the way posts and comments are rendered, without saving anything
+func (h *Handler) PreviewContent(w http.ResponseWriter, r *http.Request) {
+	log.Debug().Msg("POST /api/preview - Rendering preview")
+
+	var req model.PreviewRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPreviewBytes)).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Apply the word filter as a save would (block rules reject, replace rules rewrite; flags aren't reported)
+	if _, err := h.wordFilter.Screen(&req.Content); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to screen preview")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to render preview")
+		return
+	}
+
+	html, err := markdown.Render(req.Content)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to render preview")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to render preview")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, model.PreviewResponse{HTML: html})
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/profiles/{userId}/projects - Handler to get the projects on a profile
+func (h *Handler) GetProjectsOnProfile(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /profiles/{userId}/projects - Getting projects on profile")
+
+	vars := mux.Vars(r)
+	idStr := vars["userId"]
+
+	// Convert the ID string into an int
+	userId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("id", idStr).Msg("Invalid user ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	projects, err := h.db.GetProjectsByUser(userId)
+	if err != nil {
+		writeMappedError(w, err, "Projects not found", "Failed to get projects")
+		return
+	}
+
+	log.Info().Int("count", len(projects)).Msg("Successfully retrieved projects on profile")
+	writeJSONResponse(w, http.StatusOK, model.NewProjectResponses(projects))
+}
+
+// GET /api/profiles/{userId}/projects/{projectId} - Handler to get a project by ID
+func (h *Handler) GetProjectById(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /profiles/{userId}/projects/{projectId} - Getting project by ID")
+
+	userId, projectId, ok := parseProjectPath(w, r)
+	if !ok {
+		return
+	}
+
+	project, err := h.db.GetProject(userId, projectId)
+	if err != nil {
+		writeMappedError(w, err, "Project not found", "Failed to get project")
+		return
+	}
+
+	log.Info().Int64("ID", project.ProjectId).Msg("Successfully retrieved project")
+	writeJSONResponse(w, http.StatusOK, model.NewProjectResponse(project))
+}
+
+// POST /api/profiles/{userId}/projects - Add a project to your profile
+func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/profiles/{userId}/projects - Creating project")
+
+	// Get authenticated user from context
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
+		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	// Get UserID from req URL
+	vars := mux.Vars(r)
+	idStr := vars["userId"]
+	userId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("User ID", idStr).Msg("Invalid user ID format in URL")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+
+	// Verify the user owns the profile
+	if userId != userId {
+		log.Warn().Int64("Profile ID", userId).Int64("User ID", userId).Msg("User does not own this profile")
+		writeErrorResponse(w, http.StatusForbidden, "You can only add projects to your profile")
+		return
+	}
+
+	// Parse request body
+	var req model.ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	project := &model.Project{
+		UserId:        userId,
+		Title:         req.Title,
+		Description:   req.Description,
+		RepoURL:       req.RepoURL,
+		ScreenshotURL: req.ScreenshotURL,
+	}
+
+	// Call profile service to validate and save the project
+	if err := h.profileService.CreateProject(project); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Profile not found", "Failed to create project")
+		return
+	}
+
+	log.Info().Int64("project_id", project.ProjectId).Msg("Project created successfully")
+	writeJSONResponse(w, http.StatusCreated, model.NewProjectResponse(project))
+}
+
+// PUT /api/profiles/{userId}/projects/{projectId} - Update a project on your profile
+func (h *Handler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/profiles/{userId}/projects/{projectId} - Updating project")
+
+	// Get authenticated user from context
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
+		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	userId, projectId, ok := parseProjectPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Verify the user owns the profile
+	if userId != userId {
+		log.Warn().Int64("Profile ID", userId).Int64("User ID", userId).Msg("User does not own this profile")
+		writeErrorResponse(w, http.StatusForbidden, "You can only update your own projects")
+		return
+	}
+
+	// Get existing project from the db
+	project, err := h.db.GetProject(userId, projectId)
+	if err != nil {
+		writeMappedError(w, err, "Project not found", "Failed to get project")
+		return
+	}
+
+	// Parse request body
+	var req model.ProjectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	project.Title = req.Title
+	project.Description = req.Description
+	project.RepoURL = req.RepoURL
+	project.ScreenshotURL = req.ScreenshotURL
+
+	// Call profile service to validate and save the changes
+	if err := h.profileService.UpdateProject(project); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Project not found", "Failed to update project")
+		return
+	}
+
+	log.Info().Int64("project_id", project.ProjectId).Msg("Project updated successfully")
+	writeJSONResponse(w, http.StatusOK, model.NewProjectResponse(project))
+}
+
+// DELETE /api/profiles/{userId}/projects/{projectId} - Delete a project from your profile
+func (h *Handler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/profiles/{userId}/projects/{projectId} - Deleting project")
+
+	// Get authenticated user from context
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
+		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	userId, projectId, ok := parseProjectPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Verify the user owns the profile or is an admin
+	if userId != userId && middleware.GetRole(r) != "admin" {
+		log.Warn().Int64("Profile ID", userId).Int64("User ID", userId).Msg("User does not own this profile")
+		writeErrorResponse(w, http.StatusForbidden, "You can only delete your own projects")
+		return
+	}
+
+	if err := h.db.DeleteProject(userId, projectId); err != nil {
+		writeMappedError(w, err, "Project not found", "Failed to delete project")
+		return
+	}
+
+	log.Info().Int64("project_id", projectId).Msg("Project deleted successfully")
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Project deleted successfully"})
+}
+
+// Parses the user and project IDs from a project URL, writing a 400 if either is invalid
+func parseProjectPath(w http.ResponseWriter, r *http.Request) (userId int64, projectId int64, ok bool) {
+	vars := mux.Vars(r)
+
+	userId, err := model.ParseID(vars["userId"])
+	if err != nil {
+		log.Warn().Str("User ID", vars["userId"]).Msg("Invalid user ID format in URL")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid ID format")
+		return 0, 0, false
+	}
+
+	projectId, err = model.ParseID(vars["projectId"])
+	if err != nil {
+		log.Warn().Str("Project ID", vars["projectId"]).Msg("Invalid project ID format in URL")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return 0, 0, false
+	}
+
+	return userId, projectId, true
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// PUT /api/comments/{commentId}/reactions/{reaction} - Handler to react to a comment (reacting twice changes nothing)
+func (h *Handler) ReactToComment(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/comments/{commentId}/reactions/{reaction} - Reacting to comment")
+
+	comment, reaction, ok := h.reactionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	if err := h.db.AddCommentReaction(comment.CommentId, userId, reaction); err != nil {
+		log.Error().Err(err).Msg("Failed to add comment reaction")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to react to comment")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Int64("comment_id", comment.CommentId).Str("reaction", reaction).Msg("Comment reaction added")
+	h.writeCommentReactions(w, userId, comment.CommentId)
+}
+
+// DELETE /api/comments/{commentId}/reactions/{reaction} - Handler to take back a reaction to a comment
+func (h *Handler) UnreactToComment(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/comments/{commentId}/reactions/{reaction} - Removing comment reaction")
+
+	comment, reaction, ok := h.reactionTarget(w, r)
+	if !ok {
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	if err := h.db.RemoveCommentReaction(comment.CommentId, userId, reaction); err != nil {
+		writeMappedError(w, err, "You haven't reacted that way to this comment", "Failed to remove comment reaction")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Int64("comment_id", comment.CommentId).Str("reaction", reaction).Msg("Comment reaction removed")
+	h.writeCommentReactions(w, userId, comment.CommentId)
+}
+
+// Parses the comment and reaction of a reaction request, writing an error unless the reaction is known
+// and the viewer can read the comment
+func (h *Handler) reactionTarget(w http.ResponseWriter, r *http.Request) (*model.Comment, string, bool) {
+	vars := mux.Vars(r)
+	commentId, err := model.ParseID(vars["commentId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid comment ID")
+		return nil, "", false
+	}
+
+	reaction := vars["reaction"]
+	if !model.IsCommentReaction(reaction) {
+		writeErrorResponse(w, http.StatusBadRequest, "reaction must be one of "+strings.Join(model.CommentReactions, ", "))
+		return nil, "", false
+	}
+
+	comment, ok := h.readableComment(w, r, commentId)
+	if !ok {
+		return nil, "", false
+	}
+	return comment, reaction, true
+}
+
+// Writes a comment's reactions as the viewer sees them
+func (h *Handler) writeCommentReactions(w http.ResponseWriter, viewerId, commentId int64) {
+	summaries, err := h.db.GetCommentReactions([]int64{commentId}, viewerId)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get comment reactions")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get comment reactions")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, model.CommentReactionsResponse{CommentId: commentId, ReactionSummary: summaries[commentId]})
+}
+
+// Looks up the reactions on the given comments in one query (nil when the lookup fails, which is
+// logged, so responses go out without them)
+func (h *Handler) loadReactions(viewerId int64, commentIds []int64) map[int64]model.ReactionSummary {
+	summaries, err := h.db.GetCommentReactions(commentIds, viewerId)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load comment reactions")
+		return nil
+	}
+	return summaries
+}
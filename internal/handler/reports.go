@@ -0,0 +1,460 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/reports/reasons - Handler to get the reasons users can pick when reporting content
+func (h *Handler) GetReportReasons(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/reports/reasons - Getting report reasons")
+
+	reasons, err := h.db.GetReportReasons(true)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get report reasons")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get report reasons")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, reasons)
+}
+
+// POST /api/reports - Report a post or comment
+func (h *Handler) CreateReport(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/reports - Creating report")
+
+	// Get authenticated user from context
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
+		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	// Parse request body
+	var req model.ReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	report := &model.Report{
+		ReporterId: userId,
+		TargetType: req.TargetType,
+		TargetId:   req.TargetId,
+		ReasonCode: req.Reason,
+		Details:    req.Details,
+	}
+
+	// Call report service to validate and file the report
+	if err := h.reportService.CreateReport(report); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Reported content not found or already reported", "Failed to create report")
+		return
+	}
+
+	log.Info().Int64("report_id", report.ReportId).Msg("Report created successfully")
+	h.events.Record(model.EventReportCreated, userId, model.EventSubjectReport, report.ReportId, map[string]interface{}{
+		"target_type": report.TargetType,
+		"target_id":   report.TargetId,
+		"reason":      report.ReasonCode,
+	})
+	writeJSONResponse(w, http.StatusCreated, model.NewReportResponse(report))
+}
+
+// Columns of the report CSV export
+var reportCSVHeader = []string{
+	"report_id", "created_at", "updated_at", "status", "reason", "target_type", "target_id",
+	"reporter_id", "assigned_to", "details", "resolution_note",
+}
+
+// How long a report export may take to send before the connection is cut (the server's
+// write timeout is meant for ordinary responses)
+const reportExportWriteTimeout = 5 * time.Minute
+
+// GET /api/admin/reports?status=&reason=&target_type=&assigned_to=&unassigned=true&from=&to=&sort=oldest|newest&limit=&offset= - Handler to get reports with admin permissions
+func (h *Handler) GetReports(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/reports - Getting reports")
+
+	filter, err := parseReportFilter(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sort, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Sort, filter.Limit, filter.Offset = sort, limit, offset
+
+	reports, err := h.db.ListReports(filter)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get reports")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get reports")
+		return
+	}
+
+	log.Info().Int("count", len(reports)).Msg("Successfully retrieved reports")
+	writeJSONResponse(w, http.StatusOK, model.NewReportResponses(reports))
+}
+
+// GET /api/admin/reports/export?format=csv&status=&reason=&target_type=&assigned_to=&unassigned=true&from=&to=&sort=oldest|newest -
+// Handler to stream every matching report as CSV with admin permissions, for offline analysis
+func (h *Handler) ExportReports(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/reports/export - Exporting reports")
+
+	if format := r.URL.Query().Get("format"); format != "" && format != "csv" {
+		writeErrorResponse(w, http.StatusBadRequest, "format must be csv")
+		return
+	}
+	filter, err := parseReportFilter(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Sort = r.URL.Query().Get("sort")
+
+	// Not every writer supports deadlines; those keep the server's write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(reportExportWriteTimeout))
+
+	// Rows are buffered by the CSV writer, so a query that fails straight away can still get a JSON error
+	out := csv.NewWriter(w)
+	started := false
+	count := 0
+	start := func() {
+		filename := "reports-" + time.Now().UTC().Format("20060102") + ".csv"
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+		w.WriteHeader(http.StatusOK)
+		started = true
+	}
+
+	// Users whose data the file covers: the reporters and the authors of the reported content.
+	// Recorded once rows start leaving, even if the export is cut short.
+	reporters := map[int64]bool{}
+	var postIds, commentIds []int64
+	defer func() {
+		if started {
+			h.recordReportExportAccess(r, reporters, postIds, commentIds)
+		}
+	}()
+
+	out.Write(reportCSVHeader)
+	err = h.db.ExportReports(filter, func(report model.Report) error {
+		if err := out.Write(reportCSVRecord(report)); err != nil {
+			return err
+		}
+		if report.ReporterId != 0 {
+			reporters[report.ReporterId] = true
+		}
+		switch report.TargetType {
+		case model.ReportTargetPost:
+			postIds = append(postIds, report.TargetId)
+		case model.ReportTargetComment:
+			commentIds = append(commentIds, report.TargetId)
+		}
+
+		count++
+		if count%500 == 0 {
+			if !started {
+				start()
+			}
+			out.Flush()
+			return out.Error()
+		}
+		return nil
+	})
+	if err != nil && !started {
+		log.Error().Err(err).Msg("Failed to export reports")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to export reports")
+		return
+	}
+	if err != nil {
+		// Too late for an error status; the client gets a truncated file
+		log.Error().Err(err).Int("count", count).Msg("Report export interrupted")
+		return
+	}
+
+	if !started {
+		start()
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		log.Error().Err(err).Int("count", count).Msg("Report export interrupted")
+		return
+	}
+
+	log.Info().Int("count", count).Msg("Successfully exported reports")
+}
+
+// Records a report export in the data access log, one entry for each reporter and reported author it covered
+func (h *Handler) recordReportExportAccess(r *http.Request, reporters map[int64]bool, postIds, commentIds []int64) {
+	users := reporters
+	if len(postIds) > 0 || len(commentIds) > 0 {
+		authors, err := h.db.GetReportTargetAuthors(postIds, commentIds)
+		if err != nil {
+			// Still record the reporters rather than nothing
+			log.Error().Err(err).Msg("Failed to get reported authors for the data access log")
+		}
+		for _, userId := range authors {
+			users[userId] = true
+		}
+	}
+
+	userIds := make([]int64, 0, len(users))
+	for userId := range users {
+		userIds = append(userIds, userId)
+	}
+	h.events.RecordDataAccesses(middleware.GetUserID(r), userIds, model.DataAccessReportExport)
+}
+
+// GET /api/admin/reports/{reportId} - Handler to get a report by ID with admin permissions
+func (h *Handler) GetReportById(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/reports/{reportId} - Getting report by ID")
+
+	reportId, ok := parseReportID(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.db.GetReportById(reportId)
+	if err != nil {
+		writeMappedError(w, err, "Report not found", "Failed to get report")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, model.NewReportResponse(report))
+}
+
+// PUT /api/admin/reports/{reportId}/status - Handler to move a report through the moderation workflow
+func (h *Handler) SetReportStatus(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/reports/{reportId}/status - Changing report status")
+
+	reportId, ok := parseReportID(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req model.ReportStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	report, err := h.reportService.ChangeStatus(middleware.GetUserID(r), reportId, req.Status, req.Note)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Report not found", "Failed to change report status")
+		return
+	}
+
+	log.Info().Int64("report_id", reportId).Str("status", report.Status).Str("moderator", middleware.GetUsername(r)).Msg("Report status changed")
+	writeJSONResponse(w, http.StatusOK, model.NewReportResponse(report))
+}
+
+// PUT /api/admin/reports/{reportId}/assignee - Handler to assign a report to a moderator
+func (h *Handler) AssignReport(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/reports/{reportId}/assignee - Assigning report")
+
+	reportId, ok := parseReportID(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req model.ReportAssignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	report, err := h.reportService.AssignReport(reportId, req.ModeratorId)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Report not found", "Failed to assign report")
+		return
+	}
+
+	log.Info().Int64("report_id", reportId).Msg("Report assignment changed")
+	writeJSONResponse(w, http.StatusOK, model.NewReportResponse(report))
+}
+
+// GET /api/admin/report-reasons - Handler to get the full report reason taxonomy (including retired reasons)
+func (h *Handler) GetAllReportReasons(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/report-reasons - Getting all report reasons")
+
+	reasons, err := h.db.GetReportReasons(false)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get report reasons")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get report reasons")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, reasons)
+}
+
+// POST /api/admin/report-reasons - Handler to add a report reason
+func (h *Handler) CreateReportReason(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/report-reasons - Creating report reason")
+
+	// Parse request body
+	var req model.ReportReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	reason := &model.ReportReason{Code: req.Code, Label: req.Label, Active: req.Active == nil || *req.Active}
+	if err := h.reportService.CreateReason(reason); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "A reason with that code already exists", "Failed to create report reason")
+		return
+	}
+
+	log.Info().Str("code", reason.Code).Msg("Report reason created")
+	writeJSONResponse(w, http.StatusCreated, reason)
+}
+
+// PUT /api/admin/report-reasons/{code} - Handler to relabel a report reason or retire it (active=false)
+func (h *Handler) UpdateReportReason(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/report-reasons/{code} - Updating report reason")
+
+	reason, err := h.db.GetReportReason(mux.Vars(r)["code"])
+	if err != nil {
+		writeMappedError(w, err, "Report reason not found", "Failed to get report reason")
+		return
+	}
+
+	// Parse request body
+	var req model.ReportReasonRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	// Only change the fields that were sent
+	if req.Label != "" {
+		reason.Label = req.Label
+	}
+	if req.Active != nil {
+		reason.Active = *req.Active
+	}
+
+	if err := h.reportService.UpdateReason(reason); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Report reason not found", "Failed to update report reason")
+		return
+	}
+
+	log.Info().Str("code", reason.Code).Bool("active", reason.Active).Msg("Report reason updated")
+	writeJSONResponse(w, http.StatusOK, reason)
+}
+
+// Parses the filters shared by the report queue and the export (from and to are dates, to inclusive)
+func parseReportFilter(r *http.Request) (model.ReportFilter, error) {
+	query := r.URL.Query()
+
+	assignedTo, err := parseOptionalID(r, "assigned_to")
+	if err != nil {
+		return model.ReportFilter{}, err
+	}
+
+	filter := model.ReportFilter{
+		Status:     query.Get("status"),
+		ReasonCode: query.Get("reason"),
+		TargetType: query.Get("target_type"),
+		AssignedTo: assignedTo,
+		Unassigned: query.Get("unassigned") == "true",
+	}
+	if fromStr := query.Get("from"); fromStr != "" {
+		filter.From, err = time.Parse(model.MetricsDateLayout, fromStr)
+		if err != nil {
+			return model.ReportFilter{}, fmt.Errorf("from must be a date like 2024-01-01")
+		}
+	}
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := time.Parse(model.MetricsDateLayout, toStr)
+		if err != nil {
+			return model.ReportFilter{}, fmt.Errorf("to must be a date like 2024-01-31")
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+
+	return filter, nil
+}
+
+// Formats a report as a CSV row in reportCSVHeader order (automatic reports have no reporter_id)
+func reportCSVRecord(report model.Report) []string {
+	reporterId := ""
+	if report.ReporterId != 0 {
+		reporterId = strconv.FormatInt(report.ReporterId, 10)
+	}
+	assignedTo := ""
+	if report.AssignedTo != nil {
+		assignedTo = strconv.FormatInt(*report.AssignedTo, 10)
+	}
+
+	return []string{
+		strconv.FormatInt(report.ReportId, 10),
+		report.CreatedAt.UTC().Format(time.RFC3339),
+		report.UpdatedAt.UTC().Format(time.RFC3339),
+		report.Status,
+		report.ReasonCode,
+		report.TargetType,
+		strconv.FormatInt(report.TargetId, 10),
+		reporterId,
+		assignedTo,
+		csvSafe(report.Details),
+		csvSafe(report.ResolutionNote),
+	}
+}
+
+// Defuses free text that a spreadsheet would run as a formula (cells starting with = + - @ or a control character)
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// Parses the report ID from the URL, writing a 400 if it's invalid
+func parseReportID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	idStr := mux.Vars(r)["reportId"]
+
+	reportId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("report_id", idStr).Msg("Invalid report ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid report ID")
+		return 0, false
+	}
+
+	return reportId, true
+}
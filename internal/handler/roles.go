@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/admin/roles - Handler to list roles and the permissions they can grant
+func (h *Handler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/roles - Getting roles")
+
+	roles, err := h.roleService.List()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get roles")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get roles")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, map[string]interface{}{"roles": roles, "permissions": model.Permissions})
+}
+
+// PUT /api/admin/roles/{role} - Handler to create a role or replace its permissions
+func (h *Handler) SetRole(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/roles/{role} - Setting role")
+
+	var req model.RoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	role, err := h.roleService.Set(mux.Vars(r)["role"], req)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to set role")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to set role")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, role)
+}
+
+// DELETE /api/admin/roles/{role} - Handler to delete a role nobody holds
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/admin/roles/{role} - Deleting role")
+
+	name := mux.Vars(r)["role"]
+	if err := h.roleService.Delete(name); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		if errors.Is(err, repository.ErrConflict) {
+			writeErrorResponse(w, http.StatusConflict, "Role is still held by users")
+			return
+		}
+		writeMappedError(w, err, "Role not found", "Failed to delete role")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PUT /api/admin/users/{userId}/role - Handler to change a user's role
+func (h *Handler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/users/{userId}/role - Setting user role")
+
+	userId, err := model.ParseID(mux.Vars(r)["userId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req model.UserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, err := h.roleService.SetUserRole(middleware.GetUserID(r), userId, req.Role)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "User not found", "Failed to set user role")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, model.NewUserResponse(user))
+}
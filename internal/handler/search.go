@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Results per group in the search box typeahead
+const (
+	defaultSearchSuggestLimit = 5
+	maxSearchSuggestLimit     = 10
+)
+
+// GET /api/search/suggest?q=&limit= - Handler to suggest posts, tags and users matching what's been typed in the search box
+func (h *Handler) SuggestSearch(w http.ResponseWriter, r *http.Request) {
+	// The search box asks on every keystroke, so only log at debug level
+	log.Debug().Msg("GET /api/search/suggest - Suggesting search results")
+
+	limit := defaultSearchSuggestLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxSearchSuggestLimit {
+			writeErrorResponse(w, http.StatusBadRequest, "limit must be between 1 and 10")
+			return
+		}
+	}
+
+	suggestions, err := h.searchService.Suggest(r.Context(), r.URL.Query().Get("q"), middleware.GetUserID(r), limit)
+	if writeValidationError(w, err) {
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to suggest search results")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to suggest search results")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, suggestions)
+}
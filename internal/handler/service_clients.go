@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"byte-board/internal/service"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// Error body for the token endpoint, in the OAuth 2.0 shape client libraries expect (RFC 6749 section 5.2)
+type oauthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// Writes an OAuth error from the token endpoint
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="byteboard"`)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSONResponse(w, status, oauthError{Error: code, Description: description})
+}
+
+// POST /api/auth/token - Handler for the OAuth 2.0 client credentials grant. Takes a form body with
+// grant_type=client_credentials and an optional space-separated scope; credentials come from HTTP Basic
+// auth or the client_id/client_secret form fields. Returns a scoped machine token for admin endpoints.
+func (h *Handler) IssueClientToken(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/auth/token - Issuing service client token")
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "Request body must be form encoded")
+		return
+	}
+	if grantType := r.PostForm.Get("grant_type"); grantType != "client_credentials" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "Only the client_credentials grant is supported")
+		return
+	}
+
+	clientId, secret, ok := r.BasicAuth()
+	if !ok {
+		clientId, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientId == "" || secret == "" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "Client ID and secret are required")
+		return
+	}
+
+	token, ttl, scopes, err := h.serviceClientService.IssueToken(clientId, secret, r.PostForm.Get("scope"))
+	switch {
+	case errors.Is(err, service.ErrInvalidClient):
+		log.Warn().Str("client_id", clientId).Msg("Service client authentication failed")
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "Invalid client credentials")
+		return
+	case errors.Is(err, service.ErrInvalidScope):
+		log.Warn().Err(err).Str("client_id", clientId).Msg("Service client requested a scope it wasn't granted")
+		writeOAuthError(w, http.StatusBadRequest, "invalid_scope", strings.TrimPrefix(err.Error(), service.ErrInvalidScope.Error()+": "))
+		return
+	case err != nil:
+		log.Error().Err(err).Str("client_id", clientId).Msg("Failed to issue service client token")
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "Failed to issue token")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSONResponse(w, http.StatusOK, model.TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+}
+
+// GET /api/admin/service-clients - Handler to list registered service clients with admin permissions
+func (h *Handler) GetServiceClients(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/service-clients - Getting service clients")
+
+	_, limit, offset, err := parseListParams(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	clients, err := h.serviceClientService.List(limit, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get service clients")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get service clients")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, clients)
+}
+
+// POST /api/admin/service-clients - Handler to register a service client with admin permissions.
+// The client secret is only ever returned here.
+func (h *Handler) CreateServiceClient(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/service-clients - Registering service client")
+
+	// Parse request body
+	var req model.ServiceClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	adminId := middleware.GetUserID(r)
+	client, secret, err := h.serviceClientService.Create(req.Name, req.Scopes, adminId)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to register service client")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to register service client")
+		return
+	}
+
+	log.Info().Int64("admin_id", adminId).Str("client_id", client.ClientId).Msg("Service client registered by admin")
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSONResponse(w, http.StatusCreated, model.ServiceClientCreatedResponse{
+		ServiceClient: *client,
+		ClientSecret:  secret,
+	})
+}
+
+// DELETE /api/admin/service-clients/{clientId} - Handler to revoke a service client with admin permissions
+func (h *Handler) RevokeServiceClient(w http.ResponseWriter, r *http.Request) {
+	clientId := mux.Vars(r)["clientId"]
+	log.Info().Str("client_id", clientId).Msg("DELETE /api/admin/service-clients/{clientId} - Revoking service client")
+
+	if err := h.serviceClientService.Revoke(clientId); err != nil {
+		writeMappedError(w, err, "Service client not found", "Failed to revoke service client")
+		return
+	}
+
+	log.Info().Int64("admin_id", middleware.GetUserID(r)).Str("client_id", clientId).Msg("Service client revoked")
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Service client revoked"})
+}
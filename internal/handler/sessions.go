@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/auth/sessions - Handler to list the devices the current user is signed in on
+func (h *Handler) GetSessions(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/auth/sessions - Getting sessions")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessions, err := h.sessionService.List(user.ID, middleware.GetSessionID(r))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get sessions")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get sessions")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, sessions)
+}
+
+// DELETE /api/auth/sessions/{sessionId} - Handler to sign the current user out of one of their sessions
+// (their current one included)
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/auth/sessions/{sessionId} - Revoking session")
+
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	sessionId, err := model.ParseID(mux.Vars(r)["sessionId"])
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid session ID")
+		return
+	}
+
+	if err := h.sessionService.Revoke(user.ID, sessionId); err != nil {
+		writeMappedError(w, err, "Session not found", "Failed to revoke session")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/admin/skills/rules - Handler to get the skill synonyms and blocklist
+func (h *Handler) GetSkillRules(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/skills/rules - Getting skill rules")
+
+	rules, err := h.skillService.Rules()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get skill rules")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get skill rules")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, rules)
+}
+
+// PUT /api/admin/skills/rules/{name} - Handler to make a skill tag a synonym of another or block it
+func (h *Handler) SetSkillRule(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("PUT /api/admin/skills/rules/{name} - Setting skill rule")
+
+	var req model.SkillRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rule, status, err := h.skillService.SetRule(middleware.GetUserID(r), mux.Vars(r)["name"], req.Action, req.Skill)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeQueueError(w, err, "Failed to set skill rule")
+		return
+	}
+
+	log.Info().Str("name", rule.Name).Str("action", rule.Action).Int("job_id", status.ID).Msg("Skill rule set")
+	writeJSONResponse(w, http.StatusAccepted, map[string]interface{}{"rule": rule, "job": status})
+}
+
+// DELETE /api/admin/skills/rules/{name} - Handler to remove a skill rule
+func (h *Handler) DeleteSkillRule(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/admin/skills/rules/{name} - Deleting skill rule")
+
+	name := mux.Vars(r)["name"]
+	if err := h.skillService.DeleteRule(name); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Skill rule not found", "Failed to delete skill rule")
+		return
+	}
+
+	log.Info().Str("name", name).Msg("Skill rule deleted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/admin/skills/merge - Handler to merge skill tags into another
+func (h *Handler) MergeSkills(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/skills/merge - Merging skills")
+
+	var req model.SkillMergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rules, status, err := h.skillService.Merge(middleware.GetUserID(r), req.From, req.Into)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeQueueError(w, err, "Failed to merge skills")
+		return
+	}
+
+	log.Info().Int("count", len(rules)).Str("into", req.Into).Int("job_id", status.ID).Msg("Skills merged")
+	writeJSONResponse(w, http.StatusAccepted, map[string]interface{}{"rules": rules, "job": status})
+}
+
+// POST /api/admin/skills/migrate - Handler to queue another migration of existing profiles onto the skill rules
+func (h *Handler) StartSkillMigration(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/skills/migrate - Queueing skill migration")
+
+	status, err := h.skillService.Migrate()
+	if err != nil {
+		writeQueueError(w, err, "Failed to queue skill migration")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusAccepted, status)
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/posts/{postId}/snippets - Handler to get the snippets attached to a post
+func (h *Handler) GetSnippetsOnPost(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /posts/{postId}/snippets - Getting snippets on post")
+
+	vars := mux.Vars(r)
+	idStr := vars["postId"]
+
+	// Convert the ID string into an int
+	id, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("id", idStr).Msg("Invalid post ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid Post ID")
+		return
+	}
+
+	if _, ok := h.readablePost(w, r, id); !ok {
+		return
+	}
+
+	snippets, err := h.db.GetSnippetsByPost(id)
+	if err != nil {
+		writeMappedError(w, err, "Snippets not found", "Failed to get snippets on post")
+		return
+	}
+
+	log.Info().Int("count", len(snippets)).Msg("Successfully retrieved snippets on post")
+	writeJSONResponse(w, http.StatusOK, model.NewSnippetResponses(snippets))
+}
+
+// GET /api/snippets/{snippetId} - Handler to get a snippet by ID
+func (h *Handler) GetSnippetById(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /snippets/{snippetId} - Getting snippet by ID")
+
+	snippet, ok := h.snippetFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := h.readablePost(w, r, snippet.PostId); !ok {
+		return
+	}
+
+	log.Info().Int64("ID", snippet.SnippetId).Msg("Successfully retrieved snippet")
+	writeJSONResponse(w, http.StatusOK, model.NewSnippetResponse(snippet))
+}
+
+// GET /api/snippets/{snippetId}/raw - Handler to download a snippet as a plain file
+func (h *Handler) GetSnippetRaw(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /snippets/{snippetId}/raw - Downloading snippet")
+
+	snippet, ok := h.snippetFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if _, ok := h.readablePost(w, r, snippet.PostId); !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": snippet.Filename}))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(snippet.Body)); err != nil {
+		log.Error().Err(err).Msg("Error writing snippet body")
+	}
+}
+
+// POST /api/posts/{postId}/snippets - Attach a code snippet to your post
+func (h *Handler) CreateSnippet(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/posts/{postId}/snippets - Attaching snippet")
+
+	// Get authenticated user from context
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
+		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	// Get post ID from URL params
+	vars := mux.Vars(r)
+	idStr := vars["postId"]
+	postId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("post_id", idStr).Msg("Invalid post ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	// Verify the user owns the post
+	post, err := h.db.GetPostById(postId)
+	if err != nil {
+		writeMappedError(w, err, "Post not found", "Failed to get post")
+		return
+	}
+	if post.UserId != userId {
+		log.Warn().Int64("userId", userId).Int64("postId", postId).Msg("User does not own this post")
+		writeErrorResponse(w, http.StatusForbidden, "You can only attach snippets to your own posts")
+		return
+	}
+
+	// Parse request body
+	var req model.SnippetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	snippet := &model.Snippet{
+		PostId:   postId,
+		UserId:   userId,
+		Filename: req.Filename,
+		Language: req.Language,
+		Body:     req.Body,
+	}
+
+	// Call snippet service to validate and save the snippet
+	if err := h.snippetService.CreateSnippet(snippet); err != nil {
+		writeMappedError(w, err, "Filename and body are required (body up to 100KB)", "Failed to create snippet")
+		return
+	}
+
+	log.Info().Int64("snippet_id", snippet.SnippetId).Str("language", snippet.Language).Msg("Snippet attached successfully")
+	writeJSONResponse(w, http.StatusCreated, model.NewSnippetResponse(snippet))
+}
+
+// DELETE /api/snippets/{snippetId} - Delete a snippet from your post
+func (h *Handler) DeleteSnippet(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/snippets/{snippetId} - Deleting snippet")
+
+	// Get authenticated user from context
+	userId := middleware.GetUserID(r)
+	if userId == 0 {
+		log.Warn().Msg("No user ID in context")
+		writeErrorResponse(w, http.StatusUnauthorized, "Not authenticated")
+		return
+	}
+
+	snippet, ok := h.snippetFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Verify the user owns the snippet or can delete anyone's posts (snippets belong to posts)
+	if snippet.UserId != userId && !middleware.HasPermission(r, model.PermissionPostsDelete) {
+		log.Warn().Int64("snippet_id", snippet.SnippetId).Int64("user_id", userId).Msg("User does not own this snippet")
+		writeErrorResponse(w, http.StatusForbidden, "You can only delete your own snippets")
+		return
+	}
+
+	if err := h.db.DeleteSnippet(snippet.SnippetId); err != nil {
+		writeMappedError(w, err, "Snippet not found", "Failed to delete snippet")
+		return
+	}
+
+	log.Info().Int64("snippet_id", snippet.SnippetId).Msg("Snippet deleted successfully")
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Snippet deleted successfully"})
+}
+
+// POST /api/posts/import/gist - Create a post from a GitHub gist (files become snippets)
+func (h *Handler) ImportGist(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/posts/import/gist - Importing gist")
+
+	// Get authenticated user
+	user, ok := h.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	// Parse request body
+	var req model.GistImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	post, snippets, err := h.gistService.ImportGist(r.Context(), user, req.URL, req.Title)
+	if err != nil {
+		if writeValidationError(w, err) || writeContentQuotaError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "You already posted this recently", "Failed to import gist")
+		return
+	}
+
+	response := map[string]interface{}{
+		"post":     h.postResponse(post, viewerOf(r)),
+		"snippets": model.NewSnippetResponses(snippets),
+	}
+
+	log.Info().Int64("post_id", post.PostId).Msg("Gist imported successfully")
+	h.events.Record(model.EventPostCreated, user.ID, model.EventSubjectPost, post.PostId, map[string]interface{}{
+		"title":      post.Title,
+		"visibility": post.Visibility,
+		"gist_url":   req.URL,
+	})
+	writeJSONResponse(w, http.StatusCreated, response)
+}
+
+// Loads the snippet named by the {snippetId} URL param, writing an error response if it can't
+func (h *Handler) snippetFromRequest(w http.ResponseWriter, r *http.Request) (*model.Snippet, bool) {
+	vars := mux.Vars(r)
+	idStr := vars["snippetId"]
+
+	id, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("snippet_id", idStr).Msg("Invalid snippet ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid snippet ID")
+		return nil, false
+	}
+
+	snippet, err := h.db.GetSnippetById(id)
+	if err != nil {
+		writeMappedError(w, err, "Snippet not found", "Failed to get snippet")
+		return nil, false
+	}
+
+	return snippet, true
+}
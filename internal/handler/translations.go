@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"byte-board/internal/model"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/posts/{postId}/translation?lang= - Handler to get a post machine-translated into another language
+func (h *Handler) GetPostTranslation(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/posts/{postId}/translation - Translating post")
+
+	idStr := mux.Vars(r)["postId"]
+	postId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("post_id", idStr).Msg("Invalid post ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "lang is required")
+		return
+	}
+
+	post, ok := h.readablePost(w, r, postId)
+	if !ok {
+		return
+	}
+
+	translation, translated, err := h.translationService.TranslatePost(r.Context(), post, lang)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Translation is not available right now", "Failed to translate post")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, model.PostTranslationResponse{
+		PostId:         post.PostId,
+		SourceLanguage: post.Language,
+		Language:       translation.Language,
+		Title:          translation.Title,
+		Content:        translation.Content,
+		Translated:     translated,
+	})
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Largest user import upload (comfortably above a full batch)
+const maxUserImportBytes = 4 << 20
+
+// POST /api/admin/users/import?dry_run=true - Handler to import a batch of users migrated from another community.
+// Takes JSON ({"users": [...]}) or CSV with a header row naming the columns; reports the outcome of every row.
+func (h *Handler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/users/import - Importing users")
+
+	body := http.MaxBytesReader(w, r.Body, maxUserImportBytes)
+	var rows []model.UserImportRow
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		rows, err = parseUserImportCSV(body)
+	} else {
+		var req model.UserImportRequest
+		err = json.NewDecoder(body).Decode(&req)
+		rows = req.Users
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("Invalid user import")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid import: "+err.Error())
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	summary, err := h.authService.ImportUsers(rows, dryRun)
+	if err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to import users")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to import users")
+		return
+	}
+
+	if !dryRun {
+		adminId := middleware.GetUserID(r)
+		for _, result := range summary.Results {
+			if result.Status == model.ImportCreated || result.Status == model.ImportInvited {
+				h.events.Record(model.EventUserRegistered, adminId, model.EventSubjectUser, result.UserId, map[string]interface{}{
+					"username": result.Username,
+					"imported": true,
+				})
+			}
+		}
+	}
+
+	writeJSONResponse(w, http.StatusOK, summary)
+}
+
+// Reads CSV import rows. The header row names the columns (username, email, first_name, last_name and
+// password_hash, in any order; only username is required) and unknown columns are rejected.
+func parseUserImportCSV(body io.Reader) ([]model.UserImportRow, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the CSV is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]func(row *model.UserImportRow) *string{
+		"username":      func(row *model.UserImportRow) *string { return &row.Username },
+		"email":         func(row *model.UserImportRow) *string { return &row.Email },
+		"first_name":    func(row *model.UserImportRow) *string { return &row.FirstName },
+		"last_name":     func(row *model.UserImportRow) *string { return &row.LastName },
+		"password_hash": func(row *model.UserImportRow) *string { return &row.PasswordHash },
+	}
+	columns := make([]func(row *model.UserImportRow) *string, len(header))
+	hasUsername := false
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		field, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns[i] = field
+		hasUsername = hasUsername || name == "username"
+	}
+	if !hasUsername {
+		return nil, errors.New("the CSV needs a username column")
+	}
+
+	var rows []model.UserImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var row model.UserImportRow
+		for i, value := range record {
+			*columns[i](&row) = value
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
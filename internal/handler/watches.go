@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"byte-board/internal/middleware"
+	"byte-board/internal/model"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/posts/{postId}/watch - Handler to check whether you're notified about new comments on a post
+func (h *Handler) GetPostWatch(w http.ResponseWriter, r *http.Request) {
+	post, ok := h.watchTarget(w, r)
+	if !ok {
+		return
+	}
+
+	h.writePostWatch(w, middleware.GetUserID(r), post)
+}
+
+// POST /api/posts/{postId}/watch - Handler to watch a post for new comments (clears a mute)
+func (h *Handler) WatchPost(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/posts/{postId}/watch - Watching post")
+
+	post, ok := h.watchTarget(w, r)
+	if !ok {
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	if err := h.db.WatchPost(userId, post.PostId); err != nil {
+		log.Error().Err(err).Msg("Failed to watch post")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to watch post")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Int64("post_id", post.PostId).Msg("Post watched")
+	h.writePostWatch(w, userId, post)
+}
+
+// DELETE /api/posts/{postId}/watch - Handler to stop watching a post. Authors watch their posts by default,
+// so for them this mutes the post instead.
+func (h *Handler) UnwatchPost(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/posts/{postId}/watch - Unwatching post")
+
+	post, ok := h.watchTarget(w, r)
+	if !ok {
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	var err error
+	if post.UserId == userId {
+		err = h.db.MutePost(userId, post.PostId)
+	} else {
+		err = h.db.ClearPostWatch(userId, post.PostId, false)
+	}
+	if err != nil {
+		writeMappedError(w, err, "You aren't watching that post", "Failed to unwatch post")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Int64("post_id", post.PostId).Msg("Post unwatched")
+	h.writePostWatch(w, userId, post)
+}
+
+// POST /api/posts/{postId}/mute - Handler to mute a post (no comment notifications, even after commenting on it)
+func (h *Handler) MutePost(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/posts/{postId}/mute - Muting post")
+
+	post, ok := h.watchTarget(w, r)
+	if !ok {
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	if err := h.db.MutePost(userId, post.PostId); err != nil {
+		log.Error().Err(err).Msg("Failed to mute post")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to mute post")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Int64("post_id", post.PostId).Msg("Post muted")
+	h.writePostWatch(w, userId, post)
+}
+
+// DELETE /api/posts/{postId}/mute - Handler to unmute a post (authors go back to watching it)
+func (h *Handler) UnmutePost(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/posts/{postId}/mute - Unmuting post")
+
+	post, ok := h.watchTarget(w, r)
+	if !ok {
+		return
+	}
+
+	userId := middleware.GetUserID(r)
+	if err := h.db.ClearPostWatch(userId, post.PostId, true); err != nil {
+		writeMappedError(w, err, "You haven't muted that post", "Failed to unmute post")
+		return
+	}
+
+	log.Info().Int64("user_id", userId).Int64("post_id", post.PostId).Msg("Post unmuted")
+	h.writePostWatch(w, userId, post)
+}
+
+// Loads the post to watch or mute from the URL, writing an error if the ID is invalid or the post isn't readable
+func (h *Handler) watchTarget(w http.ResponseWriter, r *http.Request) (*model.Post, bool) {
+	idStr := mux.Vars(r)["postId"]
+	postId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("post_id", idStr).Msg("Invalid post ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid post ID")
+		return nil, false
+	}
+
+	return h.readablePost(w, r, postId)
+}
+
+// Writes the user's current watch state for a post
+func (h *Handler) writePostWatch(w http.ResponseWriter, userId int64, post *model.Post) {
+	watch, err := h.db.GetPostWatch(userId, post)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get post watch")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get watch status")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, watch)
+}
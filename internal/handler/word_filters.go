@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"byte-board/internal/model"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+)
+
+// GET /api/admin/word-filters - Handler to get all word filter rules
+func (h *Handler) GetWordFilters(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("GET /api/admin/word-filters - Getting word filters")
+
+	filters, err := h.wordFilter.GetFilters()
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to get word filters")
+		writeErrorResponse(w, http.StatusInternalServerError, "Failed to get word filters")
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, filters)
+}
+
+// POST /api/admin/word-filters - Handler to add a word filter rule
+func (h *Handler) CreateWordFilter(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("POST /api/admin/word-filters - Creating word filter")
+
+	// Parse request body
+	var req model.WordFilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Warn().Err(err).Msg("Invalid request body")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	filter := &model.WordFilter{
+		Pattern:     req.Pattern,
+		IsRegex:     req.IsRegex,
+		Action:      req.Action,
+		Replacement: req.Replacement,
+	}
+
+	if err := h.wordFilter.CreateFilter(filter); err != nil {
+		if writeValidationError(w, err) {
+			return
+		}
+		writeMappedError(w, err, "Failed to create word filter", "Failed to create word filter")
+		return
+	}
+
+	log.Info().Int64("filter_id", filter.FilterId).Str("action", filter.Action).Msg("Word filter created")
+	writeJSONResponse(w, http.StatusCreated, filter)
+}
+
+// DELETE /api/admin/word-filters/{filterId} - Handler to remove a word filter rule
+func (h *Handler) DeleteWordFilter(w http.ResponseWriter, r *http.Request) {
+	log.Info().Msg("DELETE /api/admin/word-filters/{filterId} - Deleting word filter")
+
+	idStr := mux.Vars(r)["filterId"]
+	filterId, err := model.ParseID(idStr)
+	if err != nil {
+		log.Warn().Str("filter_id", idStr).Msg("Invalid word filter ID format")
+		writeErrorResponse(w, http.StatusBadRequest, "Invalid word filter ID")
+		return
+	}
+
+	if err := h.wordFilter.DeleteFilter(filterId); err != nil {
+		writeMappedError(w, err, "Word filter not found", "Failed to delete word filter")
+		return
+	}
+
+	log.Info().Int64("filter_id", filterId).Msg("Word filter deleted")
+	writeJSONResponse(w, http.StatusOK, map[string]string{"message": "Word filter deleted successfully"})
+}
@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Lifecycle states of a queued job
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// How many finished jobs are remembered for status lookups
+const maxFinishedJobs = 100
+
+var (
+	// A job ID is unknown (or has been forgotten)
+	ErrJobNotFound = errors.New("job not found")
+	// Too many jobs are already waiting
+	ErrQueueFull = errors.New("job queue is full")
+)
+
+// Reports progress from inside a running job (done out of total steps, with an optional note)
+type ProgressFunc func(done, total int, message string)
+
+// Snapshot of a one-off job's state
+type Status struct {
+	ID         int        `json:"job_id"`
+	Kind       string     `json:"kind"`
+	State      string     `json:"status"`
+	Done       int        `json:"done"`
+	Total      int        `json:"total"`
+	Message    string     `json:"message,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	QueuedAt   time.Time  `json:"queued_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// A job waiting for the worker
+type queuedJob struct {
+	id  int
+	run func(ctx context.Context, progress ProgressFunc) error
+}
+
+// Runs one-off jobs in the background, one at a time, in the order they were enqueued
+type Queue struct {
+	pending chan queuedJob
+
+	mu       sync.Mutex
+	nextId   int
+	statuses map[int]*Status
+	finished []int
+}
+
+// Creates a new queue that holds up to size pending jobs
+func NewQueue(size int) *Queue {
+	return &Queue{
+		pending:  make(chan queuedJob, size),
+		nextId:   1,
+		statuses: make(map[int]*Status),
+	}
+}
+
+// Starts the worker; it stops when ctx is cancelled
+func (q *Queue) Start(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case job := <-q.pending:
+				q.execute(ctx, job)
+			}
+		}
+	}()
+}
+
+// Queues a job of the given kind. If a job of the same kind is already queued or running, that job's
+// status is returned instead of queueing a duplicate.
+func (q *Queue) Enqueue(kind string, run func(ctx context.Context, progress ProgressFunc) error) (Status, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, status := range q.statuses {
+		if status.Kind == kind && (status.State == StatusQueued || status.State == StatusRunning) {
+			return *status, nil
+		}
+	}
+
+	status := &Status{ID: q.nextId, Kind: kind, State: StatusQueued, QueuedAt: time.Now()}
+	select {
+	case q.pending <- queuedJob{id: status.ID, run: run}:
+	default:
+		return Status{}, ErrQueueFull
+	}
+
+	q.nextId++
+	q.statuses[status.ID] = status
+	log.Info().Int("job_id", status.ID).Str("kind", kind).Msg("Job queued")
+
+	return *status, nil
+}
+
+// Gets the current status of a job
+func (q *Queue) Get(id int) (Status, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status, ok := q.statuses[id]
+	if !ok {
+		return Status{}, ErrJobNotFound
+	}
+	return *status, nil
+}
+
+// Runs a job and records how it went
+func (q *Queue) execute(ctx context.Context, job queuedJob) {
+	q.update(job.id, func(s *Status) {
+		now := time.Now()
+		s.State = StatusRunning
+		s.StartedAt = &now
+	})
+
+	progress := func(done, total int, message string) {
+		q.update(job.id, func(s *Status) {
+			s.Done, s.Total, s.Message = done, total, message
+		})
+	}
+
+	start := time.Now()
+	err := runQueued(ctx, job, progress)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := q.statuses[job.id]
+	now := time.Now()
+	status.FinishedAt = &now
+	if err != nil {
+		status.State = StatusFailed
+		status.Error = err.Error()
+		log.Error().Err(err).Int("job_id", job.id).Str("kind", status.Kind).Msg("Job failed")
+	} else {
+		status.State = StatusSucceeded
+		log.Info().Int("job_id", job.id).Str("kind", status.Kind).Dur("duration", time.Since(start)).Msg("Job finished")
+	}
+
+	// Forget the oldest finished jobs so the status map doesn't grow forever
+	q.finished = append(q.finished, job.id)
+	if len(q.finished) > maxFinishedJobs {
+		delete(q.statuses, q.finished[0])
+		q.finished = q.finished[1:]
+	}
+}
+
+// Applies a change to a job's status under the lock
+func (q *Queue) update(id int, change func(s *Status)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if status, ok := q.statuses[id]; ok {
+		change(status)
+	}
+}
+
+// Runs a queued job, turning a panic into an error so the worker keeps going
+func runQueued(ctx context.Context, job queuedJob, progress ProgressFunc) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	return job.run(ctx, progress)
+}
@@ -0,0 +1,75 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Background work run on a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Runs jobs in the background, each on its own ticker
+type Scheduler struct {
+	jobs []Job
+}
+
+// Creates new scheduler
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Registers a job (jobs with a non-positive interval are skipped so they can be disabled from config)
+func (s *Scheduler) Add(name string, interval time.Duration, run func(ctx context.Context) error) {
+	if interval <= 0 {
+		log.Info().Str("job", name).Msg("Job disabled")
+		return
+	}
+
+	s.jobs = append(s.jobs, Job{Name: name, Interval: interval, Run: run})
+}
+
+// Starts every registered job; they stop when ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+		log.Info().Str("job", job.Name).Dur("interval", job.Interval).Msg("Job scheduled")
+	}
+}
+
+// Runs a job on every tick until ctx is cancelled
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			if err := runJob(ctx, job); err != nil {
+				log.Error().Err(err).Str("job", job.Name).Msg("Job failed")
+				continue
+			}
+			log.Debug().Str("job", job.Name).Dur("duration", time.Since(start)).Msg("Job finished")
+		}
+	}
+}
+
+// Runs a job once, turning a panic into an error so one bad run doesn't stop the schedule
+func runJob(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	return job.Run(ctx)
+}
@@ -0,0 +1,176 @@
+package language
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// Tag for text whose language isn't known (BCP 47 "undetermined")
+const Undetermined = "und"
+
+// The tag isn't a well-formed BCP 47 language tag
+var ErrInvalidTag = errors.New("language must be a BCP 47 tag such as en or pt-BR")
+
+// Checks a BCP 47 language tag and puts it in canonical case (en, pt-BR, zh-Hant).
+// Underscores are accepted as separators (en_US).
+func Normalize(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" || len(tag) > 35 {
+		return "", ErrInvalidTag
+	}
+
+	subtags := strings.Split(strings.ReplaceAll(tag, "_", "-"), "-")
+	for i, subtag := range subtags {
+		if !isAlphanumeric(subtag) {
+			return "", ErrInvalidTag
+		}
+
+		switch {
+		case i == 0:
+			// Primary language: two or three letters
+			if len(subtag) < 2 || len(subtag) > 3 || !isAlpha(subtag) {
+				return "", ErrInvalidTag
+			}
+			subtags[i] = strings.ToLower(subtag)
+		case len(subtag) == 2 && isAlpha(subtag):
+			// Region
+			subtags[i] = strings.ToUpper(subtag)
+		case len(subtag) == 4 && isAlpha(subtag):
+			// Script
+			subtags[i] = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		case len(subtag) >= 1 && len(subtag) <= 8:
+			subtags[i] = strings.ToLower(subtag)
+		default:
+			return "", ErrInvalidTag
+		}
+	}
+
+	return strings.Join(subtags, "-"), nil
+}
+
+// The primary language of a tag (pt for pt-BR)
+func Base(tag string) string {
+	base, _, _ := strings.Cut(tag, "-")
+	return base
+}
+
+// Scripts that (for this purpose) identify one language
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// Common short words of the Latin-script languages Detect can tell apart
+var stopWords = map[string][]string{
+	"en": {"the", "and", "is", "of", "to", "in", "that", "it", "with", "for", "this", "you", "are", "was", "have"},
+	"es": {"el", "la", "de", "que", "y", "en", "los", "las", "es", "por", "con", "una", "para", "del", "pero"},
+	"fr": {"le", "la", "les", "de", "et", "est", "un", "une", "des", "que", "pour", "dans", "pas", "avec", "sur"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "mit", "zu", "den", "ich", "auf", "auch", "sich"},
+	"pt": {"o", "a", "de", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "os", "mais"},
+	"it": {"il", "la", "di", "che", "e", "un", "una", "per", "non", "sono", "con", "del", "della", "gli", "anche"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "met", "voor", "ook", "maar"},
+}
+
+// Fewest stop word hits needed before a Latin-script guess is trusted
+const minStopWordHits = 3
+
+// Guesses the language of a piece of text, returning Undetermined when it can't tell.
+// Non-Latin scripts are mapped to their most common language; Latin-script text is
+// scored against short lists of common words. This is a cheap heuristic meant for
+// filtering and picking a translation source, not a full language identifier.
+func Detect(text string) string {
+	if language := detectScript(text); language != "" {
+		return language
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	best, bestHits, runnerUpHits := Undetermined, 0, 0
+	for language, list := range stopWords {
+		hits := 0
+		for _, word := range words {
+			for _, stopWord := range list {
+				if word == stopWord {
+					hits++
+					break
+				}
+			}
+		}
+
+		switch {
+		case hits > bestHits:
+			best, bestHits, runnerUpHits = language, hits, bestHits
+		case hits > runnerUpHits:
+			runnerUpHits = hits
+		}
+	}
+
+	if bestHits < minStopWordHits || bestHits == runnerUpHits {
+		return Undetermined
+	}
+	return best
+}
+
+// Finds the language of the dominant non-Latin script, if letters in one outnumber Latin letters
+func detectScript(text string) string {
+	counts := make(map[string]int)
+	latin := 0
+	for _, r := range text {
+		if unicode.Is(unicode.Latin, r) {
+			latin++
+			continue
+		}
+		for _, entry := range scriptLanguages {
+			if unicode.Is(entry.script, r) {
+				counts[entry.language]++
+				break
+			}
+		}
+	}
+
+	// Japanese text mixes kana with Han characters
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+
+	best, bestCount := "", latin
+	for language, count := range counts {
+		if count > bestCount {
+			best, bestCount = language, count
+		}
+	}
+	return best
+}
+
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,145 @@
+package mail
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Sends plain text emails
+type Mailer interface {
+	Send(to, subject, body string) error
+	// Send with extra headers (e.g. List-Unsubscribe)
+	SendWithHeaders(to, subject, body string, headers map[string]string) error
+}
+
+// SMTP configuration
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Sends emails through an SMTP server
+type SMTPMailer struct {
+	config SMTPConfig
+}
+
+// Creates a new SMTP mailer
+func NewSMTPMailer(config SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{
+		config: config,
+	}
+}
+
+// Send an email through the configured SMTP server
+func (m *SMTPMailer) Send(to, subject, body string) error {
+	return m.SendWithHeaders(to, subject, body, nil)
+}
+
+// Send an email with extra headers through the configured SMTP server
+func (m *SMTPMailer) SendWithHeaders(to, subject, body string, headers map[string]string) error {
+	addr := m.config.Host + ":" + m.config.Port
+
+	// Only authenticate when credentials are configured
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	lines := []string{
+		"From: " + m.config.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"utf-8\"",
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, name+": "+headers[name])
+	}
+	message := strings.Join(append(lines, "", body), "\r\n")
+
+	if err := smtp.SendMail(addr, auth, m.config.From, []string{to}, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", to, err)
+	}
+
+	log.Info().Str("to", to).Str("subject", subject).Msg("Email sent")
+	return nil
+}
+
+// Connects to the SMTP server and signs in (when credentials are configured) without sending anything,
+// so bad hosts or credentials show up at startup rather than on the first email
+func (m *SMTPMailer) Verify(timeout time.Duration) error {
+	addr := net.JoinHostPort(m.config.Host, m.config.Port)
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session with %s: %w", addr, err)
+	}
+	defer client.Close()
+
+	// Same order as smtp.SendMail: upgrade to TLS when offered, then authenticate
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return fmt.Errorf("failed to start TLS with %s: %w", addr, err)
+		}
+	}
+	if m.config.Username != "" {
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP server %s rejected the credentials for %s: %w", addr, m.config.Username, err)
+		}
+	}
+
+	return client.Quit()
+}
+
+// Logs emails instead of sending them (used when SMTP is not configured)
+type LogMailer struct{}
+
+// Creates a new log mailer
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// Write the email to the log
+func (m *LogMailer) Send(to, subject, body string) error {
+	log.Info().
+		Str("to", to).
+		Str("subject", subject).
+		Str("body", body).
+		Msg("SMTP not configured - email logged instead of sent")
+
+	return nil
+}
+
+// Write the email and its extra headers to the log
+func (m *LogMailer) SendWithHeaders(to, subject, body string, headers map[string]string) error {
+	log.Info().
+		Str("to", to).
+		Str("subject", subject).
+		Interface("headers", headers).
+		Str("body", body).
+		Msg("SMTP not configured - email logged instead of sent")
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	// GitHub-flavored Markdown (tables, strikethrough, autolinks, task lists).
+	// Raw HTML in the source is dropped rather than passed through.
+	renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// Allow-list for user-generated content (no scripts, styles, iframes or event handlers)
+	policy = newPolicy()
+)
+
+// Builds the sanitizer policy applied to every rendered document
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	// Keep fenced code languages (class="language-go") for client-side highlighting
+	p.AllowAttrs("class").Matching(bluemonday.SpaceSeparatedTokens).OnElements("code")
+	// Task list checkboxes
+	p.AllowAttrs("type").Matching(bluemonday.SpaceSeparatedTokens).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
+
+// Renders Markdown to sanitized HTML that is safe to embed in a page
+func Render(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+
+	return policy.Sanitize(buf.String()), nil
+}
@@ -0,0 +1,50 @@
+package markdown
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Strips Markdown down to what a reader sees: the prose (formatting, links and raw HTML removed, one
+// block per line) and, separately, the contents of code blocks
+func PlainText(source string) (prose, code string) {
+	src := []byte(source)
+	doc := renderer.Parser().Parse(text.NewReader(src))
+
+	var proseBuf, codeBuf strings.Builder
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			if n.Type() == ast.TypeBlock {
+				proseBuf.WriteByte('\n')
+			}
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			lines := node.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				segment := lines.At(i)
+				codeBuf.Write(segment.Value(src))
+			}
+			return ast.WalkSkipChildren, nil
+		case *ast.HTMLBlock, *ast.RawHTML:
+			// Dropped when rendering, so not part of the text either
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			proseBuf.Write(node.Value(src))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				proseBuf.WriteByte(' ')
+			}
+		case *ast.String:
+			proseBuf.Write(node.Value)
+		case *ast.AutoLink:
+			proseBuf.Write(node.Label(src))
+		}
+		return ast.WalkContinue, nil
+	})
+
+	return proseBuf.String(), codeBuf.String()
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Content type of the OpenMetrics text exposition format
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Counters exposed by Handler, in registration order
+var (
+	registryMu sync.Mutex
+	registry   []*Counter
+)
+
+// A monotonically increasing count, optionally split by label values. Counts live in memory,
+// so they restart at zero with the process (scrapers handle the reset).
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.RWMutex
+	values map[string]*atomic.Uint64
+}
+
+// Creates a counter and registers it for exposition. name is the metric family name without the
+// _total suffix; labels name the dimensions passed to Inc (keep their values to a small fixed set).
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		name:   name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*atomic.Uint64),
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, existing := range registry {
+		if existing.name == name {
+			panic(fmt.Sprintf("metrics: counter %s registered twice", name))
+		}
+	}
+	registry = append(registry, c)
+
+	return c
+}
+
+// Adds one to the count for the label values (given in the order the labels were declared)
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Adds n to the count for the label values
+func (c *Counter) Add(n uint64, labelValues ...string) {
+	if len(labelValues) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: counter %s takes %d label values, got %d", c.name, len(c.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\x00")
+
+	c.mu.RLock()
+	value, ok := c.values[key]
+	c.mu.RUnlock()
+	if !ok {
+		c.mu.Lock()
+		if value, ok = c.values[key]; !ok {
+			value = new(atomic.Uint64)
+			c.values[key] = value
+		}
+		c.mu.Unlock()
+	}
+
+	value.Add(n)
+}
+
+// Writes the counter family in OpenMetrics text format
+func (c *Counter) write(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# TYPE %s counter\n# HELP %s %s\n", c.name, c.name, escaper.Replace(c.help)); err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	counts := make(map[string]uint64, len(c.values))
+	for key, value := range c.values {
+		counts[key] = value.Load()
+	}
+	c.mu.RUnlock()
+
+	// Unlabeled counters always report a sample, even before the first increment
+	if len(c.labels) == 0 && len(counts) == 0 {
+		_, err := fmt.Fprintf(w, "%s_total 0\n", c.name)
+		return err
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s_total%s %d\n", c.name, c.labelSet(key), counts[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Formats the label values stored under key as {name="value",...}
+func (c *Counter) labelSet(key string) string {
+	if len(c.labels) == 0 {
+		return ""
+	}
+
+	values := strings.Split(key, "\x00")
+	pairs := make([]string, len(c.labels))
+	for i, label := range c.labels {
+		pairs[i] = fmt.Sprintf("%s=\"%s\"", label, escaper.Replace(values[i]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Writes every registered counter in OpenMetrics text format, ending with the # EOF marker
+func Write(w io.Writer) error {
+	registryMu.Lock()
+	counters := append([]*Counter(nil), registry...)
+	registryMu.Unlock()
+
+	for _, c := range counters {
+		if err := c.write(w); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "# EOF\n")
+	return err
+}
+
+// Serves every registered counter for scraping
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		Write(w)
+	})
+}
+
+// OpenMetrics escapes backslashes, newlines and double quotes in help text and label values
+var escaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Records that a user was active on a given day
+type ActivityRecorder interface {
+	RecordUserActivity(userId int64, day time.Time) error
+}
+
+// Records signed-in users' activity for the active user metrics.
+// Each user is written at most once per day per instance.
+type ActivityTracker struct {
+	recorder ActivityRecorder
+
+	mu   sync.Mutex
+	day  time.Time
+	seen map[int64]bool
+}
+
+// Creates a new activity tracker
+func NewActivityTracker(recorder ActivityRecorder) *ActivityTracker {
+	return &ActivityTracker{
+		recorder: recorder,
+		seen:     make(map[int64]bool),
+	}
+}
+
+// Middleware that records the signed-in user's activity (run after OptionalJWTAuth or JWTAuth)
+func (t *ActivityTracker) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userId := GetUserID(r); userId != 0 && t.firstToday(userId) {
+			if err := t.recorder.RecordUserActivity(userId, t.today()); err != nil {
+				// Metrics only; never fail the request over it
+				log.Error().Err(err).Int64("user_id", userId).Msg("Failed to record user activity")
+				t.forget(userId)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Reports whether this is the user's first request today (and remembers it)
+func (t *ActivityTracker) firstToday(userId int64) bool {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !today.Equal(t.day) {
+		t.day = today
+		t.seen = make(map[int64]bool)
+	}
+	if t.seen[userId] {
+		return false
+	}
+	t.seen[userId] = true
+	return true
+}
+
+// The UTC day currently being tracked
+func (t *ActivityTracker) today() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.day
+}
+
+// Lets a failed write be retried on the user's next request
+func (t *ActivityTracker) forget(userId int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.seen, userId)
+}
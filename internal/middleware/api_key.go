@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Header carrying a user's API key, accepted instead of a Bearer JWT
+const APIKeyHeader = "X-API-Key"
+
+// Maps API keys to the users they belong to (model.ErrInvalidToken for unknown or revoked keys)
+type APIKeyChecker interface {
+	Authenticate(key string) (int64, error)
+}
+
+// Gets the user an API key belongs to. Returns model.ErrInvalidToken when the key is unknown or revoked,
+// or its owner no longer exists.
+func (am *AuthMiddleware) apiKeyUser(r *http.Request, key string) (*model.User, error) {
+	userId, err := am.APIKeys.Authenticate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := CachedUser(r, am.Users, userId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, model.ErrInvalidToken
+	}
+	return user, err
+}
+
+// Stores the API key owner's user ID, username and current role in the context
+func withUser(ctx context.Context, user *model.User) context.Context {
+	ctx = context.WithValue(ctx, UserIDContextKey, user.ID)
+	ctx = context.WithValue(ctx, UsernameContextKey, user.Username)
+	return context.WithValue(ctx, RoleContextKey, user.Role)
+}
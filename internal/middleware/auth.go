@@ -3,9 +3,12 @@ package middleware
 import (
 	"byte-board/internal/auth"
 	"byte-board/internal/model"
+	"byte-board/internal/repository"
 	"context"
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -14,6 +17,7 @@ import (
 type contextKey string
 
 const (
+	UserIDContextKey   contextKey = "user_id"
 	UsernameContextKey contextKey = "username"
 	RoleContextKey     contextKey = "role"
 )
@@ -21,21 +25,56 @@ const (
 // Holds the JWT token provider for authentication
 type AuthMiddleware struct {
 	TokenProvider *auth.TokenProvider
+	Users         UserLoader
+	Clients       ClientChecker
+	APIKeys       APIKeyChecker
+	Sessions      SessionChecker
+	Roles         PermissionLoader
 }
 
 // Creates a new authentication middleware
-func NewAuthMiddleware(tokenProvider *auth.TokenProvider) *AuthMiddleware {
+func NewAuthMiddleware(tokenProvider *auth.TokenProvider, users UserLoader, clients ClientChecker, apiKeys APIKeyChecker, sessions SessionChecker, roles PermissionLoader) *AuthMiddleware {
 	return &AuthMiddleware{
 		TokenProvider: tokenProvider,
+		Users:         users,
+		Clients:       clients,
+		APIKeys:       apiKeys,
+		Sessions:      sessions,
+		Roles:         roles,
 	}
 }
 
-// Middleware that validates JWT tokens and adds user info to context
+// Middleware that validates JWT tokens (or an X-API-Key header when no Authorization header is sent)
+// and adds user info to context
 func (am *AuthMiddleware) JWTAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Extract Authorization header
 		authHeader := r.Header.Get("Authorization")
 
+		// Scripts and services may authenticate with an API key instead
+		if key := r.Header.Get(APIKeyHeader); authHeader == "" && key != "" {
+			user, err := am.apiKeyUser(r, key)
+			if errors.Is(err, model.ErrInvalidToken) {
+				log.Warn().Str("path", r.URL.Path).Msg("Invalid or revoked API key")
+				http.Error(w, "Unauthorized: Invalid or revoked API key", http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to check API key")
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			log.Debug().
+				Int64("user_id", user.ID).
+				Str("username", user.Username).
+				Str("role", user.Role).
+				Str("path", r.URL.Path).
+				Msg("User authenticated with API key")
+			next.ServeHTTP(w, r.WithContext(am.withPermissions(withUser(r.Context(), user), user)))
+			return
+		}
+
 		// Check if authorization header exists
 		if authHeader == "" {
 			log.Warn().Msg("Missing authorization header")
@@ -66,11 +105,34 @@ func (am *AuthMiddleware) JWTAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		// Add username and role to request context
-		ctx := context.WithValue(r.Context(), UsernameContextKey, claims.Username)
-		ctx = context.WithValue(ctx, RoleContextKey, claims.Role)
+		// Reject tokens issued before a password change or ban, or to a revoked session
+		user, err := am.currentUser(r, claims)
+		if err != nil {
+			log.Error().Err(err).Int64("user_id", claims.UserID).Msg("Failed to check token version and session")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if user == nil {
+			log.Warn().Int64("user_id", claims.UserID).Msg("Revoked token rejected")
+			http.Error(w, "Unauthorized: Token has been revoked, please log in again", http.StatusUnauthorized)
+			return
+		}
+
+		// Add user ID, username, role and permissions to request context
+		ctx := am.withPermissions(withClaims(r.Context(), claims), user)
+
+		// Every request made while impersonating is kept in the log, naming the admin
+		if claims.ImpersonatorID != 0 {
+			log.Info().
+				Int64("impersonator_id", claims.ImpersonatorID).
+				Int64("user_id", claims.UserID).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Msg("Impersonated request")
+		}
 
 		log.Debug().
+			Int64("user_id", claims.UserID).
 			Str("username", claims.Username).
 			Str("role", claims.Role).
 			Str("path", r.URL.Path).
@@ -81,13 +143,40 @@ func (am *AuthMiddleware) JWTAuth(next http.Handler) http.Handler {
 	})
 }
 
+// Middleware that rejects requests from banned accounts (apply after JWTAuth).
+// Checked on every request so a ban takes effect before the user's token expires.
+func (am *AuthMiddleware) RejectBanned(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username := GetUsername(r)
+
+		user, err := CachedUser(r, am.Users, GetUserID(r))
+		if err != nil {
+			log.Error().Err(err).Str("username", username).Msg("Failed to check ban status")
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if user.IsBanned(time.Now()) {
+			log.Warn().Str("username", username).Str("path", r.URL.Path).Msg("Request from banned account rejected")
+			http.Error(w, "Forbidden: Account is banned (see /api/moderation/me and /api/appeals)", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
 // Middleware that validates JWT if present, but allows requests without tokens
 func (am *AuthMiddleware) OptionalJWTAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 
-		// If no auth header, just continue without adding user to context
+		// If no auth header, use the API key if one was sent, or else continue without adding user to context
 		if authHeader == "" {
+			if key := r.Header.Get(APIKeyHeader); key != "" {
+				if user, err := am.apiKeyUser(r, key); err == nil {
+					r = r.WithContext(am.withPermissions(withUser(r.Context(), user), user))
+				}
+			}
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -115,14 +204,41 @@ func (am *AuthMiddleware) OptionalJWTAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		// Add username and role to context
-		ctx := context.WithValue(r.Context(), UsernameContextKey, claims.Username)
-		ctx = context.WithValue(ctx, RoleContextKey, claims.Role)
+		// Revoked token (or the check failed), continue without auth
+		user, err := am.currentUser(r, claims)
+		if err != nil || user == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Add user ID, username, role and permissions to request context
+		ctx := am.withPermissions(withClaims(r.Context(), claims), user)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// Gets the token's user when the token's version still matches theirs and its session is active
+// (nil when it doesn't, or the user was deleted)
+func (am *AuthMiddleware) currentUser(r *http.Request, claims *auth.Claims) (*model.User, error) {
+	user, err := CachedUser(r, am.Users, claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.TokenVersion != claims.TokenVersion {
+		return nil, nil
+	}
+
+	active, err := am.sessionActive(r, claims)
+	if err != nil || !active {
+		return nil, err
+	}
+	return user, nil
+}
+
 // Extracts the JWTtoken from "Bearer <token>" format
 func extractBearerToken(authHeader string) (string, error) {
 	parts := strings.SplitN(authHeader, " ", 2)
@@ -142,6 +258,27 @@ func extractBearerToken(authHeader string) (string, error) {
 	return token, nil
 }
 
+// Stores the token's user ID, username, role and session in the context
+func withClaims(ctx context.Context, claims *auth.Claims) context.Context {
+	ctx = context.WithValue(ctx, SessionIDContextKey, claims.SessionID)
+	ctx = context.WithValue(ctx, UserIDContextKey, claims.UserID)
+	ctx = context.WithValue(ctx, UsernameContextKey, claims.Username)
+	if claims.ImpersonatorID != 0 {
+		ctx = context.WithValue(ctx, ImpersonatorContextKey, claims.ImpersonatorID)
+	}
+	return context.WithValue(ctx, RoleContextKey, claims.Role)
+}
+
+// Extracts the user ID from the request context (0 for anonymous requests)
+func GetUserID(r *http.Request) int64 {
+	userId, ok := r.Context().Value(UserIDContextKey).(int64)
+	if !ok {
+		return 0
+	}
+
+	return userId
+}
+
 // Extracts username from the request context
 func GetUsername(r *http.Request) string {
 	username, ok := r.Context().Value(UsernameContextKey).(string) //<-- Type assertion
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/rs/zerolog/log"
@@ -12,18 +13,36 @@ type CORSConfig struct {
 	AllowedOrigins []string
 }
 
-// CORS adds Cross-Origin Resource Sharing headers to responses with credential support
-func CORS(config CORSConfig) func(http.Handler) http.Handler {
+// A CORS config for every path under PathPrefix (such as "/api/admin"), overriding the default
+type CORSRoute struct {
+	PathPrefix string
+	Config     CORSConfig
+}
+
+// CORS adds Cross-Origin Resource Sharing headers to responses with credential support. Requests under
+// one of the routes' path prefixes use that route's config (the longest prefix wins), everything else
+// uses defaultConfig. The config is picked by path rather than on a subrouter so preflight requests,
+// which match no route, get the same policy as the requests they precede.
+func CORS(defaultConfig CORSConfig, routes ...CORSRoute) func(http.Handler) http.Handler {
+	// Resolve precedence once at setup: longest prefix first
+	routes = append([]CORSRoute(nil), routes...)
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].PathPrefix) > len(routes[j].PathPrefix)
+	})
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			config := corsConfigFor(r.URL.Path, defaultConfig, routes)
 			origin := r.Header.Get("Origin")
+			// The answer depends on the origin, so caches mustn't share it between origins
+			w.Header().Add("Vary", "Origin")
 
 			// Validate origin against allowed list
 			if isAllowedOrigin(origin, config.AllowedOrigins) {
 				// Set specific origin (required for credentials)
 				w.Header().Set("Access-Control-Allow-Origin", origin)
 				// Enable credentials (cookies, authorization headers)
-				w.Header().Set("Access-Control-Allow-Origin", "true")
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 				log.Debug().
 					Str("origin", origin).
@@ -43,7 +62,10 @@ func CORS(config CORSConfig) func(http.Handler) http.Handler {
 			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 
 			// Set allowed headers
-			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, Authorization, X-API-Key, X-Edit-Lock")
+
+			// Let browser clients read deprecation notices
+			w.Header().Set("Access-Control-Expose-Headers", "Deprecation, Sunset, Link")
 
 			// Security headers
 			w.Header().Set("X-Content-Type-Options", "nosniff")
@@ -61,6 +83,17 @@ func CORS(config CORSConfig) func(http.Handler) http.Handler {
 	}
 }
 
+// Picks the config of the longest route prefix containing path (routes are sorted longest first)
+func corsConfigFor(path string, defaultConfig CORSConfig, routes []CORSRoute) CORSConfig {
+	for _, route := range routes {
+		if path == route.PathPrefix || strings.HasPrefix(path, strings.TrimSuffix(route.PathPrefix, "/")+"/") {
+			return route.Config
+		}
+	}
+
+	return defaultConfig
+}
+
 // isAllowedOrigin checks if the origin is in the allowed list
 func isAllowedOrigin(origin string, allowed []string) bool {
 	if origin == "" {
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Describes a deprecated route so clients can migrate before it's removed
+type Deprecation struct {
+	// When the route was deprecated
+	Since time.Time
+	// When the route will be removed (zero if not scheduled yet)
+	Sunset time.Time
+	// Route or documentation clients should move to (optional)
+	Successor string
+}
+
+// Middleware that marks every response as deprecated with Deprecation (RFC 9745),
+// Sunset (RFC 8594) and Link headers, and logs who is still calling the route
+func Deprecated(info Deprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", info.Since.Unix()))
+			if !info.Sunset.IsZero() {
+				w.Header().Set("Sunset", info.Sunset.UTC().Format(http.TimeFormat))
+			}
+			if info.Successor != "" {
+				w.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", info.Successor))
+			}
+
+			event := log.Warn().
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Str("user_agent", r.UserAgent())
+			if username := GetUsername(r); username != "" {
+				event = event.Str("username", username)
+			}
+			if !info.Sunset.IsZero() {
+				event = event.Time("sunset", info.Sunset)
+			}
+			event.Msg("Deprecated endpoint called")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"byte-board/internal/model"
+	"context"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+const PermissionsContextKey contextKey = "permissions"
+
+// Maps roles to the permissions they grant
+type PermissionLoader interface {
+	Permissions(role string) []string
+}
+
+// Stores the user's current role and the permissions it grants in the context. The role comes from the
+// database rather than the token, so role changes apply without logging in again.
+func (am *AuthMiddleware) withPermissions(ctx context.Context, user *model.User) context.Context {
+	ctx = context.WithValue(ctx, RoleContextKey, user.Role)
+	return context.WithValue(ctx, PermissionsContextKey, am.Roles.Permissions(user.Role))
+}
+
+// Checks the authenticated user's role grants a permission (apply after JWTAuth)
+func RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasPermission(r, permission) {
+				log.Warn().
+					Str("required_permission", permission).
+					Str("user_role", GetRole(r)).
+					Str("path", r.URL.Path).
+					Msg("User does not have required permission")
+				http.Error(w, "Forbidden: Insufficient permissions", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Reports whether the signed-in user's role grants a permission (false for anonymous requests)
+func HasPermission(r *http.Request, permission string) bool {
+	return model.HasPermission(GetPermissions(r), permission)
+}
+
+// Extracts the permissions of the signed-in user's role from the request context
+func GetPermissions(r *http.Request) []string {
+	permissions, _ := r.Context().Value(PermissionsContextKey).([]string)
+	return permissions
+}
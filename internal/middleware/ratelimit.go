@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Caps how many requests each client makes per window (signed-in users are counted by user ID,
+// anonymous ones by IP). Counts are kept in memory, so each instance limits separately.
+type RateLimiter struct {
+	limit      int
+	window     time.Duration
+	trustProxy bool
+
+	mu        sync.Mutex
+	counters  map[string]*rateCounter
+	lastSweep time.Time
+}
+
+// Requests a client made in its current window
+type rateCounter struct {
+	start time.Time
+	count int
+}
+
+// Creates a new rate limiter allowing limit requests per window (0 disables it).
+// trustProxy takes anonymous clients' IPs from X-Forwarded-For.
+func NewRateLimiter(limit int, window time.Duration, trustProxy bool) *RateLimiter {
+	return &RateLimiter{
+		limit:      limit,
+		window:     window,
+		trustProxy: trustProxy,
+		counters:   make(map[string]*rateCounter),
+	}
+}
+
+// Middleware that rejects requests over the limit with 429 (run after OptionalJWTAuth or JWTAuth)
+func (l *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.limit <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := "ip:" + ClientIP(r, l.trustProxy)
+		if userId := GetUserID(r); userId != 0 {
+			key = "user:" + strconv.FormatInt(userId, 10)
+		}
+
+		if retryAfter, ok := l.allow(key, time.Now()); !ok {
+			log.Warn().Str("client", key).Str("path", r.URL.Path).Msg("Rate limit exceeded")
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprintf(w, `{"error": "Too many requests, please slow down"}`)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Counts a request from key, reporting whether it's allowed and otherwise how long until the window resets
+func (l *RateLimiter) allow(key string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Drop finished windows now and then so idle clients don't pile up
+	if now.Sub(l.lastSweep) >= l.window {
+		for k, counter := range l.counters {
+			if now.Sub(counter.start) >= l.window {
+				delete(l.counters, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	counter, ok := l.counters[key]
+	if !ok || now.Sub(counter.start) >= l.window {
+		counter = &rateCounter{start: now}
+		l.counters[key] = counter
+	}
+	if counter.count >= l.limit {
+		return counter.start.Add(l.window).Sub(now), false
+	}
+
+	counter.count++
+	return 0, true
+}
+
+// Gets the client's IP address, using X-Forwarded-For only when the service is behind a trusted proxy.
+// The last entry is the one our proxy appended; earlier entries can be forged by the client.
+func ClientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			hops := strings.Split(forwarded, ",")
+			return strings.TrimSpace(hops[len(hops)-1])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Paths that keep accepting POST/PUT in read-only mode (so admins and service clients can still sign in and turn it off,
+// and previews that never write anything keep working)
+var readOnlyExemptPaths = map[string]bool{
+	"/api/login":           true,
+	"/api/auth/token":      true,
+	"/api/admin/read-only": true,
+	"/api/preview":         true,
+}
+
+// Runtime switch that rejects mutating requests while reads keep working
+type ReadOnlyMode struct {
+	enabled atomic.Bool
+}
+
+// Creates a new read-only mode switch
+func NewReadOnlyMode(enabled bool) *ReadOnlyMode {
+	mode := &ReadOnlyMode{}
+	mode.enabled.Store(enabled)
+	return mode
+}
+
+// Reports whether read-only mode is on
+func (m *ReadOnlyMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Turns read-only mode on or off
+func (m *ReadOnlyMode) SetEnabled(enabled bool) {
+	m.enabled.Store(enabled)
+	log.Warn().Bool("read_only", enabled).Msg("Read-only mode changed")
+}
+
+// Middleware that rejects mutating requests with 503 while read-only mode is on
+func (m *ReadOnlyMode) Enforce(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.Enabled() && !isReadMethod(r.Method) && !readOnlyExemptPaths[r.URL.Path] {
+			log.Warn().
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Msg("Rejected write request in read-only mode")
+
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, `{"error": "Service is in read-only mode, please try again later"}`)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Checks if a request method never modifies data
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"byte-board/internal/model"
+	"context"
+	"net/http"
+	"sync"
+)
+
+type requestCacheKey struct{}
+
+// Values loaded while serving one request, shared by every middleware and handler that needs them
+type requestCache struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+// Loads users by ID (implemented by the repository)
+type UserLoader interface {
+	GetUserByID(userId int64) (*model.User, error)
+}
+
+// Loads profiles by user ID (implemented by the repository)
+type ProfileLoader interface {
+	GetProfileByUserId(userId int64) (*model.Profile, error)
+}
+
+// Middleware that gives each request its own cache (apply before anything that uses Memoize)
+func RequestCache(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cache := &requestCache{values: make(map[string]interface{})}
+		ctx := context.WithValue(r.Context(), requestCacheKey{}, cache)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Returns the value cached under key for this request, calling load on the first use.
+// Errors aren't cached, and without a request cache load is called every time.
+func Memoize[T any](r *http.Request, key string, load func() (T, error)) (T, error) {
+	cache, ok := r.Context().Value(requestCacheKey{}).(*requestCache)
+	if !ok {
+		return load()
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if value, ok := cache.values[key].(T); ok {
+		return value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	cache.values[key] = value
+	return value, nil
+}
+
+// Loads a user once per request
+func CachedUser(r *http.Request, users UserLoader, userId int64) (*model.User, error) {
+	return Memoize(r, "user:"+model.FormatID(userId), func() (*model.User, error) {
+		return users.GetUserByID(userId)
+	})
+}
+
+// Loads a user's profile once per request
+func CachedProfile(r *http.Request, profiles ProfileLoader, userId int64) (*model.Profile, error) {
+	return Memoize(r, "profile:"+model.FormatID(userId), func() (*model.Profile, error) {
+		return profiles.GetProfileByUserId(userId)
+	})
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"byte-board/internal/auth"
+	"byte-board/internal/model"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	SessionIDContextKey    contextKey = "session_id"
+	ImpersonatorContextKey contextKey = "impersonator_id"
+)
+
+// Reports whether a JWT's session still belongs to its user and hasn't been revoked or expired
+type SessionChecker interface {
+	IsActive(sessionId, userId int64) (bool, error)
+}
+
+// Checks the token's session once per request
+func (am *AuthMiddleware) sessionActive(r *http.Request, claims *auth.Claims) (bool, error) {
+	return Memoize(r, "session:"+model.FormatID(claims.SessionID), func() (bool, error) {
+		return am.Sessions.IsActive(claims.SessionID, claims.UserID)
+	})
+}
+
+// Extracts the ID of the session the request's JWT belongs to (0 for API keys and anonymous requests)
+func GetSessionID(r *http.Request) int64 {
+	sessionId, ok := r.Context().Value(SessionIDContextKey).(int64)
+	if !ok {
+		return 0
+	}
+
+	return sessionId
+}
+
+// Extracts the ID of the admin acting as the user through an impersonation token (0 otherwise)
+func GetImpersonatorID(r *http.Request) int64 {
+	impersonatorId, _ := r.Context().Value(ImpersonatorContextKey).(int64)
+	return impersonatorId
+}
+
+// Middleware that keeps impersonation tokens away from account security (apply after JWTAuth): an admin
+// acting as a user mustn't change their password or email, manage their keys and sessions, or delete them
+func RejectImpersonated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if impersonatorId := GetImpersonatorID(r); impersonatorId != 0 {
+			log.Warn().Int64("impersonator_id", impersonatorId).Int64("user_id", GetUserID(r)).Str("path", r.URL.Path).Msg("Impersonated request to account security rejected")
+			http.Error(w, "Forbidden: Not allowed while impersonating a user", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"byte-board/internal/repository"
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Returned to roll back a request's transaction when its handler answers with an error status
+var errRequestFailed = errors.New("request failed")
+
+// Holds a handler's response back until its transaction has ended
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse() *bufferedResponse {
+	return &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	b.status = code
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// Sends the held response
+func (b *bufferedResponse) flush(w http.ResponseWriter) {
+	for key, values := range b.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(b.status)
+	w.Write(b.body.Bytes())
+}
+
+// Runs handlers that make several writes inside one database transaction bound to the request context
+// (handlers get it with repository.FromContext). The response is held back until the transaction ends:
+// it's committed when the handler answers with a status below 400, and rolled back on an error status or
+// a panic. If the commit fails, the client gets a 500 instead of the held response.
+//
+// Everything in the request must go through the bound database for the writes to be grouped, and
+// queries run one at a time on its connection, so rows must be closed before the next query.
+func Transaction(db *repository.DB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			response := newBufferedResponse()
+
+			err := db.InTx(r.Context(), func(tx *repository.DB) error {
+				next.ServeHTTP(response, r.WithContext(repository.NewContext(r.Context(), tx)))
+				if response.status >= http.StatusBadRequest {
+					return errRequestFailed
+				}
+				return nil
+			})
+			if err != nil && !errors.Is(err, errRequestFailed) {
+				log.Error().Err(err).Str("method", r.Method).Str("path", r.URL.Path).Msg("Request transaction failed")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"error": "Internal server error"}`))
+				return
+			}
+
+			response.flush(w)
+		})
+	}
+}
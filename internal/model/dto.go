@@ -0,0 +1,607 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// API request and response bodies. Handlers decode requests into and write
+// responses from these types instead of exposing the database models directly.
+
+// #region Requests
+
+// Create/update post request body
+type PostRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	// public, members, followers or private; defaults to public on create and is left alone on update when empty
+	Visibility string `json:"visibility,omitempty"`
+	// BCP 47 language tag; detected from the text when empty (on update, a detected language is re-detected)
+	Language string `json:"language,omitempty"`
+	// Slug of the board to file the post under; left alone on update when omitted, "" takes it off its board
+	Board *string `json:"board,omitempty"`
+	// Up to 5 tags; left alone on update when omitted, [] clears them
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Create/update board request body (the slug is in the path)
+type BoardRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// The boards and tags a member subscribes to
+type SubscriptionsResponse struct {
+	Boards []Board  `json:"boards"`
+	Tags   []string `json:"tags"`
+}
+
+// Create/update comment request body
+type CommentRequest struct {
+	Content string `json:"content"`
+}
+
+// Update profile request body
+type UpdateProfileRequest struct {
+	FirstName  string `json:"first_name"`
+	LastName   string `json:"last_name"`
+	GithubLink string `json:"github_link"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	// Left unchanged when omitted; an empty string clears it
+	DisplayName *string `json:"display_name,omitempty"`
+}
+
+// Attach snippet request body
+type SnippetRequest struct {
+	Filename string `json:"filename"`
+	Language string `json:"language"`
+	Body     string `json:"body"`
+}
+
+// Gist import request body
+type GistImportRequest struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// Set profile skills request body
+type SkillsRequest struct {
+	Skills []string `json:"skills"`
+}
+
+// Skill rule request body (skill is the canonical skill for synonyms)
+type SkillRuleRequest struct {
+	Action string `json:"action"`
+	Skill  string `json:"skill"`
+}
+
+// Skill merge request body
+type SkillMergeRequest struct {
+	From []string `json:"from"`
+	Into string   `json:"into"`
+}
+
+// Create/update project request body
+type ProjectRequest struct {
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	RepoURL       string `json:"repo_url"`
+	ScreenshotURL string `json:"screenshot_url"`
+}
+
+// Report content request body
+type ReportRequest struct {
+	TargetType string `json:"target_type"`
+	TargetId   int64  `json:"target_id"`
+	Reason     string `json:"reason"`
+	Details    string `json:"details"`
+}
+
+// Change report status request body
+type ReportStatusRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// Assign report request body (null moderator_id unassigns)
+type ReportAssignRequest struct {
+	ModeratorId *int64 `json:"moderator_id"`
+}
+
+// Create/update report reason request body
+type ReportReasonRequest struct {
+	Code   string `json:"code"`
+	Label  string `json:"label"`
+	Active *bool  `json:"active"`
+}
+
+// Moderation action request body
+type ModerationActionRequest struct {
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetId   int64  `json:"target_id"`
+	Reason     string `json:"reason"`
+	ReportId   *int64 `json:"report_id"`
+	// When a suspension ends (required for suspend, ignored otherwise)
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Ban/suspend request body. A ban with a duration is a suspension.
+type BanRequest struct {
+	Reason        string `json:"reason"`
+	DurationHours int    `json:"duration_hours"`
+	ReportId      *int64 `json:"report_id"`
+}
+
+// Appeal request body
+type AppealRequest struct {
+	ActionId int64  `json:"action_id"`
+	Message  string `json:"message"`
+}
+
+// Resolve appeal request body
+type ResolveAppealRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// Update notification settings request body (only the listed event types change)
+type NotificationSettingsRequest struct {
+	Settings []NotificationSetting `json:"settings"`
+}
+
+// Update digest settings request body
+type DigestSettingsRequest struct {
+	Frequency string `json:"frequency"`
+}
+
+// Create word filter request body
+type WordFilterRequest struct {
+	Pattern     string `json:"pattern"`
+	IsRegex     bool   `json:"is_regex"`
+	Action      string `json:"action"`
+	Replacement string `json:"replacement"`
+}
+
+// Create or update announcement request body (on update, omitted fields are left unchanged)
+type AnnouncementRequest struct {
+	Title     string     `json:"title"`
+	Message   string     `json:"message"`
+	Level     string     `json:"level"`
+	StartsAt  *time.Time `json:"starts_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Change email request body
+type ChangeEmailRequest struct {
+	Email string `json:"email"`
+}
+
+// Markdown preview request body
+type PreviewRequest struct {
+	Content string `json:"content"`
+}
+
+// Replay events request body
+type EventReplayRequest struct {
+	Target  string     `json:"target"`
+	Types   []string   `json:"types"`
+	Since   *time.Time `json:"since"`
+	Until   *time.Time `json:"until"`
+	AfterId int64      `json:"after_id"`
+}
+
+// Register service client request body
+type ServiceClientRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// Create keyword alert request body
+type KeywordAlertRequest struct {
+	Keyword string `json:"keyword"`
+}
+
+// Generate API key request body
+type APIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+// Merge accounts request body (into is the account that survives)
+type MergeUsersRequest struct {
+	Into   int64 `json:"into"`
+	DryRun bool  `json:"dry_run"`
+}
+
+// #endregion
+
+// #region Responses
+
+type CommentResponse struct {
+	CommentId  int64     `json:"comment_id"`
+	UserId     int64     `json:"user_id"`
+	PostId     int64     `json:"post_id"`
+	Content    string    `json:"content"`
+	Author     string    `json:"author"`
+	AuthorInfo *Author   `json:"author_info,omitempty"`
+	DatePosted time.Time `json:"date_posted"`
+	// Set in a post's expanded response (see PostWithCommentsResponse)
+	Reactions *ReactionSummary `json:"reactions,omitempty"`
+}
+
+// A comment's reactions, returned when the viewer adds or removes one
+type CommentReactionsResponse struct {
+	CommentId int64 `json:"comment_id"`
+	ReactionSummary
+}
+
+// Comments on one post, from the bulk comments endpoint
+type PostCommentsResponse struct {
+	PostId   int64             `json:"post_id"`
+	Comments []CommentResponse `json:"comments"`
+}
+
+type PostResponse struct {
+	PostId         int64     `json:"post_id"`
+	UserId         int64     `json:"user_id"`
+	Title          string    `json:"title"`
+	Content        string    `json:"content"`
+	Author         string    `json:"author"`
+	AuthorInfo     *Author   `json:"author_info,omitempty"`
+	DatePosted     time.Time `json:"date_posted"`
+	Locked         bool      `json:"locked"`
+	Visibility     string    `json:"visibility"`
+	Language       string    `json:"language"`
+	LanguageSource string    `json:"language_source"`
+	Excerpt        string    `json:"excerpt"`
+	ReadingMinutes int       `json:"reading_minutes"`
+	// Publicly visible comments and when the latest one (or the post itself) was made
+	CommentCount   int       `json:"comment_count"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	BoardId        *int64    `json:"board_id,omitempty"`
+	Tags           []string  `json:"tags"`
+	// Signed-in viewers only (set by the handler): whether they follow the author and are notified
+	// about new comments
+	FollowingAuthor *bool `json:"following_author,omitempty"`
+	Watching        *bool `json:"watching,omitempty"`
+}
+
+// A post with a page of its comments and their reactions (GET /api/posts/{postId}?expand=comments)
+type PostWithCommentsResponse struct {
+	PostResponse
+	Comments []CommentResponse `json:"comments"`
+}
+
+// A member's profile, shaped for the viewer: the email is only shown to the member and staff, and staff
+// also get the staff details
+type ProfileResponse struct {
+	UserId         int64         `json:"user_id"`
+	FirstName      string        `json:"first_name"`
+	LastName       string        `json:"last_name"`
+	Email          string        `json:"email,omitempty"`
+	GithubLink     string        `json:"github_link"`
+	City           string        `json:"city"`
+	State          string        `json:"state"`
+	DateRegistered time.Time     `json:"date_registered"`
+	DisplayName    string        `json:"display_name,omitempty"`
+	Skills         []string      `json:"skills,omitempty"`
+	Stats          *ProfileStats `json:"stats,omitempty"`
+	// Whether the member pinged /api/presence/ping recently (set on the profile list and profile pages)
+	Online bool `json:"online"`
+	// Signed-in viewers only (set by the handler): whether they follow the member
+	IsFollowing *bool `json:"is_following,omitempty"`
+	// Staff only (set by the handler, which loads them)
+	Staff *MemberDetails `json:"staff,omitempty"`
+}
+
+type ProjectResponse struct {
+	ProjectId     int64     `json:"project_id"`
+	UserId        int64     `json:"user_id"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	RepoURL       string    `json:"repo_url"`
+	ScreenshotURL string    `json:"screenshot_url"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type ReportResponse struct {
+	ReportId       int64      `json:"report_id"`
+	ReporterId     int64      `json:"reporter_id"`
+	TargetType     string     `json:"target_type"`
+	TargetId       int64      `json:"target_id"`
+	Reason         string     `json:"reason"`
+	Details        string     `json:"details"`
+	Status         string     `json:"status"`
+	AssignedTo     *int64     `json:"assigned_to"`
+	ResolutionNote string     `json:"resolution_note"`
+	ResolvedBy     *int64     `json:"resolved_by"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type SnippetResponse struct {
+	SnippetId int64     `json:"snippet_id"`
+	PostId    int64     `json:"post_id"`
+	UserId    int64     `json:"user_id"`
+	Filename  string    `json:"filename"`
+	Language  string    `json:"language"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// User data for admin endpoints (never includes the password hash)
+type UserResponse struct {
+	UserID       int64  `json:"user_id"`
+	Username     string `json:"username"`
+	Role         string `json:"role"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Banned       bool   `json:"banned"`
+	Shadowbanned bool   `json:"shadowbanned"`
+	// active, suspended or banned, and when a suspension lifts
+	Status      string     `json:"status"`
+	BannedUntil *time.Time `json:"banned_until,omitempty"`
+	// Staff only (set by the handler, which loads them)
+	Staff *MemberDetails `json:"staff,omitempty"`
+}
+
+// Rendered Markdown preview
+type PreviewResponse struct {
+	HTML string `json:"html"`
+}
+
+// A newly registered service client with its secret (only ever shown in this response)
+type ServiceClientCreatedResponse struct {
+	ServiceClient
+	ClientSecret string `json:"client_secret"`
+}
+
+// A newly generated API key with the key itself (only ever shown in this response)
+type APIKeyCreatedResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// OAuth 2.0 token response for the client credentials grant
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// A post's title and content in the requested language
+type PostTranslationResponse struct {
+	PostId         int64  `json:"post_id"`
+	SourceLanguage string `json:"source_language"`
+	Language       string `json:"language"`
+	Title          string `json:"title"`
+	Content        string `json:"content"`
+	// False when the post is already in the requested language and the original is returned
+	Translated bool `json:"translated"`
+}
+
+// Readiness check result (Database is only filled in for ?verbose=1)
+type ReadinessResponse struct {
+	Status   string          `json:"status"`
+	ReadOnly bool            `json:"read_only"`
+	Database *DatabaseStatus `json:"database,omitempty"`
+}
+
+// An uploaded file and where to download it
+type AttachmentResponse struct {
+	AttachmentId int64      `json:"attachment_id"`
+	UserId       int64      `json:"user_id"`
+	Filename     string     `json:"filename"`
+	ContentType  string     `json:"content_type"`
+	SizeBytes    int64      `json:"size_bytes"`
+	URL          string     `json:"url"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LinkedAt     *time.Time `json:"linked_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// #endregion
+
+// #region Mapping
+
+func NewCommentResponse(comment *Comment) CommentResponse {
+	return CommentResponse{
+		CommentId:  comment.CommentId,
+		UserId:     comment.UserId,
+		PostId:     comment.PostId,
+		Content:    comment.Content,
+		Author:     comment.Author,
+		DatePosted: comment.DatePosted,
+	}
+}
+
+func NewCommentResponses(comments []Comment) []CommentResponse {
+	responses := make([]CommentResponse, 0, len(comments))
+	for i := range comments {
+		responses = append(responses, NewCommentResponse(&comments[i]))
+	}
+	return responses
+}
+
+func NewPostResponse(post *Post) PostResponse {
+	return PostResponse{
+		PostId:         post.PostId,
+		UserId:         post.UserId,
+		Title:          post.Title,
+		Content:        post.Content,
+		Author:         post.Author,
+		DatePosted:     post.DatePosted,
+		Locked:         post.Locked,
+		Visibility:     post.Visibility,
+		Language:       post.Language,
+		LanguageSource: post.LanguageSource,
+		Excerpt:        post.Excerpt,
+		ReadingMinutes: post.ReadingMinutes,
+		CommentCount:   post.CommentCount,
+		LastActivityAt: post.LastActivityAt,
+		BoardId:        post.BoardId,
+		Tags:           post.Tags,
+	}
+}
+
+func NewPostResponses(posts []Post) []PostResponse {
+	responses := make([]PostResponse, 0, len(posts))
+	for i := range posts {
+		responses = append(responses, NewPostResponse(&posts[i]))
+	}
+	return responses
+}
+
+// Shapes a profile for the viewer (the email is left out for everyone but the member and staff)
+func NewProfileResponse(profile *Profile, viewer Viewer) ProfileResponse {
+	response := ProfileResponse{
+		UserId:         profile.UserId,
+		FirstName:      profile.FirstName,
+		LastName:       profile.LastName,
+		GithubLink:     profile.GithubLink,
+		City:           profile.City,
+		State:          profile.State,
+		DateRegistered: profile.DateRegistered,
+		DisplayName:    profile.DisplayName,
+	}
+	if viewer.AudienceFor(profile.UserId) >= AudienceOwner {
+		response.Email = profile.Email
+	}
+	return response
+}
+
+func NewProfileResponses(profiles []Profile, viewer Viewer) []ProfileResponse {
+	responses := make([]ProfileResponse, 0, len(profiles))
+	for i := range profiles {
+		responses = append(responses, NewProfileResponse(&profiles[i], viewer))
+	}
+	return responses
+}
+
+func NewProjectResponse(project *Project) ProjectResponse {
+	return ProjectResponse{
+		ProjectId:     project.ProjectId,
+		UserId:        project.UserId,
+		Title:         project.Title,
+		Description:   project.Description,
+		RepoURL:       project.RepoURL,
+		ScreenshotURL: project.ScreenshotURL,
+		CreatedAt:     project.CreatedAt,
+		UpdatedAt:     project.UpdatedAt,
+	}
+}
+
+func NewProjectResponses(projects []Project) []ProjectResponse {
+	responses := make([]ProjectResponse, 0, len(projects))
+	for i := range projects {
+		responses = append(responses, NewProjectResponse(&projects[i]))
+	}
+	return responses
+}
+
+func NewReportResponse(report *Report) ReportResponse {
+	return ReportResponse{
+		ReportId:       report.ReportId,
+		ReporterId:     report.ReporterId,
+		TargetType:     report.TargetType,
+		TargetId:       report.TargetId,
+		Reason:         report.ReasonCode,
+		Details:        report.Details,
+		Status:         report.Status,
+		AssignedTo:     report.AssignedTo,
+		ResolutionNote: report.ResolutionNote,
+		ResolvedBy:     report.ResolvedBy,
+		ResolvedAt:     report.ResolvedAt,
+		CreatedAt:      report.CreatedAt,
+		UpdatedAt:      report.UpdatedAt,
+	}
+}
+
+func NewReportResponses(reports []Report) []ReportResponse {
+	responses := make([]ReportResponse, 0, len(reports))
+	for i := range reports {
+		responses = append(responses, NewReportResponse(&reports[i]))
+	}
+	return responses
+}
+
+func NewSnippetResponse(snippet *Snippet) SnippetResponse {
+	return SnippetResponse{
+		SnippetId: snippet.SnippetId,
+		PostId:    snippet.PostId,
+		UserId:    snippet.UserId,
+		Filename:  snippet.Filename,
+		Language:  snippet.Language,
+		Body:      snippet.Body,
+		CreatedAt: snippet.CreatedAt,
+	}
+}
+
+func NewSnippetResponses(snippets []Snippet) []SnippetResponse {
+	responses := make([]SnippetResponse, 0, len(snippets))
+	for i := range snippets {
+		responses = append(responses, NewSnippetResponse(&snippets[i]))
+	}
+	return responses
+}
+
+func NewUserResponse(user *User) UserResponse {
+	return UserResponse{
+		UserID:       user.ID,
+		Username:     user.Username,
+		Role:         user.Role,
+		FirstName:    user.FirstName,
+		LastName:     user.LastName,
+		Banned:       user.IsBanned(time.Now()),
+		Shadowbanned: user.Shadowbanned,
+		Status:       user.StatusAt(time.Now()),
+		BannedUntil:  user.BannedUntil,
+	}
+}
+
+func NewUserResponses(users []User) []UserResponse {
+	responses := make([]UserResponse, 0, len(users))
+	for i := range users {
+		responses = append(responses, NewUserResponse(&users[i]))
+	}
+	return responses
+}
+
+// Safe user summary for auth responses
+func NewUserSummary(user *User) UserSummary {
+	return UserSummary{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+	}
+}
+
+func NewAttachmentResponse(attachment *Attachment) AttachmentResponse {
+	return AttachmentResponse{
+		AttachmentId: attachment.AttachmentId,
+		UserId:       attachment.UserId,
+		Filename:     attachment.Filename,
+		ContentType:  attachment.ContentType,
+		SizeBytes:    attachment.SizeBytes,
+		URL:          fmt.Sprintf("/api/attachments/%d", attachment.AttachmentId),
+		CreatedAt:    attachment.CreatedAt,
+		LinkedAt:     attachment.LinkedAt,
+		ExpiresAt:    attachment.ExpiresAt,
+	}
+}
+
+func NewAttachmentResponses(attachments []Attachment) []AttachmentResponse {
+	responses := make([]AttachmentResponse, 0, len(attachments))
+	for i := range attachments {
+		responses = append(responses, NewAttachmentResponse(&attachments[i]))
+	}
+	return responses
+}
+
+// #endregion
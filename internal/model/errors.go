@@ -11,7 +11,14 @@ var (
 	ErrExpiredToken     = errors.New("token has expired")
 	ErrInvalidSignature = errors.New("invalid token signature")
 	ErrMissingClaims    = errors.New("missing required claims")
+	ErrWrongAudience    = errors.New("token was issued for another deployment")
 
 	ErrPasswordTooLong = errors.New("password exceeds maximum length of 32 bytes")
 	ErrPasswordEmpty   = errors.New("password cannot be empty")
+
+	ErrUsernameLength   = errors.New("username must be between 3 and 30 characters long")
+	ErrUsernameCharset  = errors.New("username may only contain letters, digits, underscores, hyphens and dots, and must start with a letter or digit")
+	ErrUsernameReserved = errors.New("username is reserved")
+
+	ErrInvalidID = errors.New("id must be a positive integer")
 )
@@ -0,0 +1,18 @@
+package model
+
+import "strconv"
+
+// Parses an entity ID from a URL path or query value (IDs are positive 64-bit integers)
+func ParseID(value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 1 {
+		return 0, ErrInvalidID
+	}
+
+	return id, nil
+}
+
+// Formats an entity ID for URLs, cache keys and log lines
+func FormatID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
@@ -1,27 +1,122 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+// Converts a time taken from a request to UTC (nil stays nil). JSON times must carry an offset, so
+// this only changes how they're shown back, not the instant.
+func UTCTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
 
 type Comment struct {
-	CommentId  int       `json:"comment_id" db:"comment_id"`
-	UserId     int       `json:"user_id" db:"user_id"`
-	PostId     int       `json:"post_id" db:"post_id"`
+	CommentId  int64     `json:"comment_id" db:"comment_id"`
+	UserId     int64     `json:"user_id" db:"user_id"`
+	PostId     int64     `json:"post_id" db:"post_id"`
 	Content    string    `json:"content" db:"content"`
 	Author     string    `json:"author" db:"author"`
 	DatePosted time.Time `json:"date_posted" db:"date_posted"`
+	Hidden     bool      `json:"hidden" db:"hidden"`
+}
+
+// Reactions members can leave on a comment
+const (
+	ReactionLike       = "like"
+	ReactionLove       = "love"
+	ReactionLaugh      = "laugh"
+	ReactionInsightful = "insightful"
+	ReactionConfused   = "confused"
+)
+
+// Every comment reaction, in display order
+var CommentReactions = []string{ReactionLike, ReactionLove, ReactionLaugh, ReactionInsightful, ReactionConfused}
+
+// Reports whether a reaction is one members can leave on a comment
+func IsCommentReaction(reaction string) bool {
+	for _, known := range CommentReactions {
+		if reaction == known {
+			return true
+		}
+	}
+	return false
+}
+
+// The reactions on one comment: how many members left each, and which the viewer left
+type ReactionSummary struct {
+	Counts map[string]int `json:"counts"`
+	Mine   []string       `json:"mine"`
 }
 
 type Post struct {
-	PostId     int       `json:"post_id" db:"post_id"`
-	UserId     int       `json:"user_id" db:"user_id"`
+	PostId     int64     `json:"post_id" db:"post_id"`
+	UserId     int64     `json:"user_id" db:"user_id"`
 	Title      string    `json:"title" db:"title"`
 	Content    string    `json:"content" db:"content"`
 	Author     string    `json:"author" db:"author"`
 	DatePosted time.Time `json:"date_posted" db:"date_posted"`
+	Hidden     bool      `json:"hidden" db:"hidden"`
+	Locked     bool      `json:"locked" db:"locked"`
+	Visibility string    `json:"visibility" db:"visibility"`
+	// BCP 47 tag ("und" when unknown) and whether the author declared it or it was detected
+	Language       string `json:"language" db:"language"`
+	LanguageSource string `json:"language_source" db:"language_source"`
+	// Plain-text opening of the content and estimated reading time, computed whenever the content is saved
+	Excerpt        string `json:"excerpt" db:"excerpt"`
+	ReadingMinutes int    `json:"reading_minutes" db:"reading_minutes"`
+	// Maintained by the repository as comments are added, removed and moderated
+	CommentCount   int       `json:"comment_count" db:"comment_count"`
+	LastActivityAt time.Time `json:"last_activity_at" db:"last_activity_at"`
+	// The board the post is filed under (nil when none) and its tags, in the skill tag form
+	BoardId *int64   `json:"board_id,omitempty" db:"board_id"`
+	Tags    []string `json:"tags" db:"tags"`
+}
+
+// A board posts are filed under (members can subscribe to it)
+type Board struct {
+	BoardId     int64     `json:"board_id"`
+	Slug        string    `json:"slug"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Who can read a post
+const (
+	// Everyone, including anonymous visitors
+	VisibilityPublic = "public"
+	// Any signed-in user
+	VisibilityMembers = "members"
+	// The author's followers
+	VisibilityFollowers = "followers"
+	// Only the author
+	VisibilityPrivate = "private"
+)
+
+// Where a post's language came from
+const (
+	LanguageDeclared = "declared"
+	LanguageDetected = "detected"
+)
+
+// A machine translation of a post, cached per target language
+type PostTranslation struct {
+	PostId   int64  `json:"post_id" db:"post_id"`
+	Language string `json:"language" db:"language"`
+	Title    string `json:"title" db:"title"`
+	Content  string `json:"content" db:"content"`
+	// Hash of the title and content the translation was made from (stale once the post is edited)
+	SourceHash string    `json:"-" db:"source_hash"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
 type Profile struct {
-	UserId         int       `json:"user_id" db:"user_id"`
+	UserId         int64     `json:"user_id" db:"user_id"`
 	FirstName      string    `json:"first_name" db:"first_name"`
 	LastName       string    `json:"last_name" db:"last_name"`
 	Email          string    `json:"email" db:"email"`
@@ -29,13 +124,848 @@ type Profile struct {
 	City           string    `json:"city" db:"city"`
 	State          string    `json:"state" db:"state"`
 	DateRegistered time.Time `json:"date_registered" db:"date_registered"`
+	DisplayName    string    `json:"display_name" db:"display_name"` // empty when unset (the username is shown instead)
+}
+
+// Profile items counted by the completeness score
+const (
+	ProfileItemDisplayName = "display_name"
+	ProfileItemSkills      = "skills"
+	ProfileItemGithubLink  = "github_link"
+	ProfileItemProjects    = "projects"
+	// City or state
+	ProfileItemLocation = "location"
+)
+
+// How much of their profile a user has filled in, so frontends can prompt them to finish it
+type ProfileCompleteness struct {
+	// Percentage of the items filled in (0-100)
+	Score int `json:"score"`
+	// Items still to fill in, most worthwhile first (empty when the profile is complete)
+	Missing []string `json:"missing"`
+}
+
+// Who wrote a post or comment, looked up from their account when the content is read
+type Author struct {
+	UserId      int64  `json:"user_id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+// The name content is credited to (display name, falling back to username)
+func (a Author) Name() string {
+	if a.DisplayName != "" {
+		return a.DisplayName
+	}
+	return a.Username
+}
+
+// Activity stats computed for a profile
+type ProfileStats struct {
+	PostCount    int       `json:"post_count"`
+	CommentCount int       `json:"comment_count"`
+	MemberSince  time.Time `json:"member_since"`
 }
 
 type User struct {
-	ID             int    `json:"user_id" db:"user_id"`
+	ID             int64  `json:"user_id" db:"user_id"`
 	Username       string `json:"username" db:"username"`
 	HashedPassword string `json:"-" db:"hashed_password"`
 	Role           string `json:"role" db:"role"`
-	FirstName string `json:"first_name" db:"first_name"`
-	LastName string `json:"last_name" db:"last_name"`
+	FirstName      string `json:"first_name" db:"first_name"`
+	LastName       string `json:"last_name" db:"last_name"`
+	// One of the UserStatus values
+	Status string `json:"status" db:"status"`
+	// When a suspension lifts (nil for permanent bans and users who aren't banned)
+	BannedUntil  *time.Time `json:"banned_until,omitempty" db:"banned_until"`
+	Shadowbanned bool       `json:"shadowbanned" db:"shadowbanned"`
+	TokenVersion int        `json:"-" db:"token_version"`
+}
+
+// Account statuses (users.status), set by bans, suspensions and unbans
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
+
+// Reports whether the user is banned at a time. Suspensions stop counting as soon as they end, even
+// before the expiry job lifts them.
+func (u *User) IsBanned(at time.Time) bool {
+	if u.Status != UserStatusBanned && u.Status != UserStatusSuspended {
+		return false
+	}
+	return u.BannedUntil == nil || u.BannedUntil.After(at)
+}
+
+// The user's account status at a time (active once a suspension has ended, before the job records it)
+func (u *User) StatusAt(at time.Time) string {
+	if !u.IsBanned(at) {
+		return UserStatusActive
+	}
+	return u.Status
+}
+
+type EmailChangeRequest struct {
+	TokenHash string    `json:"-" db:"token_hash"`
+	UserId    int64     `json:"user_id" db:"user_id"`
+	NewEmail  string    `json:"new_email" db:"new_email"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// A pending password reset (only the hash of the emailed token is kept)
+type PasswordReset struct {
+	TokenHash string    `json:"-" db:"token_hash"`
+	UserId    int64     `json:"user_id" db:"user_id"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// An account a password reset link can be sent to
+type PasswordResetRecipient struct {
+	UserId   int64
+	Username string
+	Email    string
+}
+
+type EmailHistoryEntry struct {
+	Email     string    `json:"email" db:"email"`
+	ChangedAt time.Time `json:"changed_at" db:"changed_at"`
+}
+
+// Filters, sorting and pagination for post listings
+type PostFilter struct {
+	UserId int64
+	Author string
+	Search string
+	// BCP 47 tag; matches posts in that language and its regional variants (en matches en-GB)
+	Language string
+	Sort     string
+	Limit    int
+	Offset   int
+	// Signed-in user making the request (0 if anonymous); shadowbanned users still see their own posts
+	ViewerId int64
+	// Leave out posts the viewer muted
+	HideMuted bool
+}
+
+// Filters, sorting and pagination for comment listings
+type CommentFilter struct {
+	UserId int64
+	PostId int64
+	Sort   string
+	Limit  int
+	Offset int
+	// Signed-in user making the request (0 if anonymous); shadowbanned users still see their own comments
+	ViewerId int64
+}
+
+// Date format used by the metrics endpoints
+const MetricsDateLayout = "2006-01-02"
+
+// Active users on one day (WAU/MAU are rolling 7/30 day windows ending that day)
+type ActiveUsersPoint struct {
+	Date string `json:"date"`
+	DAU  int    `json:"dau"`
+	WAU  int    `json:"wau"`
+	MAU  int    `json:"mau"`
+}
+
+// New users, posts and comments on one day
+type GrowthPoint struct {
+	Date     string `json:"date"`
+	Users    int    `json:"users"`
+	Posts    int    `json:"posts"`
+	Comments int    `json:"comments"`
+}
+
+// Domain event types (named subject.verb)
+const (
+	EventUserRegistered      = "user.registered"
+	EventUserPasswordChanged = "user.password_changed"
+	EventUserEmailChanged    = "user.email_changed"
+	EventUserDeleted         = "user.deleted"
+	EventUserMerged          = "user.merged"
+	EventUserImpersonated    = "user.impersonated"
+	EventPostCreated         = "post.created"
+	EventPostUpdated         = "post.updated"
+	EventPostDeleted         = "post.deleted"
+	EventCommentCreated      = "comment.created"
+	EventCommentUpdated      = "comment.updated"
+	EventCommentDeleted      = "comment.deleted"
+	EventReportCreated       = "report.created"
+	EventModerationAction    = "moderation.action"
+)
+
+// Kinds of record an event is about
+const (
+	EventSubjectUser    = "user"
+	EventSubjectPost    = "post"
+	EventSubjectComment = "comment"
+	EventSubjectReport  = "report"
+)
+
+// Something that happened to a record, with the user who caused it
+type Event struct {
+	EventId     int64           `json:"event_id" db:"event_id"`
+	Type        string          `json:"type" db:"event_type"`
+	ActorId     *int64          `json:"actor_id" db:"actor_id"`
+	SubjectType string          `json:"subject_type" db:"subject_type"`
+	SubjectId   int64           `json:"subject_id" db:"subject_id"`
+	Payload     json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+}
+
+// Filters and paging for the event log (newest first)
+type EventFilter struct {
+	// Exact type, or a prefix ending in ".*" (e.g. "post.*")
+	Type    string
+	ActorId int64
+	// Only events older than this event ID (0 starts from the newest)
+	Before int64
+	Limit  int
+	Offset int
+}
+
+// Which events to replay to a consumer (oldest first)
+type EventReplayFilter struct {
+	// Exact types, or prefixes ending in ".*" (every type when empty)
+	Types []string
+	// Only events created at or after Since and before Until (either may be nil)
+	Since *time.Time
+	Until *time.Time
+	// Only events newer than this event ID, to resume a replay that stopped part way
+	AfterId int64
+}
+
+// Kinds of personal data whose viewing is recorded in the data access log
+const (
+	DataAccessUser              = "user"
+	DataAccessEmailHistory      = "email_history"
+	DataAccessModerationHistory = "moderation_history"
+	DataAccessReportExport      = "report_export"
+	DataAccessImpersonation     = "impersonation"
+)
+
+// An admin viewing a user's personal data
+type DataAccess struct {
+	AccessId int64 `json:"access_id" db:"access_id"`
+	// nil for bulk exports covering many users
+	UserId    *int64    `json:"user_id" db:"user_id"`
+	ViewerId  int64     `json:"viewer_id" db:"viewer_id"`
+	Access    string    `json:"access" db:"access"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Filters and paging for the data access log (newest first)
+type DataAccessFilter struct {
+	UserId   int64
+	ViewerId int64
+	// Only entries older than this access ID (0 starts from the newest)
+	Before int64
+	Limit  int
+	Offset int
+}
+
+// Rows purged by the cleanup job, per table
+type CleanupCounts struct {
+	EmailChangeRequests int64 `json:"email_change_requests"`
+	Signups             int64 `json:"signups"`
+	RefreshTokens       int64 `json:"refresh_tokens"`
+	PasswordResets      int64 `json:"password_resets"`
+	Sessions            int64 `json:"sessions"`
+}
+
+// What the cleanup job has removed: on its latest run and in total since the service started
+type CleanupStats struct {
+	LastRunAt  *time.Time    `json:"last_run_at"`
+	LastRun    CleanupCounts `json:"last_run"`
+	Total      CleanupCounts `json:"total"`
+	Runs       int           `json:"runs"`
+	FailedRuns int           `json:"failed_runs"`
+}
+
+// How the database's schema version compares to the one the code expects
+const (
+	MigrationUpToDate = "up_to_date"
+	// The database was built from an older database.sql
+	MigrationPending = "pending"
+	// The database is newer than this build of the service
+	MigrationAhead = "ahead"
+	// The database has no schema_version table
+	MigrationUnknown = "unknown"
+)
+
+// Database detail for the verbose readiness check, to speed up incident triage
+type DatabaseStatus struct {
+	ServerVersion         string `json:"server_version"`
+	SchemaVersion         *int   `json:"schema_version"`
+	ExpectedSchemaVersion int    `json:"expected_schema_version"`
+	MigrationStatus       string `json:"migration_status"`
+	// Whether this connection is to a standby replica
+	InRecovery bool `json:"in_recovery"`
+	// On a replica: seconds since the last replayed transaction (nil when nothing has been replayed)
+	ReplicationLagSeconds *float64 `json:"replication_lag_seconds"`
+	// On a primary: the replicas streaming from it
+	Replicas []ReplicaStatus `json:"replicas"`
+	Pool     PoolStats       `json:"pool"`
+	Tables   []TableStats    `json:"tables"`
+}
+
+// A replica streaming from the primary (lag is nil when the database user can't see it or it's idle)
+type ReplicaStatus struct {
+	Name             string   `json:"name"`
+	ClientAddr       *string  `json:"client_addr"`
+	State            string   `json:"state"`
+	ReplayLagSeconds *float64 `json:"replay_lag_seconds"`
+}
+
+// Connection pool usage (Saturation is InUse / MaxOpen, nil when the pool is unbounded)
+type PoolStats struct {
+	MaxOpen        int      `json:"max_open"`
+	Open           int      `json:"open"`
+	InUse          int      `json:"in_use"`
+	Idle           int      `json:"idle"`
+	Saturation     *float64 `json:"saturation"`
+	WaitCount      int64    `json:"wait_count"`
+	WaitDurationMs int64    `json:"wait_duration_ms"`
+}
+
+// Planner statistics for a table (row counts are estimates, refreshed by autovacuum/analyze)
+type TableStats struct {
+	Name            string     `json:"name"`
+	EstimatedRows   int64      `json:"estimated_rows"`
+	DeadRows        int64      `json:"dead_rows"`
+	TotalBytes      int64      `json:"total_bytes"`
+	LastAutovacuum  *time.Time `json:"last_autovacuum"`
+	LastAutoanalyze *time.Time `json:"last_autoanalyze"`
+}
+
+// A ranked user on the community leaderboard
+type LeaderboardEntry struct {
+	Rank         int    `json:"rank"`
+	UserId       int64  `json:"user_id"`
+	Username     string `json:"username"`
+	Karma        int    `json:"karma"`
+	PostCount    int    `json:"post_count"`
+	CommentCount int    `json:"comment_count"`
+}
+
+// A skill tag and how many members list it
+type SkillCount struct {
+	Name    string `json:"name"`
+	Members int    `json:"members"`
+}
+
+// A post offered by the search box typeahead
+type PostSuggestion struct {
+	PostId int64  `json:"post_id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+// Quick results for the search box, grouped by kind
+type SearchSuggestions struct {
+	Posts []PostSuggestion `json:"posts"`
+	Tags  []SkillCount     `json:"tags"`
+	Users []Author         `json:"users"`
+	// Set when a group took too long and was left empty, so clients know the results may be incomplete
+	Partial bool `json:"partial"`
+}
+
+// What a skill rule does with a tag
+const (
+	// Saved as the rule's canonical skill instead ("golang" -> "go")
+	SkillRuleSynonym = "synonym"
+	// Rejected when saved and removed from profiles
+	SkillRuleBlock = "block"
+)
+
+// An admin rule for a skill tag
+type SkillRule struct {
+	Name   string `json:"name" db:"name"`
+	Action string `json:"action" db:"action"`
+	// The canonical skill (synonyms only)
+	Skill     string    `json:"skill,omitempty" db:"canonical"`
+	CreatedBy *int64    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// What the skill migration job changed on existing profiles
+type SkillMigrationResult struct {
+	// Profile tags rewritten to their canonical skill (or dropped where the profile already had it)
+	Moved int64 `json:"moved"`
+	// Profile tags removed because they're blocked
+	Removed int64 `json:"removed"`
+}
+
+// A project showcased on a user's profile
+type Project struct {
+	ProjectId     int64     `json:"project_id" db:"project_id"`
+	UserId        int64     `json:"user_id" db:"user_id"`
+	Title         string    `json:"title" db:"title"`
+	Description   string    `json:"description" db:"description"`
+	RepoURL       string    `json:"repo_url" db:"repo_url"`
+	ScreenshotURL string    `json:"screenshot_url" db:"screenshot_url"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Report workflow states
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusReviewing = "reviewing"
+	ReportStatusActioned  = "actioned"
+	ReportStatusDismissed = "dismissed"
+)
+
+// Kinds of content that can be reported
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+)
+
+// A reason users can pick when reporting content (managed by admins)
+type ReportReason struct {
+	Code   string `json:"code" db:"code"`
+	Label  string `json:"label" db:"label"`
+	Active bool   `json:"active" db:"active"`
+}
+
+// A user report about a post or comment
+type Report struct {
+	ReportId       int64  `json:"report_id" db:"report_id"`
+	ReporterId     int64  `json:"reporter_id" db:"reporter_id"` // 0 for automatic reports
+	TargetType     string `json:"target_type" db:"target_type"`
+	TargetId       int64  `json:"target_id" db:"target_id"`
+	ReasonCode     string `json:"reason" db:"reason_code"`
+	Details        string `json:"details" db:"details"`
+	Status         string `json:"status" db:"status"`
+	AssignedTo     *int64 `json:"assigned_to" db:"assigned_to"`
+	ResolutionNote string `json:"resolution_note" db:"resolution_note"`
+	// Set while the report is actioned or dismissed
+	ResolvedBy *int64     `json:"resolved_by" db:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolved_at" db:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Reports whether a report status ends the workflow (actioned or dismissed)
+func IsReportResolved(status string) bool {
+	return status == ReportStatusActioned || status == ReportStatusDismissed
+}
+
+// One moderator's workload over a range of days
+type ModeratorStats struct {
+	ModeratorId int64  `json:"moderator_id"`
+	Username    string `json:"username"`
+	// Moderation actions taken, in total and by action
+	ActionCount int            `json:"action_count"`
+	Actions     map[string]int `json:"actions"`
+	// Reports resolved, how many of them were actioned, and the time from filing to resolution in
+	// seconds (nil without resolved reports)
+	ReportsResolved         int      `json:"reports_resolved"`
+	ReportsActioned         int      `json:"reports_actioned"`
+	AvgResolutionSeconds    *float64 `json:"avg_resolution_seconds"`
+	MedianResolutionSeconds *float64 `json:"median_resolution_seconds"`
+	// Appeals against the moderator's actions decided in the range
+	AppealsUpheld     int `json:"appeals_upheld"`
+	AppealsOverturned int `json:"appeals_overturned"`
+}
+
+// Filters, sorting and pagination for the admin report listing
+type ReportFilter struct {
+	Status     string
+	ReasonCode string
+	TargetType string
+	AssignedTo int64
+	Unassigned bool
+	// Created on or after From and before To (zero values don't filter)
+	From   time.Time
+	To     time.Time
+	Sort   string
+	Limit  int
+	Offset int
+}
+
+// Moderation actions
+const (
+	ModerationHide   = "hide"
+	ModerationUnhide = "unhide"
+	ModerationLock   = "lock"
+	ModerationUnlock = "unlock"
+	ModerationBan    = "ban"
+	ModerationUnban  = "unban"
+	// A ban that lifts at the action's ExpiresAt
+	ModerationSuspend = "suspend"
+	// Shadowbans are never shown to the affected user
+	ModerationShadowban   = "shadowban"
+	ModerationUnshadowban = "unshadowban"
+	// Clears an inappropriate display name (the user's posts and comments go back to their username)
+	ModerationResetName = "reset_display_name"
+)
+
+// Kinds of targets a moderation action can apply to (besides posts and comments)
+const ModerationTargetUser = "user"
+
+// A recorded moderation action (the audit trail)
+type ModerationAction struct {
+	ActionId     int64  `json:"action_id" db:"action_id"`
+	ActorId      *int64 `json:"actor_id" db:"actor_id"`
+	Action       string `json:"action" db:"action"`
+	TargetType   string `json:"target_type" db:"target_type"`
+	TargetId     int64  `json:"target_id" db:"target_id"`
+	TargetUserId int64  `json:"target_user_id" db:"target_user_id"`
+	Reason       string `json:"reason" db:"reason"`
+	ReportId     *int64 `json:"report_id" db:"report_id"`
+	// When a suspension ends (nil for every other action)
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Filters and pagination for the moderation audit trail
+type ModerationActionFilter struct {
+	TargetUserId int64
+	Action       string
+	Limit        int
+	Offset       int
+	// Leave out actions the affected user shouldn't know about (shadowbans)
+	VisibleToTarget bool
+}
+
+// Appeal states
+const (
+	AppealStatusPending    = "pending"
+	AppealStatusUpheld     = "upheld"
+	AppealStatusOverturned = "overturned"
+)
+
+// Filters and pagination for appeal listings
+type AppealFilter struct {
+	Status string
+	UserId int64
+	Limit  int
+	Offset int
+}
+
+// A user's appeal against a moderation action
+type Appeal struct {
+	AppealId       int64      `json:"appeal_id" db:"appeal_id"`
+	ActionId       int64      `json:"action_id" db:"action_id"`
+	UserId         int64      `json:"user_id" db:"user_id"`
+	Message        string     `json:"message" db:"message"`
+	Status         string     `json:"status" db:"status"`
+	ResolvedBy     *int64     `json:"resolved_by" db:"resolved_by"`
+	ResolutionNote string     `json:"resolution_note" db:"resolution_note"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt     *time.Time `json:"resolved_at" db:"resolved_at"`
+}
+
+// Events users can be notified about
+const (
+	// Someone commented on your post
+	NotifyComment = "comment"
+	// A moderator acted on your content or account, or decided your appeal
+	NotifyModeration = "moderation"
+	// The top posts digest (email only)
+	NotifyDigest = "digest"
+	// A new post mentioned one of your alert keywords
+	NotifyKeyword = "keyword"
+)
+
+// How a user wants to hear about one kind of event
+type NotificationSetting struct {
+	EventType string `json:"event_type" db:"event_type"`
+	Email     bool   `json:"email" db:"email"`
+	InApp     bool   `json:"in_app" db:"in_app"`
+}
+
+// An in-app notification
+type Notification struct {
+	NotificationId int64      `json:"notification_id" db:"notification_id"`
+	UserId         int64      `json:"user_id" db:"user_id"`
+	EventType      string     `json:"event_type" db:"event_type"`
+	Message        string     `json:"message" db:"message"`
+	Link           string     `json:"link" db:"link"`
+	ReadAt         *time.Time `json:"read_at" db:"read_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// How many of a user's notifications are unread, and the newest of them (0 when there are none)
+type UnreadNotifications struct {
+	Count    int   `json:"unread_count"`
+	LatestId int64 `json:"latest_unread_id"`
+}
+
+// Whether a user is notified about new comments on a post
+type PostWatch struct {
+	PostId   int64 `json:"post_id"`
+	Watching bool  `json:"watching"`
+	Muted    bool  `json:"muted"`
+}
+
+// Announcement levels (clients pick the banner style from them)
+const (
+	AnnouncementInfo     = "info"
+	AnnouncementWarning  = "warning"
+	AnnouncementCritical = "critical"
+)
+
+// A site-wide banner posted by admins
+type Announcement struct {
+	AnnouncementId int64      `json:"announcement_id" db:"announcement_id"`
+	Title          string     `json:"title" db:"title"`
+	Message        string     `json:"message" db:"message"`
+	Level          string     `json:"level" db:"level"`
+	StartsAt       time.Time  `json:"starts_at" db:"starts_at"`
+	ExpiresAt      *time.Time `json:"expires_at" db:"expires_at"` // nil until an admin sets or triggers expiry
+	CreatedBy      *int64     `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Backup states
+const (
+	BackupRunning   = "running"
+	BackupSucceeded = "succeeded"
+	BackupFailed    = "failed"
+	// The archive was deleted to keep only the newest backups
+	BackupPruned = "pruned"
+)
+
+// A logical database backup (a pg_dump archive in backup storage)
+type Backup struct {
+	BackupId    int64      `json:"backup_id" db:"backup_id"`
+	StorageKey  string     `json:"storage_key" db:"storage_key"`
+	Status      string     `json:"status" db:"status"`
+	SizeBytes   int64      `json:"size_bytes" db:"size_bytes"`
+	Error       string     `json:"error,omitempty" db:"error"`
+	TriggeredBy *int64     `json:"triggered_by" db:"triggered_by"` // nil for scheduled backups
+	StartedAt   time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at" db:"finished_at"`
+}
+
+// A machine client (e.g. an internal cron service) that gets tokens through the client credentials grant
+type ServiceClient struct {
+	ClientId   string     `json:"client_id" db:"client_id"`
+	Name       string     `json:"name" db:"name"`
+	SecretHash string     `json:"-" db:"secret_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	CreatedBy  *int64     `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// A long-lived key a user's scripts and services send in the X-API-Key header instead of a JWT.
+// Requests made with it act as the user, with their current role.
+type APIKey struct {
+	KeyId      int64      `json:"key_id" db:"key_id"`
+	UserId     int64      `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// Scopes a service client can be granted; each unlocks a group of admin endpoints
+const (
+	// Reindex, cache flush, job status and read-only mode
+	ScopeMaintenance = "maintenance"
+	// List and start database backups
+	ScopeBackups = "backups"
+	// Read the admin metrics
+	ScopeMetrics = "metrics"
+	// Read the domain event log
+	ScopeEvents = "events"
+	// Manage site-wide announcements
+	ScopeAnnouncements = "announcements"
+)
+
+// Every scope a service client can be granted
+var ServiceScopes = []string{ScopeMaintenance, ScopeBackups, ScopeMetrics, ScopeEvents, ScopeAnnouncements}
+
+// Filters and pagination for a user's notifications
+type NotificationFilter struct {
+	UserId     int64
+	UnreadOnly bool
+	Limit      int
+	Offset     int
+}
+
+// How often a user receives the top posts digest
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// A user's digest email preference
+type DigestSettings struct {
+	UserId     int64      `json:"user_id" db:"user_id"`
+	Frequency  string     `json:"frequency" db:"frequency"`
+	LastSentAt *time.Time `json:"last_sent_at" db:"last_sent_at"`
+}
+
+// A subscriber whose digest is due, with the address to send it to
+type DigestRecipient struct {
+	DigestSettings
+	Username string
+	Email    string
+}
+
+// A post featured in a digest (score is comments from other users in the digest period)
+type DigestPost struct {
+	PostId     int64     `json:"post_id"`
+	Title      string    `json:"title"`
+	Author     string    `json:"author"`
+	Score      int       `json:"comments"`
+	DatePosted time.Time `json:"date_posted"`
+}
+
+// A member featured in a site digest: a top commenter (with their comment count) or a new member
+type DigestMember struct {
+	UserId int64  `json:"user_id"`
+	Author string `json:"author"`
+	// Comments written in the digest period (top commenters only)
+	Comments       int        `json:"comments,omitempty"`
+	DateRegistered *time.Time `json:"date_registered,omitempty"`
+}
+
+// Site activity summary for a period, regenerated on a schedule for homepage "this week" modules
+type SiteDigest struct {
+	Period         string         `json:"period"`
+	Since          time.Time      `json:"since"`
+	GeneratedAt    time.Time      `json:"generated_at"`
+	TopPosts       []DigestPost   `json:"top_posts"`
+	TopCommenters  []DigestMember `json:"top_commenters"`
+	NewMembers     []DigestMember `json:"new_members"`
+	NewMemberCount int            `json:"new_member_count"`
+}
+
+// Word filter actions
+const (
+	WordFilterBlock   = "block"
+	WordFilterFlag    = "flag"
+	WordFilterReplace = "replace"
+)
+
+// Report reason used when the word filter flags content
+const ReportReasonWordFilter = "word_filter"
+
+// An admin-managed banned word or pattern
+type WordFilter struct {
+	FilterId    int64     `json:"filter_id" db:"filter_id"`
+	Pattern     string    `json:"pattern" db:"pattern"`
+	IsRegex     bool      `json:"is_regex" db:"is_regex"`
+	Action      string    `json:"action" db:"action"`
+	Replacement string    `json:"replacement" db:"replacement"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// A code snippet attached to a post
+type Snippet struct {
+	SnippetId int64     `json:"snippet_id" db:"snippet_id"`
+	PostId    int64     `json:"post_id" db:"post_id"`
+	UserId    int64     `json:"user_id" db:"user_id"`
+	Filename  string    `json:"filename" db:"filename"`
+	Language  string    `json:"language" db:"language"`
+	Body      string    `json:"body" db:"body"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// An uploaded file (the contents live in the attachment store under StorageKey)
+type Attachment struct {
+	AttachmentId int64     `json:"attachment_id" db:"attachment_id"`
+	UserId       int64     `json:"user_id" db:"user_id"`
+	Filename     string    `json:"filename" db:"filename"`
+	ContentType  string    `json:"content_type" db:"content_type"`
+	SizeBytes    int64     `json:"size_bytes" db:"size_bytes"`
+	StorageKey   string    `json:"-" db:"storage_key"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	// When a post or comment first linked it (nil until then)
+	LinkedAt *time.Time `json:"linked_at" db:"linked_at"`
+	// When it's deleted unless something links it first (nil once linked, or when unlinked uploads are kept)
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"-"`
+}
+
+// How much attachment storage a user is using (QuotaBytes 0 means unlimited)
+type StorageUsage struct {
+	UsedBytes       int64 `json:"used_bytes"`
+	QuotaBytes      int64 `json:"quota_bytes"`
+	AttachmentCount int   `json:"attachment_count"`
+}
+
+// What an account merge moved to the surviving account (or would move, for a dry run).
+// Followers, following, watches and skills count only those the survivor didn't already have.
+type AccountMergeSummary struct {
+	SourceId          int64 `json:"source_id"`
+	TargetId          int64 `json:"target_id"`
+	DryRun            bool  `json:"dry_run"`
+	Posts             int64 `json:"posts"`
+	Comments          int64 `json:"comments"`
+	Snippets          int64 `json:"snippets"`
+	Attachments       int64 `json:"attachments"`
+	Projects          int64 `json:"projects"`
+	Skills            int64 `json:"skills"`
+	Followers         int64 `json:"followers"`
+	Following         int64 `json:"following"`
+	Watches           int64 `json:"watches"`
+	Notifications     int64 `json:"notifications"`
+	Reports           int64 `json:"reports"`
+	ModerationActions int64 `json:"moderation_actions"`
+}
+
+// A keyword a user wants to be notified about when it appears in new posts
+type KeywordAlert struct {
+	AlertId int64  `json:"alert_id" db:"alert_id"`
+	UserId  int64  `json:"user_id" db:"user_id"`
+	Keyword string `json:"keyword" db:"keyword"`
+	// Newest post the matcher has checked for this alert
+	LastPostId     int64      `json:"-" db:"last_post_id"`
+	LastNotifiedAt *time.Time `json:"last_notified_at" db:"last_notified_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// New posts that mentioned an alert's keyword (Count 0 when none did)
+type KeywordMatches struct {
+	Count int
+	// The newest matching post
+	PostId int64
+	Title  string
+}
+
+// An advisory lock held by whoever is editing a post. The token is only returned to the session
+// that acquired the lock; it has to be sent back to renew or release the lock and to save the post.
+type PostEditLock struct {
+	PostId int64 `json:"post_id" db:"post_id"`
+	UserId int64 `json:"user_id" db:"user_id"`
+	// Display name (or username) of the editor holding the lock
+	LockedBy   string    `json:"locked_by"`
+	LockToken  string    `json:"lock_token,omitempty"`
+	AcquiredAt time.Time `json:"acquired_at" db:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// A fediverse account (on Mastodon or another ActivityPub server) following a local author
+type FederationFollower struct {
+	UserId int64 `db:"user_id"`
+	// The remote actor's ActivityPub ID
+	ActorId  string `db:"actor_id"`
+	InboxURL string `db:"inbox_url"`
+	// The remote server's inbox for all its users (empty when it has none)
+	SharedInboxURL string `db:"shared_inbox_url"`
+}
+
+// A signed activity waiting to be delivered to a remote inbox
+type FederationDelivery struct {
+	DeliveryId int64           `db:"delivery_id"`
+	UserId     int64           `db:"user_id"`
+	InboxURL   string          `db:"inbox_url"`
+	Activity   json.RawMessage `db:"activity"`
+	// Attempts made so far, including the one in progress
+	Attempts int `db:"attempts"`
 }
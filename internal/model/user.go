@@ -1,11 +1,17 @@
 package model
 
+import "time"
+
 // Registration request body
 type RegisterRequest struct {
 	Username  string `json:"username"`
 	Password  string `json:"password"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
+	// Optional; saved on the profile and subject to the signup email limits
+	Email string `json:"email,omitempty"`
+	// Response from the hCaptcha/reCAPTCHA widget; required when the server has a CAPTCHA provider configured
+	CaptchaToken string `json:"captcha_token,omitempty"`
 }
 
 // Login request body
@@ -14,18 +20,254 @@ type LoginRequest struct {
 	Password string `json:"password"`
 }
 
+// Refresh request body
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Password change request body
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+	// Leave the user's other sessions and tokens signed in (by default every one is signed out)
+	KeepOtherSessions bool `json:"keep_other_sessions"`
+}
+
+// Forgot password request body
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// Password reset request body (the token comes from the reset email)
+type ResetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// One account in a bulk user import. Without a password hash the account is invited: it gets no password
+// and is emailed a link to choose one.
+type UserImportRow struct {
+	Username     string `json:"username"`
+	Email        string `json:"email"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	PasswordHash string `json:"password_hash,omitempty"`
+}
+
+// Bulk user import request body (JSON; CSV uploads use the same fields as column headers)
+type UserImportRequest struct {
+	Users []UserImportRow `json:"users"`
+}
+
+// What happened to a row of a user import
+const (
+	ImportCreated  = "created"
+	ImportInvited  = "invited"
+	ImportConflict = "conflict"
+	ImportInvalid  = "invalid"
+)
+
+// The outcome for one row of a user import (rows are numbered from 1)
+type UserImportResult struct {
+	Row      int    `json:"row"`
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	UserId   int64  `json:"user_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// A validated account being imported, and where its outcome is reported
+type UserImport struct {
+	User   User
+	Email  string
+	Result *UserImportResult
+}
+
+// Outcome of a user import batch
+type UserImportSummary struct {
+	DryRun    bool               `json:"dry_run"`
+	Created   int                `json:"created"`
+	Invited   int                `json:"invited"`
+	Conflicts int                `json:"conflicts"`
+	Invalid   int                `json:"invalid"`
+	Results   []UserImportResult `json:"results"`
+}
+
 // Authentication response
 type AuthResponse struct {
-	Token   string      `json:"token"`
-	User    UserSummary `json:"user"`
-	Profile interface{} `json:"profile"`
+	Token string `json:"token"`
+	// Exchanged at POST /api/auth/refresh for a new token pair once the access token expires
+	RefreshToken     string      `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time  `json:"refresh_expires_at,omitempty"`
+	User             UserSummary `json:"user"`
+	Profile          interface{} `json:"profile"`
+}
+
+// Admin impersonation request body
+type ImpersonationRequest struct {
+	// Why the admin is acting as the user (e.g. the support ticket), kept in the audit log
+	Reason string `json:"reason"`
+}
+
+// A token for acting as a user (no refresh token; start a new session once it expires)
+type ImpersonationResponse struct {
+	Token     string      `json:"token"`
+	SessionId int64       `json:"session_id"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	User      UserSummary `json:"user"`
+}
+
+// An access token with the refresh token that renews it
+type TokenPair struct {
+	AccessToken      string
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// A stored refresh token (identified by the hash of its value)
+type RefreshToken struct {
+	TokenHash    string
+	UserId       int64
+	FamilyId     string
+	TokenVersion int
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	RevokedAt    *time.Time
+}
+
+// A signed-in device: one login, refreshed for as long as its refresh tokens keep being exchanged
+type Session struct {
+	SessionId    int64      `json:"session_id" db:"session_id"`
+	UserId       int64      `json:"-" db:"user_id"`
+	FamilyId     string     `json:"-" db:"family_id"`
+	TokenVersion int        `json:"-" db:"token_version"`
+	IP           string     `json:"ip" db:"ip"`
+	UserAgent    string     `json:"user_agent" db:"user_agent"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt   time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt    *time.Time `json:"-" db:"revoked_at"`
+	// Set when an admin is acting as the user (shown to the user as impersonated)
+	ImpersonatorId *int64 `json:"-" db:"impersonator_id"`
+	Impersonated   bool   `json:"impersonated" db:"-"`
+	// Whether it's the session the request listing sessions was made with
+	Current bool `json:"current" db:"-"`
 }
 
 // Safe user data (no password)
 type UserSummary struct {
-	UserID    int    `json:"user_id"`
+	UserID    int64  `json:"user_id"`
 	Username  string `json:"username"`
 	Role      string `json:"role"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 }
+
+// Built-in roles: new accounts get RoleUser, and RoleAdmin grants every permission
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Permissions a role can grant
+const (
+	// Every permission, including the admin endpoints no narrower permission covers
+	PermissionAll = "*"
+	// Delete other users' posts and their snippets
+	PermissionPostsDelete = "posts:delete"
+	// Delete other users' comments
+	PermissionCommentsDelete = "comments:delete"
+	// Delete other users' accounts
+	PermissionUsersDelete = "users:delete"
+	// See members' private details (email, moderation status, report counts) and look up accounts
+	PermissionUsersRead = "users:read"
+	// Work the report queue: list, export, view, assign and resolve reports (and be assigned them)
+	PermissionReportsManage = "reports:manage"
+	// Take moderation actions (hiding content, warnings, bans) and resolve appeals
+	PermissionModerationAct = "moderation:act"
+)
+
+// Every permission a role can be granted
+var Permissions = []string{PermissionAll, PermissionPostsDelete, PermissionCommentsDelete, PermissionUsersDelete,
+	PermissionUsersRead, PermissionReportsManage, PermissionModerationAct}
+
+// Reports whether a set of granted permissions includes the given one (PermissionAll includes them all)
+func HasPermission(granted []string, permission string) bool {
+	for _, p := range granted {
+		if p == permission || p == PermissionAll {
+			return true
+		}
+	}
+	return false
+}
+
+// A role users can hold, with the permissions it grants
+type Role struct {
+	Name        string    `json:"name" db:"name"`
+	Description string    `json:"description" db:"description"`
+	Permissions []string  `json:"permissions" db:"-"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// Create or replace role request body
+type RoleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// Role change request body
+type UserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// Who a response about a member is shaped for; each audience sees everything the one before it does
+type Audience int
+
+const (
+	// Anyone, signed in or not
+	AudiencePublic Audience = iota
+	// The member themselves
+	AudienceOwner
+	// Staff whose role grants PermissionUsersRead
+	AudienceStaff
+)
+
+// Who is making a request, for shaping responses about members
+type Viewer struct {
+	// 0 for anonymous requests, which get no viewer-specific fields
+	UserId int64
+	// Whether their role grants PermissionUsersRead
+	Staff bool
+}
+
+// The audience the viewer is for an object belonging to a member
+func (v Viewer) AudienceFor(userId int64) Audience {
+	switch {
+	case v.Staff:
+		return AudienceStaff
+	case v.UserId != 0 && v.UserId == userId:
+		return AudienceOwner
+	}
+	return AudiencePublic
+}
+
+// Reports involving a member
+type MemberReportCounts struct {
+	// Reports on their posts and comments
+	Against int `json:"against"`
+	// Those still open or being reviewed
+	OpenAgainst int `json:"open_against"`
+	// Reports they filed
+	Filed int `json:"filed"`
+}
+
+// Details about a member only staff see, added to user and profile responses as "staff"
+type MemberDetails struct {
+	Email        string             `json:"email"`
+	Role         string             `json:"role"`
+	Banned       bool               `json:"banned"`
+	BannedUntil  *time.Time         `json:"banned_until,omitempty"`
+	Shadowbanned bool               `json:"shadowbanned"`
+	Reports      MemberReportCounts `json:"reports"`
+}
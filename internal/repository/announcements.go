@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// #region Announcements
+
+// Get every announcement, including scheduled and expired ones (newest first)
+func (db *DB) ListAnnouncements(limit, offset int) ([]model.Announcement, error) {
+	query, args := db.newSelect(announcementColumns, "announcements").
+		OrderBy("created_at DESC, announcement_id DESC").
+		Limit(limit).
+		Offset(offset).
+		Build()
+
+	return db.queryAnnouncements(query, args...)
+}
+
+// Get the announcements showing at a point in time, leaving out any the user dismissed (0 for anonymous users)
+func (db *DB) GetActiveAnnouncements(userId int64, at time.Time) ([]model.Announcement, error) {
+	query, args := db.newSelect(announcementColumns, "announcements").
+		Where("starts_at <= ?", at).
+		Where("(expires_at IS NULL OR expires_at > ?)", at).
+		Where("NOT EXISTS (SELECT 1 FROM announcement_dismissals d WHERE d.announcement_id = announcements.announcement_id AND d.user_id = ?)", userId).
+		OrderBy("starts_at DESC, announcement_id DESC").
+		Build()
+
+	return db.queryAnnouncements(query, args...)
+}
+
+// Runs a query selecting announcementColumns
+func (db *DB) queryAnnouncements(query string, args ...interface{}) ([]model.Announcement, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcements: %w", err)
+	}
+	defer rows.Close()
+
+	announcements := []model.Announcement{}
+	for rows.Next() {
+		announcement, err := scanAnnouncement(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan announcement: %w", err)
+		}
+		announcements = append(announcements, announcement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating announcements: %w", err)
+	}
+
+	return announcements, nil
+}
+
+// Get an announcement by ID
+func (db *DB) GetAnnouncementById(announcementId int64) (*model.Announcement, error) {
+	query := "SELECT " + announcementColumns + " FROM announcements WHERE announcement_id = $1"
+
+	announcement, err := scanAnnouncement(db.QueryRow(query, announcementId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("announcement %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcement: %w", err)
+	}
+
+	return &announcement, nil
+}
+
+// Create an announcement
+func (db *DB) CreateAnnouncement(announcement *model.Announcement) error {
+	query := `
+		INSERT INTO announcements (title, message, level, starts_at, expires_at, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING announcement_id
+	`
+
+	err := db.QueryRow(query, announcement.Title, announcement.Message, announcement.Level, announcement.StartsAt, announcement.ExpiresAt,
+		announcement.CreatedBy, announcement.CreatedAt, announcement.UpdatedAt).Scan(&announcement.AnnouncementId)
+	if err != nil {
+		return fmt.Errorf("failed to create announcement: %w", err)
+	}
+
+	return nil
+}
+
+// Update an announcement's content and schedule
+func (db *DB) UpdateAnnouncement(announcement *model.Announcement) error {
+	query := `
+		UPDATE announcements
+		SET title = $2, message = $3, level = $4, starts_at = $5, expires_at = $6, updated_at = $7
+		WHERE announcement_id = $1
+	`
+
+	result, err := db.Exec(query, announcement.AnnouncementId, announcement.Title, announcement.Message, announcement.Level,
+		announcement.StartsAt, announcement.ExpiresAt, announcement.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update announcement: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("announcement %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Hide an announcement for a user from now on (dismissing twice is a no-op)
+func (db *DB) DismissAnnouncement(userId, announcementId int64) error {
+	query := `
+		INSERT INTO announcement_dismissals (user_id, announcement_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := db.Exec(query, userId, announcementId); err != nil {
+		return fmt.Errorf("failed to dismiss announcement: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
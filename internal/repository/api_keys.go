@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// #region API keys
+
+// Get a user's API keys (including revoked ones), newest first
+func (db *DB) GetAPIKeys(userId int64) ([]model.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC, key_id DESC"
+
+	rows, err := db.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Create an API key unless the user already has maxActive unrevoked keys (reports whether it was created)
+func (db *DB) CreateAPIKey(key *model.APIKey, maxActive int) (bool, error) {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, created_at)
+		SELECT $1, $2, $3, $4, $5
+		WHERE (SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND revoked_at IS NULL) < $6
+		RETURNING key_id
+	`
+
+	rows, err := db.Query(query, key.UserId, key.Name, key.Prefix, key.KeyHash, key.CreatedAt, maxActive)
+	if isUniqueViolation(err) {
+		return false, fmt.Errorf("API key %w", ErrConflict)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create API key: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+	if err := rows.Scan(&key.KeyId); err != nil {
+		return false, fmt.Errorf("failed to scan API key: %w", err)
+	}
+
+	return true, nil
+}
+
+// Get an API key by the hash of the key (including revoked ones)
+func (db *DB) GetAPIKeyByHash(keyHash string) (*model.APIKey, error) {
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE key_hash = $1"
+
+	key, err := scanAPIKey(db.QueryRow(query, keyHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("API key %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API key: %w", err)
+	}
+
+	return &key, nil
+}
+
+// Revoke one of a user's API keys; requests sending it are rejected from then on
+func (db *DB) RevokeAPIKey(userId, keyId int64, at time.Time) error {
+	result, err := db.Exec("UPDATE api_keys SET revoked_at = $3 WHERE key_id = $1 AND user_id = $2 AND revoked_at IS NULL", keyId, userId, at)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("active API key %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Revoke every active API key a user has (run with a password change or reset, in its transaction)
+func revokeAPIKeys(tx execer, userId int64, at time.Time) error {
+	if _, err := tx.Exec("UPDATE api_keys SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL", userId, at); err != nil {
+		return fmt.Errorf("failed to revoke API keys: %w", err)
+	}
+	return nil
+}
+
+// Record when an API key was last used
+func (db *DB) TouchAPIKey(keyId int64, at time.Time) error {
+	if _, err := db.Exec("UPDATE api_keys SET last_used_at = $2 WHERE key_id = $1", keyId, at); err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// #region Attachments
+
+// Record an attachment, taking a reference on the blob holding its contents (StorageKey), and add its
+// size to the owner's storage use unless that would take them over quotaBytes (0 for no quota).
+// Reports whether the attachment fit within the quota. Quotas count every attachment at its full
+// size, even when its contents are shared with other uploads.
+func (db *DB) CreateAttachment(attachment *model.Attachment, quotaBytes int64) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Checked and reserved in one statement so concurrent uploads can't both squeeze under the quota
+	reserve := `
+		UPDATE users SET storage_used_bytes = storage_used_bytes + $2
+		WHERE user_id = $1 AND ($3 <= 0 OR storage_used_bytes + $2 <= $3)
+	`
+
+	result, err := tx.Exec(reserve, attachment.UserId, attachment.SizeBytes, quotaBytes)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve storage: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	// Waits for a garbage collection of the same blob to finish, then recreates the row
+	blob := `
+		INSERT INTO attachment_blobs (content_hash, size_bytes, ref_count, created_at)
+		VALUES ($1, $2, 1, $3)
+		ON CONFLICT (content_hash) DO UPDATE SET ref_count = attachment_blobs.ref_count + 1, unreferenced_at = NULL
+	`
+
+	if _, err := tx.Exec(blob, attachment.StorageKey, attachment.SizeBytes, attachment.CreatedAt); err != nil {
+		return false, fmt.Errorf("failed to reference attachment blob: %w", err)
+	}
+
+	query := `
+		INSERT INTO attachments (user_id, filename, content_type, size_bytes, storage_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING attachment_id
+	`
+
+	err = tx.QueryRow(query, attachment.UserId, attachment.Filename, attachment.ContentType, attachment.SizeBytes, attachment.StorageKey, attachment.CreatedAt).
+		Scan(&attachment.AttachmentId)
+	if err != nil {
+		return false, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit attachment: %w", err)
+	}
+
+	return true, nil
+}
+
+// Get attachment by ID
+func (db *DB) GetAttachment(attachmentId int64) (*model.Attachment, error) {
+	query := "SELECT " + attachmentColumns + " FROM attachments WHERE attachment_id = $1"
+
+	attachment, err := scanAttachment(db.QueryRow(query, attachmentId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("attachment %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachment: %w", err)
+	}
+
+	return &attachment, nil
+}
+
+// Get a user's attachments (newest first)
+func (db *DB) GetAttachmentsByUser(userId int64, limit, offset int) ([]model.Attachment, error) {
+	query, args := db.newSelect(attachmentColumns, "attachments").
+		Where("user_id = ?", userId).
+		OrderBy("created_at DESC, attachment_id DESC").
+		Limit(limit).
+		Offset(offset).
+		Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []model.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachments: %w", err)
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// Delete an attachment record, drop its blob reference and give its size back to the owner's storage allowance
+func (db *DB) DeleteAttachment(attachmentId int64) error {
+	deleted, err := db.deleteAttachment("attachment_id = $1", attachmentId)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("attachment %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Delete an attachment as DeleteAttachment does if nothing has linked it and it was uploaded before the cutoff.
+// Reports whether it was deleted (false when it has been linked since, or is already gone).
+func (db *DB) DeleteUnlinkedAttachment(attachmentId int64, before time.Time) (bool, error) {
+	return db.deleteAttachment("attachment_id = $1 AND linked_at IS NULL AND created_at < $2", attachmentId, before)
+}
+
+// Delete the attachment matching condition, releasing its storage and blob reference (reports whether one matched)
+func (db *DB) deleteAttachment(condition string, args ...interface{}) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userId int64
+	var size int64
+	var storageKey string
+	err = tx.QueryRow("DELETE FROM attachments WHERE "+condition+" RETURNING user_id, size_bytes, storage_key", args...).
+		Scan(&userId, &size, &storageKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	release := "UPDATE users SET storage_used_bytes = GREATEST(storage_used_bytes - $2, 0) WHERE user_id = $1"
+	if _, err := tx.Exec(release, userId, size); err != nil {
+		return false, fmt.Errorf("failed to release storage: %w", err)
+	}
+
+	if err := releaseBlobs(tx, time.Now(), "SELECT UNNEST($2::text[])", pq.Array([]string{storageKey})); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit attachment deletion: %w", err)
+	}
+
+	return true, nil
+}
+
+// Stamp the attachments with these IDs as linked (those already linked keep their first link time)
+func (db *DB) LinkAttachments(attachmentIds []int64, at time.Time) error {
+	query := "UPDATE attachments SET linked_at = $2 WHERE attachment_id = ANY($1) AND linked_at IS NULL"
+
+	if _, err := db.Exec(query, pq.Array(attachmentIds), at); err != nil {
+		return fmt.Errorf("failed to link attachments: %w", err)
+	}
+
+	return nil
+}
+
+// Get attachments nothing has linked that were uploaded before the cutoff (oldest first)
+func (db *DB) GetUnlinkedAttachments(before time.Time, limit int) ([]model.Attachment, error) {
+	query, args := db.newSelect(attachmentColumns, "attachments").
+		Where("linked_at IS NULL").
+		Where("created_at < ?", before).
+		OrderBy("created_at, attachment_id").
+		Limit(limit).
+		Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unlinked attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []model.Attachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan unlinked attachments: %w", err)
+		}
+
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, nil
+}
+
+// Get how much storage a user's attachments take up (QuotaBytes is left for the caller)
+func (db *DB) GetStorageUsage(userId int64) (*model.StorageUsage, error) {
+	query := `
+		SELECT u.storage_used_bytes, (SELECT COUNT(*) FROM attachments a WHERE a.user_id = u.user_id)
+		FROM users u
+		WHERE u.user_id = $1
+	`
+
+	var usage model.StorageUsage
+	err := db.QueryRow(query, userId).Scan(&usage.UsedBytes, &usage.AttachmentCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query storage usage: %w", err)
+	}
+
+	return &usage, nil
+}
+
+// Drop one blob reference for each storage key the keys subquery returns (repeats count once each).
+// The subquery takes its parameter as $2; blobs left without references are stamped with at.
+func releaseBlobs(tx execer, at time.Time, keys string, arg interface{}) error {
+	query := `
+		UPDATE attachment_blobs b
+		SET ref_count = GREATEST(b.ref_count - r.refs, 0),
+			unreferenced_at = CASE WHEN b.ref_count - r.refs <= 0 THEN $1 ELSE b.unreferenced_at END
+		FROM (SELECT k.key, COUNT(*) AS refs FROM (` + keys + `) AS k(key) GROUP BY k.key) r
+		WHERE b.content_hash = r.key
+	`
+
+	if _, err := tx.Exec(query, at, arg); err != nil {
+		return fmt.Errorf("failed to release attachment blobs: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
+
+// #region Attachment blobs
+
+// Get the content hashes of blobs nothing has referenced since before the cutoff (oldest first)
+func (db *DB) GetUnreferencedBlobs(before time.Time, limit int) ([]string, error) {
+	query := `
+		SELECT content_hash FROM attachment_blobs
+		WHERE ref_count = 0 AND unreferenced_at < $1
+		ORDER BY unreferenced_at
+		LIMIT $2
+	`
+
+	rows, err := db.Query(query, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unreferenced blobs: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := []string{}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan unreferenced blobs: %w", err)
+		}
+
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
+
+// Delete a blob's record if it's still unreferenced since before the cutoff, calling removeContents
+// while the row is locked so an upload of the same file waits and then stores it afresh.
+// Reports whether the blob was deleted (false when it has been referenced again).
+func (db *DB) DeleteUnreferencedBlob(contentHash string, before time.Time, removeContents func() error) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	lock := `
+		SELECT content_hash FROM attachment_blobs
+		WHERE content_hash = $1 AND ref_count = 0 AND unreferenced_at < $2
+		FOR UPDATE
+	`
+
+	err = tx.QueryRow(lock, contentHash, before).Scan(&contentHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to lock blob: %w", err)
+	}
+
+	if err := removeContents(); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM attachment_blobs WHERE content_hash = $1", contentHash); err != nil {
+		return false, fmt.Errorf("failed to delete blob: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit blob deletion: %w", err)
+	}
+
+	return true, nil
+}
+
+// #endregion
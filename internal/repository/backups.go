@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"fmt"
+	"time"
+)
+
+// #region Backups
+
+// Record a backup that has just started
+func (db *DB) CreateBackup(backup *model.Backup) error {
+	query := `
+		INSERT INTO backups (storage_key, status, triggered_by, started_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING backup_id
+	`
+
+	err := db.QueryRow(query, backup.StorageKey, backup.Status, backup.TriggeredBy, backup.StartedAt).Scan(&backup.BackupId)
+	if err != nil {
+		return fmt.Errorf("failed to record backup: %w", err)
+	}
+
+	return nil
+}
+
+// Record how a backup ended (or that its archive was pruned)
+func (db *DB) UpdateBackup(backup *model.Backup) error {
+	query := `
+		UPDATE backups
+		SET status = $2, size_bytes = $3, error = $4, finished_at = $5
+		WHERE backup_id = $1
+	`
+
+	result, err := db.Exec(query, backup.BackupId, backup.Status, backup.SizeBytes, backup.Error, backup.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update backup: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("backup %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Get backups, newest first
+func (db *DB) ListBackups(limit, offset int) ([]model.Backup, error) {
+	query, args := db.newSelect(backupColumns, "backups").
+		OrderBy("started_at DESC, backup_id DESC").
+		Limit(limit).
+		Offset(offset).
+		Build()
+
+	return db.queryBackups(query, args...)
+}
+
+// Get the successful backups older than the newest keep of them (the ones retention should prune)
+func (db *DB) GetBackupsBeyond(keep int) ([]model.Backup, error) {
+	query := "SELECT " + backupColumns + " FROM backups WHERE status = $1 ORDER BY started_at DESC, backup_id DESC OFFSET $2"
+
+	return db.queryBackups(query, model.BackupSucceeded, keep)
+}
+
+// Runs a query selecting backupColumns
+func (db *DB) queryBackups(query string, args ...interface{}) ([]model.Backup, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query backups: %w", err)
+	}
+	defer rows.Close()
+
+	backups := []model.Backup{}
+	for rows.Next() {
+		backup, err := scanBackup(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan backup: %w", err)
+		}
+		backups = append(backups, backup)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating backups: %w", err)
+	}
+
+	return backups, nil
+}
+
+// Mark backups left running by a previous process as failed, returning how many there were
+func (db *DB) FailInterruptedBackups(at time.Time) (int64, error) {
+	query := "UPDATE backups SET status = $1, error = $2, finished_at = $3 WHERE status = $4"
+
+	result, err := db.Exec(query, model.BackupFailed, "interrupted by a server restart", at, model.BackupRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail interrupted backups: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// #endregion
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// #region Boards
+
+// Get every board, by name
+func (db *DB) GetBoards() ([]model.Board, error) {
+	rows, err := db.Query("SELECT " + boardColumns + " FROM boards ORDER BY name, board_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query boards: %w", err)
+	}
+	defer rows.Close()
+
+	boards := []model.Board{}
+	for rows.Next() {
+		board, err := scanBoard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan boards: %w", err)
+		}
+
+		boards = append(boards, board)
+	}
+
+	return boards, nil
+}
+
+// Get a board by its slug
+func (db *DB) GetBoardBySlug(slug string) (*model.Board, error) {
+	board, err := scanBoard(db.QueryRow("SELECT "+boardColumns+" FROM boards WHERE slug = $1", slug))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("board %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query board: %w", err)
+	}
+
+	return &board, nil
+}
+
+// Create a board or replace its name and description, filling in its ID and creation time
+func (db *DB) SetBoard(board *model.Board) error {
+	query := `
+		INSERT INTO boards (slug, name, description)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (slug) DO UPDATE SET name = $2, description = $3
+		RETURNING board_id, created_at
+	`
+
+	if err := db.QueryRow(query, board.Slug, board.Name, board.Description).Scan(&board.BoardId, &board.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save board: %w", err)
+	}
+
+	return nil
+}
+
+// Delete a board (its posts are left without one)
+func (db *DB) DeleteBoard(slug string) error {
+	result, err := db.Exec("DELETE FROM boards WHERE slug = $1", slug)
+	if err != nil {
+		return fmt.Errorf("failed to delete board: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("board %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// #endregion
+
+// #region Subscriptions
+
+// Subscribe a user to a board's posts (subscribing twice changes nothing)
+func (db *DB) SubscribeToBoard(userId, boardId int64) error {
+	query := `
+		INSERT INTO board_subscriptions (user_id, board_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := db.Exec(query, userId, boardId); err != nil {
+		return fmt.Errorf("failed to subscribe to board: %w", err)
+	}
+
+	return nil
+}
+
+// Unsubscribe a user from a board
+func (db *DB) UnsubscribeFromBoard(userId, boardId int64) error {
+	return db.deleteSubscription("DELETE FROM board_subscriptions WHERE user_id = $1 AND board_id = $2", userId, boardId)
+}
+
+// Subscribe a user to the posts carrying a tag (subscribing twice changes nothing)
+func (db *DB) SubscribeToTag(userId int64, tag string) error {
+	query := `
+		INSERT INTO tag_subscriptions (user_id, tag)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := db.Exec(query, userId, tag); err != nil {
+		return fmt.Errorf("failed to subscribe to tag: %w", err)
+	}
+
+	return nil
+}
+
+// Unsubscribe a user from a tag
+func (db *DB) UnsubscribeFromTag(userId int64, tag string) error {
+	return db.deleteSubscription("DELETE FROM tag_subscriptions WHERE user_id = $1 AND tag = $2", userId, tag)
+}
+
+// Runs a subscription DELETE, reporting ErrNotFound when the user wasn't subscribed
+func (db *DB) deleteSubscription(query string, args ...interface{}) error {
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("subscription %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Get the boards (by name) and tags (alphabetically) a user subscribes to
+func (db *DB) GetSubscriptions(userId int64) (*model.SubscriptionsResponse, error) {
+	subscriptions := &model.SubscriptionsResponse{Boards: []model.Board{}, Tags: []string{}}
+
+	query := `
+		SELECT b.board_id, b.slug, b.name, b.description, b.created_at
+		FROM board_subscriptions s
+		JOIN boards b ON b.board_id = s.board_id
+		WHERE s.user_id = $1
+		ORDER BY b.name, b.board_id
+	`
+	rows, err := db.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query board subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		board, err := scanBoard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan board subscriptions: %w", err)
+		}
+
+		subscriptions.Boards = append(subscriptions.Boards, board)
+	}
+	rows.Close()
+
+	tags, err := db.Query("SELECT tag FROM tag_subscriptions WHERE user_id = $1 ORDER BY tag", userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tag subscriptions: %w", err)
+	}
+	defer tags.Close()
+
+	for tags.Next() {
+		var tag string
+		if err := tags.Scan(&tag); err != nil {
+			return nil, fmt.Errorf("failed to scan tag subscriptions: %w", err)
+		}
+
+		subscriptions.Tags = append(subscriptions.Tags, tag)
+	}
+
+	return subscriptions, nil
+}
+
+// Posts on the boards or carrying the tags a user subscribes to. Each half of the UNION uses its own
+// index (posts by board, and the GIN index on tags), and the UNION drops posts that match both.
+const subscribedPosts = `post_id IN (
+	SELECT p.post_id FROM posts p JOIN board_subscriptions s ON s.board_id = p.board_id WHERE s.user_id = ?
+	UNION
+	SELECT post_id FROM posts WHERE tags && ARRAY(SELECT tag FROM tag_subscriptions WHERE user_id = ?))`
+
+// Get a page of the subscriptions feed: posts the user can see on the boards and with the tags they
+// subscribe to, newest first, leaving out posts they muted (reports whether another page follows)
+func (db *DB) GetSubscriptionFeed(userId int64, limit, offset int) ([]model.Post, bool, error) {
+	size := db.pageSize(limit)
+	query, args := db.newSelect(postColumns, "posts").
+		Where(subscribedPosts, userId, userId).
+		Where("NOT hidden").
+		Where(visibleToViewer, userId).
+		Where(postAudience, postAudienceArgs(userId)...).
+		Where("NOT EXISTS (SELECT 1 FROM post_watches w WHERE w.user_id = ? AND w.post_id = posts.post_id AND w.muted)", userId).
+		OrderBy(postSorts["newest"]).
+		Page(size, offset).
+		Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query subscription feed: %w", err)
+	}
+	defer rows.Close()
+
+	postList := []model.Post{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to scan rows: %w", err)
+		}
+
+		postList = append(postList, post)
+	}
+
+	postList, more := trimPage(postList, size)
+	return postList, more, nil
+}
+
+// #endregion
@@ -4,14 +4,20 @@ import (
 	"byte-board/internal/appconfig"
 	"byte-board/internal/model"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
 )
 
 type DB struct {
 	*sql.DB
+	// Set on copies bound to a transaction (see InTx); their queries run inside it
+	bound *boundTx
+	// Most rows a listing built with newSelect returns (MAX_RESULT_ROWS)
+	maxRows int
 }
 
 // Create new database connection
@@ -27,6 +33,8 @@ func New(cfg *appconfig.Config) (*DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not establish connection with database: %w", err)
 	}
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
 
 	// Ping database (verify conn to db is still alive)
 	if err := db.Ping(); err != nil {
@@ -34,16 +42,76 @@ func New(cfg *appconfig.Config) (*DB, error) {
 	}
 
 	log.Info().Msg("Database successfully connected!")
-	return &DB{DB: db}, nil
+	return &DB{DB: db, maxRows: cfg.MaxResultRows}, nil
+}
+
+// Checks if an error is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode
+}
+
+// Condition that hides content by shadowbanned users from everyone but its author.
+// Bind it to the viewer's user ID (0 for anonymous requests).
+const visibleToViewer = "(user_id = ? OR user_id NOT IN (SELECT user_id FROM users WHERE shadowbanned))"
+
+// Condition that limits posts to the audience their visibility allows: everyone for public posts,
+// signed-in users for members posts, the author's followers for followers posts, and always the author.
+// Bind it with postAudienceArgs (the visibility values are bound too, as the query builder rejects quoted literals).
+const postAudience = `(visibility = ? OR user_id = ?
+	OR (visibility = ? AND ? <> 0)
+	OR (visibility = ? AND user_id IN (SELECT followee_id FROM follows WHERE follower_id = ?)))`
+
+// Condition that limits comments to those on posts the viewer can read: not hidden by a moderator
+// and within the post's audience (bind it with postAudienceArgs)
+const onPostInAudience = "post_id IN (SELECT post_id FROM posts WHERE NOT hidden AND " + postAudience + ")"
+
+// Arguments for postAudience and onPostInAudience (viewerId 0 for anonymous requests)
+func postAudienceArgs(viewerId int64) []interface{} {
+	return []interface{}{
+		model.VisibilityPublic, viewerId,
+		model.VisibilityMembers, viewerId,
+		model.VisibilityFollowers, viewerId,
+	}
+}
+
+// Implemented by both *DB and *Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// Recount the publicly visible comments on the posts returned by postIds,
+// a subquery that takes its parameters from args
+func refreshCommentCounts(tx execer, postIds string, args ...interface{}) error {
+	query := `
+		UPDATE posts p SET comment_count = (
+			SELECT COUNT(*) FROM comments c
+			WHERE c.post_id = p.post_id AND NOT c.hidden
+			AND c.user_id NOT IN (SELECT user_id FROM users WHERE shadowbanned)
+		)
+		WHERE p.post_id IN (` + postIds + `)
+	`
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to refresh comment counts: %w", err)
+	}
+
+	return nil
 }
 
 // #region Comments
 
-// Get all comments in the db
-func (db *DB) GetAllComments() ([]model.Comment, error) {
-	query := "SELECT * FROM comments"
+// Sort options for comment listings
+var commentSorts = map[string]string{
+	"oldest": "date_posted ASC, comment_id ASC",
+	"newest": "date_posted DESC, comment_id DESC",
+}
+
+// Get comments matching a filter
+func (db *DB) ListComments(filter model.CommentFilter) ([]model.Comment, error) {
+	query, args := db.commentListQuery(filter)
 
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query comments: %w", err)
 	}
@@ -51,8 +119,7 @@ func (db *DB) GetAllComments() ([]model.Comment, error) {
 
 	var commentsList []model.Comment
 	for rows.Next() {
-		var comment model.Comment
-		err := rows.Scan(&comment.CommentId, &comment.UserId, &comment.PostId, &comment.Content, &comment.Author, &comment.DatePosted)
+		comment, err := scanComment(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan comments: %w", err)
 		}
@@ -63,111 +130,178 @@ func (db *DB) GetAllComments() ([]model.Comment, error) {
 	return commentsList, nil
 }
 
-// Get comment by ID
-func (db *DB) GetCommentById(commentId int) (*model.Comment, error) {
-	query := "SELECT * FROM comments WHERE comment_id = $1"
+// Builds the statement ListComments runs
+func (db *DB) commentListQuery(filter model.CommentFilter) (string, []interface{}) {
+	builder := db.newSelect(commentColumns, "comments").
+		Where("NOT hidden").
+		Where(visibleToViewer, filter.ViewerId).
+		Where(onPostInAudience, postAudienceArgs(filter.ViewerId)...)
+	if filter.UserId > 0 {
+		builder.Where("user_id = ?", filter.UserId)
+	}
+	if filter.PostId > 0 {
+		builder.Where("post_id = ?", filter.PostId)
+	}
+
+	sort, ok := commentSorts[filter.Sort]
+	if !ok {
+		sort = commentSorts["oldest"]
+	}
+	return builder.OrderBy(sort).Limit(filter.Limit).Offset(filter.Offset).Build()
+}
 
-	var comment model.Comment
-	err := db.QueryRow(query, commentId).Scan(&comment.CommentId, &comment.UserId, &comment.PostId, &comment.Content, &comment.Author, &comment.DatePosted)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("comment not found")
+// Get up to perPost comments on each of several posts in one query, keyed by post ID
+func (db *DB) GetCommentsByPosts(postIds []int64, perPost int, sort string, viewerId int64) (map[int64][]model.Comment, error) {
+	order, ok := commentSorts[sort]
+	if !ok {
+		order = commentSorts["oldest"]
+	}
+
+	// Number each post's comments in display order, then keep the first perPost of each
+	inner, args := db.newSelect(commentColumns+", ROW_NUMBER() OVER (PARTITION BY post_id ORDER BY "+order+") AS position", "comments").
+		Where("post_id = ANY(?)", pq.Array(postIds)).
+		Where(onPostInAudience, postAudienceArgs(viewerId)...).
+		Where("NOT hidden").
+		Where(visibleToViewer, viewerId).
+		Unlimited().
+		Build()
+	query := fmt.Sprintf("SELECT %s FROM (%s) ranked WHERE position <= $%d ORDER BY post_id, position", commentColumns, inner, len(args)+1)
+
+	rows, err := db.Query(query, append(args, perPost)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments by posts: %w", err)
+	}
+	defer rows.Close()
+
+	comments := make(map[int64][]model.Comment, len(postIds))
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan comments: %w", err)
+		}
+
+		comments[comment.PostId] = append(comments[comment.PostId], comment)
+	}
+
+	return comments, nil
+}
+
+// Get comment by ID
+func (db *DB) GetCommentById(commentId int64) (*model.Comment, error) {
+	row, err := getCommentByID(db, commentId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("comment %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query comments: %w", err)
 	}
 
+	comment := row.comment()
 	return &comment, nil
 }
 
-// Get all comments on a post
-func (db *DB) GetCommentsByPost(postId int) ([]model.Comment, error) {
-	query := "SELECT * FROM comments WHERE post_id = $1"
+// Get a page of the comments on a post that the viewer can see, oldest first (viewerId 0 for anonymous
+// requests; reports whether another page follows)
+func (db *DB) GetCommentsByPost(postId, viewerId int64, limit, offset int) ([]model.Comment, bool, error) {
+	size := db.pageSize(limit)
+	query, args := db.newSelect(commentColumns, "comments").
+		Where("post_id = ?", postId).
+		Where(onPostInAudience, postAudienceArgs(viewerId)...).
+		Where("NOT hidden").
+		Where(visibleToViewer, viewerId).
+		OrderBy(commentSorts["oldest"]).
+		Page(size, offset).
+		Build()
 
-	rows, err := db.Query(query, postId)
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query comments on post: %w", err)
+		return nil, false, fmt.Errorf("failed to query comments on post: %w", err)
 	}
 	defer rows.Close()
 
 	var commentList []model.Comment
 	for rows.Next() {
-		var comment model.Comment
-		err := rows.Scan(&comment.CommentId, &comment.UserId, &comment.PostId, &comment.Content, &comment.Author, &comment.DatePosted)
+		comment, err := scanComment(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan comments on post")
+			return nil, false, fmt.Errorf("failed to scan comments on post")
 		}
 
 		commentList = append(commentList, comment)
 	}
 
 	if len(commentList) == 0 {
-		return nil, fmt.Errorf("no comments were found")
+		return nil, false, fmt.Errorf("comments on post %w", ErrNotFound)
 	}
-	return commentList, nil
+	commentList, more := trimPage(commentList, size)
+	return commentList, more, nil
 }
 
 // Create comment on a post
-func (db *DB) CreateComment(comment *model.Comment, postId int) error {
-	log.Info().Int("PostID", postId).Msg("Creating comment on post")
+func (db *DB) CreateComment(comment *model.Comment, postId int64) error {
+	log.Info().Int64("PostID", postId).Msg("Creating comment on post")
 
-	query := `
-		INSERT INTO comments (user_id, post_id, content, author, date_posted)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING comment_id
-			`
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	err := db.QueryRow(query, comment.UserId, comment.PostId, comment.Content, comment.Author, comment.DatePosted).
-		Scan(&comment.CommentId)
+	comment.CommentId, err = createComment(tx, comment.UserId, comment.PostId, comment.Content, comment.Author, comment.DatePosted)
 	if err != nil {
 		return fmt.Errorf("failed to create comment: %w", err)
 	}
 
+	// Bump the post's activity (comments by shadowbanned users don't resurface it)
+	if err := bumpPostActivity(tx, comment.PostId, comment.DatePosted, comment.UserId); err != nil {
+		return fmt.Errorf("failed to update post activity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit comment: %w", err)
+	}
+
 	return nil
 }
 
 // Update a comment
 func (db *DB) UpdateComment(comment *model.Comment) error {
-	log.Info().Int("ID", comment.CommentId).Msg("Updating comment in the database")
-
-	query := `
-		UPDATE comments 
-		SET content = $2, 
-		author = $3 
-		WHERE comment_id = $1
-	`
+	log.Info().Int64("ID", comment.CommentId).Msg("Updating comment in the database")
 
-	result, err := db.Exec(query, comment.CommentId, comment.Content, comment.Author)
+	rows, err := updateComment(db, comment.CommentId, comment.Content, comment.Author)
 	if err != nil {
 		return fmt.Errorf("failed to update comment: %w", err)
 	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
 	if rows == 0 {
-		return fmt.Errorf("comment not found")
+		return fmt.Errorf("comment %w", ErrNotFound)
 	}
 
 	return nil
 }
 
 // Delete a comment
-func (db *DB) DeleteComment(id int) error {
-	log.Info().Int("ID", id).Msg("Deleting comment from the database")
+func (db *DB) DeleteComment(id int64) error {
+	log.Info().Int64("ID", id).Msg("Deleting comment from the database")
 
-	query := "DELETE FROM comments WHERE comment_id = $1"
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	result, err := db.Exec(query, id)
+	postId, err := deleteComment(tx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("comment %w", ErrNotFound)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to delete comment: %w", err)
 	}
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+
+	if err := refreshCommentCounts(tx, "$1", postId); err != nil {
+		return err
 	}
-	if rows == 0 {
-		return fmt.Errorf("comment not found")
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit comment deletion: %w", err)
 	}
 
 	return nil
@@ -177,11 +311,20 @@ func (db *DB) DeleteComment(id int) error {
 
 // #region Posts
 
-// Get all posts in the DB
-func (db *DB) GetAllPosts() ([]model.Post, error) {
-	query := "SELECT * FROM posts ORDER BY date_posted DESC"
+// Sort options for post listings
+var postSorts = map[string]string{
+	"newest": "date_posted DESC, post_id DESC",
+	"oldest": "date_posted ASC, post_id ASC",
+	"title":  "title ASC, post_id ASC",
+	// Recently commented threads first, like a classic forum
+	"active": "last_activity_at DESC, post_id DESC",
+}
+
+// Get posts matching a filter
+func (db *DB) ListPosts(filter model.PostFilter) ([]model.Post, error) {
+	query, args := db.postListQuery(filter)
 
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query rows: %w", err)
 	}
@@ -189,8 +332,7 @@ func (db *DB) GetAllPosts() ([]model.Post, error) {
 
 	var postList []model.Post
 	for rows.Next() {
-		var post model.Post
-		err := rows.Scan(&post.PostId, &post.UserId, &post.Title, &post.Content, &post.Author, &post.DatePosted)
+		post, err := scanPost(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan rows: %w", err)
 		}
@@ -201,117 +343,183 @@ func (db *DB) GetAllPosts() ([]model.Post, error) {
 	return postList, nil
 }
 
-// Get post by post ID
-func (db *DB) GetPostById(postId int) (*model.Post, error) {
-	query := "SELECT * FROM posts WHERE post_id = $1"
+// Builds the statement ListPosts runs
+func (db *DB) postListQuery(filter model.PostFilter) (string, []interface{}) {
+	builder := db.newSelect(postColumns, "posts").
+		Where("NOT hidden").
+		Where(visibleToViewer, filter.ViewerId).
+		Where(postAudience, postAudienceArgs(filter.ViewerId)...)
+	if filter.UserId > 0 {
+		builder.Where("user_id = ?", filter.UserId)
+	}
+	if filter.Author != "" {
+		// Match the shown author name or the login username behind it
+		builder.Where("(LOWER(author) = LOWER(?) OR user_id IN (SELECT user_id FROM users WHERE LOWER(username) = LOWER(?)))", filter.Author, filter.Author)
+	}
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		builder.Where("(title ILIKE ? OR content ILIKE ?)", pattern, pattern)
+	}
+	if filter.Language != "" {
+		// Tags are stored in canonical case; a bare language also matches its regional variants
+		builder.Where("(language = ? OR language LIKE ?)", filter.Language, escapeLike(filter.Language)+"-%")
+	}
+	if filter.HideMuted && filter.ViewerId > 0 {
+		builder.Where("NOT EXISTS (SELECT 1 FROM post_watches w WHERE w.user_id = ? AND w.post_id = posts.post_id AND w.muted)", filter.ViewerId)
+	}
 
-	var post model.Post
-	err := db.QueryRow(query, postId).Scan(&post.PostId, &post.UserId, &post.Title, &post.Content, &post.Author, &post.DatePosted)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("post not found")
+	sort, ok := postSorts[filter.Sort]
+	if !ok {
+		sort = postSorts["newest"]
+	}
+	return builder.OrderBy(sort).Limit(filter.Limit).Offset(filter.Offset).Build()
+}
+
+// Get post by post ID
+func (db *DB) GetPostById(postId int64) (*model.Post, error) {
+	row, err := getPostByID(db, postId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("post %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query post with that id: %w", err)
 	}
 
+	post := row.post()
 	return &post, nil
 }
 
-// Get all posts made by a user
-func (db *DB) GetPostsByUserId(userId int) ([]model.Post, error) {
-	query := "SELECT * FROM posts WHERE user_id = $1"
+// Get a page of the posts made by a user that the viewer can see, newest first (viewerId 0 for anonymous
+// requests; reports whether another page follows)
+func (db *DB) GetPostsByUserId(userId, viewerId int64, limit, offset int) ([]model.Post, bool, error) {
+	size := db.pageSize(limit)
+	query, args := db.newSelect(postColumns, "posts").
+		Where("user_id = ?", userId).
+		Where("NOT hidden").
+		Where(visibleToViewer, viewerId).
+		Where(postAudience, postAudienceArgs(viewerId)...).
+		OrderBy(postSorts["newest"]).
+		Page(size, offset).
+		Build()
 
-	rows, err := db.Query(query, userId)
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query rows: %w", err)
+		return nil, false, fmt.Errorf("failed to query rows: %w", err)
 	}
+	defer rows.Close()
 
 	var postList []model.Post
 	for rows.Next() {
-		var post model.Post
-		err := rows.Scan(&post.PostId, &post.UserId, &post.Title, &post.Content, &post.Author, &post.DatePosted)
+		post, err := scanPost(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan rows: %w", err)
+			return nil, false, fmt.Errorf("failed to scan rows: %w", err)
 		}
 
 		postList = append(postList, post)
 	}
 
 	if len(postList) == 0 {
-		return nil, fmt.Errorf("users posts not found")
+		return nil, false, fmt.Errorf("users posts %w", ErrNotFound)
 	}
+	postList, more := trimPage(postList, size)
+	return postList, more, nil
+}
+
+// Get when the user's nth most recent post since the cutoff was made (nil when they made fewer than n)
+func (db *DB) GetNthRecentPostTime(userId int64, since time.Time, n int) (*time.Time, error) {
+	posted, err := getNthRecentPostTime(db, userId, since, n-1)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent posts: %w", err)
+	}
+
+	return &posted, nil
+}
+
+// Get when the user's nth most recent comment since the cutoff was made (nil when they made fewer than n)
+func (db *DB) GetNthRecentCommentTime(userId int64, since time.Time, n int) (*time.Time, error) {
+	posted, err := getNthRecentCommentTime(db, userId, since, n-1)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent comments: %w", err)
+	}
+
+	return &posted, nil
+}
+
+// Get posts made by a user since a given time
+func (db *DB) GetRecentPostsByUserId(userId int64, since time.Time) ([]model.Post, error) {
+	rows, err := getRecentPostsByUser(db, userId, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recent posts: %w", err)
+	}
+
+	var postList []model.Post
+	for _, row := range rows {
+		postList = append(postList, row.post())
+	}
+
 	return postList, nil
 }
 
 // POST api/posts - Create a post
 func (db *DB) CreatePost(post *model.Post) error {
-	query := `
-		INSERT INTO posts (user_id, title, content, author, date_posted) 
-		VALUES ($1, $2, $3, $4, $5) 
-		RETURNING post_id
-	`
-
-	err := db.QueryRow(query, post.UserId, post.Title, post.Content, post.Author, post.DatePosted).
-		Scan(&post.PostId)
+	if post.Tags == nil {
+		post.Tags = []string{}
+	}
+	created, err := createPost(db, post.UserId, post.Title, post.Content, post.Author, post.DatePosted, post.Visibility, post.Language,
+		post.LanguageSource, post.Excerpt, post.ReadingMinutes, ptrNullInt(post.BoardId), post.Tags)
 	if err != nil {
 		return fmt.Errorf("failed to create post: %w", err)
 	}
+	post.PostId, post.LastActivityAt = created.PostId, created.LastActivityAt
 
 	return nil
 }
 
 // PUT api/posts/{postId} - Update a post
 func (db *DB) UpdatePost(post *model.Post) error {
-	query := `
-		UPDATE posts
-		SET user_id = $2, title = $3, content = $4, author = $5, date_posted = $6
-		WHERE post_id = $1
-	`
-
-	result, err := db.Exec(query, post.PostId, post.UserId, post.Title, post.Content, post.Author, post.DatePosted)
-	if err != nil {
-		return fmt.Errorf("failed to update post: %w", err)
+	if post.Tags == nil {
+		post.Tags = []string{}
 	}
-
-	rowsAffected, err := result.RowsAffected()
+	rowsAffected, err := updatePost(db, post.PostId, post.UserId, post.Title, post.Content, post.Author, post.DatePosted, post.Visibility,
+		post.Language, post.LanguageSource, post.Excerpt, post.ReadingMinutes, ptrNullInt(post.BoardId), post.Tags)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to update post: %w", err)
 	}
 
-	log.Info().Int("post_id", post.PostId).Int64("rows affected", rowsAffected).Msg("Post update query executed")
+	log.Info().Int64("post_id", post.PostId).Int64("rows affected", rowsAffected).Msg("Post update query executed")
 
 	if rowsAffected == 0 {
-		log.Warn().Int("post_id", post.PostId).Msg("No rows affected - post not found")
+		log.Warn().Int64("post_id", post.PostId).Msg("No rows affected - post not found")
 	}
 
-	log.Info().Int("post_id", post.PostId).Msg("Successfully updated post in database")
+	log.Info().Int64("post_id", post.PostId).Msg("Successfully updated post in database")
 	return nil
 }
 
 // DELETE api/posts/{postId} - Delete a post
-func (db *DB) DeletePost(postId int) error {
-	log.Info().Int("ID", postId).Msg("Deleting post from the database")
+func (db *DB) DeletePost(postId int64) error {
+	log.Info().Int64("ID", postId).Msg("Deleting post from the database")
 
-	query := "DELETE FROM posts WHERE post_id = $1"
-	result, err := db.Exec(query, postId)
+	rowsAffected, err := deletePost(db, postId)
 	if err != nil {
-		log.Error().Err(err).Int("PostID", postId).Msg("Failed to execute post deletion query")
+		log.Error().Err(err).Int64("PostID", postId).Msg("Failed to execute post deletion query")
 		return fmt.Errorf("failed to delete post: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	log.Info().Int("PostID", postId).Int64("rows affected", rowsAffected).Msg("Post deletion query executed")
+	log.Info().Int64("PostID", postId).Int64("rows affected", rowsAffected).Msg("Post deletion query executed")
 
 	if rowsAffected == 0 {
-		log.Warn().Int("PostID", postId).Msg("No rows affected - post not found")
-		return fmt.Errorf("post not found")
+		log.Warn().Int64("PostID", postId).Msg("No rows affected - post not found")
+		return fmt.Errorf("post %w", ErrNotFound)
 	}
 
-	log.Info().Int("PostID", postId).Msg("Successfully deleted post from the database")
+	log.Info().Int64("PostID", postId).Msg("Successfully deleted post from the database")
 	return nil
 }
 
@@ -319,61 +527,87 @@ func (db *DB) DeletePost(postId int) error {
 
 // #region Profiles
 
-// Get all profiles
-func (db *DB) GetAllProfiles() ([]model.Profile, error) {
-	query := "SELECT * FROM profiles"
+// Get a page of profiles, oldest accounts first (reports whether another page follows)
+func (db *DB) GetAllProfiles(limit, offset int) ([]model.Profile, bool, error) {
+	return db.listProfiles(db.newSelect(profileColumns, "profiles"), limit, offset)
+}
+
+// Get a page of the profiles a query selects, by user ID (reports whether another page follows)
+func (db *DB) listProfiles(builder *selectBuilder, limit, offset int) ([]model.Profile, bool, error) {
+	size := db.pageSize(limit)
+	query, args := builder.OrderBy("user_id").Page(size, offset).Build()
 
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query profiles: %w", err)
+		return nil, false, fmt.Errorf("failed to query profiles: %w", err)
 	}
+	defer rows.Close()
 
 	var profileList []model.Profile
 	for rows.Next() {
-		var profile model.Profile
-		err := rows.Scan(&profile.UserId, &profile.FirstName, &profile.LastName, &profile.Email, &profile.GithubLink, &profile.City, &profile.State, &profile.DateRegistered)
+		profile, err := scanProfile(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan profiles: %w", err)
+			return nil, false, fmt.Errorf("failed to scan profiles: %w", err)
 		}
 
 		profileList = append(profileList, profile)
 	}
 
-	return profileList, nil
+	profileList, more := trimPage(profileList, size)
+	return profileList, more, nil
 }
 
 // Get profile by User ID
-func (db *DB) GetProfileByUserId(userId int) (*model.Profile, error) {
-	query := "SELECT * FROM profiles WHERE user_id = $1"
-
-	var profile model.Profile
-	err := db.QueryRow(query, userId).Scan(&profile.UserId, &profile.FirstName, &profile.LastName, &profile.Email, &profile.GithubLink, &profile.City, &profile.State, &profile.DateRegistered)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("profile not found")
+func (db *DB) GetProfileByUserId(userId int64) (*model.Profile, error) {
+	row, err := getProfileByUserID(db, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("profile %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query profiles: %w", err)
 	}
 
-	return &profile, err
+	profile := row.profile()
+	return &profile, nil
+}
+
+// Get profile by User ID along with its activity stats (in one query)
+func (db *DB) GetProfileWithStats(userId int64) (*model.Profile, *model.ProfileStats, error) {
+	row, err := getProfileWithStats(db, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, fmt.Errorf("profile %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query profile stats: %w", err)
+	}
+
+	profile := profileRow{
+		UserId:         row.UserId,
+		FirstName:      row.FirstName,
+		LastName:       row.LastName,
+		Email:          row.Email,
+		GithubLink:     row.GithubLink,
+		City:           row.City,
+		State:          row.State,
+		DateRegistered: row.DateRegistered,
+		DisplayName:    row.DisplayName,
+	}.profile()
+	stats := model.ProfileStats{PostCount: row.PostCount, CommentCount: row.CommentCount, MemberSince: profile.DateRegistered}
+
+	return &profile, &stats, nil
 }
 
 // Create a profile
 func (db *DB) CreateProfile(profile *model.Profile) (*model.Profile, error) {
-	query := `
-		INSERT INTO profiles (user_id, first_name, last_name, email, github_link, city, state, date_registered)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-	`
-
-	_, err := db.Exec(query,
+	err := createProfile(db,
 		profile.UserId,
-		profile.FirstName,
-		profile.LastName,
-		profile.Email,
-		profile.GithubLink,
-		profile.City,
-		profile.State,
-		profile.DateRegistered)
+		nullString(profile.FirstName),
+		nullString(profile.LastName),
+		nullString(profile.Email),
+		nullString(profile.GithubLink),
+		nullString(profile.City),
+		nullString(profile.State),
+		sql.NullTime{Time: profile.DateRegistered, Valid: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create profile: %w", err)
 	}
@@ -381,59 +615,36 @@ func (db *DB) CreateProfile(profile *model.Profile) (*model.Profile, error) {
 	return profile, nil
 }
 
-// Update a profile
+// Update a profile's details (not its email, which changes through a confirmed email change)
 func (db *DB) UpdateProfile(profile *model.Profile) error {
-	log.Info().Int("User ID:", profile.UserId).Msg("Updating user profile in the db")
-
-	query := `
-		UPDATE profiles 
-		SET first_name = $2,
-		last_name = $3,
-		email = $4,
-		github_link = $5,
-		city = $6,
-		state = $7
-		WHERE user_id = $1
-	`
+	log.Info().Int64("User ID:", profile.UserId).Msg("Updating user profile in the db")
 
-	// Execute query
-	result, err := db.Exec(query, profile.UserId, profile.FirstName, profile.LastName, profile.Email, profile.GithubLink, profile.City, profile.State)
+	rows, err := updateProfile(db, profile.UserId, nullString(profile.FirstName), nullString(profile.LastName),
+		nullString(profile.GithubLink), nullString(profile.City), nullString(profile.State))
 	if err != nil {
 		return fmt.Errorf("failed to update users profile: %w", err)
 	}
 
-	// Get rows affected
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
-
-	log.Info().Int("User ID", profile.UserId).Int64("Rows affected", rows).Msg("Profile update query was executed")
+	log.Info().Int64("User ID", profile.UserId).Int64("Rows affected", rows).Msg("Profile update query was executed")
 
 	// Verify profile exists
 	if rows == 0 {
-		return fmt.Errorf("profile not found")
+		return fmt.Errorf("profile %w", ErrNotFound)
 	}
 
 	return nil
 }
 
 // Delete a profile
-func (db *DB) DeleteProfile(userId int) error {
-	log.Info().Int("User ID", userId).Msg("Deleting user's profile")
+func (db *DB) DeleteProfile(userId int64) error {
+	log.Info().Int64("User ID", userId).Msg("Deleting user's profile")
 
-	query := "DELETE FROM profiles WHERE user_id = $1"
-	result, err := db.Exec(query, userId)
+	rows, err := deleteProfile(db, userId)
 	if err != nil {
 		return fmt.Errorf("Failed to delete profile: %w", err)
 	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
-	}
 	if rows == 0 {
-		return fmt.Errorf("profile not found")
+		return fmt.Errorf("profile %w", ErrNotFound)
 	}
 
 	return nil
@@ -443,131 +654,188 @@ func (db *DB) DeleteProfile(userId int) error {
 
 // #region Users
 
-// Get all users
-func (db *DB) GetAllUsers() ([]model.User, error) {
-	query := "SELECT * FROM users"
+// Get a page of users, oldest accounts first (reports whether another page follows)
+func (db *DB) GetAllUsers(limit, offset int) ([]model.User, bool, error) {
+	size := db.pageSize(limit)
+	query, args := db.newSelect(userColumns, "users").OrderBy("user_id").Page(size, offset).Build()
 
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query users")
+		return nil, false, fmt.Errorf("failed to query users")
 	}
+	defer rows.Close()
 
 	var userList []model.User
 	for rows.Next() {
-		var user model.User
-		err := rows.Scan(&user.ID, &user.Username, &user.HashedPassword, &user.Role, &user.FirstName, &user.LastName)
+		user, err := scanUser(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan users")
+			return nil, false, fmt.Errorf("failed to scan users")
 		}
 
 		userList = append(userList, user)
 	}
 
-	return userList, nil
+	userList, more := trimPage(userList, size)
+	return userList, more, nil
 }
 
 // Get user by user ID
-func (db *DB) GetUserByID(userId int) (*model.User, error) {
-	query := "SELECT * FROM users WHERE user_id = $1"
-
-	var user model.User
-	err := db.QueryRow(query, userId).Scan(&user.ID, &user.Username, &user.HashedPassword, &user.Role, &user.FirstName, &user.LastName)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+func (db *DB) GetUserByID(userId int64) (*model.User, error) {
+	row, err := getUserByID(db, userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query or scan rows: %w", err)
 	}
 
+	user := row.user()
 	return &user, nil
 }
 
 // GET api/users/username/{username} - Get user by username
 func (db *DB) GetUserByUsername(username string) (*model.User, error) {
-	query := "SELECT * FROM users WHERE username = $1"
-
-	var user model.User
-	err := db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.HashedPassword, &user.Role, &user.FirstName, &user.LastName)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("username not found")
+	row, err := getUserByUsername(db, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("username %w", ErrNotFound)
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to query or scan rows: %w", err)
 	}
 
+	user := row.user()
 	return &user, nil
 }
 
 // Create new user
 func (db *DB) CreateUser(user *model.User) error {
-	query := `
-		INSERT INTO users (username, hashed_password, role, first_name, last_name)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING user_id
-	`
-
-	err := db.QueryRow(query, user.Username, user.HashedPassword, user.Role, user.FirstName, user.LastName).Scan(&user.ID)
+	userId, err := createUser(db, user.Username, user.HashedPassword, user.Role, nullString(user.FirstName), nullString(user.LastName))
+	if isUniqueViolation(err) {
+		return fmt.Errorf("username already exists: %w", ErrConflict)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
+	user.ID = userId
 
 	return nil
 }
 
-// Update user
-func (db *DB) UpdateUser(user *model.User) error {
-	query := `
-		UPDATE users
-		SET username = $1,
-		hashed_password = $2,
-		role = $3,
-		first_name = $4,
-		last_name = $5
-		WHERE user_id = $6
-	`
+// Set a user's password, bump their token version so existing JWTs stop working, and revoke their
+// API keys (in one transaction). Returns the new token version.
+func (db *DB) UpdatePassword(userId int64, hashedPassword string) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	result, err := db.Exec(query, user.Username, user.HashedPassword, user.Role, user.FirstName, user.LastName, user.ID)
+	version, err := updatePassword(tx, userId, hashedPassword)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("user %w", ErrNotFound)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
+		return 0, fmt.Errorf("failed to update password: %w", err)
+	}
+	if err := revokeAPIKeys(tx, userId, time.Now()); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit password change: %w", err)
 	}
 
-	rows, err := result.RowsAffected()
+	return version, nil
+}
+
+// Replace a user's password hash while keeping their token version, so their tokens keep working.
+// Only applies while the stored hash is still oldHash (ErrConflict otherwise), so it can't undo a
+// password change made meanwhile.
+func (db *DB) ReplacePasswordHash(userId int64, oldHash, newHash string) error {
+	rows, err := replacePasswordHash(db, userId, oldHash, newHash)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to replace password hash: %w", err)
 	}
 	if rows == 0 {
-		return fmt.Errorf("user not found")
+		return fmt.Errorf("password changed meanwhile: %w", ErrConflict)
+	}
+
+	return nil
+}
+
+// Update user (a changed username is copied onto their posts and comments in the same transaction)
+func (db *DB) UpdateUser(user *model.User) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := updateUser(tx, user.ID, user.Username, user.HashedPassword, user.Role, nullString(user.FirstName), nullString(user.LastName))
+	if isUniqueViolation(err) {
+		return fmt.Errorf("username %w", ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	if err := recreditAuthor(tx, user.ID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user update: %w", err)
 	}
 
 	return nil
 }
 
 // Delete user
-func (db *DB) DeleteUser(userId int) error {
-	query := "DELETE FROM users WHERE user_id = $1"
+func (db *DB) DeleteUser(userId int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
-	result, err := db.Exec(query, userId)
+	// Posts the user commented on lose those comments with the account
+	commentedOn, err := getCommentedPostIDs(tx, userId)
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return fmt.Errorf("failed to find the user's comments: %w", err)
 	}
 
-	rows, err := result.RowsAffected()
+	// Their attachments go with the account, so drop the blob references they held
+	if err := releaseBlobs(tx, time.Now(), "SELECT storage_key FROM attachments WHERE user_id = $2", userId); err != nil {
+		return err
+	}
+
+	rows, err := deleteUser(tx, userId)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to delete user: %w", err)
 	}
 	if rows == 0 {
-		return fmt.Errorf("user not found")
+		return fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	if len(commentedOn) > 0 {
+		if err := refreshCommentCounts(tx, "SELECT UNNEST($1::bigint[])", pq.Array(commentedOn)); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user deletion: %w", err)
 	}
 
 	return nil
 }
 
-// Check if username already exists
+// Check if username already exists (case-insensitive)
 func (db *DB) UserExists(username string) (bool, error) {
-	query := "SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)"
-
-	var exists bool
-	err := db.QueryRow(query, username).Scan(&exists)
+	exists, err := userExists(db, username)
 	if err != nil {
 		return false, fmt.Errorf("failed to check if user exists: %w", err)
 	}
@@ -575,6 +843,66 @@ func (db *DB) UserExists(username string) (bool, error) {
 	return exists, nil
 }
 
+// Make a new user the admin while the site has none (and, with onlyUser, only if they're the only account).
+// Reports whether the user was promoted.
+func (db *DB) BootstrapAdmin(userId int64, onlyUser bool) (bool, error) {
+	rows, err := bootstrapAdmin(db, userId, onlyUser)
+	if err != nil {
+		return false, fmt.Errorf("failed to bootstrap admin: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// Get the staff-only details of several users: email, role, moderation status and report counts
+// (users that don't exist are left out)
+func (db *DB) GetMemberDetails(userIds []int64) (map[int64]model.MemberDetails, error) {
+	details := make(map[int64]model.MemberDetails, len(userIds))
+	if len(userIds) == 0 {
+		return details, nil
+	}
+
+	query := `
+		SELECT u.user_id, COALESCE(pr.email, ''), u.role,
+			u.status <> 'active' AND (u.banned_until IS NULL OR u.banned_until > NOW()), u.banned_until, u.shadowbanned,
+			COUNT(a.status),
+			COUNT(a.status) FILTER (WHERE a.status IN ('open', 'reviewing')),
+			(SELECT COUNT(*) FROM reports f WHERE f.reporter_id = u.user_id)
+		FROM users u
+		LEFT JOIN profiles pr ON pr.user_id = u.user_id
+		LEFT JOIN (
+			SELECT COALESCE(p.user_id, c.user_id) AS user_id, r.status
+			FROM reports r
+			LEFT JOIN posts p ON r.target_type = 'post' AND p.post_id = r.target_id
+			LEFT JOIN comments c ON r.target_type = 'comment' AND c.comment_id = r.target_id
+		) a ON a.user_id = u.user_id
+		WHERE u.user_id = ANY($1)
+		GROUP BY u.user_id, pr.email
+	`
+
+	rows, err := db.Query(query, pq.Array(userIds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query member details: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userId int64
+		var member model.MemberDetails
+		var bannedUntil sql.NullTime
+		err := rows.Scan(&userId, &member.Email, &member.Role, &member.Banned, &bannedUntil, &member.Shadowbanned,
+			&member.Reports.Against, &member.Reports.OpenAgainst, &member.Reports.Filed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan member details: %w", err)
+		}
+
+		member.BannedUntil = nullTimePtr(bannedUntil)
+		details[userId] = member
+	}
+
+	return details, nil
+}
+
 // #endregion
 
 /*
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// #region Digests
+
+// Get a user's digest settings (off if they never subscribed)
+func (db *DB) GetDigestSettings(userId int64) (*model.DigestSettings, error) {
+	query := "SELECT " + digestColumns + " FROM digest_subscriptions WHERE user_id = $1"
+
+	settings, err := scanDigestSettings(db.QueryRow(query, userId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.DigestSettings{UserId: userId, Frequency: model.DigestOff}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query digest settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// Set how often a user gets the digest
+func (db *DB) SetDigestFrequency(userId int64, frequency string) (*model.DigestSettings, error) {
+	query := `
+		INSERT INTO digest_subscriptions (user_id, frequency)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET frequency = EXCLUDED.frequency
+		RETURNING ` + digestColumns
+
+	settings, err := scanDigestSettings(db.QueryRow(query, userId, frequency))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set digest frequency: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// Get subscribers with an email address whose digest period has passed since the last one
+func (db *DB) GetDueDigestRecipients(now time.Time) ([]model.DigestRecipient, error) {
+	query := `
+		SELECT d.user_id, d.frequency, d.last_sent_at, u.username, p.email
+		FROM digest_subscriptions d
+		JOIN users u ON u.user_id = d.user_id
+		JOIN profiles p ON p.user_id = d.user_id
+		WHERE d.frequency <> 'off'
+			AND p.email IS NOT NULL AND p.email <> ''
+			AND u.status = 'active'
+			AND (d.last_sent_at IS NULL OR d.last_sent_at <= $1 -
+				CASE d.frequency WHEN 'daily' THEN INTERVAL '1 day' ELSE INTERVAL '7 days' END)
+		ORDER BY d.user_id
+	`
+
+	rows, err := db.Query(query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query digest recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []model.DigestRecipient
+	for rows.Next() {
+		var recipient model.DigestRecipient
+		var lastSentAt sql.NullTime
+		if err := rows.Scan(&recipient.UserId, &recipient.Frequency, &lastSentAt, &recipient.Username, &recipient.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan digest recipients: %w", err)
+		}
+		if lastSentAt.Valid {
+			recipient.LastSentAt = &lastSentAt.Time
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+// Mark a digest as sent, unless another instance already did (reports whether this call claimed it)
+func (db *DB) ClaimDigest(userId int64, previous *time.Time, sentAt time.Time) (bool, error) {
+	query := `
+		UPDATE digest_subscriptions SET last_sent_at = $2
+		WHERE user_id = $1 AND last_sent_at IS NOT DISTINCT FROM $3
+	`
+
+	result, err := db.Exec(query, userId, sentAt, previous)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim digest: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// Undo a claim after the digest failed to send
+func (db *DB) ReleaseDigest(userId int64, previous *time.Time) error {
+	if _, err := db.Exec("UPDATE digest_subscriptions SET last_sent_at = $2 WHERE user_id = $1", userId, previous); err != nil {
+		return fmt.Errorf("failed to release digest: %w", err)
+	}
+
+	return nil
+}
+
+// Get the highest scoring public posts made since a time.
+// A post's score is the number of comments other users left on it.
+func (db *DB) GetTopPostsSince(since time.Time, limit int) ([]model.DigestPost, error) {
+	query := `
+		SELECT p.post_id, p.title, p.author, p.date_posted, COUNT(c.comment_id) AS score
+		FROM posts p
+		JOIN users u ON u.user_id = p.user_id
+		LEFT JOIN comments c ON c.post_id = p.post_id AND c.user_id <> p.user_id AND NOT c.hidden
+		WHERE p.date_posted >= $1 AND NOT p.hidden AND NOT u.shadowbanned AND p.visibility = 'public'
+		GROUP BY p.post_id
+		ORDER BY score DESC, p.date_posted DESC
+		LIMIT $2
+	`
+
+	rows, err := db.Query(query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []model.DigestPost
+	for rows.Next() {
+		var post model.DigestPost
+		if err := rows.Scan(&post.PostId, &post.Title, &post.Author, &post.DatePosted, &post.Score); err != nil {
+			return nil, fmt.Errorf("failed to scan top posts: %w", err)
+		}
+
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
+
+// Get the users who wrote the most comments since a time on public posts (hidden comments and posts,
+// and banned or shadowbanned users, don't count)
+func (db *DB) GetTopCommentersSince(since time.Time, limit int) ([]model.DigestMember, error) {
+	query := `
+		SELECT u.user_id, COALESCE(pr.display_name, u.username), COUNT(*) AS comments
+		FROM comments c
+		JOIN posts p ON p.post_id = c.post_id
+		JOIN users u ON u.user_id = c.user_id
+		LEFT JOIN profiles pr ON pr.user_id = u.user_id
+		WHERE c.date_posted >= $1 AND NOT c.hidden AND NOT p.hidden AND p.visibility = 'public'
+			AND u.status = 'active' AND NOT u.shadowbanned
+		GROUP BY u.user_id, pr.display_name
+		ORDER BY comments DESC, u.user_id
+		LIMIT $2
+	`
+
+	rows, err := db.Query(query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top commenters: %w", err)
+	}
+	defer rows.Close()
+
+	members := []model.DigestMember{}
+	for rows.Next() {
+		var member model.DigestMember
+		if err := rows.Scan(&member.UserId, &member.Author, &member.Comments); err != nil {
+			return nil, fmt.Errorf("failed to scan top commenters: %w", err)
+		}
+
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// Get the newest members who registered on or after since's date (newest first, up to limit) and how
+// many registered in total. Banned and shadowbanned accounts are left out.
+func (db *DB) GetNewMembersSince(since time.Time, limit int) ([]model.DigestMember, int, error) {
+	query := `
+		SELECT u.user_id, COALESCE(p.display_name, u.username), p.date_registered, COUNT(*) OVER ()
+		FROM users u
+		JOIN profiles p ON p.user_id = u.user_id
+		WHERE p.date_registered >= $1::date AND u.status = 'active' AND NOT u.shadowbanned
+		ORDER BY p.date_registered DESC, u.user_id DESC
+		LIMIT $2
+	`
+
+	rows, err := db.Query(query, since, limit)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query new members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []model.DigestMember{}
+	total := 0
+	for rows.Next() {
+		var member model.DigestMember
+		var registered time.Time
+		if err := rows.Scan(&member.UserId, &member.Author, &registered, &total); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan new members: %w", err)
+		}
+
+		member.DateRegistered = &registered
+		members = append(members, member)
+	}
+
+	return members, total, nil
+}
+
+// #endregion
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// The name shown as the author of a user's posts and comments ($1 is the user ID)
+const authorNameQuery = `
+	SELECT COALESCE(p.display_name, u.username)
+	FROM users u
+	LEFT JOIN profiles p ON p.user_id = u.user_id
+	WHERE u.user_id = $1
+`
+
+// #region Display names
+
+// Get the name a user's new posts and comments are credited to (display name, falling back to username)
+func (db *DB) GetAuthorName(userId int64) (string, error) {
+	var name string
+	err := db.QueryRow(authorNameQuery, userId).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("user %w", ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query author name: %w", err)
+	}
+
+	return name, nil
+}
+
+// Get the current author details for a set of users, keyed by user ID (unknown users are left out)
+func (db *DB) GetAuthors(userIds []int64) (map[int64]model.Author, error) {
+	authors := make(map[int64]model.Author, len(userIds))
+	if len(userIds) == 0 {
+		return authors, nil
+	}
+
+	query := `
+		SELECT u.user_id, u.username, COALESCE(p.display_name, '')
+		FROM users u
+		LEFT JOIN profiles p ON p.user_id = u.user_id
+		WHERE u.user_id = ANY($1)
+	`
+
+	rows, err := db.Query(query, pq.Array(userIds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query authors: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var author model.Author
+		if err := rows.Scan(&author.UserId, &author.Username, &author.DisplayName); err != nil {
+			return nil, fmt.Errorf("failed to scan author: %w", err)
+		}
+		authors[author.UserId] = author
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating authors: %w", err)
+	}
+
+	return authors, nil
+}
+
+// Find users whose username or display name starts with prefix, for @mention autocompletes.
+// Banned and shadowbanned users are left out; exact username matches come first, then shorter names.
+func (db *DB) SuggestAuthors(ctx context.Context, prefix string, limit int) ([]model.Author, error) {
+	query := `
+		SELECT u.user_id, u.username, COALESCE(p.display_name, '')
+		FROM users u
+		LEFT JOIN profiles p ON p.user_id = u.user_id
+		WHERE (LOWER(u.username) LIKE $1 OR LOWER(p.display_name) LIKE $1)
+			AND u.status = 'active' AND NOT u.shadowbanned
+		ORDER BY LOWER(u.username) = $2 DESC, LENGTH(u.username), LOWER(u.username)
+		LIMIT $3
+	`
+
+	lowered := strings.ToLower(prefix)
+	rows, err := db.QueryContext(ctx, query, escapeLike(lowered)+"%", lowered, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query author suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	authors := []model.Author{}
+	for rows.Next() {
+		var author model.Author
+		if err := rows.Scan(&author.UserId, &author.Username, &author.DisplayName); err != nil {
+			return nil, fmt.Errorf("failed to scan author suggestion: %w", err)
+		}
+		authors = append(authors, author)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating author suggestions: %w", err)
+	}
+
+	return authors, nil
+}
+
+// Check whether another user already goes by name (as a username or display name, ignoring case)
+func (db *DB) IsNameTaken(userId int64, name string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM users WHERE LOWER(username) = LOWER($2) AND user_id <> $1)
+			OR EXISTS (SELECT 1 FROM profiles WHERE LOWER(display_name) = LOWER($2) AND user_id <> $1)
+	`
+
+	var taken bool
+	if err := db.QueryRow(query, userId, name).Scan(&taken); err != nil {
+		return false, fmt.Errorf("failed to check name: %w", err)
+	}
+
+	return taken, nil
+}
+
+// Set (or clear, when empty) a user's display name and re-credit their existing posts and comments (in one transaction)
+func (db *DB) SetDisplayName(userId int64, displayName string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE profiles SET display_name = NULLIF($2, '') WHERE user_id = $1", userId, displayName)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("display name %w", ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update display name: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("profile %w", ErrNotFound)
+	}
+
+	if err := recreditAuthor(tx, userId); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit display name: %w", err)
+	}
+
+	return nil
+}
+
+// Rewrites the author shown on a user's posts and comments after their display name changes
+func recreditAuthor(tx execer, userId int64) error {
+	for _, table := range []string{"posts", "comments"} {
+		query := "UPDATE " + table + " SET author = (" + authorNameQuery + ") WHERE user_id = $1"
+		if _, err := tx.Exec(query, userId); err != nil {
+			return fmt.Errorf("failed to update %s author: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// #endregion
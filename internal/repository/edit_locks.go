@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// #region Post edit locks
+
+// Get the live edit lock on a post, or nil when nobody holds one (expired locks don't count)
+func (db *DB) GetPostEditLock(postId int64, now time.Time) (*model.PostEditLock, error) {
+	query := `
+		SELECT l.post_id, l.user_id, COALESCE(p.display_name, u.username), l.acquired_at, l.expires_at
+		FROM post_edit_locks l
+		JOIN users u ON u.user_id = l.user_id
+		LEFT JOIN profiles p ON p.user_id = l.user_id
+		WHERE l.post_id = $1 AND l.expires_at > $2
+	`
+
+	var lock model.PostEditLock
+	err := db.QueryRow(query, postId, now).Scan(&lock.PostId, &lock.UserId, &lock.LockedBy, &lock.AcquiredAt, &lock.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post edit lock: %w", err)
+	}
+
+	return &lock, nil
+}
+
+// Take the edit lock on a post unless someone else holds a live one (reports whether it was taken).
+// An expired lock is replaced in the same statement, so two editors can't both take it.
+func (db *DB) AcquirePostEditLock(lock *model.PostEditLock, tokenHash string) (bool, error) {
+	query := `
+		INSERT INTO post_edit_locks (post_id, user_id, token_hash, acquired_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (post_id) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			token_hash = EXCLUDED.token_hash,
+			acquired_at = EXCLUDED.acquired_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE post_edit_locks.expires_at <= EXCLUDED.acquired_at
+	`
+
+	result, err := db.Exec(query, lock.PostId, lock.UserId, tokenHash, lock.AcquiredAt, lock.ExpiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire post edit lock: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// Push back the expiry of a live lock held with tokenHash (reports whether the lock was still held)
+func (db *DB) RenewPostEditLock(postId int64, tokenHash string, now, expiresAt time.Time) (bool, error) {
+	query := `
+		UPDATE post_edit_locks SET expires_at = $4
+		WHERE post_id = $1 AND token_hash = $2 AND expires_at > $3
+	`
+
+	result, err := db.Exec(query, postId, tokenHash, now, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to renew post edit lock: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// Release the lock held with tokenHash (releasing a lock that already expired or was taken over is a no-op)
+func (db *DB) ReleasePostEditLock(postId int64, tokenHash string) error {
+	if _, err := db.Exec("DELETE FROM post_edit_locks WHERE post_id = $1 AND token_hash = $2", postId, tokenHash); err != nil {
+		return fmt.Errorf("failed to release post edit lock: %w", err)
+	}
+
+	return nil
+}
+
+// Reports whether someone other than the holder of tokenHash has a live edit lock on a post
+func (db *DB) IsPostEditLockedByOther(postId int64, tokenHash string, now time.Time) (bool, error) {
+	var locked bool
+	query := "SELECT EXISTS (SELECT 1 FROM post_edit_locks WHERE post_id = $1 AND expires_at > $2 AND token_hash <> $3)"
+	if err := db.QueryRow(query, postId, now, tokenHash).Scan(&locked); err != nil {
+		return false, fmt.Errorf("failed to check post edit lock: %w", err)
+	}
+
+	return locked, nil
+}
+
+// #endregion
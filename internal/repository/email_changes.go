@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// #region Email changes
+
+// Create a pending email change request
+func (db *DB) CreateEmailChangeRequest(request *model.EmailChangeRequest) error {
+	query := `
+		INSERT INTO email_change_requests (token_hash, user_id, new_email, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	_, err := db.Exec(query, request.TokenHash, request.UserId, request.NewEmail, request.ExpiresAt, request.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create email change request: %w", err)
+	}
+
+	return nil
+}
+
+// Apply a pending email change: record the old address, update the profile, and consume the request
+func (db *DB) ConfirmEmailChange(tokenHash string) (*model.EmailChangeRequest, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Get the pending request (only if it hasn't expired)
+	query := `
+		DELETE FROM email_change_requests
+		WHERE token_hash = $1 AND expires_at > NOW()
+		RETURNING user_id, new_email, expires_at, created_at
+	`
+
+	request := model.EmailChangeRequest{TokenHash: tokenHash}
+	err = tx.QueryRow(query, tokenHash).Scan(&request.UserId, &request.NewEmail, &request.ExpiresAt, &request.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("email change request %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email change request: %w", err)
+	}
+
+	// Keep the previous address for account recovery
+	historyQuery := `
+		INSERT INTO email_history (user_id, email, changed_at)
+		SELECT user_id, email, NOW() FROM profiles
+		WHERE user_id = $1 AND email IS NOT NULL AND email <> ''
+	`
+	if _, err := tx.Exec(historyQuery, request.UserId); err != nil {
+		return nil, fmt.Errorf("failed to record email history: %w", err)
+	}
+
+	// Update the profile with the confirmed address
+	result, err := tx.Exec("UPDATE profiles SET email = $2 WHERE user_id = $1", request.UserId, request.NewEmail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("profile %w", ErrNotFound)
+	}
+
+	// Any other pending requests for this user are now stale
+	if _, err := tx.Exec("DELETE FROM email_change_requests WHERE user_id = $1", request.UserId); err != nil {
+		return nil, fmt.Errorf("failed to clear pending email changes: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit email change: %w", err)
+	}
+
+	log.Info().Int64("user_id", request.UserId).Msg("Email change confirmed")
+	return &request, nil
+}
+
+// Delete email change requests that expired before a time (returns how many were removed)
+func (db *DB) DeleteExpiredEmailChangeRequests(before time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM email_change_requests WHERE expires_at <= $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired email change requests: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// Get the previous email addresses of a user, newest first
+func (db *DB) GetEmailHistory(userId int64) ([]model.EmailHistoryEntry, error) {
+	query, args := db.newSelect("email, changed_at", "email_history").
+		Where("user_id = ?", userId).
+		OrderBy("changed_at DESC").
+		Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query email history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []model.EmailHistoryEntry
+	for rows.Next() {
+		var entry model.EmailHistoryEntry
+		if err := rows.Scan(&entry.Email, &entry.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan email history: %w", err)
+		}
+
+		history = append(history, entry)
+	}
+
+	return history, nil
+}
+
+// #endregion
@@ -0,0 +1,14 @@
+package repository
+
+import "errors"
+
+// Errors returned by repository methods (check with errors.Is)
+var (
+	// The requested row doesn't exist
+	ErrNotFound = errors.New("not found")
+	// The write would violate a uniqueness constraint (or remove a row that is still in use)
+	ErrConflict = errors.New("conflict")
+)
+
+// Postgres error code for unique_violation
+const uniqueViolationCode = "23505"
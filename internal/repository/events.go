@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// #region Events
+
+// Append an event to the log (a zero actor is stored as NULL)
+func (db *DB) RecordEvent(event *model.Event) error {
+	query := `
+		INSERT INTO events (event_type, actor_id, subject_type, subject_id, payload, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING event_id
+	`
+
+	var actorId sql.NullInt64
+	if event.ActorId != nil {
+		actorId = sql.NullInt64{Int64: int64(*event.ActorId), Valid: true}
+	}
+
+	err := db.QueryRow(query, event.Type, actorId, event.SubjectType, event.SubjectId, []byte(event.Payload), event.CreatedAt).
+		Scan(&event.EventId)
+	if err != nil {
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+
+	return nil
+}
+
+// Get events matching a filter, newest first
+func (db *DB) ListEvents(filter model.EventFilter) ([]model.Event, error) {
+	builder := db.newSelect(eventColumns, "events")
+	if filter.Type != "" {
+		whereEventTypes(builder, []string{filter.Type})
+	}
+	if filter.ActorId > 0 {
+		builder.Where("actor_id = ?", filter.ActorId)
+	}
+	if filter.Before > 0 {
+		builder.Where("event_id < ?", filter.Before)
+	}
+	query, args := builder.OrderBy("event_id DESC").Limit(filter.Limit).Offset(filter.Offset).Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []model.Event{}
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan events: %w", err)
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// Count the events a replay filter matches
+func (db *DB) CountReplayEvents(filter model.EventReplayFilter) (int, error) {
+	query, args := whereReplay(db.newSelect("COUNT(*)", "events"), filter).Build()
+
+	var count int
+	if err := db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count events: %w", err)
+	}
+
+	return count, nil
+}
+
+// Get up to limit events matching a replay filter with IDs above afterId, oldest first
+func (db *DB) GetReplayEvents(ctx context.Context, filter model.EventReplayFilter, afterId int64, limit int) ([]model.Event, error) {
+	query, args := whereReplay(db.newSelect(eventColumns, "events"), filter).
+		Where("event_id > ?", afterId).
+		OrderBy("event_id").
+		Limit(limit).
+		Build()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []model.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan events: %w", err)
+		}
+
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Adds the conditions of a replay filter
+func whereReplay(builder *selectBuilder, filter model.EventReplayFilter) *selectBuilder {
+	whereEventTypes(builder, filter.Types)
+	if filter.Since != nil {
+		builder.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		builder.Where("created_at < ?", *filter.Until)
+	}
+	if filter.AfterId > 0 {
+		builder.Where("event_id > ?", filter.AfterId)
+	}
+	return builder
+}
+
+// Adds a condition matching any of the event types (exact, or a prefix ending in ".*"); none matches every type
+func whereEventTypes(builder *selectBuilder, types []string) {
+	if len(types) == 0 {
+		return
+	}
+
+	conditions := make([]string, 0, len(types))
+	args := make([]interface{}, 0, len(types))
+	for _, eventType := range types {
+		if prefix, ok := strings.CutSuffix(eventType, ".*"); ok {
+			conditions = append(conditions, "event_type LIKE ?")
+			args = append(args, escapeLike(prefix)+".%")
+		} else {
+			conditions = append(conditions, "event_type = ?")
+			args = append(args, eventType)
+		}
+	}
+	builder.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// #endregion
+
+// #region Data access log
+
+// Record that an admin viewed a user's personal data (a nil user ID marks an entry covering no one user)
+func (db *DB) RecordDataAccess(access *model.DataAccess) error {
+	query := `
+		INSERT INTO data_access_log (user_id, viewer_id, access, created_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING access_id
+	`
+
+	var userId sql.NullInt64
+	if access.UserId != nil {
+		userId = sql.NullInt64{Int64: *access.UserId, Valid: true}
+	}
+
+	err := db.QueryRow(query, userId, access.ViewerId, access.Access, access.CreatedAt).Scan(&access.AccessId)
+	if err != nil {
+		return fmt.Errorf("failed to record data access: %w", err)
+	}
+
+	return nil
+}
+
+// Record that an admin viewed several users' personal data at once, one entry per user
+func (db *DB) RecordDataAccesses(viewerId int64, userIds []int64, access string, at time.Time) error {
+	query := `
+		INSERT INTO data_access_log (user_id, viewer_id, access, created_at)
+		SELECT UNNEST($1::BIGINT[]), $2, $3, $4
+	`
+
+	if _, err := db.Exec(query, pq.Array(userIds), viewerId, access, at); err != nil {
+		return fmt.Errorf("failed to record data access: %w", err)
+	}
+
+	return nil
+}
+
+// Get data access log entries matching a filter, newest first
+func (db *DB) ListDataAccess(filter model.DataAccessFilter) ([]model.DataAccess, error) {
+	builder := db.newSelect(dataAccessColumns, "data_access_log")
+	if filter.UserId > 0 {
+		builder.Where("user_id = ?", filter.UserId)
+	}
+	if filter.ViewerId > 0 {
+		builder.Where("viewer_id = ?", filter.ViewerId)
+	}
+	if filter.Before > 0 {
+		builder.Where("access_id < ?", filter.Before)
+	}
+	query, args := builder.OrderBy("access_id DESC").Limit(filter.Limit).Offset(filter.Offset).Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data access log: %w", err)
+	}
+	defer rows.Close()
+
+	accesses := []model.DataAccess{}
+	for rows.Next() {
+		access, err := scanDataAccess(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan data access log: %w", err)
+		}
+
+		accesses = append(accesses, access)
+	}
+
+	return accesses, nil
+}
+
+// #endregion
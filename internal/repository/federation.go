@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"fmt"
+	"time"
+)
+
+// #region Federation followers
+
+// Record that a remote actor follows a user (following again updates its inboxes)
+func (db *DB) AddFederationFollower(follower *model.FederationFollower) error {
+	query := `
+		INSERT INTO federation_followers (user_id, actor_id, inbox_url, shared_inbox_url)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		ON CONFLICT (user_id, actor_id) DO UPDATE
+		SET inbox_url = EXCLUDED.inbox_url, shared_inbox_url = EXCLUDED.shared_inbox_url
+	`
+
+	if _, err := db.Exec(query, follower.UserId, follower.ActorId, follower.InboxURL, follower.SharedInboxURL); err != nil {
+		return fmt.Errorf("failed to add federation follower: %w", err)
+	}
+
+	return nil
+}
+
+// Remove a remote follower (removing one that doesn't follow the user is not an error)
+func (db *DB) RemoveFederationFollower(userId int64, actorId string) error {
+	if _, err := db.Exec("DELETE FROM federation_followers WHERE user_id = $1 AND actor_id = $2", userId, actorId); err != nil {
+		return fmt.Errorf("failed to remove federation follower: %w", err)
+	}
+
+	return nil
+}
+
+// Count a user's remote followers
+func (db *DB) CountFederationFollowers(userId int64) (int, error) {
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM federation_followers WHERE user_id = $1", userId).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count federation followers: %w", err)
+	}
+
+	return count, nil
+}
+
+// Count a user's public posts (the ones their outbox lists)
+func (db *DB) CountPublicPosts(userId int64) (int, error) {
+	query := "SELECT COUNT(*) FROM posts WHERE user_id = $1 AND visibility = 'public' AND NOT hidden"
+
+	var count int
+	if err := db.QueryRow(query, userId).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count public posts: %w", err)
+	}
+
+	return count, nil
+}
+
+// #endregion
+
+// #region Federation deliveries
+
+// Claim up to limit public posts made since the given time that haven't been federated yet and whose
+// authors have remote followers, marking them federated. Claimed posts aren't returned again, so two
+// instances never publish the same post. Hidden posts and posts by banned or shadowbanned users are skipped.
+func (db *DB) ClaimPostsToFederate(since, now time.Time, limit int) ([]model.Post, error) {
+	query := `
+		UPDATE posts SET federated_at = $2
+		WHERE post_id IN (
+			SELECT post_id FROM posts
+			WHERE federated_at IS NULL AND date_posted > $1
+				AND visibility = 'public' AND NOT hidden
+				AND user_id IN (SELECT user_id FROM federation_followers)
+				AND user_id NOT IN (SELECT user_id FROM users WHERE status <> 'active' OR shadowbanned)
+			ORDER BY post_id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + postColumns
+
+	rows, err := db.Query(query, since, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim posts to federate: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []model.Post{}
+	for rows.Next() {
+		post, err := scanPost(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan post to federate: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating posts to federate: %w", err)
+	}
+
+	return posts, nil
+}
+
+// Queue an activity from a user for delivery to all their remote followers, once per server (followers
+// on a server with a shared inbox share one delivery). Returns how many deliveries were queued.
+func (db *DB) QueueFollowerDeliveries(userId int64, activity []byte) (int, error) {
+	query := `
+		INSERT INTO federation_deliveries (user_id, inbox_url, activity)
+		SELECT DISTINCT $1::BIGINT, COALESCE(shared_inbox_url, inbox_url), $2::JSONB
+		FROM federation_followers
+		WHERE user_id = $1
+	`
+
+	result, err := db.Exec(query, userId, string(activity))
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue follower deliveries: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rows), nil
+}
+
+// Queue an activity from a user for delivery to one inbox
+func (db *DB) QueueFederationDelivery(userId int64, inboxURL string, activity []byte) error {
+	query := "INSERT INTO federation_deliveries (user_id, inbox_url, activity) VALUES ($1, $2, $3)"
+
+	if _, err := db.Exec(query, userId, inboxURL, string(activity)); err != nil {
+		return fmt.Errorf("failed to queue federation delivery: %w", err)
+	}
+
+	return nil
+}
+
+// Claim up to limit deliveries that are due, counting the attempt and putting them off until leaseUntil
+// so other instances leave them alone while they're sent
+func (db *DB) ClaimFederationDeliveries(now, leaseUntil time.Time, limit int) ([]model.FederationDelivery, error) {
+	query := `
+		UPDATE federation_deliveries SET attempts = attempts + 1, next_attempt_at = $2
+		WHERE delivery_id IN (
+			SELECT delivery_id FROM federation_deliveries
+			WHERE next_attempt_at <= $1
+			ORDER BY next_attempt_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING delivery_id, user_id, inbox_url, activity, attempts
+	`
+
+	rows, err := db.Query(query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim federation deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []model.FederationDelivery{}
+	for rows.Next() {
+		var delivery model.FederationDelivery
+		if err := rows.Scan(&delivery.DeliveryId, &delivery.UserId, &delivery.InboxURL, &delivery.Activity, &delivery.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan federation delivery: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating federation deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Remove a delivery that was made or given up on
+func (db *DB) DeleteFederationDelivery(deliveryId int64) error {
+	if _, err := db.Exec("DELETE FROM federation_deliveries WHERE delivery_id = $1", deliveryId); err != nil {
+		return fmt.Errorf("failed to delete federation delivery: %w", err)
+	}
+
+	return nil
+}
+
+// Schedule another attempt at a failed delivery
+func (db *DB) RetryFederationDelivery(deliveryId int64, nextAttemptAt time.Time, lastError string) error {
+	query := "UPDATE federation_deliveries SET next_attempt_at = $2, last_error = $3 WHERE delivery_id = $1"
+
+	if _, err := db.Exec(query, deliveryId, nextAttemptAt, lastError); err != nil {
+		return fmt.Errorf("failed to reschedule federation delivery: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
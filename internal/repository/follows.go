@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// #region Follows
+
+// Make followerId follow followeeId (following someone twice is a no-op)
+func (db *DB) FollowUser(followerId, followeeId int64) error {
+	query := `
+		INSERT INTO follows (follower_id, followee_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := db.Exec(query, followerId, followeeId); err != nil {
+		return fmt.Errorf("failed to follow user: %w", err)
+	}
+
+	return nil
+}
+
+// Stop followerId following followeeId
+func (db *DB) UnfollowUser(followerId, followeeId int64) error {
+	result, err := db.Exec("DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2", followerId, followeeId)
+	if err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("follow %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Check whether followerId follows followeeId
+func (db *DB) IsFollowing(followerId, followeeId int64) (bool, error) {
+	var following bool
+	query := "SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)"
+	if err := db.QueryRow(query, followerId, followeeId).Scan(&following); err != nil {
+		return false, fmt.Errorf("failed to check follow: %w", err)
+	}
+
+	return following, nil
+}
+
+// Get which of the given users followerId follows, for marking them in responses
+func (db *DB) GetFollowedAmong(followerId int64, userIds []int64) (map[int64]bool, error) {
+	followed := make(map[int64]bool, len(userIds))
+	if len(userIds) == 0 {
+		return followed, nil
+	}
+
+	query := "SELECT followee_id FROM follows WHERE follower_id = $1 AND followee_id = ANY($2)"
+	rows, err := db.Query(query, followerId, pq.Array(userIds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query followed users: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userId int64
+		if err := rows.Scan(&userId); err != nil {
+			return nil, fmt.Errorf("failed to scan followed user: %w", err)
+		}
+		followed[userId] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating followed users: %w", err)
+	}
+
+	return followed, nil
+}
+
+// #endregion
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"fmt"
+	"time"
+)
+
+// #region Keyword alerts
+
+// Get a user's keyword alerts, oldest first
+func (db *DB) GetKeywordAlerts(userId int64) ([]model.KeywordAlert, error) {
+	query := "SELECT " + keywordAlertColumns + " FROM keyword_alerts WHERE user_id = $1 ORDER BY alert_id"
+
+	rows, err := db.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query keyword alerts: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := []model.KeywordAlert{}
+	for rows.Next() {
+		alert, err := scanKeywordAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan keyword alerts: %w", err)
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+// Create a keyword alert unless the user already has maxAlerts of them (reports whether it was created).
+// It starts after the newest existing post, so only posts made from now on match.
+func (db *DB) CreateKeywordAlert(alert *model.KeywordAlert, maxAlerts int) (bool, error) {
+	query := `
+		INSERT INTO keyword_alerts (user_id, keyword, last_post_id, created_at)
+		SELECT $1, $2, COALESCE((SELECT MAX(post_id) FROM posts), 0), $3
+		WHERE (SELECT COUNT(*) FROM keyword_alerts WHERE user_id = $1) < $4
+		RETURNING alert_id, last_post_id
+	`
+
+	rows, err := db.Query(query, alert.UserId, alert.Keyword, alert.CreatedAt, maxAlerts)
+	if isUniqueViolation(err) {
+		return false, fmt.Errorf("keyword alert %w", ErrConflict)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create keyword alert: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+	if err := rows.Scan(&alert.AlertId, &alert.LastPostId); err != nil {
+		return false, fmt.Errorf("failed to scan keyword alert: %w", err)
+	}
+
+	return true, nil
+}
+
+// Delete one of a user's keyword alerts
+func (db *DB) DeleteKeywordAlert(userId, alertId int64) error {
+	result, err := db.Exec("DELETE FROM keyword_alerts WHERE alert_id = $1 AND user_id = $2", alertId, userId)
+	if err != nil {
+		return fmt.Errorf("failed to delete keyword alert: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("keyword alert %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Get the ID of the newest post (0 when there are none)
+func (db *DB) GetLatestPostId() (int64, error) {
+	var postId int64
+	if err := db.QueryRow("SELECT COALESCE(MAX(post_id), 0) FROM posts").Scan(&postId); err != nil {
+		return 0, fmt.Errorf("failed to query latest post: %w", err)
+	}
+
+	return postId, nil
+}
+
+// Get the alerts of users in good standing that have unscanned posts up to upTo and weren't notified after cooledDown
+func (db *DB) GetDueKeywordAlerts(upTo int64, cooledDown time.Time) ([]model.KeywordAlert, error) {
+	query := "SELECT " + keywordAlertColumns + ` FROM keyword_alerts
+		WHERE last_post_id < $1
+			AND (last_notified_at IS NULL OR last_notified_at <= $2)
+			AND user_id NOT IN (SELECT user_id FROM users WHERE status <> 'active')
+		ORDER BY alert_id
+	`
+
+	rows, err := db.Query(query, upTo, cooledDown)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due keyword alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []model.KeywordAlert
+	for rows.Next() {
+		alert, err := scanKeywordAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan keyword alerts: %w", err)
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, nil
+}
+
+// Count the posts after the alert's last scanned post (up to upTo) whose title or content matches pattern,
+// a case-insensitive regular expression. Only posts the alert's owner can read count, and never their own
+// or ones they muted.
+func (db *DB) FindKeywordMatches(alert model.KeywordAlert, upTo int64, pattern string) (model.KeywordMatches, error) {
+	query, args := db.newSelect("COUNT(*), COALESCE(MAX(post_id), 0)", "posts").
+		Where("post_id > ? AND post_id <= ?", alert.LastPostId, upTo).
+		Where("user_id <> ?", alert.UserId).
+		Where("NOT hidden").
+		Where(visibleToViewer, alert.UserId).
+		Where(postAudience, postAudienceArgs(alert.UserId)...).
+		Where("NOT EXISTS (SELECT 1 FROM post_watches w WHERE w.user_id = ? AND w.post_id = posts.post_id AND w.muted)", alert.UserId).
+		Where("(title ~* ? OR content ~* ?)", pattern, pattern).
+		Build()
+
+	var matches model.KeywordMatches
+	if err := db.QueryRow(query, args...).Scan(&matches.Count, &matches.PostId); err != nil {
+		return model.KeywordMatches{}, fmt.Errorf("failed to match keyword: %w", err)
+	}
+	if matches.Count == 0 {
+		return matches, nil
+	}
+
+	if err := db.QueryRow("SELECT title FROM posts WHERE post_id = $1", matches.PostId).Scan(&matches.Title); err != nil {
+		return model.KeywordMatches{}, fmt.Errorf("failed to query matching post: %w", err)
+	}
+
+	return matches, nil
+}
+
+// Move an alert's scan position to upTo, unless another instance already moved it (reports whether this call
+// claimed the posts in between). notifiedAt is set when the claimed posts are being notified about.
+func (db *DB) ClaimKeywordMatches(alert model.KeywordAlert, upTo int64, notifiedAt *time.Time) (bool, error) {
+	query := `
+		UPDATE keyword_alerts SET last_post_id = $3, last_notified_at = COALESCE($4, last_notified_at)
+		WHERE alert_id = $1 AND last_post_id = $2
+	`
+
+	result, err := db.Exec(query, alert.AlertId, alert.LastPostId, upTo, notifiedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim keyword matches: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// #endregion
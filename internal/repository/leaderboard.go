@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"fmt"
+	"time"
+)
+
+// #region Leaderboard
+
+// Ranking expressions for the leaderboard
+var leaderboardOrders = map[string]string{
+	"karma":    "karma DESC, post_count DESC, comment_count DESC, u.user_id ASC",
+	"posts":    "post_count DESC, karma DESC, u.user_id ASC",
+	"comments": "comment_count DESC, karma DESC, u.user_id ASC",
+}
+
+// Rank users by activity since a given time (zero time means all time).
+// Karma is the number of comments other users left on the user's posts.
+func (db *DB) GetLeaderboard(since time.Time, rankBy string, limit int) ([]model.LeaderboardEntry, error) {
+	order, ok := leaderboardOrders[rankBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard ranking %q", rankBy)
+	}
+
+	query := `
+		WITH post_counts AS (
+			SELECT user_id, COUNT(*) AS total FROM posts
+			WHERE date_posted >= $1
+			GROUP BY user_id
+		), comment_counts AS (
+			SELECT user_id, COUNT(*) AS total FROM comments
+			WHERE date_posted >= $1
+			GROUP BY user_id
+		), karma_counts AS (
+			SELECT p.user_id, COUNT(*) AS total FROM comments c
+			JOIN posts p ON p.post_id = c.post_id
+			WHERE c.date_posted >= $1 AND c.user_id <> p.user_id
+			GROUP BY p.user_id
+		)
+		SELECT u.user_id, u.username,
+			COALESCE(k.total, 0) AS karma,
+			COALESCE(pc.total, 0) AS post_count,
+			COALESCE(cc.total, 0) AS comment_count
+		FROM users u
+		LEFT JOIN post_counts pc ON pc.user_id = u.user_id
+		LEFT JOIN comment_counts cc ON cc.user_id = u.user_id
+		LEFT JOIN karma_counts k ON k.user_id = u.user_id
+		WHERE (pc.total IS NOT NULL OR cc.total IS NOT NULL OR k.total IS NOT NULL)
+			AND NOT u.shadowbanned
+		ORDER BY ` + order + `
+		LIMIT $2
+	`
+
+	rows, err := db.Query(query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []model.LeaderboardEntry
+	for rows.Next() {
+		var entry model.LeaderboardEntry
+		if err := rows.Scan(&entry.UserId, &entry.Username, &entry.Karma, &entry.PostCount, &entry.CommentCount); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard: %w", err)
+		}
+
+		entry.Rank = len(entries) + 1
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// #endregion
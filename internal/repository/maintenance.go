@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+)
+
+// #region Maintenance
+
+// Indexes that back post search (trigram indexes used by ILIKE)
+var SearchIndexes = []string{
+	"idx_posts_title_trgm",
+	"idx_posts_content_trgm",
+}
+
+// Rebuild one search index without blocking writes to its table
+func (db *DB) RebuildIndex(ctx context.Context, index string) error {
+	// Index names can't be bound as parameters, so only allow known ones
+	known := false
+	for _, name := range SearchIndexes {
+		if name == index {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("unknown index %q", index)
+	}
+
+	if _, err := db.ExecContext(ctx, "REINDEX INDEX CONCURRENTLY "+index); err != nil {
+		return fmt.Errorf("failed to rebuild index %s: %w", index, err)
+	}
+
+	return nil
+}
+
+// Refresh planner statistics for the posts table
+func (db *DB) AnalyzePosts(ctx context.Context) error {
+	if _, err := db.ExecContext(ctx, "ANALYZE posts"); err != nil {
+		return fmt.Errorf("failed to analyze posts: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
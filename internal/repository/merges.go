@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// #region Account merges
+
+// Move everything a duplicate account owns to the surviving account, then delete the duplicate (in one
+// transaction). The survivor keeps its own profile fields, settings and role; the duplicate's only fill
+// gaps. With dryRun the transaction is rolled back, so the summary shows what a merge would move.
+func (db *DB) MergeUsers(sourceId, targetId int64, dryRun bool) (*model.AccountMergeSummary, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock both accounts so nothing is written for the duplicate while it's being emptied
+	var locked int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM (SELECT 1 FROM users WHERE user_id IN ($1, $2) FOR UPDATE) u", sourceId, targetId).Scan(&locked); err != nil {
+		return nil, fmt.Errorf("failed to lock users: %w", err)
+	}
+	if locked != 2 {
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	// Comment counts hide shadowbanned commenters, so posts the duplicate commented on are recounted
+	var commentedOn pq.Int64Array
+	if err := tx.QueryRow("SELECT ARRAY(SELECT DISTINCT post_id FROM comments WHERE user_id = $1)", sourceId).Scan(&commentedOn); err != nil {
+		return nil, fmt.Errorf("failed to find the duplicate's comments: %w", err)
+	}
+
+	summary := &model.AccountMergeSummary{SourceId: sourceId, TargetId: targetId, DryRun: dryRun}
+
+	// Statements whose affected rows are reported ($1 is the duplicate, $2 the survivor). Follows, watches and
+	// skills are copied where the survivor doesn't already have them; the duplicate's rows go with its account.
+	counted := []struct {
+		count *int64
+		query string
+	}{
+		{&summary.Posts, "UPDATE posts SET user_id = $2 WHERE user_id = $1"},
+		{&summary.Comments, "UPDATE comments SET user_id = $2 WHERE user_id = $1"},
+		{&summary.Snippets, "UPDATE snippets SET user_id = $2 WHERE user_id = $1"},
+		{&summary.Attachments, "UPDATE attachments SET user_id = $2 WHERE user_id = $1"},
+		{&summary.Projects, "UPDATE projects SET user_id = $2 WHERE user_id = $1"},
+		{&summary.Skills, `
+			INSERT INTO profile_skills (user_id, skill_id)
+			SELECT $2, skill_id FROM profile_skills WHERE user_id = $1
+			ON CONFLICT DO NOTHING`},
+		{&summary.Followers, `
+			INSERT INTO follows (follower_id, followee_id, created_at)
+			SELECT follower_id, $2, created_at FROM follows WHERE followee_id = $1 AND follower_id <> $2
+			ON CONFLICT DO NOTHING`},
+		{&summary.Following, `
+			INSERT INTO follows (follower_id, followee_id, created_at)
+			SELECT $2, followee_id, created_at FROM follows WHERE follower_id = $1 AND followee_id <> $2
+			ON CONFLICT DO NOTHING`},
+		{&summary.Watches, `
+			INSERT INTO post_watches (user_id, post_id, muted, created_at)
+			SELECT $2, post_id, muted, created_at FROM post_watches WHERE user_id = $1
+			ON CONFLICT DO NOTHING`},
+		{&summary.Notifications, "UPDATE notifications SET user_id = $2 WHERE user_id = $1"},
+		// An open report the survivor already filed on the same content wins; the duplicate's copy goes
+		{&summary.Reports, `
+			UPDATE reports r SET reporter_id = $2
+			WHERE r.reporter_id = $1
+				AND NOT (r.status IN ('open', 'reviewing') AND EXISTS (
+					SELECT 1 FROM reports o
+					WHERE o.reporter_id = $2 AND o.target_type = r.target_type AND o.target_id = r.target_id
+						AND o.status IN ('open', 'reviewing')
+				))`},
+		{&summary.ModerationActions, "UPDATE moderation_actions SET target_user_id = $2 WHERE target_user_id = $1"},
+	}
+	for _, statement := range counted {
+		result, err := tx.Exec(statement.query, sourceId, targetId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge accounts: %w", err)
+		}
+		if *statement.count, err = result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+	}
+
+	// Everything else that points at the duplicate is repointed or copied without being reported
+	uncounted := []string{
+		"UPDATE users SET storage_used_bytes = storage_used_bytes + (SELECT storage_used_bytes FROM users WHERE user_id = $1) WHERE user_id = $2",
+		"UPDATE reports SET assigned_to = $2 WHERE assigned_to = $1",
+		"UPDATE reports SET resolved_by = $2 WHERE resolved_by = $1",
+		"UPDATE moderation_actions SET actor_id = $2 WHERE actor_id = $1",
+		"UPDATE appeals SET user_id = $2 WHERE user_id = $1",
+		"UPDATE appeals SET resolved_by = $2 WHERE resolved_by = $1",
+		"UPDATE announcements SET created_by = $2 WHERE created_by = $1",
+		"UPDATE backups SET triggered_by = $2 WHERE triggered_by = $1",
+		"UPDATE service_clients SET created_by = $2 WHERE created_by = $1",
+		"UPDATE signups SET user_id = $2 WHERE user_id = $1",
+		"UPDATE email_history SET user_id = $2 WHERE user_id = $1",
+		"INSERT INTO user_activity (day, user_id) SELECT day, $2 FROM user_activity WHERE user_id = $1 ON CONFLICT DO NOTHING",
+		`INSERT INTO announcement_dismissals (user_id, announcement_id, dismissed_at)
+			SELECT $2, announcement_id, dismissed_at FROM announcement_dismissals WHERE user_id = $1
+			ON CONFLICT DO NOTHING`,
+		`INSERT INTO digest_subscriptions (user_id, frequency, last_sent_at)
+			SELECT $2, frequency, last_sent_at FROM digest_subscriptions WHERE user_id = $1
+			ON CONFLICT DO NOTHING`,
+		`INSERT INTO notification_settings (user_id, event_type, email, in_app)
+			SELECT $2, event_type, email, in_app FROM notification_settings WHERE user_id = $1
+			ON CONFLICT DO NOTHING`,
+		`INSERT INTO comment_reactions (comment_id, user_id, reaction, created_at)
+			SELECT comment_id, $2, reaction, created_at FROM comment_reactions WHERE user_id = $1
+			ON CONFLICT DO NOTHING`,
+		`INSERT INTO board_subscriptions (user_id, board_id, created_at)
+			SELECT $2, board_id, created_at FROM board_subscriptions WHERE user_id = $1
+			ON CONFLICT DO NOTHING`,
+		`INSERT INTO tag_subscriptions (user_id, tag, created_at)
+			SELECT $2, tag, created_at FROM tag_subscriptions WHERE user_id = $1
+			ON CONFLICT DO NOTHING`,
+		`INSERT INTO keyword_alerts (user_id, keyword, last_post_id, last_notified_at, created_at)
+			SELECT $2, keyword, last_post_id, last_notified_at, created_at FROM keyword_alerts WHERE user_id = $1
+			ON CONFLICT DO NOTHING`,
+	}
+	for _, query := range uncounted {
+		if _, err := tx.Exec(query, sourceId, targetId); err != nil {
+			return nil, fmt.Errorf("failed to merge accounts: %w", err)
+		}
+	}
+
+	if err := mergeProfiles(tx, sourceId, targetId); err != nil {
+		return nil, err
+	}
+	if err := recreditAuthor(tx, targetId); err != nil {
+		return nil, err
+	}
+
+	// Retire the duplicate; whatever it still holds (its own profile, copied follows, pending email changes) goes with it
+	if _, err := tx.Exec("DELETE FROM users WHERE user_id = $1", sourceId); err != nil {
+		return nil, fmt.Errorf("failed to delete the duplicate account: %w", err)
+	}
+
+	if len(commentedOn) > 0 {
+		if err := refreshCommentCounts(tx, "SELECT UNNEST($1::bigint[])", commentedOn); err != nil {
+			return nil, err
+		}
+	}
+
+	if dryRun {
+		return summary, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit account merge: %w", err)
+	}
+
+	return summary, nil
+}
+
+// Fills the survivor's empty profile fields from the duplicate's profile. The display name moves over
+// only when the survivor has none, and is released from the duplicate first since names are unique.
+func mergeProfiles(tx *Tx, sourceId, targetId int64) error {
+	query := `
+		UPDATE profiles t SET
+			first_name = COALESCE(NULLIF(t.first_name, ''), s.first_name),
+			last_name = COALESCE(NULLIF(t.last_name, ''), s.last_name),
+			email = COALESCE(NULLIF(t.email, ''), s.email),
+			github_link = COALESCE(NULLIF(t.github_link, ''), s.github_link),
+			city = COALESCE(NULLIF(t.city, ''), s.city),
+			state = COALESCE(NULLIF(t.state, ''), s.state),
+			date_registered = LEAST(t.date_registered, s.date_registered)
+		FROM profiles s
+		WHERE t.user_id = $2 AND s.user_id = $1
+	`
+	if _, err := tx.Exec(query, sourceId, targetId); err != nil {
+		return fmt.Errorf("failed to merge profiles: %w", err)
+	}
+
+	var displayName *string
+	if err := tx.QueryRow("SELECT (SELECT display_name FROM profiles WHERE user_id = $1)", sourceId).Scan(&displayName); err != nil {
+		return fmt.Errorf("failed to query display name: %w", err)
+	}
+	if displayName == nil {
+		return nil
+	}
+
+	if _, err := tx.Exec("UPDATE profiles SET display_name = NULL WHERE user_id = $1", sourceId); err != nil {
+		return fmt.Errorf("failed to release display name: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE profiles SET display_name = $2 WHERE user_id = $1 AND display_name IS NULL", targetId, *displayName); err != nil {
+		return fmt.Errorf("failed to move display name: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"fmt"
+	"time"
+)
+
+// #region Metrics
+
+// Record that a user was active on a day and bump their last activity time
+func (db *DB) RecordUserActivity(userId int64, day time.Time) error {
+	query := `
+		WITH active AS (
+			UPDATE users SET last_active_at = NOW()
+			WHERE user_id = $1
+			RETURNING user_id
+		)
+		INSERT INTO user_activity (day, user_id)
+		SELECT $2::date, user_id FROM active
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := db.Exec(query, userId, day); err != nil {
+		return fmt.Errorf("failed to record user activity: %w", err)
+	}
+
+	return nil
+}
+
+// Get daily, weekly and monthly active users for each day in a range (inclusive).
+// Weekly and monthly counts are rolling 7 and 30 day windows ending on that day.
+func (db *DB) GetActiveUserMetrics(from, to time.Time) ([]model.ActiveUsersPoint, error) {
+	query := `
+		SELECT d::date,
+			(SELECT COUNT(*) FROM user_activity WHERE day = d::date),
+			(SELECT COUNT(DISTINCT user_id) FROM user_activity WHERE day > d::date - 7 AND day <= d::date),
+			(SELECT COUNT(DISTINCT user_id) FROM user_activity WHERE day > d::date - 30 AND day <= d::date)
+		FROM generate_series($1::date, $2::date, INTERVAL '1 day') AS d
+		ORDER BY d
+	`
+
+	rows, err := db.Query(query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active user metrics: %w", err)
+	}
+	defer rows.Close()
+
+	points := []model.ActiveUsersPoint{}
+	for rows.Next() {
+		var point model.ActiveUsersPoint
+		var day time.Time
+		if err := rows.Scan(&day, &point.DAU, &point.WAU, &point.MAU); err != nil {
+			return nil, fmt.Errorf("failed to scan active user metrics: %w", err)
+		}
+		point.Date = day.Format(model.MetricsDateLayout)
+
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+// Get the number of new users, posts and comments on each day in a range (inclusive)
+func (db *DB) GetGrowthMetrics(from, to time.Time) ([]model.GrowthPoint, error) {
+	query := `
+		WITH days AS (
+			SELECT d::date AS day FROM generate_series($1::date, $2::date, INTERVAL '1 day') AS d
+		), new_users AS (
+			SELECT date_registered AS day, COUNT(*) AS total FROM profiles
+			WHERE date_registered BETWEEN $1::date AND $2::date
+			GROUP BY date_registered
+		), new_posts AS (
+			SELECT date_posted::date AS day, COUNT(*) AS total FROM posts
+			WHERE date_posted >= $1::date AND date_posted < $2::date + 1
+			GROUP BY date_posted::date
+		), new_comments AS (
+			SELECT date_posted::date AS day, COUNT(*) AS total FROM comments
+			WHERE date_posted >= $1::date AND date_posted < $2::date + 1
+			GROUP BY date_posted::date
+		)
+		SELECT days.day, COALESCE(u.total, 0), COALESCE(p.total, 0), COALESCE(c.total, 0)
+		FROM days
+		LEFT JOIN new_users u ON u.day = days.day
+		LEFT JOIN new_posts p ON p.day = days.day
+		LEFT JOIN new_comments c ON c.day = days.day
+		ORDER BY days.day
+	`
+
+	rows, err := db.Query(query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query growth metrics: %w", err)
+	}
+	defer rows.Close()
+
+	points := []model.GrowthPoint{}
+	for rows.Next() {
+		var point model.GrowthPoint
+		var day time.Time
+		if err := rows.Scan(&day, &point.Users, &point.Posts, &point.Comments); err != nil {
+			return nil, fmt.Errorf("failed to scan growth metrics: %w", err)
+		}
+		point.Date = day.Format(model.MetricsDateLayout)
+
+		points = append(points, point)
+	}
+
+	return points, nil
+}
+
+// #endregion
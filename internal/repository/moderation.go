@@ -0,0 +1,444 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Statements that apply each moderation action, keyed by target type and action.
+// Each takes the target ID as $1 (and actions in moderationExpires their expiry as $2).
+var moderationStatements = map[string]string{
+	model.ReportTargetPost + ":" + model.ModerationHide:            "UPDATE posts SET hidden = TRUE WHERE post_id = $1",
+	model.ReportTargetPost + ":" + model.ModerationUnhide:          "UPDATE posts SET hidden = FALSE WHERE post_id = $1",
+	model.ReportTargetPost + ":" + model.ModerationLock:            "UPDATE posts SET locked = TRUE WHERE post_id = $1",
+	model.ReportTargetPost + ":" + model.ModerationUnlock:          "UPDATE posts SET locked = FALSE WHERE post_id = $1",
+	model.ReportTargetComment + ":" + model.ModerationHide:         "UPDATE comments SET hidden = TRUE WHERE comment_id = $1",
+	model.ReportTargetComment + ":" + model.ModerationUnhide:       "UPDATE comments SET hidden = FALSE WHERE comment_id = $1",
+	model.ModerationTargetUser + ":" + model.ModerationBan:         "UPDATE users SET status = 'banned', banned_until = NULL, token_version = token_version + 1 WHERE user_id = $1",
+	model.ModerationTargetUser + ":" + model.ModerationSuspend:     "UPDATE users SET status = 'suspended', banned_until = $2, token_version = token_version + 1 WHERE user_id = $1",
+	model.ModerationTargetUser + ":" + model.ModerationUnban:       "UPDATE users SET status = 'active', banned_until = NULL WHERE user_id = $1",
+	model.ModerationTargetUser + ":" + model.ModerationShadowban:   "UPDATE users SET shadowbanned = TRUE WHERE user_id = $1",
+	model.ModerationTargetUser + ":" + model.ModerationUnshadowban: "UPDATE users SET shadowbanned = FALSE WHERE user_id = $1",
+	model.ModerationTargetUser + ":" + model.ModerationResetName:   "UPDATE profiles SET display_name = NULL WHERE user_id = $1",
+}
+
+// Posts whose comment counts change with each action, as a subquery on the target ID ($1)
+var moderationCountedPosts = map[string]string{
+	model.ReportTargetComment + ":" + model.ModerationHide:         "SELECT post_id FROM comments WHERE comment_id = $1",
+	model.ReportTargetComment + ":" + model.ModerationUnhide:       "SELECT post_id FROM comments WHERE comment_id = $1",
+	model.ModerationTargetUser + ":" + model.ModerationShadowban:   "SELECT post_id FROM comments WHERE user_id = $1",
+	model.ModerationTargetUser + ":" + model.ModerationUnshadowban: "SELECT post_id FROM comments WHERE user_id = $1",
+}
+
+// Actions that last until the action's ExpiresAt
+var moderationExpires = map[string]bool{
+	model.ModerationTargetUser + ":" + model.ModerationSuspend: true,
+}
+
+// Actions that change the name a user's posts and comments are credited to
+var moderationRecreditsAuthor = map[string]bool{
+	model.ModerationTargetUser + ":" + model.ModerationResetName: true,
+}
+
+// Reports whether an action can be applied to a target type
+func IsModerationActionSupported(targetType, action string) bool {
+	_, ok := moderationStatements[targetType+":"+action]
+	return ok
+}
+
+// Reports whether an action needs an expiry
+func IsModerationActionTimed(targetType, action string) bool {
+	return moderationExpires[targetType+":"+action]
+}
+
+// #region Moderation actions
+
+// Apply a moderation action and record it in the audit trail (in one transaction)
+func (db *DB) ApplyModerationAction(action *model.ModerationAction) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := applyModerationAction(tx, action); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit moderation action: %w", err)
+	}
+
+	return nil
+}
+
+// Applies and records a moderation action inside a transaction
+func applyModerationAction(tx *Tx, action *model.ModerationAction) error {
+	statement, ok := moderationStatements[action.TargetType+":"+action.Action]
+	if !ok {
+		return fmt.Errorf("unsupported moderation action %s on %s", action.Action, action.TargetType)
+	}
+
+	args := []interface{}{action.TargetId}
+	if moderationExpires[action.TargetType+":"+action.Action] {
+		args = append(args, action.ExpiresAt)
+	}
+
+	result, err := tx.Exec(statement, args...)
+	if err != nil {
+		return fmt.Errorf("failed to apply moderation action: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("%s %w", action.TargetType, ErrNotFound)
+	}
+
+	if postIds, ok := moderationCountedPosts[action.TargetType+":"+action.Action]; ok {
+		if err := refreshCommentCounts(tx, postIds, action.TargetId); err != nil {
+			return err
+		}
+	}
+
+	if moderationRecreditsAuthor[action.TargetType+":"+action.Action] {
+		if err := recreditAuthor(tx, action.TargetId); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		INSERT INTO moderation_actions (actor_id, action, target_type, target_id, target_user_id, reason, report_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING action_id
+	`
+
+	err = tx.QueryRow(query, action.ActorId, action.Action, action.TargetType, action.TargetId, action.TargetUserId, action.Reason, action.ReportId, action.ExpiresAt, action.CreatedAt).
+		Scan(&action.ActionId)
+	if err != nil {
+		return fmt.Errorf("failed to record moderation action: %w", err)
+	}
+
+	return nil
+}
+
+// Get moderation actions matching a filter (newest first)
+func (db *DB) ListModerationActions(filter model.ModerationActionFilter) ([]model.ModerationAction, error) {
+	builder := db.newSelect(moderationActionColumns, "moderation_actions")
+	if filter.TargetUserId > 0 {
+		builder.Where("target_user_id = ?", filter.TargetUserId)
+	}
+	if filter.Action != "" {
+		builder.Where("action = ?", filter.Action)
+	}
+	if filter.VisibleToTarget {
+		builder.Where("action NOT IN (?, ?)", model.ModerationShadowban, model.ModerationUnshadowban)
+	}
+	query, args := builder.OrderBy("created_at DESC, action_id DESC").Limit(filter.Limit).Offset(filter.Offset).Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query moderation actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []model.ModerationAction{}
+	for rows.Next() {
+		action, err := scanModerationAction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan moderation actions: %w", err)
+		}
+
+		actions = append(actions, action)
+	}
+
+	return actions, nil
+}
+
+// Get moderation action by ID
+func (db *DB) GetModerationAction(actionId int64) (*model.ModerationAction, error) {
+	query := "SELECT " + moderationActionColumns + " FROM moderation_actions WHERE action_id = $1"
+
+	action, err := scanModerationAction(db.QueryRow(query, actionId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("moderation action %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query moderation action: %w", err)
+	}
+
+	return &action, nil
+}
+
+// Get the users whose suspensions ended at or before a time (the expiry job lifts them)
+func (db *DB) GetExpiredSuspensions(at time.Time) ([]int64, error) {
+	rows, err := db.Query("SELECT user_id FROM users WHERE status = 'suspended' AND banned_until <= $1 ORDER BY banned_until", at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query expired suspensions: %w", err)
+	}
+	defer rows.Close()
+
+	var userIds []int64
+	for rows.Next() {
+		var userId int64
+		if err := rows.Scan(&userId); err != nil {
+			return nil, fmt.Errorf("failed to scan expired suspension: %w", err)
+		}
+		userIds = append(userIds, userId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating expired suspensions: %w", err)
+	}
+
+	return userIds, nil
+}
+
+// Check whether a user is shadowbanned (false for unknown users)
+func (db *DB) IsUserShadowbanned(userId int64) (bool, error) {
+	query := "SELECT EXISTS(SELECT 1 FROM users WHERE user_id = $1 AND shadowbanned)"
+
+	var shadowbanned bool
+	if err := db.QueryRow(query, userId).Scan(&shadowbanned); err != nil {
+		return false, fmt.Errorf("failed to check if user is shadowbanned: %w", err)
+	}
+
+	return shadowbanned, nil
+}
+
+// #endregion
+
+// #region Appeals
+
+// Get appeals matching a filter (oldest first, so the queue is worked in order)
+func (db *DB) ListAppeals(filter model.AppealFilter) ([]model.Appeal, error) {
+	builder := db.newSelect(appealColumns, "appeals")
+	if filter.Status != "" {
+		builder.Where("status = ?", filter.Status)
+	}
+	if filter.UserId > 0 {
+		builder.Where("user_id = ?", filter.UserId)
+	}
+	query, args := builder.OrderBy("created_at ASC, appeal_id ASC").Limit(filter.Limit).Offset(filter.Offset).Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appeals: %w", err)
+	}
+	defer rows.Close()
+
+	appeals := []model.Appeal{}
+	for rows.Next() {
+		appeal, err := scanAppeal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan appeals: %w", err)
+		}
+
+		appeals = append(appeals, appeal)
+	}
+
+	return appeals, nil
+}
+
+// Get appeal by ID
+func (db *DB) GetAppealById(appealId int64) (*model.Appeal, error) {
+	query := "SELECT " + appealColumns + " FROM appeals WHERE appeal_id = $1"
+
+	appeal, err := scanAppeal(db.QueryRow(query, appealId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("appeal %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query appeal: %w", err)
+	}
+
+	return &appeal, nil
+}
+
+// Create an appeal (ErrConflict if the action was already appealed)
+func (db *DB) CreateAppeal(appeal *model.Appeal) error {
+	query := `
+		INSERT INTO appeals (action_id, user_id, message, status, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING appeal_id
+	`
+
+	err := db.QueryRow(query, appeal.ActionId, appeal.UserId, appeal.Message, appeal.Status, appeal.CreatedAt).Scan(&appeal.AppealId)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("appeal %w", ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create appeal: %w", err)
+	}
+
+	return nil
+}
+
+// Record an appeal decision, applying the reversal action if the original action was overturned
+func (db *DB) ResolveAppeal(appeal *model.Appeal, reversal *model.ModerationAction) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if reversal != nil {
+		if err := applyModerationAction(tx, reversal); err != nil {
+			return err
+		}
+	}
+
+	query := `
+		UPDATE appeals
+		SET status = $2,
+		resolved_by = $3,
+		resolution_note = $4,
+		resolved_at = $5
+		WHERE appeal_id = $1 AND status = $6
+	`
+
+	result, err := tx.Exec(query, appeal.AppealId, appeal.Status, appeal.ResolvedBy, appeal.ResolutionNote, appeal.ResolvedAt, model.AppealStatusPending)
+	if err != nil {
+		return fmt.Errorf("failed to resolve appeal: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		// Someone else resolved it first
+		return fmt.Errorf("pending appeal %w", ErrNotFound)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit appeal decision: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
+
+// #region Moderator stats
+
+// Get each moderator's actions, resolved reports and decided appeals over a range of days (inclusive),
+// busiest first. Moderators with nothing in the range are left out.
+func (db *DB) GetModeratorStats(from, to time.Time) ([]model.ModeratorStats, error) {
+	stats := make(map[int64]*model.ModeratorStats)
+	moderator := func(id int64) *model.ModeratorStats {
+		if _, ok := stats[id]; !ok {
+			stats[id] = &model.ModeratorStats{ModeratorId: id, Actions: make(map[string]int)}
+		}
+		return stats[id]
+	}
+
+	// Actions taken (the system's own, like lifting suspensions, have no actor)
+	rows, err := db.Query(`
+		SELECT actor_id, action, COUNT(*) FROM moderation_actions
+		WHERE actor_id IS NOT NULL AND created_at >= $1::date AND created_at < $2::date + 1
+		GROUP BY actor_id, action
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query moderator actions: %w", err)
+	}
+	for rows.Next() {
+		var actorId int64
+		var action string
+		var count int
+		if err := rows.Scan(&actorId, &action, &count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan moderator actions: %w", err)
+		}
+		moderator(actorId).Actions[action] = count
+		moderator(actorId).ActionCount += count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating moderator actions: %w", err)
+	}
+
+	// Reports resolved, with the time each took from filing
+	rows, err = db.Query(`
+		SELECT resolved_by, COUNT(*), COUNT(*) FILTER (WHERE status = $3),
+			AVG(EXTRACT(EPOCH FROM resolved_at - created_at)),
+			PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM resolved_at - created_at))
+		FROM reports
+		WHERE resolved_by IS NOT NULL AND resolved_at >= $1::date AND resolved_at < $2::date + 1
+		GROUP BY resolved_by
+	`, from, to, model.ReportStatusActioned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query moderator reports: %w", err)
+	}
+	for rows.Next() {
+		var resolvedBy int64
+		var resolved, actioned int
+		var avg, median float64
+		if err := rows.Scan(&resolvedBy, &resolved, &actioned, &avg, &median); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan moderator reports: %w", err)
+		}
+		m := moderator(resolvedBy)
+		m.ReportsResolved, m.ReportsActioned = resolved, actioned
+		m.AvgResolutionSeconds, m.MedianResolutionSeconds = &avg, &median
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating moderator reports: %w", err)
+	}
+
+	// Appeals against the moderator's actions decided in the range
+	rows, err = db.Query(`
+		SELECT a.actor_id, COUNT(*) FILTER (WHERE ap.status = $3), COUNT(*) FILTER (WHERE ap.status = $4)
+		FROM appeals ap
+		JOIN moderation_actions a ON a.action_id = ap.action_id
+		WHERE a.actor_id IS NOT NULL AND ap.resolved_at >= $1::date AND ap.resolved_at < $2::date + 1
+		GROUP BY a.actor_id
+	`, from, to, model.AppealStatusUpheld, model.AppealStatusOverturned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query moderator appeals: %w", err)
+	}
+	for rows.Next() {
+		var actorId int64
+		var upheld, overturned int
+		if err := rows.Scan(&actorId, &upheld, &overturned); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan moderator appeals: %w", err)
+		}
+		moderator(actorId).AppealsUpheld, moderator(actorId).AppealsOverturned = upheld, overturned
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating moderator appeals: %w", err)
+	}
+
+	moderatorIds := make([]int64, 0, len(stats))
+	for id := range stats {
+		moderatorIds = append(moderatorIds, id)
+	}
+	authors, err := db.GetAuthors(moderatorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]model.ModeratorStats, 0, len(stats))
+	for _, m := range stats {
+		m.Username = authors[m.ModeratorId].Username
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ActionCount+result[i].ReportsResolved != result[j].ActionCount+result[j].ReportsResolved {
+			return result[i].ActionCount+result[i].ReportsResolved > result[j].ActionCount+result[j].ReportsResolved
+		}
+		return result[i].ModeratorId < result[j].ModeratorId
+	})
+
+	return result, nil
+}
+
+// #endregion
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"fmt"
+	"time"
+)
+
+// #region Notification settings
+
+// Get the notification settings a user has saved (event types they never changed are omitted)
+func (db *DB) GetNotificationSettings(userId int64) ([]model.NotificationSetting, error) {
+	query := "SELECT event_type, email, in_app FROM notification_settings WHERE user_id = $1"
+
+	rows, err := db.Query(query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification settings: %w", err)
+	}
+	defer rows.Close()
+
+	var settings []model.NotificationSetting
+	for rows.Next() {
+		var setting model.NotificationSetting
+		if err := rows.Scan(&setting.EventType, &setting.Email, &setting.InApp); err != nil {
+			return nil, fmt.Errorf("failed to scan notification settings: %w", err)
+		}
+
+		settings = append(settings, setting)
+	}
+
+	return settings, nil
+}
+
+// Save a user's settings for one event type
+func (db *DB) SetNotificationSetting(userId int64, setting model.NotificationSetting) error {
+	query := `
+		INSERT INTO notification_settings (user_id, event_type, email, in_app)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, event_type) DO UPDATE SET email = EXCLUDED.email, in_app = EXCLUDED.in_app
+	`
+
+	if _, err := db.Exec(query, userId, setting.EventType, setting.Email, setting.InApp); err != nil {
+		return fmt.Errorf("failed to save notification setting: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
+
+// #region Notifications
+
+// Create an in-app notification
+func (db *DB) CreateNotification(notification *model.Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, event_type, message, link, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING notification_id
+	`
+
+	err := db.QueryRow(query, notification.UserId, notification.EventType, notification.Message, notification.Link, notification.CreatedAt).
+		Scan(&notification.NotificationId)
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+
+	return nil
+}
+
+// Get a user's notifications (newest first)
+func (db *DB) ListNotifications(filter model.NotificationFilter) ([]model.Notification, error) {
+	builder := db.newSelect(notificationColumns, "notifications").Where("user_id = ?", filter.UserId)
+	if filter.UnreadOnly {
+		builder.Where("read_at IS NULL")
+	}
+	query, args := builder.OrderBy("created_at DESC, notification_id DESC").Limit(filter.Limit).Offset(filter.Offset).Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []model.Notification{}
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notifications: %w", err)
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// Count a user's unread notifications (backed by a partial index, so it stays cheap to poll)
+func (db *DB) GetUnreadNotifications(userId int64) (model.UnreadNotifications, error) {
+	query := "SELECT COUNT(*), COALESCE(MAX(notification_id), 0) FROM notifications WHERE user_id = $1 AND read_at IS NULL"
+
+	var unread model.UnreadNotifications
+	if err := db.QueryRow(query, userId).Scan(&unread.Count, &unread.LatestId); err != nil {
+		return model.UnreadNotifications{}, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+
+	return unread, nil
+}
+
+// Mark one of a user's notifications as read
+func (db *DB) MarkNotificationRead(userId, notificationId int64, readAt time.Time) error {
+	query := "UPDATE notifications SET read_at = COALESCE(read_at, $3) WHERE notification_id = $1 AND user_id = $2"
+
+	result, err := db.Exec(query, notificationId, userId, readAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("notification %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Mark all of a user's notifications as read
+func (db *DB) MarkAllNotificationsRead(userId int64, readAt time.Time) error {
+	query := "UPDATE notifications SET read_at = $2 WHERE user_id = $1 AND read_at IS NULL"
+
+	if _, err := db.Exec(query, userId, readAt); err != nil {
+		return fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// #region Password resets
+
+// Get the accounts whose profile email matches, ignoring case (one address can be on several profiles)
+func (db *DB) GetPasswordResetRecipients(email string) ([]model.PasswordResetRecipient, error) {
+	query := `
+		SELECT u.user_id, u.username, p.email
+		FROM users u
+		JOIN profiles p ON p.user_id = u.user_id
+		WHERE LOWER(p.email) = LOWER($1)
+		ORDER BY u.user_id
+	`
+
+	rows, err := db.Query(query, email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query accounts by email: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []model.PasswordResetRecipient
+	for rows.Next() {
+		var recipient model.PasswordResetRecipient
+		if err := rows.Scan(&recipient.UserId, &recipient.Username, &recipient.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan accounts by email: %w", err)
+		}
+
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+// Store a password reset token unless the user already requested maxRecent within the window ending at
+// reset.CreatedAt (reports whether it was stored)
+func (db *DB) CreatePasswordReset(reset *model.PasswordReset, maxRecent int, window time.Duration) (bool, error) {
+	query := `
+		INSERT INTO password_resets (token_hash, user_id, expires_at, created_at)
+		SELECT $1, $2, $3, $4
+		WHERE (SELECT COUNT(*) FROM password_resets WHERE user_id = $2 AND created_at > $5) < $6
+	`
+
+	result, err := db.Exec(query, reset.TokenHash, reset.UserId, reset.ExpiresAt, reset.CreatedAt, reset.CreatedAt.Add(-window), maxRecent)
+	if err != nil {
+		return false, fmt.Errorf("failed to create password reset: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// Set a new password with a reset token: the token is consumed, every other reset token the user has is
+// dropped, the token version is bumped so existing sessions end, and the user's API keys are revoked
+// (in one transaction). Returns the user ID.
+func (db *DB) ResetPassword(tokenHash, hashedPassword string, now time.Time) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userId int64
+	err = tx.QueryRow("DELETE FROM password_resets WHERE token_hash = $1 AND expires_at > $2 RETURNING user_id", tokenHash, now).Scan(&userId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("password reset %w", ErrNotFound)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to use password reset: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE users SET hashed_password = $2, token_version = token_version + 1 WHERE user_id = $1", userId, hashedPassword); err != nil {
+		return 0, fmt.Errorf("failed to update password: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM password_resets WHERE user_id = $1", userId); err != nil {
+		return 0, fmt.Errorf("failed to clear password resets: %w", err)
+	}
+	if err := revokeAPIKeys(tx, userId, now); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit password reset: %w", err)
+	}
+
+	return userId, nil
+}
+
+// Delete password reset tokens that expired before a time (returns how many were removed)
+func (db *DB) DeleteExpiredPasswordResets(before time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM password_resets WHERE expires_at <= $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired password resets: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// #endregion
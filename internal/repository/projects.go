@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// #region Projects
+
+// Get all projects on a user's profile
+func (db *DB) GetProjectsByUser(userId int64) ([]model.Project, error) {
+	query, args := db.newSelect(projectColumns, "projects").
+		Where("user_id = ?", userId).
+		OrderBy("created_at DESC").
+		Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projectList []model.Project
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan projects: %w", err)
+		}
+
+		projectList = append(projectList, project)
+	}
+
+	return projectList, nil
+}
+
+// Get a project on a user's profile by ID
+func (db *DB) GetProject(userId, projectId int64) (*model.Project, error) {
+	query := "SELECT " + projectColumns + " FROM projects WHERE user_id = $1 AND project_id = $2"
+
+	project, err := scanProject(db.QueryRow(query, userId, projectId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("project %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query project: %w", err)
+	}
+
+	return &project, nil
+}
+
+// Add a project to a profile
+func (db *DB) CreateProject(project *model.Project) error {
+	query := `
+		INSERT INTO projects (user_id, title, description, repo_url, screenshot_url, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING project_id
+	`
+
+	err := db.QueryRow(query, project.UserId, project.Title, project.Description, project.RepoURL, project.ScreenshotURL, project.CreatedAt, project.UpdatedAt).
+		Scan(&project.ProjectId)
+	if err != nil {
+		return fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return nil
+}
+
+// Update a project
+func (db *DB) UpdateProject(project *model.Project) error {
+	query := `
+		UPDATE projects
+		SET title = $3,
+		description = $4,
+		repo_url = $5,
+		screenshot_url = $6,
+		updated_at = $7
+		WHERE user_id = $1 AND project_id = $2
+	`
+
+	result, err := db.Exec(query, project.UserId, project.ProjectId, project.Title, project.Description, project.RepoURL, project.ScreenshotURL, project.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update project: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("project %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Delete a project
+func (db *DB) DeleteProject(userId, projectId int64) error {
+	result, err := db.Exec("DELETE FROM projects WHERE user_id = $1 AND project_id = $2", userId, projectId)
+	if err != nil {
+		return fmt.Errorf("failed to delete project: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("project %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// #endregion
@@ -0,0 +1,42 @@
+-- Queries on comments. Run go generate ./internal/repository after changing this file.
+-- Listings with optional filters are built at runtime with newSelect and scan commentRow.
+
+-- name: GetCommentByID :one
+-- row: commentRow
+SELECT comment_id, user_id, post_id, content, author, date_posted, hidden
+FROM comments
+WHERE comment_id = $1;
+
+-- name: GetNthRecentCommentTime :one
+-- param: $2 since
+-- param: $3 skip
+-- When the user made the comment skip places behind their most recent one since the cutoff
+SELECT date_posted FROM comments WHERE user_id = $1 AND date_posted > $2 ORDER BY date_posted DESC LIMIT 1 OFFSET $3::int;
+
+-- name: GetCommentedPostIDs :one
+-- The posts a user has commented on
+SELECT ARRAY(SELECT DISTINCT post_id FROM comments WHERE user_id = $1)::bigint[] AS post_ids;
+
+-- name: CreateComment :one
+INSERT INTO comments (user_id, post_id, content, author, date_posted)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING comment_id;
+
+-- name: BumpPostActivity :exec
+-- param: $2 commented_at
+-- param: $3 commenter_id
+-- Counts a new comment on its post and resurfaces the post (comments by shadowbanned users do neither)
+UPDATE posts
+SET comment_count = comment_count + 1,
+    last_activity_at = GREATEST(last_activity_at, $2::timestamptz)
+WHERE post_id = $1 AND $3::bigint NOT IN (SELECT user_id FROM users WHERE shadowbanned);
+
+-- name: UpdateComment :execrows
+UPDATE comments
+SET content = $2,
+    author = $3
+WHERE comment_id = $1;
+
+-- name: DeleteComment :one
+-- Deletes a comment, returning the post it was on
+DELETE FROM comments WHERE comment_id = $1 RETURNING post_id;
@@ -0,0 +1,42 @@
+-- Queries on posts. Run go generate ./internal/repository after changing this file.
+-- Listings with optional filters are built at runtime with newSelect and scan postRow.
+
+-- name: GetPostByID :one
+-- row: postRow
+SELECT post_id, user_id, title, content, author, date_posted, hidden, locked, visibility, language, language_source,
+    excerpt, reading_minutes, comment_count, last_activity_at, board_id, tags
+FROM posts
+WHERE post_id = $1;
+
+-- name: GetRecentPostsByUser :many
+-- row: postRow
+-- param: $2 since
+-- Posts made by a user since a given time, newest first
+SELECT post_id, user_id, title, content, author, date_posted, hidden, locked, visibility, language, language_source,
+    excerpt, reading_minutes, comment_count, last_activity_at, board_id, tags
+FROM posts
+WHERE user_id = $1 AND date_posted >= $2
+ORDER BY date_posted DESC;
+
+-- name: GetNthRecentPostTime :one
+-- param: $2 since
+-- param: $3 skip
+-- When the user made the post skip places behind their most recent one since the cutoff
+SELECT date_posted FROM posts WHERE user_id = $1 AND date_posted > $2 ORDER BY date_posted DESC LIMIT 1 OFFSET $3::int;
+
+-- name: CreatePost :one
+-- row: createdPostRow
+-- A new post's activity starts when it's posted
+INSERT INTO posts (user_id, title, content, author, date_posted, last_activity_at, visibility, language, language_source,
+    excerpt, reading_minutes, board_id, tags)
+VALUES ($1, $2, $3, $4, $5, $5, $6, $7, $8, $9, $10, $11, $12)
+RETURNING post_id, last_activity_at;
+
+-- name: UpdatePost :execrows
+UPDATE posts
+SET user_id = $2, title = $3, content = $4, author = $5, date_posted = $6, visibility = $7, language = $8,
+    language_source = $9, excerpt = $10, reading_minutes = $11, board_id = $12, tags = $13
+WHERE post_id = $1;
+
+-- name: DeletePost :execrows
+DELETE FROM posts WHERE post_id = $1;
@@ -0,0 +1,34 @@
+-- Queries on profiles. Run go generate ./internal/repository after changing this file.
+-- Listings are built at runtime with newSelect and scan profileRow.
+
+-- name: GetProfileByUserID :one
+-- row: profileRow
+SELECT user_id, first_name, last_name, email, github_link, city, state, date_registered, display_name
+FROM profiles
+WHERE user_id = $1;
+
+-- name: GetProfileWithStats :one
+-- row: profileStatsRow
+-- A profile with how many posts and comments its user made
+SELECT user_id, first_name, last_name, email, github_link, city, state, date_registered, display_name,
+    (SELECT COUNT(*) FROM posts WHERE posts.user_id = profiles.user_id)::int AS post_count,
+    (SELECT COUNT(*) FROM comments WHERE comments.user_id = profiles.user_id)::int AS comment_count
+FROM profiles
+WHERE user_id = $1;
+
+-- name: CreateProfile :exec
+INSERT INTO profiles (user_id, first_name, last_name, email, github_link, city, state, date_registered)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: UpdateProfile :execrows
+-- Leaves the email alone, which changes through a confirmed email change
+UPDATE profiles
+SET first_name = $2,
+    last_name = $3,
+    github_link = $4,
+    city = $5,
+    state = $6
+WHERE user_id = $1;
+
+-- name: DeleteProfile :execrows
+DELETE FROM profiles WHERE user_id = $1;
@@ -0,0 +1,57 @@
+-- Queries on users. Run go generate ./internal/repository after changing this file.
+
+-- name: GetUserByID :one
+-- row: userRow
+SELECT user_id, username, hashed_password, role, first_name, last_name, status, banned_until, shadowbanned, token_version
+FROM users
+WHERE user_id = $1;
+
+-- name: GetUserByUsername :one
+-- row: userRow
+-- Usernames match case-insensitively
+SELECT user_id, username, hashed_password, role, first_name, last_name, status, banned_until, shadowbanned, token_version
+FROM users
+WHERE LOWER(username) = LOWER($1);
+
+-- name: CreateUser :one
+INSERT INTO users (username, hashed_password, role, first_name, last_name)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING user_id;
+
+-- name: UpdatePassword :one
+-- Sets a password and bumps the token version, returning the new version
+UPDATE users
+SET hashed_password = $2,
+    token_version = token_version + 1
+WHERE user_id = $1
+RETURNING token_version;
+
+-- name: ReplacePasswordHash :execrows
+-- param: $2 old_hash
+-- param: $3 new_hash
+-- Swaps the password hash only while it's still old_hash, keeping the token version
+UPDATE users SET hashed_password = $3 WHERE user_id = $1 AND hashed_password = $2;
+
+-- name: UpdateUser :execrows
+UPDATE users
+SET username = $2,
+    hashed_password = $3,
+    role = $4,
+    first_name = $5,
+    last_name = $6
+WHERE user_id = $1;
+
+-- name: DeleteUser :execrows
+DELETE FROM users WHERE user_id = $1;
+
+-- name: UserExists :one
+-- Whether a username is taken (case-insensitively)
+SELECT (EXISTS (SELECT 1 FROM users WHERE LOWER(username) = LOWER($1)))::boolean AS taken;
+
+-- name: BootstrapAdmin :execrows
+-- param: $2 only_user
+-- Promotes the user while the site has no admin (and, with only_user, only if they're the only account)
+UPDATE users SET role = 'admin'
+WHERE user_id = $1
+    AND NOT EXISTS (SELECT 1 FROM users WHERE role = 'admin')
+    AND (NOT $2::boolean OR NOT EXISTS (SELECT 1 FROM users WHERE user_id <> $1));
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Builds parameterized SELECT statements for filterable listings.
+// Values are only ever passed as $n arguments; identifiers (columns, sort
+// expressions) come from code, never from request input. Tables, conditions
+// and sort expressions are checked as they're added, and anything that looks
+// like spliced-in input panics rather than reaching the database.
+type selectBuilder struct {
+	columns    string
+	from       string
+	conditions []string
+	args       []interface{}
+	orderBy    string
+	limit      int
+	offset     int
+	// Most rows the statement may return (the database's MAX_RESULT_ROWS), unless unlimited
+	maxRows   int
+	unlimited bool
+}
+
+// Table names and sort terms (a column, optionally qualified, with an optional direction)
+var (
+	tablePattern    = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	sortTermPattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?( (ASC|DESC))?( NULLS (FIRST|LAST))?$`)
+)
+
+// Never part of a trusted condition: values are bound with "?", so quotes, statement separators,
+// comments and hand-written placeholders can only come from input spliced into the SQL
+var conditionForbidden = []string{"'", `"`, ";", "--", "/*", "$"}
+
+// Starts a SELECT of the given columns from a table. The statement never returns more than the
+// configured maximum number of rows, even without a Limit, so a listing can't dump a whole table.
+func (db *DB) newSelect(columns, from string) *selectBuilder {
+	if !tablePattern.MatchString(from) {
+		panic(fmt.Sprintf("query builder: unexpected table %q", from))
+	}
+
+	return &selectBuilder{
+		columns: columns,
+		from:    from,
+		maxRows: db.maxRows,
+	}
+}
+
+// Adds a condition (ANDed with the others). Each "?" in expr is bound to the next arg.
+func (b *selectBuilder) Where(expr string, args ...interface{}) *selectBuilder {
+	if strings.Count(expr, "?") != len(args) {
+		panic(fmt.Sprintf("query builder: %q expects %d args, got %d", expr, strings.Count(expr, "?"), len(args)))
+	}
+	for _, forbidden := range conditionForbidden {
+		if strings.Contains(expr, forbidden) {
+			panic(fmt.Sprintf("query builder: unexpected %q in condition %q", forbidden, expr))
+		}
+	}
+
+	// Replace each ? with the next positional placeholder
+	var condition strings.Builder
+	for _, c := range expr {
+		if c == '?' {
+			b.args = append(b.args, args[0])
+			args = args[1:]
+			condition.WriteString("$" + strconv.Itoa(len(b.args)))
+			continue
+		}
+		condition.WriteRune(c)
+	}
+
+	b.conditions = append(b.conditions, condition.String())
+	return b
+}
+
+// Sets the ORDER BY clause (must be a trusted expression, not request input): comma-separated
+// columns, each optionally followed by ASC/DESC and NULLS FIRST/LAST
+func (b *selectBuilder) OrderBy(expr string) *selectBuilder {
+	terms := strings.Split(expr, ",")
+	for i, term := range terms {
+		terms[i] = strings.TrimSpace(term)
+		if !sortTermPattern.MatchString(terms[i]) {
+			panic(fmt.Sprintf("query builder: unexpected sort term %q", term))
+		}
+	}
+
+	// Rebuilt from the checked terms, so only what the pattern matched reaches the SQL
+	b.orderBy = strings.Join(terms, ", ")
+	return b
+}
+
+// Sets the LIMIT (0 means no limit of its own; the maximum row count still applies)
+func (b *selectBuilder) Limit(limit int) *selectBuilder {
+	b.limit = limit
+	return b
+}
+
+// Lifts the maximum row count, for subqueries whose outer query is bounded and exports that stream
+// their rows rather than holding them
+func (b *selectBuilder) Unlimited() *selectBuilder {
+	b.unlimited = true
+	return b
+}
+
+// Sets the OFFSET
+func (b *selectBuilder) Offset(offset int) *selectBuilder {
+	b.offset = offset
+	return b
+}
+
+// Selects a page of size rows (see pageSize) starting at offset. One extra row is fetched so trimPage
+// can tell whether another page follows.
+func (b *selectBuilder) Page(size, offset int) *selectBuilder {
+	b.limit = size + 1
+	b.offset = offset
+	b.unlimited = true
+	return b
+}
+
+// Returns the SQL statement and its arguments
+func (b *selectBuilder) Build() (string, []interface{}) {
+	var query strings.Builder
+	query.WriteString("SELECT " + b.columns + " FROM " + b.from)
+
+	if len(b.conditions) > 0 {
+		query.WriteString(" WHERE " + strings.Join(b.conditions, " AND "))
+	}
+	if b.orderBy != "" {
+		query.WriteString(" ORDER BY " + b.orderBy)
+	}
+
+	args := append([]interface{}{}, b.args...)
+	limit := b.limit
+	if !b.unlimited && b.maxRows > 0 && (limit <= 0 || limit > b.maxRows) {
+		limit = b.maxRows
+	}
+	if limit > 0 {
+		args = append(args, limit)
+		query.WriteString(" LIMIT $" + strconv.Itoa(len(args)))
+	}
+	if b.offset > 0 {
+		args = append(args, b.offset)
+		query.WriteString(" OFFSET $" + strconv.Itoa(len(args)))
+	}
+
+	return query.String(), args
+}
+
+// The rows one page of a listing holds: limit, capped at the maximum row count (which a limit of 0 gets)
+func (db *DB) pageSize(limit int) int {
+	if limit <= 0 || limit > db.maxRows {
+		return db.maxRows
+	}
+	return limit
+}
+
+// Caps a hand-written listing the query builder can't express (joins, grouping) at the maximum row
+// count, binding it as the statement's next argument
+func (db *DB) capRows(query string, args ...interface{}) (string, []interface{}) {
+	args = append(args, db.maxRows)
+	return query + " LIMIT $" + strconv.Itoa(len(args)), args
+}
+
+// Drops the extra row a Page query fetched, reporting whether there was one (so another page follows)
+func trimPage[T any](items []T, size int) ([]T, bool) {
+	if len(items) > size {
+		return items[:size], true
+	}
+	return items, false
+}
+
+// Escapes LIKE wildcards so user input only matches literally
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,425 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Inputs an attacker might put in a filter, search box or sort parameter
+var adversarialInputs = []string{
+	"' OR '1'='1",
+	"'; DROP TABLE users; --",
+	`" OR ""="`,
+	"admin'--",
+	"1; SELECT pg_sleep(10)",
+	"$1",
+	"$$ OR 1=1 $$",
+	"?",
+	"?, ?",
+	"/* comment */",
+	"-- comment",
+	"%",
+	"_",
+	`\`,
+	`\' OR 1=1`,
+	"posts WHERE 1=1",
+	"title DESC; DELETE FROM posts",
+	"en' OR language LIKE '%",
+	"\x00",
+	"ünïcödé ' --",
+	strings.Repeat("'", 100),
+	"",
+}
+
+var placeholderPattern = regexp.MustCompile(`\$(\d+)`)
+
+// A database value that only builds statements (newSelect reads nothing but the row limit)
+func testDB() *DB {
+	return &DB{maxRows: 1000}
+}
+
+// Reports whether f panics
+func panics(f func()) (panicked bool) {
+	defer func() {
+		panicked = recover() != nil
+	}()
+	f()
+	return false
+}
+
+// Checks that a statement only refers to its values through placeholders numbered $1..$n, one per arg
+func assertPlaceholders(t *testing.T, query string, args []interface{}) {
+	t.Helper()
+
+	seen := make(map[int]bool)
+	for _, match := range placeholderPattern.FindAllStringSubmatch(query, -1) {
+		n, _ := strconv.Atoi(match[1])
+		seen[n] = true
+	}
+	if len(seen) != len(args) {
+		t.Fatalf("query has %d distinct placeholders for %d args: %s", len(seen), len(args), query)
+	}
+	for n := 1; n <= len(args); n++ {
+		if !seen[n] {
+			t.Fatalf("placeholder $%d is missing: %s", n, query)
+		}
+	}
+	if strings.Contains(query, "?") {
+		t.Fatalf("unbound ? left in query: %s", query)
+	}
+}
+
+// Maps a string to a harmless one that takes the same branches in the query builders
+func benign(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "x"
+}
+
+// Maps a number to a harmless one with the same sign
+func benignInt(value int64) int64 {
+	switch {
+	case value > 0:
+		return 1
+	case value < 0:
+		return -1
+	}
+	return 0
+}
+
+// Keeps a sort key only if the listing knows it (unknown keys fall back to the default)
+func benignSort(sorts map[string]string, key string) string {
+	if _, ok := sorts[key]; ok {
+		return key
+	}
+	return ""
+}
+
+func TestTrustedConditionsBuild(t *testing.T) {
+	// Shared conditions used across listings must pass the builder's own checks
+	conditions := []struct {
+		name string
+		expr string
+		args []interface{}
+	}{
+		{"visibleToViewer", visibleToViewer, []interface{}{int64(7)}},
+		{"postAudience", postAudience, postAudienceArgs(7)},
+		{"onPostInAudience", onPostInAudience, postAudienceArgs(7)},
+		{"subscribedPosts", subscribedPosts, []interface{}{int64(7), int64(7)}},
+	}
+
+	for _, tc := range conditions {
+		t.Run(tc.name, func(t *testing.T) {
+			var query string
+			var args []interface{}
+			if panics(func() {
+				query, args = testDB().newSelect(postColumns, "posts").Where(tc.expr, tc.args...).Build()
+			}) {
+				t.Fatalf("%s was rejected by the query builder", tc.name)
+			}
+			assertPlaceholders(t, query, args)
+			if strings.Contains(query, "'") {
+				t.Fatalf("%s puts a literal in the SQL: %s", tc.name, query)
+			}
+		})
+	}
+}
+
+func TestRejectsUnexpectedIdentifiers(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func()
+	}{
+		{"table with a statement", func() { testDB().newSelect("*", "posts; DROP TABLE users") }},
+		{"quoted table", func() { testDB().newSelect("*", `"posts"`) }},
+		{"schema-qualified table", func() { testDB().newSelect("*", "pg_catalog.pg_user") }},
+		{"uppercase table", func() { testDB().newSelect("*", "Posts") }},
+		{"condition with a quote", func() { testDB().newSelect("*", "posts").Where("title = 'x'") }},
+		{"condition with a double quote", func() { testDB().newSelect("*", "posts").Where(`"title" = ?`, "x") }},
+		{"condition with a separator", func() { testDB().newSelect("*", "posts").Where("1 = 1; DELETE FROM posts") }},
+		{"condition with a line comment", func() { testDB().newSelect("*", "posts").Where("user_id = ? -- x", 1) }},
+		{"condition with a block comment", func() { testDB().newSelect("*", "posts").Where("user_id = ? /* x */", 1) }},
+		{"hand-written placeholder", func() { testDB().newSelect("*", "posts").Where("user_id = $1") }},
+		{"missing arg", func() { testDB().newSelect("*", "posts").Where("user_id = ? AND post_id = ?", 1) }},
+		{"extra arg", func() { testDB().newSelect("*", "posts").Where("user_id = ?", 1, 2) }},
+		{"sort with a statement", func() { testDB().newSelect("*", "posts").OrderBy("title; DROP TABLE users") }},
+		{"sort with a subquery", func() { testDB().newSelect("*", "posts").OrderBy("(SELECT 1)") }},
+		{"sort with a function", func() { testDB().newSelect("*", "posts").OrderBy("random()") }},
+		{"sort with a quote", func() { testDB().newSelect("*", "posts").OrderBy("title'") }},
+		{"sort with an unknown direction", func() { testDB().newSelect("*", "posts").OrderBy("title SIDEWAYS") }},
+		{"sort with a comment", func() { testDB().newSelect("*", "posts").OrderBy("title -- x") }},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if !panics(tc.build) {
+				t.Fatal("expected the query builder to panic")
+			}
+		})
+	}
+}
+
+func TestAcceptsTrustedSorts(t *testing.T) {
+	for _, sorts := range []map[string]string{postSorts, commentSorts} {
+		for key, expr := range sorts {
+			if panics(func() { testDB().newSelect("*", "posts").OrderBy(expr) }) {
+				t.Errorf("sort %q (%s) was rejected", key, expr)
+			}
+		}
+	}
+}
+
+func TestLimitIsBoundAndCapped(t *testing.T) {
+	for _, limit := range []int{-1, 0, 10, 1000, 1001, 1 << 30} {
+		query, args := testDB().newSelect("*", "posts").Limit(limit).Offset(limit).Build()
+		assertPlaceholders(t, query, args)
+
+		got := args[0].(int)
+		if got < 1 || got > 1000 {
+			t.Errorf("limit %d built LIMIT %d, want 1..1000", limit, got)
+		}
+		if strings.Contains(query, strconv.Itoa(limit)) && limit != 0 {
+			t.Errorf("limit %d was spliced into the SQL: %s", limit, query)
+		}
+	}
+}
+
+func TestPageFetchesOneExtraRow(t *testing.T) {
+	tests := []struct {
+		limit int
+		size  int
+	}{
+		{0, 1000},
+		{-1, 1000},
+		{25, 25},
+		{1000, 1000},
+		{5000, 1000},
+	}
+
+	for _, tc := range tests {
+		size := testDB().pageSize(tc.limit)
+		if size != tc.size {
+			t.Errorf("limit %d gave pages of %d, want %d", tc.limit, size, tc.size)
+		}
+		query, args := testDB().newSelect("*", "users").OrderBy("user_id").Page(size, 40).Build()
+		assertPlaceholders(t, query, args)
+		if args[0] != size+1 || args[1] != 40 {
+			t.Errorf("page of %d at offset 40 bound %v", size, args)
+		}
+	}
+
+	rows := []int{1, 2, 3}
+	if page, more := trimPage(rows, 2); len(page) != 2 || !more {
+		t.Errorf("trimPage(3 rows, 2) = %v, %t", page, more)
+	}
+	if page, more := trimPage(rows, 3); len(page) != 3 || more {
+		t.Errorf("trimPage(3 rows, 3) = %v, %t", page, more)
+	}
+}
+
+func FuzzWhereBindsValues(f *testing.F) {
+	for _, input := range adversarialInputs {
+		f.Add(input)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		query, args := testDB().newSelect(postColumns, "posts").
+			Where("title = ?", value).
+			Where("(LOWER(author) = LOWER(?) OR content ILIKE ?)", value, "%"+escapeLike(value)+"%").
+			OrderBy(postSorts["newest"]).
+			Build()
+
+		assertPlaceholders(t, query, args)
+		want, _ := testDB().newSelect(postColumns, "posts").
+			Where("title = ?", "").
+			Where("(LOWER(author) = LOWER(?) OR content ILIKE ?)", "", "").
+			OrderBy(postSorts["newest"]).
+			Build()
+		if query != want {
+			t.Fatalf("SQL depends on the value %q:\n%s\nwant\n%s", value, query, want)
+		}
+		if args[0] != value || args[1] != value {
+			t.Fatalf("value %q wasn't passed through unchanged: %v", value, args)
+		}
+	})
+}
+
+func FuzzConditionsRejectSplicedInput(f *testing.F) {
+	for _, input := range adversarialInputs {
+		f.Add(input)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		// A condition built by concatenating input is accepted only if the input is harmless as SQL
+		expr := "title = " + value
+		var query string
+		if panics(func() {
+			query, _ = testDB().newSelect("*", "posts").Where(expr, make([]interface{}, strings.Count(expr, "?"))...).Build()
+		}) {
+			return
+		}
+		for _, forbidden := range conditionForbidden {
+			if strings.Contains(value, forbidden) {
+				t.Fatalf("condition with %q was accepted: %s", forbidden, query)
+			}
+		}
+	})
+}
+
+func FuzzOrderByOnlyAcceptsIdentifiers(f *testing.F) {
+	for _, input := range adversarialInputs {
+		f.Add(input)
+	}
+	for _, expr := range postSorts {
+		f.Add(expr)
+	}
+
+	// Accepted sorts are plain column lists: no quotes, parentheses, operators or comments
+	safe := regexp.MustCompile(`^[a-zA-Z0-9_., ]*$`)
+	f.Fuzz(func(t *testing.T, expr string) {
+		var query string
+		if panics(func() { query, _ = testDB().newSelect("*", "posts").OrderBy(expr).Build() }) {
+			return
+		}
+		built := strings.TrimSuffix(strings.SplitN(query, " ORDER BY ", 2)[1], " LIMIT $1")
+		if !safe.MatchString(built) {
+			t.Fatalf("sort %q built %q", expr, built)
+		}
+		for _, term := range strings.Split(built, ",") {
+			words := strings.Fields(term)
+			for _, word := range words[1:] {
+				switch word {
+				case "ASC", "DESC", "NULLS", "FIRST", "LAST":
+				default:
+					t.Fatalf("sort %q has unexpected keyword %q", expr, word)
+				}
+			}
+		}
+	})
+}
+
+func FuzzPostListQuery(f *testing.F) {
+	for _, input := range adversarialInputs {
+		f.Add(input, input, input, input, int64(0), 20, 0, false)
+		f.Add(input, "", "", "newest", int64(42), 1<<30, -5, true)
+	}
+
+	f.Fuzz(func(t *testing.T, author, search, language, sortKey string, viewerId int64, limit, offset int, hideMuted bool) {
+		filter := model.PostFilter{
+			UserId:    viewerId,
+			Author:    author,
+			Search:    search,
+			Language:  language,
+			Sort:      sortKey,
+			Limit:     limit,
+			Offset:    offset,
+			ViewerId:  viewerId,
+			HideMuted: hideMuted,
+		}
+		query, args := testDB().postListQuery(filter)
+		assertPlaceholders(t, query, args)
+
+		// The statement's text only depends on which filters are set, never on their values
+		want, _ := testDB().postListQuery(model.PostFilter{
+			UserId:    benignInt(viewerId),
+			Author:    benign(author),
+			Search:    benign(search),
+			Language:  benign(language),
+			Sort:      benignSort(postSorts, sortKey),
+			Limit:     int(benignInt(int64(limit))),
+			Offset:    int(benignInt(int64(offset))),
+			ViewerId:  benignInt(viewerId),
+			HideMuted: hideMuted,
+		})
+		if query != want {
+			t.Fatalf("SQL depends on filter values %+v:\n%s\nwant\n%s", filter, query, want)
+		}
+		if author != "" && !containsArg(args, author) {
+			t.Fatalf("author %q wasn't bound: %v", author, args)
+		}
+	})
+}
+
+func FuzzCommentListQuery(f *testing.F) {
+	for _, input := range adversarialInputs {
+		f.Add(input, int64(0), int64(3), 20, 0)
+		f.Add(input, int64(42), int64(-1), -1, 1<<30)
+	}
+
+	f.Fuzz(func(t *testing.T, sortKey string, viewerId, postId int64, limit, offset int) {
+		filter := model.CommentFilter{
+			UserId:   viewerId,
+			PostId:   postId,
+			Sort:     sortKey,
+			Limit:    limit,
+			Offset:   offset,
+			ViewerId: viewerId,
+		}
+		query, args := testDB().commentListQuery(filter)
+		assertPlaceholders(t, query, args)
+
+		want, _ := testDB().commentListQuery(model.CommentFilter{
+			UserId:   benignInt(viewerId),
+			PostId:   benignInt(postId),
+			Sort:     benignSort(commentSorts, sortKey),
+			Limit:    int(benignInt(int64(limit))),
+			Offset:   int(benignInt(int64(offset))),
+			ViewerId: benignInt(viewerId),
+		})
+		if query != want {
+			t.Fatalf("SQL depends on filter values %+v:\n%s\nwant\n%s", filter, query, want)
+		}
+	})
+}
+
+func TestListQueriesUseEveryKnownSort(t *testing.T) {
+	// Each sort key picks its own ORDER BY, and unknown keys fall back instead of reaching the SQL
+	keys := make([]string, 0, len(postSorts))
+	for key := range postSorts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		query, _ := testDB().postListQuery(model.PostFilter{Sort: key})
+		if !strings.HasSuffix(strings.SplitN(query, " ORDER BY ", 2)[1], postSorts[key]+" LIMIT $8") {
+			t.Errorf("sort %q built %s", key, query)
+		}
+	}
+	fallback, _ := testDB().postListQuery(model.PostFilter{})
+	for _, input := range adversarialInputs {
+		if query, _ := testDB().postListQuery(model.PostFilter{Sort: input}); query != fallback {
+			t.Errorf("sort %q changed the SQL: %s", input, query)
+		}
+	}
+}
+
+func containsArg(args []interface{}, value string) bool {
+	for _, arg := range args {
+		if arg == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCommentListSkipsHiddenPosts(t *testing.T) {
+	// Comments on a post a moderator hid must not be listed, whatever the filter
+	query, _ := testDB().commentListQuery(model.CommentFilter{PostId: 3})
+	if !strings.Contains(query, "SELECT post_id FROM posts WHERE NOT hidden AND") {
+		t.Fatalf("comment listing doesn't exclude hidden posts: %s", query)
+	}
+}
+
+func TestCapRowsBindsTheMaximum(t *testing.T) {
+	query, args := testDB().capRows("SELECT name FROM skills WHERE user_id = $1 ORDER BY name", int64(7))
+	assertPlaceholders(t, query, args)
+	if !strings.HasSuffix(query, " LIMIT $2") || args[1] != 1000 {
+		t.Fatalf("capRows built %s with %v", query, args)
+	}
+}
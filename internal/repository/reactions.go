@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// #region Comment reactions
+
+// Leave a reaction on a comment (leaving one twice changes nothing)
+func (db *DB) AddCommentReaction(commentId, userId int64, reaction string) error {
+	query := `
+		INSERT INTO comment_reactions (comment_id, user_id, reaction)
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := db.Exec(query, commentId, userId, reaction); err != nil {
+		return fmt.Errorf("failed to add comment reaction: %w", err)
+	}
+
+	return nil
+}
+
+// Take back a reaction on a comment
+func (db *DB) RemoveCommentReaction(commentId, userId int64, reaction string) error {
+	query := "DELETE FROM comment_reactions WHERE comment_id = $1 AND user_id = $2 AND reaction = $3"
+	result, err := db.Exec(query, commentId, userId, reaction)
+	if err != nil {
+		return fmt.Errorf("failed to remove comment reaction: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("comment reaction %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Get the reactions on several comments in one grouped query, keyed by comment ID. Every comment gets a
+// summary, empty when nobody reacted. Reactions by shadowbanned users only count for themselves, like
+// their comments (viewerId 0 for anonymous requests).
+func (db *DB) GetCommentReactions(commentIds []int64, viewerId int64) (map[int64]model.ReactionSummary, error) {
+	summaries := make(map[int64]model.ReactionSummary, len(commentIds))
+	for _, commentId := range commentIds {
+		summaries[commentId] = model.ReactionSummary{Counts: map[string]int{}, Mine: []string{}}
+	}
+	if len(commentIds) == 0 {
+		return summaries, nil
+	}
+
+	query := `
+		SELECT comment_id, reaction, COUNT(*), BOOL_OR(user_id = $2)
+		FROM comment_reactions
+		WHERE comment_id = ANY($1)
+			AND (user_id = $2 OR user_id NOT IN (SELECT user_id FROM users WHERE shadowbanned))
+		GROUP BY comment_id, reaction
+		ORDER BY comment_id, reaction
+	`
+
+	rows, err := db.Query(query, pq.Array(commentIds), viewerId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comment reactions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var commentId int64
+		var reaction string
+		var count int
+		var mine bool
+		if err := rows.Scan(&commentId, &reaction, &count, &mine); err != nil {
+			return nil, fmt.Errorf("failed to scan comment reactions: %w", err)
+		}
+
+		summary := summaries[commentId]
+		summary.Counts[reaction] = count
+		if mine {
+			summary.Mine = append(summary.Mine, reaction)
+		}
+		summaries[commentId] = summary
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read comment reactions: %w", err)
+	}
+	return summaries, nil
+}
+
+// #endregion
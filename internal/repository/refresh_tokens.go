@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// #region Refresh tokens
+
+// Store a newly issued refresh token
+func (db *DB) CreateRefreshToken(token *model.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (token_hash, user_id, family_id, token_version, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if _, err := db.Exec(query, token.TokenHash, token.UserId, token.FamilyId, token.TokenVersion, token.CreatedAt, token.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	return nil
+}
+
+// Mark a live refresh token as used and return it. Only one caller can use a token; anyone else
+// (and anyone presenting an unknown, expired, revoked or already used token) gets ErrNotFound.
+func (db *DB) UseRefreshToken(tokenHash string, now time.Time) (*model.RefreshToken, error) {
+	query := `
+		UPDATE refresh_tokens SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND revoked_at IS NULL AND expires_at > $2
+		RETURNING ` + refreshTokenColumns
+
+	token, err := scanRefreshToken(db.QueryRow(query, tokenHash, now))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("refresh token %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to use refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// Get a refresh token by hash, whatever its state
+func (db *DB) GetRefreshToken(tokenHash string) (*model.RefreshToken, error) {
+	query := "SELECT " + refreshTokenColumns + " FROM refresh_tokens WHERE token_hash = $1"
+
+	token, err := scanRefreshToken(db.QueryRow(query, tokenHash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("refresh token %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query refresh token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// Revoke every token in a refresh token family that isn't revoked yet (returns how many were)
+func (db *DB) RevokeRefreshTokenFamily(familyId string, now time.Time) (int64, error) {
+	result, err := db.Exec("UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL", familyId, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// Delete refresh tokens that expired before the given time (returns how many were deleted)
+func (db *DB) DeleteExpiredRefreshTokens(before time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM refresh_tokens WHERE expires_at <= $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired refresh tokens: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// #endregion
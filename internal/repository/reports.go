@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// #region Report reasons
+
+// Get the report reason taxonomy (optionally only reasons users can currently pick)
+func (db *DB) GetReportReasons(activeOnly bool) ([]model.ReportReason, error) {
+	query := "SELECT code, label, active FROM report_reasons"
+	if activeOnly {
+		query += " WHERE active"
+	}
+	query += " ORDER BY label"
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query report reasons: %w", err)
+	}
+	defer rows.Close()
+
+	reasons := []model.ReportReason{}
+	for rows.Next() {
+		var reason model.ReportReason
+		if err := rows.Scan(&reason.Code, &reason.Label, &reason.Active); err != nil {
+			return nil, fmt.Errorf("failed to scan report reasons: %w", err)
+		}
+
+		reasons = append(reasons, reason)
+	}
+
+	return reasons, nil
+}
+
+// Get a report reason by code
+func (db *DB) GetReportReason(code string) (*model.ReportReason, error) {
+	var reason model.ReportReason
+	err := db.QueryRow("SELECT code, label, active FROM report_reasons WHERE code = $1", code).
+		Scan(&reason.Code, &reason.Label, &reason.Active)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("report reason %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query report reason: %w", err)
+	}
+
+	return &reason, nil
+}
+
+// Add a report reason
+func (db *DB) CreateReportReason(reason *model.ReportReason) error {
+	_, err := db.Exec("INSERT INTO report_reasons (code, label, active) VALUES ($1, $2, $3)", reason.Code, reason.Label, reason.Active)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("report reason %w", ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create report reason: %w", err)
+	}
+
+	return nil
+}
+
+// Update a report reason's label and whether it can be picked
+func (db *DB) UpdateReportReason(reason *model.ReportReason) error {
+	result, err := db.Exec("UPDATE report_reasons SET label = $2, active = $3 WHERE code = $1", reason.Code, reason.Label, reason.Active)
+	if err != nil {
+		return fmt.Errorf("failed to update report reason: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("report reason %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// #endregion
+
+// #region Reports
+
+// Sort options for report listings
+var reportSorts = map[string]string{
+	"newest": "created_at DESC, report_id DESC",
+	"oldest": "created_at ASC, report_id ASC",
+}
+
+// Get reports matching a filter
+func (db *DB) ListReports(filter model.ReportFilter) ([]model.Report, error) {
+	query, args := db.reportQuery(filter).Limit(filter.Limit).Offset(filter.Offset).Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reportList []model.Report
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reports: %w", err)
+		}
+
+		reportList = append(reportList, report)
+	}
+
+	return reportList, nil
+}
+
+// Pass every report matching a filter to fn as it's read, ignoring the filter's limit and offset.
+// Stops at the first error fn returns.
+func (db *DB) ExportReports(filter model.ReportFilter, fn func(model.Report) error) error {
+	query, args := db.reportQuery(filter).Unlimited().Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query reports: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan reports: %w", err)
+		}
+		if err := fn(report); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read reports: %w", err)
+	}
+	return nil
+}
+
+// Get the distinct authors of reported posts and comments (targets deleted since are skipped)
+func (db *DB) GetReportTargetAuthors(postIds, commentIds []int64) ([]int64, error) {
+	query := `
+		SELECT user_id FROM posts WHERE post_id = ANY($1)
+		UNION
+		SELECT user_id FROM comments WHERE comment_id = ANY($2)
+	`
+
+	rows, err := db.Query(query, pq.Array(postIds), pq.Array(commentIds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query report target authors: %w", err)
+	}
+	defer rows.Close()
+
+	var userIds []int64
+	for rows.Next() {
+		var userId int64
+		if err := rows.Scan(&userId); err != nil {
+			return nil, fmt.Errorf("failed to scan report target authors: %w", err)
+		}
+
+		userIds = append(userIds, userId)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read report target authors: %w", err)
+	}
+	return userIds, nil
+}
+
+// Builds the sorted report query for a filter, without pagination
+func (db *DB) reportQuery(filter model.ReportFilter) *selectBuilder {
+	builder := db.newSelect(reportColumns, "reports")
+	if filter.Status != "" {
+		builder.Where("status = ?", filter.Status)
+	}
+	if filter.ReasonCode != "" {
+		builder.Where("reason_code = ?", filter.ReasonCode)
+	}
+	if filter.TargetType != "" {
+		builder.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.AssignedTo > 0 {
+		builder.Where("assigned_to = ?", filter.AssignedTo)
+	}
+	if filter.Unassigned {
+		builder.Where("assigned_to IS NULL")
+	}
+	if !filter.From.IsZero() {
+		builder.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		builder.Where("created_at < ?", filter.To)
+	}
+
+	sort, ok := reportSorts[filter.Sort]
+	if !ok {
+		sort = reportSorts["oldest"]
+	}
+	return builder.OrderBy(sort)
+}
+
+// Get report by ID
+func (db *DB) GetReportById(reportId int64) (*model.Report, error) {
+	query := "SELECT " + reportColumns + " FROM reports WHERE report_id = $1"
+
+	report, err := scanReport(db.QueryRow(query, reportId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("report %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query report: %w", err)
+	}
+
+	return &report, nil
+}
+
+// Create a report (ErrConflict if the reporter already has one open on the same content)
+func (db *DB) CreateReport(report *model.Report) error {
+	query := `
+		INSERT INTO reports (reporter_id, target_type, target_id, reason_code, details, status, created_at, updated_at)
+		VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8)
+		RETURNING report_id
+	`
+
+	err := db.QueryRow(query, report.ReporterId, report.TargetType, report.TargetId, report.ReasonCode, report.Details, report.Status, report.CreatedAt, report.UpdatedAt).
+		Scan(&report.ReportId)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("report %w", ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+
+	return nil
+}
+
+// Save a report's workflow fields (status, assignee, resolution note and who resolved it)
+func (db *DB) UpdateReport(report *model.Report) error {
+	query := `
+		UPDATE reports
+		SET status = $2,
+		assigned_to = $3,
+		resolution_note = $4,
+		resolved_by = $5,
+		resolved_at = $6,
+		updated_at = $7
+		WHERE report_id = $1
+	`
+
+	result, err := db.Exec(query, report.ReportId, report.Status, report.AssignedTo, report.ResolutionNote, report.ResolvedBy, report.ResolvedAt, report.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update report: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("report %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// #endregion
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// #region Roles
+
+// Get every role with the permissions it grants, by name
+func (db *DB) GetRoles() ([]model.Role, error) {
+	rows, err := db.Query("SELECT " + roleColumns + " FROM roles ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roles: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []model.Role{}
+	byName := make(map[string]int)
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan roles: %w", err)
+		}
+
+		byName[role.Name] = len(roles)
+		roles = append(roles, role)
+	}
+	rows.Close()
+
+	permissions, err := db.Query("SELECT role, permission FROM role_permissions ORDER BY role, permission")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query role permissions: %w", err)
+	}
+	defer permissions.Close()
+
+	for permissions.Next() {
+		var role, permission string
+		if err := permissions.Scan(&role, &permission); err != nil {
+			return nil, fmt.Errorf("failed to scan role permissions: %w", err)
+		}
+
+		if i, ok := byName[role]; ok {
+			roles[i].Permissions = append(roles[i].Permissions, permission)
+		}
+	}
+
+	return roles, nil
+}
+
+// Report whether a role exists
+func (db *DB) RoleExists(name string) (bool, error) {
+	var exists bool
+	if err := db.QueryRow("SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)", name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check role: %w", err)
+	}
+
+	return exists, nil
+}
+
+// Create a role or replace its description and permissions (in one transaction)
+func (db *DB) SetRole(role *model.Role) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO roles (name, description, created_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET description = $2
+		RETURNING created_at
+	`
+	if err := tx.QueryRow(query, role.Name, role.Description, role.CreatedAt).Scan(&role.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save role: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM role_permissions WHERE role = $1", role.Name); err != nil {
+		return fmt.Errorf("failed to clear role permissions: %w", err)
+	}
+	for _, permission := range role.Permissions {
+		if _, err := tx.Exec("INSERT INTO role_permissions (role, permission) VALUES ($1, $2)", role.Name, permission); err != nil {
+			return fmt.Errorf("failed to save role permission: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit role: %w", err)
+	}
+
+	return nil
+}
+
+// Delete a role and its permissions. Returns ErrConflict while users still hold it.
+func (db *DB) DeleteRole(name string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the role first so nobody can be given it between the check and the delete
+	var locked string
+	err = tx.QueryRow("SELECT name FROM roles WHERE name = $1 FOR UPDATE", name).Scan(&locked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("role %w", ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock role: %w", err)
+	}
+
+	var held bool
+	if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE role = $1)", name).Scan(&held); err != nil {
+		return fmt.Errorf("failed to check role holders: %w", err)
+	}
+	if held {
+		return fmt.Errorf("role is still held by users: %w", ErrConflict)
+	}
+
+	if _, err := tx.Exec("DELETE FROM roles WHERE name = $1", name); err != nil {
+		return fmt.Errorf("failed to delete role: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit role deletion: %w", err)
+	}
+
+	return nil
+}
+
+// Give a user a role (which must exist)
+func (db *DB) SetUserRole(userId int64, role string) error {
+	result, err := db.Exec("UPDATE users SET role = $2 WHERE user_id = $1", userId, role)
+	if err != nil {
+		return fmt.Errorf("failed to set user role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// #endregion
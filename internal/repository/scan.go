@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Typed functions for the static queries in queries/*.sql (see cmd/querygen)
+//go:generate go run ../../cmd/querygen -schema ../../database.sql -queries queries -out queries.sql.go
+
+// Explicit column lists (keep in the same order as the matching scan function). Comments, posts,
+// profiles and users take theirs from the generated rows.
+const (
+	commentColumns          = commentRowColumns
+	postColumns             = postRowColumns
+	profileColumns          = profileRowColumns
+	userColumns             = userRowColumns
+	snippetColumns          = "snippet_id, post_id, user_id, filename, language, body, created_at"
+	projectColumns          = "project_id, user_id, title, description, repo_url, screenshot_url, created_at, updated_at"
+	notificationColumns     = "notification_id, user_id, event_type, message, link, read_at, created_at"
+	digestColumns           = "user_id, frequency, last_sent_at"
+	wordFilterColumns       = "filter_id, pattern, is_regex, action, replacement, created_at"
+	reportColumns           = "report_id, reporter_id, target_type, target_id, reason_code, details, status, assigned_to, resolution_note, resolved_by, resolved_at, created_at, updated_at"
+	moderationActionColumns = "action_id, actor_id, action, target_type, target_id, target_user_id, reason, report_id, expires_at, created_at"
+	appealColumns           = "appeal_id, action_id, user_id, message, status, resolved_by, resolution_note, created_at, resolved_at"
+	attachmentColumns       = "attachment_id, user_id, filename, content_type, size_bytes, storage_key, created_at, linked_at"
+	eventColumns            = "event_id, event_type, actor_id, subject_type, subject_id, payload, created_at"
+	dataAccessColumns       = "access_id, user_id, viewer_id, access, created_at"
+	keywordAlertColumns     = "alert_id, user_id, keyword, last_post_id, last_notified_at, created_at"
+	announcementColumns     = "announcement_id, title, message, level, starts_at, expires_at, created_by, created_at, updated_at"
+	backupColumns           = "backup_id, storage_key, status, size_bytes, error, triggered_by, started_at, finished_at"
+	postTranslationColumns  = "post_id, language, title, content, source_hash, created_at"
+	serviceClientColumns    = "client_id, name, secret_hash, scopes, created_by, created_at, last_used_at, revoked_at"
+	refreshTokenColumns     = "token_hash, user_id, family_id, token_version, created_at, expires_at, used_at, revoked_at"
+	skillRuleColumns        = "name, action, canonical, created_by, created_at"
+	sessionColumns          = "session_id, user_id, family_id, token_version, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at, impersonator_id"
+	apiKeyColumns           = "key_id, user_id, name, prefix, key_hash, created_at, last_used_at, revoked_at"
+	roleColumns             = "name, description, created_at"
+	boardColumns            = "board_id, slug, name, description, created_at"
+)
+
+// Implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Scan a row selected with commentColumns
+func scanComment(row rowScanner) (model.Comment, error) {
+	var r commentRow
+	err := row.Scan(r.dest()...)
+	return r.comment(), err
+}
+
+func (r commentRow) comment() model.Comment {
+	return model.Comment{
+		CommentId:  r.CommentId,
+		UserId:     r.UserId,
+		PostId:     r.PostId,
+		Content:    r.Content,
+		Author:     r.Author,
+		DatePosted: r.DatePosted,
+		Hidden:     r.Hidden,
+	}
+}
+
+// Scan a row selected with postColumns
+func scanPost(row rowScanner) (model.Post, error) {
+	var r postRow
+	err := row.Scan(r.dest()...)
+	return r.post(), err
+}
+
+func (r postRow) post() model.Post {
+	return model.Post{
+		PostId:         r.PostId,
+		UserId:         r.UserId,
+		Title:          r.Title,
+		Content:        r.Content,
+		Author:         r.Author,
+		DatePosted:     r.DatePosted,
+		Hidden:         r.Hidden,
+		Locked:         r.Locked,
+		Visibility:     r.Visibility,
+		Language:       r.Language,
+		LanguageSource: r.LanguageSource,
+		Excerpt:        r.Excerpt,
+		ReadingMinutes: r.ReadingMinutes,
+		CommentCount:   r.CommentCount,
+		LastActivityAt: r.LastActivityAt,
+		BoardId:        nullIntPtr(r.BoardId),
+		Tags:           r.Tags,
+	}
+}
+
+// Scan a row selected with boardColumns
+func scanBoard(row rowScanner) (model.Board, error) {
+	var board model.Board
+	err := row.Scan(&board.BoardId, &board.Slug, &board.Name, &board.Description, &board.CreatedAt)
+	return board, err
+}
+
+// Scan a row selected with profileColumns
+func scanProfile(row rowScanner) (model.Profile, error) {
+	var r profileRow
+	err := row.Scan(r.dest()...)
+	return r.profile(), err
+}
+
+// Unset names and details read as empty strings
+func (r profileRow) profile() model.Profile {
+	return model.Profile{
+		UserId:         r.UserId,
+		FirstName:      r.FirstName.String,
+		LastName:       r.LastName.String,
+		Email:          r.Email.String,
+		GithubLink:     r.GithubLink.String,
+		City:           r.City.String,
+		State:          r.State.String,
+		DateRegistered: r.DateRegistered.Time,
+		DisplayName:    r.DisplayName.String,
+	}
+}
+
+// Scan a row selected with userColumns
+func scanUser(row rowScanner) (model.User, error) {
+	var r userRow
+	err := row.Scan(r.dest()...)
+	return r.user(), err
+}
+
+func (r userRow) user() model.User {
+	return model.User{
+		ID:             r.UserId,
+		Username:       r.Username,
+		HashedPassword: r.HashedPassword,
+		Role:           r.Role,
+		FirstName:      r.FirstName.String,
+		LastName:       r.LastName.String,
+		Status:         r.Status,
+		BannedUntil:    nullTimePtr(r.BannedUntil),
+		Shadowbanned:   r.Shadowbanned,
+		TokenVersion:   r.TokenVersion,
+	}
+}
+
+// Scan a row selected with snippetColumns
+func scanSnippet(row rowScanner) (model.Snippet, error) {
+	var snippet model.Snippet
+	err := row.Scan(&snippet.SnippetId, &snippet.PostId, &snippet.UserId, &snippet.Filename, &snippet.Language, &snippet.Body, &snippet.CreatedAt)
+	return snippet, err
+}
+
+// Scan a row selected with projectColumns
+func scanProject(row rowScanner) (model.Project, error) {
+	var project model.Project
+	err := row.Scan(&project.ProjectId, &project.UserId, &project.Title, &project.Description, &project.RepoURL, &project.ScreenshotURL, &project.CreatedAt, &project.UpdatedAt)
+	return project, err
+}
+
+// Scan a row selected with notificationColumns
+func scanNotification(row rowScanner) (model.Notification, error) {
+	var notification model.Notification
+	var readAt sql.NullTime
+	err := row.Scan(&notification.NotificationId, &notification.UserId, &notification.EventType, &notification.Message, &notification.Link, &readAt, &notification.CreatedAt)
+	if readAt.Valid {
+		notification.ReadAt = &readAt.Time
+	}
+	return notification, err
+}
+
+// Scan a row selected with digestColumns
+func scanDigestSettings(row rowScanner) (model.DigestSettings, error) {
+	var settings model.DigestSettings
+	var lastSentAt sql.NullTime
+	err := row.Scan(&settings.UserId, &settings.Frequency, &lastSentAt)
+	if lastSentAt.Valid {
+		settings.LastSentAt = &lastSentAt.Time
+	}
+	return settings, err
+}
+
+// Scan a row selected with wordFilterColumns
+func scanWordFilter(row rowScanner) (model.WordFilter, error) {
+	var filter model.WordFilter
+	err := row.Scan(&filter.FilterId, &filter.Pattern, &filter.IsRegex, &filter.Action, &filter.Replacement, &filter.CreatedAt)
+	return filter, err
+}
+
+// Scan a row selected with reportColumns
+func scanReport(row rowScanner) (model.Report, error) {
+	var report model.Report
+	var reporterId, assignedTo, resolvedBy sql.NullInt64
+	var resolvedAt sql.NullTime
+	err := row.Scan(&report.ReportId, &reporterId, &report.TargetType, &report.TargetId, &report.ReasonCode, &report.Details, &report.Status, &assignedTo, &report.ResolutionNote, &resolvedBy, &resolvedAt, &report.CreatedAt, &report.UpdatedAt)
+	report.ReporterId = reporterId.Int64
+	report.AssignedTo = nullIntPtr(assignedTo)
+	report.ResolvedBy = nullIntPtr(resolvedBy)
+	report.ResolvedAt = nullTimePtr(resolvedAt)
+	return report, err
+}
+
+// Scan a row selected with moderationActionColumns
+func scanModerationAction(row rowScanner) (model.ModerationAction, error) {
+	var action model.ModerationAction
+	var actorId, reportId sql.NullInt64
+	var expiresAt sql.NullTime
+	err := row.Scan(&action.ActionId, &actorId, &action.Action, &action.TargetType, &action.TargetId, &action.TargetUserId, &action.Reason, &reportId, &expiresAt, &action.CreatedAt)
+	action.ActorId = nullIntPtr(actorId)
+	action.ReportId = nullIntPtr(reportId)
+	action.ExpiresAt = nullTimePtr(expiresAt)
+	return action, err
+}
+
+// Scan a row selected with appealColumns
+func scanAppeal(row rowScanner) (model.Appeal, error) {
+	var appeal model.Appeal
+	var resolvedBy sql.NullInt64
+	var resolvedAt sql.NullTime
+	err := row.Scan(&appeal.AppealId, &appeal.ActionId, &appeal.UserId, &appeal.Message, &appeal.Status, &resolvedBy, &appeal.ResolutionNote, &appeal.CreatedAt, &resolvedAt)
+	appeal.ResolvedBy = nullIntPtr(resolvedBy)
+	if resolvedAt.Valid {
+		appeal.ResolvedAt = &resolvedAt.Time
+	}
+	return appeal, err
+}
+
+// Scan a row selected with attachmentColumns
+func scanAttachment(row rowScanner) (model.Attachment, error) {
+	var attachment model.Attachment
+	var linkedAt sql.NullTime
+	err := row.Scan(&attachment.AttachmentId, &attachment.UserId, &attachment.Filename, &attachment.ContentType, &attachment.SizeBytes, &attachment.StorageKey, &attachment.CreatedAt, &linkedAt)
+	attachment.LinkedAt = nullTimePtr(linkedAt)
+	return attachment, err
+}
+
+// Scan a row selected with eventColumns
+func scanEvent(row rowScanner) (model.Event, error) {
+	var event model.Event
+	var actorId sql.NullInt64
+	var payload []byte
+	err := row.Scan(&event.EventId, &event.Type, &actorId, &event.SubjectType, &event.SubjectId, &payload, &event.CreatedAt)
+	event.ActorId = nullIntPtr(actorId)
+	event.Payload = payload
+	return event, err
+}
+
+// Scan a row selected with dataAccessColumns
+func scanDataAccess(row rowScanner) (model.DataAccess, error) {
+	var access model.DataAccess
+	var userId sql.NullInt64
+	err := row.Scan(&access.AccessId, &userId, &access.ViewerId, &access.Access, &access.CreatedAt)
+	access.UserId = nullIntPtr(userId)
+	return access, err
+}
+
+// Scan a row selected with keywordAlertColumns
+func scanKeywordAlert(row rowScanner) (model.KeywordAlert, error) {
+	var alert model.KeywordAlert
+	var lastNotifiedAt sql.NullTime
+	err := row.Scan(&alert.AlertId, &alert.UserId, &alert.Keyword, &alert.LastPostId, &lastNotifiedAt, &alert.CreatedAt)
+	alert.LastNotifiedAt = nullTimePtr(lastNotifiedAt)
+	return alert, err
+}
+
+// Scan a row selected with announcementColumns
+func scanAnnouncement(row rowScanner) (model.Announcement, error) {
+	var announcement model.Announcement
+	var expiresAt sql.NullTime
+	var createdBy sql.NullInt64
+	err := row.Scan(&announcement.AnnouncementId, &announcement.Title, &announcement.Message, &announcement.Level, &announcement.StartsAt, &expiresAt, &createdBy, &announcement.CreatedAt, &announcement.UpdatedAt)
+	if expiresAt.Valid {
+		announcement.ExpiresAt = &expiresAt.Time
+	}
+	announcement.CreatedBy = nullIntPtr(createdBy)
+	return announcement, err
+}
+
+// Scan a row selected with backupColumns
+func scanBackup(row rowScanner) (model.Backup, error) {
+	var backup model.Backup
+	var triggeredBy sql.NullInt64
+	var finishedAt sql.NullTime
+	err := row.Scan(&backup.BackupId, &backup.StorageKey, &backup.Status, &backup.SizeBytes, &backup.Error, &triggeredBy, &backup.StartedAt, &finishedAt)
+	backup.TriggeredBy = nullIntPtr(triggeredBy)
+	if finishedAt.Valid {
+		backup.FinishedAt = &finishedAt.Time
+	}
+	return backup, err
+}
+
+// Scan a row selected with postTranslationColumns
+func scanPostTranslation(row rowScanner) (model.PostTranslation, error) {
+	var translation model.PostTranslation
+	err := row.Scan(&translation.PostId, &translation.Language, &translation.Title, &translation.Content, &translation.SourceHash, &translation.CreatedAt)
+	return translation, err
+}
+
+// Scan a row selected with serviceClientColumns
+func scanServiceClient(row rowScanner) (model.ServiceClient, error) {
+	var client model.ServiceClient
+	var createdBy sql.NullInt64
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&client.ClientId, &client.Name, &client.SecretHash, pq.Array(&client.Scopes), &createdBy, &client.CreatedAt, &lastUsedAt, &revokedAt)
+	client.CreatedBy = nullIntPtr(createdBy)
+	if lastUsedAt.Valid {
+		client.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		client.RevokedAt = &revokedAt.Time
+	}
+	return client, err
+}
+
+// Scan a row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (model.APIKey, error) {
+	var key model.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.KeyId, &key.UserId, &key.Name, &key.Prefix, &key.KeyHash, &key.CreatedAt, &lastUsedAt, &revokedAt)
+	key.LastUsedAt = nullTimePtr(lastUsedAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+	return key, err
+}
+
+// Converts a nullable integer column to an *int64 (nil for NULL)
+func nullIntPtr(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	id := value.Int64
+	return &id
+}
+
+// Converts an *int64 to a nullable integer parameter (NULL for nil)
+func ptrNullInt(value *int64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *value, Valid: true}
+}
+
+// A string for a nullable text parameter, stored as is (empty strings included)
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: true}
+}
+
+// Scan a row selected with sessionColumns
+func scanSession(row rowScanner) (model.Session, error) {
+	var session model.Session
+	var revokedAt sql.NullTime
+	var impersonatorId sql.NullInt64
+	err := row.Scan(&session.SessionId, &session.UserId, &session.FamilyId, &session.TokenVersion, &session.IP, &session.UserAgent,
+		&session.CreatedAt, &session.LastSeenAt, &session.ExpiresAt, &revokedAt, &impersonatorId)
+	session.RevokedAt = nullTimePtr(revokedAt)
+	session.ImpersonatorId = nullIntPtr(impersonatorId)
+	session.Impersonated = impersonatorId.Valid
+	return session, err
+}
+
+// Scan a row selected with refreshTokenColumns
+func scanRefreshToken(row rowScanner) (model.RefreshToken, error) {
+	var token model.RefreshToken
+	var usedAt, revokedAt sql.NullTime
+	err := row.Scan(&token.TokenHash, &token.UserId, &token.FamilyId, &token.TokenVersion, &token.CreatedAt, &token.ExpiresAt, &usedAt, &revokedAt)
+	token.UsedAt = nullTimePtr(usedAt)
+	token.RevokedAt = nullTimePtr(revokedAt)
+	return token, err
+}
+
+// Scan a row selected with skillRuleColumns
+func scanSkillRule(row rowScanner) (model.SkillRule, error) {
+	var rule model.SkillRule
+	var canonical sql.NullString
+	var createdBy sql.NullInt64
+	err := row.Scan(&rule.Name, &rule.Action, &canonical, &createdBy, &rule.CreatedAt)
+	rule.Skill = canonical.String
+	rule.CreatedBy = nullIntPtr(createdBy)
+	return rule, err
+}
+
+// Scan a row selected with roleColumns (permissions are loaded separately)
+func scanRole(row rowScanner) (model.Role, error) {
+	var role model.Role
+	err := row.Scan(&role.Name, &role.Description, &role.CreatedAt)
+	role.Permissions = []string{}
+	return role, err
+}
+
+// Converts a nullable float column to a *float64 (nil for NULL)
+func nullFloatPtr(value sql.NullFloat64) *float64 {
+	if !value.Valid {
+		return nil
+	}
+	f := value.Float64
+	return &f
+}
+
+// Converts a nullable timestamp column to a *time.Time (nil for NULL)
+func nullTimePtr(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+	t := value.Time
+	return &t
+}
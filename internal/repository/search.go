@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// #region Search suggestions
+
+// Find posts the viewer can see whose title starts with prefix, most recently active first (viewerId 0
+// for anonymous requests). The prefix index on titles keeps this fast enough to run on every keystroke.
+func (db *DB) SuggestPostTitles(ctx context.Context, prefix string, viewerId int64, limit int) ([]model.PostSuggestion, error) {
+	query, args := db.newSelect("post_id, title, author", "posts").
+		Where("LOWER(title) LIKE ?", escapeLike(strings.ToLower(prefix))+"%").
+		Where("NOT hidden").
+		Where(visibleToViewer, viewerId).
+		Where(postAudience, postAudienceArgs(viewerId)...).
+		OrderBy("last_activity_at DESC, post_id DESC").
+		Limit(limit).
+		Build()
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	posts := []model.PostSuggestion{}
+	for rows.Next() {
+		var post model.PostSuggestion
+		if err := rows.Scan(&post.PostId, &post.Title, &post.Author); err != nil {
+			return nil, fmt.Errorf("failed to scan post suggestion: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post suggestions: %w", err)
+	}
+
+	return posts, nil
+}
+
+// Find skill tags in use that start with prefix, the ones most members list first
+func (db *DB) SuggestSkills(ctx context.Context, prefix string, limit int) ([]model.SkillCount, error) {
+	query := `
+		SELECT s.name, COUNT(ps.user_id) AS members
+		FROM skills s
+		JOIN profile_skills ps ON ps.skill_id = s.skill_id
+		WHERE LOWER(s.name) LIKE $1
+		GROUP BY s.name
+		ORDER BY members DESC, s.name
+		LIMIT $2
+	`
+
+	rows, err := db.QueryContext(ctx, query, escapeLike(strings.ToLower(prefix))+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query skill suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	skills := []model.SkillCount{}
+	for rows.Next() {
+		var skill model.SkillCount
+		if err := rows.Scan(&skill.Name, &skill.Members); err != nil {
+			return nil, fmt.Errorf("failed to scan skill suggestion: %w", err)
+		}
+		skills = append(skills, skill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating skill suggestions: %w", err)
+	}
+
+	return skills, nil
+}
+
+// #endregion
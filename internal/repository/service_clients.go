@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// #region Service clients
+
+// Register a service client
+func (db *DB) CreateServiceClient(client *model.ServiceClient) error {
+	query := `
+		INSERT INTO service_clients (client_id, name, secret_hash, scopes, created_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	_, err := db.Exec(query, client.ClientId, client.Name, client.SecretHash, pq.Array(client.Scopes), client.CreatedBy, client.CreatedAt)
+	if isUniqueViolation(err) {
+		return fmt.Errorf("service client %w", ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create service client: %w", err)
+	}
+
+	return nil
+}
+
+// Get a service client by ID (including revoked ones)
+func (db *DB) GetServiceClient(clientId string) (*model.ServiceClient, error) {
+	query := "SELECT " + serviceClientColumns + " FROM service_clients WHERE client_id = $1"
+
+	client, err := scanServiceClient(db.QueryRow(query, clientId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("service client %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service client: %w", err)
+	}
+
+	return &client, nil
+}
+
+// Get every service client, newest first
+func (db *DB) ListServiceClients(limit, offset int) ([]model.ServiceClient, error) {
+	query, args := db.newSelect(serviceClientColumns, "service_clients").
+		OrderBy("created_at DESC, client_id").
+		Limit(limit).
+		Offset(offset).
+		Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query service clients: %w", err)
+	}
+	defer rows.Close()
+
+	clients := []model.ServiceClient{}
+	for rows.Next() {
+		client, err := scanServiceClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan service client: %w", err)
+		}
+		clients = append(clients, client)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating service clients: %w", err)
+	}
+
+	return clients, nil
+}
+
+// Revoke a service client so it can't get new tokens and its current ones stop working
+func (db *DB) RevokeServiceClient(clientId string, at time.Time) error {
+	result, err := db.Exec("UPDATE service_clients SET revoked_at = $2 WHERE client_id = $1 AND revoked_at IS NULL", clientId, at)
+	if err != nil {
+		return fmt.Errorf("failed to revoke service client: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("active service client %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Record when a service client last got a token
+func (db *DB) TouchServiceClient(clientId string, at time.Time) error {
+	if _, err := db.Exec("UPDATE service_clients SET last_used_at = $2 WHERE client_id = $1", clientId, at); err != nil {
+		return fmt.Errorf("failed to update service client: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
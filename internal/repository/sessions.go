@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// #region Sessions
+
+// Record a new session, filling in its ID
+func (db *DB) CreateSession(session *model.Session) error {
+	query := `
+		INSERT INTO sessions (user_id, family_id, token_version, ip, user_agent, created_at, last_seen_at, expires_at, impersonator_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $6, $7, $8)
+		RETURNING session_id
+	`
+
+	err := db.QueryRow(query, session.UserId, session.FamilyId, session.TokenVersion, session.IP, session.UserAgent, session.CreatedAt, session.ExpiresAt, session.ImpersonatorId).
+		Scan(&session.SessionId)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// Get a session by ID, whatever its state
+func (db *DB) GetSession(sessionId int64) (*model.Session, error) {
+	query := "SELECT " + sessionColumns + " FROM sessions WHERE session_id = $1"
+
+	session, err := scanSession(db.QueryRow(query, sessionId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("session %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// Get the session a refresh token family belongs to, whatever its state
+func (db *DB) GetSessionByFamily(familyId string) (*model.Session, error) {
+	query := "SELECT " + sessionColumns + " FROM sessions WHERE family_id = $1"
+
+	session, err := scanSession(db.QueryRow(query, familyId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("session %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// Get a user's sessions that are still live: not revoked or expired, and started since their last
+// password change or ban (most recently used first)
+func (db *DB) GetActiveSessions(userId int64, now time.Time) ([]model.Session, error) {
+	query := "SELECT " + sessionColumns + ` FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+			AND token_version = (SELECT token_version FROM users WHERE user_id = $1)
+		ORDER BY last_seen_at DESC, session_id DESC
+	`
+
+	rows, err := db.Query(query, userId, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []model.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sessions: %w", err)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// Move a session's expiry to that of the refresh token just issued for it
+func (db *DB) ExtendSession(sessionId int64, expiresAt time.Time) error {
+	if _, err := db.Exec("UPDATE sessions SET expires_at = $2 WHERE session_id = $1", sessionId, expiresAt); err != nil {
+		return fmt.Errorf("failed to extend session: %w", err)
+	}
+
+	return nil
+}
+
+// Record that a session was just used
+func (db *DB) TouchSession(sessionId int64, at time.Time) error {
+	if _, err := db.Exec("UPDATE sessions SET last_seen_at = $2 WHERE session_id = $1", sessionId, at); err != nil {
+		return fmt.Errorf("failed to update session last seen time: %w", err)
+	}
+
+	return nil
+}
+
+// Revoke one of a user's sessions along with its refresh tokens. Returns ErrNotFound when the user has
+// no such session or it's already revoked.
+func (db *DB) RevokeSession(userId, sessionId int64, at time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var familyId string
+	err = tx.QueryRow("UPDATE sessions SET revoked_at = $3 WHERE session_id = $1 AND user_id = $2 AND revoked_at IS NULL RETURNING family_id",
+		sessionId, userId, at).Scan(&familyId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("session %w", ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = $2 WHERE family_id = $1 AND revoked_at IS NULL", familyId, at); err != nil {
+		return fmt.Errorf("failed to revoke session refresh tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit session revocation: %w", err)
+	}
+
+	return nil
+}
+
+// Delete sessions that expired before the given time (returns how many were deleted)
+func (db *DB) DeleteExpiredSessions(before time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM sessions WHERE expires_at <= $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// #endregion
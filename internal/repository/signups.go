@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+)
+
+// #region Signups
+
+// Record a registration for signup throttling
+func (db *DB) RecordSignup(userId int64, ip, subnet, email string, createdAt time.Time) error {
+	query := `
+		INSERT INTO signups (user_id, ip_address, subnet, email, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5)
+	`
+
+	if _, err := db.Exec(query, userId, ip, subnet, email, createdAt); err != nil {
+		return fmt.Errorf("failed to record signup: %w", err)
+	}
+
+	return nil
+}
+
+// Delete signup records made before a time (returns how many were removed)
+func (db *DB) DeleteSignupsBefore(before time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM signups WHERE created_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old signups: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// Count registrations from an IP address since a time
+func (db *DB) CountSignupsByIP(ip string, since time.Time) (int, error) {
+	return db.countSignups("ip_address", ip, since)
+}
+
+// Count registrations from a subnet since a time
+func (db *DB) CountSignupsBySubnet(subnet string, since time.Time) (int, error) {
+	return db.countSignups("subnet", subnet, since)
+}
+
+// Count registrations with a (normalized) email address since a time
+func (db *DB) CountSignupsByEmail(email string, since time.Time) (int, error) {
+	return db.countSignups("email", email, since)
+}
+
+// Counts signups where column matches value (column is never user input)
+func (db *DB) countSignups(column, value string, since time.Time) (int, error) {
+	query := "SELECT COUNT(*) FROM signups WHERE " + column + " = $1 AND created_at >= $2"
+
+	var count int
+	if err := db.QueryRow(query, value, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count signups by %s: %w", column, err)
+	}
+
+	return count, nil
+}
+
+// #endregion
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"context"
+	"fmt"
+)
+
+// #region Skill rules
+
+// Get every skill rule, by tag
+func (db *DB) GetSkillRules() ([]model.SkillRule, error) {
+	rows, err := db.Query("SELECT " + skillRuleColumns + " FROM skill_rules ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query skill rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []model.SkillRule{}
+	for rows.Next() {
+		rule, err := scanSkillRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan skill rules: %w", err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Create or replace the rules for several tags (in one transaction)
+func (db *DB) SetSkillRules(rules []model.SkillRule) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO skill_rules (name, action, canonical, created_by, created_at)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+		ON CONFLICT (name) DO UPDATE SET action = $2, canonical = NULLIF($3, ''), created_by = $4, created_at = $5
+	`
+	for _, rule := range rules {
+		if _, err := tx.Exec(query, rule.Name, rule.Action, rule.Skill, rule.CreatedBy, rule.CreatedAt); err != nil {
+			return fmt.Errorf("failed to save skill rule: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit skill rules: %w", err)
+	}
+
+	return nil
+}
+
+// Delete the rule for a tag (profiles already migrated keep their canonical skill)
+func (db *DB) DeleteSkillRule(name string) error {
+	result, err := db.Exec("DELETE FROM skill_rules WHERE name = $1", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete skill rule: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("skill rule %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Bring existing profiles in line with the skill rules (in one transaction): synonyms are replaced by their
+// canonical skill, blocked tags are removed, and the tags the rules name are deleted from the skill list
+func (db *DB) ApplySkillRules(ctx context.Context) (model.SkillMigrationResult, error) {
+	var result model.SkillMigrationResult
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	createCanonical := `
+		INSERT INTO skills (name)
+		SELECT DISTINCT r.canonical
+		FROM skill_rules r
+		JOIN skills s ON s.name = r.name
+		WHERE r.action = 'synonym'
+		ON CONFLICT (name) DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, createCanonical); err != nil {
+		return result, fmt.Errorf("failed to create canonical skills: %w", err)
+	}
+
+	// Profiles that already list the canonical skill just lose the synonym
+	moveSynonyms := `
+		INSERT INTO profile_skills (user_id, skill_id)
+		SELECT ps.user_id, c.skill_id
+		FROM profile_skills ps
+		JOIN skills s ON s.skill_id = ps.skill_id
+		JOIN skill_rules r ON r.name = s.name AND r.action = 'synonym'
+		JOIN skills c ON c.name = r.canonical
+		ON CONFLICT DO NOTHING
+	`
+	if _, err := tx.ExecContext(ctx, moveSynonyms); err != nil {
+		return result, fmt.Errorf("failed to move synonym skills: %w", err)
+	}
+
+	removeTags := `
+		DELETE FROM profile_skills ps
+		USING skills s, skill_rules r
+		WHERE s.skill_id = ps.skill_id AND r.name = s.name
+		RETURNING r.action
+	`
+	rows, err := tx.QueryContext(ctx, removeTags)
+	if err != nil {
+		return result, fmt.Errorf("failed to remove ruled skills: %w", err)
+	}
+	for rows.Next() {
+		var action string
+		if err := rows.Scan(&action); err != nil {
+			rows.Close()
+			return result, fmt.Errorf("failed to scan removed skills: %w", err)
+		}
+
+		if action == model.SkillRuleSynonym {
+			result.Moved++
+		} else {
+			result.Removed++
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("failed to remove ruled skills: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM skills WHERE name IN (SELECT name FROM skill_rules)"); err != nil {
+		return result, fmt.Errorf("failed to delete ruled skills: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit skill migration: %w", err)
+	}
+
+	return result, nil
+}
+
+// #endregion
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// #region Skills
+
+// Get the skill names on a user's profile
+func (db *DB) GetSkillsByUser(userId int64) ([]string, error) {
+	query, args := db.capRows(`
+		SELECT s.name
+		FROM profile_skills ps
+		JOIN skills s ON s.skill_id = ps.skill_id
+		WHERE ps.user_id = $1
+		ORDER BY s.name`, userId)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query skills: %w", err)
+	}
+	defer rows.Close()
+
+	skills := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan skills: %w", err)
+		}
+
+		skills = append(skills, name)
+	}
+
+	return skills, nil
+}
+
+// Get the skill names for several users at once, keyed by user ID
+func (db *DB) GetSkillsByUsers(userIds []int64) (map[int64][]string, error) {
+	skillsByUser := make(map[int64][]string, len(userIds))
+	if len(userIds) == 0 {
+		return skillsByUser, nil
+	}
+
+	ids := make([]int64, 0, len(userIds))
+	for _, id := range userIds {
+		ids = append(ids, int64(id))
+	}
+
+	query := `
+		SELECT ps.user_id, s.name
+		FROM profile_skills ps
+		JOIN skills s ON s.skill_id = ps.skill_id
+		WHERE ps.user_id = ANY($1)
+		ORDER BY ps.user_id, s.name
+	`
+
+	rows, err := db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query skills: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userId int64
+		var name string
+		if err := rows.Scan(&userId, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan skills: %w", err)
+		}
+
+		skillsByUser[userId] = append(skillsByUser[userId], name)
+	}
+
+	return skillsByUser, nil
+}
+
+// Replace the skills on a user's profile (skill names must already be normalized)
+func (db *DB) SetUserSkills(userId int64, skills []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM profile_skills WHERE user_id = $1", userId); err != nil {
+		return fmt.Errorf("failed to clear skills: %w", err)
+	}
+
+	if len(skills) > 0 {
+		// Add any tags we haven't seen before
+		insertSkills := `
+			INSERT INTO skills (name)
+			SELECT UNNEST($1::text[])
+			ON CONFLICT (name) DO NOTHING
+		`
+		if _, err := tx.Exec(insertSkills, pq.Array(skills)); err != nil {
+			return fmt.Errorf("failed to create skills: %w", err)
+		}
+
+		linkSkills := `
+			INSERT INTO profile_skills (user_id, skill_id)
+			SELECT $1, skill_id FROM skills WHERE name = ANY($2)
+		`
+		if _, err := tx.Exec(linkSkills, userId, pq.Array(skills)); err != nil {
+			return fmt.Errorf("failed to set skills: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit skills: %w", err)
+	}
+
+	return nil
+}
+
+// Get a page of the profiles tagged with a skill (reports whether another page follows)
+func (db *DB) GetProfilesBySkill(skill string, limit, offset int) ([]model.Profile, bool, error) {
+	return db.listProfiles(db.newSelect(profileColumns, "profiles").Where(`user_id IN (
+			SELECT ps.user_id
+			FROM profile_skills ps
+			JOIN skills s ON s.skill_id = ps.skill_id
+			WHERE s.name = ?
+		)`, skill), limit, offset)
+}
+
+// Get the skills in use along with how many members list them, most listed first (up to the maximum row count)
+func (db *DB) GetSkillCounts() ([]model.SkillCount, error) {
+	query, args := db.capRows(`
+		SELECT s.name, COUNT(ps.user_id) AS members
+		FROM skills s
+		JOIN profile_skills ps ON ps.skill_id = s.skill_id
+		GROUP BY s.name
+		ORDER BY members DESC, s.name`)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query skill counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []model.SkillCount{}
+	for rows.Next() {
+		var count model.SkillCount
+		if err := rows.Scan(&count.Name, &count.Members); err != nil {
+			return nil, fmt.Errorf("failed to scan skill counts: %w", err)
+		}
+
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+// #endregion
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// #region Snippets
+
+// Get all snippets attached to a post
+func (db *DB) GetSnippetsByPost(postId int64) ([]model.Snippet, error) {
+	query, args := db.newSelect(snippetColumns, "snippets").
+		Where("post_id = ?", postId).
+		OrderBy("snippet_id").
+		Build()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snippets: %w", err)
+	}
+	defer rows.Close()
+
+	var snippetList []model.Snippet
+	for rows.Next() {
+		snippet, err := scanSnippet(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan snippets: %w", err)
+		}
+
+		snippetList = append(snippetList, snippet)
+	}
+
+	return snippetList, nil
+}
+
+// Get snippet by ID
+func (db *DB) GetSnippetById(snippetId int64) (*model.Snippet, error) {
+	query := "SELECT " + snippetColumns + " FROM snippets WHERE snippet_id = $1"
+
+	snippet, err := scanSnippet(db.QueryRow(query, snippetId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("snippet %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query snippet: %w", err)
+	}
+
+	return &snippet, nil
+}
+
+// Attach a snippet to a post
+func (db *DB) CreateSnippet(snippet *model.Snippet) error {
+	query := `
+		INSERT INTO snippets (post_id, user_id, filename, language, body, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING snippet_id
+	`
+
+	err := db.QueryRow(query, snippet.PostId, snippet.UserId, snippet.Filename, snippet.Language, snippet.Body, snippet.CreatedAt).
+		Scan(&snippet.SnippetId)
+	if err != nil {
+		return fmt.Errorf("failed to create snippet: %w", err)
+	}
+
+	return nil
+}
+
+// Delete a snippet
+func (db *DB) DeleteSnippet(snippetId int64) error {
+	result, err := db.Exec("DELETE FROM snippets WHERE snippet_id = $1", snippetId)
+	if err != nil {
+		return fmt.Errorf("failed to delete snippet: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("snippet %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// #endregion
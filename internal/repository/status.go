@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"fmt"
+)
+
+// Version of database.sql this build expects. Bump it with the schema_version seed row in the commit that
+// changes the schema (TestSchemaVersionMatchesSchema fails until then).
+const SchemaVersion = 4
+
+// #region Database status
+
+// Gathers database detail for the verbose readiness check: server and schema versions, replication
+// and pool state, and per-table statistics. Row counts come from pg_stat_user_tables rather than
+// COUNT(*), so checking readiness never scans a table.
+func (db *DB) GetDatabaseStatus() (*model.DatabaseStatus, error) {
+	status := &model.DatabaseStatus{
+		ExpectedSchemaVersion: SchemaVersion,
+		Replicas:              []model.ReplicaStatus{},
+		Pool:                  db.poolStats(),
+	}
+
+	if err := db.QueryRow("SHOW server_version").Scan(&status.ServerVersion); err != nil {
+		return nil, fmt.Errorf("failed to query server version: %w", err)
+	}
+
+	if err := db.schemaStatus(status); err != nil {
+		return nil, err
+	}
+	if err := db.replicationStatus(status); err != nil {
+		return nil, err
+	}
+
+	tables, err := db.tableStats()
+	if err != nil {
+		return nil, err
+	}
+	status.Tables = tables
+
+	return status, nil
+}
+
+// Compares the database's schema_version with SchemaVersion, returning the migration status and the
+// database's version (nil when it has none)
+func (db *DB) GetMigrationStatus() (string, *int, error) {
+	var status model.DatabaseStatus
+	if err := db.schemaStatus(&status); err != nil {
+		return "", nil, err
+	}
+
+	return status.MigrationStatus, status.SchemaVersion, nil
+}
+
+// Compares the schema_version row with SchemaVersion
+func (db *DB) schemaStatus(status *model.DatabaseStatus) error {
+	var exists bool
+	if err := db.QueryRow("SELECT to_regclass('schema_version') IS NOT NULL").Scan(&exists); err != nil {
+		return fmt.Errorf("failed to look up schema_version: %w", err)
+	}
+	if !exists {
+		status.MigrationStatus = model.MigrationUnknown
+		return nil
+	}
+
+	var version sql.NullInt64
+	if err := db.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to query schema version: %w", err)
+	}
+	if !version.Valid {
+		status.MigrationStatus = model.MigrationUnknown
+		return nil
+	}
+
+	current := int(version.Int64)
+	status.SchemaVersion = &current
+	switch {
+	case current < SchemaVersion:
+		status.MigrationStatus = model.MigrationPending
+	case current > SchemaVersion:
+		status.MigrationStatus = model.MigrationAhead
+	default:
+		status.MigrationStatus = model.MigrationUpToDate
+	}
+
+	return nil
+}
+
+// Reports replay lag on a standby, or the replicas streaming from a primary
+func (db *DB) replicationStatus(status *model.DatabaseStatus) error {
+	if err := db.QueryRow("SELECT pg_is_in_recovery()").Scan(&status.InRecovery); err != nil {
+		return fmt.Errorf("failed to query recovery state: %w", err)
+	}
+
+	if status.InRecovery {
+		var lag sql.NullFloat64
+		if err := db.QueryRow("SELECT EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())").Scan(&lag); err != nil {
+			return fmt.Errorf("failed to query replication lag: %w", err)
+		}
+		status.ReplicationLagSeconds = nullFloatPtr(lag)
+		return nil
+	}
+
+	// Without pg_monitor the address and lag columns read as NULL
+	query := `
+		SELECT application_name, client_addr::text, state, EXTRACT(EPOCH FROM replay_lag)
+		FROM pg_stat_replication
+		ORDER BY application_name
+	`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return fmt.Errorf("failed to query replicas: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var replica model.ReplicaStatus
+		var clientAddr sql.NullString
+		var lag sql.NullFloat64
+		if err := rows.Scan(&replica.Name, &clientAddr, &replica.State, &lag); err != nil {
+			return fmt.Errorf("failed to scan replicas: %w", err)
+		}
+		if clientAddr.Valid {
+			replica.ClientAddr = &clientAddr.String
+		}
+		replica.ReplayLagSeconds = nullFloatPtr(lag)
+
+		status.Replicas = append(status.Replicas, replica)
+	}
+
+	return nil
+}
+
+// Get planner statistics for every table in the schema
+func (db *DB) tableStats() ([]model.TableStats, error) {
+	query := `
+		SELECT relname, n_live_tup, n_dead_tup, pg_total_relation_size(relid), last_autovacuum, last_autoanalyze
+		FROM pg_stat_user_tables
+		ORDER BY relname
+	`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query table stats: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []model.TableStats{}
+	for rows.Next() {
+		var table model.TableStats
+		var lastAutovacuum, lastAutoanalyze sql.NullTime
+		err := rows.Scan(&table.Name, &table.EstimatedRows, &table.DeadRows, &table.TotalBytes, &lastAutovacuum, &lastAutoanalyze)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table stats: %w", err)
+		}
+		table.LastAutovacuum = nullTimePtr(lastAutovacuum)
+		table.LastAutoanalyze = nullTimePtr(lastAutoanalyze)
+
+		tables = append(tables, table)
+	}
+
+	return tables, nil
+}
+
+// Reports how busy the connection pool is
+func (db *DB) poolStats() model.PoolStats {
+	stats := db.Stats()
+
+	pool := model.PoolStats{
+		MaxOpen:        stats.MaxOpenConnections,
+		Open:           stats.OpenConnections,
+		InUse:          stats.InUse,
+		Idle:           stats.Idle,
+		WaitCount:      stats.WaitCount,
+		WaitDurationMs: stats.WaitDuration.Milliseconds(),
+	}
+	if stats.MaxOpenConnections > 0 {
+		saturation := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		pool.Saturation = &saturation
+	}
+
+	return pool
+}
+
+// #endregion
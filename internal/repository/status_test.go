@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// The fingerprint of database.sql at each schema version. A schema change without a version bump
+// leaves /readyz and the startup check comparing against the wrong version, so changing the schema
+// fails this test until SchemaVersion and the schema_version seed row are bumped (in the same commit)
+// and the new fingerprint is recorded here.
+var schemaFingerprints = map[int]string{
+	2: "63efd168d032a6456e48961ddde069c763b80c477d4a36655acef78e1d47c471",
+	3: "b813aad23ba2bb27ee702edb871cac4c6a1f393dcc7209dbe7167af2357db4ba",
+	4: "85a43d140389240a473c2063c891d134b089f2cfdc31cf8ae0087f6ce8e33151",
+}
+
+var (
+	sqlComment      = regexp.MustCompile(`--[^\n]*`)
+	seedVersionStmt = regexp.MustCompile(`INSERT INTO schema_version \(version\) VALUES \((\d+)\);`)
+)
+
+// Hashes the statements of a schema file, ignoring comments, layout and the schema_version seed row
+func schemaFingerprint(schema string) string {
+	schema = seedVersionStmt.ReplaceAllString(sqlComment.ReplaceAllString(schema, ""), "")
+	statements := strings.Join(strings.Fields(schema), " ")
+	sum := sha256.Sum256([]byte(statements))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestSchemaVersionMatchesSchema(t *testing.T) {
+	schema, err := os.ReadFile("../../database.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := seedVersionStmt.FindStringSubmatch(string(schema))
+	if seed == nil {
+		t.Fatal("database.sql doesn't seed schema_version")
+	}
+	if version, _ := strconv.Atoi(seed[1]); version != SchemaVersion {
+		t.Fatalf("database.sql seeds schema_version %d, but SchemaVersion is %d", version, SchemaVersion)
+	}
+
+	got := schemaFingerprint(string(schema))
+	want, recorded := schemaFingerprints[SchemaVersion]
+	if !recorded {
+		t.Fatalf("no fingerprint for schema version %d: record %d: %q in schemaFingerprints", SchemaVersion, SchemaVersion, got)
+	}
+	if got != want {
+		t.Fatalf("database.sql changed without a schema version bump: bump SchemaVersion and the seed row, "+
+			"then record %d: %q in schemaFingerprints", SchemaVersion+1, got)
+	}
+}
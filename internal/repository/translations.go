@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// #region Post translations
+
+// Get the cached translation of a post into a language
+func (db *DB) GetPostTranslation(postId int64, language string) (*model.PostTranslation, error) {
+	query := "SELECT " + postTranslationColumns + " FROM post_translations WHERE post_id = $1 AND language = $2"
+
+	translation, err := scanPostTranslation(db.QueryRow(query, postId, language))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("post translation %w", ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post translation: %w", err)
+	}
+
+	return &translation, nil
+}
+
+// Cache a translation of a post, replacing any earlier one for the same language
+func (db *DB) SavePostTranslation(translation *model.PostTranslation) error {
+	query := `
+		INSERT INTO post_translations (post_id, language, title, content, source_hash, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (post_id, language) DO UPDATE
+		SET title = EXCLUDED.title, content = EXCLUDED.content, source_hash = EXCLUDED.source_hash, created_at = EXCLUDED.created_at
+	`
+
+	_, err := db.Exec(query, translation.PostId, translation.Language, translation.Title, translation.Content,
+		translation.SourceHash, translation.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save post translation: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// #region Transactions
+
+// Context key for the database bound to a request's transaction
+type dbContextKey struct{}
+
+// The transaction a DB copy is bound to (see InTx)
+type boundTx struct {
+	tx *sql.Tx
+	// Savepoints opened so far, for naming the next one
+	savepoints int
+	// Run once the transaction commits (see AfterCommit)
+	afterCommit []func()
+}
+
+// A transaction from Begin. Begun on a DB bound to a transaction (see InTx), it's a savepoint in that
+// transaction instead, so repository methods that group their own writes work the same either way.
+type Tx struct {
+	tx *sql.Tx
+	// Set when nested in a bound transaction
+	savepoint string
+	done      bool
+}
+
+// Start a transaction, or a savepoint when the DB is bound to one
+func (db *DB) Begin() (*Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+// Start a transaction, or a savepoint when the DB is bound to one (opts are ignored then, as the
+// bound transaction's settings apply)
+func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if db.bound == nil {
+		tx, err := db.DB.BeginTx(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &Tx{tx: tx}, nil
+	}
+
+	db.bound.savepoints++
+	savepoint := fmt.Sprintf("sp_%d", db.bound.savepoints)
+	if _, err := db.bound.tx.ExecContext(ctx, "SAVEPOINT "+savepoint); err != nil {
+		return nil, err
+	}
+	return &Tx{tx: db.bound.tx, savepoint: savepoint}, nil
+}
+
+// Run fn with a copy of the database bound to a new transaction: every query made through the copy,
+// including the transactions its methods begin, runs inside it. The transaction is committed when fn
+// returns nil, and rolled back when it returns an error or panics. On a DB already bound to a
+// transaction, fn runs in a savepoint of it instead.
+func (db *DB) InTx(ctx context.Context, fn func(tx *DB) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	bound := db
+	if db.bound == nil {
+		bound = &DB{DB: db.DB, bound: &boundTx{tx: tx.tx}, maxRows: db.maxRows}
+	}
+
+	if err := fn(bound); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Only the outermost transaction's commit makes the writes visible
+	if db.bound == nil {
+		for _, hook := range bound.bound.afterCommit {
+			hook()
+		}
+	}
+
+	return nil
+}
+
+// Run fn once the writes made so far are visible to other connections: right away, or after the bound
+// transaction commits (never, if it rolls back). For invalidating caches, which a concurrent request could
+// otherwise refill with rows the transaction is about to change.
+func (db *DB) AfterCommit(fn func()) {
+	if db.bound == nil {
+		fn()
+		return
+	}
+	db.bound.afterCommit = append(db.bound.afterCommit, fn)
+}
+
+// Store a database (usually one bound to the request's transaction) in a context
+func NewContext(ctx context.Context, db *DB) context.Context {
+	return context.WithValue(ctx, dbContextKey{}, db)
+}
+
+// Get the database stored in a context, or fallback when there is none
+func FromContext(ctx context.Context, fallback *DB) *DB {
+	if db, ok := ctx.Value(dbContextKey{}).(*DB); ok {
+		return db
+	}
+	return fallback
+}
+
+// Queries go through the bound transaction, if any
+
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if db.bound != nil {
+		return db.bound.tx.ExecContext(ctx, query, args...)
+	}
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if db.bound != nil {
+		return db.bound.tx.QueryContext(ctx, query, args...)
+	}
+	return db.DB.QueryContext(ctx, query, args...)
+}
+
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if db.bound != nil {
+		return db.bound.tx.QueryRowContext(ctx, query, args...)
+	}
+	return db.DB.QueryRowContext(ctx, query, args...)
+}
+
+func (t *Tx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.Exec(query, args...)
+}
+
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+func (t *Tx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.Query(query, args...)
+}
+
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return t.tx.QueryContext(ctx, query, args...)
+}
+
+func (t *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.tx.QueryRow(query, args...)
+}
+
+func (t *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return t.tx.QueryRowContext(ctx, query, args...)
+}
+
+// Commit the transaction, or release the savepoint (its writes then commit with the bound transaction)
+func (t *Tx) Commit() error {
+	if t.savepoint == "" {
+		return t.tx.Commit()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+
+	t.done = true
+	_, err := t.tx.Exec("RELEASE SAVEPOINT " + t.savepoint)
+	return err
+}
+
+// Roll back the transaction, or undo the writes made since the savepoint. Like sql.Tx, rolling back
+// after a commit does nothing, so it can be deferred.
+func (t *Tx) Rollback() error {
+	if t.savepoint == "" {
+		return t.tx.Rollback()
+	}
+	if t.done {
+		return sql.ErrTxDone
+	}
+
+	t.done = true
+	_, err := t.tx.Exec("ROLLBACK TO SAVEPOINT " + t.savepoint)
+	return err
+}
+
+// #endregion
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// A database/sql driver whose connections only begin, commit and roll back transactions (and open
+// savepoints), recording each transaction's outcome
+type txDriver struct {
+	outcomes []string
+}
+
+func (d *txDriver) Open(string) (driver.Conn, error) { return &txConn{driver: d}, nil }
+
+type txConn struct{ driver *txDriver }
+
+func (c *txConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("prepare isn't supported")
+}
+func (c *txConn) Close() error              { return nil }
+func (c *txConn) Begin() (driver.Tx, error) { return &txTx{driver: c.driver}, nil }
+
+func (c *txConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+type txTx struct{ driver *txDriver }
+
+func (t *txTx) Commit() error {
+	t.driver.outcomes = append(t.driver.outcomes, "commit")
+	return nil
+}
+
+func (t *txTx) Rollback() error {
+	t.driver.outcomes = append(t.driver.outcomes, "rollback")
+	return nil
+}
+
+func openTxDB(t *testing.T) (*DB, *txDriver) {
+	t.Helper()
+
+	d := &txDriver{}
+	name := "tx-" + t.Name()
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &DB{DB: db}, d
+}
+
+func TestAfterCommit(t *testing.T) {
+	t.Run("unbound runs right away", func(t *testing.T) {
+		db, _ := openTxDB(t)
+		ran := false
+		db.AfterCommit(func() { ran = true })
+		if !ran {
+			t.Fatal("hook on an unbound database didn't run")
+		}
+	})
+
+	t.Run("runs after the commit", func(t *testing.T) {
+		db, d := openTxDB(t)
+		var seen []string
+		err := db.InTx(context.Background(), func(tx *DB) error {
+			tx.AfterCommit(func() { seen = append(seen, d.outcomes...) })
+			// Hooks registered in a savepoint wait for the outer commit too
+			return tx.InTx(context.Background(), func(inner *DB) error {
+				inner.AfterCommit(func() { seen = append(seen, "inner") })
+				if len(seen) != 0 {
+					t.Error("hook ran before the commit")
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(seen) != 2 || seen[0] != "commit" || seen[1] != "inner" {
+			t.Fatalf("hooks saw %v, want [commit inner]", seen)
+		}
+	})
+
+	t.Run("skipped on rollback", func(t *testing.T) {
+		db, _ := openTxDB(t)
+		ran := false
+		failed := errors.New("failed")
+		err := db.InTx(context.Background(), func(tx *DB) error {
+			tx.AfterCommit(func() { ran = true })
+			return failed
+		})
+		if !errors.Is(err, failed) || ran {
+			t.Fatalf("InTx returned %v and ran the hook: %t", err, ran)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// #region User imports
+
+// Create the accounts of an import batch along with their profiles (in one transaction). An account whose
+// username is taken, or whose email another profile already uses, is skipped and its result marked as a
+// conflict; earlier rows of the same batch count. Created accounts get their user ID in the result.
+// With dryRun the transaction is rolled back, so the results show what an import would do.
+func (db *DB) ImportUsers(imports []model.UserImport, registeredAt time.Time, dryRun bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, imp := range imports {
+		if imp.Email != "" {
+			var emailTaken bool
+			if err := tx.QueryRow("SELECT EXISTS (SELECT 1 FROM profiles WHERE LOWER(email) = LOWER($1))", imp.Email).Scan(&emailTaken); err != nil {
+				return fmt.Errorf("failed to check email: %w", err)
+			}
+			if emailTaken {
+				imp.Result.Status = model.ImportConflict
+				imp.Result.Error = "email already in use"
+				continue
+			}
+		}
+
+		user := imp.User
+		err := tx.QueryRow(`
+			INSERT INTO users (username, hashed_password, role, first_name, last_name)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT DO NOTHING
+			RETURNING user_id
+		`, user.Username, user.HashedPassword, user.Role, user.FirstName, user.LastName).Scan(&imp.Result.UserId)
+		if errors.Is(err, sql.ErrNoRows) {
+			imp.Result.Status = model.ImportConflict
+			imp.Result.Error = "username already exists"
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		profileQuery := `
+			INSERT INTO profiles (user_id, first_name, last_name, email, github_link, city, state, date_registered)
+			VALUES ($1, $2, $3, $4, '', '', '', $5)
+		`
+		if _, err := tx.Exec(profileQuery, imp.Result.UserId, user.FirstName, user.LastName, imp.Email, registeredAt); err != nil {
+			return fmt.Errorf("failed to create profile: %w", err)
+		}
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit user import: %w", err)
+	}
+
+	return nil
+}
+
+// #endregion
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// #region Post watches
+
+// Watch a post for new comments (clears a mute)
+func (db *DB) WatchPost(userId, postId int64) error {
+	query := `
+		INSERT INTO post_watches (user_id, post_id, muted)
+		VALUES ($1, $2, FALSE)
+		ON CONFLICT (user_id, post_id) DO UPDATE SET muted = FALSE
+	`
+
+	if _, err := db.Exec(query, userId, postId); err != nil {
+		return fmt.Errorf("failed to watch post: %w", err)
+	}
+
+	return nil
+}
+
+// Watch a post unless the user already watches or muted it (used when they comment)
+func (db *DB) AutoWatchPost(userId, postId int64) error {
+	query := `
+		INSERT INTO post_watches (user_id, post_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	if _, err := db.Exec(query, userId, postId); err != nil {
+		return fmt.Errorf("failed to auto-watch post: %w", err)
+	}
+
+	return nil
+}
+
+// Mute a post so none of its new comments notify the user (overrides watches, including the author's default one)
+func (db *DB) MutePost(userId, postId int64) error {
+	query := `
+		INSERT INTO post_watches (user_id, post_id, muted)
+		VALUES ($1, $2, TRUE)
+		ON CONFLICT (user_id, post_id) DO UPDATE SET muted = TRUE
+	`
+
+	if _, err := db.Exec(query, userId, postId); err != nil {
+		return fmt.Errorf("failed to mute post: %w", err)
+	}
+
+	return nil
+}
+
+// Remove a user's watch on a post (muted reports whether to remove a mute instead)
+func (db *DB) ClearPostWatch(userId, postId int64, muted bool) error {
+	result, err := db.Exec("DELETE FROM post_watches WHERE user_id = $1 AND post_id = $2 AND muted = $3", userId, postId, muted)
+	if err != nil {
+		return fmt.Errorf("failed to clear post watch: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("post watch %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// Get whether a user is notified about new comments on a post
+func (db *DB) GetPostWatch(userId int64, post *model.Post) (model.PostWatch, error) {
+	watch := model.PostWatch{PostId: post.PostId}
+
+	var muted bool
+	err := db.QueryRow("SELECT muted FROM post_watches WHERE user_id = $1 AND post_id = $2", userId, post.PostId).Scan(&muted)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// Authors watch their own posts by default
+		watch.Watching = post.UserId == userId
+	case err != nil:
+		return watch, fmt.Errorf("failed to query post watch: %w", err)
+	default:
+		watch.Watching = !muted
+		watch.Muted = muted
+	}
+
+	return watch, nil
+}
+
+// Get the users to notify about new comments on a post: its watchers, plus the author unless they muted it
+func (db *DB) GetPostWatchers(postId int64) ([]int64, error) {
+	query := `
+		SELECT user_id FROM post_watches WHERE post_id = $1 AND NOT muted
+		UNION
+		SELECT p.user_id FROM posts p
+		WHERE p.post_id = $1
+			AND NOT EXISTS (SELECT 1 FROM post_watches w WHERE w.post_id = p.post_id AND w.user_id = p.user_id AND w.muted)
+	`
+
+	rows, err := db.Query(query, postId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post watchers: %w", err)
+	}
+	defer rows.Close()
+
+	var userIds []int64
+	for rows.Next() {
+		var userId int64
+		if err := rows.Scan(&userId); err != nil {
+			return nil, fmt.Errorf("failed to scan post watcher: %w", err)
+		}
+		userIds = append(userIds, userId)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating post watchers: %w", err)
+	}
+
+	return userIds, nil
+}
+
+// Get which of the given posts notify userId about new comments: ones they watch, plus their own posts
+// unless muted (the batch form of GetPostWatch)
+func (db *DB) GetWatchedAmong(userId int64, postIds []int64) (map[int64]bool, error) {
+	watched := make(map[int64]bool, len(postIds))
+	if len(postIds) == 0 {
+		return watched, nil
+	}
+
+	query := `
+		SELECT p.post_id
+		FROM posts p
+		LEFT JOIN post_watches w ON w.post_id = p.post_id AND w.user_id = $1
+		WHERE p.post_id = ANY($2)
+			AND (NOT w.muted OR (w.user_id IS NULL AND p.user_id = $1))
+	`
+
+	rows, err := db.Query(query, userId, pq.Array(postIds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watched posts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var postId int64
+		if err := rows.Scan(&postId); err != nil {
+			return nil, fmt.Errorf("failed to scan watched post: %w", err)
+		}
+		watched[postId] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating watched posts: %w", err)
+	}
+
+	return watched, nil
+}
+
+// #endregion
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"byte-board/internal/model"
+	"fmt"
+)
+
+// #region Word filters
+
+// Get all word filter rules
+func (db *DB) GetWordFilters() ([]model.WordFilter, error) {
+	query := "SELECT " + wordFilterColumns + " FROM word_filters ORDER BY filter_id"
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query word filters: %w", err)
+	}
+	defer rows.Close()
+
+	filters := []model.WordFilter{}
+	for rows.Next() {
+		filter, err := scanWordFilter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan word filters: %w", err)
+		}
+
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+// Add a word filter rule
+func (db *DB) CreateWordFilter(filter *model.WordFilter) error {
+	query := `
+		INSERT INTO word_filters (pattern, is_regex, action, replacement, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING filter_id
+	`
+
+	err := db.QueryRow(query, filter.Pattern, filter.IsRegex, filter.Action, filter.Replacement, filter.CreatedAt).Scan(&filter.FilterId)
+	if err != nil {
+		return fmt.Errorf("failed to create word filter: %w", err)
+	}
+
+	return nil
+}
+
+// Delete a word filter rule
+func (db *DB) DeleteWordFilter(filterId int64) error {
+	result, err := db.Exec("DELETE FROM word_filters WHERE filter_id = $1", filterId)
+	if err != nil {
+		return fmt.Errorf("failed to delete word filter: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("word filter %w", ErrNotFound)
+	}
+
+	return nil
+}
+
+// #endregion
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Announcement length limits
+const (
+	maxAnnouncementTitle   = 200
+	maxAnnouncementMessage = 2000
+)
+
+// Handles site-wide announcements and their dismissals
+type AnnouncementService struct {
+	db *repository.DB
+}
+
+// Creates new announcement service
+func NewAnnouncementService(db *repository.DB) *AnnouncementService {
+	return &AnnouncementService{db: db}
+}
+
+// Gets the announcements currently showing to a user (0 for anonymous visitors)
+func (s *AnnouncementService) Active(userId int64) ([]model.Announcement, error) {
+	return s.db.GetActiveAnnouncements(userId, time.Now())
+}
+
+// Gets every announcement for the admin list
+func (s *AnnouncementService) List(limit, offset int) ([]model.Announcement, error) {
+	return s.db.ListAnnouncements(limit, offset)
+}
+
+// Validates and saves a new announcement posted by an admin, or by a service client when actorId
+// is 0 (it starts now unless scheduled)
+func (s *AnnouncementService) Create(actorId int64, announcement *model.Announcement) error {
+	now := time.Now()
+	if announcement.StartsAt.IsZero() {
+		announcement.StartsAt = now
+	}
+	if announcement.Level == "" {
+		announcement.Level = model.AnnouncementInfo
+	}
+	if err := validateAnnouncement(announcement); err != nil {
+		return err
+	}
+
+	if actorId != 0 {
+		announcement.CreatedBy = &actorId
+	}
+	announcement.CreatedAt = now
+	announcement.UpdatedAt = now
+	return s.db.CreateAnnouncement(announcement)
+}
+
+// Validates and saves changes to an announcement
+func (s *AnnouncementService) Update(announcement *model.Announcement) error {
+	if err := validateAnnouncement(announcement); err != nil {
+		return err
+	}
+
+	announcement.UpdatedAt = time.Now()
+	return s.db.UpdateAnnouncement(announcement)
+}
+
+// Takes an announcement down immediately (already expired ones keep their original expiry)
+func (s *AnnouncementService) Expire(announcementId int64) (*model.Announcement, error) {
+	announcement, err := s.db.GetAnnouncementById(announcementId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if announcement.ExpiresAt != nil && !announcement.ExpiresAt.After(now) {
+		return announcement, nil
+	}
+
+	announcement.ExpiresAt = &now
+	announcement.UpdatedAt = now
+	if err := s.db.UpdateAnnouncement(announcement); err != nil {
+		return nil, err
+	}
+
+	return announcement, nil
+}
+
+// Stops an announcement being returned to a user
+func (s *AnnouncementService) Dismiss(userId, announcementId int64) error {
+	if _, err := s.db.GetAnnouncementById(announcementId); err != nil {
+		return err
+	}
+
+	return s.db.DismissAnnouncement(userId, announcementId)
+}
+
+// Trims announcement text and checks lengths, level and schedule
+func validateAnnouncement(announcement *model.Announcement) error {
+	announcement.Title = strings.TrimSpace(announcement.Title)
+	announcement.Message = strings.TrimSpace(announcement.Message)
+
+	if announcement.Title == "" || len(announcement.Title) > maxAnnouncementTitle {
+		return fmt.Errorf("%w: title is required (up to %d characters)", ErrInvalidInput, maxAnnouncementTitle)
+	}
+	if announcement.Message == "" || len(announcement.Message) > maxAnnouncementMessage {
+		return fmt.Errorf("%w: message is required (up to %d characters)", ErrInvalidInput, maxAnnouncementMessage)
+	}
+
+	switch announcement.Level {
+	case model.AnnouncementInfo, model.AnnouncementWarning, model.AnnouncementCritical:
+	default:
+		return fmt.Errorf("%w: level must be info, warning or critical", ErrInvalidInput)
+	}
+
+	if announcement.ExpiresAt != nil && !announcement.ExpiresAt.After(announcement.StartsAt) {
+		return fmt.Errorf("%w: expires_at must be after starts_at", ErrInvalidInput)
+	}
+
+	return nil
+}
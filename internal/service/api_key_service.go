@@ -0,0 +1,99 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// API key limits
+const (
+	maxAPIKeys       = 10
+	maxAPIKeyName    = 100
+	apiKeyPrefix     = "bbk_"
+	apiKeyShownChars = 12
+	// Uses closer together than this don't update last_used_at again
+	apiKeyTouchInterval = time.Minute
+)
+
+// Manages the API keys users give their scripts and services, and authenticates requests made with them
+type APIKeyService struct {
+	db *repository.DB
+}
+
+// Creates new API key service
+func NewAPIKeyService(db *repository.DB) *APIKeyService {
+	return &APIKeyService{db: db}
+}
+
+// Gets a user's API keys, newest first
+func (s *APIKeyService) List(userId int64) ([]model.APIKey, error) {
+	return s.db.GetAPIKeys(userId)
+}
+
+// Generates an API key for a user, returning it with the key itself (which isn't stored and can't be shown again)
+func (s *APIKeyService) Create(userId int64, name string) (*model.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAPIKeyName {
+		return nil, "", fmt.Errorf("%w: name is required (up to %d characters)", ErrInvalidInput, maxAPIKeyName)
+	}
+
+	token, _, err := generateToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	secret := apiKeyPrefix + token
+
+	key := &model.APIKey{
+		UserId:    userId,
+		Name:      name,
+		Prefix:    secret[:apiKeyShownChars],
+		KeyHash:   hashToken(secret),
+		CreatedAt: time.Now(),
+	}
+	created, err := s.db.CreateAPIKey(key, maxAPIKeys)
+	if err != nil {
+		return nil, "", err
+	}
+	if !created {
+		return nil, "", fmt.Errorf("%w: you can have up to %d active API keys", ErrInvalidInput, maxAPIKeys)
+	}
+
+	log.Info().Int64("user_id", userId).Int64("key_id", key.KeyId).Msg("API key generated")
+	return key, secret, nil
+}
+
+// Revokes one of a user's API keys; requests sending it are rejected straight away
+func (s *APIKeyService) Revoke(userId, keyId int64) error {
+	return s.db.RevokeAPIKey(userId, keyId, time.Now())
+}
+
+// Gets the ID of the user an API key belongs to (checked on every request sending one).
+// Unknown and revoked keys get model.ErrInvalidToken.
+func (s *APIKeyService) Authenticate(secret string) (int64, error) {
+	key, err := s.db.GetAPIKeyByHash(hashToken(secret))
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, model.ErrInvalidToken
+	}
+	if err != nil {
+		return 0, err
+	}
+	if key.RevokedAt != nil {
+		return 0, model.ErrInvalidToken
+	}
+
+	// Usage tracking only; never fail the request over it
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.db.TouchAPIKey(key.KeyId, now); err != nil {
+			log.Error().Err(err).Int64("key_id", key.KeyId).Msg("Failed to record API key use")
+		}
+	}
+
+	return key.UserId, nil
+}
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"byte-board/internal/storage"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Longest original filename kept for an attachment
+const maxAttachmentFilename = 255
+
+// Blob garbage collection: how long a blob stays unreferenced before it's deleted (so a file that's
+// deleted and uploaded again straight away keeps its blob), and how many are deleted per batch
+const (
+	blobGracePeriod = time.Hour
+	blobGCBatchSize = 100
+)
+
+// How many unlinked attachments are deleted per batch
+const unlinkedCleanupBatchSize = 100
+
+// Links to attachments in post and comment content (relative, or absolute on any host)
+var attachmentLinkPattern = regexp.MustCompile(`/api/attachments/(\d+)\b`)
+
+// File types that can be uploaded (detected from the contents, not the client's claim)
+var allowedAttachmentTypes = map[string]bool{
+	"image/png":                 true,
+	"image/jpeg":                true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"application/pdf":           true,
+	"text/plain; charset=utf-8": true,
+}
+
+// Size limits for attachments (0 disables a limit)
+type AttachmentLimits struct {
+	// Largest single file
+	MaxFileBytes int64
+	// Most storage a user's attachments may take up in total
+	QuotaBytes int64
+}
+
+// Handles file uploads and per-user storage quotas. Contents are stored once per distinct file,
+// keyed by their SHA-256, and shared by every attachment with the same bytes.
+//
+// Uploads nothing links to are deleted once they're older than unlinkedTTL (0 keeps them). Posts and comments
+// link an attachment by including its URL; once linked it's kept even if the link is edited out later.
+type AttachmentService struct {
+	db          *repository.DB
+	store       storage.Store
+	limits      AttachmentLimits
+	unlinkedTTL time.Duration
+}
+
+// Creates new attachment service
+func NewAttachmentService(db *repository.DB, store storage.Store, limits AttachmentLimits, unlinkedTTL time.Duration) *AttachmentService {
+	return &AttachmentService{
+		db:          db,
+		store:       store,
+		limits:      limits,
+		unlinkedTTL: unlinkedTTL,
+	}
+}
+
+// Stores an upload for the user, enforcing the file size limit and their storage quota
+func (s *AttachmentService) Upload(userId int64, filename string, contents io.Reader) (*model.Attachment, error) {
+	filename = path.Base(strings.ReplaceAll(strings.TrimSpace(filename), `\`, "/"))
+	if filename == "" || filename == "." || filename == "/" || len(filename) > maxAttachmentFilename {
+		return nil, fmt.Errorf("%w: filename is required (up to %d characters)", ErrInvalidInput, maxAttachmentFilename)
+	}
+
+	// Sniff the type from the first bytes, then put them back in front of the rest
+	head := make([]byte, 512)
+	n, err := io.ReadFull(contents, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidInput)
+	}
+	head = head[:n]
+	contentType := http.DetectContentType(head)
+	if !allowedAttachmentTypes[contentType] {
+		return nil, fmt.Errorf("%w: file type %s is not allowed (images, PDFs and plain text only)", ErrInvalidInput, contentType)
+	}
+	body := io.MultiReader(bytes.NewReader(head), contents)
+	if s.limits.MaxFileBytes > 0 {
+		// One extra byte tells an oversized file apart from one exactly at the limit
+		body = io.LimitReader(body, s.limits.MaxFileBytes+1)
+	}
+
+	// The hash isn't known until the whole file is read, so it lands under a temporary key first
+	pendingKey, err := newPendingKey()
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	size, err := s.store.Put(pendingKey, io.TeeReader(body, hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+	if s.limits.MaxFileBytes > 0 && size > s.limits.MaxFileBytes {
+		s.discard(pendingKey)
+		return nil, fmt.Errorf("%w: files can be up to %d bytes", ErrFileTooLarge, s.limits.MaxFileBytes)
+	}
+
+	attachment := &model.Attachment{
+		UserId:      userId,
+		Filename:    filename,
+		ContentType: contentType,
+		SizeBytes:   size,
+		StorageKey:  hex.EncodeToString(hash.Sum(nil)),
+		CreatedAt:   time.Now(),
+	}
+	fits, err := s.db.CreateAttachment(attachment, s.limits.QuotaBytes)
+	if err != nil {
+		s.discard(pendingKey)
+		return nil, err
+	}
+	if !fits {
+		s.discard(pendingKey)
+		return nil, fmt.Errorf("%w: this upload needs %d bytes and your quota is %d bytes", ErrQuotaExceeded, size, s.limits.QuotaBytes)
+	}
+
+	// Moved into place even when the blob already existed: the bytes are identical, and it guarantees
+	// the contents are there however this upload raced with others of the same file or the garbage collector
+	if err := s.store.Move(pendingKey, attachment.StorageKey); err != nil {
+		s.discard(pendingKey)
+		if deleteErr := s.db.DeleteAttachment(attachment.AttachmentId); deleteErr != nil {
+			log.Error().Err(deleteErr).Int64("attachment_id", attachment.AttachmentId).Msg("Failed to remove attachment without contents")
+		}
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	log.Info().
+		Int64("attachment_id", attachment.AttachmentId).
+		Int64("user_id", userId).
+		Int64("size", size).
+		Str("blob", attachment.StorageKey).
+		Msg("Attachment uploaded")
+	s.setExpiry(attachment)
+	return attachment, nil
+}
+
+// Looks up an attachment and opens its contents (the caller closes the reader)
+func (s *AttachmentService) Open(attachmentId int64) (*model.Attachment, io.ReadCloser, error) {
+	attachment, err := s.db.GetAttachment(attachmentId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contents, err := s.store.Open(attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Error().Int64("attachment_id", attachmentId).Msg("Attachment contents are missing from the store")
+		return nil, nil, fmt.Errorf("attachment %w", repository.ErrNotFound)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, contents, nil
+}
+
+// Get the user's attachments (newest first)
+func (s *AttachmentService) List(userId int64, limit, offset int) ([]model.Attachment, error) {
+	attachments, err := s.db.GetAttachmentsByUser(userId, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range attachments {
+		s.setExpiry(&attachments[i])
+	}
+	return attachments, nil
+}
+
+// Deletes an attachment owned by the user (admins can delete any)
+func (s *AttachmentService) Delete(user *model.User, attachmentId int64) error {
+	attachment, err := s.db.GetAttachment(attachmentId)
+	if err != nil {
+		return err
+	}
+	if attachment.UserId != user.ID && user.Role != "admin" {
+		// Don't reveal other users' attachments exist
+		return fmt.Errorf("attachment %w", repository.ErrNotFound)
+	}
+
+	// The contents may be shared, so they're left for the garbage collector once nothing references them
+	return s.db.DeleteAttachment(attachmentId)
+}
+
+// Get how much storage the user is using and their quota
+func (s *AttachmentService) Usage(userId int64) (*model.StorageUsage, error) {
+	usage, err := s.db.GetStorageUsage(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.QuotaBytes = s.limits.QuotaBytes
+	return usage, nil
+}
+
+// Scheduled job that deletes blobs no attachment has referenced for the grace period
+func (s *AttachmentService) CollectGarbage(ctx context.Context) error {
+	cutoff := time.Now().Add(-blobGracePeriod)
+
+	deleted := 0
+	for {
+		hashes, err := s.db.GetUnreferencedBlobs(cutoff, blobGCBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, hash := range hashes {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			removed, err := s.db.DeleteUnreferencedBlob(hash, cutoff, func() error {
+				if err := s.store.Delete(hash); err != nil && !errors.Is(err, storage.ErrNotFound) {
+					return fmt.Errorf("failed to delete blob contents: %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if removed {
+				deleted++
+			}
+		}
+
+		if len(hashes) < blobGCBatchSize {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		log.Info().Int("blobs", deleted).Msg("Unreferenced attachment blobs deleted")
+	}
+	return nil
+}
+
+// Scheduled job that deletes attachments nothing linked within unlinkedTTL of their upload, giving their
+// size back to the owner's quota. The contents go once the blob garbage collector finds them unreferenced.
+func (s *AttachmentService) CleanupUnlinked(ctx context.Context) error {
+	if s.unlinkedTTL <= 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-s.unlinkedTTL)
+
+	deleted := 0
+	var reclaimed int64
+	for {
+		attachments, err := s.db.GetUnlinkedAttachments(cutoff, unlinkedCleanupBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, attachment := range attachments {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			// Skipped when a post linked it since it was listed
+			removed, err := s.db.DeleteUnlinkedAttachment(attachment.AttachmentId, cutoff)
+			if err != nil {
+				return err
+			}
+			if removed {
+				deleted++
+				reclaimed += attachment.SizeBytes
+				unlinkedAttachmentsDeletedTotal.Inc()
+				unlinkedAttachmentBytesReclaimedTotal.Add(uint64(attachment.SizeBytes))
+			}
+		}
+
+		if len(attachments) < unlinkedCleanupBatchSize {
+			break
+		}
+	}
+
+	if deleted > 0 {
+		log.Info().Int("attachments", deleted).Int64("bytes", reclaimed).Msg("Unlinked attachments deleted")
+	}
+	return nil
+}
+
+// Fills in when an attachment nothing has linked yet will be deleted
+func (s *AttachmentService) setExpiry(attachment *model.Attachment) {
+	if attachment.LinkedAt != nil || s.unlinkedTTL <= 0 {
+		return
+	}
+
+	expiresAt := attachment.CreatedAt.Add(s.unlinkedTTL)
+	attachment.ExpiresAt = &expiresAt
+}
+
+// Marks the attachments the content links to as linked, so the unlinked cleanup keeps them. Failures are only
+// logged: the content is already saved, and a missed link at worst lets an upload expire.
+func linkAttachments(db *repository.DB, content ...string) {
+	var attachmentIds []int64
+	for _, text := range content {
+		for _, match := range attachmentLinkPattern.FindAllStringSubmatch(text, -1) {
+			if id, err := strconv.ParseInt(match[1], 10, 64); err == nil && id > 0 {
+				attachmentIds = append(attachmentIds, id)
+			}
+		}
+	}
+	if len(attachmentIds) == 0 {
+		return
+	}
+
+	if err := db.LinkAttachments(attachmentIds, time.Now()); err != nil {
+		log.Error().Err(err).Msg("Failed to link attachments")
+	}
+}
+
+// Removes stored contents that no longer have (or never got) a database record
+func (s *AttachmentService) discard(key string) {
+	if err := s.store.Delete(key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Error().Err(err).Str("key", key).Msg("Failed to delete stored attachment")
+	}
+}
+
+// Generates a random temporary key to store an upload under until its content hash is known
+func newPendingKey() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate storage key: %w", err)
+	}
+
+	return "pending-" + hex.EncodeToString(buf), nil
+}
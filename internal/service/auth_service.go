@@ -2,53 +2,288 @@ package service
 
 import (
 	"byte-board/internal/auth"
+	"byte-board/internal/mail"
 	"byte-board/internal/model"
 	"byte-board/internal/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	netmail "net/mail"
+	"net/url"
+	"strings"
 	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
+// How long an email change confirmation link stays valid
+const EmailChangeTokenTTL = 24 * time.Hour
+
+// How long a password reset token stays valid
+const PasswordResetTokenTTL = time.Hour
+
+// How many reset emails an account can be sent per PasswordResetTokenTTL, so the endpoint can't flood an inbox
+const maxPasswordResetsPerTTL = 3
+
+// Who gets the admin role at signup while the site has no admin yet
+type AdminBootstrap struct {
+	// The very first account to register
+	FirstUser bool
+	// An account registering with this username or email address
+	Username string
+	Email    string
+}
+
+// Reports whether a signup is named by the bootstrap config
+func (b AdminBootstrap) matches(username, email string) bool {
+	return (b.Username != "" && strings.EqualFold(b.Username, username)) ||
+		(b.Email != "" && email != "" && strings.EqualFold(b.Email, email))
+}
+
 // Handles authentication business logic
 type AuthService struct {
 	db            *repository.DB
 	tokenProvider *auth.TokenProvider
+	hasher        *auth.PasswordHasher
+	mailer        mail.Mailer
+	publicURL     string
+	resetURL      string
+	signupGuard   *SignupGuard
+	loginThrottle *LoginThrottle
+	bootstrap     AdminBootstrap
 }
 
-// Creates new authentication service
-func NewAuthService(db *repository.DB, tokenProvider *auth.TokenProvider) *AuthService {
+// Creates new authentication service. resetURL is the page password reset emails link to (with ?token=);
+// when empty the email carries the bare token instead.
+func NewAuthService(db *repository.DB, tokenProvider *auth.TokenProvider, hasher *auth.PasswordHasher, mailer mail.Mailer, publicURL, resetURL string, signupGuard *SignupGuard,
+	loginThrottle *LoginThrottle, bootstrap AdminBootstrap) *AuthService {
 	return &AuthService{
 		db:            db,
 		tokenProvider: tokenProvider,
+		hasher:        hasher,
+		mailer:        mailer,
+		publicURL:     strings.TrimRight(publicURL, "/"),
+		resetURL:      resetURL,
+		signupGuard:   signupGuard,
+		loginThrottle: loginThrottle,
+		bootstrap:     bootstrap,
 	}
 }
 
-// Login - Authenticate user and return JWT token
-func (s *AuthService) Login(username, password string) (string, error) {
+// Login - Authenticate user and return a JWT with a refresh token, starting a session for the device
+// (ip and userAgent are shown in its session list). Repeated failures for the username from the same IP
+// get a *LoginThrottledError until their delay has passed. Banned and suspended accounts get ErrAccountBanned.
+func (s *AuthService) Login(username, password, ip, userAgent string) (*model.TokenPair, error) {
+	username = auth.NormalizeUsername(username)
+
+	// Checked before the password so throttled guesses learn nothing
+	if err := s.loginThrottle.Attempt(username, ip, time.Now()); err != nil {
+		loginsTotal.Inc("throttled")
+		return nil, err
+	}
+
 	// Get user from database
 	user, err := s.db.GetUserByUsername(username)
 	if err != nil {
-		return "", fmt.Errorf("invalid credentials")
+		loginsTotal.Inc("failure")
+		return nil, ErrInvalidCredentials
 	}
 
 	// Verify password
 	if !auth.CheckPassword(password, user.HashedPassword) {
-		return "", fmt.Errorf("invalid credentials")
+		loginsTotal.Inc("failure")
+		return nil, ErrInvalidCredentials
+	}
+
+	// Checked after the password so it tells nobody else whether the account is banned
+	if user.IsBanned(time.Now()) {
+		s.loginThrottle.Succeeded(username, ip)
+		loginsTotal.Inc("banned")
+		return nil, ErrAccountBanned
 	}
+	s.upgradePasswordHash(user, password)
 
-	// Generate JWT token
-	token, err := s.tokenProvider.CreateToken(user.Username, user.Role)
+	// Generate JWT and refresh tokens
+	tokens, err := s.issueTokens(user, newSession(user.TokenVersion, ip, userAgent))
 	if err != nil {
-		return "", fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
+	}
+
+	s.loginThrottle.Succeeded(username, ip)
+	loginsTotal.Inc("success")
+	return tokens, nil
+}
+
+// Re-hashes a password that was just verified when its hash was made with an older algorithm or weaker
+// settings (e.g. bcrypt after switching to Argon2id). Failures are logged and the old hash kept, since it
+// still works and the next login tries again.
+func (s *AuthService) upgradePasswordHash(user *model.User, password string) {
+	if !s.hasher.NeedsRehash(user.HashedPassword) {
+		return
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", user.ID).Msg("Failed to rehash password")
+		return
+	}
+	if err := s.db.ReplacePasswordHash(user.ID, user.HashedPassword, hashedPassword); err != nil {
+		log.Error().Err(err).Int64("user_id", user.ID).Msg("Failed to rehash password")
+		return
+	}
+
+	user.HashedPassword = hashedPassword
+	log.Info().Int64("user_id", user.ID).Msg("Password hash upgraded")
+}
+
+// Exchanges a refresh token for a new JWT and refresh token. The old refresh token is used up; presenting
+// it again revokes every token descended from the same login, since it means the token was copied.
+// Tokens issued before a password change or ban, or to a revoked session, are rejected.
+func (s *AuthService) Refresh(refreshToken string) (*model.TokenPair, *model.User, error) {
+	now := time.Now()
+	tokenHash := auth.HashRefreshToken(refreshToken)
+
+	used, err := s.db.UseRefreshToken(tokenHash, now)
+	if errors.Is(err, repository.ErrNotFound) {
+		s.revokeIfReused(tokenHash, now)
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Re-read the user so the new token carries their current username and role
+	user, err := s.db.GetUserByID(used.UserId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.IsBanned(now) {
+		return nil, nil, ErrAccountBanned
+	}
+	if user.TokenVersion != used.TokenVersion {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.db.GetSessionByFamily(used.FamilyId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.RevokedAt != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	tokens, err := s.issueTokens(user, session)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tokens, user, nil
+}
+
+// Revokes the family (and session) of a refresh token that was presented after being used
+func (s *AuthService) revokeIfReused(tokenHash string, now time.Time) {
+	token, err := s.db.GetRefreshToken(tokenHash)
+	if err != nil || token.UsedAt == nil || token.RevokedAt != nil {
+		return
+	}
+
+	revoked, err := s.db.RevokeRefreshTokenFamily(token.FamilyId, now)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", token.UserId).Msg("Failed to revoke reused refresh token family")
+		return
+	}
+	log.Warn().Int64("user_id", token.UserId).Int64("revoked", revoked).Msg("Used refresh token presented again; family revoked")
+
+	// Also end the session, so JWTs already issued from the family stop working
+	session, err := s.db.GetSessionByFamily(token.FamilyId)
+	if err != nil {
+		return
+	}
+	if err := s.db.RevokeSession(session.UserId, session.SessionId, now); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Error().Err(err).Int64("session_id", session.SessionId).Msg("Failed to revoke session of reused refresh token")
+	}
+}
+
+// Creates a JWT and a refresh token for a user's session. A session without an ID is a fresh login: it's
+// recorded with a new refresh token family. An existing one is extended to the new refresh token's expiry.
+func (s *AuthService) issueTokens(user *model.User, session *model.Session) (*model.TokenPair, error) {
+	refreshToken, refreshHash, expiresAt, err := s.tokenProvider.CreateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if session.SessionId == 0 {
+		familyBytes := make([]byte, 16)
+		if _, err := rand.Read(familyBytes); err != nil {
+			return nil, fmt.Errorf("failed to generate refresh token family: %w", err)
+		}
+		session.UserId = user.ID
+		session.FamilyId = hex.EncodeToString(familyBytes)
+		session.CreatedAt = now
+		session.ExpiresAt = expiresAt
+		if err := s.db.CreateSession(session); err != nil {
+			return nil, err
+		}
+	} else if err := s.db.ExtendSession(session.SessionId, expiresAt); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.tokenProvider.CreateToken(user.ID, user.Username, user.Role, session.TokenVersion, session.SessionId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	err = s.db.CreateRefreshToken(&model.RefreshToken{
+		TokenHash:    refreshHash,
+		UserId:       user.ID,
+		FamilyId:     session.FamilyId,
+		TokenVersion: session.TokenVersion,
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return token, nil
+	return &model.TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: expiresAt,
+	}, nil
 }
 
-// Creates new account
-func (s *AuthService) Register(username, password, firstName, lastName string) (*model.User, *model.Profile, error) {
+// Creates new account (email is optional; ip is the client address used for signup throttling)
+func (s *AuthService) Register(ctx context.Context, username, password, firstName, lastName, email, ip, captchaResponse string) (*model.User, *model.Profile, error) {
+	// Normalize and validate username
+	username = auth.NormalizeUsername(username)
+	if err := auth.ValidateUsername(username); err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+
 	// Validate password strength
 	if err := auth.ValidatePasswordStrength(password); err != nil {
-		return nil, nil, fmt.Errorf("invalid password: %w", err)
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+
+	// Validate the email address, if one was given
+	email = strings.TrimSpace(email)
+	if email != "" {
+		address, err := netmail.ParseAddress(email)
+		if err != nil || address.Address != email {
+			return nil, nil, fmt.Errorf("%w: invalid email address", ErrInvalidInput)
+		}
+	}
+
+	// Slow down mass account creation
+	if err := s.signupGuard.Check(ctx, ip, email, captchaResponse); err != nil {
+		return nil, nil, err
 	}
 
 	// Check if username already exists
@@ -57,11 +292,11 @@ func (s *AuthService) Register(username, password, firstName, lastName string) (
 		return nil, nil, fmt.Errorf("failed to check username availability: %w", err)
 	}
 	if exists {
-		return nil, nil, fmt.Errorf("username already exists")
+		return nil, nil, ErrUsernameTaken
 	}
 
 	// Hash password
-	hashedPassword, err := auth.HashPassword(password)
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -85,7 +320,7 @@ func (s *AuthService) Register(username, password, firstName, lastName string) (
 		UserId:         user.ID,
 		FirstName:      firstName,
 		LastName:       lastName,
-		Email:          "",
+		Email:          email,
 		GithubLink:     "",
 		City:           "",
 		State:          "",
@@ -98,48 +333,222 @@ func (s *AuthService) Register(username, password, firstName, lastName string) (
 		return nil, nil, fmt.Errorf("failed to create profile: %w", err)
 	}
 
+	s.signupGuard.Record(user.ID, ip, email)
+	registrationsTotal.Inc()
+
+	// Give the initial administrator the admin role without a manual SQL step
+	if s.bootstrap.FirstUser || s.bootstrap.matches(username, email) {
+		promoted, err := s.db.BootstrapAdmin(user.ID, !s.bootstrap.matches(username, email))
+		if err != nil {
+			return nil, nil, err
+		}
+		if promoted {
+			user.Role = "admin"
+			log.Warn().Str("username", user.Username).Int64("user_id", user.ID).Msg("Bootstrapped initial admin account")
+		}
+	}
+
 	// user.ID is now populated by CreateUser bc of RETURNING clause
 	return user, createdProfile, nil
 }
 
-// Change a user's password
-func (s *AuthService) ChangePassword(userId int, oldPass, newPass string) error {
+// Change a user's password. Every token issued before the change stops working (refresh tokens and
+// sessions included) and the user's API keys are revoked, so a fresh pair is returned in a new session
+// for the device making the change. With keepOtherSessions, existing tokens and API keys stay valid
+// instead and the fresh pair continues sessionId
+// (the session making the change; 0 when it has none, e.g. an API key, which starts a new one).
+func (s *AuthService) ChangePassword(userId, sessionId int64, oldPass, newPass string, keepOtherSessions bool, ip, userAgent string) (*model.TokenPair, error) {
 	// Get user
 	user, err := s.db.GetUserByID(userId)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	// Verify old password
 	if !auth.CheckPassword(oldPass, user.HashedPassword) {
-		return fmt.Errorf("invalid current password")
+		return nil, fmt.Errorf("invalid current password: %w", ErrInvalidCredentials)
 	}
 
 	// Validate new password
 	if err := auth.ValidatePasswordStrength(newPass); err != nil {
-		return err
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
 	}
 
 	// Hash new password
-	hashedPass, err := auth.HashPassword(newPass)
+	hashedPass, err := s.hasher.Hash(newPass)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if keepOtherSessions {
+		if err := s.db.ReplacePasswordHash(user.ID, user.HashedPassword, hashedPass); err != nil {
+			return nil, fmt.Errorf("failed to update password: %w", err)
+		}
+
+		session := newSession(user.TokenVersion, ip, userAgent)
+		if sessionId != 0 {
+			if session, err = s.db.GetSession(sessionId); err != nil {
+				return nil, fmt.Errorf("failed to get session: %w", err)
+			}
+		}
+		return s.issueTokens(user, session)
+	}
+
+	// Update the password (and invalidate existing tokens)
+	version, err := s.db.UpdatePassword(user.ID, hashedPass)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return s.issueTokens(user, newSession(version, ip, userAgent))
+}
+
+// Starts an email change by sending a confirmation link to the new address
+func (s *AuthService) RequestEmailChange(userId int64, newEmail string) error {
+	// Validate the new address
+	address, err := netmail.ParseAddress(strings.TrimSpace(newEmail))
+	if err != nil || address.Address != strings.TrimSpace(newEmail) {
+		return fmt.Errorf("%w: invalid email address", ErrInvalidInput)
+	}
+
+	// Generate the confirmation token (only its hash is stored)
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+
+	now := time.Now()
+	request := &model.EmailChangeRequest{
+		TokenHash: tokenHash,
+		UserId:    userId,
+		NewEmail:  address.Address,
+		ExpiresAt: now.Add(EmailChangeTokenTTL),
+		CreatedAt: now,
+	}
+	if err := s.db.CreateEmailChangeRequest(request); err != nil {
+		return fmt.Errorf("failed to save email change request: %w", err)
+	}
+
+	// Send the confirmation link to the new address
+	link := s.publicURL + "/api/profiles/email/confirm?token=" + url.QueryEscape(token)
+	body := fmt.Sprintf("Someone requested to change the email address on your Byte Board account to this address.\n\n"+
+		"Confirm the change by opening this link within %d hours:\n%s\n\n"+
+		"If you didn't request this, you can ignore this email.", int(EmailChangeTokenTTL.Hours()), link)
+	if err := s.mailer.Send(address.Address, "Confirm your new Byte Board email address", body); err != nil {
+		return fmt.Errorf("failed to send confirmation email: %w", err)
+	}
+
+	return nil
+}
+
+// Commits a pending email change once its confirmation token is presented
+func (s *AuthService) ConfirmEmailChange(token string) (*model.EmailChangeRequest, error) {
+	request, err := s.db.ConfirmEmailChange(hashToken(token))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to confirm email change: %w", err)
+	}
+
+	return request, nil
+}
+
+// Emails a password reset token to every account whose profile uses the address. Nothing tells the caller
+// whether any account matched (so addresses can't be probed); the emails go out in the background.
+func (s *AuthService) RequestPasswordReset(email string) error {
+	address, err := netmail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || address.Address != strings.TrimSpace(email) {
+		return fmt.Errorf("%w: invalid email address", ErrInvalidInput)
+	}
+
+	recipients, err := s.db.GetPasswordResetRecipients(address.Address)
 	if err != nil {
-		return fmt.Errorf("failed to hash password: %w", err)
+		return fmt.Errorf("failed to look up accounts: %w", err)
 	}
 
-	// Update user
-	user.HashedPassword = hashedPass
-	if err := s.db.UpdateUser(user); err != nil {
-		return fmt.Errorf("failed to update password: %w", err)
+	for _, recipient := range recipients {
+		// Generate the reset token (only its hash is stored)
+		token, tokenHash, err := generateToken()
+		if err != nil {
+			return fmt.Errorf("failed to generate reset token: %w", err)
+		}
+
+		now := time.Now()
+		reset := &model.PasswordReset{
+			TokenHash: tokenHash,
+			UserId:    recipient.UserId,
+			ExpiresAt: now.Add(PasswordResetTokenTTL),
+			CreatedAt: now,
+		}
+		created, err := s.db.CreatePasswordReset(reset, maxPasswordResetsPerTTL, PasswordResetTokenTTL)
+		if err != nil {
+			return fmt.Errorf("failed to save password reset: %w", err)
+		}
+		if !created {
+			log.Warn().Int64("user_id", recipient.UserId).Msg("Password reset not sent, too many recent requests")
+			continue
+		}
+
+		go s.sendPasswordReset(recipient, token)
 	}
 
 	return nil
 }
 
+// Sends a password reset email (failures are only logged, the requester has already been answered)
+func (s *AuthService) sendPasswordReset(recipient model.PasswordResetRecipient, token string) {
+	body := fmt.Sprintf("Someone asked to reset the password of the Byte Board account %s.\n\n%s\n\n"+
+		"If you didn't ask for this, you can ignore this email; your password hasn't changed.",
+		recipient.Username, s.choosePasswordInstructions(token, fmt.Sprintf("%d minutes", int(PasswordResetTokenTTL.Minutes()))))
+	if err := s.mailer.Send(recipient.Email, "Reset your Byte Board password", body); err != nil {
+		log.Error().Err(err).Int64("user_id", recipient.UserId).Msg("Failed to send password reset email")
+	}
+}
+
+// Tells the reader how to choose a password with a reset token: a link to PASSWORD_RESET_URL, or the bare
+// token when none is configured
+func (s *AuthService) choosePasswordInstructions(token, within string) string {
+	if s.resetURL == "" {
+		return fmt.Sprintf("Use this code within %s to choose a new password:\n%s", within, token)
+	}
+	return fmt.Sprintf("Open this link within %s to choose a new password:\n%s", within, s.resetURL+"?token="+url.QueryEscape(token))
+}
+
+// Sets a new password with an emailed reset token. The token works once; every token issued before the reset
+// stops working, and the user's other pending reset tokens are dropped. Returns the user whose password changed.
+func (s *AuthService) ResetPassword(token, newPass string) (int64, error) {
+	// Validate the new password first so a weak one doesn't use up the token
+	if err := auth.ValidatePasswordStrength(newPass); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+
+	hashedPass, err := s.hasher.Hash(newPass)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	userId, err := s.db.ResetPassword(hashToken(token), hashedPass, time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, ErrInvalidToken
+		}
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	return userId, nil
+}
+
 // Checks if JWT token is valid
 func (s *AuthService) ValidateToken(tokenString string) error {
 	return s.tokenProvider.ValidateToken(tokenString)
 }
 
+// Gets the public keys tokens are signed with, for other services to verify them
+func (s *AuthService) JWKS() auth.JWKSet {
+	return s.tokenProvider.JWKS()
+}
+
 // Extracts user information from a JWT token
 func (s *AuthService) GetUserFromToken(tokenString string) (*model.User, error) {
 	// Parse token
@@ -148,11 +557,28 @@ func (s *AuthService) GetUserFromToken(tokenString string) (*model.User, error)
 		return nil, err
 	}
 
-	// Get user from database
-	user, err := s.db.GetUserByUsername(claims.Username)
+	// Get user from database by the ID in the claims
+	user, err := s.db.GetUserByID(claims.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, fmt.Errorf("failed to get user from token: %w", err)
 	}
 
 	return user, nil
 }
+
+// Generates a random URL-safe token and the hash that gets stored for it
+func generateToken() (string, string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", "", err
+	}
+
+	token := hex.EncodeToString(tokenBytes)
+	return token, hashToken(token), nil
+}
+
+// Hashes a token for storage so a database leak doesn't expose usable tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
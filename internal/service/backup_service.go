@@ -0,0 +1,200 @@
+package service
+
+import (
+	"byte-board/internal/jobs"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"byte-board/internal/storage"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Kind of the queued backup job (scheduled and admin-triggered backups share it, so they never overlap)
+const JobBackup = "backup"
+
+// Longest pg_dump error output kept on a failed backup
+const maxBackupErrorLength = 1000
+
+// How to run pg_dump against the application database
+type BackupConfig struct {
+	PgDumpPath string
+	Host       string
+	Port       string
+	Database   string
+	User       string
+	Password   string
+	SSLMode    string
+	// How many successful backups to keep (0 keeps them all)
+	Retention int
+}
+
+// Takes pg_dump backups into backup storage and keeps track of them
+type BackupService struct {
+	db     *repository.DB
+	queue  *jobs.Queue
+	store  storage.Store
+	config BackupConfig
+}
+
+// Creates new backup service
+func NewBackupService(db *repository.DB, queue *jobs.Queue, store storage.Store, config BackupConfig) *BackupService {
+	return &BackupService{
+		db:     db,
+		queue:  queue,
+		store:  store,
+		config: config,
+	}
+}
+
+// Queues a backup (triggeredBy is nil for scheduled backups). If one is already queued or running, its status
+// is returned instead.
+func (s *BackupService) Start(triggeredBy *int64) (jobs.Status, error) {
+	return s.queue.Enqueue(JobBackup, func(ctx context.Context, progress jobs.ProgressFunc) error {
+		return s.run(ctx, triggeredBy, progress)
+	})
+}
+
+// Scheduled job entry point
+func (s *BackupService) ScheduledJob(ctx context.Context) error {
+	_, err := s.Start(nil)
+	return err
+}
+
+// Gets recent backups, newest first
+func (s *BackupService) List(limit, offset int) ([]model.Backup, error) {
+	return s.db.ListBackups(limit, offset)
+}
+
+// Marks backups a previous process never finished as failed (call once at startup)
+func (s *BackupService) FailInterrupted() error {
+	count, err := s.db.FailInterruptedBackups(time.Now())
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Warn().Int64("count", count).Msg("Marked interrupted backups as failed")
+	}
+	return nil
+}
+
+// Dumps the database into storage, records the outcome, then prunes old backups
+func (s *BackupService) run(ctx context.Context, triggeredBy *int64, progress jobs.ProgressFunc) error {
+	progress(0, 2, "dumping database")
+
+	backup := &model.Backup{
+		StorageKey:  "byteboard-" + time.Now().UTC().Format("20060102T150405Z") + ".dump",
+		Status:      model.BackupRunning,
+		TriggeredBy: triggeredBy,
+		StartedAt:   time.Now(),
+	}
+	if err := s.db.CreateBackup(backup); err != nil {
+		return err
+	}
+
+	size, dumpErr := s.dump(ctx, backup.StorageKey)
+	finishedAt := time.Now()
+	backup.FinishedAt = &finishedAt
+	backup.SizeBytes = size
+	backup.Status = model.BackupSucceeded
+	if dumpErr != nil {
+		backup.Status = model.BackupFailed
+		backup.Error = dumpErr.Error()
+		if len(backup.Error) > maxBackupErrorLength {
+			backup.Error = backup.Error[:maxBackupErrorLength]
+		}
+	}
+	if err := s.db.UpdateBackup(backup); err != nil {
+		return err
+	}
+	if dumpErr != nil {
+		return dumpErr
+	}
+
+	log.Info().Int64("backup_id", backup.BackupId).Int64("size_bytes", size).Dur("duration", finishedAt.Sub(backup.StartedAt)).Msg("Database backup finished")
+
+	progress(1, 2, "pruning old backups")
+	if err := s.prune(); err != nil {
+		return err
+	}
+
+	progress(2, 2, "done")
+	return nil
+}
+
+// Streams a custom-format pg_dump archive into storage under key
+func (s *BackupService) dump(ctx context.Context, key string) (int64, error) {
+	cmd := exec.CommandContext(ctx, s.config.PgDumpPath,
+		"--format=custom", "--no-owner", "--no-privileges",
+		"--host", s.config.Host,
+		"--port", s.config.Port,
+		"--username", s.config.User,
+		"--dbname", s.config.Database,
+	)
+	// Pass the password through the environment so it never shows up in the process list
+	cmd.Env = append(os.Environ(), "PGPASSWORD="+s.config.Password, "PGSSLMODE="+s.config.SSLMode)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, fmt.Errorf("failed to set up pg_dump: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start pg_dump: %w", err)
+	}
+
+	size, putErr := s.store.Put(key, stdout)
+	if putErr != nil {
+		// Stop pg_dump, which would otherwise block writing to a pipe nobody reads
+		cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+
+	if putErr != nil {
+		return 0, fmt.Errorf("failed to store backup: %w", putErr)
+	}
+	if waitErr != nil {
+		// Don't keep a truncated archive around
+		if err := s.store.Delete(key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Error().Err(err).Str("key", key).Msg("Failed to delete incomplete backup")
+		}
+		return 0, fmt.Errorf("pg_dump failed: %w: %s", waitErr, strings.TrimSpace(stderr.String()))
+	}
+
+	return size, nil
+}
+
+// Deletes the archives of successful backups beyond the retention count
+func (s *BackupService) prune() error {
+	if s.config.Retention <= 0 {
+		return nil
+	}
+
+	old, err := s.db.GetBackupsBeyond(s.config.Retention)
+	if err != nil {
+		return err
+	}
+
+	for i := range old {
+		backup := &old[i]
+		if err := s.store.Delete(backup.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("failed to delete backup %d: %w", backup.BackupId, err)
+		}
+
+		backup.Status = model.BackupPruned
+		if err := s.db.UpdateBackup(backup); err != nil {
+			return err
+		}
+		log.Info().Int64("backup_id", backup.BackupId).Msg("Old backup pruned")
+	}
+
+	return nil
+}
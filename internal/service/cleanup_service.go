@@ -0,0 +1,95 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Purges expired tokens and old throttling records so those tables don't grow forever
+type CleanupService struct {
+	db *repository.DB
+	// How long signup records are kept (they only need to outlive the throttling window)
+	signupRetention time.Duration
+
+	mu    sync.Mutex
+	stats model.CleanupStats
+}
+
+// Creates new cleanup service (signup records younger than the throttling window are always kept)
+func NewCleanupService(db *repository.DB, signupRetention time.Duration) *CleanupService {
+	if signupRetention < signupWindow {
+		signupRetention = signupWindow
+	}
+
+	return &CleanupService{
+		db:              db,
+		signupRetention: signupRetention,
+	}
+}
+
+// Deletes expired email change, refresh and password reset tokens, expired sessions and signup records past
+// retention (run by the scheduler)
+func (s *CleanupService) Run(ctx context.Context) error {
+	now := time.Now()
+
+	var counts model.CleanupCounts
+	var err error
+	counts.EmailChangeRequests, err = s.db.DeleteExpiredEmailChangeRequests(now)
+	if err == nil {
+		counts.Signups, err = s.db.DeleteSignupsBefore(now.Add(-s.signupRetention))
+	}
+	if err == nil {
+		counts.RefreshTokens, err = s.db.DeleteExpiredRefreshTokens(now)
+	}
+	if err == nil {
+		counts.PasswordResets, err = s.db.DeleteExpiredPasswordResets(now)
+	}
+	if err == nil {
+		counts.Sessions, err = s.db.DeleteExpiredSessions(now)
+	}
+
+	s.record(now, counts, err)
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Int64("email_change_requests", counts.EmailChangeRequests).
+		Int64("signups", counts.Signups).
+		Int64("refresh_tokens", counts.RefreshTokens).
+		Int64("password_resets", counts.PasswordResets).
+		Int64("sessions", counts.Sessions).
+		Msg("Expired rows purged")
+	return nil
+}
+
+// Adds a run to the stats
+func (s *CleanupService) record(at time.Time, counts model.CleanupCounts, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stats.Runs++
+	if err != nil {
+		s.stats.FailedRuns++
+	}
+	s.stats.LastRunAt = &at
+	s.stats.LastRun = counts
+	s.stats.Total.EmailChangeRequests += counts.EmailChangeRequests
+	s.stats.Total.Signups += counts.Signups
+	s.stats.Total.RefreshTokens += counts.RefreshTokens
+	s.stats.Total.PasswordResets += counts.PasswordResets
+	s.stats.Total.Sessions += counts.Sessions
+}
+
+// Get what the cleanup job has removed so far
+func (s *CleanupService) Stats() model.CleanupStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stats
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Handles comment business logic
+type CommentService struct {
+	db         *repository.DB
+	wordFilter *WordFilterService
+	notifier   *NotificationService
+	quotas     *ContentQuotas
+}
+
+// Creates new comment service
+func NewCommentService(db *repository.DB, wordFilter *WordFilterService, notifier *NotificationService, quotas *ContentQuotas) *CommentService {
+	return &CommentService{
+		db:         db,
+		wordFilter: wordFilter,
+		notifier:   notifier,
+		quotas:     quotas,
+	}
+}
+
+// Creates a comment after checking the user's commenting quota and screening it with the word filter
+func (s *CommentService) CreateComment(comment *model.Comment) error {
+	if err := s.quotas.CheckComment(comment.UserId); err != nil {
+		return err
+	}
+
+	flagged, err := s.wordFilter.Screen(&comment.Content)
+	if err != nil {
+		return err
+	}
+
+	// Credit the comment to the author's display name (or username when they haven't set one)
+	if comment.Author, err = s.db.GetAuthorName(comment.UserId); err != nil {
+		return err
+	}
+
+	if err := s.db.CreateComment(comment, comment.PostId); err != nil {
+		return fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	commentsCreatedTotal.Inc()
+	s.wordFilter.FlagContent(model.ReportTargetComment, comment.CommentId, flagged)
+	linkAttachments(s.db, comment.Content)
+	s.notifyWatchers(comment)
+
+	// Commenters follow the rest of the thread (unless they muted it)
+	if err := s.db.AutoWatchPost(comment.UserId, comment.PostId); err != nil {
+		log.Error().Err(err).Int64("post_id", comment.PostId).Msg("Failed to watch post after commenting")
+	}
+	return nil
+}
+
+// Tells the post's watchers (its author by default) about a new comment, skipping the commenter, anyone who
+// can no longer read the post, and everyone when the commenter is shadowbanned
+func (s *CommentService) notifyWatchers(comment *model.Comment) {
+	post, err := s.db.GetPostById(comment.PostId)
+	if err != nil {
+		log.Error().Err(err).Int64("post_id", comment.PostId).Msg("Failed to get post for comment notification")
+		return
+	}
+
+	shadowbanned, err := s.db.IsUserShadowbanned(comment.UserId)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", comment.UserId).Msg("Failed to check shadowban for comment notification")
+		return
+	}
+	if shadowbanned {
+		return
+	}
+
+	watchers, err := s.db.GetPostWatchers(post.PostId)
+	if err != nil {
+		log.Error().Err(err).Int64("post_id", post.PostId).Msg("Failed to get post watchers")
+		return
+	}
+
+	link := fmt.Sprintf("/api/posts/%d", post.PostId)
+	for _, userId := range watchers {
+		if userId == comment.UserId {
+			continue
+		}
+
+		if userId == post.UserId {
+			message := fmt.Sprintf("%s commented on your post \"%s\"", comment.Author, post.Title)
+			s.notifier.Notify(userId, model.NotifyComment, "New comment on your post", message, link)
+			continue
+		}
+
+		visible, err := canViewPost(s.db, post, userId)
+		if err != nil {
+			log.Error().Err(err).Int64("user_id", userId).Msg("Failed to check post visibility for comment notification")
+			continue
+		}
+		if !visible {
+			continue
+		}
+
+		message := fmt.Sprintf("%s commented on \"%s\", a post you're watching", comment.Author, post.Title)
+		s.notifier.Notify(userId, model.NotifyComment, "New comment on a post you're watching", message, link)
+	}
+}
+
+// Updates a comment after screening the new content with the word filter
+func (s *CommentService) UpdateComment(comment *model.Comment) error {
+	flagged, err := s.wordFilter.Screen(&comment.Content)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.UpdateComment(comment); err != nil {
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	s.wordFilter.FlagContent(model.ReportTargetComment, comment.CommentId, flagged)
+	linkAttachments(s.db, comment.Content)
+	return nil
+}
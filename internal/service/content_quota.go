@@ -0,0 +1,91 @@
+package service
+
+import (
+	"byte-board/internal/repository"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Window the content quotas are counted over
+const contentQuotaWindow = time.Hour
+
+// How many posts and comments an account may create per hour (0 disables a limit)
+type ContentQuota struct {
+	PostsPerHour    int
+	CommentsPerHour int
+}
+
+// Enforces per-account content quotas by role. Counts come from the posts and comments tables,
+// so the limits hold across instances and restarts (deleted content no longer counts).
+type ContentQuotas struct {
+	db     *repository.DB
+	byRole map[string]ContentQuota
+}
+
+// Creates new content quota checker (roles missing from byRole are unlimited)
+func NewContentQuotas(db *repository.DB, byRole map[string]ContentQuota) *ContentQuotas {
+	return &ContentQuotas{
+		db:     db,
+		byRole: byRole,
+	}
+}
+
+// Checks the user may create another post, returning a *ContentQuotaError when they're at their limit
+func (q *ContentQuotas) CheckPost(userId int64) error {
+	quota, err := q.quotaFor(userId)
+	if err != nil {
+		return err
+	}
+
+	return q.check(userId, "posts", quota.PostsPerHour, q.db.GetNthRecentPostTime)
+}
+
+// Checks the user may create another comment, returning a *ContentQuotaError when they're at their limit
+func (q *ContentQuotas) CheckComment(userId int64) error {
+	quota, err := q.quotaFor(userId)
+	if err != nil {
+		return err
+	}
+
+	return q.check(userId, "comments", quota.CommentsPerHour, q.db.GetNthRecentCommentTime)
+}
+
+// Looks up the quota for the user's role
+func (q *ContentQuotas) quotaFor(userId int64) (ContentQuota, error) {
+	user, err := q.db.GetUserByID(userId)
+	if err != nil {
+		return ContentQuota{}, err
+	}
+
+	return q.byRole[user.Role], nil
+}
+
+// The user is at the limit when they've created limit items within the window; they can post again
+// once the oldest of those falls out of it
+func (q *ContentQuotas) check(userId int64, content string, limit int, nthRecent func(userId int64, since time.Time, n int) (*time.Time, error)) error {
+	if limit <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	oldest, err := nthRecent(userId, now.Add(-contentQuotaWindow), limit)
+	if err != nil {
+		return err
+	}
+	if oldest == nil {
+		return nil
+	}
+
+	retryAfter := oldest.Add(contentQuotaWindow).Sub(now)
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+
+	log.Warn().Int64("user_id", userId).Str("content", content).Int("limit", limit).Msg("Content quota reached")
+	return &ContentQuotaError{
+		Content:    content,
+		Limit:      limit,
+		RetryAfter: retryAfter,
+	}
+}
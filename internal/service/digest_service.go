@@ -0,0 +1,207 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Number of posts listed in a digest
+const DigestSize = 10
+
+// Number of top commenters and new members listed in a site digest
+const siteDigestMembers = 10
+
+// How long a site digest is served when the refresh job is disabled
+const siteDigestFallbackAge = time.Hour
+
+// How far back each digest frequency looks
+var digestPeriods = map[string]time.Duration{
+	model.DigestDaily:  24 * time.Hour,
+	model.DigestWeekly: 7 * 24 * time.Hour,
+}
+
+// How far back each site digest period looks
+var siteDigestPeriods = map[string]time.Duration{
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+// Sends subscribers a periodic email of the top posts, and keeps the public site digests
+// (top posts, top commenters, new members) that frontends show on their homepage
+type DigestService struct {
+	db        *repository.DB
+	notifier  *NotificationService
+	publicURL string
+	// How often the site digests are regenerated
+	refresh time.Duration
+
+	mu          sync.Mutex
+	siteDigests map[string]*model.SiteDigest
+}
+
+// Creates new digest service
+func NewDigestService(db *repository.DB, notifier *NotificationService, publicURL string, refresh time.Duration) *DigestService {
+	return &DigestService{
+		db:          db,
+		notifier:    notifier,
+		publicURL:   strings.TrimRight(publicURL, "/"),
+		refresh:     refresh,
+		siteDigests: make(map[string]*model.SiteDigest),
+	}
+}
+
+// Get a user's digest settings
+func (s *DigestService) GetSettings(userId int64) (*model.DigestSettings, error) {
+	return s.db.GetDigestSettings(userId)
+}
+
+// Change how often a user gets the digest (off, daily or weekly)
+func (s *DigestService) SetFrequency(userId int64, frequency string) (*model.DigestSettings, error) {
+	if _, ok := digestPeriods[frequency]; !ok && frequency != model.DigestOff {
+		return nil, fmt.Errorf("%w: frequency must be off, daily or weekly", ErrInvalidInput)
+	}
+
+	return s.db.SetDigestFrequency(userId, frequency)
+}
+
+// Get the site digest for a period (day or week). It comes from the cache the scheduled job keeps warm;
+// one that's missing or overdue (say, just after startup) is generated on the spot.
+func (s *DigestService) GetSiteDigest(period string) (*model.SiteDigest, error) {
+	if _, ok := siteDigestPeriods[period]; !ok {
+		return nil, fmt.Errorf("%w: period must be day or week", ErrInvalidInput)
+	}
+
+	maxAge := 2 * s.refresh
+	if s.refresh <= 0 {
+		maxAge = siteDigestFallbackAge
+	}
+
+	s.mu.Lock()
+	digest, ok := s.siteDigests[period]
+	s.mu.Unlock()
+	if ok && time.Since(digest.GeneratedAt) < maxAge {
+		return digest, nil
+	}
+
+	return s.generateSiteDigest(period, time.Now())
+}
+
+// Scheduled job: regenerates the site digest for every period
+func (s *DigestService) RefreshSiteDigests(ctx context.Context) error {
+	now := time.Now()
+	for period := range siteDigestPeriods {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := s.generateSiteDigest(period, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Builds the site digest for a period ending now and caches it
+func (s *DigestService) generateSiteDigest(period string, now time.Time) (*model.SiteDigest, error) {
+	since := now.Add(-siteDigestPeriods[period])
+	digest := &model.SiteDigest{
+		Period:      period,
+		Since:       since,
+		GeneratedAt: now,
+	}
+
+	var err error
+	if digest.TopPosts, err = s.db.GetTopPostsSince(since, DigestSize); err != nil {
+		return nil, err
+	}
+	if digest.TopPosts == nil {
+		digest.TopPosts = []model.DigestPost{}
+	}
+	if digest.TopCommenters, err = s.db.GetTopCommentersSince(since, siteDigestMembers); err != nil {
+		return nil, err
+	}
+	if digest.NewMembers, digest.NewMemberCount, err = s.db.GetNewMembersSince(since, siteDigestMembers); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.siteDigests[period] = digest
+	s.mu.Unlock()
+
+	return digest, nil
+}
+
+// Scheduled job: sends every digest that is due.
+// One failed email doesn't stop the rest; it is retried on the next run.
+func (s *DigestService) SendDueDigests(ctx context.Context) error {
+	now := time.Now()
+	recipients, err := s.db.GetDueDigestRecipients(now)
+	if err != nil {
+		return err
+	}
+
+	// Subscribers on the same frequency get the same posts
+	topPosts := make(map[string][]model.DigestPost)
+	sent := 0
+	for _, recipient := range recipients {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		posts, ok := topPosts[recipient.Frequency]
+		if !ok {
+			posts, err = s.db.GetTopPostsSince(now.Add(-digestPeriods[recipient.Frequency]), DigestSize)
+			if err != nil {
+				return err
+			}
+			topPosts[recipient.Frequency] = posts
+		}
+
+		// Claim first so two instances never send the same digest
+		claimed, err := s.db.ClaimDigest(recipient.UserId, recipient.LastSentAt, now)
+		if err != nil {
+			return err
+		}
+		if !claimed || len(posts) == 0 {
+			continue
+		}
+
+		// Users who unsubscribed from digest emails are skipped (and stay claimed until the next period)
+		delivered, err := s.notifier.SendEmail(recipient.UserId, recipient.Email, model.NotifyDigest, "Your Byte Board "+recipient.Frequency+" digest", s.digestBody(recipient, posts))
+		if err != nil {
+			log.Error().Err(err).Int64("user_id", recipient.UserId).Msg("Failed to send digest")
+			// Release the claim so the next run retries
+			if err := s.db.ReleaseDigest(recipient.UserId, recipient.LastSentAt); err != nil {
+				log.Error().Err(err).Int64("user_id", recipient.UserId).Msg("Failed to release digest claim")
+			}
+			continue
+		}
+		if delivered {
+			sent++
+		}
+	}
+
+	if sent > 0 {
+		log.Info().Int("sent", sent).Msg("Digests sent")
+	}
+	return nil
+}
+
+// Builds the plain text body of a digest
+func (s *DigestService) digestBody(recipient model.DigestRecipient, posts []model.DigestPost) string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\nHere are the top posts on Byte Board since your last %s digest:\n\n", recipient.Username, recipient.Frequency)
+	for i, post := range posts {
+		fmt.Fprintf(&body, "%d. %s by %s (%d comments)\n   %s/api/posts/%d\n\n", i+1, post.Title, post.Author, post.Score, s.publicURL, post.PostId)
+	}
+	body.WriteString("You can change how often you get this email with PUT /api/auth/me/digest.")
+
+	return body.String()
+}
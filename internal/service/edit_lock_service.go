@@ -0,0 +1,138 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"time"
+)
+
+// Hands out advisory edit locks on posts so a second editing session gets told who is editing instead of
+// silently overwriting their changes. Locks expire after the TTL unless renewed, so an abandoned editor
+// never blocks a post for long. Saving a post without the lock token only fails while someone else holds it.
+type EditLockService struct {
+	db  *repository.DB
+	ttl time.Duration
+}
+
+// Creates new edit lock service
+func NewEditLockService(db *repository.DB, ttl time.Duration) *EditLockService {
+	return &EditLockService{
+		db:  db,
+		ttl: ttl,
+	}
+}
+
+// Gets the live edit lock on a post (nil when nobody is editing it)
+func (s *EditLockService) Get(postId int64) (*model.PostEditLock, error) {
+	return s.db.GetPostEditLock(postId, time.Now())
+}
+
+// Takes the edit lock on a post for a user, returning it with its token, or a *PostLockedError
+// when another session holds it
+func (s *EditLockService) Acquire(postId, userId int64) (*model.PostEditLock, error) {
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	lock := &model.PostEditLock{
+		PostId:     postId,
+		UserId:     userId,
+		LockToken:  token,
+		AcquiredAt: now,
+		ExpiresAt:  now.Add(s.ttl),
+	}
+	acquired, err := s.db.AcquirePostEditLock(lock, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, s.lockedError(postId, now)
+	}
+
+	return s.withHolder(lock)
+}
+
+// Extends the lock held with token by another TTL. A lock that lapsed is taken again if nobody else
+// took it in the meantime; otherwise a *PostLockedError says who did.
+func (s *EditLockService) Renew(postId, userId int64, token string) (*model.PostEditLock, error) {
+	now := time.Now()
+	tokenHash := hashToken(token)
+	renewed, err := s.db.RenewPostEditLock(postId, tokenHash, now, now.Add(s.ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	if !renewed {
+		lock := &model.PostEditLock{
+			PostId:     postId,
+			UserId:     userId,
+			LockToken:  token,
+			AcquiredAt: now,
+			ExpiresAt:  now.Add(s.ttl),
+		}
+		acquired, err := s.db.AcquirePostEditLock(lock, tokenHash)
+		if err != nil {
+			return nil, err
+		}
+		if !acquired {
+			return nil, s.lockedError(postId, now)
+		}
+
+		return s.withHolder(lock)
+	}
+
+	lock, err := s.db.GetPostEditLock(postId, now)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, s.lockedError(postId, now)
+	}
+
+	lock.LockToken = token
+	return lock, nil
+}
+
+// Gives up the lock held with token
+func (s *EditLockService) Release(postId int64, token string) error {
+	return s.db.ReleasePostEditLock(postId, hashToken(token))
+}
+
+// Checks a post can be saved by the session holding token (empty when it holds no lock), returning a
+// *PostLockedError while another session holds the lock
+func (s *EditLockService) CheckSave(postId int64, token string) error {
+	now := time.Now()
+	locked, err := s.db.IsPostEditLockedByOther(postId, hashToken(token), now)
+	if err != nil || !locked {
+		return err
+	}
+
+	return s.lockedError(postId, now)
+}
+
+// Describes the lock another session holds on a post
+func (s *EditLockService) lockedError(postId int64, now time.Time) error {
+	lock, err := s.db.GetPostEditLock(postId, now)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		// Released between the two queries; report it as just expiring
+		lock = &model.PostEditLock{PostId: postId, ExpiresAt: now}
+	}
+
+	return &PostLockedError{Lock: lock}
+}
+
+// Fills in the display name of the lock's holder
+func (s *EditLockService) withHolder(lock *model.PostEditLock) (*model.PostEditLock, error) {
+	name, err := s.db.GetAuthorName(lock.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	lock.LockedBy = name
+	return lock, nil
+}
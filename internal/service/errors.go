@@ -0,0 +1,99 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Errors returned by services (check with errors.Is)
+var (
+	// The request failed validation (wrapped together with the specific cause)
+	ErrInvalidInput = errors.New("invalid input")
+	// Username/password or current password didn't match
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// A refresh token is unknown, expired, revoked or was already used
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// The account is banned or suspended, so it can't sign in or refresh its tokens
+	ErrAccountBanned = errors.New("account is banned")
+	// A confirmation or reset token is unknown, used, or expired
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// Too many accounts were registered from the same IP, subnet or email recently
+	ErrTooManySignups = errors.New("too many signups")
+	// An upload is bigger than the per-file limit
+	ErrFileTooLarge = errors.New("file too large")
+	// An upload would take the user over their storage quota
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	// The user has created as many posts or comments as their role allows for now (see ContentQuotaError)
+	ErrContentQuotaExceeded = errors.New("content quota exceeded")
+	// Too many failed logins for the username from the same IP; wait before trying again (see LoginThrottledError)
+	ErrLoginThrottled = errors.New("too many failed logins")
+	// Unknown or revoked service client, or wrong client secret
+	ErrInvalidClient = errors.New("invalid client credentials")
+	// A service client asked for a scope it wasn't granted
+	ErrInvalidScope = errors.New("invalid scope")
+	// No machine translation provider is configured
+	ErrTranslationUnavailable = errors.New("translation is not available")
+	// The translation provider failed or returned an error
+	ErrTranslationFailed = errors.New("translation failed")
+	// A signup's CAPTCHA challenge response is missing or invalid
+	ErrCaptchaFailed = errors.New("captcha verification failed")
+	// The CAPTCHA provider couldn't check the challenge response, so the signup is turned away for now
+	ErrCaptchaUnavailable = errors.New("captcha verification unavailable")
+
+	ErrUsernameTaken = fmt.Errorf("username already exists: %w", repository.ErrConflict)
+	// Another user already goes by the requested display name
+	ErrDisplayNameTaken = fmt.Errorf("display name is taken: %w", repository.ErrConflict)
+	ErrDuplicatePost    = fmt.Errorf("duplicate post: %w", repository.ErrConflict)
+	// The user already has an open report on the same content
+	ErrDuplicateReport = fmt.Errorf("duplicate report: %w", repository.ErrConflict)
+	// Someone else is editing the post (see PostLockedError)
+	ErrPostLocked = fmt.Errorf("post is locked for editing: %w", repository.ErrConflict)
+)
+
+// Says which content quota was hit and when the user can post again (wraps ErrContentQuotaExceeded)
+type ContentQuotaError struct {
+	// "posts" or "comments"
+	Content string
+	// Items allowed per hour
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *ContentQuotaError) Error() string {
+	return fmt.Sprintf("you can create up to %d %s per hour; try again in %s",
+		e.Limit, e.Content, e.RetryAfter.Round(time.Second))
+}
+
+func (e *ContentQuotaError) Unwrap() error {
+	return ErrContentQuotaExceeded
+}
+
+// Says how long to wait before the next login attempt (wraps ErrLoginThrottled)
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed logins; try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrLoginThrottled
+}
+
+// Says who holds the edit lock on a post and until when (wraps ErrPostLocked)
+type PostLockedError struct {
+	Lock *model.PostEditLock
+}
+
+func (e *PostLockedError) Error() string {
+	return fmt.Sprintf("post is locked for editing by %s until %s",
+		e.Lock.LockedBy, e.Lock.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+func (e *PostLockedError) Unwrap() error {
+	return ErrPostLocked
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"byte-board/internal/jobs"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Kind of the job that replays the event log to a consumer (suffixed with the consumer's name)
+const JobEventReplay = "event-replay"
+
+// Event replay limits
+const (
+	eventReplayBatch    = 100
+	maxEventReplayTypes = 20
+)
+
+// Receives replayed domain events, e.g. a search indexer or notification store rebuilding its state
+type EventConsumer interface {
+	// Handles a batch of events, oldest first; an error stops the replay
+	Consume(ctx context.Context, events []model.Event) error
+}
+
+// Replays the domain event log to consumers so they can rebuild the state they derive from it
+type EventReplayService struct {
+	db        *repository.DB
+	queue     *jobs.Queue
+	consumers map[string]EventConsumer
+}
+
+// Creates new event replay service with the consumers events can be replayed to, by name
+func NewEventReplayService(db *repository.DB, queue *jobs.Queue, consumers map[string]EventConsumer) *EventReplayService {
+	return &EventReplayService{
+		db:        db,
+		queue:     queue,
+		consumers: consumers,
+	}
+}
+
+// Gets the names of the consumers events can be replayed to
+func (s *EventReplayService) Targets() []string {
+	return slices.Sorted(maps.Keys(s.consumers))
+}
+
+// Queues a replay of the events matching filter to the target consumer, oldest first in batches. When the
+// consumer fails, the job fails naming the last event it accepted, so the replay can resume after it. While
+// a replay to the same consumer is queued or running that job is returned instead.
+func (s *EventReplayService) Replay(target string, filter model.EventReplayFilter) (jobs.Status, error) {
+	consumer, ok := s.consumers[target]
+	if !ok {
+		targets := s.Targets()
+		if len(targets) == 0 {
+			return jobs.Status{}, fmt.Errorf("%w: no replay targets are configured (see EVENT_REPLAY_TARGETS)", ErrInvalidInput)
+		}
+		return jobs.Status{}, fmt.Errorf("%w: unknown target %q (must be one of %s)", ErrInvalidInput, target, strings.Join(targets, ", "))
+	}
+	if len(filter.Types) > maxEventReplayTypes {
+		return jobs.Status{}, fmt.Errorf("%w: up to %d event types can be replayed at once", ErrInvalidInput, maxEventReplayTypes)
+	}
+	for _, eventType := range filter.Types {
+		if strings.TrimSpace(eventType) == "" {
+			return jobs.Status{}, fmt.Errorf("%w: event types can't be empty", ErrInvalidInput)
+		}
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return jobs.Status{}, fmt.Errorf("%w: since must be before until", ErrInvalidInput)
+	}
+	if filter.AfterId < 0 {
+		return jobs.Status{}, fmt.Errorf("%w: after_id can't be negative", ErrInvalidInput)
+	}
+
+	return s.queue.Enqueue(JobEventReplay+":"+target, func(ctx context.Context, progress jobs.ProgressFunc) error {
+		total, err := s.db.CountReplayEvents(filter)
+		if err != nil {
+			return err
+		}
+
+		done := 0
+		lastId := filter.AfterId
+		progress(done, total, "replaying to "+target)
+		for {
+			events, err := s.db.GetReplayEvents(ctx, filter, lastId, eventReplayBatch)
+			if err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				break
+			}
+
+			if err := consumer.Consume(ctx, events); err != nil {
+				return fmt.Errorf("replay to %s stopped after event %d (resume with that after_id): %w", target, lastId, err)
+			}
+
+			done += len(events)
+			lastId = events[len(events)-1].EventId
+			progress(min(done, total), total, fmt.Sprintf("replayed up to event %d", lastId))
+		}
+
+		log.Info().Str("target", target).Int("events", done).Int64("last_event_id", lastId).Msg("Events replayed")
+		progress(total, total, fmt.Sprintf("replayed %d events to %s", done, target))
+		return nil
+	})
+}
+
+// Delivers replayed events to an HTTP endpoint: each batch is POSTed as {"events": [...]}, and any 2xx
+// response accepts it. When a secret is set, batches carry an X-Byteboard-Signature header with the
+// hex HMAC-SHA256 of the body ("sha256=...") so the consumer can check they came from us.
+type HTTPEventConsumer struct {
+	httpClient *http.Client
+	url        string
+	secret     string
+}
+
+// Creates a new HTTP event consumer
+func NewHTTPEventConsumer(url, secret string) *HTTPEventConsumer {
+	return &HTTPEventConsumer{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		url:        url,
+		secret:     secret,
+	}
+}
+
+// Sends a batch of events to the endpoint
+func (c *HTTPEventConsumer) Consume(ctx context.Context, events []model.Event) error {
+	body, err := json.Marshal(map[string][]model.Event{"events": events})
+	if err != nil {
+		return fmt.Errorf("failed to encode events: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build replay request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.secret != "" {
+		mac := hmac.New(sha256.New, []byte(c.secret))
+		mac.Write(body)
+		req.Header.Set("X-Byteboard-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach consumer: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("consumer returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	return nil
+}
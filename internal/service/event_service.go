@@ -0,0 +1,90 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"encoding/json"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Records domain events and admins' views of personal data, and reads them back for admins
+type EventService struct {
+	db *repository.DB
+}
+
+// Creates new event service
+func NewEventService(db *repository.DB) *EventService {
+	return &EventService{db: db}
+}
+
+// Returns a copy of the service that records through db (e.g. one bound to the request's transaction)
+func (s *EventService) WithDB(db *repository.DB) *EventService {
+	copy := *s
+	copy.db = db
+	return &copy
+}
+
+// Records that actorId (0 for system events) did eventType to a subject. Failures are logged,
+// never returned, since the change the event describes has already been made.
+func (s *EventService) Record(eventType string, actorId int64, subjectType string, subjectId int64, payload map[string]interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil || payload == nil {
+		data = []byte("{}")
+	}
+
+	event := &model.Event{
+		Type:        eventType,
+		SubjectType: subjectType,
+		SubjectId:   subjectId,
+		Payload:     data,
+		CreatedAt:   time.Now(),
+	}
+	if actorId != 0 {
+		event.ActorId = &actorId
+	}
+
+	if err := s.db.RecordEvent(event); err != nil {
+		log.Error().Err(err).Str("event_type", eventType).Int64("subject_id", subjectId).Msg("Failed to record event")
+	}
+}
+
+// Get events matching a filter, newest first
+func (s *EventService) List(filter model.EventFilter) ([]model.Event, error) {
+	return s.db.ListEvents(filter)
+}
+
+// Records that an admin viewed a user's personal data (userId 0 for entries that cover no one user).
+// Like events, failures are logged rather than returned.
+func (s *EventService) RecordDataAccess(viewerId, userId int64, access string) {
+	entry := &model.DataAccess{
+		ViewerId:  viewerId,
+		Access:    access,
+		CreatedAt: time.Now(),
+	}
+	if userId != 0 {
+		entry.UserId = &userId
+	}
+
+	if err := s.db.RecordDataAccess(entry); err != nil {
+		log.Error().Err(err).Int64("viewer_id", viewerId).Int64("user_id", userId).Str("access", access).Msg("Failed to record data access")
+	}
+}
+
+// Records that an admin viewed several users' personal data at once (e.g. an export), one entry per user.
+// Like events, failures are logged rather than returned.
+func (s *EventService) RecordDataAccesses(viewerId int64, userIds []int64, access string) {
+	if len(userIds) == 0 {
+		return
+	}
+
+	if err := s.db.RecordDataAccesses(viewerId, userIds, access, time.Now()); err != nil {
+		log.Error().Err(err).Int64("viewer_id", viewerId).Int("users", len(userIds)).Str("access", access).Msg("Failed to record data access")
+	}
+}
+
+// Get data access log entries matching a filter, newest first
+func (s *EventService) ListDataAccess(filter model.DataAccessFilter) ([]model.DataAccess, error) {
+	return s.db.ListDataAccess(filter)
+}
@@ -0,0 +1,484 @@
+package service
+
+import (
+	"byte-board/internal/federation"
+	"byte-board/internal/language"
+	"byte-board/internal/markdown"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Federation settings
+const (
+	// Posts listed per outbox page
+	outboxPageSize = 20
+	// Only posts this recent are published to followers, so enabling federation (or gaining a first
+	// follower) doesn't push an author's whole history
+	federationPublishWindow = time.Hour
+	// Posts claimed and deliveries sent per job run
+	federationBatchSize = 50
+	// How long a claimed delivery is left to its sender before another instance may retry it
+	federationDeliveryLease = 5 * time.Minute
+	// Failed deliveries are retried with doubling delays from a minute, then dropped
+	maxFederationAttempts = 8
+)
+
+// Publishes local authors to the fediverse (Mastodon and other ActivityPub servers), publish-only:
+// authors are exposed as actors with WebFinger addresses and outboxes of their public posts, remote
+// accounts can follow them, and new public posts are delivered to those followers. Nothing remote is
+// read into the board.
+type FederationService struct {
+	db           *repository.DB
+	client       *federation.Client
+	publicURL    string
+	host         string
+	publicKeyPem string
+}
+
+// Creates new federation service; activities are signed with key, and actors live under publicURL
+func NewFederationService(db *repository.DB, key *rsa.PrivateKey, publicURL string) (*FederationService, error) {
+	parsed, err := url.Parse(publicURL)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid public URL %q", publicURL)
+	}
+
+	publicKeyPem, err := federation.PublicKeyPEM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FederationService{
+		db:           db,
+		client:       federation.NewClient(key),
+		publicURL:    strings.TrimRight(publicURL, "/"),
+		host:         parsed.Host,
+		publicKeyPem: publicKeyPem,
+	}, nil
+}
+
+// The ActivityPub ID of a user's actor
+func (s *FederationService) actorId(username string) string {
+	return s.publicURL + "/users/" + url.PathEscape(username)
+}
+
+// The ActivityPub ID of a post
+func (s *FederationService) noteId(username string, postId int64) string {
+	return fmt.Sprintf("%s/posts/%d", s.actorId(username), postId)
+}
+
+// Looks up a user that's exposed as an actor. Banned and shadowbanned users aren't.
+func (s *FederationService) findAuthor(username string) (*model.User, error) {
+	user, err := s.db.GetUserByUsername(username)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsBanned(time.Now()) || user.Shadowbanned {
+		return nil, fmt.Errorf("user %w", repository.ErrNotFound)
+	}
+	return user, nil
+}
+
+// Resolves a WebFinger resource (acct:username@host, or an actor ID) to the user's actor
+func (s *FederationService) WebFinger(resource string) (*federation.WebFinger, error) {
+	var username string
+	switch {
+	case strings.HasPrefix(resource, "acct:"):
+		name, host, ok := strings.Cut(strings.TrimPrefix(resource, "acct:"), "@")
+		if !ok || !strings.EqualFold(host, s.host) {
+			return nil, fmt.Errorf("account %w", repository.ErrNotFound)
+		}
+		username = name
+	case strings.HasPrefix(resource, s.publicURL+"/users/"):
+		username, _ = url.PathUnescape(strings.TrimPrefix(resource, s.publicURL+"/users/"))
+	default:
+		return nil, fmt.Errorf("%w: resource must be acct:username@%s or an actor URL", ErrInvalidInput, s.host)
+	}
+
+	user, err := s.findAuthor(username)
+	if err != nil {
+		return nil, err
+	}
+
+	actorId := s.actorId(user.Username)
+	return &federation.WebFinger{
+		Subject: "acct:" + user.Username + "@" + s.host,
+		Aliases: []string{actorId},
+		Links: []federation.WebFingerLink{
+			{Rel: "self", Type: federation.ContentType, Href: actorId},
+		},
+	}, nil
+}
+
+// Builds a user's actor document
+func (s *FederationService) Actor(username string) (*federation.Actor, error) {
+	user, err := s.findAuthor(username)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.db.GetProfileByUserId(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := profile.DisplayName
+	if name == "" {
+		name = user.Username
+	}
+	profileURL := fmt.Sprintf("%s/api/profiles/%d", s.publicURL, user.ID)
+	return federation.NewActor(s.actorId(user.Username), user.Username, name, profileURL, profile.DateRegistered, s.publicKeyPem), nil
+}
+
+// Builds the summary of a user's outbox (their public posts), which links to its first page
+func (s *FederationService) Outbox(username string) (*federation.OrderedCollection, error) {
+	user, err := s.findAuthor(username)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.db.CountPublicPosts(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	outbox := s.actorId(user.Username) + "/outbox"
+	return &federation.OrderedCollection{
+		Context:    federation.CollectionContext(),
+		ID:         outbox,
+		Type:       "OrderedCollection",
+		TotalItems: total,
+		First:      outbox + "?page=1",
+	}, nil
+}
+
+// Builds a page of a user's outbox (1-based, newest posts first)
+func (s *FederationService) OutboxPage(username string, page int) (*federation.OrderedCollectionPage, error) {
+	if page < 1 {
+		return nil, fmt.Errorf("%w: page must be 1 or more", ErrInvalidInput)
+	}
+
+	user, err := s.findAuthor(username)
+	if err != nil {
+		return nil, err
+	}
+
+	// Listed as an anonymous viewer: public posts only
+	posts, err := s.db.ListPosts(model.PostFilter{
+		UserId: user.ID,
+		Sort:   "newest",
+		Limit:  outboxPageSize + 1,
+		Offset: (page - 1) * outboxPageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	outbox := s.actorId(user.Username) + "/outbox"
+	collectionPage := &federation.OrderedCollectionPage{
+		Context:      federation.CollectionContext(),
+		ID:           fmt.Sprintf("%s?page=%d", outbox, page),
+		Type:         "OrderedCollectionPage",
+		PartOf:       outbox,
+		OrderedItems: []federation.Activity{},
+	}
+	if len(posts) > outboxPageSize {
+		posts = posts[:outboxPageSize]
+		collectionPage.Next = fmt.Sprintf("%s?page=%d", outbox, page+1)
+	}
+	if page > 1 {
+		collectionPage.Prev = fmt.Sprintf("%s?page=%d", outbox, page-1)
+	}
+
+	for _, post := range posts {
+		note, err := s.note(user.Username, &post)
+		if err != nil {
+			return nil, err
+		}
+		collectionPage.OrderedItems = append(collectionPage.OrderedItems, federation.NewCreate(*note))
+	}
+
+	return collectionPage, nil
+}
+
+// Builds a user's followers collection. Only the count is shown, not who the followers are.
+func (s *FederationService) Followers(username string) (*federation.OrderedCollection, error) {
+	user, err := s.findAuthor(username)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := s.db.CountFederationFollowers(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &federation.OrderedCollection{
+		Context:    federation.CollectionContext(),
+		ID:         s.actorId(user.Username) + "/followers",
+		Type:       "OrderedCollection",
+		TotalItems: total,
+	}, nil
+}
+
+// Builds the note for one of a user's public posts
+func (s *FederationService) Note(username string, postId int64) (*federation.Note, error) {
+	user, err := s.findAuthor(username)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.db.GetPostById(postId)
+	if err != nil {
+		return nil, err
+	}
+	if post.UserId != user.ID || post.Hidden || post.Visibility != model.VisibilityPublic {
+		return nil, fmt.Errorf("post %w", repository.ErrNotFound)
+	}
+
+	note, err := s.note(user.Username, post)
+	if err != nil {
+		return nil, err
+	}
+	withContext := federation.NoteWithContext(*note)
+	return &withContext, nil
+}
+
+// Builds a post's note: the title in bold over the rendered Markdown, addressed to everyone and cc'd to
+// the author's followers
+func (s *FederationService) note(username string, post *model.Post) (*federation.Note, error) {
+	body, err := markdown.Render(post.Content)
+	if err != nil {
+		return nil, err
+	}
+	content := "<p><strong>" + html.EscapeString(post.Title) + "</strong></p>" + body
+
+	actorId := s.actorId(username)
+	note := &federation.Note{
+		ID:           s.noteId(username, post.PostId),
+		Type:         "Note",
+		AttributedTo: actorId,
+		Content:      content,
+		Published:    post.DatePosted.UTC(),
+		URL:          fmt.Sprintf("%s/api/posts/%d", s.publicURL, post.PostId),
+		To:           []string{federation.PublicAddress},
+		CC:           []string{actorId + "/followers"},
+	}
+	if post.Language != "" && post.Language != language.Undetermined {
+		note.ContentMap = map[string]string{post.Language: content}
+	}
+	return note, nil
+}
+
+// Handles an activity posted to a user's inbox. Only follows and their undoing are acted on, after
+// checking the HTTP signature belongs to the actor sending them; everything else is ignored.
+func (s *FederationService) HandleInbox(ctx context.Context, username string, r *http.Request, body []byte) error {
+	user, err := s.findAuthor(username)
+	if err != nil {
+		return err
+	}
+
+	var activity federation.IncomingActivity
+	if err := json.Unmarshal(body, &activity); err != nil || activity.Actor == "" {
+		return fmt.Errorf("%w: body must be an ActivityPub activity", ErrInvalidInput)
+	}
+	if activity.Type != federation.TypeFollow && activity.Type != federation.TypeUndo {
+		log.Debug().Str("type", activity.Type).Str("actor", activity.Actor).Msg("Ignoring inbox activity")
+		return nil
+	}
+
+	actorId := s.actorId(user.Username)
+	remote, err := s.verifySender(ctx, r, body, activity.Actor, actorId)
+	if err != nil {
+		return err
+	}
+
+	switch activity.Type {
+	case federation.TypeFollow:
+		if activity.ObjectID() != actorId {
+			return fmt.Errorf("%w: follow is for another actor", ErrInvalidInput)
+		}
+		return s.acceptFollow(user, activity, remote)
+
+	case federation.TypeUndo:
+		follow, ok := activity.EmbeddedActivity()
+		if !ok || follow.Type != federation.TypeFollow || follow.Actor != activity.Actor || follow.ObjectID() != actorId {
+			log.Debug().Str("actor", activity.Actor).Msg("Ignoring undo of something other than a follow")
+			return nil
+		}
+		if err := s.db.RemoveFederationFollower(user.ID, activity.Actor); err != nil {
+			return err
+		}
+		log.Info().Int64("user_id", user.ID).Str("actor", activity.Actor).Msg("Fediverse follower removed")
+	}
+
+	return nil
+}
+
+// Checks an inbox request was signed by the actor it claims to be from, returning that actor
+func (s *FederationService) verifySender(ctx context.Context, r *http.Request, body []byte, senderId, localActorId string) (*federation.RemoteActor, error) {
+	signature, err := federation.ParseSignature(r)
+	if err != nil {
+		return nil, err
+	}
+
+	remote, err := s.client.FetchActor(ctx, senderId, localActorId)
+	if err != nil {
+		log.Warn().Err(err).Str("actor", senderId).Msg("Failed to fetch inbox sender")
+		return nil, fmt.Errorf("%w: couldn't fetch the sending actor", federation.ErrBadSignature)
+	}
+	if signature.KeyID != remote.PublicKey.ID || remote.PublicKey.Owner != remote.ID {
+		return nil, fmt.Errorf("%w: not signed with the sending actor's key", federation.ErrBadSignature)
+	}
+	if err := signature.Verify(r, body, remote.PublicKey.PublicKeyPem, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return remote, nil
+}
+
+// Records a remote follower and queues the Accept telling their server the follow went through
+func (s *FederationService) acceptFollow(user *model.User, follow federation.IncomingActivity, remote *federation.RemoteActor) error {
+	accept, err := json.Marshal(federation.WithContext(federation.NewAccept(s.actorId(user.Username), follow)))
+	if err != nil {
+		return fmt.Errorf("failed to encode accept: %w", err)
+	}
+
+	return s.db.InTx(context.Background(), func(tx *repository.DB) error {
+		if err := tx.AddFederationFollower(&model.FederationFollower{
+			UserId:         user.ID,
+			ActorId:        remote.ID,
+			InboxURL:       remote.Inbox,
+			SharedInboxURL: remote.Endpoints.SharedInbox,
+		}); err != nil {
+			return err
+		}
+		if err := tx.QueueFederationDelivery(user.ID, remote.Inbox, accept); err != nil {
+			return err
+		}
+
+		log.Info().Int64("user_id", user.ID).Str("actor", remote.ID).Msg("Fediverse follower added")
+		return nil
+	})
+}
+
+// Scheduled job: queues a Create for each new public post by an author with fediverse followers, then
+// sends the queued deliveries that are due
+func (s *FederationService) Run(ctx context.Context) error {
+	if err := s.publishNewPosts(); err != nil {
+		return err
+	}
+	return s.deliver(ctx)
+}
+
+// Claims new public posts and queues their Create activities for the authors' followers
+func (s *FederationService) publishNewPosts() error {
+	now := time.Now()
+	queued := 0
+	err := s.db.InTx(context.Background(), func(tx *repository.DB) error {
+		posts, err := tx.ClaimPostsToFederate(now.Add(-federationPublishWindow), now, federationBatchSize)
+		if err != nil {
+			return err
+		}
+
+		usernames := make(map[int64]string)
+		for _, post := range posts {
+			username, ok := usernames[post.UserId]
+			if !ok {
+				user, err := tx.GetUserByID(post.UserId)
+				if err != nil {
+					return err
+				}
+				username = user.Username
+				usernames[post.UserId] = username
+			}
+
+			note, err := s.note(username, &post)
+			if err != nil {
+				return err
+			}
+			activity, err := json.Marshal(federation.WithContext(federation.NewCreate(*note)))
+			if err != nil {
+				return fmt.Errorf("failed to encode create: %w", err)
+			}
+
+			count, err := tx.QueueFollowerDeliveries(post.UserId, activity)
+			if err != nil {
+				return err
+			}
+			queued += count
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if queued > 0 {
+		log.Info().Int("deliveries", queued).Msg("Queued new posts for fediverse followers")
+	}
+	return nil
+}
+
+// Sends the deliveries that are due. Failures are retried with backoff; inboxes that are gone and
+// deliveries out of attempts are dropped.
+func (s *FederationService) deliver(ctx context.Context) error {
+	now := time.Now()
+	deliveries, err := s.db.ClaimFederationDeliveries(now, now.Add(federationDeliveryLease), federationBatchSize)
+	if err != nil {
+		return err
+	}
+
+	usernames := make(map[int64]string)
+	sent := 0
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		username, ok := usernames[delivery.UserId]
+		if !ok {
+			user, err := s.db.GetUserByID(delivery.UserId)
+			if err != nil {
+				return err
+			}
+			username = user.Username
+			usernames[delivery.UserId] = username
+		}
+
+		err := s.client.Deliver(ctx, delivery.InboxURL, delivery.Activity, s.actorId(username))
+		if err == nil || errors.Is(err, federation.ErrGone) || delivery.Attempts >= maxFederationAttempts {
+			if err != nil {
+				log.Warn().Err(err).Int64("delivery_id", delivery.DeliveryId).Str("inbox", delivery.InboxURL).Int("attempts", delivery.Attempts).Msg("Dropping fediverse delivery")
+			} else {
+				sent++
+			}
+			if err := s.db.DeleteFederationDelivery(delivery.DeliveryId); err != nil {
+				return err
+			}
+			continue
+		}
+
+		retryIn := time.Minute << (delivery.Attempts - 1)
+		log.Warn().Err(err).Int64("delivery_id", delivery.DeliveryId).Str("inbox", delivery.InboxURL).Dur("retry_in", retryIn).Msg("Fediverse delivery failed")
+		if err := s.db.RetryFederationDelivery(delivery.DeliveryId, time.Now().Add(retryIn), err.Error()); err != nil {
+			return err
+		}
+	}
+
+	if sent > 0 {
+		log.Info().Int("sent", sent).Msg("Delivered activities to the fediverse")
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Most files imported from a single gist
+const maxGistFiles = 20
+
+// A gist as returned by the GitHub API
+type gist struct {
+	Description string              `json:"description"`
+	HTMLURL     string              `json:"html_url"`
+	Files       map[string]gistFile `json:"files"`
+}
+
+type gistFile struct {
+	Filename  string `json:"filename"`
+	Language  string `json:"language"`
+	Content   string `json:"content"`
+	Truncated bool   `json:"truncated"`
+	RawURL    string `json:"raw_url"`
+	Size      int    `json:"size"`
+}
+
+// Imports GitHub gists as posts with attached snippets
+type GistService struct {
+	postService    *PostService
+	snippetService *SnippetService
+	httpClient     *http.Client
+	apiURL         string
+	token          string
+}
+
+// Creates new gist import service
+func NewGistService(postService *PostService, snippetService *SnippetService, apiURL, token string) *GistService {
+	return &GistService{
+		postService:    postService,
+		snippetService: snippetService,
+		httpClient:     &http.Client{Timeout: 10 * time.Second},
+		apiURL:         strings.TrimRight(apiURL, "/"),
+		token:          token,
+	}
+}
+
+// Fetches a gist and creates a post owned by user with one snippet per gist file
+func (s *GistService) ImportGist(ctx context.Context, user *model.User, gistURL, title string) (*model.Post, []model.Snippet, error) {
+	gistId, err := parseGistID(gistURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	g, err := s.fetchGist(ctx, gistId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(g.Files) == 0 {
+		return nil, nil, fmt.Errorf("%w: gist has no files", ErrInvalidInput)
+	}
+	if len(g.Files) > maxGistFiles {
+		return nil, nil, fmt.Errorf("%w: gist has more than %d files", ErrInvalidInput, maxGistFiles)
+	}
+
+	// Keep the gist's file order stable
+	filenames := make([]string, 0, len(g.Files))
+	for name := range g.Files {
+		filenames = append(filenames, name)
+	}
+	sort.Strings(filenames)
+
+	// Build the post from the gist description
+	if strings.TrimSpace(title) == "" {
+		title = strings.TrimSpace(g.Description)
+	}
+	if title == "" {
+		title = filenames[0]
+	}
+	content := strings.TrimSpace(g.Description)
+	if content == "" {
+		content = "Imported from " + g.HTMLURL
+	} else {
+		content += "\n\nImported from " + g.HTMLURL
+	}
+
+	post := &model.Post{
+		UserId:     user.ID,
+		Title:      title,
+		Content:    content,
+		DatePosted: time.Now(),
+	}
+	if err := s.postService.CreatePost(post); err != nil {
+		return nil, nil, err
+	}
+
+	// Attach each file as a snippet, keeping GitHub's language when it has one
+	snippets := make([]model.Snippet, 0, len(filenames))
+	for _, name := range filenames {
+		file := g.Files[name]
+		body := file.Content
+		if file.Truncated {
+			body, err = s.fetchRaw(ctx, file.RawURL)
+			if err != nil {
+				s.rollbackPost(post.PostId)
+				return nil, nil, err
+			}
+		}
+
+		snippet := &model.Snippet{
+			PostId:   post.PostId,
+			UserId:   user.ID,
+			Filename: file.Filename,
+			Language: strings.ToLower(file.Language),
+			Body:     body,
+		}
+		if err := s.snippetService.CreateSnippet(snippet); err != nil {
+			s.rollbackPost(post.PostId)
+			return nil, nil, fmt.Errorf("failed to import %s: %w", file.Filename, err)
+		}
+		snippets = append(snippets, *snippet)
+	}
+
+	log.Info().Str("gist_id", gistId).Int64("post_id", post.PostId).Int("files", len(snippets)).Msg("Gist imported")
+	return post, snippets, nil
+}
+
+// Removes a partially imported post
+func (s *GistService) rollbackPost(postId int64) {
+	if err := s.postService.DeletePost(postId); err != nil {
+		log.Error().Err(err).Int64("post_id", postId).Msg("Failed to remove partially imported gist post")
+	}
+}
+
+// Get a gist from the GitHub API
+func (s *GistService) fetchGist(ctx context.Context, gistId string) (*gist, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.apiURL+"/gists/"+gistId, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gist request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch gist: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: gist not found", ErrInvalidInput)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("github returned status %d for gist %s", resp.StatusCode, gistId)
+	}
+
+	var g gist
+	if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
+		return nil, fmt.Errorf("failed to decode gist: %w", err)
+	}
+
+	return &g, nil
+}
+
+// Get the full content of a truncated gist file
+func (s *GistService) fetchRaw(ctx context.Context, rawURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create raw file request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch raw gist file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("raw gist file returned status %d", resp.StatusCode)
+	}
+
+	// Read one byte past the limit so oversized files fail validation
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxSnippetSize+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read raw gist file: %w", err)
+	}
+
+	return string(body), nil
+}
+
+// Extracts the gist ID from a gist URL (or accepts a bare ID)
+func parseGistID(gistURL string) (string, error) {
+	gistURL = strings.TrimSpace(gistURL)
+	if gistURL == "" {
+		return "", fmt.Errorf("%w: gist url is required", ErrInvalidInput)
+	}
+
+	id := gistURL
+	if strings.Contains(gistURL, "/") {
+		parsed, err := url.Parse(gistURL)
+		if err != nil || parsed.Host != "gist.github.com" {
+			return "", fmt.Errorf("%w: url must be a gist.github.com link", ErrInvalidInput)
+		}
+		id = path.Base(strings.TrimRight(parsed.Path, "/"))
+	}
+
+	// Gist IDs are hex strings
+	for _, c := range id {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return "", fmt.Errorf("%w: invalid gist id", ErrInvalidInput)
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("%w: invalid gist id", ErrInvalidInput)
+	}
+
+	return id, nil
+}
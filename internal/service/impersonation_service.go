@@ -0,0 +1,85 @@
+package service
+
+import (
+	"byte-board/internal/auth"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Longest reason accepted for impersonating a user
+const maxImpersonationReason = 500
+
+// Issues short-lived tokens that let support staff act as a user, to reproduce the issues they report
+type ImpersonationService struct {
+	db            *repository.DB
+	tokenProvider *auth.TokenProvider
+	roles         *RoleService
+	tokenTTL      time.Duration
+}
+
+// Creates new impersonation service (tokens are valid for tokenTTL)
+func NewImpersonationService(db *repository.DB, tokenProvider *auth.TokenProvider, roles *RoleService, tokenTTL time.Duration) *ImpersonationService {
+	return &ImpersonationService{
+		db:            db,
+		tokenProvider: tokenProvider,
+		roles:         roles,
+		tokenTTL:      tokenTTL,
+	}
+}
+
+// Starts an impersonation session for a user and returns its token, which carries the admin's ID in
+// impersonator_id. There's no refresh token: when it expires the admin starts a new one. Admins can't
+// impersonate themselves or other admins.
+func (s *ImpersonationService) Impersonate(adminId, userId int64, reason, ip, userAgent string) (*model.ImpersonationResponse, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxImpersonationReason {
+		return nil, fmt.Errorf("%w: reason is required (up to %d characters)", ErrInvalidInput, maxImpersonationReason)
+	}
+	if userId == adminId {
+		return nil, fmt.Errorf("%w: you can't impersonate yourself", ErrInvalidInput)
+	}
+
+	user, err := s.db.GetUserByID(userId)
+	if err != nil {
+		return nil, err
+	}
+	if s.roles.Grants(user.Role, model.PermissionAll) {
+		return nil, fmt.Errorf("%w: admins can't be impersonated", ErrInvalidInput)
+	}
+
+	familyBytes := make([]byte, 16)
+	if _, err := rand.Read(familyBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate session family: %w", err)
+	}
+
+	now := time.Now()
+	session := newSession(user.TokenVersion, ip, userAgent)
+	session.UserId = user.ID
+	session.FamilyId = hex.EncodeToString(familyBytes)
+	session.CreatedAt = now
+	session.ExpiresAt = now.Add(s.tokenTTL)
+	session.ImpersonatorId = &adminId
+	if err := s.db.CreateSession(session); err != nil {
+		return nil, err
+	}
+
+	token, err := s.tokenProvider.CreateImpersonationToken(user.ID, user.Username, user.Role, session.TokenVersion, session.SessionId, adminId, s.tokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate impersonation token: %w", err)
+	}
+
+	log.Warn().Int64("user_id", user.ID).Int64("impersonator_id", adminId).Int64("session_id", session.SessionId).Str("reason", reason).Msg("Impersonation session started")
+	return &model.ImpersonationResponse{
+		Token:     token,
+		SessionId: session.SessionId,
+		ExpiresAt: session.ExpiresAt,
+		User:      model.NewUserSummary(user),
+	}, nil
+}
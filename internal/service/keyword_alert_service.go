@@ -0,0 +1,158 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Keyword alert limits
+const (
+	maxKeywordAlerts    = 20
+	minKeywordLength    = 2
+	maxKeywordLength    = 50
+	keywordExtraSymbols = " -_.+#"
+)
+
+// Anything that can't be part of a word, in PostgreSQL regular expression syntax
+const keywordBoundary = "[^[:alnum:]_]"
+
+// Notifies users when new posts mention keywords they registered. Each alert notifies at most once per
+// cooldown; posts that match in the meantime are gathered into the next notification.
+type KeywordAlertService struct {
+	db       *repository.DB
+	notifier *NotificationService
+	cooldown time.Duration
+}
+
+// Creates new keyword alert service
+func NewKeywordAlertService(db *repository.DB, notifier *NotificationService, cooldown time.Duration) *KeywordAlertService {
+	return &KeywordAlertService{
+		db:       db,
+		notifier: notifier,
+		cooldown: cooldown,
+	}
+}
+
+// Get a user's keyword alerts
+func (s *KeywordAlertService) List(userId int64) ([]model.KeywordAlert, error) {
+	return s.db.GetKeywordAlerts(userId)
+}
+
+// Registers a keyword for a user (case-insensitive; only posts made from now on match it)
+func (s *KeywordAlertService) Create(userId int64, keyword string) (*model.KeywordAlert, error) {
+	keyword, err := normalizeKeyword(keyword)
+	if err != nil {
+		return nil, err
+	}
+
+	alert := &model.KeywordAlert{
+		UserId:    userId,
+		Keyword:   keyword,
+		CreatedAt: time.Now(),
+	}
+	created, err := s.db.CreateKeywordAlert(alert, maxKeywordAlerts)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, fmt.Errorf("%w: you can have up to %d keyword alerts", ErrInvalidInput, maxKeywordAlerts)
+	}
+
+	return alert, nil
+}
+
+// Removes one of a user's keyword alerts
+func (s *KeywordAlertService) Delete(userId, alertId int64) error {
+	return s.db.DeleteKeywordAlert(userId, alertId)
+}
+
+// Scheduled job: checks the posts made since each due alert last ran and notifies its owner about matches.
+// Alerts are claimed before notifying, so two instances never send the same matches.
+func (s *KeywordAlertService) MatchNewPosts(ctx context.Context) error {
+	upTo, err := s.db.GetLatestPostId()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	alerts, err := s.db.GetDueKeywordAlerts(upTo, now.Add(-s.cooldown))
+	if err != nil {
+		return err
+	}
+
+	notified := 0
+	for _, alert := range alerts {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		matches, err := s.db.FindKeywordMatches(alert, upTo, keywordPattern(alert.Keyword))
+		if err != nil {
+			return err
+		}
+
+		var notifiedAt *time.Time
+		if matches.Count > 0 {
+			notifiedAt = &now
+		}
+		claimed, err := s.db.ClaimKeywordMatches(alert, upTo, notifiedAt)
+		if err != nil {
+			return err
+		}
+		if !claimed || matches.Count == 0 {
+			continue
+		}
+
+		message := fmt.Sprintf("\"%s\" was mentioned in \"%s\"", alert.Keyword, matches.Title)
+		if matches.Count > 1 {
+			message = fmt.Sprintf("\"%s\" was mentioned in %d new posts, most recently \"%s\"", alert.Keyword, matches.Count, matches.Title)
+		}
+		link := fmt.Sprintf("/api/posts/%d", matches.PostId)
+		s.notifier.Notify(alert.UserId, model.NotifyKeyword, "New posts mention \""+alert.Keyword+"\"", message, link)
+		notified++
+	}
+
+	if notified > 0 {
+		log.Info().Int("alerts", notified).Int64("up_to_post", upTo).Msg("Keyword alerts sent")
+	}
+	return nil
+}
+
+// Lowercases a keyword, collapses its spaces and checks its length and characters
+// (letters, digits, spaces and - _ . + #, starting with a letter or digit)
+func normalizeKeyword(keyword string) (string, error) {
+	keyword = strings.Join(strings.Fields(strings.ToLower(keyword)), " ")
+
+	if len(keyword) < minKeywordLength || len(keyword) > maxKeywordLength {
+		return "", fmt.Errorf("%w: keyword must be %d-%d characters", ErrInvalidInput, minKeywordLength, maxKeywordLength)
+	}
+	for i, c := range keyword {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) {
+			continue
+		}
+		if i == 0 || !strings.ContainsRune(keywordExtraSymbols, c) {
+			return "", fmt.Errorf("%w: keyword may only contain letters, digits, spaces and - _ . + # and must start with a letter or digit", ErrInvalidInput)
+		}
+	}
+
+	return keyword, nil
+}
+
+// Builds the PostgreSQL regular expression matching a keyword as a whole word or phrase
+// (so "go" matches "Go 1.22" but not "good"). Spaces match any run of whitespace.
+func keywordPattern(keyword string) string {
+	words := strings.Fields(keyword)
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+
+	return "(^|" + keywordBoundary + ")" + strings.Join(words, `\s+`) + "($|" + keywordBoundary + ")"
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Number of users shown on the leaderboard
+const LeaderboardSize = 25
+
+// How far back each leaderboard period looks (zero means all time)
+var leaderboardPeriods = map[string]time.Duration{
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"all":   0,
+}
+
+// A cached leaderboard
+type leaderboardCacheEntry struct {
+	entries   []model.LeaderboardEntry
+	expiresAt time.Time
+}
+
+// Computes community leaderboards with a short-lived cache
+type LeaderboardService struct {
+	db       *repository.DB
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]leaderboardCacheEntry
+}
+
+// Creates new leaderboard service
+func NewLeaderboardService(db *repository.DB, cacheTTL time.Duration) *LeaderboardService {
+	return &LeaderboardService{
+		db:       db,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]leaderboardCacheEntry),
+	}
+}
+
+// Get the leaderboard for a period (week, month, all) ranked by karma, posts or comments
+func (s *LeaderboardService) GetLeaderboard(period, rankBy string) ([]model.LeaderboardEntry, error) {
+	lookback, ok := leaderboardPeriods[period]
+	if !ok {
+		return nil, fmt.Errorf("%w: period must be week, month or all", ErrInvalidInput)
+	}
+	if rankBy != "karma" && rankBy != "posts" && rankBy != "comments" {
+		return nil, fmt.Errorf("%w: by must be karma, posts or comments", ErrInvalidInput)
+	}
+
+	key := period + ":" + rankBy
+	now := time.Now()
+
+	// Serve from cache while fresh
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.entries, nil
+	}
+
+	var since time.Time
+	if lookback > 0 {
+		since = now.Add(-lookback)
+	}
+
+	entries, err := s.db.GetLeaderboard(since, rankBy, LeaderboardSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	if entries == nil {
+		entries = []model.LeaderboardEntry{}
+	}
+
+	s.mu.Lock()
+	s.cache[key] = leaderboardCacheEntry{entries: entries, expiresAt: now.Add(s.cacheTTL)}
+	s.mu.Unlock()
+
+	return entries, nil
+}
+
+// Drops every cached leaderboard so the next request recomputes it
+func (s *LeaderboardService) Flush() {
+	s.mu.Lock()
+	s.cache = make(map[string]leaderboardCacheEntry)
+	s.mu.Unlock()
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// How long a username+IP's failed logins are remembered after the last attempt
+const loginFailureMemory = time.Hour
+
+// Slows down repeated failed logins for the same username from the same IP: after the free failures each
+// attempt has to wait twice as long as the one before, up to the maximum delay. Nothing is locked, so the
+// account keeps working from other IPs. Counts are kept in memory, so each instance throttles separately.
+type LoginThrottle struct {
+	freeFailures int
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+
+	mu       sync.Mutex
+	failures map[string]*loginFailures
+}
+
+// Failed attempts for one username+IP
+type loginFailures struct {
+	count        int
+	lastAttempt  time.Time
+	blockedUntil time.Time
+}
+
+// Creates a new login throttle allowing freeFailures failed logins before delays start (0 disables it)
+func NewLoginThrottle(freeFailures int, baseDelay, maxDelay time.Duration) *LoginThrottle {
+	return &LoginThrottle{
+		freeFailures: freeFailures,
+		baseDelay:    baseDelay,
+		maxDelay:     maxDelay,
+		failures:     make(map[string]*loginFailures),
+	}
+}
+
+// Records a login attempt, returning a *LoginThrottledError when the username+IP has to wait first.
+// Attempts count as failures until Succeeded clears them, so parallel guesses can't slip past the delays.
+func (t *LoginThrottle) Attempt(username, ip string, now time.Time) error {
+	if t.freeFailures <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := loginThrottleKey(username, ip)
+	failures, ok := t.failures[key]
+	if !ok {
+		failures = &loginFailures{}
+		t.failures[key] = failures
+	}
+	if now.Before(failures.blockedUntil) {
+		return &LoginThrottledError{RetryAfter: failures.blockedUntil.Sub(now)}
+	}
+
+	failures.count++
+	failures.lastAttempt = now
+	if delay := t.delay(failures.count); delay > 0 {
+		failures.blockedUntil = now.Add(delay)
+		log.Warn().Str("username", username).Str("ip", ip).Int("failures", failures.count).Dur("delay", delay).Msg("Login attempts throttled")
+	}
+
+	return nil
+}
+
+// Forgets the failures of a username+IP after a successful login
+func (t *LoginThrottle) Succeeded(username, ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.failures, loginThrottleKey(username, ip))
+}
+
+// Scheduled job: forgets failures that are no longer delaying anyone and are older than loginFailureMemory
+func (t *LoginThrottle) Sweep(ctx context.Context) error {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, failures := range t.failures {
+		if now.After(failures.blockedUntil) && now.Sub(failures.lastAttempt) >= loginFailureMemory {
+			delete(t.failures, key)
+		}
+	}
+
+	return nil
+}
+
+// How long to wait after the given number of failures: nothing within the free failures, then the base
+// delay doubling with each further failure, capped at the maximum
+func (t *LoginThrottle) delay(failures int) time.Duration {
+	if failures < t.freeFailures {
+		return 0
+	}
+
+	delay := t.baseDelay
+	for i := t.freeFailures; i < failures && delay < t.maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, t.maxDelay)
+}
+
+// Usernames are case-insensitive, so "Alice" and "alice" share their failures
+func loginThrottleKey(username, ip string) string {
+	return strings.ToLower(username) + "|" + ip
+}
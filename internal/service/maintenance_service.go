@@ -0,0 +1,83 @@
+package service
+
+import (
+	"byte-board/internal/jobs"
+	"byte-board/internal/repository"
+	"context"
+	"fmt"
+)
+
+// Kinds of maintenance job admins can queue
+const (
+	JobReindex    = "reindex"
+	JobCacheFlush = "cache-flush"
+)
+
+// Queues admin maintenance work (search reindexing, cache flushes) and reports its progress
+type MaintenanceService struct {
+	db          *repository.DB
+	queue       *jobs.Queue
+	leaderboard *LeaderboardService
+	postLists   *PostListCache
+	wordFilter  *WordFilterService
+}
+
+// Creates new maintenance service
+func NewMaintenanceService(db *repository.DB, queue *jobs.Queue, leaderboard *LeaderboardService, postLists *PostListCache, wordFilter *WordFilterService) *MaintenanceService {
+	return &MaintenanceService{
+		db:          db,
+		queue:       queue,
+		leaderboard: leaderboard,
+		postLists:   postLists,
+		wordFilter:  wordFilter,
+	}
+}
+
+// Queues a rebuild of the post search indexes (one step per index, then a statistics refresh)
+func (s *MaintenanceService) Reindex() (jobs.Status, error) {
+	return s.queue.Enqueue(JobReindex, func(ctx context.Context, progress jobs.ProgressFunc) error {
+		total := len(repository.SearchIndexes) + 1
+		for i, index := range repository.SearchIndexes {
+			progress(i, total, "rebuilding "+index)
+			if err := s.db.RebuildIndex(ctx, index); err != nil {
+				return err
+			}
+		}
+
+		progress(total-1, total, "refreshing statistics")
+		if err := s.db.AnalyzePosts(ctx); err != nil {
+			return err
+		}
+
+		progress(total, total, "done")
+		return nil
+	})
+}
+
+// Queues a flush of the in-memory caches (leaderboards and post lists are dropped, word filters are reloaded)
+func (s *MaintenanceService) FlushCaches() (jobs.Status, error) {
+	return s.queue.Enqueue(JobCacheFlush, func(ctx context.Context, progress jobs.ProgressFunc) error {
+		progress(0, 3, "flushing leaderboards")
+		s.leaderboard.Flush()
+
+		progress(1, 3, "flushing post lists")
+		s.postLists.Flush()
+
+		progress(2, 3, "reloading word filters")
+		if err := s.wordFilter.Reload(); err != nil {
+			return fmt.Errorf("failed to reload word filters: %w", err)
+		}
+
+		progress(3, 3, "done")
+		return nil
+	})
+}
+
+// Gets the status of a queued maintenance job
+func (s *MaintenanceService) JobStatus(jobId int) (jobs.Status, error) {
+	status, err := s.queue.Get(jobId)
+	if err != nil {
+		return jobs.Status{}, fmt.Errorf("%w: %w", repository.ErrNotFound, err)
+	}
+	return status, nil
+}
@@ -0,0 +1,22 @@
+package service
+
+import "byte-board/internal/metrics"
+
+// Business counters exported at GET /api/admin/metrics/counters, so dashboards can follow product
+// health alongside latency. Label values come from fixed sets to keep the series count small.
+var (
+	registrationsTotal = metrics.NewCounter("byteboard_registrations",
+		"Accounts registered")
+	loginsTotal = metrics.NewCounter("byteboard_logins",
+		"Login attempts by result (success, failure, banned or throttled)", "result")
+	postsCreatedTotal = metrics.NewCounter("byteboard_posts_created",
+		"Posts created (including gist imports), by visibility", "visibility")
+	commentsCreatedTotal = metrics.NewCounter("byteboard_comments_created",
+		"Comments created")
+	moderationActionsTotal = metrics.NewCounter("byteboard_moderation_actions",
+		"Moderation actions applied, by action and target type (appeal reversals included)", "action", "target_type")
+	unlinkedAttachmentsDeletedTotal = metrics.NewCounter("byteboard_unlinked_attachments_deleted",
+		"Attachments deleted because no post or comment linked them in time")
+	unlinkedAttachmentBytesReclaimedTotal = metrics.NewCounter("byteboard_unlinked_attachment_bytes_reclaimed",
+		"Bytes of storage quota given back by deleting unlinked attachments")
+)
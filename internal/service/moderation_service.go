@@ -0,0 +1,250 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Longest moderation reason or appeal message accepted
+const maxModerationText = 2000
+
+// Longest suspension accepted (a ban is the way to remove someone for longer)
+const MaxSuspension = 365 * 24 * time.Hour
+
+// The action that undoes each restrictive action (only these can be appealed)
+var reversals = map[string]string{
+	model.ModerationHide: model.ModerationUnhide,
+	model.ModerationLock: model.ModerationUnlock,
+	model.ModerationBan:  model.ModerationUnban,
+	// Overturning a suspension lifts it early
+	model.ModerationSuspend: model.ModerationUnban,
+}
+
+// Handles moderation actions and appeals
+type ModerationService struct {
+	db       *repository.DB
+	notifier *NotificationService
+	// Flushed after actions, since hiding posts or shadowbanning their authors changes the lists
+	postLists *PostListCache
+}
+
+// Creates new moderation service
+func NewModerationService(db *repository.DB, notifier *NotificationService, postLists *PostListCache) *ModerationService {
+	return &ModerationService{
+		db:        db,
+		notifier:  notifier,
+		postLists: postLists,
+	}
+}
+
+// Validates, applies and records a moderation action taken by actor
+func (s *ModerationService) TakeAction(actor *model.User, action *model.ModerationAction) error {
+	if !repository.IsModerationActionSupported(action.TargetType, action.Action) {
+		return fmt.Errorf("%w: can't %s a %s", ErrInvalidInput, action.Action, action.TargetType)
+	}
+
+	action.Reason = strings.TrimSpace(action.Reason)
+	if action.Reason == "" || len(action.Reason) > maxModerationText {
+		return fmt.Errorf("%w: reason is required (up to %d characters)", ErrInvalidInput, maxModerationText)
+	}
+
+	now := time.Now()
+	if repository.IsModerationActionTimed(action.TargetType, action.Action) {
+		if action.ExpiresAt == nil || !action.ExpiresAt.After(now) || action.ExpiresAt.After(now.Add(MaxSuspension)) {
+			return fmt.Errorf("%w: a %s must end in the future, within %d days", ErrInvalidInput, action.Action, int(MaxSuspension.Hours()/24))
+		}
+	} else {
+		action.ExpiresAt = nil
+	}
+
+	if action.ReportId != nil {
+		if _, err := s.db.GetReportById(*action.ReportId); err != nil {
+			return err
+		}
+	}
+
+	// Work out whose content or account is affected
+	switch action.TargetType {
+	case model.ReportTargetPost:
+		post, err := s.db.GetPostById(action.TargetId)
+		if err != nil {
+			return err
+		}
+		action.TargetUserId = post.UserId
+	case model.ReportTargetComment:
+		comment, err := s.db.GetCommentById(action.TargetId)
+		if err != nil {
+			return err
+		}
+		action.TargetUserId = comment.UserId
+	case model.ModerationTargetUser:
+		user, err := s.db.GetUserByID(action.TargetId)
+		if err != nil {
+			return err
+		}
+		if user.ID == actor.ID || user.Role == "admin" {
+			return fmt.Errorf("%w: admin accounts can't be moderated", ErrInvalidInput)
+		}
+		action.TargetUserId = user.ID
+	}
+
+	action.ActorId = &actor.ID
+	action.CreatedAt = now
+	if err := s.db.ApplyModerationAction(action); err != nil {
+		return err
+	}
+	s.postLists.Flush()
+	moderationActionsTotal.Inc(action.Action, action.TargetType)
+
+	log.Info().
+		Int64("action_id", action.ActionId).
+		Str("action", action.Action).
+		Str("target_type", action.TargetType).
+		Int64("target_id", action.TargetId).
+		Str("actor", actor.Username).
+		Msg("Moderation action taken")
+
+	// Shadowbans stay invisible to the user
+	if visibleToTarget(action, action.TargetUserId) {
+		message := fmt.Sprintf("A moderator applied \"%s\" to your %s: %s", action.Action, action.TargetType, action.Reason)
+		if action.ExpiresAt != nil {
+			message += fmt.Sprintf(" (until %s)", action.ExpiresAt.UTC().Format(time.RFC3339))
+		}
+		s.notifier.Notify(action.TargetUserId, model.NotifyModeration, "A moderator acted on your account or content", message, "/api/moderation/me")
+	}
+	return nil
+}
+
+// Files an appeal against a restrictive action taken on the user's content or account
+func (s *ModerationService) CreateAppeal(user *model.User, appeal *model.Appeal) error {
+	action, err := s.db.GetModerationAction(appeal.ActionId)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !visibleToTarget(action, user.ID)) {
+		// Don't reveal actions taken against other users (or shadowbans)
+		return fmt.Errorf("moderation action %w", repository.ErrNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := reversals[action.Action]; !ok {
+		return fmt.Errorf("%w: only hide, lock, ban and suspend actions can be appealed", ErrInvalidInput)
+	}
+
+	appeal.Message = strings.TrimSpace(appeal.Message)
+	if appeal.Message == "" || len(appeal.Message) > maxModerationText {
+		return fmt.Errorf("%w: message is required (up to %d characters)", ErrInvalidInput, maxModerationText)
+	}
+
+	appeal.UserId = user.ID
+	appeal.Status = model.AppealStatusPending
+	appeal.CreatedAt = time.Now()
+	return s.db.CreateAppeal(appeal)
+}
+
+// Records a moderator's decision on an appeal. Overturning it reverses the original action.
+func (s *ModerationService) ResolveAppeal(moderator *model.User, appealId int64, status, note string) (*model.Appeal, error) {
+	if status != model.AppealStatusUpheld && status != model.AppealStatusOverturned {
+		return nil, fmt.Errorf("%w: status must be upheld or overturned", ErrInvalidInput)
+	}
+
+	appeal, err := s.db.GetAppealById(appealId)
+	if err != nil {
+		return nil, err
+	}
+	if appeal.Status != model.AppealStatusPending {
+		return nil, fmt.Errorf("%w: appeal was already %s", ErrInvalidInput, appeal.Status)
+	}
+
+	now := time.Now()
+	appeal.Status = status
+	appeal.ResolvedBy = &moderator.ID
+	appeal.ResolutionNote = strings.TrimSpace(note)
+	appeal.ResolvedAt = &now
+
+	var reversal *model.ModerationAction
+	if status == model.AppealStatusOverturned {
+		action, err := s.db.GetModerationAction(appeal.ActionId)
+		if err != nil {
+			return nil, err
+		}
+
+		reason := fmt.Sprintf("Appeal #%d overturned", appeal.AppealId)
+		if appeal.ResolutionNote != "" {
+			reason += ": " + appeal.ResolutionNote
+		}
+		reversal = &model.ModerationAction{
+			ActorId:      &moderator.ID,
+			Action:       reversals[action.Action],
+			TargetType:   action.TargetType,
+			TargetId:     action.TargetId,
+			TargetUserId: action.TargetUserId,
+			Reason:       reason,
+			ReportId:     action.ReportId,
+			CreatedAt:    now,
+		}
+	}
+
+	if err := s.db.ResolveAppeal(appeal, reversal); err != nil {
+		return nil, err
+	}
+	if reversal != nil {
+		s.postLists.Flush()
+		moderationActionsTotal.Inc(reversal.Action, reversal.TargetType)
+	}
+
+	log.Info().Int64("appeal_id", appeal.AppealId).Str("status", status).Str("moderator", moderator.Username).Msg("Appeal resolved")
+
+	message := fmt.Sprintf("Your appeal #%d was %s", appeal.AppealId, status)
+	if appeal.ResolutionNote != "" {
+		message += ": " + appeal.ResolutionNote
+	}
+	s.notifier.Notify(appeal.UserId, model.NotifyModeration, "Your appeal was decided", message, "/api/appeals")
+	return appeal, nil
+}
+
+// Lifts suspensions that have ended, recording an unban by the system for each (scheduled job)
+func (s *ModerationService) LiftExpiredSuspensions(ctx context.Context) error {
+	userIds, err := s.db.GetExpiredSuspensions(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, userId := range userIds {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		action := &model.ModerationAction{
+			Action:       model.ModerationUnban,
+			TargetType:   model.ModerationTargetUser,
+			TargetId:     userId,
+			TargetUserId: userId,
+			Reason:       "Suspension ended",
+			CreatedAt:    time.Now(),
+		}
+		if err := s.db.ApplyModerationAction(action); err != nil {
+			log.Error().Err(err).Int64("user_id", userId).Msg("Failed to lift suspension")
+			continue
+		}
+		moderationActionsTotal.Inc(action.Action, action.TargetType)
+
+		log.Info().Int64("user_id", userId).Msg("Suspension lifted")
+		s.notifier.Notify(userId, model.NotifyModeration, "Your suspension has ended", "Your account is no longer suspended", "/api/moderation/me")
+	}
+
+	return nil
+}
+
+// Reports whether the user may see an action (it targets them and isn't a shadowban)
+func visibleToTarget(action *model.ModerationAction, userId int64) bool {
+	if action.Action == model.ModerationShadowban || action.Action == model.ModerationUnshadowban {
+		return false
+	}
+	return action.TargetUserId == userId
+}
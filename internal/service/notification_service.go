@@ -0,0 +1,247 @@
+package service
+
+import (
+	"byte-board/internal/mail"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Channels used for each event until the user changes them (also the list of known event types)
+var defaultNotificationSettings = []model.NotificationSetting{
+	{EventType: model.NotifyComment, Email: false, InApp: true},
+	{EventType: model.NotifyModeration, Email: true, InApp: true},
+	{EventType: model.NotifyDigest, Email: true, InApp: false},
+	{EventType: model.NotifyKeyword, Email: false, InApp: true},
+}
+
+// Events that only exist as emails
+var emailOnlyEvents = map[string]bool{
+	model.NotifyDigest: true,
+}
+
+// Delivers notifications in-app and by email according to each user's settings
+type NotificationService struct {
+	db             *repository.DB
+	mailer         mail.Mailer
+	publicURL      string
+	unsubscribeKey []byte
+}
+
+// Creates new notification service (secret signs the unsubscribe links)
+func NewNotificationService(db *repository.DB, mailer mail.Mailer, publicURL, secret string) *NotificationService {
+	return &NotificationService{
+		db:             db,
+		mailer:         mailer,
+		publicURL:      strings.TrimRight(publicURL, "/"),
+		unsubscribeKey: []byte("unsubscribe:" + secret),
+	}
+}
+
+// Get a user's settings for every event type (defaults filled in)
+func (s *NotificationService) GetSettings(userId int64) ([]model.NotificationSetting, error) {
+	saved, err := s.db.GetNotificationSettings(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	byEvent := make(map[string]model.NotificationSetting, len(saved))
+	for _, setting := range saved {
+		byEvent[setting.EventType] = setting
+	}
+
+	settings := make([]model.NotificationSetting, len(defaultNotificationSettings))
+	for i, setting := range defaultNotificationSettings {
+		if custom, ok := byEvent[setting.EventType]; ok {
+			setting = custom
+		}
+		settings[i] = setting
+	}
+
+	return settings, nil
+}
+
+// Get a user's settings for one event type
+func (s *NotificationService) getSetting(userId int64, eventType string) (model.NotificationSetting, error) {
+	settings, err := s.GetSettings(userId)
+	if err != nil {
+		return model.NotificationSetting{}, err
+	}
+
+	for _, setting := range settings {
+		if setting.EventType == eventType {
+			return setting, nil
+		}
+	}
+
+	return model.NotificationSetting{}, fmt.Errorf("unknown notification event %q", eventType)
+}
+
+// Changes the settings for the listed event types and returns the full set
+func (s *NotificationService) UpdateSettings(userId int64, changes []model.NotificationSetting) ([]model.NotificationSetting, error) {
+	for _, change := range changes {
+		if !isNotificationEvent(change.EventType) {
+			return nil, fmt.Errorf("%w: unknown event type %q", ErrInvalidInput, change.EventType)
+		}
+		if emailOnlyEvents[change.EventType] && change.InApp {
+			return nil, fmt.Errorf("%w: %s notifications are only sent by email", ErrInvalidInput, change.EventType)
+		}
+	}
+
+	for _, change := range changes {
+		if err := s.db.SetNotificationSetting(userId, change); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetSettings(userId)
+}
+
+// Notifies a user about an event on the channels they chose. Failures are logged,
+// never returned, since the action that triggered the notification already happened.
+func (s *NotificationService) Notify(userId int64, eventType, subject, message, link string) {
+	setting, err := s.getSetting(userId, eventType)
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", userId).Str("event_type", eventType).Msg("Failed to get notification settings")
+		return
+	}
+
+	if setting.InApp {
+		notification := &model.Notification{
+			UserId:    userId,
+			EventType: eventType,
+			Message:   message,
+			Link:      link,
+			CreatedAt: time.Now(),
+		}
+		if err := s.db.CreateNotification(notification); err != nil {
+			log.Error().Err(err).Int64("user_id", userId).Str("event_type", eventType).Msg("Failed to create notification")
+		}
+	}
+
+	if setting.Email {
+		profile, err := s.db.GetProfileByUserId(userId)
+		if err != nil {
+			log.Error().Err(err).Int64("user_id", userId).Msg("Failed to get profile for notification email")
+			return
+		}
+		if profile.Email == "" {
+			return
+		}
+
+		body := message
+		if link != "" {
+			body += "\n\n" + s.publicURL + link
+		}
+		if _, err := s.sendEmail(userId, profile.Email, eventType, subject, body); err != nil {
+			log.Error().Err(err).Int64("user_id", userId).Str("event_type", eventType).Msg("Failed to send notification email")
+		}
+	}
+}
+
+// Emails a user about an event unless they turned that email off (reports whether it was sent).
+// Every email carries a one-click unsubscribe link for its event type.
+func (s *NotificationService) SendEmail(userId int64, to, eventType, subject, body string) (bool, error) {
+	setting, err := s.getSetting(userId, eventType)
+	if err != nil {
+		return false, err
+	}
+	if !setting.Email {
+		return false, nil
+	}
+
+	return s.sendEmail(userId, to, eventType, subject, body)
+}
+
+// Sends an email with the unsubscribe link and headers
+func (s *NotificationService) sendEmail(userId int64, to, eventType, subject, body string) (bool, error) {
+	unsubscribeURL := s.UnsubscribeURL(userId, eventType)
+	body += "\n\n--\nStop getting these emails: " + unsubscribeURL
+
+	// RFC 8058 one-click unsubscribe
+	headers := map[string]string{
+		"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+		"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+	}
+	if err := s.mailer.SendWithHeaders(to, subject, body, headers); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Builds the signed link that turns off emails for one event type
+func (s *NotificationService) UnsubscribeURL(userId int64, eventType string) string {
+	query := url.Values{}
+	query.Set("user", model.FormatID(userId))
+	query.Set("event", eventType)
+	query.Set("sig", s.unsubscribeSignature(userId, eventType))
+
+	return s.publicURL + "/api/unsubscribe?" + query.Encode()
+}
+
+// Turns off emails for an event type after checking the link's signature
+func (s *NotificationService) Unsubscribe(userId int64, eventType, signature string) error {
+	expected := s.unsubscribeSignature(userId, eventType)
+	if !isNotificationEvent(eventType) || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidToken
+	}
+
+	setting, err := s.getSetting(userId, eventType)
+	if err != nil {
+		return err
+	}
+	setting.Email = false
+
+	if err := s.db.SetNotificationSetting(userId, setting); err != nil {
+		return err
+	}
+
+	log.Info().Int64("user_id", userId).Str("event_type", eventType).Msg("Unsubscribed from emails")
+	return nil
+}
+
+// HMAC of the user and event type, so links can't be forged for other users
+func (s *NotificationService) unsubscribeSignature(userId int64, eventType string) string {
+	mac := hmac.New(sha256.New, s.unsubscribeKey)
+	fmt.Fprintf(mac, "%d:%s", userId, eventType)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Get a user's in-app notifications
+func (s *NotificationService) List(filter model.NotificationFilter) ([]model.Notification, error) {
+	return s.db.ListNotifications(filter)
+}
+
+// Get how many of a user's notifications are unread
+func (s *NotificationService) Unread(userId int64) (model.UnreadNotifications, error) {
+	return s.db.GetUnreadNotifications(userId)
+}
+
+// Mark one of a user's notifications as read
+func (s *NotificationService) MarkRead(userId, notificationId int64) error {
+	return s.db.MarkNotificationRead(userId, notificationId, time.Now())
+}
+
+// Mark all of a user's notifications as read
+func (s *NotificationService) MarkAllRead(userId int64) error {
+	return s.db.MarkAllNotificationsRead(userId, time.Now())
+}
+
+// Reports whether eventType is a known notification event
+func isNotificationEvent(eventType string) bool {
+	for _, setting := range defaultNotificationSettings {
+		if setting.EventType == eventType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Most post lists kept at once (each combination of filters, sort and page is one list)
+const maxPostListCacheEntries = 500
+
+// A cached post list
+type postListEntry struct {
+	posts     []model.Post
+	fetchedAt time.Time
+}
+
+// A query for a post list that's running; everyone asking for the list meanwhile waits on it
+type postListCall struct {
+	done  chan struct{}
+	posts []model.Post
+	err   error
+}
+
+// Caches the post lists anonymous visitors get (the homepage feed) for a short time. An expired list is
+// still served for up to maxStale while one background query refreshes it, and requests for a list that
+// isn't cached share one query, so traffic spikes don't stampede the database. Writes that change what
+// the lists show (new, edited, deleted or moderated posts) flush the cache. Lists are shared between
+// requests and must not be modified.
+type PostListCache struct {
+	db       *repository.DB
+	ttl      time.Duration
+	maxStale time.Duration
+
+	mu       sync.Mutex
+	entries  map[string]postListEntry
+	inflight map[string]*postListCall
+	// Bumped by Flush, so queries that started before it don't store what they read
+	generation uint64
+}
+
+// Creates a new post list cache (a zero ttl turns it off)
+func NewPostListCache(db *repository.DB, ttl, maxStale time.Duration) *PostListCache {
+	return &PostListCache{
+		db:       db,
+		ttl:      ttl,
+		maxStale: maxStale,
+		entries:  make(map[string]postListEntry),
+		inflight: make(map[string]*postListCall),
+	}
+}
+
+// List the posts matching a filter. Anonymous listings without a search come from the cache; signed-in
+// viewers see their own shadowbanned posts and mutes, and search terms would fill the cache with
+// one-off lists, so those always go to the database.
+func (c *PostListCache) ListPosts(filter model.PostFilter) ([]model.Post, error) {
+	if c.ttl <= 0 || filter.ViewerId != 0 || filter.Search != "" {
+		return c.db.ListPosts(filter)
+	}
+
+	key := fmt.Sprintf("%d|%s|%s|%s|%d|%d|%t", filter.UserId, filter.Author, filter.Language, filter.Sort,
+		filter.Limit, filter.Offset, filter.HideMuted)
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if ok {
+		age := time.Since(entry.fetchedAt)
+		if age < c.ttl {
+			return entry.posts, nil
+		}
+		// Serve the stale list while it's refreshed
+		if age < c.ttl+c.maxStale {
+			c.fetch(key, filter)
+			return entry.posts, nil
+		}
+	}
+
+	call := c.fetch(key, filter)
+	<-call.done
+	return call.posts, call.err
+}
+
+// Starts a query for a list, or joins the one already running
+func (c *PostListCache) fetch(key string, filter model.PostFilter) *postListCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if call, ok := c.inflight[key]; ok {
+		return call
+	}
+
+	call := &postListCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	generation := c.generation
+
+	go func() {
+		defer close(call.done)
+
+		call.posts, call.err = c.query(filter)
+		if call.err != nil {
+			log.Error().Err(call.err).Msg("Failed to refresh cached post list")
+		}
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.inflight[key] == call {
+			delete(c.inflight, key)
+		}
+		if call.err == nil && generation == c.generation {
+			c.store(key, call.posts)
+		}
+	}()
+
+	return call
+}
+
+// Runs the query for a list. It runs outside any request, so a panic is turned into an error for the
+// waiting requests instead of taking down the server.
+func (c *PostListCache) query(filter model.PostFilter) (posts []model.Post, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			posts, err = nil, fmt.Errorf("post list query panicked: %v", recovered)
+		}
+	}()
+
+	return c.db.ListPosts(filter)
+}
+
+// Caches a list, making room by dropping lists too stale to serve (callers hold mu). When every cached
+// list is still servable the new one isn't kept.
+func (c *PostListCache) store(key string, posts []model.Post) {
+	now := time.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= maxPostListCacheEntries {
+		for k, entry := range c.entries {
+			if now.Sub(entry.fetchedAt) >= c.ttl+c.maxStale {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxPostListCacheEntries {
+			return
+		}
+	}
+
+	c.entries[key] = postListEntry{posts: posts, fetchedAt: now}
+}
+
+// Drops every cached list so the next request fetches it. Queries already running don't store their
+// results, and later requests don't wait on them.
+func (c *PostListCache) Flush() {
+	c.mu.Lock()
+	c.entries = make(map[string]postListEntry)
+	c.inflight = make(map[string]*postListCall)
+	c.generation++
+	c.mu.Unlock()
+}
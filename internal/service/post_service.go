@@ -0,0 +1,311 @@
+package service
+
+import (
+	"byte-board/internal/language"
+	"byte-board/internal/markdown"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Post summary settings
+const (
+	// Longest excerpt, in characters (longer text is cut at a word boundary and gets an ellipsis)
+	maxExcerptLength = 200
+	// Reading speed for the reading time estimate
+	readingWordsPerMinute = 200
+	// Most tags a post can carry
+	MaxPostTags = 5
+	// Longest board name and description accepted
+	maxBoardName        = 100
+	maxBoardDescription = 500
+)
+
+// Board slugs: lowercase letters, digits and -, starting with a letter or digit
+var boardSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// Handles post business logic
+type PostService struct {
+	db              *repository.DB
+	duplicateWindow time.Duration
+	wordFilter      *WordFilterService
+	quotas          *ContentQuotas
+	// Flushed after every write so anonymous listings don't keep showing the old posts
+	postLists *PostListCache
+}
+
+// Creates new post service
+func NewPostService(db *repository.DB, duplicateWindow time.Duration, wordFilter *WordFilterService, quotas *ContentQuotas, postLists *PostListCache) *PostService {
+	return &PostService{
+		db:              db,
+		duplicateWindow: duplicateWindow,
+		wordFilter:      wordFilter,
+		quotas:          quotas,
+		postLists:       postLists,
+	}
+}
+
+// Creates a post after checking the user's posting quota, screening it with the word filter and checking
+// the user hasn't just posted the same thing
+func (s *PostService) CreatePost(post *model.Post) error {
+	if post.Visibility == "" {
+		post.Visibility = model.VisibilityPublic
+	}
+	if err := validateVisibility(post.Visibility); err != nil {
+		return err
+	}
+	if err := normalizePostTags(post); err != nil {
+		return err
+	}
+	if err := s.quotas.CheckPost(post.UserId); err != nil {
+		return err
+	}
+	if post.Language != "" {
+		post.LanguageSource = model.LanguageDeclared
+	}
+
+	flagged, err := s.wordFilter.Screen(&post.Title, &post.Content)
+	if err != nil {
+		return err
+	}
+	if err := setPostLanguage(post); err != nil {
+		return err
+	}
+	setPostSummary(post)
+
+	// Credit the post to the author's display name (or username when they haven't set one)
+	if post.Author, err = s.db.GetAuthorName(post.UserId); err != nil {
+		return err
+	}
+
+	// Reject near-identical posts from the same user within the window
+	if s.duplicateWindow > 0 {
+		recentPosts, err := s.db.GetRecentPostsByUserId(post.UserId, time.Now().Add(-s.duplicateWindow))
+		if err != nil {
+			return fmt.Errorf("failed to check for duplicate posts: %w", err)
+		}
+
+		fingerprint := ContentFingerprint(post.Title, post.Content)
+		for _, recent := range recentPosts {
+			if ContentFingerprint(recent.Title, recent.Content) == fingerprint {
+				log.Warn().
+					Int64("user_id", post.UserId).
+					Int64("duplicate_of", recent.PostId).
+					Msg("Duplicate post rejected")
+				return ErrDuplicatePost
+			}
+		}
+	}
+
+	// Save to database
+	if err := s.db.CreatePost(post); err != nil {
+		return fmt.Errorf("failed to create post: %w", err)
+	}
+	s.postLists.Flush()
+
+	postsCreatedTotal.Inc(post.Visibility)
+	s.wordFilter.FlagContent(model.ReportTargetPost, post.PostId, flagged)
+	linkAttachments(s.db, post.Content)
+	return nil
+}
+
+// Updates a post after screening the new title and content with the word filter.
+// A detected language is detected again from the new text; a declared one is kept.
+func (s *PostService) UpdatePost(post *model.Post) error {
+	if err := validateVisibility(post.Visibility); err != nil {
+		return err
+	}
+	if err := normalizePostTags(post); err != nil {
+		return err
+	}
+
+	flagged, err := s.wordFilter.Screen(&post.Title, &post.Content)
+	if err != nil {
+		return err
+	}
+	if err := setPostLanguage(post); err != nil {
+		return err
+	}
+	setPostSummary(post)
+
+	if err := s.db.UpdatePost(post); err != nil {
+		return fmt.Errorf("failed to update post: %w", err)
+	}
+	s.postLists.Flush()
+
+	s.wordFilter.FlagContent(model.ReportTargetPost, post.PostId, flagged)
+	linkAttachments(s.db, post.Content)
+	return nil
+}
+
+// Deletes a post
+func (s *PostService) DeletePost(postId int64) error {
+	if err := s.db.DeletePost(postId); err != nil {
+		return err
+	}
+	s.postLists.Flush()
+	return nil
+}
+
+// Gets every board, by name
+func (s *PostService) Boards() ([]model.Board, error) {
+	return s.db.GetBoards()
+}
+
+// Gets a board by its slug
+func (s *PostService) Board(slug string) (*model.Board, error) {
+	return s.db.GetBoardBySlug(slug)
+}
+
+// Creates a board or renames it and replaces its description
+func (s *PostService) SetBoard(slug string, req model.BoardRequest) (*model.Board, error) {
+	if !boardSlugPattern.MatchString(slug) {
+		return nil, fmt.Errorf("%w: board slugs are up to 50 lowercase letters, digits and -", ErrInvalidInput)
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxBoardName {
+		return nil, fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidInput, maxBoardName)
+	}
+	if utf8.RuneCountInString(req.Description) > maxBoardDescription {
+		return nil, fmt.Errorf("%w: description must be %d characters or fewer", ErrInvalidInput, maxBoardDescription)
+	}
+
+	board := &model.Board{Slug: slug, Name: name, Description: req.Description}
+	if err := s.db.SetBoard(board); err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("board", slug).Msg("Board saved")
+	return board, nil
+}
+
+// Deletes a board. Its posts stay, without a board, and its subscriptions go with it.
+func (s *PostService) DeleteBoard(slug string) error {
+	if err := s.db.DeleteBoard(slug); err != nil {
+		return err
+	}
+	s.postLists.Flush()
+
+	log.Info().Str("board", slug).Msg("Board deleted")
+	return nil
+}
+
+// Reports whether the viewer (0 for anonymous requests) is in the audience the post's visibility allows.
+// Hidden posts and shadowbans are checked separately.
+func (s *PostService) CanView(post *model.Post, viewerId int64) (bool, error) {
+	return canViewPost(s.db, post, viewerId)
+}
+
+// Reports whether a post's visibility lets viewerId read it (0 for anonymous viewers)
+func canViewPost(db *repository.DB, post *model.Post, viewerId int64) (bool, error) {
+	if viewerId != 0 && viewerId == post.UserId {
+		return true, nil
+	}
+
+	switch post.Visibility {
+	case model.VisibilityPublic:
+		return true, nil
+	case model.VisibilityMembers:
+		return viewerId != 0, nil
+	case model.VisibilityFollowers:
+		if viewerId == 0 {
+			return false, nil
+		}
+		return db.IsFollowing(viewerId, post.UserId)
+	}
+
+	return false, nil
+}
+
+// Checks a post visibility is one of the known levels
+func validateVisibility(visibility string) error {
+	switch visibility {
+	case model.VisibilityPublic, model.VisibilityMembers, model.VisibilityFollowers, model.VisibilityPrivate:
+		return nil
+	}
+
+	return fmt.Errorf("%w: visibility must be public, members, followers or private", ErrInvalidInput)
+}
+
+// Puts a post's tags in the skill tag form, dropping repeats
+func normalizePostTags(post *model.Post) error {
+	tags := make([]string, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaxPostTags {
+		return fmt.Errorf("%w: at most %d tags allowed", ErrInvalidInput, MaxPostTags)
+	}
+
+	post.Tags = tags
+	return nil
+}
+
+// Normalizes a declared post language, or detects the language from the title and content
+func setPostLanguage(post *model.Post) error {
+	if post.LanguageSource == model.LanguageDeclared {
+		tag, err := language.Normalize(post.Language)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		post.Language = tag
+		return nil
+	}
+
+	post.Language = language.Detect(post.Title + "\n" + post.Content)
+	post.LanguageSource = model.LanguageDetected
+	return nil
+}
+
+// Sets a post's excerpt and reading time from its content, so every client shows the same ones.
+// Code blocks count toward the reading time but are left out of the excerpt.
+func setPostSummary(post *model.Post) {
+	prose, code := markdown.PlainText(post.Content)
+	post.Excerpt = excerpt(prose, maxExcerptLength)
+
+	words := len(strings.Fields(prose)) + len(strings.Fields(code))
+	post.ReadingMinutes = max(1, (words+readingWordsPerMinute-1)/readingWordsPerMinute)
+}
+
+// Collapses whitespace in text and cuts it to at most maxLength characters, at a word boundary when there is one
+func excerpt(text string, maxLength int) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:maxLength-1])
+	if space := strings.LastIndexByte(cut, ' '); space > len(cut)/2 {
+		cut = cut[:space]
+	}
+	return strings.TrimRight(cut, " .,;:!?-") + "…"
+}
+
+// Computes a fingerprint of post content that ignores case and whitespace differences
+func ContentFingerprint(title, content string) string {
+	normalized := normalizeContent(title) + "\n" + normalizeContent(content)
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Lowercases text and collapses all runs of whitespace into single spaces
+func normalizeContent(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
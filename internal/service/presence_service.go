@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Tracks which signed-in users are online. Clients ping while the site is open; a user counts as online until
+// the TTL passes without a ping. Presence is kept in memory, so each instance only knows about the users
+// whose pings it received.
+type PresenceService struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	lastSeen map[int64]time.Time
+}
+
+// Creates new presence service
+func NewPresenceService(ttl time.Duration) *PresenceService {
+	return &PresenceService{
+		ttl:      ttl,
+		lastSeen: make(map[int64]time.Time),
+	}
+}
+
+// Marks a user as online for another TTL
+func (s *PresenceService) Ping(userId int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSeen[userId] = time.Now()
+}
+
+// Reports whether a user pinged within the TTL
+func (s *PresenceService) IsOnline(userId int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.online(userId, time.Now())
+}
+
+// Gets which of the users are online, keyed by user ID (offline users are left out)
+func (s *PresenceService) Online(userIds []int64) map[int64]bool {
+	now := time.Now()
+	online := make(map[int64]bool)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, userId := range userIds {
+		if s.online(userId, now) {
+			online[userId] = true
+		}
+	}
+
+	return online
+}
+
+// Counts the users online right now
+func (s *PresenceService) OnlineCount() int {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for userId := range s.lastSeen {
+		if s.online(userId, now) {
+			count++
+		}
+	}
+	return count
+}
+
+// Scheduled job: forgets users whose TTL has passed so the map doesn't grow with everyone who ever pinged
+func (s *PresenceService) Sweep(ctx context.Context) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for userId := range s.lastSeen {
+		if !s.online(userId, now) {
+			delete(s.lastSeen, userId)
+		}
+	}
+	return nil
+}
+
+// Reports whether a user's last ping is within the TTL of now (callers hold mu)
+func (s *PresenceService) online(userId int64, now time.Time) bool {
+	seen, ok := s.lastSeen[userId]
+	return ok && now.Sub(seen) < s.ttl
+}
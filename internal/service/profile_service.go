@@ -0,0 +1,265 @@
+package service
+
+import (
+	"byte-board/internal/auth"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// Most skills a single profile can list
+	MaxProfileSkills = 20
+	// Longest allowed skill or post tag
+	maxSkillLength = 30
+	// Display name length limits (in characters, after trimming)
+	minDisplayNameLength = 2
+	maxDisplayNameLength = 50
+)
+
+// Handles profile business logic
+type ProfileService struct {
+	db         *repository.DB
+	wordFilter *WordFilterService
+	skills     *SkillService
+}
+
+// Creates new profile service
+func NewProfileService(db *repository.DB, wordFilter *WordFilterService, skills *SkillService) *ProfileService {
+	return &ProfileService{
+		db:         db,
+		wordFilter: wordFilter,
+		skills:     skills,
+	}
+}
+
+// Returns a copy of the service that works through db (e.g. one bound to the request's transaction)
+func (s *ProfileService) WithDB(db *repository.DB) *ProfileService {
+	copy := *s
+	copy.db = db
+	return &copy
+}
+
+// Scores how much of a profile is filled in and lists the items still missing, most worthwhile first
+func (s *ProfileService) Completeness(profile *model.Profile) (*model.ProfileCompleteness, error) {
+	skills, err := s.db.GetSkillsByUser(profile.UserId)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := s.db.GetProjectsByUser(profile.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []struct {
+		name   string
+		filled bool
+	}{
+		{model.ProfileItemDisplayName, profile.DisplayName != ""},
+		{model.ProfileItemSkills, len(skills) > 0},
+		{model.ProfileItemGithubLink, profile.GithubLink != ""},
+		{model.ProfileItemProjects, len(projects) > 0},
+		{model.ProfileItemLocation, profile.City != "" || profile.State != ""},
+	}
+
+	completeness := &model.ProfileCompleteness{Missing: []string{}}
+	filled := 0
+	for _, item := range items {
+		if item.filled {
+			filled++
+		} else {
+			completeness.Missing = append(completeness.Missing, item.name)
+		}
+	}
+	completeness.Score = filled * 100 / len(items)
+
+	return completeness, nil
+}
+
+// Validates and saves a user's display name (empty clears it), returning the name as stored.
+// Existing posts and comments are re-credited to the new name.
+func (s *ProfileService) SetDisplayName(userId int64, displayName string) (string, error) {
+	displayName = strings.Join(strings.Fields(displayName), " ")
+	if displayName != "" {
+		if err := validateDisplayName(displayName); err != nil {
+			return "", err
+		}
+
+		// Any word filter match rejects the name (rewriting it with replacements would be confusing)
+		screened := displayName
+		flagged, err := s.wordFilter.Screen(&screened)
+		if err != nil {
+			return "", err
+		}
+		if screened != displayName || len(flagged) > 0 {
+			return "", fmt.Errorf("%w: display name contains a word that isn't allowed", ErrInvalidInput)
+		}
+
+		// Don't let anyone pose as another member
+		taken, err := s.db.IsNameTaken(userId, displayName)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", ErrDisplayNameTaken
+		}
+	}
+
+	if err := s.db.SetDisplayName(userId, displayName); err != nil {
+		return "", err
+	}
+
+	return displayName, nil
+}
+
+// Checks a trimmed display name's length and characters
+func validateDisplayName(displayName string) error {
+	if length := utf8.RuneCountInString(displayName); length < minDisplayNameLength || length > maxDisplayNameLength {
+		return fmt.Errorf("%w: display name must be %d to %d characters", ErrInvalidInput, minDisplayNameLength, maxDisplayNameLength)
+	}
+
+	// Letters (in any script), digits, spaces and a little punctuation; no control or invisible characters
+	for _, c := range displayName {
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !unicode.Is(unicode.Mn, c) && !strings.ContainsRune(" .-_'", c) {
+			return fmt.Errorf("%w: display name contains invalid characters", ErrInvalidInput)
+		}
+	}
+
+	if auth.IsReservedName(displayName) {
+		return fmt.Errorf("%w: display name is reserved", ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// Validates and normalizes skill tags and applies the skill rules, then replaces the user's skills with them
+func (s *ProfileService) SetSkills(userId int64, skills []string) ([]string, error) {
+	normalized := make([]string, 0, len(skills))
+	seen := make(map[string]bool, len(skills))
+	for _, skill := range skills {
+		tag, err := NormalizeSkill(skill)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	normalized, err := s.skills.Apply(normalized)
+	if err != nil {
+		return nil, err
+	}
+	if len(normalized) > MaxProfileSkills {
+		return nil, fmt.Errorf("%w: at most %d skills allowed", ErrInvalidInput, MaxProfileSkills)
+	}
+
+	if err := s.db.SetUserSkills(userId, normalized); err != nil {
+		return nil, err
+	}
+
+	return s.db.GetSkillsByUser(userId)
+}
+
+// Converts a skill to its canonical tag form ("Node JS" -> "node-js")
+func NormalizeSkill(skill string) (string, error) {
+	return normalizeTag("skill", skill)
+}
+
+// Converts a post tag to the same form as skills, so "Node JS" on a post matches the node-js skill
+func NormalizeTag(tag string) (string, error) {
+	return normalizeTag("tag", tag)
+}
+
+// Lowercases a tag and joins its words with "-", naming it kind in errors
+func normalizeTag(kind, value string) (string, error) {
+	tag := strings.Join(strings.Fields(strings.ToLower(value)), "-")
+	if tag == "" {
+		return "", fmt.Errorf("%w: %s cannot be empty", ErrInvalidInput, kind)
+	}
+	if len(tag) > maxSkillLength {
+		return "", fmt.Errorf("%w: %s %q is longer than %d characters", ErrInvalidInput, kind, tag, maxSkillLength)
+	}
+
+	// Allow the punctuation used in technology names (c++, c#, .net, node.js)
+	for _, c := range tag {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && !strings.ContainsRune("+#.-_", c) {
+			return "", fmt.Errorf("%w: %s %q contains invalid characters", ErrInvalidInput, kind, tag)
+		}
+	}
+
+	return tag, nil
+}
+
+// Validates and saves a new project on the user's profile
+func (s *ProfileService) CreateProject(project *model.Project) error {
+	if err := validateProject(project); err != nil {
+		return err
+	}
+
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = project.CreatedAt
+	if err := s.db.CreateProject(project); err != nil {
+		return fmt.Errorf("failed to create project: %w", err)
+	}
+
+	return nil
+}
+
+// Validates and saves changes to an existing project
+func (s *ProfileService) UpdateProject(project *model.Project) error {
+	if err := validateProject(project); err != nil {
+		return err
+	}
+
+	project.UpdatedAt = time.Now()
+	if err := s.db.UpdateProject(project); err != nil {
+		return fmt.Errorf("failed to update project: %w", err)
+	}
+
+	return nil
+}
+
+// Trims project fields and checks lengths and links
+func validateProject(project *model.Project) error {
+	project.Title = strings.TrimSpace(project.Title)
+	project.Description = strings.TrimSpace(project.Description)
+	project.RepoURL = strings.TrimSpace(project.RepoURL)
+	project.ScreenshotURL = strings.TrimSpace(project.ScreenshotURL)
+
+	if project.Title == "" || len(project.Title) > 100 {
+		return fmt.Errorf("%w: title is required (up to 100 characters)", ErrInvalidInput)
+	}
+	if len(project.Description) > 2000 {
+		return fmt.Errorf("%w: description is longer than 2000 characters", ErrInvalidInput)
+	}
+	if project.RepoURL != "" && !isWebURL(project.RepoURL) {
+		return fmt.Errorf("%w: repo_url must be an http(s) link", ErrInvalidInput)
+	}
+	if project.ScreenshotURL != "" && !isWebURL(project.ScreenshotURL) {
+		return fmt.Errorf("%w: screenshot_url must be an http(s) link", ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// Reports whether link is an absolute http(s) URL of a sane length
+func isWebURL(link string) bool {
+	if len(link) > 500 {
+		return false
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Longest report details accepted
+const maxReportDetails = 1000
+
+// Status changes moderators may make (closed reports can be reopened)
+var reportTransitions = map[string][]string{
+	model.ReportStatusOpen:      {model.ReportStatusReviewing, model.ReportStatusActioned, model.ReportStatusDismissed},
+	model.ReportStatusReviewing: {model.ReportStatusOpen, model.ReportStatusActioned, model.ReportStatusDismissed},
+	model.ReportStatusActioned:  {model.ReportStatusOpen},
+	model.ReportStatusDismissed: {model.ReportStatusOpen},
+}
+
+// Handles content reports and the moderation workflow
+type ReportService struct {
+	db    *repository.DB
+	roles *RoleService
+}
+
+// Creates new report service
+func NewReportService(db *repository.DB, roles *RoleService) *ReportService {
+	return &ReportService{db: db, roles: roles}
+}
+
+// Validates and files a report about a post or comment
+func (s *ReportService) CreateReport(report *model.Report) error {
+	// Make sure the reason is one users can currently pick
+	reason, err := s.db.GetReportReason(report.ReasonCode)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !reason.Active) {
+		return fmt.Errorf("%w: unknown report reason", ErrInvalidInput)
+	}
+	if err != nil {
+		return err
+	}
+
+	report.Details = strings.TrimSpace(report.Details)
+	if len(report.Details) > maxReportDetails {
+		return fmt.Errorf("%w: details are longer than %d characters", ErrInvalidInput, maxReportDetails)
+	}
+
+	// Make sure the reported content exists
+	switch report.TargetType {
+	case model.ReportTargetPost:
+		_, err = s.db.GetPostById(report.TargetId)
+	case model.ReportTargetComment:
+		_, err = s.db.GetCommentById(report.TargetId)
+	default:
+		return fmt.Errorf("%w: target_type must be post or comment", ErrInvalidInput)
+	}
+	if err != nil {
+		return err
+	}
+
+	report.Status = model.ReportStatusOpen
+	report.CreatedAt = time.Now()
+	report.UpdatedAt = report.CreatedAt
+	if err := s.db.CreateReport(report); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return ErrDuplicateReport
+		}
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+
+	log.Info().Int64("report_id", report.ReportId).Str("target_type", report.TargetType).Int64("target_id", report.TargetId).Msg("Report filed")
+	return nil
+}
+
+// Moves a report to a new workflow state, recording the moderator's note (and the moderator, when it's
+// resolved)
+func (s *ReportService) ChangeStatus(moderatorId, reportId int64, status, note string) (*model.Report, error) {
+	report, err := s.db.GetReportById(reportId)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := reportTransitions[status]; !ok {
+		return nil, fmt.Errorf("%w: status must be open, reviewing, actioned or dismissed", ErrInvalidInput)
+	}
+	if !canTransition(report.Status, status) {
+		return nil, fmt.Errorf("%w: can't move a report from %s to %s", ErrInvalidInput, report.Status, status)
+	}
+
+	report.Status = status
+	if note = strings.TrimSpace(note); note != "" {
+		report.ResolutionNote = note
+	}
+	report.UpdatedAt = time.Now()
+	if model.IsReportResolved(status) {
+		report.ResolvedBy = &moderatorId
+		report.ResolvedAt = &report.UpdatedAt
+	} else {
+		report.ResolvedBy = nil
+		report.ResolvedAt = nil
+	}
+	if err := s.db.UpdateReport(report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Assigns a report to a moderator, someone whose role can manage reports (nil unassigns).
+// Assigning an open report starts its review.
+func (s *ReportService) AssignReport(reportId int64, moderatorId *int64) (*model.Report, error) {
+	report, err := s.db.GetReportById(reportId)
+	if err != nil {
+		return nil, err
+	}
+
+	if moderatorId != nil {
+		moderator, err := s.db.GetUserByID(*moderatorId)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && !s.roles.Grants(moderator.Role, model.PermissionReportsManage)) {
+			return nil, fmt.Errorf("%w: reports can only be assigned to moderators", ErrInvalidInput)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if report.Status == model.ReportStatusOpen {
+			report.Status = model.ReportStatusReviewing
+		}
+	}
+
+	report.AssignedTo = moderatorId
+	report.UpdatedAt = time.Now()
+	if err := s.db.UpdateReport(report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Validates and adds a reason to the report taxonomy
+func (s *ReportService) CreateReason(reason *model.ReportReason) error {
+	reason.Code = strings.ToLower(strings.TrimSpace(reason.Code))
+	if reason.Code == "" || len(reason.Code) > 50 {
+		return fmt.Errorf("%w: code is required (up to 50 characters)", ErrInvalidInput)
+	}
+	for _, c := range reason.Code {
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '_' {
+			return fmt.Errorf("%w: code can only contain letters, digits and underscores", ErrInvalidInput)
+		}
+	}
+	if err := validateReasonLabel(reason); err != nil {
+		return err
+	}
+
+	return s.db.CreateReportReason(reason)
+}
+
+// Validates and saves changes to a report reason
+func (s *ReportService) UpdateReason(reason *model.ReportReason) error {
+	if err := validateReasonLabel(reason); err != nil {
+		return err
+	}
+
+	return s.db.UpdateReportReason(reason)
+}
+
+// Trims a reason's label and checks its length
+func validateReasonLabel(reason *model.ReportReason) error {
+	reason.Label = strings.TrimSpace(reason.Label)
+	if reason.Label == "" || len(reason.Label) > 100 {
+		return fmt.Errorf("%w: label is required (up to 100 characters)", ErrInvalidInput)
+	}
+
+	return nil
+}
+
+// Reports whether a report may move from one status to another
+func canTransition(from, to string) bool {
+	for _, allowed := range reportTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
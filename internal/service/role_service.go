@@ -0,0 +1,155 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Longest role description accepted
+const maxRoleDescription = 255
+
+// Role names: lowercase letters, digits, - and _, starting with a letter
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+// Manages roles and the permissions they grant, and answers permission checks.
+// Permissions are cached in memory and reloaded after every change (and by a scheduled job).
+type RoleService struct {
+	db *repository.DB
+
+	mu          sync.RWMutex
+	permissions map[string][]string
+}
+
+// Creates new role service and loads the current permissions
+func NewRoleService(db *repository.DB) (*RoleService, error) {
+	s := &RoleService{db: db}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reloads every role's permissions from the database
+func (s *RoleService) Reload() error {
+	roles, err := s.db.GetRoles()
+	if err != nil {
+		return err
+	}
+
+	permissions := make(map[string][]string, len(roles))
+	for _, role := range roles {
+		permissions[role.Name] = role.Permissions
+	}
+
+	s.mu.Lock()
+	s.permissions = permissions
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Scheduled job: picks up role changes made through other instances
+func (s *RoleService) ReloadJob(ctx context.Context) error {
+	return s.Reload()
+}
+
+// Gets the permissions a role grants (none for unknown roles)
+func (s *RoleService) Permissions(role string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.permissions[role]
+}
+
+// Reports whether a role grants a permission
+func (s *RoleService) Grants(role, permission string) bool {
+	return model.HasPermission(s.Permissions(role), permission)
+}
+
+// Gets every role with its permissions
+func (s *RoleService) List() ([]model.Role, error) {
+	return s.db.GetRoles()
+}
+
+// Creates a role or replaces its description and permissions, then reloads the cache.
+// The built-in admin role always grants every permission, so it can't be changed.
+func (s *RoleService) Set(name string, req model.RoleRequest) (*model.Role, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("%w: role names are up to 50 lowercase letters, digits, - and _, starting with a letter", ErrInvalidInput)
+	}
+	if name == model.RoleAdmin {
+		return nil, fmt.Errorf("%w: the admin role can't be changed", ErrInvalidInput)
+	}
+	if len([]rune(req.Description)) > maxRoleDescription {
+		return nil, fmt.Errorf("%w: description must be %d characters or fewer", ErrInvalidInput, maxRoleDescription)
+	}
+
+	permissions := []string{}
+	for _, permission := range req.Permissions {
+		if !slices.Contains(model.Permissions, permission) {
+			return nil, fmt.Errorf("%w: unknown permission %q", ErrInvalidInput, permission)
+		}
+		if !slices.Contains(permissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+	slices.Sort(permissions)
+
+	role := &model.Role{
+		Name:        name,
+		Description: req.Description,
+		Permissions: permissions,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.db.SetRole(role); err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("role", name).Strs("permissions", permissions).Msg("Role saved")
+	return role, s.Reload()
+}
+
+// Deletes a role nobody holds, then reloads the cache (the built-in roles can't be deleted)
+func (s *RoleService) Delete(name string) error {
+	if name == model.RoleUser || name == model.RoleAdmin {
+		return fmt.Errorf("%w: built-in roles can't be deleted", ErrInvalidInput)
+	}
+
+	if err := s.db.DeleteRole(name); err != nil {
+		return err
+	}
+
+	log.Info().Str("role", name).Msg("Role deleted")
+	return s.Reload()
+}
+
+// Gives a user a role. The change applies to their next request, without logging in again.
+// Admins can't change their own role, so the last admin can't lock everyone out by accident.
+func (s *RoleService) SetUserRole(actorId, userId int64, role string) (*model.User, error) {
+	if userId == actorId {
+		return nil, fmt.Errorf("%w: you can't change your own role", ErrInvalidInput)
+	}
+
+	exists, err := s.db.RoleExists(role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidInput, role)
+	}
+
+	if err := s.db.SetUserRole(userId, role); err != nil {
+		return nil, err
+	}
+
+	log.Info().Int64("user_id", userId).Int64("actor_id", actorId).Str("role", role).Msg("User role changed")
+	return s.db.GetUserByID(userId)
+}
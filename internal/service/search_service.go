@@ -0,0 +1,106 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Longest query the search box typeahead accepts
+const maxSuggestQuery = 50
+
+// Quick results for the global search box: posts by title, skill tags and users, all matched by prefix
+type SearchService struct {
+	db *repository.DB
+	// How long the lookups get before whatever hasn't come back is left out
+	timeout time.Duration
+}
+
+// Creates new search service
+func NewSearchService(db *repository.DB, timeout time.Duration) *SearchService {
+	return &SearchService{
+		db:      db,
+		timeout: timeout,
+	}
+}
+
+// Suggest up to limit posts, tags and users each for what's been typed so far (viewerId 0 for anonymous
+// requests). The three lookups run at once and share the timeout; a group that runs out of time or fails
+// comes back empty and the results are marked partial, so a slow lookup never holds up the search box.
+func (s *SearchService) Suggest(ctx context.Context, q string, viewerId int64, limit int) (*model.SearchSuggestions, error) {
+	q = strings.TrimSpace(q)
+	if q == "" || len(q) > maxSuggestQuery {
+		return nil, fmt.Errorf("%w: q must be 1-%d characters", ErrInvalidInput, maxSuggestQuery)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	suggestions := &model.SearchSuggestions{
+		Posts: []model.PostSuggestion{},
+		Tags:  []model.SkillCount{},
+		Users: []model.Author{},
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures []error
+	)
+	lookup := func(group string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					log.Error().Err(err).Str("group", group).Msg("Failed to look up search suggestions")
+				}
+				mu.Lock()
+				failures = append(failures, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Each lookup fills its own group, so they don't need the lock
+	lookup("posts", func() error {
+		posts, err := s.db.SuggestPostTitles(ctx, q, viewerId, limit)
+		if err == nil {
+			suggestions.Posts = posts
+		}
+		return err
+	})
+	lookup("tags", func() error {
+		tags, err := s.db.SuggestSkills(ctx, q, limit)
+		if err == nil {
+			suggestions.Tags = tags
+		}
+		return err
+	})
+	lookup("users", func() error {
+		users, err := s.db.SuggestAuthors(ctx, strings.TrimPrefix(q, "@"), limit)
+		if err == nil {
+			suggestions.Users = users
+		}
+		return err
+	})
+	wg.Wait()
+
+	// Something to show beats an error, unless every lookup broke for a reason other than time
+	if len(failures) == 3 && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, failures[0]
+	}
+	if len(failures) > 0 {
+		log.Warn().Int("failed_groups", len(failures)).Dur("timeout", s.timeout).Msg("Search suggestions are partial")
+		suggestions.Partial = true
+	}
+
+	return suggestions, nil
+}
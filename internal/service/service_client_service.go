@@ -0,0 +1,137 @@
+package service
+
+import (
+	"byte-board/internal/auth"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Longest service client name
+const maxServiceClientName = 100
+
+// Registers service clients and issues their machine tokens (OAuth 2.0 client credentials grant)
+type ServiceClientService struct {
+	db            *repository.DB
+	tokenProvider *auth.TokenProvider
+	tokenTTL      time.Duration
+}
+
+// Creates new service client service (tokens are valid for tokenTTL)
+func NewServiceClientService(db *repository.DB, tokenProvider *auth.TokenProvider, tokenTTL time.Duration) *ServiceClientService {
+	return &ServiceClientService{
+		db:            db,
+		tokenProvider: tokenProvider,
+		tokenTTL:      tokenTTL,
+	}
+}
+
+// Registers a client with the given scopes, returning it with its secret (which isn't stored and can't be shown again)
+func (s *ServiceClientService) Create(name string, scopes []string, createdBy int64) (*model.ServiceClient, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxServiceClientName {
+		return nil, "", fmt.Errorf("%w: name is required (up to %d characters)", ErrInvalidInput, maxServiceClientName)
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidInput)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(model.ServiceScopes, scope) {
+			return nil, "", fmt.Errorf("%w: unknown scope %q (must be one of %s)", ErrInvalidInput, scope, strings.Join(model.ServiceScopes, ", "))
+		}
+	}
+
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, "", fmt.Errorf("failed to generate client ID: %w", err)
+	}
+	secret, secretHash, err := generateToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate client secret: %w", err)
+	}
+
+	client := &model.ServiceClient{
+		ClientId:   "svc_" + hex.EncodeToString(idBytes),
+		Name:       name,
+		SecretHash: secretHash,
+		Scopes:     slices.Compact(slices.Sorted(slices.Values(scopes))),
+		CreatedBy:  &createdBy,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.db.CreateServiceClient(client); err != nil {
+		return nil, "", err
+	}
+
+	log.Info().Str("client_id", client.ClientId).Strs("scopes", client.Scopes).Msg("Service client registered")
+	return client, secret, nil
+}
+
+// Gets registered service clients, newest first
+func (s *ServiceClientService) List(limit, offset int) ([]model.ServiceClient, error) {
+	return s.db.ListServiceClients(limit, offset)
+}
+
+// Revokes a client; its tokens stop working straight away
+func (s *ServiceClientService) Revoke(clientId string) error {
+	return s.db.RevokeServiceClient(clientId, time.Now())
+}
+
+// Checks a client's credentials and issues a token for the requested space-separated scopes
+// (all of the client's scopes when empty). Returns the token, its lifetime and the granted scopes.
+func (s *ServiceClientService) IssueToken(clientId, secret, scope string) (string, time.Duration, []string, error) {
+	client, err := s.db.GetServiceClient(clientId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", 0, nil, ErrInvalidClient
+	}
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if client.RevokedAt != nil || subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) != 1 {
+		return "", 0, nil, ErrInvalidClient
+	}
+
+	granted := client.Scopes
+	if requested := strings.Fields(scope); len(requested) > 0 {
+		for _, scope := range requested {
+			if !slices.Contains(client.Scopes, scope) {
+				return "", 0, nil, fmt.Errorf("%w: %q is not granted to this client", ErrInvalidScope, scope)
+			}
+		}
+		granted = requested
+	}
+
+	token, err := s.tokenProvider.CreateClientToken(client.ClientId, granted, s.tokenTTL)
+	if err != nil {
+		return "", 0, nil, err
+	}
+
+	// Usage tracking only; never fail the grant over it
+	if err := s.db.TouchServiceClient(client.ClientId, time.Now()); err != nil {
+		log.Error().Err(err).Str("client_id", client.ClientId).Msg("Failed to record service client use")
+	}
+
+	log.Info().Str("client_id", client.ClientId).Strs("scopes", granted).Msg("Service client token issued")
+	return token, s.tokenTTL, granted, nil
+}
+
+// Reports whether a client exists and hasn't been revoked (checked on every machine-token request)
+func (s *ServiceClientService) IsActive(clientId string) (bool, error) {
+	client, err := s.db.GetServiceClient(clientId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return client.RevokedAt == nil, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Session limits
+const (
+	maxSessionUserAgent = 255
+	// Requests closer together than this don't update last_seen_at again
+	sessionTouchInterval = time.Minute
+)
+
+// Lists and revokes the sessions (signed-in devices) users have, and checks the session of every
+// request made with a JWT
+type SessionService struct {
+	db *repository.DB
+}
+
+// Creates new session service
+func NewSessionService(db *repository.DB) *SessionService {
+	return &SessionService{db: db}
+}
+
+// Gets a user's live sessions, most recently used first, marking currentId (0 when the request has no session)
+func (s *SessionService) List(userId, currentId int64) ([]model.Session, error) {
+	sessions, err := s.db.GetActiveSessions(userId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionId == currentId
+	}
+	return sessions, nil
+}
+
+// Revokes one of a user's sessions: its JWTs are rejected straight away and its refresh token stops working
+func (s *SessionService) Revoke(userId, sessionId int64) error {
+	if err := s.db.RevokeSession(userId, sessionId, time.Now()); err != nil {
+		return err
+	}
+
+	log.Info().Int64("user_id", userId).Int64("session_id", sessionId).Msg("Session revoked")
+	return nil
+}
+
+// Reports whether a JWT's session still belongs to its user and hasn't been revoked or expired
+// (checked on every request made with one)
+func (s *SessionService) IsActive(sessionId, userId int64) (bool, error) {
+	session, err := s.db.GetSession(sessionId)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	if session.UserId != userId || session.RevokedAt != nil || !now.Before(session.ExpiresAt) {
+		return false, nil
+	}
+
+	// Activity tracking only; never fail the request over it
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.db.TouchSession(sessionId, now); err != nil {
+			log.Error().Err(err).Int64("session_id", sessionId).Msg("Failed to record session use")
+		}
+	}
+
+	return true, nil
+}
+
+// Starts describing a new session for the device logging in (recorded when its tokens are issued)
+func newSession(tokenVersion int, ip, userAgent string) *model.Session {
+	if runes := []rune(userAgent); len(runes) > maxSessionUserAgent {
+		userAgent = string(runes[:maxSessionUserAgent])
+	}
+
+	return &model.Session{
+		TokenVersion: tokenVersion,
+		IP:           ip,
+		UserAgent:    userAgent,
+	}
+}
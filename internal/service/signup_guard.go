@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bufio"
+	"byte-board/internal/captcha"
+	"byte-board/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// How far back signup limits look
+const signupWindow = 24 * time.Hour
+
+// Decides whether an email domain is a disposable/throwaway provider
+type DisposableDomainList interface {
+	IsDisposable(domain string) bool
+}
+
+// A fixed set of disposable domains (subdomains of a listed domain match too)
+type DomainSet map[string]struct{}
+
+// Small built-in list used when no domain list file is configured
+var DefaultDisposableDomains = NewDomainSet(
+	"mailinator.com", "guerrillamail.com", "guerrillamail.net", "sharklasers.com",
+	"10minutemail.com", "temp-mail.org", "tempmail.com", "yopmail.com",
+	"trashmail.com", "getnada.com", "dispostable.com", "maildrop.cc",
+	"throwawaymail.com", "fakeinbox.com", "mailnesia.com", "mintemail.com",
+)
+
+// Builds a domain set from a list of domains
+func NewDomainSet(domains ...string) DomainSet {
+	set := make(DomainSet, len(domains))
+	for _, domain := range domains {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			set[domain] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+// Loads a domain set from a file with one domain per line (# starts a comment)
+func LoadDomainSet(path string) (DomainSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open domain list %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read domain list %s: %w", path, err)
+	}
+
+	return NewDomainSet(domains...), nil
+}
+
+// Reports whether the domain or any parent domain is in the set
+func (s DomainSet) IsDisposable(domain string) bool {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	for domain != "" {
+		if _, ok := s[domain]; ok {
+			return true
+		}
+		_, domain, _ = strings.Cut(domain, ".")
+	}
+
+	return false
+}
+
+// Signup limits per day (0 disables a limit)
+type SignupLimits struct {
+	PerIP     int
+	PerSubnet int
+	PerEmail  int
+	// Reject addresses on the disposable domain list
+	BlockDisposable bool
+}
+
+// Slows down mass account creation with a CAPTCHA and by limiting signups per IP, subnet and email
+type SignupGuard struct {
+	db         *repository.DB
+	limits     SignupLimits
+	disposable DisposableDomainList
+	// nil when signups don't need a CAPTCHA
+	captcha captcha.Verifier
+}
+
+// Creates new signup guard (verifier may be nil to skip the CAPTCHA)
+func NewSignupGuard(db *repository.DB, limits SignupLimits, disposable DisposableDomainList, verifier captcha.Verifier) *SignupGuard {
+	return &SignupGuard{
+		db:         db,
+		limits:     limits,
+		disposable: disposable,
+		captcha:    verifier,
+	}
+}
+
+// Checks whether a signup from this IP with this email (may be empty) is allowed. When a CAPTCHA is
+// required, captchaResponse must be a valid response to the challenge, checked before anything is counted.
+func (g *SignupGuard) Check(ctx context.Context, ip, email, captchaResponse string) error {
+	if email != "" && g.limits.BlockDisposable {
+		_, domain, _ := strings.Cut(email, "@")
+		if g.disposable.IsDisposable(domain) {
+			return fmt.Errorf("%w: disposable email addresses can't be used to register", ErrInvalidInput)
+		}
+	}
+
+	if g.captcha != nil {
+		if err := g.captcha.Verify(ctx, captchaResponse, ip); err != nil {
+			if errors.Is(err, captcha.ErrFailed) {
+				log.Warn().Err(err).Str("ip", ip).Msg("Signup failed the CAPTCHA")
+				return fmt.Errorf("%w: %v", ErrCaptchaFailed, err)
+			}
+			log.Error().Err(err).Msg("Failed to verify signup CAPTCHA")
+			return fmt.Errorf("%w: %v", ErrCaptchaUnavailable, err)
+		}
+	}
+
+	since := time.Now().Add(-signupWindow)
+	checks := []struct {
+		name  string
+		limit int
+		count func() (int, error)
+	}{
+		{"ip", g.limits.PerIP, func() (int, error) { return g.db.CountSignupsByIP(ip, since) }},
+		{"subnet", g.limits.PerSubnet, func() (int, error) { return g.db.CountSignupsBySubnet(signupSubnet(ip), since) }},
+		{"email", g.limits.PerEmail, func() (int, error) { return g.db.CountSignupsByEmail(normalizeSignupEmail(email), since) }},
+	}
+
+	for _, check := range checks {
+		if check.limit <= 0 || (check.name == "email" && email == "") {
+			continue
+		}
+
+		count, err := check.count()
+		if err != nil {
+			return fmt.Errorf("failed to count signups: %w", err)
+		}
+		if count >= check.limit {
+			log.Warn().Str("limit", check.name).Str("ip", ip).Int("count", count).Msg("Signup throttled")
+			return ErrTooManySignups
+		}
+	}
+
+	return nil
+}
+
+// Records a successful signup so it counts toward the limits
+func (g *SignupGuard) Record(userId int64, ip, email string) {
+	err := g.db.RecordSignup(userId, ip, signupSubnet(ip), normalizeSignupEmail(email), time.Now())
+	if err != nil {
+		// The account already exists, so this only weakens throttling
+		log.Error().Err(err).Int64("user_id", userId).Msg("Failed to record signup")
+	}
+}
+
+// Groups IPs into the block one network typically controls (/24 for IPv4, /64 for IPv6)
+func signupSubnet(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+
+	return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(64, 128)), Mask: net.CIDRMask(64, 128)}).String()
+}
+
+// Lowercases an address and drops any +tag so aliases of one inbox count together
+func normalizeSignupEmail(email string) string {
+	local, domain, ok := strings.Cut(strings.ToLower(email), "@")
+	if !ok {
+		return local
+	}
+	local, _, _ = strings.Cut(local, "+")
+
+	return local + "@" + domain
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"byte-board/internal/jobs"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Kind of the job that migrates existing profiles onto the skill rules
+const JobSkillMigration = "skill-migration"
+
+// Moderates skill tags: admins define synonyms ("golang" -> "go"), merge tags and blocklist them. The rules
+// apply whenever skills are saved, and a background job migrates the profiles that already list the tags.
+type SkillService struct {
+	db    *repository.DB
+	queue *jobs.Queue
+}
+
+// Creates new skill service
+func NewSkillService(db *repository.DB, queue *jobs.Queue) *SkillService {
+	return &SkillService{
+		db:    db,
+		queue: queue,
+	}
+}
+
+// Gets every skill rule
+func (s *SkillService) Rules() ([]model.SkillRule, error) {
+	return s.db.GetSkillRules()
+}
+
+// Sets the rule for a tag (skill is the canonical skill for synonyms) and queues the migration of existing profiles
+func (s *SkillService) SetRule(actorId int64, name, action, skill string) (*model.SkillRule, jobs.Status, error) {
+	switch action {
+	case model.SkillRuleSynonym, model.SkillRuleBlock:
+	default:
+		return nil, jobs.Status{}, fmt.Errorf("%w: action must be synonym or block", ErrInvalidInput)
+	}
+
+	rules, err := s.newRules(actorId, []string{name}, action, skill)
+	if err != nil {
+		return nil, jobs.Status{}, err
+	}
+
+	status, err := s.save(rules)
+	if err != nil {
+		return nil, jobs.Status{}, err
+	}
+	return &rules[0], status, nil
+}
+
+// Merges tags into another: each becomes a synonym of it, and existing profiles are migrated in the background
+func (s *SkillService) Merge(actorId int64, from []string, into string) ([]model.SkillRule, jobs.Status, error) {
+	if len(from) == 0 {
+		return nil, jobs.Status{}, fmt.Errorf("%w: from must list at least one skill", ErrInvalidInput)
+	}
+
+	rules, err := s.newRules(actorId, from, model.SkillRuleSynonym, into)
+	if err != nil {
+		return nil, jobs.Status{}, err
+	}
+
+	status, err := s.save(rules)
+	if err != nil {
+		return nil, jobs.Status{}, err
+	}
+	return rules, status, nil
+}
+
+// Removes the rule for a tag. Profiles that were already migrated keep their canonical skill.
+func (s *SkillService) DeleteRule(name string) error {
+	tag, err := NormalizeSkill(name)
+	if err != nil {
+		return err
+	}
+
+	return s.db.DeleteSkillRule(tag)
+}
+
+// Applies the rules to skills being saved: synonyms become their canonical skill (duplicates are dropped)
+// and blocked tags are rejected. The skills must already be normalized.
+func (s *SkillService) Apply(skills []string) ([]string, error) {
+	rules, err := s.ruleMap()
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make([]string, 0, len(skills))
+	seen := make(map[string]bool, len(skills))
+	for _, skill := range skills {
+		if rule, ok := rules[skill]; ok {
+			if rule.Action == model.SkillRuleBlock {
+				return nil, fmt.Errorf("%w: skill %q isn't allowed", ErrInvalidInput, skill)
+			}
+			skill = rule.Skill
+		}
+
+		if seen[skill] {
+			continue
+		}
+		seen[skill] = true
+		applied = append(applied, skill)
+	}
+
+	return applied, nil
+}
+
+// Gets the canonical form of a normalized skill (the skill itself unless it's a synonym)
+func (s *SkillService) Canonical(skill string) (string, error) {
+	rules, err := s.ruleMap()
+	if err != nil {
+		return "", err
+	}
+
+	if rule, ok := rules[skill]; ok && rule.Action == model.SkillRuleSynonym {
+		return rule.Skill, nil
+	}
+	return skill, nil
+}
+
+// Queues the job that migrates existing profiles onto the current rules. While a migration is already
+// queued or running that job is returned instead, so rules saved during a run need another migration.
+func (s *SkillService) Migrate() (jobs.Status, error) {
+	return s.queue.Enqueue(JobSkillMigration, func(ctx context.Context, progress jobs.ProgressFunc) error {
+		progress(0, 1, "migrating profile skills")
+		result, err := s.db.ApplySkillRules(ctx)
+		if err != nil {
+			return err
+		}
+
+		log.Info().Int64("moved", result.Moved).Int64("removed", result.Removed).Msg("Profile skills migrated")
+		progress(1, 1, fmt.Sprintf("%d moved to their canonical skill, %d blocked removed", result.Moved, result.Removed))
+		return nil
+	})
+}
+
+// Validates rules giving the same action and canonical skill to several tags. Rules never chain: a canonical
+// skill can't itself have a rule, and a tag other rules point to can't be given one.
+func (s *SkillService) newRules(actorId int64, names []string, action, skill string) ([]model.SkillRule, error) {
+	existing, err := s.ruleMap()
+	if err != nil {
+		return nil, err
+	}
+	canonical := make(map[string]bool, len(existing))
+	for _, rule := range existing {
+		if rule.Action == model.SkillRuleSynonym {
+			canonical[rule.Skill] = true
+		}
+	}
+
+	if action == model.SkillRuleSynonym {
+		if skill, err = NormalizeSkill(skill); err != nil {
+			return nil, err
+		}
+		if _, ok := existing[skill]; ok {
+			return nil, fmt.Errorf("%w: %q has a rule of its own, so it can't be a canonical skill", ErrInvalidInput, skill)
+		}
+	} else {
+		skill = ""
+	}
+
+	now := time.Now()
+	rules := make([]model.SkillRule, 0, len(names))
+	for _, name := range names {
+		tag, err := NormalizeSkill(name)
+		if err != nil {
+			return nil, err
+		}
+		if tag == skill {
+			return nil, fmt.Errorf("%w: %q can't be a synonym of itself", ErrInvalidInput, tag)
+		}
+		if canonical[tag] {
+			return nil, fmt.Errorf("%w: %q is the canonical skill of other synonyms", ErrInvalidInput, tag)
+		}
+
+		rule := model.SkillRule{Name: tag, Action: action, Skill: skill, CreatedAt: now}
+		if actorId != 0 {
+			rule.CreatedBy = &actorId
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Saves rules and queues the migration (the rules stay saved if the queue is full; the next migration picks them up)
+func (s *SkillService) save(rules []model.SkillRule) (jobs.Status, error) {
+	if err := s.db.SetSkillRules(rules); err != nil {
+		return jobs.Status{}, err
+	}
+
+	return s.Migrate()
+}
+
+// Gets the rules keyed by tag
+func (s *SkillService) ruleMap() (map[string]model.SkillRule, error) {
+	rules, err := s.db.GetSkillRules()
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]model.SkillRule, len(rules))
+	for _, rule := range rules {
+		byName[rule.Name] = rule
+	}
+	return byName, nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Largest snippet body accepted (in bytes)
+const MaxSnippetSize = 100 * 1024
+
+// Languages recognized from file extensions
+var languagesByExtension = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "tsx",
+	".jsx":   "jsx",
+	".java":  "java",
+	".kt":    "kotlin",
+	".rs":    "rust",
+	".rb":    "ruby",
+	".php":   "php",
+	".c":     "c",
+	".h":     "c",
+	".cpp":   "cpp",
+	".cc":    "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".swift": "swift",
+	".sh":    "bash",
+	".bash":  "bash",
+	".sql":   "sql",
+	".html":  "html",
+	".css":   "css",
+	".json":  "json",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".toml":  "toml",
+	".xml":   "xml",
+	".md":    "markdown",
+}
+
+// Languages recognized from well-known filenames
+var languagesByFilename = map[string]string{
+	"dockerfile":  "dockerfile",
+	"makefile":    "makefile",
+	"jenkinsfile": "groovy",
+	"go.mod":      "go-mod",
+}
+
+// Handles code snippet business logic
+type SnippetService struct {
+	db *repository.DB
+}
+
+// Creates new snippet service
+func NewSnippetService(db *repository.DB) *SnippetService {
+	return &SnippetService{
+		db: db,
+	}
+}
+
+// Validates a snippet, fills in its language if missing, and attaches it to a post
+func (s *SnippetService) CreateSnippet(snippet *model.Snippet) error {
+	snippet.Filename = strings.TrimSpace(snippet.Filename)
+	if snippet.Filename == "" || strings.ContainsAny(snippet.Filename, "/\\") || len(snippet.Filename) > 255 {
+		return fmt.Errorf("%w: filename is required and can't contain slashes", ErrInvalidInput)
+	}
+	if snippet.Body == "" {
+		return fmt.Errorf("%w: body is required", ErrInvalidInput)
+	}
+	if len(snippet.Body) > MaxSnippetSize {
+		return fmt.Errorf("%w: body exceeds %d bytes", ErrInvalidInput, MaxSnippetSize)
+	}
+
+	snippet.Language = strings.ToLower(strings.TrimSpace(snippet.Language))
+	if snippet.Language == "" {
+		snippet.Language = DetectLanguage(snippet.Filename, snippet.Body)
+	}
+	if len(snippet.Language) > 50 {
+		return fmt.Errorf("%w: language is too long", ErrInvalidInput)
+	}
+	snippet.CreatedAt = time.Now()
+
+	if err := s.db.CreateSnippet(snippet); err != nil {
+		return fmt.Errorf("failed to create snippet: %w", err)
+	}
+
+	return nil
+}
+
+// Guesses a snippet's language from its filename, falling back to a shebang line
+func DetectLanguage(filename, body string) string {
+	name := strings.ToLower(filename)
+	if language, ok := languagesByFilename[name]; ok {
+		return language
+	}
+	if language, ok := languagesByExtension[path.Ext(name)]; ok {
+		return language
+	}
+
+	// Look at the interpreter in a shebang line
+	if strings.HasPrefix(body, "#!") {
+		firstLine := strings.SplitN(body, "\n", 2)[0]
+		switch {
+		case strings.Contains(firstLine, "python"):
+			return "python"
+		case strings.Contains(firstLine, "node"):
+			return "javascript"
+		case strings.Contains(firstLine, "ruby"):
+			return "ruby"
+		case strings.Contains(firstLine, "sh"):
+			return "bash"
+		}
+	}
+
+	return "text"
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"byte-board/internal/language"
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"byte-board/internal/translate"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Serves machine translations of posts, caching them per target language
+type TranslationService struct {
+	db         *repository.DB
+	translator translate.Translator
+}
+
+// Creates new translation service (translator is nil when no provider is configured)
+func NewTranslationService(db *repository.DB, translator translate.Translator) *TranslationService {
+	return &TranslationService{
+		db:         db,
+		translator: translator,
+	}
+}
+
+// Gets a post in the target language. Translations are made in the target's base language
+// (pt-BR is served as pt) and reused until the post is edited. The bool reports whether the
+// result is a translation; a post already in that language comes back as it is.
+func (s *TranslationService) TranslatePost(ctx context.Context, post *model.Post, target string) (*model.PostTranslation, bool, error) {
+	tag, err := language.Normalize(target)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	target = language.Base(tag)
+	if target == language.Undetermined {
+		return nil, false, fmt.Errorf("%w: lang must name a language", ErrInvalidInput)
+	}
+
+	if language.Base(post.Language) == target {
+		return &model.PostTranslation{
+			PostId:   post.PostId,
+			Language: post.Language,
+			Title:    post.Title,
+			Content:  post.Content,
+		}, false, nil
+	}
+
+	if s.translator == nil {
+		return nil, false, ErrTranslationUnavailable
+	}
+
+	hash := translationSourceHash(post)
+	cached, err := s.db.GetPostTranslation(post.PostId, target)
+	if err == nil && cached.SourceHash == hash {
+		return cached, true, nil
+	}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, false, err
+	}
+
+	source := translate.AutoDetect
+	if post.Language != language.Undetermined {
+		source = language.Base(post.Language)
+	}
+
+	title, err := s.translator.Translate(ctx, post.Title, source, target)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrTranslationFailed, err)
+	}
+	content, err := s.translator.Translate(ctx, post.Content, source, target)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrTranslationFailed, err)
+	}
+
+	translation := &model.PostTranslation{
+		PostId:     post.PostId,
+		Language:   target,
+		Title:      title,
+		Content:    content,
+		SourceHash: hash,
+		CreatedAt:  time.Now(),
+	}
+
+	// A translation that couldn't be cached is still worth returning
+	if err := s.db.SavePostTranslation(translation); err != nil {
+		log.Error().Err(err).Int64("post_id", post.PostId).Str("language", target).Msg("Failed to cache post translation")
+	}
+
+	log.Info().Int64("post_id", post.PostId).Str("source", source).Str("language", target).Msg("Post translated")
+	return translation, true, nil
+}
+
+// Hashes the exact title and content a translation is made from
+func translationSourceHash(post *model.Post) string {
+	sum := sha256.Sum256([]byte(post.Title + "\x00" + post.Content))
+	return hex.EncodeToString(sum[:])
+}
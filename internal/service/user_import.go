@@ -0,0 +1,171 @@
+package service
+
+import (
+	"byte-board/internal/auth"
+	"byte-board/internal/model"
+	"fmt"
+	netmail "net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// Most rows one import request can carry (larger communities are imported in several batches)
+	MaxUserImportBatch = 500
+	// How long the link emailed to an invited account stays valid
+	InviteTokenTTL = 7 * 24 * time.Hour
+	// Column sizes in the users and profiles tables
+	maxImportNameLength  = 50
+	maxImportEmailLength = 200
+)
+
+// Imports a batch of accounts migrated from another community. Rows with a bcrypt password hash keep their
+// password; rows without one are invited (emailed a link to choose a password). Invalid rows and rows that
+// conflict with existing accounts are reported and skipped, and the rest are imported. With dryRun nothing
+// is saved or sent.
+func (s *AuthService) ImportUsers(rows []model.UserImportRow, dryRun bool) (*model.UserImportSummary, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no users to import", ErrInvalidInput)
+	}
+	if len(rows) > MaxUserImportBatch {
+		return nil, fmt.Errorf("%w: at most %d users per import, split larger imports into batches", ErrInvalidInput, MaxUserImportBatch)
+	}
+
+	summary := &model.UserImportSummary{DryRun: dryRun, Results: make([]model.UserImportResult, len(rows))}
+	imports := make([]model.UserImport, 0, len(rows))
+	for i, row := range rows {
+		result := &summary.Results[i]
+		result.Row = i + 1
+		result.Username = auth.NormalizeUsername(row.Username)
+
+		imp, err := newUserImport(row)
+		if err != nil {
+			result.Status = model.ImportInvalid
+			result.Error = err.Error()
+			continue
+		}
+
+		result.Status = model.ImportCreated
+		if imp.User.HashedPassword == auth.NoPassword {
+			result.Status = model.ImportInvited
+		}
+		imp.Result = result
+		imports = append(imports, imp)
+	}
+
+	if len(imports) > 0 {
+		if err := s.db.ImportUsers(imports, time.Now(), dryRun); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, imp := range imports {
+		if dryRun {
+			// The IDs were rolled back with everything else
+			imp.Result.UserId = 0
+		} else if imp.Result.Status == model.ImportInvited {
+			s.invite(imp)
+		}
+	}
+
+	for _, result := range summary.Results {
+		switch result.Status {
+		case model.ImportCreated:
+			summary.Created++
+		case model.ImportInvited:
+			summary.Invited++
+		case model.ImportConflict:
+			summary.Conflicts++
+		case model.ImportInvalid:
+			summary.Invalid++
+		}
+	}
+
+	log.Info().
+		Bool("dry_run", dryRun).
+		Int("created", summary.Created).
+		Int("invited", summary.Invited).
+		Int("conflicts", summary.Conflicts).
+		Int("invalid", summary.Invalid).
+		Msg("User import batch processed")
+	return summary, nil
+}
+
+// Validates an import row and builds the account for it
+func newUserImport(row model.UserImportRow) (model.UserImport, error) {
+	username := auth.NormalizeUsername(row.Username)
+	if err := auth.ValidateUsername(username); err != nil {
+		return model.UserImport{}, err
+	}
+
+	email := strings.TrimSpace(row.Email)
+	if email != "" {
+		address, err := netmail.ParseAddress(email)
+		if err != nil || address.Address != email || len(email) > maxImportEmailLength {
+			return model.UserImport{}, fmt.Errorf("invalid email address")
+		}
+	}
+
+	firstName := strings.TrimSpace(row.FirstName)
+	lastName := strings.TrimSpace(row.LastName)
+	if utf8.RuneCountInString(firstName) > maxImportNameLength || utf8.RuneCountInString(lastName) > maxImportNameLength {
+		return model.UserImport{}, fmt.Errorf("first and last name can be at most %d characters", maxImportNameLength)
+	}
+
+	hashedPassword := strings.TrimSpace(row.PasswordHash)
+	if hashedPassword == "" {
+		if email == "" {
+			return model.UserImport{}, fmt.Errorf("an email address is required to invite an account without a password hash")
+		}
+		hashedPassword = auth.NoPassword
+	} else if err := auth.ValidatePasswordHash(hashedPassword); err != nil {
+		return model.UserImport{}, err
+	}
+
+	return model.UserImport{
+		User: model.User{
+			Username:       username,
+			HashedPassword: hashedPassword,
+			Role:           "user",
+			FirstName:      firstName,
+			LastName:       lastName,
+		},
+		Email: email,
+	}, nil
+}
+
+// Saves an invitation token for an imported account and emails it in the background. The account is already
+// created, so a failure is only noted on the result (an admin can have the user use forgot-password instead).
+func (s *AuthService) invite(imp model.UserImport) {
+	result := imp.Result
+	token, tokenHash, err := generateToken()
+	if err == nil {
+		now := time.Now()
+		reset := &model.PasswordReset{
+			TokenHash: tokenHash,
+			UserId:    result.UserId,
+			ExpiresAt: now.Add(InviteTokenTTL),
+			CreatedAt: now,
+		}
+		_, err = s.db.CreatePasswordReset(reset, maxPasswordResetsPerTTL, PasswordResetTokenTTL)
+	}
+	if err != nil {
+		log.Error().Err(err).Int64("user_id", result.UserId).Msg("Failed to save invitation")
+		result.Error = "account created, but the invitation couldn't be sent; ask the user to reset their password"
+		return
+	}
+
+	go s.sendInvite(model.PasswordResetRecipient{UserId: result.UserId, Username: result.Username, Email: imp.Email}, token)
+}
+
+// Sends the invitation email for an imported account (failures are only logged)
+func (s *AuthService) sendInvite(recipient model.PasswordResetRecipient, token string) {
+	body := fmt.Sprintf("Your community has moved to Byte Board, and an account has been created for you with the username %s.\n\n%s",
+		recipient.Username, s.choosePasswordInstructions(token, fmt.Sprintf("%d days", int(InviteTokenTTL.Hours()/24))))
+	if err := s.mailer.Send(recipient.Email, "Your Byte Board account is ready", body); err != nil {
+		log.Error().Err(err).Int64("user_id", recipient.UserId).Msg("Failed to send invitation email")
+	}
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"byte-board/internal/model"
+	"byte-board/internal/repository"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Longest pattern/replacement accepted for a word filter rule
+const (
+	maxWordFilterPattern     = 255
+	maxWordFilterReplacement = 100
+)
+
+// Used when a replace rule has no replacement of its own
+const defaultWordFilterReplacement = "***"
+
+// A word filter rule with its compiled pattern
+type compiledWordFilter struct {
+	filter model.WordFilter
+	re     *regexp.Regexp
+}
+
+// Screens post and comment text against the admin-managed word filter.
+// Rules are cached in memory and reloaded after every change (and by a scheduled job).
+type WordFilterService struct {
+	db *repository.DB
+
+	mu    sync.RWMutex
+	rules []compiledWordFilter
+}
+
+// Creates new word filter service and loads the current rules
+func NewWordFilterService(db *repository.DB) (*WordFilterService, error) {
+	s := &WordFilterService{db: db}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reloads the rules from the database (rules that no longer compile are skipped)
+func (s *WordFilterService) Reload() error {
+	filters, err := s.db.GetWordFilters()
+	if err != nil {
+		return err
+	}
+
+	rules := make([]compiledWordFilter, 0, len(filters))
+	for _, filter := range filters {
+		re, err := compileWordFilter(filter)
+		if err != nil {
+			log.Warn().Err(err).Int64("filter_id", filter.FilterId).Msg("Skipping word filter that doesn't compile")
+			continue
+		}
+		rules = append(rules, compiledWordFilter{filter: filter, re: re})
+	}
+
+	s.mu.Lock()
+	s.rules = rules
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Scheduled job: picks up rule changes made through other instances
+func (s *WordFilterService) ReloadJob(ctx context.Context) error {
+	return s.Reload()
+}
+
+// Checks texts against the rules, rewriting them in place for replace rules.
+// Returns ErrInvalidInput if a block rule matches, otherwise the flag rules that matched.
+func (s *WordFilterService) Screen(texts ...*string) ([]model.WordFilter, error) {
+	s.mu.RLock()
+	rules := s.rules
+	s.mu.RUnlock()
+
+	// Block rules win over everything else, so check them before rewriting anything
+	for _, rule := range rules {
+		if rule.filter.Action != model.WordFilterBlock {
+			continue
+		}
+		for _, text := range texts {
+			if rule.re.MatchString(*text) {
+				log.Warn().Int64("filter_id", rule.filter.FilterId).Msg("Content blocked by word filter")
+				return nil, fmt.Errorf("%w: content contains a blocked word", ErrInvalidInput)
+			}
+		}
+	}
+
+	var flagged []model.WordFilter
+	for _, rule := range rules {
+		switch rule.filter.Action {
+		case model.WordFilterReplace:
+			for _, text := range texts {
+				*text = rule.re.ReplaceAllLiteralString(*text, rule.filter.Replacement)
+			}
+		case model.WordFilterFlag:
+			for _, text := range texts {
+				if rule.re.MatchString(*text) {
+					flagged = append(flagged, rule.filter)
+					break
+				}
+			}
+		}
+	}
+
+	return flagged, nil
+}
+
+// Files a report on content that matched flag rules so moderators review it
+func (s *WordFilterService) FlagContent(targetType string, targetId int64, flagged []model.WordFilter) {
+	if len(flagged) == 0 {
+		return
+	}
+
+	patterns := make([]string, len(flagged))
+	for i, filter := range flagged {
+		patterns[i] = filter.Pattern
+	}
+
+	report := &model.Report{
+		TargetType: targetType,
+		TargetId:   targetId,
+		ReasonCode: model.ReportReasonWordFilter,
+		Details:    "Matched word filter: " + strings.Join(patterns, ", "),
+		Status:     model.ReportStatusOpen,
+		CreatedAt:  time.Now(),
+	}
+	report.UpdatedAt = report.CreatedAt
+
+	// The content is already saved, so a failed report is logged rather than returned
+	if err := s.db.CreateReport(report); err != nil {
+		log.Error().Err(err).Str("target_type", targetType).Int64("target_id", targetId).Msg("Failed to flag content from word filter")
+		return
+	}
+
+	log.Info().Int64("report_id", report.ReportId).Str("target_type", targetType).Int64("target_id", targetId).Msg("Content flagged by word filter")
+}
+
+// Get all rules
+func (s *WordFilterService) GetFilters() ([]model.WordFilter, error) {
+	return s.db.GetWordFilters()
+}
+
+// Validates and adds a rule, then reloads the cache
+func (s *WordFilterService) CreateFilter(filter *model.WordFilter) error {
+	filter.Pattern = strings.TrimSpace(filter.Pattern)
+	if filter.Pattern == "" {
+		return fmt.Errorf("%w: pattern is required", ErrInvalidInput)
+	}
+	if len(filter.Pattern) > maxWordFilterPattern {
+		return fmt.Errorf("%w: pattern is longer than %d characters", ErrInvalidInput, maxWordFilterPattern)
+	}
+
+	switch filter.Action {
+	case model.WordFilterBlock, model.WordFilterFlag:
+		filter.Replacement = ""
+	case model.WordFilterReplace:
+		if filter.Replacement == "" {
+			filter.Replacement = defaultWordFilterReplacement
+		}
+		if len(filter.Replacement) > maxWordFilterReplacement {
+			return fmt.Errorf("%w: replacement is longer than %d characters", ErrInvalidInput, maxWordFilterReplacement)
+		}
+	default:
+		return fmt.Errorf("%w: action must be block, flag or replace", ErrInvalidInput)
+	}
+
+	if _, err := compileWordFilter(*filter); err != nil {
+		return fmt.Errorf("%w: invalid pattern: %v", ErrInvalidInput, err)
+	}
+
+	filter.CreatedAt = time.Now()
+	if err := s.db.CreateWordFilter(filter); err != nil {
+		return err
+	}
+
+	return s.Reload()
+}
+
+// Removes a rule, then reloads the cache
+func (s *WordFilterService) DeleteFilter(filterId int64) error {
+	if err := s.db.DeleteWordFilter(filterId); err != nil {
+		return err
+	}
+
+	return s.Reload()
+}
+
+// Compiles a rule: plain words match case-insensitively on word boundaries
+func compileWordFilter(filter model.WordFilter) (*regexp.Regexp, error) {
+	if filter.IsRegex {
+		return regexp.Compile(filter.Pattern)
+	}
+
+	return regexp.Compile(`(?i)\b` + regexp.QuoteMeta(filter.Pattern) + `\b`)
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Returned by Open and Delete when nothing is stored under the key
+var ErrNotFound = errors.New("stored file not found")
+
+// Keeps uploaded file contents under opaque keys
+type Store interface {
+	// Writes the reader's contents under key, returning the number of bytes written
+	Put(key string, r io.Reader) (int64, error)
+	Open(key string) (io.ReadCloser, error)
+	// Renames what's stored under from to to, atomically replacing anything already under to
+	Move(from, to string) error
+	Delete(key string) error
+}
+
+// Checks a store can be written, read back and deleted from, using a throwaway file
+func Probe(store Store) error {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	key := "probe-" + hex.EncodeToString(suffix)
+	content := []byte("byte-board storage probe")
+
+	if _, err := store.Put(key, bytes.NewReader(content)); err != nil {
+		return err
+	}
+	defer store.Delete(key)
+
+	file, err := store.Open(key)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	read, err := io.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("failed to read back probe file: %w", err)
+	}
+	if !bytes.Equal(read, content) {
+		return errors.New("probe file read back with different contents")
+	}
+
+	return nil
+}
+
+// Stores files in a local directory, one file per key
+type DiskStore struct {
+	dir string
+}
+
+// Creates a disk store rooted at dir (created if missing)
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory %s: %w", dir, err)
+	}
+
+	return &DiskStore{dir: dir}, nil
+}
+
+// Writes to a temporary file first so readers never see a partial upload
+func (s *DiskStore) Put(key string, r io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return written, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return written, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	return written, nil
+}
+
+func (s *DiskStore) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stored file: %w", err)
+	}
+
+	return file, nil
+}
+
+func (s *DiskStore) Move(from, to string) error {
+	fromPath, err := s.path(from)
+	if err != nil {
+		return err
+	}
+	toPath, err := s.path(to)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(fromPath, toPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to move stored file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *DiskStore) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete stored file: %w", err)
+	}
+
+	return nil
+}
+
+// Maps a key to its file, rejecting keys that could escape the directory
+func (s *DiskStore) path(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+
+	return filepath.Join(s.dir, key), nil
+}
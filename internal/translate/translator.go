@@ -0,0 +1,86 @@
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Translates text between languages
+type Translator interface {
+	// Translate text from the source language into the target (source "auto" asks the provider to detect it)
+	Translate(ctx context.Context, text, source, target string) (string, error)
+}
+
+// Source language value asking the provider to detect the language itself
+const AutoDetect = "auto"
+
+// Translates through a LibreTranslate-compatible HTTP API (POST {url}/translate)
+type HTTPTranslator struct {
+	httpClient *http.Client
+	apiURL     string
+	apiKey     string
+}
+
+// Creates a new HTTP translator (apiKey may be empty for self-hosted instances)
+func NewHTTPTranslator(apiURL, apiKey string) *HTTPTranslator {
+	return &HTTPTranslator{
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		apiURL:     strings.TrimRight(apiURL, "/"),
+		apiKey:     apiKey,
+	}
+}
+
+type translateRequest struct {
+	Q      string `json:"q"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Format string `json:"format"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+type translateResponse struct {
+	TranslatedText string `json:"translatedText"`
+	Error          string `json:"error"`
+}
+
+// Send the text to the translation API
+func (t *HTTPTranslator) Translate(ctx context.Context, text, source, target string) (string, error) {
+	body, err := json.Marshal(translateRequest{
+		Q:      text,
+		Source: source,
+		Target: target,
+		Format: "text",
+		APIKey: t.apiKey,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode translation request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.apiURL+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to build translation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach translation API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var result translateResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode translation response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("translation API returned status %d: %s", resp.StatusCode, result.Error)
+	}
+
+	return result.TranslatedText, nil
+}